@@ -0,0 +1,47 @@
+package telegram
+
+// Ключи текстов кнопок в каталогах i18n. Роутер сопоставляет нажатую кнопку
+// с ключом, поэтому кнопки распознаются на любом поддерживаемом языке
+const (
+	BtnLogin                = "btn.login"
+	BtnRegister             = "btn.register"
+	BtnLogout               = "btn.logout"
+	BtnProfile              = "btn.profile"
+	BtnBalance              = "btn.balance"
+	BtnUsers                = "btn.users"
+	BtnUserManagement       = "btn.user_management"
+	BtnRoles                = "btn.roles"
+	BtnAudit                = "btn.audit"
+	BtnSettings             = "btn.settings"
+	BtnAddUser              = "btn.add_user"
+	BtnDeleteUser           = "btn.delete_user"
+	BtnChangeUserRole       = "btn.change_user_role"
+	BtnBack                 = "btn.back"
+	BtnCancel               = "btn.cancel"
+	BtnPay                  = "btn.pay"
+	BtnConfirmPayment       = "btn.confirm_payment"
+	BtnCancelPayment        = "btn.cancel_payment"
+	BtnBackToPaymentMethods = "btn.back_to_payment_methods"
+	BtnRoster               = "btn.roster"
+	BtnApprove              = "btn.approve"
+	BtnReject               = "btn.reject"
+	BtnCreateRole           = "btn.create_role"
+	BtnDeleteRole           = "btn.delete_role"
+	BtnBackToRoles          = "btn.back_to_roles"
+	BtnNextPage             = "btn.next_page"
+	BtnExportCSV            = "btn.export_csv"
+	BtnExportJSON           = "btn.export_json"
+	BtnApplyImport          = "btn.apply_import"
+	BtnRSVPGoing            = "btn.rsvp_going"
+	BtnRSVPNotGoing         = "btn.rsvp_not_going"
+	BtnRSVPMaybe            = "btn.rsvp_maybe"
+	BtnRefresh              = "btn.refresh"
+	BtnCheckIn              = "btn.check_in"
+	BtnDutyDone             = "btn.duty_done"
+	BtnDutyAccept           = "btn.duty_accept"
+	BtnDutyDecline          = "btn.duty_decline"
+	BtnAcknowledge          = "btn.acknowledge"
+	BtnTicketReply          = "btn.ticket_reply"
+	BtnTicketTake           = "btn.ticket_take"
+	BtnTicketClose          = "btn.ticket_close"
+)
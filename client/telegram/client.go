@@ -1,32 +1,58 @@
 package telegram
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
 	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/markup"
 )
 
+// downloadClient загружает файлы, отправленные боту
+var downloadClient = &http.Client{Timeout: 30 * time.Second}
+
 // Client представляет клиент для работы с Telegram API
 type Client struct {
-	bot *tgbotapi.BotAPI
+	bot           *tgbotapi.BotAPI
+	format        markup.Mode   // Разметка форматированных сообщений
+	pollTimeout   time.Duration // Таймаут long polling
+	messagesLimit int           // Максимальное число обновлений в одном ответе
+	logger        *slog.Logger
 }
 
-func NewClient(token string, pollTimeout time.Duration, messagesLimit int) (*Client, error) {
+// NewClient создает новый экземпляр Client
+func NewClient(token string, pollTimeout time.Duration, messagesLimit int, format markup.Mode, logger *slog.Logger) (*Client, error) {
+	// Сообщения библиотеки направляем в общий лог, где из них удаляются токены
+	if err := tgbotapi.SetLogger(slog.NewLogLogger(logger.Handler(), slog.LevelWarn)); err != nil {
+		return nil, fmt.Errorf("failed to set bot logger: %w", err)
+	}
+
 	bot, err := tgbotapi.NewBotAPI(token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot: %w", err)
 	}
 
-	// Включаем режим отладки
+	// Режим отладки библиотеки не включаем: он пишет в лог сырые запросы,
+	// в том числе тексты сообщений с паролями
 	bot.Debug = false
-	log.Printf("Authorized on account %s", bot.Self.UserName)
+	logger.Info("Authorized on account", "account", bot.Self.UserName)
 
 	return &Client{
-		bot: bot,
+		bot:           bot,
+		format:        format,
+		pollTimeout:   pollTimeout,
+		messagesLimit: messagesLimit,
+		logger:        logger,
 	}, nil
 }
 
@@ -55,6 +81,180 @@ func (c *Client) SendMessageWithKeyboard(chatID int64, text string, keyboard int
 	return err
 }
 
+// SendText отправляет форматированное сообщение
+func (c *Client) SendText(chatID int64, text markup.Text) error {
+	return c.SendTextWithKeyboard(chatID, text, nil)
+}
+
+// SendTextWithKeyboard отправляет форматированное сообщение с клавиатурой.
+// Если Telegram не принимает разметку, сообщение отправляется обычным текстом
+func (c *Client) SendTextWithKeyboard(chatID int64, text markup.Text, keyboard interface{}) error {
+	_, err := c.SendTextWithKeyboardID(chatID, text, keyboard)
+	return err
+}
+
+// SendTextWithKeyboardID отправляет форматированное сообщение с клавиатурой и возвращает
+// его идентификатор, чтобы позже изменить сообщение
+func (c *Client) SendTextWithKeyboardID(chatID int64, text markup.Text, keyboard interface{}) (int, error) {
+	msg := tgbotapi.NewMessage(chatID, text.Render(c.format))
+	msg.ParseMode = string(c.format)
+	if keyboard != nil {
+		msg.ReplyMarkup = keyboard
+	}
+
+	sent, err := c.bot.Send(msg)
+	if isEntityError(err) {
+		c.logger.Warn("Telegram rejected markup, sending plain text", "format", c.format, "chat_id", chatID, logging.Err(err))
+		msg.Text = text.Render(markup.Plain)
+		msg.ParseMode = ""
+		sent, err = c.bot.Send(msg)
+	}
+	return sent.MessageID, err
+}
+
+// EditText изменяет ранее отправленное сообщение на форматированное и убирает его инлайн-клавиатуру
+func (c *Client) EditText(chatID int64, messageID int, text markup.Text) error {
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text.Render(c.format))
+	edit.ParseMode = string(c.format)
+
+	_, err := c.bot.Send(edit)
+	if isEntityError(err) {
+		c.logger.Warn("Telegram rejected markup, editing as plain text", "format", c.format, "chat_id", chatID, logging.Err(err))
+		edit.Text = text.Render(markup.Plain)
+		edit.ParseMode = ""
+		_, err = c.bot.Send(edit)
+	}
+	if isNotModifiedError(err) {
+		// Сообщение уже содержит этот текст и клавиатуру
+		return nil
+	}
+	return err
+}
+
+// EditTextWithKeyboard изменяет форматированный текст и инлайн-клавиатуру ранее отправленного сообщения
+func (c *Client) EditTextWithKeyboard(chatID int64, messageID int, text markup.Text, keyboard tgbotapi.InlineKeyboardMarkup) error {
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text.Render(c.format), keyboard)
+	edit.ParseMode = string(c.format)
+
+	_, err := c.bot.Send(edit)
+	if isEntityError(err) {
+		c.logger.Warn("Telegram rejected markup, editing as plain text", "format", c.format, "chat_id", chatID, logging.Err(err))
+		edit.Text = text.Render(markup.Plain)
+		edit.ParseMode = ""
+		_, err = c.bot.Send(edit)
+	}
+	if isNotModifiedError(err) {
+		// Сообщение уже содержит этот текст и клавиатуру
+		return nil
+	}
+	return err
+}
+
+// isEntityError проверяет, что Telegram отклонил сообщение из-за ошибки в разметке
+func isEntityError(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == 400 && strings.Contains(apiErr.Message, "can't parse entities")
+}
+
+// isNotModifiedError проверяет, что Telegram отклонил изменение сообщения, потому что оно не изменилось
+func isNotModifiedError(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == 400 && strings.Contains(apiErr.Message, "message is not modified")
+}
+
+// SendDocument отправляет файл с указанным именем и содержимым
+func (c *Client) SendDocument(chatID int64, fileName string, data []byte, caption string) error {
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: fileName, Bytes: data})
+	doc.Caption = caption
+	_, err := c.bot.Send(doc)
+	return err
+}
+
+// DownloadFile загружает файл, отправленный боту. Файлы больше maxSize байт не загружаются
+func (c *Client) DownloadFile(fileID string, maxSize int64) ([]byte, error) {
+	fileURL, err := c.bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	resp, err := downloadClient.Get(fileURL)
+	if err != nil {
+		// Ссылка на файл содержит токен бота, поэтому в ошибку она не попадает
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxSize)
+	}
+	return data, nil
+}
+
+// SendPhoto отправляет изображение с форматированной подписью
+func (c *Client) SendPhoto(chatID int64, fileName string, data []byte, caption markup.Text) error {
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: fileName, Bytes: data})
+	photo.Caption = caption.Render(c.format)
+	photo.ParseMode = string(c.format)
+
+	_, err := c.bot.Send(photo)
+	if isEntityError(err) {
+		c.logger.Warn("Telegram rejected markup, sending plain caption", "format", c.format, "chat_id", chatID, logging.Err(err))
+		photo.Caption = caption.Render(markup.Plain)
+		photo.ParseMode = ""
+		_, err = c.bot.Send(photo)
+	}
+	return err
+}
+
+// EditMessage изменяет текст ранее отправленного сообщения и убирает его инлайн-клавиатуру
+func (c *Client) EditMessage(chatID int64, messageID int, text string) error {
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	_, err := c.bot.Send(edit)
+	return err
+}
+
+// EditMessageWithKeyboard изменяет текст и инлайн-клавиатуру ранее отправленного сообщения
+func (c *Client) EditMessageWithKeyboard(chatID int64, messageID int, text string, keyboard tgbotapi.InlineKeyboardMarkup) error {
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, keyboard)
+	_, err := c.bot.Send(edit)
+	return err
+}
+
+// DeleteMessage удаляет сообщение из чата
+func (c *Client) DeleteMessage(chatID int64, messageID int) error {
+	_, err := c.bot.Request(tgbotapi.NewDeleteMessage(chatID, messageID))
+	return err
+}
+
+// PinMessage закрепляет сообщение в чате с уведомлением участников
+func (c *Client) PinMessage(chatID int64, messageID int) error {
+	_, err := c.bot.Request(tgbotapi.PinChatMessageConfig{ChatID: chatID, MessageID: messageID})
+	return err
+}
+
+// UnpinMessage открепляет сообщение в чате
+func (c *Client) UnpinMessage(chatID int64, messageID int) error {
+	_, err := c.bot.Request(tgbotapi.UnpinChatMessageConfig{ChatID: chatID, MessageID: messageID})
+	return err
+}
+
+// AnswerCallback отвечает на нажатие инлайн-кнопки
+func (c *Client) AnswerCallback(callbackID string, text string) error {
+	_, err := c.bot.Request(tgbotapi.NewCallback(callbackID, text))
+	return err
+}
+
 // RemoveKeyboard удаляет клавиатуру
 func (c *Client) RemoveKeyboard(chatID int64, text string) error {
 	msg := tgbotapi.NewMessage(chatID, text)
@@ -69,54 +269,236 @@ func (c *Client) GetUserFromMessage(message *tgbotapi.Message) *domain.User {
 		return nil
 	}
 
-	// Генерируем уникальное имя пользователя, добавляя chat_id
-	username := fmt.Sprintf("%s_%d", message.From.UserName, message.Chat.ID)
+	// Генерируем уникальное имя пользователя, добавляя Telegram ID
+	username := fmt.Sprintf("%s_%d", message.From.UserName, message.From.ID)
+
+	user := &domain.User{
+		TelegramID: message.From.ID,
+		Username:   username,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
 
-	return &domain.User{
-		ChatID:    message.Chat.ID,
-		Username:  username,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	// Сообщения доставляются только в личный чат с пользователем
+	if message.Chat.IsPrivate() {
+		user.ChatID = message.Chat.ID
 	}
+
+	return user
 }
 
 // GetLoginKeyboard возвращает клавиатуру для авторизации
-func (c *Client) GetLoginKeyboard() tgbotapi.ReplyKeyboardMarkup {
+func (c *Client) GetLoginKeyboard(lang i18n.Lang) tgbotapi.ReplyKeyboardMarkup {
 	buttons := [][]string{
-		{"Войти"},
-		{"Зарегистрироваться"},
+		{i18n.T(lang, BtnLogin)},
+		{i18n.T(lang, BtnRegister)},
 	}
 	return c.CreateReplyKeyboard(buttons)
 }
 
-// GetMainMenuKeyboard возвращает клавиатуру главного меню
-func (c *Client) GetMainMenuKeyboard(isAdmin bool) tgbotapi.ReplyKeyboardMarkup {
-	var buttons [][]string
-	if isAdmin {
-		buttons = [][]string{
-			{"Мой профиль", "Пополнить баланс"},
-			{"Список пользователей", "Управление пользователями"},
-			{"Выйти"},
-		}
-	} else {
-		buttons = [][]string{
-			{"Мой профиль", "Пополнить баланс"},
-			{"Выйти"},
-		}
+// GetMainMenuKeyboard возвращает клавиатуру главного меню с пунктами, доступными пользователю
+func (c *Client) GetMainMenuKeyboard(lang i18n.Lang, can func(domain.Permission) bool) tgbotapi.ReplyKeyboardMarkup {
+	buttons := [][]string{
+		{i18n.T(lang, BtnProfile), i18n.T(lang, BtnBalance)},
+	}
+
+	var adminRow []string
+	if can(domain.PermViewUsers) {
+		adminRow = append(adminRow, i18n.T(lang, BtnUsers))
+	}
+	if can(domain.PermManageUsers) {
+		adminRow = append(adminRow, i18n.T(lang, BtnUserManagement))
 	}
+	if len(adminRow) > 0 {
+		buttons = append(buttons, adminRow)
+	}
+	var settingsRow []string
+	if can(domain.PermManageRoles) {
+		settingsRow = append(settingsRow, i18n.T(lang, BtnRoles))
+	}
+	if can(domain.PermViewAudit) {
+		settingsRow = append(settingsRow, i18n.T(lang, BtnAudit))
+	}
+	if len(settingsRow) > 0 {
+		buttons = append(buttons, settingsRow)
+	}
+
+	buttons = append(buttons, []string{i18n.T(lang, BtnSettings), i18n.T(lang, BtnLogout)})
 	return c.CreateReplyKeyboard(buttons)
 }
 
 // GetUserManagementKeyboard возвращает клавиатуру управления пользователями
-func (c *Client) GetUserManagementKeyboard() tgbotapi.ReplyKeyboardMarkup {
+func (c *Client) GetUserManagementKeyboard(lang i18n.Lang) tgbotapi.ReplyKeyboardMarkup {
 	buttons := [][]string{
-		{"Добавить пользователя", "Удалить пользователя"},
-		{"Изменить роль пользователя"},
-		{"Назад"},
+		{i18n.T(lang, BtnAddUser), i18n.T(lang, BtnDeleteUser)},
+		{i18n.T(lang, BtnChangeUserRole)},
+		{i18n.T(lang, BtnBack)},
 	}
 	return c.CreateReplyKeyboard(buttons)
 }
 
+// GetTransferKeyboard возвращает инлайн-клавиатуру для рассмотрения запроса на перенос аккаунта
+func (c *Client) GetTransferKeyboard(lang i18n.Lang, transferID int64) tgbotapi.InlineKeyboardMarkup {
+	buttons := [][]InlineButton{
+		{
+			{Text: i18n.T(lang, BtnApprove), Data: fmt.Sprintf("transfer_approve:%d", transferID)},
+			{Text: i18n.T(lang, BtnReject), Data: fmt.Sprintf("transfer_reject:%d", transferID)},
+		},
+	}
+	return c.CreateInlineKeyboard(buttons)
+}
+
+// GetNextPageKeyboard возвращает инлайн-клавиатуру с кнопкой перехода к следующей странице списка
+func (c *Client) GetNextPageKeyboard(lang i18n.Lang, data string) tgbotapi.InlineKeyboardMarkup {
+	return c.CreateInlineKeyboard([][]InlineButton{{{Text: i18n.T(lang, BtnNextPage), Data: data}}})
+}
+
+// GetRosterKeyboard возвращает инлайн-клавиатуру выгрузки состава команды
+func (c *Client) GetRosterKeyboard(lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
+	return c.CreateInlineKeyboard([][]InlineButton{{
+		{Text: i18n.T(lang, BtnExportCSV), Data: "roster_export:csv"},
+		{Text: i18n.T(lang, BtnExportJSON), Data: "roster_export:json"},
+	}})
+}
+
+// GetImportKeyboard возвращает инлайн-клавиатуру подтверждения импорта состава команды
+func (c *Client) GetImportKeyboard(lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
+	return c.CreateInlineKeyboard([][]InlineButton{{
+		{Text: i18n.T(lang, BtnApplyImport), Data: "roster_import:apply"},
+		{Text: i18n.T(lang, BtnCancel), Data: "roster_import:cancel"},
+	}})
+}
+
+// GetTeamKeyboard возвращает инлайн-клавиатуру выбора активной команды
+func (c *Client) GetTeamKeyboard(teams []*domain.TeamMember, activeID int64) tgbotapi.InlineKeyboardMarkup {
+	var buttons [][]InlineButton
+	for _, team := range teams {
+		text := team.TeamName
+		if team.TeamID == activeID {
+			text = "✅ " + text
+		}
+		buttons = append(buttons, []InlineButton{{Text: text, Data: fmt.Sprintf("team_switch:%d", team.TeamID)}})
+	}
+	return c.CreateInlineKeyboard(buttons)
+}
+
+// GetRSVPKeyboard возвращает инлайн-клавиатуру ответа на приглашение на событие,
+// обновления списка участников и самостоятельной отметки о присутствии
+func (c *Client) GetRSVPKeyboard(lang i18n.Lang, eventID int64) tgbotapi.InlineKeyboardMarkup {
+	return c.CreateInlineKeyboard([][]InlineButton{
+		{
+			{Text: i18n.T(lang, BtnRSVPGoing), Data: fmt.Sprintf("rsvp:%d:%s", eventID, domain.RSVPGoing)},
+			{Text: i18n.T(lang, BtnRSVPNotGoing), Data: fmt.Sprintf("rsvp:%d:%s", eventID, domain.RSVPNotGoing)},
+			{Text: i18n.T(lang, BtnRSVPMaybe), Data: fmt.Sprintf("rsvp:%d:%s", eventID, domain.RSVPMaybe)},
+		},
+		{
+			{Text: i18n.T(lang, BtnRefresh), Data: fmt.Sprintf("event_show:%d", eventID)},
+			{Text: i18n.T(lang, BtnCheckIn), Data: fmt.Sprintf("checkin:%d", eventID)},
+		},
+	})
+}
+
+// GetDutyKeyboard возвращает инлайн-клавиатуру отметки выполнения дежурства
+func (c *Client) GetDutyKeyboard(lang i18n.Lang, assignmentID int64) tgbotapi.InlineKeyboardMarkup {
+	return c.CreateInlineKeyboard([][]InlineButton{
+		{{Text: i18n.T(lang, BtnDutyDone), Data: fmt.Sprintf("duty_done:%d", assignmentID)}},
+	})
+}
+
+// GetDutySwapKeyboard возвращает инлайн-клавиатуру ответа на просьбу взять дежурство
+func (c *Client) GetDutySwapKeyboard(lang i18n.Lang, swapID int64) tgbotapi.InlineKeyboardMarkup {
+	return c.CreateInlineKeyboard([][]InlineButton{
+		{
+			{Text: i18n.T(lang, BtnDutyAccept), Data: fmt.Sprintf("duty_swap:%d:accept", swapID)},
+			{Text: i18n.T(lang, BtnDutyDecline), Data: fmt.Sprintf("duty_swap:%d:decline", swapID)},
+		},
+	})
+}
+
+// GetAnnouncementKeyboard возвращает инлайн-клавиатуру отметки о прочтении объявления
+func (c *Client) GetAnnouncementKeyboard(lang i18n.Lang, announcementID int64) tgbotapi.InlineKeyboardMarkup {
+	return c.CreateInlineKeyboard([][]InlineButton{
+		{{Text: i18n.T(lang, BtnAcknowledge), Data: fmt.Sprintf("ack:%d", announcementID)}},
+	})
+}
+
+// GetTicketKeyboard возвращает инлайн-клавиатуру обращения в поддержку. Администратор
+// кроме ответа и закрытия может взять обращение в работу
+func (c *Client) GetTicketKeyboard(lang i18n.Lang, ticketID int64, staff bool) tgbotapi.InlineKeyboardMarkup {
+	row := []InlineButton{{Text: i18n.T(lang, BtnTicketReply), Data: fmt.Sprintf("ticket:%d:reply", ticketID)}}
+	if staff {
+		row = append(row, InlineButton{Text: i18n.T(lang, BtnTicketTake), Data: fmt.Sprintf("ticket:%d:take", ticketID)})
+	}
+	row = append(row, InlineButton{Text: i18n.T(lang, BtnTicketClose), Data: fmt.Sprintf("ticket:%d:close", ticketID)})
+	return c.CreateInlineKeyboard([][]InlineButton{row})
+}
+
+// GetAttendanceKeyboard возвращает инлайн-клавиатуру отметки посещения: по кнопке
+// на участника с его текущей отметкой. Нажатие переключает отметку
+func (c *Client) GetAttendanceKeyboard(eventID int64, marks []*domain.Attendance) tgbotapi.InlineKeyboardMarkup {
+	var buttons [][]InlineButton
+	for i, mark := range marks {
+		icon, next := "▫️", domain.AttendancePresent
+		switch mark.Status {
+		case domain.AttendancePresent:
+			icon, next = "✅", domain.AttendanceAbsent
+		case domain.AttendanceAbsent:
+			icon = "❌"
+		}
+		button := InlineButton{
+			Text: icon + " " + mark.Username,
+			Data: fmt.Sprintf("att:%d:%d:%s", eventID, mark.UserID, next),
+		}
+		if i%2 == 0 {
+			buttons = append(buttons, []InlineButton{button})
+		} else {
+			buttons[len(buttons)-1] = append(buttons[len(buttons)-1], button)
+		}
+	}
+	return c.CreateInlineKeyboard(buttons)
+}
+
+// GetPollKeyboard возвращает инлайн-клавиатуру голосования: по кнопке на вариант ответа
+// и кнопку обновления результатов
+func (c *Client) GetPollKeyboard(lang i18n.Lang, poll *domain.Poll) tgbotapi.InlineKeyboardMarkup {
+	var buttons [][]InlineButton
+	for _, option := range poll.Options {
+		buttons = append(buttons, []InlineButton{{
+			Text: fmt.Sprintf("%d. %s", option.Position, option.Text),
+			Data: fmt.Sprintf("vote:%d:%d", poll.ID, option.Position),
+		}})
+	}
+	buttons = append(buttons, []InlineButton{{Text: i18n.T(lang, BtnRefresh), Data: fmt.Sprintf("poll_show:%d", poll.ID)}})
+	return c.CreateInlineKeyboard(buttons)
+}
+
+// GetLanguageKeyboard возвращает инлайн-клавиатуру выбора языка интерфейса.
+// Названия языков всегда показываются на самих этих языках
+func (c *Client) GetLanguageKeyboard(current i18n.Lang) tgbotapi.InlineKeyboardMarkup {
+	var row []InlineButton
+	for _, lang := range i18n.Supported {
+		text := i18n.T(lang, "lang."+string(lang))
+		if lang == current {
+			text = "✅ " + text
+		}
+		row = append(row, InlineButton{Text: text, Data: "lang:" + string(lang)})
+	}
+	return c.CreateInlineKeyboard([][]InlineButton{row})
+}
+
+// CreateInlineKeyboard создает инлайн-клавиатуру с указанными кнопками
+func (c *Client) CreateInlineKeyboard(buttons [][]InlineButton) tgbotapi.InlineKeyboardMarkup {
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for _, row := range buttons {
+		var keyboardRow []tgbotapi.InlineKeyboardButton
+		for _, button := range row {
+			keyboardRow = append(keyboardRow, tgbotapi.NewInlineKeyboardButtonData(button.Text, button.Data))
+		}
+		keyboard = append(keyboard, keyboardRow)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+}
+
 // CreateReplyKeyboard создает клавиатуру с указанными кнопками
 func (c *Client) CreateReplyKeyboard(buttons [][]string) tgbotapi.ReplyKeyboardMarkup {
 	var keyboard [][]tgbotapi.KeyboardButton
@@ -133,7 +515,8 @@ func (c *Client) CreateReplyKeyboard(buttons [][]string) tgbotapi.ReplyKeyboardM
 // StartPolling начинает получение обновлений от Telegram
 func (c *Client) StartPolling(handler func(*tgbotapi.Update)) error {
 	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
+	u.Timeout = int(c.pollTimeout.Seconds())
+	u.Limit = c.messagesLimit
 
 	updates := c.bot.GetUpdatesChan(u)
 
@@ -1,68 +1,179 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	tgclient "HelpBot/client/telegram"
+	"HelpBot/internal/backup"
 	"HelpBot/internal/config"
 	tgdelivery "HelpBot/internal/delivery/telegram"
+	"HelpBot/internal/logging"
 	"HelpBot/internal/repository"
-	"HelpBot/internal/repository/sqlite"
 	"HelpBot/internal/service"
 )
 
 func main() {
+	configPath := flag.String("config", "", "path to YAML or TOML config file (default $CONFIG_FILE)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-config file] [config print]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// Команда config print выводит действующую конфигурацию и завершает работу
+	if args := flag.Args(); len(args) > 0 {
+		if len(args) == 2 && args[0] == "config" && args[1] == "print" {
+			os.Exit(printConfig(*configPath))
+		}
+		flag.Usage()
+		os.Exit(2)
+	}
+
 	// Инициализируем конфигурацию
-	cfg := config.NewConfig()
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
+
+	// Инициализируем логгер. Он передается в компоненты явно, а глобальным
+	// назначается только для сообщений сторонних библиотек
+	logger, err := logging.New(os.Stderr, logging.Options{
+		Level:     cfg.LogLevel,
+		Format:    cfg.LogFormat,
+		AddSource: cfg.Debug,
+	})
+	if err != nil {
+		log.Fatalf("Error creating logger: %v", err)
+	}
+	slog.SetDefault(logger)
 
-	
 	if cfg.Debug {
-		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
-		log.Println("Debug mode enabled")
-	} else {
-		log.SetFlags(log.Ldate | log.Ltime)
+		logger.Debug("Debug mode enabled")
+	}
+	if cfg.JWTSecretGenerated() {
+		logger.Warn("JWT secret is not configured, using a random one: tokens will not survive a restart")
 	}
 
-	// Инициализируем базу данных
-	db, err := sqlite.NewDB(cfg.DBPath)
+	// Подключаемся к хранилищу, выбранному строкой подключения, и создаем репозитории
+	repos, db, err := repository.Open(cfg.DatabaseDSN(), logger)
 	if err != nil {
-		log.Fatalf("Error initializing database: %v", err)
+		fatal(logger, "Error initializing database", err)
 	}
 	defer db.Close()
 
-	// Инициализируем репозитории
-	userRepo := sqlite.NewUserRepository(db)
-
-	// Создаем репозитории
-	repos := repository.NewRepositories(userRepo)
-
 	// Инициализируем сервисы
 	userService := service.NewUserService(repos.UserRepository)
-	authService := service.NewAuthService(repos.UserRepository, cfg)
-	sessionService := service.NewSessionService(userService, authService)
+	auditService := service.NewAuditService(repos.AuditRepository, repos.UserRepository, logger)
+	roleService := service.NewRoleService(repos.RoleRepository, repos.UserRepository, repos.TeamRepository, auditService)
+	authService := service.NewAuthService(repos.UserRepository, repos.TransferRepository, repos.InviteRepository, repos.TeamRepository, roleService, auditService, cfg)
+	twoFactorService := service.NewTwoFactorService(repos.TwoFactorRepository, auditService, cfg)
+	sessionService := service.NewSessionService(userService, authService, twoFactorService)
+	teamService := service.NewTeamService(repos.TeamRepository, repos.UserRepository, roleService, auditService)
+	rosterService := service.NewRosterService(repos.UserRepository, repos.TeamRepository, repos.InviteRepository, roleService, auditService)
+	duesService := service.NewDuesService(repos.DuesRepository, repos.BalanceRepository, repos.TeamRepository, repos.UserRepository, roleService, auditService, logger)
+	expenseService := service.NewExpenseService(repos.ExpenseRepository, repos.TeamRepository, repos.UserRepository, roleService, auditService)
+	eventService := service.NewEventService(repos.EventRepository, repos.TeamRepository, repos.UserRepository, roleService, auditService, cfg.EventReminder)
+	pollService := service.NewPollService(repos.PollRepository, repos.TeamRepository, repos.UserRepository, roleService, auditService)
+	attendanceService := service.NewAttendanceService(repos.AttendanceRepository, repos.EventRepository, repos.TeamRepository, repos.UserRepository, roleService, auditService, cfg.CheckInWindow)
+	dutyService := service.NewDutyService(repos.DutyRepository, repos.TeamRepository, repos.UserRepository, roleService, auditService)
+	announcementService := service.NewAnnouncementService(repos.AnnouncementRepository, repos.TeamRepository, repos.UserRepository, roleService, auditService, cfg.AnnounceReminder)
+	ticketService := service.NewTicketService(repos.TicketRepository, repos.UserRepository, roleService, auditService)
+	purgeService := service.NewPurgeService(repos.UserRepository, repos.TwoFactorRepository, auditService, logger)
+
+	// Резервное копирование поддерживается только для SQLite
+	var backupManager *backup.Manager
+	if path, ok := repository.SQLitePath(cfg.DatabaseDSN()); ok {
+		backupManager = backup.NewManager(db, path, backupOptions(cfg))
+	}
+	backupService := service.NewBackupService(backupManager, roleService, auditService, logger)
 
 	// Инициализируем клиент Telegram
-	client, err := tgclient.NewClient(cfg.TelegramToken, cfg.PollTimeout, cfg.MessagesLimit)
+	client, err := tgclient.NewClient(cfg.TelegramToken, cfg.PollTimeout, cfg.MessagesLimit, cfg.MessageFormat, logger)
 	if err != nil {
-		log.Fatalf("Error creating Telegram client: %v", err)
+		fatal(logger, "Error creating Telegram client", err)
 	}
 
 	// Инициализируем обработчик
-	handler := tgdelivery.NewHandler(client, userService, sessionService)
+	handler := tgdelivery.NewHandler(client, userService, sessionService, authService, roleService, auditService, twoFactorService, rosterService, backupService, teamService, duesService, expenseService, eventService, pollService, attendanceService, dutyService, announcementService, ticketService, logger)
 
-	log.Printf("Bot started with poll timeout: %v", cfg.PollTimeout)
+	logger.Info("Bot started", "poll_timeout", cfg.PollTimeout, "messages_limit", cfg.MessagesLimit)
 
 	// Настраиваем обработку сигналов для корректного завершения
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Запускаем обработку сообщений в отдельной горутине
 	go client.StartPolling(handler.HandleUpdate)
 
+	// Раз в час обезличиваем пользователей, удаленных дольше срока хранения
+	go purgeService.Run(ctx, cfg.DeletedRetention, time.Hour)
+
+	// Раз в час начисляем взносы за наступившие периоды
+	go duesService.Run(ctx, time.Hour)
+
+	// Напоминаем участникам о предстоящих событиях
+	if cfg.EventReminder > 0 {
+		go handler.RunEventReminders(ctx, 5*time.Minute)
+	}
+
+	// Раз в минуту закрываем опросы с истекшим сроком и публикуем итоги
+	go handler.RunPollClosing(ctx, time.Minute)
+
+	// Раз в час назначаем дежурных на наступившие периоды графиков
+	go handler.RunDutyRotation(ctx, time.Hour)
+
+	// Напоминаем участникам о непрочитанных объявлениях
+	if cfg.AnnounceReminder > 0 {
+		go handler.RunAnnouncementReminders(ctx, 10*time.Minute)
+	}
+
+	// Создаем резервные копии базы по расписанию
+	go backupService.Run(ctx, cfg.BackupInterval)
+
 	// Ожидаем сигнал завершения
-	<-c
-	log.Println("Shutting down bot...")
+	<-ctx.Done()
+	logger.Info("Shutting down bot")
+}
+
+// backupOptions возвращает параметры резервного копирования из конфигурации
+func backupOptions(cfg *config.Config) backup.Options {
+	return backup.Options{
+		Dir:        cfg.BackupDir,
+		Compress:   cfg.BackupCompress,
+		KeepDaily:  cfg.BackupKeepDaily,
+		KeepWeekly: cfg.BackupKeepWeekly,
+	}
+}
+
+// fatal записывает ошибку в лог и завершает работу
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, logging.Err(err))
+	os.Exit(1)
+}
+
+// printConfig выводит действующую конфигурацию со скрытыми секретами
+// и возвращает код завершения
+func printConfig(path string) int {
+	cfg, err := config.Read(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := cfg.Print(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
 }
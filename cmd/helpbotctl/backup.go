@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"HelpBot/internal/backup"
+	"HelpBot/internal/repository"
+	"HelpBot/internal/repository/sqlite"
+)
+
+// backup выполняет команды backup create|list|verify <копия>|restore <копия>.
+// Восстановление выполняется при остановленном боте
+func (c *ctl) backup(args []string) error {
+	path, ok := repository.SQLitePath(c.cfg.DatabaseDSN())
+	if !ok {
+		return errors.New("backups are only supported for SQLite, use pg_dump for PostgreSQL")
+	}
+	opts := backup.Options{
+		Dir:        c.cfg.BackupDir,
+		Compress:   c.cfg.BackupCompress,
+		KeepDaily:  c.cfg.BackupKeepDaily,
+		KeepWeekly: c.cfg.BackupKeepWeekly,
+	}
+
+	switch {
+	case len(args) == 1 && args[0] == "create":
+		db, err := sqlite.OpenReadOnly(path)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		manager := backup.NewManager(db, path, opts)
+		created, err := manager.Create()
+		if err != nil {
+			return err
+		}
+		removed, err := manager.Prune()
+		if err != nil {
+			return err
+		}
+		return c.out.backupResult(created, map[string]any{"removed": removed})
+
+	case len(args) == 1 && args[0] == "list":
+		backups, err := backup.NewManager(nil, path, opts).List()
+		if err != nil {
+			return err
+		}
+		return c.out.backups(backups)
+
+	case len(args) == 2 && args[0] == "verify":
+		verified, err := backup.NewManager(nil, path, opts).Verify(args[1])
+		if err != nil {
+			return fmt.Errorf("%s: %w", args[1], err)
+		}
+		return c.out.backupResult(verified, nil)
+
+	case len(args) == 2 && args[0] == "restore":
+		restored, previous, err := backup.NewManager(nil, path, opts).Restore(args[1])
+		if err != nil {
+			return fmt.Errorf("%s: %w", args[1], err)
+		}
+		return c.out.backupResult(restored, map[string]any{"previous": previous})
+
+	default:
+		return errUsage
+	}
+}
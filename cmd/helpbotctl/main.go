@@ -0,0 +1,187 @@
+// Команда helpbotctl - утилита администратора сервера. Она работает напрямую с базой данных
+// из конфигурации бота: создает пользователей (в том числе первого администратора),
+// назначает роли, сбрасывает пароли, завершает сессии, применяет миграции
+// и управляет резервными копиями
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"HelpBot/internal/config"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/repository"
+	"HelpBot/internal/service"
+)
+
+const usage = `Usage: %s [-config file] [-o table|json] <command>
+
+Commands:
+  user create <username> [-role R] [-password-stdin]
+  user list [-role R] [-status S,...|all] [-name prefix] [-sort id|username|created_at] [-limit N]
+  user show <username>
+  user delete <username> [-reason text]
+  role set <username> <role>
+  password reset <username> [-password-stdin]
+  session revoke <username>
+  migrate
+  backup create|list|verify <name>|restore <name>
+
+Options:
+`
+
+// errUsage означает неверные аргументы команды: выводится справка и код завершения 2
+var errUsage = errors.New("invalid arguments")
+
+// ctl содержит общие параметры команд
+type ctl struct {
+	configPath string
+	cfg        *config.Config
+	out        *printer
+	stdin      io.Reader
+}
+
+func main() {
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	configPath := flags.String("config", "", "path to YAML or TOML config file (default $CONFIG_FILE)")
+	output := flags.String("o", formatTable, "output format: table or json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), usage, os.Args[0])
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+
+	out, err := newPrinter(os.Stdout, *output)
+	if err != nil || flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	c := &ctl{configPath: *configPath, out: out, stdin: os.Stdin}
+	err = c.run(flags.Args())
+	switch {
+	case errors.Is(err, errUsage):
+		if err != errUsage {
+			fmt.Fprintln(os.Stderr, "error:", err)
+		}
+		flags.Usage()
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "error:", errorText(err))
+		os.Exit(1)
+	}
+}
+
+// errorText возвращает текст ошибки. Ошибки сервисов из каталога выводятся на английском,
+// как и остальные сообщения утилиты
+func errorText(err error) string {
+	var localizable i18n.Localizable
+	if errors.As(err, &localizable) {
+		return localizable.Localize(i18n.EN)
+	}
+	return err.Error()
+}
+
+// run выполняет команду
+func (c *ctl) run(args []string) error {
+	// Токен бота утилите не нужен, поэтому конфигурация не проверяется целиком
+	cfg, err := config.Read(c.configPath)
+	if err != nil {
+		return err
+	}
+	c.cfg = cfg
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "migrate":
+		if len(args) != 0 {
+			return errUsage
+		}
+		return c.migrate()
+	case "backup":
+		return c.backup(args)
+	}
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	admin, closeDB, err := c.openAdmin()
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	switch command + " " + args[0] {
+	case "user create":
+		return c.userCreate(admin, args[1:])
+	case "user list":
+		return c.userList(admin, args[1:])
+	case "user show":
+		return c.userShow(admin, args[1:])
+	case "user delete":
+		return c.userDelete(admin, args[1:])
+	case "role set":
+		return c.roleSet(admin, args[1:])
+	case "password reset":
+		return c.passwordReset(admin, args[1:])
+	case "session revoke":
+		return c.sessionRevoke(admin, args[1:])
+	default:
+		return errUsage
+	}
+}
+
+// admin объединяет сервис администратора и репозиторий пользователей для чтения
+type admin struct {
+	*service.AdminService
+	repos *repository.Repositories
+}
+
+// openAdmin подключается к базе данных (применяя миграции) и создает сервисы
+func (c *ctl) openAdmin() (*admin, func(), error) {
+	repos, db, err := repository.Open(c.cfg.DatabaseDSN(), c.logger(slog.LevelWarn))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Журнал аудита пишется так же, как ботом, чтобы действия из утилиты были видны в /audit
+	auditService := service.NewAuditService(repos.AuditRepository, repos.UserRepository, c.logger(slog.LevelWarn))
+	roleService := service.NewRoleService(repos.RoleRepository, repos.UserRepository, repos.TeamRepository, auditService)
+	authService := service.NewAuthService(repos.UserRepository, repos.TransferRepository, repos.InviteRepository, repos.TeamRepository, roleService, auditService, c.cfg)
+	adminService := service.NewAdminService(authService, repos.UserRepository, roleService, auditService)
+	return &admin{AdminService: adminService, repos: repos}, func() { db.Close() }, nil
+}
+
+// migrate применяет миграции и выводит версию схемы
+func (c *ctl) migrate() error {
+	backend, err := repository.Backend(c.cfg.DatabaseDSN())
+	if err != nil {
+		return err
+	}
+	// Примененные миграции выводятся в журнал
+	_, db, err := repository.Open(c.cfg.DatabaseDSN(), c.logger(slog.LevelInfo))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	version, err := repository.SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	return c.out.schema(backend, version)
+}
+
+// logger возвращает логгер утилиты: сообщения пишутся в stderr, чтобы не смешиваться с выводом команд
+func (c *ctl) logger(level slog.Level) *slog.Logger {
+	// Текстовый формат поддерживается всегда, поэтому ошибки здесь нет
+	logger, _ := logging.New(os.Stderr, logging.Options{Level: level, Format: logging.FormatText})
+	return logger
+}
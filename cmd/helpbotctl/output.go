@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// Форматы вывода
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// timeLayout - формат времени в табличном выводе
+const timeLayout = "2006-01-02 15:04:05"
+
+// printer выводит результаты команд таблицей или в JSON
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+// newPrinter создает printer для указанного формата вывода
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case formatTable:
+		return &printer{w: w}, nil
+	case formatJSON:
+		return &printer{w: w, json: true}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// userView - пользователь в выводе утилиты. Хеш пароля никогда не выводится
+type userView struct {
+	ID                int64             `json:"id"`
+	Username          string            `json:"username"`
+	Role              string            `json:"role"`
+	Status            domain.UserStatus `json:"status"`
+	StatusReason      string            `json:"status_reason,omitempty"`
+	TelegramID        int64             `json:"telegram_id,omitempty"`
+	Position          string            `json:"position,omitempty"`
+	Language          string            `json:"language,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+	SessionsRevokedAt *time.Time        `json:"sessions_revoked_at,omitempty"`
+	Password          string            `json:"password,omitempty"` // Сгенерированный пароль
+}
+
+// newUserView создает userView из пользователя
+func newUserView(user *domain.User) userView {
+	view := userView{
+		ID:           user.ID,
+		Username:     user.Username,
+		Role:         user.Role,
+		Status:       user.Status,
+		StatusReason: user.StatusReason,
+		TelegramID:   user.TelegramID,
+		Position:     user.Position,
+		Language:     user.Language,
+		CreatedAt:    user.CreatedAt,
+	}
+	if view.Status == "" {
+		view.Status = domain.UserActive
+	}
+	if !user.SessionsRevokedAt.IsZero() {
+		view.SessionsRevokedAt = &user.SessionsRevokedAt
+	}
+	return view
+}
+
+// users выводит список пользователей
+func (p *printer) users(users []*domain.User) error {
+	views := make([]userView, 0, len(users))
+	for _, user := range users {
+		views = append(views, newUserView(user))
+	}
+	if p.json {
+		return p.encode(views)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tUSERNAME\tROLE\tSTATUS\tTELEGRAM\tPOSITION\tCREATED")
+	for _, v := range views {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			v.ID, v.Username, v.Role, v.Status, optional(v.TelegramID != 0, fmt.Sprint(v.TelegramID)),
+			optional(v.Position != "", v.Position), formatTime(v.CreatedAt))
+	}
+	return tw.Flush()
+}
+
+// user выводит одного пользователя
+func (p *printer) user(user *domain.User) error {
+	return p.userWithPassword(user, "")
+}
+
+// userWithPassword выводит пользователя и сгенерированный для него пароль (если он задан)
+func (p *printer) userWithPassword(user *domain.User, password string) error {
+	v := newUserView(user)
+	v.Password = password
+	if p.json {
+		return p.encode(v)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "id:\t%d\n", v.ID)
+	fmt.Fprintf(tw, "username:\t%s\n", v.Username)
+	fmt.Fprintf(tw, "role:\t%s\n", v.Role)
+	fmt.Fprintf(tw, "status:\t%s\n", v.Status)
+	if v.StatusReason != "" {
+		fmt.Fprintf(tw, "status reason:\t%s\n", v.StatusReason)
+	}
+	fmt.Fprintf(tw, "telegram:\t%s\n", optional(v.TelegramID != 0, fmt.Sprint(v.TelegramID)))
+	fmt.Fprintf(tw, "position:\t%s\n", optional(v.Position != "", v.Position))
+	fmt.Fprintf(tw, "created:\t%s\n", formatTime(v.CreatedAt))
+	if v.SessionsRevokedAt != nil {
+		fmt.Fprintf(tw, "sessions revoked:\t%s\n", formatTime(*v.SessionsRevokedAt))
+	}
+	if v.Password != "" {
+		fmt.Fprintf(tw, "password:\t%s\n", v.Password)
+	}
+	return tw.Flush()
+}
+
+// schema выводит версию схемы базы данных
+func (p *printer) schema(backend string, version int) error {
+	if p.json {
+		return p.encode(map[string]any{"backend": backend, "schema_version": version})
+	}
+	_, err := fmt.Fprintf(p.w, "%s schema is at version %d\n", backend, version)
+	return err
+}
+
+// backups выводит список резервных копий
+func (p *printer) backups(backups []*domain.Backup) error {
+	if p.json {
+		if backups == nil {
+			backups = []*domain.Backup{}
+		}
+		return p.encode(backups)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tCREATED\tSIZE\tSHA256")
+	for _, b := range backups {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", b.Name, formatTime(b.CreatedAt), b.Size, optional(b.Checksum != "", b.Checksum))
+	}
+	return tw.Flush()
+}
+
+// backupResult выводит результат операции с резервной копией и сопутствующие сообщения
+// (удаленные старые копии, путь к сохраненной базе)
+func (p *printer) backupResult(b *domain.Backup, extra map[string]any) error {
+	if p.json {
+		result := map[string]any{"backup": b}
+		for key, value := range extra {
+			result[key] = value
+		}
+		return p.encode(result)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "name:\t%s\n", b.Name)
+	fmt.Fprintf(tw, "size:\t%d\n", b.Size)
+	if b.Checksum != "" {
+		fmt.Fprintf(tw, "sha256:\t%s\n", b.Checksum)
+	}
+	if b.SchemaVersion != 0 {
+		fmt.Fprintf(tw, "schema version:\t%d\n", b.SchemaVersion)
+	}
+	for _, key := range []string{"removed", "previous"} {
+		switch value := extra[key].(type) {
+		case []string:
+			for _, name := range value {
+				fmt.Fprintf(tw, "%s:\t%s\n", key, name)
+			}
+		case string:
+			if value != "" {
+				fmt.Fprintf(tw, "%s:\t%s\n", key, value)
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+// encode выводит значение в JSON с отступами
+func (p *printer) encode(v any) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// optional возвращает значение или прочерк, если значения нет
+func optional(ok bool, value string) string {
+	if !ok {
+		return "-"
+	}
+	return value
+}
+
+// formatTime форматирует время для таблицы в местном часовом поясе
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(timeLayout)
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+)
+
+// listPageSize - размер страницы при выборке пользователей для user list
+const listPageSize = 200
+
+// userCreate выполняет команду user create <username> [-role R] [-password-stdin]
+func (c *ctl) userCreate(a *admin, args []string) error {
+	flags := newFlagSet("user create")
+	role := flags.String("role", domain.RoleUser, "role of the new user")
+	fromStdin := flags.Bool("password-stdin", false, "read the password from stdin instead of generating one")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errUsage
+	}
+
+	password, err := c.password(*fromStdin)
+	if err != nil {
+		return err
+	}
+	user, generated, err := a.CreateUser(positional[0], password, *role)
+	if err != nil {
+		return err
+	}
+	return c.out.userWithPassword(user, shownPassword(password, generated))
+}
+
+// userList выполняет команду user list с фильтрами
+func (c *ctl) userList(a *admin, args []string) error {
+	flags := newFlagSet("user list")
+	role := flags.String("role", "", "only users with this role")
+	status := flags.String("status", "", "comma-separated statuses or all (default: all except deleted)")
+	name := flags.String("name", "", "only usernames starting with this prefix")
+	sort := flags.String("sort", string(domain.UserSortID), "sort by id, username or created_at")
+	limit := flags.Int("limit", 0, "maximum number of users (0 - no limit)")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 || *limit < 0 {
+		return errUsage
+	}
+
+	statuses, err := parseStatuses(*status)
+	if err != nil {
+		return err
+	}
+	query := domain.UserQuery{
+		Statuses:   statuses,
+		Role:       *role,
+		NamePrefix: *name,
+		Sort:       domain.UserSort(*sort),
+		Limit:      listPageSize,
+	}
+
+	// Пользователи выбираются страницами, пока они не закончатся или не будет достигнут лимит
+	var users []*domain.User
+	for {
+		if *limit > 0 {
+			query.Limit = min(listPageSize, *limit-len(users))
+		}
+		page, err := a.repos.UserRepository.Find(query)
+		if err != nil {
+			return err
+		}
+		users = append(users, page.Users...)
+		if page.NextCursor == "" || (*limit > 0 && len(users) >= *limit) {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	return c.out.users(users)
+}
+
+// userShow выполняет команду user show <username>
+func (c *ctl) userShow(a *admin, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	user, err := a.repos.UserRepository.GetByUsername(args[0])
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return i18n.NewError("error.user_not_found")
+	}
+	return c.out.user(user)
+}
+
+// userDelete выполняет команду user delete <username> [-reason text]
+func (c *ctl) userDelete(a *admin, args []string) error {
+	flags := newFlagSet("user delete")
+	reason := flags.String("reason", "", "reason shown in the audit log")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errUsage
+	}
+
+	user, err := a.DeleteUser(positional[0], *reason)
+	if err != nil {
+		return err
+	}
+	return c.out.user(user)
+}
+
+// roleSet выполняет команду role set <username> <role>
+func (c *ctl) roleSet(a *admin, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	user, err := a.SetRole(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return c.out.user(user)
+}
+
+// passwordReset выполняет команду password reset <username> [-password-stdin]
+func (c *ctl) passwordReset(a *admin, args []string) error {
+	flags := newFlagSet("password reset")
+	fromStdin := flags.Bool("password-stdin", false, "read the password from stdin instead of generating one")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errUsage
+	}
+
+	password, err := c.password(*fromStdin)
+	if err != nil {
+		return err
+	}
+	user, generated, err := a.ResetPassword(positional[0], password)
+	if err != nil {
+		return err
+	}
+	return c.out.userWithPassword(user, shownPassword(password, generated))
+}
+
+// sessionRevoke выполняет команду session revoke <username>
+func (c *ctl) sessionRevoke(a *admin, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	user, err := a.RevokeSessions(args[0])
+	if err != nil {
+		return err
+	}
+	return c.out.user(user)
+}
+
+// password читает пароль из первой строки stdin. Без флага возвращается пустая строка,
+// и сервис генерирует пароль сам. Пароль в аргументах попал бы в историю команд
+func (c *ctl) password(fromStdin bool) (string, error) {
+	if !fromStdin {
+		return "", nil
+	}
+	line, err := bufio.NewReader(c.stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("password is empty")
+	}
+	return password, nil
+}
+
+// shownPassword возвращает пароль для вывода: показывается только сгенерированный пароль,
+// введенный администратором он и так знает
+func shownPassword(entered, result string) string {
+	if entered != "" {
+		return ""
+	}
+	return result
+}
+
+// parseStatuses разбирает список состояний учетной записи. Пустой список означает
+// все состояния, кроме удаленного, all - все состояния
+func parseStatuses(value string) ([]domain.UserStatus, error) {
+	switch value {
+	case "":
+		return []domain.UserStatus{domain.UserActive, domain.UserSuspended, domain.UserDeactivated}, nil
+	case "all":
+		return nil, nil
+	}
+
+	var statuses []domain.UserStatus
+	for _, part := range strings.Split(value, ",") {
+		status := domain.UserStatus(strings.TrimSpace(part))
+		if !domain.IsKnownUserStatus(status) {
+			return nil, fmt.Errorf("unknown status %q", status)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// newFlagSet создает набор флагов подкоманды. Ошибки разбора выводятся вместе с общей справкой
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+// parseArgs разбирает флаги подкоманды, которые могут стоять как до, так и после
+// позиционных аргументов, и возвращает позиционные аргументы
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %v", errUsage, err)
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,436 @@
+// Package backup создает, проверяет и восстанавливает резервные копии базы данных SQLite.
+//
+// Копия - файл helpbot-<дата>-<время>.db (или .db.gz при сжатии) в каталоге резервных копий.
+// Рядом хранится файл <копия>.sha256 с контрольной суммой в формате sha256sum
+package backup
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/repository/sqlite"
+)
+
+// Формат имен файлов резервных копий
+const (
+	filePrefix     = "helpbot-"
+	timeLayout     = "20060102-150405"
+	fileExt        = ".db"
+	gzipExt        = ".gz"
+	checksumExt    = ".sha256"
+	tempExt        = ".tmp"
+	restoreExt     = ".restore"
+	beforeRestore  = ".before-restore-"
+	dirPermissions = 0o700 // Копии содержат хеши паролей и доступны только владельцу
+)
+
+// namePattern описывает имя файла резервной копии
+var namePattern = regexp.MustCompile(`^` + filePrefix + `(\d{8}-\d{6})` + regexp.QuoteMeta(fileExt) + `(` + regexp.QuoteMeta(gzipExt) + `)?$`)
+
+// Ошибки резервного копирования
+var (
+	ErrNotFound         = errors.New("backup not found")
+	ErrChecksumMissing  = errors.New("backup checksum file is missing")
+	ErrChecksumMismatch = errors.New("backup checksum does not match")
+	ErrDatabaseInUse    = errors.New("database has a pending journal: stop the bot and open the database once before restoring")
+)
+
+// Options задает параметры резервного копирования
+type Options struct {
+	Dir        string // Каталог резервных копий
+	Compress   bool   // Сжимать копии gzip
+	KeepDaily  int    // Сколько последних дней хранить по одной копии за день
+	KeepWeekly int    // Сколько последних недель хранить по одной копии за неделю
+}
+
+// Manager управляет резервными копиями одной базы данных
+type Manager struct {
+	db     *sql.DB // Открытая база данных (nil, если копии только проверяются и восстанавливаются)
+	dbPath string
+	opts   Options
+	mu     sync.Mutex
+}
+
+// NewManager создает новый экземпляр Manager
+func NewManager(db *sql.DB, dbPath string, opts Options) *Manager {
+	return &Manager{
+		db:     db,
+		dbPath: dbPath,
+		opts:   opts,
+	}
+}
+
+// Create создает резервную копию открытой базы данных. Копия проверяется до того,
+// как появится в каталоге под своим именем, поэтому List не видит незавершенных копий
+func (m *Manager) Create() (*domain.Backup, error) {
+	if m.db == nil {
+		return nil, errors.New("backup: database is not open")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(m.opts.Dir, dirPermissions); err != nil {
+		return nil, err
+	}
+
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	name := filePrefix + createdAt.Format(timeLayout) + fileExt
+	if m.opts.Compress {
+		name += gzipExt
+	}
+	path := m.path(name)
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("backup %s already exists", name)
+	}
+
+	snapshot := filepath.Join(m.opts.Dir, filePrefix+createdAt.Format(timeLayout)+".snapshot"+tempExt)
+	os.Remove(snapshot)
+	defer os.Remove(snapshot)
+	if err := sqlite.Snapshot(m.db, snapshot); err != nil {
+		return nil, err
+	}
+	if _, err := sqlite.Check(snapshot); err != nil {
+		return nil, fmt.Errorf("snapshot is invalid: %w", err)
+	}
+
+	// Файл копии записывается во временный файл и одновременно хешируется
+	temp := path + tempExt
+	defer os.Remove(temp)
+	checksum, size, err := writeBackup(snapshot, temp, m.opts.Compress)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path+checksumExt, []byte(checksum+"  "+name+"\n"), 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(temp, path); err != nil {
+		os.Remove(path + checksumExt)
+		return nil, err
+	}
+
+	return &domain.Backup{
+		Name:       name,
+		Size:       size,
+		Checksum:   checksum,
+		Compressed: m.opts.Compress,
+		CreatedAt:  createdAt,
+	}, nil
+}
+
+// writeBackup копирует снимок базы в файл копии, при необходимости сжимая его,
+// и возвращает контрольную сумму и размер записанного файла
+func writeBackup(snapshot, path string, compress bool) (string, int64, error) {
+	src, err := os.Open(snapshot)
+	if err != nil {
+		return "", 0, err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", 0, err
+	}
+	defer dst.Close()
+
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(dst, hash)}
+	if compress {
+		zw := gzip.NewWriter(counter)
+		if _, err := io.Copy(zw, src); err != nil {
+			return "", 0, err
+		}
+		if err := zw.Close(); err != nil {
+			return "", 0, err
+		}
+	} else if _, err := io.Copy(counter, src); err != nil {
+		return "", 0, err
+	}
+	if err := dst.Sync(); err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), counter.n, dst.Close()
+}
+
+// countingWriter считает записанные байты
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// List возвращает резервные копии из каталога от новых к старым
+func (m *Manager) List() ([]*domain.Backup, error) {
+	entries, err := os.ReadDir(m.opts.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []*domain.Backup
+	for _, entry := range entries {
+		match := namePattern.FindStringSubmatch(entry.Name())
+		if match == nil || !entry.Type().IsRegular() {
+			continue
+		}
+		createdAt, err := time.Parse(timeLayout, match[1])
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		checksum, _ := m.readChecksum(entry.Name())
+		backups = append(backups, &domain.Backup{
+			Name:       entry.Name(),
+			Size:       info.Size(),
+			Checksum:   checksum,
+			Compressed: match[2] != "",
+			CreatedAt:  createdAt,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// Verify проверяет контрольную сумму копии, распаковывает ее во временный файл
+// и проверяет целостность базы данных
+func (m *Manager) Verify(name string) (*domain.Backup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	temp := m.path(name) + ".verify" + tempExt
+	defer os.Remove(temp)
+	return m.extract(name, temp)
+}
+
+// extract проверяет копию и распаковывает ее в указанный файл
+func (m *Manager) extract(name, dst string) (*domain.Backup, error) {
+	backup, err := m.find(name)
+	if err != nil {
+		return nil, err
+	}
+	expected, err := m.readChecksum(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Контрольная сумма проверяется до распаковки: поврежденный файл не читается как база
+	checksum, err := fileChecksum(m.path(name))
+	if err != nil {
+		return nil, err
+	}
+	if checksum != expected {
+		return nil, ErrChecksumMismatch
+	}
+
+	src, err := os.Open(m.path(name))
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	var reader io.Reader = src
+	if backup.Compressed {
+		zr, err := gzip.NewReader(src)
+		if err != nil {
+			return nil, fmt.Errorf("backup is not a valid gzip file: %w", err)
+		}
+		defer zr.Close()
+		reader = zr
+	}
+
+	os.Remove(dst)
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(out, reader); err != nil {
+		out.Close()
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+
+	backup.Checksum = expected
+	if backup.SchemaVersion, err = sqlite.Check(dst); err != nil {
+		return nil, fmt.Errorf("backup database is invalid: %w", err)
+	}
+	return backup, nil
+}
+
+// Restore проверяет копию и подменяет ею файл базы данных. Текущая база сохраняется
+// рядом с суффиксом .before-restore-<время>, путь к ней возвращается.
+// Бот должен быть остановлен: база данных не должна быть открыта
+func (m *Manager) Restore(name string) (*domain.Backup, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Незавершенный журнал относится к текущему файлу базы и испортит восстановленный
+	for _, suffix := range []string{"-journal", "-wal"} {
+		if _, err := os.Stat(m.dbPath + suffix); err == nil {
+			return nil, "", ErrDatabaseInUse
+		}
+	}
+
+	// Копия распаковывается рядом с базой, чтобы подмена была атомарным переименованием
+	temp := m.dbPath + restoreExt
+	defer os.Remove(temp)
+	backup, err := m.extract(name, temp)
+	if err != nil {
+		return nil, "", err
+	}
+
+	previous := ""
+	if _, err := os.Stat(m.dbPath); err == nil {
+		previous = m.dbPath + beforeRestore + time.Now().UTC().Format(timeLayout)
+		if err := os.Rename(m.dbPath, previous); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := os.Rename(temp, m.dbPath); err != nil {
+		if previous != "" {
+			os.Rename(previous, m.dbPath)
+		}
+		return nil, "", err
+	}
+	os.Remove(m.dbPath + "-shm")
+	return backup, previous, nil
+}
+
+// Prune удаляет копии, не попадающие в срок хранения, и возвращает их имена.
+// Самая новая копия сохраняется всегда
+func (m *Manager) Prune() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	backups, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	keep := retain(backups, m.opts.KeepDaily, m.opts.KeepWeekly)
+
+	var removed []string
+	for _, backup := range backups {
+		if keep[backup.Name] {
+			continue
+		}
+		if err := os.Remove(m.path(backup.Name)); err != nil {
+			return removed, err
+		}
+		os.Remove(m.path(backup.Name) + checksumExt)
+		removed = append(removed, backup.Name)
+	}
+	return removed, nil
+}
+
+// retain выбирает копии для хранения: самую новую копию за каждый из keepDaily последних дней
+// и за каждую из keepWeekly последних недель. Копии должны быть отсортированы от новых к старым
+func retain(backups []*domain.Backup, keepDaily, keepWeekly int) map[string]bool {
+	keep := make(map[string]bool)
+	if len(backups) > 0 {
+		keep[backups[0].Name] = true
+	}
+
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	for _, backup := range backups {
+		day := backup.CreatedAt.Format(time.DateOnly)
+		if !days[day] && len(days) < keepDaily {
+			days[day] = true
+			keep[backup.Name] = true
+		}
+		year, week := backup.CreatedAt.ISOWeek()
+		if key := fmt.Sprintf("%d-%02d", year, week); !weeks[key] && len(weeks) < keepWeekly {
+			weeks[key] = true
+			keep[backup.Name] = true
+		}
+	}
+	return keep
+}
+
+// Latest возвращает время создания самой новой копии (нулевое, если копий нет)
+func (m *Manager) Latest() (time.Time, error) {
+	backups, err := m.List()
+	if err != nil || len(backups) == 0 {
+		return time.Time{}, err
+	}
+	return backups[0].CreatedAt, nil
+}
+
+// find возвращает копию по имени. Имя проверяется по шаблону,
+// поэтому обратиться к файлам вне каталога копий нельзя
+func (m *Manager) find(name string) (*domain.Backup, error) {
+	if !namePattern.MatchString(name) {
+		return nil, ErrNotFound
+	}
+	backups, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, backup := range backups {
+		if backup.Name == name {
+			return backup, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// fileChecksum вычисляет SHA-256 файла
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readChecksum читает контрольную сумму копии из файла <копия>.sha256
+func (m *Manager) readChecksum(name string) (string, error) {
+	data, err := os.ReadFile(m.path(name) + checksumExt)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrChecksumMissing
+	}
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", ErrChecksumMissing
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// path возвращает путь к файлу в каталоге копий
+func (m *Manager) path(name string) string {
+	return filepath.Join(m.opts.Dir, name)
+}
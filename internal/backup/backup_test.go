@@ -0,0 +1,200 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/repository"
+)
+
+// newManager создает базу SQLite с одним пользователем и Manager для нее
+func newManager(t *testing.T, compress bool) (*Manager, *repository.Repositories, string) {
+	t.Helper()
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "users.db")
+	repos, db, err := repository.Open("sqlite:"+dbPath, logging.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := repos.UserRepository.Save(&domain.User{Username: "alice", Password: "hash", Role: "user"}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{Dir: filepath.Join(dir, "backups"), Compress: compress, KeepDaily: 7, KeepWeekly: 4}
+	return NewManager(db, dbPath, opts), repos, dbPath
+}
+
+func TestCreateAndVerify(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		manager, _, _ := newManager(t, compress)
+		created, err := manager.Create()
+		if err != nil {
+			t.Fatalf("Create(compress=%v): %v", compress, err)
+		}
+		if created.Compressed != compress || created.Checksum == "" || created.Size == 0 {
+			t.Errorf("Create(compress=%v) = %+v", compress, created)
+		}
+
+		backups, err := manager.List()
+		if err != nil || len(backups) != 1 || backups[0].Name != created.Name || backups[0].Checksum != created.Checksum {
+			t.Fatalf("List = %v, %v", backups, err)
+		}
+
+		verified, err := manager.Verify(created.Name)
+		if err != nil {
+			t.Fatalf("Verify(compress=%v): %v", compress, err)
+		}
+		if verified.SchemaVersion == 0 {
+			t.Error("Verify did not report the schema version")
+		}
+		entries, _ := os.ReadDir(manager.opts.Dir)
+		if len(entries) != 2 {
+			t.Errorf("backup directory contains %d files, want the backup and its checksum", len(entries))
+		}
+	}
+}
+
+func TestVerifyRejectsDamagedBackups(t *testing.T) {
+	manager, _, _ := newManager(t, true)
+	created, err := manager.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"missing.db", "../users.db", "helpbot-20200101-000000.db"} {
+		if _, err := manager.Verify(name); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Verify(%q) = %v, want ErrNotFound", name, err)
+		}
+	}
+
+	path := manager.path(created.Name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)/2] ^= 0xff
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.Verify(created.Name); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Verify of a modified backup = %v, want ErrChecksumMismatch", err)
+	}
+
+	os.Remove(path + checksumExt)
+	if _, err := manager.Verify(created.Name); !errors.Is(err, ErrChecksumMissing) {
+		t.Errorf("Verify without checksum = %v, want ErrChecksumMissing", err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	manager, repos, dbPath := newManager(t, true)
+	created, err := manager.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.UserRepository.Save(&domain.User{Username: "bob", Role: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	manager.db.Close()
+
+	// Незавершенный журнал текущей базы блокирует восстановление
+	if err := os.WriteFile(dbPath+"-journal", []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := manager.Restore(created.Name); !errors.Is(err, ErrDatabaseInUse) {
+		t.Errorf("Restore with a journal = %v, want ErrDatabaseInUse", err)
+	}
+	os.Remove(dbPath + "-journal")
+
+	restored, previous, err := manager.Restore(created.Name)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.Name != created.Name || previous == "" {
+		t.Errorf("Restore = %+v, %q", restored, previous)
+	}
+	if _, err := os.Stat(previous); err != nil {
+		t.Errorf("previous database was not kept: %v", err)
+	}
+
+	repos, db, err := repository.Open("sqlite:"+dbPath, logging.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if user, err := repos.UserRepository.GetByUsername("alice"); err != nil || user == nil {
+		t.Errorf("alice after restore = %v, %v", user, err)
+	}
+	if user, err := repos.UserRepository.GetByUsername("bob"); err != nil || user != nil {
+		t.Errorf("bob was created after the backup, got %v, %v", user, err)
+	}
+}
+
+func TestRetain(t *testing.T) {
+	// Копии каждые 12 часов за пять недель, от новых к старым
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	var backups []*domain.Backup
+	for i := 0; i < 70; i++ {
+		createdAt := now.Add(-time.Duration(i) * 12 * time.Hour)
+		backups = append(backups, &domain.Backup{Name: createdAt.Format(timeLayout), CreatedAt: createdAt})
+	}
+
+	var kept []string
+	keep := retain(backups, 3, 3)
+	for _, backup := range backups {
+		if keep[backup.Name] {
+			kept = append(kept, backup.Name)
+		}
+	}
+	// Три последних дня и последняя копия третьей недели: 2026-10-19 - понедельник,
+	// поэтому копии за 19 и 18 октября уже относятся к двум разным неделям
+	want := []string{"20261019-120000", "20261018-120000", "20261017-120000", "20261011-120000"}
+	if !reflect.DeepEqual(kept, want) {
+		t.Errorf("retain = %v, want %v", kept, want)
+	}
+
+	if keep := retain(backups, 0, 0); len(keep) != 1 || !keep[backups[0].Name] {
+		t.Errorf("retain without limits = %v, want only the newest backup", keep)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	manager, _, _ := newManager(t, false)
+	manager.opts.KeepDaily = 1
+	manager.opts.KeepWeekly = 0
+
+	// Старые копии за прошлые дни удаляются вместе с файлами контрольных сумм
+	if err := os.MkdirAll(manager.opts.Dir, dirPermissions); err != nil {
+		t.Fatal(err)
+	}
+	old := filePrefix + "20200101-000000" + fileExt
+	for _, name := range []string{old, old + checksumExt, "notes.txt"} {
+		if err := os.WriteFile(manager.path(name), []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	created, err := manager.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := manager.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(removed, []string{old}) {
+		t.Errorf("Prune removed %v, want [%s]", removed, old)
+	}
+	for name, exists := range map[string]bool{old: false, old + checksumExt: false, "notes.txt": true, created.Name: true} {
+		if _, err := os.Stat(manager.path(name)); (err == nil) != exists {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, exists)
+		}
+	}
+}
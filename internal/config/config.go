@@ -3,82 +3,296 @@ package config
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"fmt"
+	"log/slog"
 	"os"
+	"reflect"
 	"strconv"
+	"strings"
 	"time"
+
+	"HelpBot/internal/markup"
 )
 
-// Config содержит конфигурацию приложения
+// Config содержит конфигурацию приложения.
+//
+// Теги полей описывают схему конфигурации: config - ключ в файле, env - переменная окружения,
+// secret - секретное значение (маскируется при выводе, может читаться из файла через *_FILE),
+// unit - единица измерения для длительностей, заданных числом
 type Config struct {
-	TelegramToken string
-	DBPath        string
-	PollTimeout   time.Duration
-	MessagesLimit int
-	Debug         bool
-	JWTSecret     string        // Секретный ключ для JWT токенов
-	JWTExpiration time.Duration // Время жизни JWT токена
+	TelegramToken    string        `config:"bot_token" env:"BOT_TOKEN" secret:"true"`
+	DBPath           string        `config:"db_path" env:"DB_PATH"`
+	DBDSN            string        `config:"db_dsn" env:"DB_DSN" secret:"true"` // Строка подключения к БД: postgres://... или sqlite:<путь>
+	PollTimeout      time.Duration `config:"poll_timeout" env:"POLL_TIMEOUT" unit:"s"`
+	MessagesLimit    int           `config:"messages_limit" env:"MESSAGES_LIMIT"`
+	Debug            bool          `config:"debug" env:"DEBUG"`
+	JWTSecret        string        `config:"jwt_secret" env:"JWT_SECRET" secret:"true"`          // Секретный ключ для JWT токенов
+	JWTExpiration    time.Duration `config:"jwt_expiration" env:"JWT_EXPIRATION" unit:"h"`       // Время жизни JWT токена
+	RequireAdmin2FA  bool          `config:"require_admin_2fa" env:"REQUIRE_ADMIN_2FA"`          // Обязательная двухфакторная аутентификация для администраторов
+	MessageFormat    markup.Mode   `config:"message_format" env:"MESSAGE_FORMAT"`                // Разметка сообщений бота: HTML или MarkdownV2
+	LogLevel         slog.Level    `config:"log_level" env:"LOG_LEVEL"`                          // Минимальный уровень записей в логе
+	LogFormat        string        `config:"log_format" env:"LOG_FORMAT"`                        // Формат логов: text или json
+	DeletedRetention time.Duration `config:"deleted_retention" env:"DELETED_RETENTION" unit:"h"` // Срок хранения данных удаленных пользователей до обезличивания (0 - не обезличивать)
+	BackupDir        string        `config:"backup_dir" env:"BACKUP_DIR"`                        // Каталог резервных копий базы SQLite
+	BackupInterval   time.Duration `config:"backup_interval" env:"BACKUP_INTERVAL" unit:"h"`     // Интервал резервного копирования (0 - только по команде)
+	BackupKeepDaily  int           `config:"backup_keep_daily" env:"BACKUP_KEEP_DAILY"`          // Сколько последних дней хранить по одной копии
+	BackupKeepWeekly int           `config:"backup_keep_weekly" env:"BACKUP_KEEP_WEEKLY"`        // Сколько последних недель хранить по одной копии
+	BackupCompress   bool          `config:"backup_compress" env:"BACKUP_COMPRESS"`              // Сжимать резервные копии gzip
+	EventReminder    time.Duration `config:"event_reminder" env:"EVENT_REMINDER" unit:"h"`       // За сколько до начала события напоминать участникам (0 - не напоминать)
+	CheckInWindow    time.Duration `config:"checkin_window" env:"CHECKIN_WINDOW" unit:"h"`       // Сколько до и после начала события участники могут отметиться сами (0 - только отметки координатора)
+	AnnounceReminder time.Duration `config:"announce_reminder" env:"ANNOUNCE_REMINDER" unit:"h"` // Через сколько напоминать о непрочитанном объявлении (0 - не напоминать)
+
+	sources map[string]string // Источник значения каждого ключа: default, файл, env
 }
 
-// generateRandomKey генерирует случайный ключ заданной длины
-func generateRandomKey(length int) string {
-	bytes := make([]byte, length)
-	if _, err := rand.Read(bytes); err != nil {
-		panic(err)
+// Источники значений конфигурации
+const (
+	sourceDefault   = "default"
+	sourceGenerated = "generated"
+)
+
+// field описывает поле конфигурации по тегам структуры
+type field struct {
+	key    string
+	env    string
+	secret bool
+	unit   time.Duration
+	value  reflect.Value
+}
+
+// defaults возвращает конфигурацию со значениями по умолчанию
+func defaults() *Config {
+	return &Config{
+		DBPath:           "users.db",
+		PollTimeout:      60 * time.Second,
+		MessagesLimit:    100,
+		JWTExpiration:    24 * time.Hour,
+		MessageFormat:    markup.HTML,
+		LogLevel:         slog.LevelInfo,
+		LogFormat:        "text",
+		DeletedRetention: 30 * 24 * time.Hour,
+		BackupDir:        "backups",
+		BackupInterval:   24 * time.Hour,
+		BackupKeepDaily:  7,
+		BackupKeepWeekly: 4,
+		BackupCompress:   true,
+		EventReminder:    24 * time.Hour,
+		CheckInWindow:    2 * time.Hour,
+		AnnounceReminder: 24 * time.Hour,
+		sources:          make(map[string]string),
 	}
-	return base64.URLEncoding.EncodeToString(bytes)
 }
 
-// NewConfig создает экземпляр Config
-func NewConfig() *Config {
-	token := os.Getenv("BOT_TOKEN")
-	if token == "" {
-		token = "OurToken" // читать из env файлика
+// fields возвращает описание полей конфигурации
+func (c *Config) fields() []field {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	var result []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := sf.Tag.Get("config")
+		if key == "" {
+			continue
+		}
+		f := field{
+			key:    key,
+			env:    sf.Tag.Get("env"),
+			secret: sf.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		}
+		switch sf.Tag.Get("unit") {
+		case "s":
+			f.unit = time.Second
+		case "h":
+			f.unit = time.Hour
+		}
+		result = append(result, f)
 	}
+	return result
+}
 
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "users.db"
+// Load читает конфигурацию из файла (если путь задан аргументом или в CONFIG_FILE)
+// и переменных окружения и проверяет ее
+func Load(path string) (*Config, error) {
+	cfg, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
+	return cfg, nil
+}
 
-	// Проверяем режим отладки
-	debug := false
-	debugEnv := os.Getenv("DEBUG")
-	if debugEnv != "" {
-		debug, _ = strconv.ParseBool(debugEnv)
+// Read читает конфигурацию без проверки значений. Переменные окружения
+// имеют приоритет над файлом, файл - над значениями по умолчанию
+func Read(path string) (*Config, error) {
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
 	}
 
-	// Устанавливаем таймаут
-	timeout := 60 * time.Second
-	timeoutEnv := os.Getenv("POLL_TIMEOUT")
-	if timeoutEnv != "" {
-		if t, err := strconv.Atoi(timeoutEnv); err == nil {
-			timeout = time.Duration(t) * time.Second
+	cfg := defaults()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
 		}
 	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	// Режим отладки включает подробный лог, если уровень не задан явно
+	if cfg.Debug && cfg.sources["log_level"] == "" {
+		cfg.LogLevel = slog.LevelDebug
+		cfg.sources["log_level"] = "debug"
+	}
+
+	// Если секрет для JWT не задан, генерируем случайный ключ длиной 32 байта (256 бит).
+	// Токены, выданные до перезапуска, при этом перестанут действовать
+	if cfg.JWTSecret == "" {
+		cfg.JWTSecret = generateRandomKey(32)
+		cfg.sources["jwt_secret"] = sourceGenerated
+	}
+	return cfg, nil
+}
+
+// DatabaseDSN возвращает строку подключения к БД. Если она не задана,
+// используется SQLite с файлом из db_path
+func (c *Config) DatabaseDSN() string {
+	if c.DBDSN != "" {
+		return c.DBDSN
+	}
+	return "sqlite:" + c.DBPath
+}
+
+// JWTSecretGenerated проверяет, что секрет для JWT не был задан и сгенерирован при запуске
+func (c *Config) JWTSecretGenerated() bool {
+	return c.sources["jwt_secret"] == sourceGenerated
+}
+
+// loadEnv применяет переменные окружения. Для секретов вместо значения можно указать
+// путь к файлу с ним в переменной с суффиксом _FILE
+func (c *Config) loadEnv() error {
+	var errs []string
+	for _, f := range c.fields() {
+		value, ok := os.LookupEnv(f.env)
+		fileName, fromFile := os.LookupEnv(f.env + "_FILE")
+
+		switch {
+		case fromFile && !f.secret:
+			errs = append(errs, fmt.Sprintf("%s_FILE: indirection is only supported for secrets", f.env))
+			continue
+		case fromFile && ok:
+			errs = append(errs, fmt.Sprintf("%s and %s_FILE are both set, use only one of them", f.env, f.env))
+			continue
+		case fromFile:
+			secret, err := readSecret(fileName)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s_FILE: %v", f.env, err))
+				continue
+			}
+			value, ok = secret, true
+		}
+		if !ok {
+			continue
+		}
 
-	// Получаем секретный ключ для JWT
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		// Генерируем случайный ключ длиной 32 байта (256 бит)
-		jwtSecret = generateRandomKey(32)
+		if err := f.set(value); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", f.env, err))
+			continue
+		}
+		c.sources[f.key] = "env " + f.env
+		if fromFile {
+			c.sources[f.key] = "env " + f.env + "_FILE"
+		}
 	}
+	return joinErrors("invalid environment", errs)
+}
+
+// set разбирает строковое значение и записывает его в поле
+func (f field) set(value string) error {
+	value = strings.TrimSpace(value)
+	switch {
+	case f.value.Type() == reflect.TypeOf(markup.Mode("")):
+		mode, err := markup.ParseMode(value)
+		if err != nil {
+			return fmt.Errorf("must be one of html, markdownv2, plain")
+		}
+		f.value.Set(reflect.ValueOf(mode))
+
+	case f.value.Type() == reflect.TypeOf(slog.Level(0)):
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("must be one of debug, info, warn, error")
+		}
+		f.value.Set(reflect.ValueOf(level))
 
-	// Устанавливаем время жизни JWT токена (по умолчанию 24 часа)
-	jwtExpiration := 24 * time.Hour
-	jwtExpirationEnv := os.Getenv("JWT_EXPIRATION")
-	if jwtExpirationEnv != "" {
-		if exp, err := strconv.Atoi(jwtExpirationEnv); err == nil {
-			jwtExpiration = time.Duration(exp) * time.Hour
+	case f.value.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := parseDuration(value, f.unit)
+		if err != nil {
+			return err
 		}
+		f.value.SetInt(int64(d))
+
+	case f.value.Kind() == reflect.String:
+		f.value.SetString(value)
+
+	case f.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		f.value.SetBool(b)
+
+	case f.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		f.value.SetInt(int64(n))
+
+	default:
+		return fmt.Errorf("unsupported type %s", f.value.Type())
 	}
+	return nil
+}
 
-	return &Config{
-		TelegramToken: token,
-		DBPath:        dbPath,
-		PollTimeout:   timeout,
-		MessagesLimit: 100,
-		Debug:         debug,
-		JWTSecret:     jwtSecret,
-		JWTExpiration: jwtExpiration,
+// parseDuration разбирает длительность вида "90s" или "24h". Число без единицы
+// измерения трактуется в единицах поля для совместимости с прежним форматом переменных
+func parseDuration(value string, unit time.Duration) (time.Duration, error) {
+	if n, err := strconv.Atoi(value); err == nil && unit != 0 {
+		return time.Duration(n) * unit, nil
 	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q (examples: 30s, 24h)", value)
+	}
+	return d, nil
+}
+
+// readSecret читает секрет из файла, отбрасывая завершающий перевод строки
+func readSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// joinErrors объединяет ошибки в одну с перечнем всех проблем
+func joinErrors(title string, errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s:\n  - %s", title, strings.Join(errs, "\n  - "))
+}
+
+// generateRandomKey генерирует случайный ключ заданной длины
+func generateRandomKey(length int) string {
+	bytes := make([]byte, length)
+	if _, err := rand.Read(bytes); err != nil {
+		panic(err)
+	}
+	return base64.URLEncoding.EncodeToString(bytes)
 }
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// loadFile применяет значения из файла конфигурации в формате YAML или TOML.
+// Формат определяется по расширению файла. Для секретов вместо значения можно
+// указать путь к файлу с ним в ключе с суффиксом _file
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	values := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	source := "file " + path
+	known := make(map[string]bool)
+	var errs []string
+	for _, f := range c.fields() {
+		known[f.key] = true
+		value, ok := values[f.key]
+
+		fileKey := f.key + "_file"
+		if f.secret {
+			known[fileKey] = true
+			if fileName, fromFile := values[fileKey]; fromFile {
+				if ok {
+					errs = append(errs, fmt.Sprintf("%s and %s are both set, use only one of them", f.key, fileKey))
+					continue
+				}
+				secret, err := readSecret(fmt.Sprint(fileName))
+				if err != nil {
+					errs = append(errs, fmt.Sprintf("%s: %v", fileKey, err))
+					continue
+				}
+				value, ok = secret, true
+			}
+		}
+		if !ok || value == nil {
+			continue
+		}
+
+		if err := f.set(fmt.Sprint(value)); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", f.key, err))
+			continue
+		}
+		c.sources[f.key] = source
+	}
+
+	// Неизвестные ключи чаще всего означают опечатку, поэтому не пропускаем их молча
+	var unknown []string
+	for key := range values {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		errs = append(errs, fmt.Sprintf("%s: unknown key", key))
+	}
+
+	return joinErrors("invalid config file "+path, errs)
+}
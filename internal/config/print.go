@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"HelpBot/internal/markup"
+)
+
+// secretMask заменяет значения секретов при выводе конфигурации
+const secretMask = "********"
+
+// Print выводит действующую конфигурацию в формате YAML с указанием источника
+// каждого значения. Секреты маскируются
+func (c *Config) Print(w io.Writer) error {
+	for _, f := range c.fields() {
+		source := c.sources[f.key]
+		if source == "" {
+			source = sourceDefault
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s # %s\n", f.key, c.display(f), source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// display возвращает значение поля для вывода
+func (c *Config) display(f field) string {
+	switch value := f.value.Interface().(type) {
+	case string:
+		if f.secret && value != "" {
+			return secretMask
+		}
+		return strconv.Quote(value)
+	case markup.Mode:
+		if value == markup.Plain {
+			return "plain"
+		}
+		return string(value)
+	case slog.Level:
+		return strings.ToLower(value.String())
+	case time.Duration:
+		return value.String()
+	default:
+		return fmt.Sprint(value)
+	}
+}
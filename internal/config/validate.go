@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"HelpBot/internal/logging"
+)
+
+// Ограничения значений конфигурации
+const (
+	minJWTSecretLength = 16
+	maxPollTimeout     = 10 * time.Minute
+	maxMessagesLimit   = 100 // Ограничение Telegram Bot API для getUpdates
+)
+
+// tokenPattern описывает формат токена бота, выданного BotFather
+var tokenPattern = regexp.MustCompile(`^\d+:[\w-]+$`)
+
+// Validate проверяет значения конфигурации и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []string
+	invalid := func(key, env, format string, args ...any) {
+		errs = append(errs, fmt.Sprintf("%s (%s): %s", key, env, fmt.Sprintf(format, args...)))
+	}
+
+	switch {
+	case c.TelegramToken == "":
+		invalid("bot_token", "BOT_TOKEN", "is required")
+	case !tokenPattern.MatchString(c.TelegramToken):
+		invalid("bot_token", "BOT_TOKEN", "must look like 123456:ABC-DEF...")
+	}
+
+	switch {
+	case c.DBDSN != "" && !isSupportedDSN(c.DBDSN):
+		invalid("db_dsn", "DB_DSN", "must start with postgres://, postgresql:// or sqlite:")
+	case c.DBDSN == "" && c.DBPath == "":
+		invalid("db_path", "DB_PATH", "is required when db_dsn is not set")
+	}
+
+	if c.PollTimeout <= 0 || c.PollTimeout > maxPollTimeout {
+		invalid("poll_timeout", "POLL_TIMEOUT", "must be between 1s and %s, got %s", maxPollTimeout, c.PollTimeout)
+	}
+
+	if c.MessagesLimit < 1 || c.MessagesLimit > maxMessagesLimit {
+		invalid("messages_limit", "MESSAGES_LIMIT", "must be between 1 and %d, got %d", maxMessagesLimit, c.MessagesLimit)
+	}
+
+	if c.sources["jwt_secret"] != sourceGenerated && len(c.JWTSecret) < minJWTSecretLength {
+		invalid("jwt_secret", "JWT_SECRET", "must be at least %d characters long", minJWTSecretLength)
+	}
+
+	if c.JWTExpiration <= 0 {
+		invalid("jwt_expiration", "JWT_EXPIRATION", "must be positive, got %s", c.JWTExpiration)
+	}
+
+	if c.LogFormat != logging.FormatText && c.LogFormat != logging.FormatJSON {
+		invalid("log_format", "LOG_FORMAT", "must be text or json, got %q", c.LogFormat)
+	}
+
+	if c.DeletedRetention < 0 {
+		invalid("deleted_retention", "DELETED_RETENTION", "must not be negative, got %s", c.DeletedRetention)
+	}
+
+	if c.BackupDir == "" {
+		invalid("backup_dir", "BACKUP_DIR", "is required")
+	}
+	if c.BackupInterval < 0 {
+		invalid("backup_interval", "BACKUP_INTERVAL", "must not be negative, got %s", c.BackupInterval)
+	}
+	if c.BackupKeepDaily < 1 {
+		invalid("backup_keep_daily", "BACKUP_KEEP_DAILY", "must be at least 1, got %d", c.BackupKeepDaily)
+	}
+	if c.BackupKeepWeekly < 0 {
+		invalid("backup_keep_weekly", "BACKUP_KEEP_WEEKLY", "must not be negative, got %d", c.BackupKeepWeekly)
+	}
+
+	if c.EventReminder < 0 {
+		invalid("event_reminder", "EVENT_REMINDER", "must not be negative, got %s", c.EventReminder)
+	}
+	if c.CheckInWindow < 0 {
+		invalid("checkin_window", "CHECKIN_WINDOW", "must not be negative, got %s", c.CheckInWindow)
+	}
+	if c.AnnounceReminder < 0 {
+		invalid("announce_reminder", "ANNOUNCE_REMINDER", "must not be negative, got %s", c.AnnounceReminder)
+	}
+
+	return joinErrors("invalid configuration", errs)
+}
+
+// isSupportedDSN проверяет, что строка подключения относится к поддерживаемому хранилищу
+func isSupportedDSN(dsn string) bool {
+	for _, prefix := range []string{"postgres://", "postgresql://", "sqlite:"} {
+		if strings.HasPrefix(dsn, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package telegram
+
+import (
+	"log/slog"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/markup"
+)
+
+// accountCommands сопоставляет команды управления учетными записями с новым состоянием
+var accountCommands = map[string]domain.UserStatus{
+	"suspend":    domain.UserSuspended,
+	"deactivate": domain.UserDeactivated,
+	"deleteuser": domain.UserDeleted,
+	"restore":    domain.UserActive,
+}
+
+// AccountHandler обрабатывает приостановку, деактивацию, удаление и восстановление учетных записей
+type AccountHandler struct {
+	client         *telegram.Client
+	sessionService domain.SessionService
+	logger         *slog.Logger
+}
+
+// NewAccountHandler создает новый экземпляр AccountHandler
+func NewAccountHandler(client *telegram.Client, sessionService domain.SessionService, logger *slog.Logger) *AccountHandler {
+	return &AccountHandler{
+		client:         client,
+		sessionService: sessionService,
+		logger:         logger,
+	}
+}
+
+// HandleStatusCommand обрабатывает команды /suspend, /deactivate, /deleteuser <имя пользователя> [причина]
+// и /restore <имя пользователя>
+func (h *AccountHandler) HandleStatusCommand(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	status := accountCommands[message.Command()]
+	username, reason, _ := strings.Cut(strings.TrimSpace(message.CommandArguments()), " ")
+	reason = strings.TrimSpace(reason)
+	if username == "" || (status == domain.UserActive && reason != "") {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "account.usage"))
+	}
+
+	user, err := h.sessionService.ChangeStatus(session.User.ID, username, status, reason)
+	if err != nil {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "account.status_failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+	return h.client.SendText(message.Chat.ID, i18n.M(lang, "account.status_changed", i18n.P{
+		"username": user.Username,
+		"status":   i18n.T(lang, "status."+string(status)),
+	}))
+}
+
+// blockedText формирует сообщение для пользователя, чья учетная запись заблокирована
+func blockedText(lang i18n.Lang, user *domain.User) markup.Text {
+	text := i18n.M(lang, "account.blocked", i18n.P{"status": i18n.T(lang, "status."+string(user.Status))})
+	if user.StatusReason == "" {
+		return text
+	}
+	return markup.Join("\n", text, i18n.M(lang, "account.blocked_reason", i18n.P{"reason": user.StatusReason}))
+}
@@ -0,0 +1,257 @@
+package telegram
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/markup"
+)
+
+// AnnouncementHandler обрабатывает объявления команды: рассылку с кнопкой «Прочитано»,
+// закрепление в группе, напоминания непрочитавшим и ход прочтения. Если к команде привязана
+// группа (/team group), объявление кроме рассылки участникам публикуется и закрепляется
+// в ней, из какого бы чата его ни создали
+type AnnouncementHandler struct {
+	client              *telegram.Client
+	announcementService domain.AnnouncementService
+	logger              *slog.Logger
+}
+
+// NewAnnouncementHandler создает новый экземпляр AnnouncementHandler
+func NewAnnouncementHandler(client *telegram.Client, announcementService domain.AnnouncementService, logger *slog.Logger) *AnnouncementHandler {
+	return &AnnouncementHandler{
+		client:              client,
+		announcementService: announcementService,
+		logger:              logger,
+	}
+}
+
+// HandleAnnounceCommand обрабатывает команды /announce, /announce <номер>,
+// /announce add <текст> и /announce close <номер>
+func (h *AnnouncementHandler) HandleAnnounceCommand(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	arguments := strings.TrimSpace(message.CommandArguments())
+	command, rest, _ := strings.Cut(arguments, " ")
+	failed := func(err error) error {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "announce.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+	actorID := session.User.ID
+
+	switch {
+	case arguments == "":
+		announcements, err := h.announcementService.Announcements(actorID)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, announcementsText(lang, announcements))
+
+	case command == "add" && strings.TrimSpace(rest) != "":
+		announcement, recipients, err := h.announcementService.Create(actorID, rest)
+		if err != nil {
+			return failed(err)
+		}
+		sent := 0
+		for _, user := range recipients {
+			if h.send(user, announcement, "announce.message") {
+				sent++
+			}
+		}
+		if announcement.ChatID != 0 {
+			h.publish(announcement.ChatID, lang, announcement)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.MN(lang, "announce.created", sent, i18n.P{
+			"id":         announcement.ID,
+			"recipients": len(recipients),
+		}))
+
+	case command == "close":
+		announcementID, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(rest), "#"), 10, 64)
+		if err != nil {
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "announce.usage"))
+		}
+		announcement, err := h.announcementService.Close(actorID, announcementID)
+		if err != nil {
+			return failed(err)
+		}
+		if announcement.MessageID != 0 {
+			if err := h.client.UnpinMessage(announcement.ChatID, announcement.MessageID); err != nil {
+				h.logger.Warn("Error unpinning announcement", "announcement_id", announcement.ID, "chat_id", announcement.ChatID, logging.Err(err))
+			}
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "announce.closed", progressParams(announcement)))
+
+	default:
+		announcementID, err := strconv.ParseInt(strings.TrimPrefix(arguments, "#"), 10, 64)
+		if err != nil {
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "announce.usage"))
+		}
+		announcement, receipts, err := h.announcementService.Progress(actorID, announcementID)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, progressText(lang, announcement, receipts))
+	}
+}
+
+// HandleAcknowledgeCallback отмечает объявление прочитанным. В личном чате кнопка
+// убирается из сообщения, а в группе остается для остальных участников
+func (h *AnnouncementHandler) HandleAcknowledgeCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, param string) (string, error) {
+	lang := sessionLang(session, callback.From)
+	announcementID, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+	announcement, already, err := h.announcementService.Acknowledge(session.User.ID, announcementID)
+	if err != nil {
+		return "", err
+	}
+
+	if callback.Message.Chat.IsPrivate() {
+		text := markup.Join("\n", announcementText(lang, announcement), markup.Raw(""), i18n.M(lang, "announce.acknowledged"))
+		if err := h.client.EditText(callback.Message.Chat.ID, callback.Message.MessageID, text); err != nil {
+			h.logger.Warn("Error editing announcement message", "announcement_id", announcement.ID, logging.Err(err))
+		}
+	}
+	if already {
+		return i18n.T(lang, "announce.already_acknowledged"), nil
+	}
+	return i18n.T(lang, "announce.acknowledged"), nil
+}
+
+// Run напоминает получателям о непрочитанных объявлениях сразу и затем с указанным
+// интервалом, пока не отменен контекст
+func (h *AnnouncementHandler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		reminders, err := h.announcementService.DueReminders(time.Now())
+		if err != nil {
+			h.logger.Error("Error collecting announcement reminders", logging.Err(err))
+		}
+		for _, reminder := range reminders {
+			sent := 0
+			for _, user := range reminder.Users {
+				if h.send(user, reminder.Announcement, "announce.reminder") {
+					sent++
+				}
+			}
+			h.logger.Info("Announcement reminder sent", "announcement_id", reminder.Announcement.ID, "recipients", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// send отправляет пользователю объявление с кнопкой «Прочитано» на его языке
+// и сообщает, удалось ли его доставить
+func (h *AnnouncementHandler) send(user *domain.User, announcement *domain.Announcement, key string) bool {
+	if user.ChatID == 0 {
+		return false
+	}
+	lang := userLang(user)
+	text := markup.Join("\n", i18n.M(lang, key), announcementText(lang, announcement))
+	if err := h.client.SendTextWithKeyboard(user.ChatID, text, h.client.GetAnnouncementKeyboard(lang, announcement.ID)); err != nil {
+		h.logger.Warn("Error sending announcement", "announcement_id", announcement.ID, "user_id", user.ID, "message", key, logging.Err(err))
+		return false
+	}
+	return true
+}
+
+// publish публикует объявление в группе команды и закрепляет его. Ошибки закрепления
+// (например, у бота нет прав администратора группы) только записываются в лог
+func (h *AnnouncementHandler) publish(chatID int64, lang i18n.Lang, announcement *domain.Announcement) {
+	messageID, err := h.client.SendTextWithKeyboardID(chatID, announcementText(lang, announcement), h.client.GetAnnouncementKeyboard(lang, announcement.ID))
+	if err != nil {
+		h.logger.Warn("Error publishing announcement", "announcement_id", announcement.ID, "chat_id", chatID, logging.Err(err))
+		return
+	}
+	if err := h.client.PinMessage(chatID, messageID); err != nil {
+		h.logger.Warn("Error pinning announcement", "announcement_id", announcement.ID, "chat_id", chatID, logging.Err(err))
+		return
+	}
+	if err := h.announcementService.SetMessage(announcement.ID, chatID, messageID); err != nil {
+		h.logger.Warn("Error saving announcement message", "announcement_id", announcement.ID, "chat_id", chatID, logging.Err(err))
+	}
+}
+
+// announcementText формирует текст объявления
+func announcementText(lang i18n.Lang, announcement *domain.Announcement) markup.Text {
+	return i18n.M(lang, "announce.card", i18n.P{"id": announcement.ID, "text": announcement.Text})
+}
+
+// progressParams возвращает ход прочтения объявления для текста
+func progressParams(announcement *domain.Announcement) i18n.P {
+	return i18n.P{
+		"id":           announcement.ID,
+		"acknowledged": announcement.Acknowledged,
+		"recipients":   announcement.Recipients,
+		"percent":      announcement.Percent(),
+	}
+}
+
+// announcementsText формирует список действующих объявлений с ходом прочтения
+func announcementsText(lang i18n.Lang, announcements []*domain.Announcement) markup.Text {
+	if len(announcements) == 0 {
+		return i18n.M(lang, "announce.none")
+	}
+	lines := []markup.Text{i18n.M(lang, "announce.title")}
+	for _, announcement := range announcements {
+		params := progressParams(announcement)
+		params["date"] = formatDate(lang, announcement.CreatedAt)
+		params["text"] = announcementPreview(announcement.Text)
+		lines = append(lines, i18n.M(lang, "announce.entry", params))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// progressText формирует объявление со списками прочитавших и непрочитавших получателей
+func progressText(lang i18n.Lang, announcement *domain.Announcement, receipts []*domain.AnnouncementReceipt) markup.Text {
+	key := "announce.progress"
+	if !announcement.Active {
+		key = "announce.progress_closed"
+	}
+	lines := []markup.Text{announcementText(lang, announcement), markup.Raw(""), i18n.M(lang, key, progressParams(announcement))}
+
+	var pending []string
+	for _, receipt := range receipts {
+		if !receipt.Acknowledged() {
+			pending = append(pending, receipt.Username)
+			continue
+		}
+		lines = append(lines, i18n.M(lang, "announce.progress_entry", i18n.P{
+			"username": receipt.Username,
+			"time":     formatTime(lang, receipt.AcknowledgedAt.Local()),
+		}))
+	}
+	if len(pending) > 0 {
+		lines = append(lines, markup.Raw(""), i18n.M(lang, "announce.progress_pending", i18n.P{"users": strings.Join(pending, ", ")}))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// announcementPreview возвращает начало текста объявления для списка
+func announcementPreview(text string) string {
+	const limit = 50
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > limit {
+		return string(runes[:limit]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,228 @@
+package telegram
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/markup"
+)
+
+// attendancePeriod - период статистики посещаемости по умолчанию, в днях
+const attendancePeriod = 30
+
+// AttendanceHandler обрабатывает учет посещаемости: отметки координатора, самостоятельные
+// отметки участников, статистику команды и историю посещений
+type AttendanceHandler struct {
+	client            *telegram.Client
+	attendanceService domain.AttendanceService
+	logger            *slog.Logger
+}
+
+// NewAttendanceHandler создает новый экземпляр AttendanceHandler
+func NewAttendanceHandler(client *telegram.Client, attendanceService domain.AttendanceService, logger *slog.Logger) *AttendanceHandler {
+	return &AttendanceHandler{
+		client:            client,
+		attendanceService: attendanceService,
+		logger:            logger,
+	}
+}
+
+// HandleAttendanceCommand обрабатывает команды /attendance [ГГГГ-ММ-ДД [ГГГГ-ММ-ДД]],
+// /attendance stats [ГГГГ-ММ-ДД [ГГГГ-ММ-ДД]] и /attendance <номер события>
+func (h *AttendanceHandler) HandleAttendanceCommand(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	args := strings.Fields(message.CommandArguments())
+	failed := func(err error) error {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "attendance.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+
+	if len(args) == 1 {
+		if eventID, err := strconv.ParseInt(args[0], 10, 64); err == nil {
+			event, marks, err := h.attendanceService.EventAttendance(session.User.ID, eventID)
+			if err != nil {
+				return failed(err)
+			}
+			return h.client.SendTextWithKeyboard(message.Chat.ID, markText(lang, event, marks), h.client.GetAttendanceKeyboard(event.ID, marks))
+		}
+	}
+
+	stats := len(args) > 0 && args[0] == "stats"
+	if stats {
+		args = args[1:]
+	}
+	// Вместо даты начала периода передано слово: показываем справку
+	if len(args) > 2 || len(args) > 0 && (args[0][0] < '0' || args[0][0] > '9') {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "attendance.usage", i18n.P{"days": attendancePeriod}))
+	}
+	from, to, err := parsePeriod(args, time.Now())
+	if err != nil {
+		return failed(err)
+	}
+
+	if stats {
+		rates, err := h.attendanceService.Rates(session.User.ID, from, to)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, ratesText(lang, rates, from, to))
+	}
+	entries, err := h.attendanceService.History(session.User.ID, from, to)
+	if err != nil {
+		return failed(err)
+	}
+	return h.client.SendText(message.Chat.ID, historyText(lang, entries, from, to))
+}
+
+// HandleMarkCallback меняет отметку участника и обновляет сообщение со списком отметок
+func (h *AttendanceHandler) HandleMarkCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, param string) (string, error) {
+	lang := sessionLang(session, callback.From)
+	parts := strings.Split(param, ":")
+	if len(parts) != 3 {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+	eventID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+	userID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+
+	mark, err := h.attendanceService.Mark(session.User.ID, eventID, userID, domain.AttendanceStatus(parts[2]))
+	if err != nil {
+		return "", err
+	}
+	event, marks, err := h.attendanceService.EventAttendance(session.User.ID, eventID)
+	if err != nil {
+		return "", err
+	}
+	text := markText(lang, event, marks)
+	if err := h.client.EditTextWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, h.client.GetAttendanceKeyboard(event.ID, marks)); err != nil {
+		h.logger.Warn("Error editing attendance message", "event_id", eventID, logging.Err(err))
+	}
+	return i18n.T(lang, "attendance.marked", i18n.P{
+		"username": mark.Username,
+		"status":   i18n.T(lang, "attendance.status_"+string(mark.Status)),
+	}), nil
+}
+
+// HandleCheckInCallback отмечает присутствие участника на событии по его собственной отметке
+func (h *AttendanceHandler) HandleCheckInCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, param string) (string, error) {
+	lang := sessionLang(session, callback.From)
+	eventID, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+	event, already, err := h.attendanceService.CheckIn(session.User.ID, eventID)
+	if err != nil {
+		return "", err
+	}
+	if already {
+		return i18n.T(lang, "attendance.already_checked_in", i18n.P{"title": event.Title}), nil
+	}
+	return i18n.T(lang, "attendance.checked_in", i18n.P{"title": event.Title}), nil
+}
+
+// markText формирует карточку события с числом отмеченных участников
+func markText(lang i18n.Lang, event *domain.Event, marks []*domain.Attendance) markup.Text {
+	present := 0
+	for _, mark := range marks {
+		if mark.Status == domain.AttendancePresent {
+			present++
+		}
+	}
+	return markup.Join("\n", eventText(lang, event), markup.Raw(""),
+		i18n.M(lang, "attendance.card", i18n.P{"present": present, "total": len(marks)}))
+}
+
+// ratesText формирует статистику посещаемости участников команды за период
+func ratesText(lang i18n.Lang, rates []*domain.AttendanceRate, from, to time.Time) markup.Text {
+	lines := []markup.Text{i18n.M(lang, "attendance.rates_title", periodParams(lang, from, to))}
+	tracked := false
+	for _, rate := range rates {
+		if rate.Events == 0 {
+			continue
+		}
+		tracked = true
+		lines = append(lines, i18n.M(lang, "attendance.rate_entry", i18n.P{
+			"username": rate.User.Username,
+			"attended": rate.Attended,
+			"events":   rate.Events,
+			"percent":  rate.Percent(),
+		}))
+	}
+	if !tracked {
+		lines = append(lines, i18n.M(lang, "attendance.rates_none"))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// historyText формирует историю посещений пользователя за период
+func historyText(lang i18n.Lang, entries []*domain.AttendanceEntry, from, to time.Time) markup.Text {
+	lines := []markup.Text{i18n.M(lang, "attendance.history_title", periodParams(lang, from, to))}
+	if len(entries) == 0 {
+		lines = append(lines, i18n.M(lang, "attendance.history_none"))
+		return markup.Join("\n", lines...)
+	}
+
+	rate := domain.AttendanceRate{Events: len(entries)}
+	for _, entry := range entries {
+		status := entry.Status
+		if status == "" {
+			status = "none"
+		}
+		if entry.Status == domain.AttendancePresent {
+			rate.Attended++
+		}
+		lines = append(lines, i18n.M(lang, "attendance.history_entry", i18n.P{
+			"id":     entry.Event.ID,
+			"title":  entry.Event.Title,
+			"time":   formatTime(lang, entry.Event.StartsAt.Local()),
+			"status": i18n.T(lang, "attendance.status_"+string(status)),
+		}))
+	}
+	lines = append(lines, markup.Raw(""), i18n.M(lang, "attendance.history_total", i18n.P{
+		"attended": rate.Attended,
+		"events":   rate.Events,
+		"percent":  rate.Percent(),
+	}))
+	return markup.Join("\n", lines...)
+}
+
+// periodParams возвращает границы периода для текста; дата окончания показывается включительно
+func periodParams(lang i18n.Lang, from, to time.Time) i18n.P {
+	return i18n.P{"from": formatDate(lang, from), "to": formatDate(lang, to.AddDate(0, 0, -1))}
+}
+
+// parsePeriod разбирает необязательные даты начала и окончания периода вида 2006-01-02.
+// По умолчанию период охватывает последние дни до сегодняшнего включительно
+func parsePeriod(args []string, now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from, to := today.AddDate(0, 0, -attendancePeriod+1), today
+	for i, value := range args {
+		date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, i18n.NewError("error.filter_date", i18n.P{"value": value})
+		}
+		if i == 0 {
+			from = date
+		} else {
+			to = date
+		}
+	}
+	// Дата окончания включается в период целиком
+	return from, to.AddDate(0, 0, 1), nil
+}
@@ -0,0 +1,147 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/markup"
+)
+
+// auditPageSize определяет количество записей журнала, показываемых в чате
+const auditPageSize = 20
+
+// AuditHandler обрабатывает просмотр и выгрузку журнала аудита
+type AuditHandler struct {
+	client       *telegram.Client
+	userService  domain.UserService
+	roleService  domain.RoleService
+	auditService domain.AuditService
+	logger       *slog.Logger
+}
+
+// NewAuditHandler создает новый экземпляр AuditHandler
+func NewAuditHandler(client *telegram.Client, userService domain.UserService, roleService domain.RoleService, auditService domain.AuditService, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		client:       client,
+		userService:  userService,
+		roleService:  roleService,
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// HandleAudit показывает последние записи журнала аудита по фильтрам из аргументов команды
+func (h *AuditHandler) HandleAudit(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized || !h.roleService.Can(session.User, domain.PermViewAudit) {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.forbidden"))
+	}
+
+	usage := i18n.M(lang, "audit.usage")
+	filter, err := h.parseFilter(message.CommandArguments())
+	if err != nil {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "audit.filter_failed", i18n.P{"error": errorText(h.logger, lang, err), "usage": usage}))
+	}
+	filter.Limit = auditPageSize
+
+	entries, err := h.auditService.Find(filter)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "audit.empty", i18n.P{"usage": usage}))
+	}
+
+	lines := []markup.Text{i18n.MN(lang, "audit.title", len(entries)), markup.Raw("")}
+	for _, entry := range entries {
+		parts := []markup.Text{i18n.M(lang, "audit.entry", i18n.P{
+			"time":   formatTime(lang, entry.CreatedAt),
+			"action": entry.Action,
+		})}
+		if entry.ActorID != 0 {
+			parts = append(parts, i18n.M(lang, "audit.actor", i18n.P{"name": h.auditService.Username(entry.ActorID)}))
+		} else if entry.TelegramID != 0 {
+			parts = append(parts, i18n.M(lang, "audit.telegram", i18n.P{"id": entry.TelegramID}))
+		}
+		if entry.TargetID != 0 && entry.TargetID != entry.ActorID {
+			parts = append(parts, i18n.M(lang, "audit.target", i18n.P{"name": h.auditService.Username(entry.TargetID)}))
+		}
+		if entry.Details != "" {
+			parts = append(parts, i18n.M(lang, "audit.details", i18n.P{"details": entry.Details}))
+		}
+		lines = append(lines, markup.Join("", parts...))
+	}
+	lines = append(lines, markup.Raw(""), usage)
+
+	return h.client.SendText(message.Chat.ID, markup.Join("\n", lines...))
+}
+
+// HandleAuditExport выгружает журнал аудита в CSV по фильтрам из аргументов команды
+func (h *AuditHandler) HandleAuditExport(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized || !h.roleService.Can(session.User, domain.PermViewAudit) {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.forbidden"))
+	}
+
+	filter, err := h.parseFilter(message.CommandArguments())
+	if err != nil {
+		usage := i18n.M(lang, "audit.usage")
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "audit.filter_failed", i18n.P{"error": errorText(h.logger, lang, err), "usage": usage}))
+	}
+
+	data, err := h.auditService.ExportCSV(filter)
+	if err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("audit_%s.csv", time.Now().Format("20060102_150405"))
+	return h.client.SendDocument(message.Chat.ID, fileName, data, i18n.T(lang, "audit.file_caption"))
+}
+
+// parseFilter разбирает фильтры вида key=value
+func (h *AuditHandler) parseFilter(args string) (domain.AuditFilter, error) {
+	var filter domain.AuditFilter
+	for _, arg := range strings.Fields(args) {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || value == "" {
+			return filter, i18n.NewError("error.filter_invalid", i18n.P{"filter": arg})
+		}
+
+		switch key {
+		case "user":
+			user, err := h.userService.GetUserByUsername(value)
+			if err != nil {
+				return filter, err
+			}
+			if user == nil {
+				return filter, i18n.NewError("error.filter_user_not_found", i18n.P{"username": value})
+			}
+			filter.UserID = user.ID
+		case "action":
+			filter.Action = value
+		case "from":
+			from, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				return filter, i18n.NewError("error.filter_date", i18n.P{"value": value})
+			}
+			filter.From = from
+		case "to":
+			to, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				return filter, i18n.NewError("error.filter_date", i18n.P{"value": value})
+			}
+			// Дата окончания включается в период целиком
+			filter.To = to.AddDate(0, 0, 1)
+		default:
+			return filter, i18n.NewError("error.filter_unknown", i18n.P{"filter": key})
+		}
+	}
+	return filter, nil
+}
@@ -1,45 +1,73 @@
 package telegram
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
 	"HelpBot/client/telegram"
 	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
 )
 
+// notifyPageSize определяет размер страницы при переборе пользователей для уведомлений
+const notifyPageSize = 100
+
 // AuthHandler обрабатывает команды авторизации
 type AuthHandler struct {
 	client         *telegram.Client
 	sessionService domain.SessionService
-	userService    domain.UserService
+	userService      domain.UserService
+	roleService      domain.RoleService
+	twoFactorService domain.TwoFactorService
+	logger           *slog.Logger
 }
 
 // NewAuthHandler создает новый экземпляр AuthHandler
-func NewAuthHandler(client *telegram.Client, sessionService domain.SessionService, userService domain.UserService) *AuthHandler {
+func NewAuthHandler(client *telegram.Client, sessionService domain.SessionService, userService domain.UserService, roleService domain.RoleService, twoFactorService domain.TwoFactorService, logger *slog.Logger) *AuthHandler {
 	return &AuthHandler{
-		client:         client,
-		sessionService: sessionService,
-		userService:    userService,
+		client:           client,
+		sessionService:   sessionService,
+		userService:      userService,
+		roleService:      roleService,
+		twoFactorService: twoFactorService,
+		logger:           logger,
 	}
 }
 
+// mainMenuKeyboard возвращает главное меню с пунктами, доступными пользователю
+func (h *AuthHandler) mainMenuKeyboard(telegramID int64, lang i18n.Lang) (tgbotapi.ReplyKeyboardMarkup, error) {
+	session, err := h.sessionService.GetSession(telegramID)
+	if err != nil {
+		return tgbotapi.ReplyKeyboardMarkup{}, err
+	}
+
+	var user *domain.User
+	if session != nil && session.IsAuthorized {
+		user = session.User
+	}
+	return h.client.GetMainMenuKeyboard(lang, func(permission domain.Permission) bool {
+		return h.roleService.Can(user, permission)
+	}), nil
+}
+
 // HandleStart обрабатывает команду /start
 func (h *AuthHandler) HandleStart(message *tgbotapi.Message) error {
 	// Получаем сессию пользователя
-	session, err := h.sessionService.GetSession(message.Chat.ID)
+	session, err := h.sessionService.GetSession(message.From.ID)
 	if err != nil {
 		return err
 	}
 
-	// Если пользователь не существует, создаем его
+	// Если пользователь не найден, создаем сессию без сохранения в базу:
+	// пользователь появится в базе только после регистрации
 	if session == nil {
 		user := h.client.GetUserFromMessage(message)
-		if err := h.userService.SaveUser(user); err != nil {
-			return err
-		}
 
 		// Создаем новую сессию
 		session = &domain.UserSession{
@@ -50,40 +78,48 @@ func (h *AuthHandler) HandleStart(message *tgbotapi.Message) error {
 	} else {
 		// Сбрасываем состояние существующей сессии
 		session.State = domain.StateNone
-		// Сбрасываем имя пользователя в сессии, чтобы начать процесс заново
-		session.User.Username = ""
+		// Сбрасываем имя пользователя в сессии неавторизованного пользователя, чтобы начать процесс заново
+		if !session.IsAuthorized {
+			session.User.Username = ""
+		}
 		// Сбрасываем последнюю команду
 		session.LastCommand = ""
 	}
 
 	// Обновляем сессию
-	if err := h.sessionService.UpdateSession(message.Chat.ID, session); err != nil {
+	if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
 		return err
 	}
+	lang := sessionLang(session, message.From)
 
 	// Если пользователь уже авторизован, показываем главное меню
 	if session.IsAuthorized {
-		isAdmin, err := h.sessionService.IsAdmin(message.Chat.ID)
+		keyboard, err := h.mainMenuKeyboard(message.From.ID, lang)
 		if err != nil {
 			return err
 		}
 
-		keyboard := h.client.GetMainMenuKeyboard(isAdmin)
-		return h.client.SendMessageWithKeyboard(message.Chat.ID, "Добро пожаловать! Выберите действие:", keyboard)
+		return h.client.SendTextWithKeyboard(message.Chat.ID, i18n.M(lang, "start.welcome_authorized"), keyboard)
 	}
 
 	// Если пользователь не авторизован, показываем меню авторизации
-	keyboard := h.client.GetLoginKeyboard()
-	return h.client.SendMessageWithKeyboard(message.Chat.ID, "Добро пожаловать! Для начала работы необходимо авторизоваться:", keyboard)
+	keyboard := h.client.GetLoginKeyboard(lang)
+	return h.client.SendTextWithKeyboard(message.Chat.ID, i18n.M(lang, "start.welcome"), keyboard)
 }
 
 // HandleLogin обрабатывает процесс входа в систему
 func (h *AuthHandler) HandleLogin(message *tgbotapi.Message) error {
 	// Получаем сессию пользователя
-	session, err := h.sessionService.GetSession(message.Chat.ID)
+	session, err := h.sessionService.GetSession(message.From.ID)
 	if err != nil {
 		return err
 	}
+	lang := sessionLang(session, message.From)
+
+	// Пароли вводятся только в личном чате с ботом
+	if !message.Chat.IsPrivate() {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "auth.private_only"))
+	}
 	if session == nil {
 		// Если сессия не существует, создаем новую
 		user := h.client.GetUserFromMessage(message)
@@ -92,7 +128,7 @@ func (h *AuthHandler) HandleLogin(message *tgbotapi.Message) error {
 			State:       domain.StateNone,
 			LastCommand: "login", // Устанавливаем последнюю команду как "login"
 		}
-		if err := h.sessionService.UpdateSession(message.Chat.ID, session); err != nil {
+		if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
 			return err
 		}
 	}
@@ -100,15 +136,15 @@ func (h *AuthHandler) HandleLogin(message *tgbotapi.Message) error {
 	// В зависимости от текущего состояния сессии
 	switch session.State {
 	case domain.StateNone:
-		// Если текст сообщения "Войти", начинаем процесс входа
-		if message.Text == "Войти" {
+		// Если нажата кнопка входа, начинаем процесс входа
+		if buttonKey(message.Text) == telegram.BtnLogin {
 			// Запрашиваем имя пользователя
 			session.State = domain.StateAwaitingUsername
 			session.LastCommand = "login" // Устанавливаем последнюю команду как "login"
-			if err := h.sessionService.UpdateSession(message.Chat.ID, session); err != nil {
+			if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
 				return err
 			}
-			return h.client.SendMessage(message.Chat.ID, "Введите имя пользователя:")
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "auth.enter_username"))
 		}
 		return nil
 
@@ -116,7 +152,7 @@ func (h *AuthHandler) HandleLogin(message *tgbotapi.Message) error {
 		// Сохраняем имя пользователя и запрашиваем пароль
 		username := message.Text
 		if username == "" {
-			return h.client.SendMessage(message.Chat.ID, "Имя пользователя не может быть пустым. Попробуйте еще раз:")
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "auth.username_empty"))
 		}
 
 		// Проверяем, существует ли пользователь с таким именем
@@ -127,40 +163,54 @@ func (h *AuthHandler) HandleLogin(message *tgbotapi.Message) error {
 
 		if existingUser == nil {
 			// Если пользователь не существует, предлагаем зарегистрироваться
-			keyboard := h.client.GetLoginKeyboard()
-			return h.client.SendMessageWithKeyboard(message.Chat.ID, "Пользователь с таким именем не найден. Выберите действие:", keyboard)
+			keyboard := h.client.GetLoginKeyboard(lang)
+			return h.client.SendTextWithKeyboard(message.Chat.ID, i18n.M(lang, "auth.user_not_found"), keyboard)
 		}
 
 		session.User.Username = username
 		session.State = domain.StateAwaitingPassword
 		session.LastCommand = "login" // Устанавливаем последнюю команду как "login"
-		if err := h.sessionService.UpdateSession(message.Chat.ID, session); err != nil {
+		if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
 			return err
 		}
-		return h.client.SendMessage(message.Chat.ID, "Введите пароль:")
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "auth.enter_password"))
 
 	case domain.StateAwaitingPassword:
 		// Пытаемся авторизовать пользователя
 		password := message.Text
+
+		// Сразу удаляем сообщение с паролем из чата
+		h.deleteSensitiveMessage(message)
 		if password == "" {
-			return h.client.SendMessage(message.Chat.ID, "Пароль не может быть пустым. Попробуйте еще раз:")
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "auth.password_empty"))
 		}
 
 		// Авторизуем пользователя
-		err := h.sessionService.Login(message.Chat.ID, session.User.Username, password)
+		err := h.sessionService.Login(message.From.ID, message.Chat.ID, session.User.Username, password)
+		if handled, tfErr := h.handleTwoFactorLogin(message, lang, err); handled {
+			// Пароль верный, вход продолжится после ввода кода 2FA
+			return tfErr
+		}
 		if err != nil {
 			// Если произошла ошибка авторизации, сбрасываем состояние и предлагаем выбрать действие
 			session.State = domain.StateNone
 			session.User.Username = "" // Сбрасываем имя пользователя
-			if updateErr := h.sessionService.UpdateSession(message.Chat.ID, session); updateErr != nil {
+			if updateErr := h.sessionService.UpdateSession(message.From.ID, session); updateErr != nil {
 				return updateErr
 			}
-			keyboard := h.client.GetLoginKeyboard()
-			return h.client.SendMessageWithKeyboard(message.Chat.ID, fmt.Sprintf("Ошибка авторизации: %s. Выберите действие:", err.Error()), keyboard)
+
+			// Аккаунт привязан к другому Telegram-аккаунту: сообщаем администраторам о запросе на перенос
+			var transferErr *domain.TransferRequiredError
+			if errors.As(err, &transferErr) && transferErr.Created {
+				h.notifyAdminsAboutTransfer(transferErr.Transfer, message.From)
+			}
+
+			keyboard := h.client.GetLoginKeyboard(lang)
+			return h.client.SendTextWithKeyboard(message.Chat.ID, i18n.M(lang, "auth.login_failed", i18n.P{"error": errorText(h.logger, lang, err)}), keyboard)
 		}
 
 		// Получаем обновленную сессию
-		session, err = h.sessionService.GetSession(message.Chat.ID)
+		session, err = h.sessionService.GetSession(message.From.ID)
 		if err != nil {
 			return err
 		}
@@ -168,18 +218,18 @@ func (h *AuthHandler) HandleLogin(message *tgbotapi.Message) error {
 		// Сбрасываем состояние сессии
 		session.State = domain.StateNone
 		session.LastCommand = "" // Сбрасываем последнюю команду
-		if err := h.sessionService.UpdateSession(message.Chat.ID, session); err != nil {
+		if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
 			return err
 		}
 
-		// Показываем главное меню
-		isAdmin, err := h.sessionService.IsAdmin(message.Chat.ID)
+		// Показываем главное меню на языке, сохраненном в профиле
+		lang = sessionLang(session, message.From)
+		keyboard, err := h.mainMenuKeyboard(message.From.ID, lang)
 		if err != nil {
 			return err
 		}
 
-		keyboard := h.client.GetMainMenuKeyboard(isAdmin)
-		return h.client.SendMessageWithKeyboard(message.Chat.ID, "Вы успешно авторизованы! Выберите действие:", keyboard)
+		return h.client.SendTextWithKeyboard(message.Chat.ID, i18n.M(lang, "auth.login_success"), keyboard)
 
 	default:
 		return fmt.Errorf("неизвестное состояние сессии")
@@ -189,10 +239,16 @@ func (h *AuthHandler) HandleLogin(message *tgbotapi.Message) error {
 // HandleRegister обрабатывает процесс регистрации
 func (h *AuthHandler) HandleRegister(message *tgbotapi.Message) error {
 	// Получаем сессию пользователя
-	session, err := h.sessionService.GetSession(message.Chat.ID)
+	session, err := h.sessionService.GetSession(message.From.ID)
 	if err != nil {
 		return err
 	}
+	lang := sessionLang(session, message.From)
+
+	// Пароли вводятся только в личном чате с ботом
+	if !message.Chat.IsPrivate() {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "auth.private_only"))
+	}
 	if session == nil {
 		// Если сессия не существует, создаем новую
 		user := h.client.GetUserFromMessage(message)
@@ -201,7 +257,7 @@ func (h *AuthHandler) HandleRegister(message *tgbotapi.Message) error {
 			State:       domain.StateNone,
 			LastCommand: "register", // Устанавливаем последнюю команду как "register"
 		}
-		if err := h.sessionService.UpdateSession(message.Chat.ID, session); err != nil {
+		if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
 			return err
 		}
 	}
@@ -209,15 +265,15 @@ func (h *AuthHandler) HandleRegister(message *tgbotapi.Message) error {
 	// В зависимости от текущего состояния сессии
 	switch session.State {
 	case domain.StateNone:
-		// Если текст сообщения "Зарегистрироваться", начинаем процесс регистрации
-		if message.Text == "Зарегистрироваться" {
+		// Если нажата кнопка регистрации, начинаем процесс регистрации
+		if buttonKey(message.Text) == telegram.BtnRegister {
 			// Запрашиваем имя пользователя
 			session.State = domain.StateAwaitingUsername
 			session.LastCommand = "register" // Устанавливаем последнюю команду как "register"
-			if err := h.sessionService.UpdateSession(message.Chat.ID, session); err != nil {
+			if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
 				return err
 			}
-			return h.client.SendMessage(message.Chat.ID, "Введите имя пользователя для регистрации:")
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "auth.register_enter_username"))
 		}
 		return nil
 
@@ -225,7 +281,7 @@ func (h *AuthHandler) HandleRegister(message *tgbotapi.Message) error {
 		// Сохраняем имя пользователя и запрашиваем пароль
 		username := message.Text
 		if username == "" {
-			return h.client.SendMessage(message.Chat.ID, "Имя пользователя не может быть пустым. Попробуйте еще раз:")
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "auth.username_empty"))
 		}
 
 		// Проверяем, существует ли пользователь с таким именем
@@ -234,32 +290,37 @@ func (h *AuthHandler) HandleRegister(message *tgbotapi.Message) error {
 			return err
 		}
 		if existingUser != nil {
-			return h.client.SendMessage(message.Chat.ID, "Пользователь с таким именем уже существует. Введите другое имя:")
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "auth.register_username_taken"))
 		}
 
 		session.User.Username = username
 		session.State = domain.StateAwaitingPassword
 		session.LastCommand = "register" // Устанавливаем последнюю команду как "register"
-		if err := h.sessionService.UpdateSession(message.Chat.ID, session); err != nil {
+		if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
 			return err
 		}
-		return h.client.SendMessage(message.Chat.ID, "Введите пароль для регистрации:")
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "auth.register_enter_password"))
 
 	case domain.StateAwaitingPassword:
 		// Регистрируем пользователя
 		password := message.Text
+
+		// Сразу удаляем сообщение с паролем из чата
+		h.deleteSensitiveMessage(message)
 		if password == "" {
-			return h.client.SendMessage(message.Chat.ID, "Пароль не может быть пустым. Попробуйте еще раз:")
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "auth.password_empty"))
 		}
 
-		// Создаем нового пользователя
+		// Создаем нового пользователя, привязанного к текущему Telegram-аккаунту
 		user := &domain.User{
-			ChatID:    message.Chat.ID,
-			Username:  session.User.Username,
-			Password:  password,
-			Role:      domain.RoleUser,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			TelegramID: message.From.ID,
+			ChatID:     message.Chat.ID,
+			Username:   session.User.Username,
+			Password:   password,
+			Role:       domain.RoleUser,
+			Language:   string(lang),
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
 		}
 
 		// Регистрируем пользователя
@@ -268,69 +329,184 @@ func (h *AuthHandler) HandleRegister(message *tgbotapi.Message) error {
 			session.State = domain.StateNone
 			session.User.Username = "" // Сбрасываем имя пользователя
 			session.LastCommand = ""   // Сбрасываем последнюю команду
-			if updateErr := h.sessionService.UpdateSession(message.Chat.ID, session); updateErr != nil {
+			if updateErr := h.sessionService.UpdateSession(message.From.ID, session); updateErr != nil {
 				return updateErr
 			}
-			keyboard := h.client.GetLoginKeyboard()
-			return h.client.SendMessageWithKeyboard(message.Chat.ID, fmt.Sprintf("Ошибка регистрации: %s. Выберите действие:", err.Error()), keyboard)
+			keyboard := h.client.GetLoginKeyboard(lang)
+			return h.client.SendTextWithKeyboard(message.Chat.ID, i18n.M(lang, "auth.register_failed", i18n.P{"error": errorText(h.logger, lang, err)}), keyboard)
 		}
 
 		// Успешная регистрация
 		session.State = domain.StateNone
 		session.LastCommand = ""
-		if err := h.sessionService.UpdateSession(message.Chat.ID, session); err != nil {
+		if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
 			return err
 		}
 
 		// Отправляем сообщение об успешной регистрации
-		keyboard := h.client.GetLoginKeyboard()
-		return h.client.SendMessageWithKeyboard(message.Chat.ID, "Регистрация успешно завершена! Теперь вы можете войти в систему.", keyboard)
+		keyboard := h.client.GetLoginKeyboard(lang)
+		return h.client.SendTextWithKeyboard(message.Chat.ID, i18n.M(lang, "auth.register_success"), keyboard)
 
 	default:
 		return fmt.Errorf("неизвестное состояние сессии")
 	}
 }
 
+// HandleInvite обрабатывает команду /invite <код> и ссылку /start <код>:
+// проверяет код приглашения и запрашивает пароль
+func (h *AuthHandler) HandleInvite(message *tgbotapi.Message) error {
+	session, err := h.sessionService.GetSession(message.From.ID)
+	if err != nil {
+		return err
+	}
+	lang := sessionLang(session, message.From)
+
+	// Пароли вводятся только в личном чате с ботом
+	if !message.Chat.IsPrivate() {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "auth.private_only"))
+	}
+	code := strings.TrimSpace(message.CommandArguments())
+	if code == "" {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "invite.usage"))
+	}
+	if session != nil && session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "invite.logged_in"))
+	}
+
+	user, err := h.sessionService.CheckInvite(code)
+	if err != nil {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "invite.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+
+	if session == nil {
+		session = &domain.UserSession{User: h.client.GetUserFromMessage(message)}
+	}
+	session.State = domain.StateAwaitingInvitePassword
+	session.LastCommand = ""
+	session.InviteCode = code
+	if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
+		return err
+	}
+	return h.client.SendText(message.Chat.ID, i18n.M(lang, "invite.enter_password", i18n.P{"username": user.Username}))
+}
+
+// HandleInvitePassword задает пароль пользователя, принимающего приглашение
+func (h *AuthHandler) HandleInvitePassword(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	password := message.Text
+
+	// Сразу удаляем сообщение с паролем из чата
+	h.deleteSensitiveMessage(message)
+	if password == "" {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "auth.password_empty"))
+	}
+
+	code := session.InviteCode
+	session.State = domain.StateNone
+	session.InviteCode = ""
+	if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
+		return err
+	}
+
+	keyboard := h.client.GetLoginKeyboard(lang)
+	user, err := h.sessionService.AcceptInvite(message.From.ID, message.Chat.ID, code, password)
+	if err != nil {
+		return h.client.SendTextWithKeyboard(message.Chat.ID, i18n.M(lang, "invite.failed", i18n.P{"error": errorText(h.logger, lang, err)}), keyboard)
+	}
+	return h.client.SendTextWithKeyboard(message.Chat.ID, i18n.M(lang, "invite.accepted", i18n.P{"username": user.Username}), keyboard)
+}
+
 // HandleLogout обрабатывает выход из системы
 func (h *AuthHandler) HandleLogout(message *tgbotapi.Message) error {
+	// Язык определяем до удаления сессии
+	session, err := h.sessionService.GetSession(message.From.ID)
+	if err != nil {
+		return err
+	}
+	lang := sessionLang(session, message.From)
+
 	// Удаляем сессию пользователя
-	if err := h.sessionService.Logout(message.Chat.ID); err != nil {
+	if err := h.sessionService.Logout(message.From.ID); err != nil {
 		return err
 	}
 
 	// Показываем меню авторизации
-	keyboard := h.client.GetLoginKeyboard()
-	return h.client.SendMessageWithKeyboard(message.Chat.ID, "Вы вышли из системы. Для продолжения работы необходимо авторизоваться:", keyboard)
+	keyboard := h.client.GetLoginKeyboard(lang)
+	return h.client.SendTextWithKeyboard(message.Chat.ID, i18n.M(lang, "auth.logout"), keyboard)
 }
 
 // HandleToken обрабатывает авторизацию по JWT токену
 func (h *AuthHandler) HandleToken(message *tgbotapi.Message) error {
+	session, _ := h.sessionService.GetSession(message.From.ID)
+	lang := sessionLang(session, message.From)
+
 	// Получаем токен из сообщения
 	token := message.Text
 	if token == "" {
-		return h.client.SendMessage(message.Chat.ID, "Токен не может быть пустым. Попробуйте еще раз:")
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "auth.token_empty"))
 	}
 
 	// Проверяем токен и обновляем сессию
-	err := h.sessionService.ValidateToken(message.Chat.ID, token)
+	err := h.sessionService.ValidateToken(message.From.ID, token)
 	if err != nil {
 		// Если произошла ошибка валидации токена, сбрасываем состояние и предлагаем выбрать действие
-		session, _ := h.sessionService.GetSession(message.Chat.ID)
 		if session != nil {
 			session.State = domain.StateNone
 			session.LastCommand = ""
-			h.sessionService.UpdateSession(message.Chat.ID, session)
+			h.sessionService.UpdateSession(message.From.ID, session)
 		}
-		keyboard := h.client.GetLoginKeyboard()
-		return h.client.SendMessageWithKeyboard(message.Chat.ID, fmt.Sprintf("Ошибка валидации токена: %s. Выберите действие:", err.Error()), keyboard)
+		keyboard := h.client.GetLoginKeyboard(lang)
+		return h.client.SendTextWithKeyboard(message.Chat.ID, i18n.M(lang, "auth.token_failed", i18n.P{"error": errorText(h.logger, lang, err)}), keyboard)
 	}
 
 	// Показываем главное меню
-	isAdmin, err := h.sessionService.IsAdmin(message.Chat.ID)
+	keyboard, err := h.mainMenuKeyboard(message.From.ID, lang)
 	if err != nil {
 		return err
 	}
 
-	keyboard := h.client.GetMainMenuKeyboard(isAdmin)
-	return h.client.SendMessageWithKeyboard(message.Chat.ID, "Вы успешно авторизованы по токену! Выберите действие:", keyboard)
+	return h.client.SendTextWithKeyboard(message.Chat.ID, i18n.M(lang, "auth.token_success"), keyboard)
+}
+
+// notifyAdminsAboutTransfer отправляет запрос на перенос аккаунта пользователям с правом его одобрить
+func (h *AuthHandler) notifyAdminsAboutTransfer(transfer *domain.AccountTransfer, from *tgbotapi.User) {
+	user, err := h.userService.GetUser(transfer.UserID)
+	if err != nil || user == nil {
+		h.logger.Error("Error getting user for transfer", "transfer_id", transfer.ID, logging.Err(err))
+		return
+	}
+
+	// Просматриваем активных пользователей постранично, не загружая весь список сразу
+	query := domain.UserQuery{Statuses: []domain.UserStatus{domain.UserActive}, Limit: notifyPageSize}
+	for {
+		page, err := h.userService.FindUsers(query)
+		if err != nil {
+			h.logger.Error("Error getting admins for transfer", "transfer_id", transfer.ID, logging.Err(err))
+			return
+		}
+		for _, admin := range page.Users {
+			if admin.ChatID != 0 && h.roleService.Can(admin, domain.PermApproveTransfers) {
+				h.notifyAdminAboutTransfer(admin, user, transfer, from)
+			}
+		}
+		if page.NextCursor == "" {
+			return
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+// notifyAdminAboutTransfer отправляет запрос на перенос аккаунта одному администратору
+func (h *AuthHandler) notifyAdminAboutTransfer(admin, user *domain.User, transfer *domain.AccountTransfer, from *tgbotapi.User) {
+	// Каждый администратор получает уведомление на своем языке
+	lang := userLang(admin)
+	text := i18n.M(lang, "transfer.request_new", i18n.P{
+		"id":          transfer.ID,
+		"username":    user.Username,
+		"account":     from.String(),
+		"telegram_id": from.ID,
+	})
+	if err := h.client.SendTextWithKeyboard(admin.ChatID, text, h.client.GetTransferKeyboard(lang, transfer.ID)); err != nil {
+		h.logger.Warn("Error notifying admin about transfer", "admin_id", admin.ID, "transfer_id", transfer.ID, logging.Err(err))
+	}
 }
@@ -0,0 +1,102 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/markup"
+)
+
+// maxBackupsListed - количество копий, показываемых командой /backup list
+const maxBackupsListed = 20
+
+// BackupHandler обрабатывает команды резервного копирования базы данных
+type BackupHandler struct {
+	client        *telegram.Client
+	backupService domain.BackupService
+	logger        *slog.Logger
+}
+
+// NewBackupHandler создает новый экземпляр BackupHandler
+func NewBackupHandler(client *telegram.Client, backupService domain.BackupService, logger *slog.Logger) *BackupHandler {
+	return &BackupHandler{
+		client:        client,
+		backupService: backupService,
+		logger:        logger,
+	}
+}
+
+// HandleBackupCommand обрабатывает команды /backup, /backup list и /backup verify <копия>
+func (h *BackupHandler) HandleBackupCommand(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	args := strings.Fields(message.CommandArguments())
+	failed := func(err error) error {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "backup.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+
+	switch {
+	case len(args) == 0:
+		backup, err := h.backupService.Create(session.User.ID)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "backup.created", i18n.P{
+			"name":     backup.Name,
+			"size":     formatSize(backup.Size),
+			"checksum": backup.Checksum,
+		}))
+
+	case len(args) == 1 && args[0] == "list":
+		backups, err := h.backupService.List(session.User.ID)
+		if err != nil {
+			return failed(err)
+		}
+		if len(backups) == 0 {
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "backup.list_empty"))
+		}
+		lines := []markup.Text{i18n.M(lang, "backup.list_title", i18n.P{"count": len(backups)})}
+		for i, backup := range backups {
+			if i == maxBackupsListed {
+				lines = append(lines, i18n.M(lang, "backup.list_more"))
+				break
+			}
+			lines = append(lines, i18n.M(lang, "backup.list_entry", i18n.P{
+				"name":    backup.Name,
+				"size":    formatSize(backup.Size),
+				"created": formatTime(lang, backup.CreatedAt.Local()),
+			}))
+		}
+		return h.client.SendText(message.Chat.ID, markup.Join("\n", lines...))
+
+	case len(args) == 2 && args[0] == "verify":
+		backup, err := h.backupService.Verify(session.User.ID, args[1])
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "backup.verified", i18n.P{
+			"name":    backup.Name,
+			"version": backup.SchemaVersion,
+		}))
+
+	default:
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "backup.usage"))
+	}
+}
+
+// formatSize форматирует размер файла в килобайтах или мегабайтах
+func formatSize(size int64) string {
+	if size < 1<<20 {
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+}
@@ -0,0 +1,210 @@
+package telegram
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/markup"
+)
+
+// DuesHandler обрабатывает взносы, оплаты и балансы участников
+type DuesHandler struct {
+	client      *telegram.Client
+	duesService domain.DuesService
+	logger      *slog.Logger
+}
+
+// NewDuesHandler создает новый экземпляр DuesHandler
+func NewDuesHandler(client *telegram.Client, duesService domain.DuesService, logger *slog.Logger) *DuesHandler {
+	return &DuesHandler{
+		client:      client,
+		duesService: duesService,
+		logger:      logger,
+	}
+}
+
+// HandleBalance показывает пользователю баланс в активной команде, долг и последние операции
+func (h *DuesHandler) HandleBalance(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	summary, err := h.duesService.Balance(session.User.ID)
+	if err != nil {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "balance.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+
+	lines := []markup.Text{i18n.M(lang, "balance.title", i18n.P{"team": summary.Team.Name, "balance": domain.FormatAmount(summary.Balance)})}
+	if summary.Balance < 0 {
+		lines = append(lines, i18n.M(lang, "balance.debt", i18n.P{"debt": domain.FormatAmount(-summary.Balance)}))
+	} else {
+		lines = append(lines, i18n.M(lang, "balance.no_debt"))
+	}
+	if len(summary.Entries) == 0 {
+		lines = append(lines, markup.Raw(""), i18n.M(lang, "balance.no_entries"))
+		return h.client.SendText(message.Chat.ID, markup.Join("\n", lines...))
+	}
+
+	lines = append(lines, markup.Raw(""), i18n.M(lang, "balance.entries"))
+	for _, entry := range summary.Entries {
+		params := i18n.P{
+			"date":   formatDate(lang, entry.CreatedAt),
+			"amount": domain.FormatAmount(entry.Amount),
+			"period": formatDate(lang, entry.Period),
+			"note":   entry.Note,
+		}
+		var key string
+		switch entry.Kind {
+		case domain.EntryCharge:
+			key = "balance.entry_charge"
+		case domain.EntryPayment:
+			key = "balance.entry_payment"
+		case domain.EntryExpense:
+			key = "balance.entry_expense"
+			params["amount"] = signedAmount(entry.Amount)
+		default:
+			key = "balance.entry_settlement"
+			params["amount"] = signedAmount(entry.Amount)
+		}
+		if entry.Note != "" && entry.Kind != domain.EntryCharge {
+			key += "_note"
+		}
+		lines = append(lines, i18n.M(lang, key, params))
+	}
+	return h.client.SendText(message.Chat.ID, markup.Join("\n", lines...))
+}
+
+// HandleDuesCommand обрабатывает команды /dues, /dues add <название> <сумма> <период> <дата> [пользователи...],
+// /dues stop <номер> и /dues pay <пользователь> <сумма> [комментарий]
+func (h *DuesHandler) HandleDuesCommand(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	args := strings.Fields(message.CommandArguments())
+	failed := func(err error) error {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "dues.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+	actorID := session.User.ID
+
+	switch {
+	case len(args) == 0:
+		plans, err := h.duesService.Plans(actorID)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, h.plansText(lang, plans))
+
+	case len(args) >= 5 && args[0] == "add":
+		amount, err := domain.ParseAmount(args[2])
+		if err != nil {
+			return failed(i18n.NewError("error.dues_amount_invalid"))
+		}
+		start, err := time.Parse(domain.DateLayout, args[4])
+		if err != nil {
+			return failed(i18n.NewError("error.dues_start_invalid"))
+		}
+		plan, err := h.duesService.CreatePlan(actorID, args[1], amount, domain.DuesPeriod(strings.ToLower(args[3])), start, args[5:])
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "dues.created", i18n.P{"id": plan.ID, "name": plan.Name}))
+
+	case len(args) == 2 && args[0] == "stop":
+		planID, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+		if err != nil {
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "dues.usage"))
+		}
+		plan, err := h.duesService.StopPlan(actorID, planID)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "dues.stopped", i18n.P{"name": plan.Name}))
+
+	case len(args) >= 3 && args[0] == "pay":
+		amount, err := domain.ParseAmount(args[2])
+		if err != nil {
+			return failed(i18n.NewError("error.dues_amount_invalid"))
+		}
+		entry, err := h.duesService.RecordPayment(actorID, args[1], amount, strings.Join(args[3:], " "))
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "dues.payment_recorded", i18n.P{
+			"username": strings.TrimPrefix(args[1], "@"),
+			"amount":   domain.FormatAmount(entry.Amount),
+		}))
+
+	default:
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "dues.usage"))
+	}
+}
+
+// HandleDebtors отправляет отчет о должниках активной команды
+func (h *DuesHandler) HandleDebtors(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	debtors, err := h.duesService.Debtors(session.User.ID)
+	if err != nil {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "dues.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+	if len(debtors) == 0 {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "debtors.none"))
+	}
+
+	var total int64
+	lines := []markup.Text{i18n.M(lang, "debtors.title")}
+	for _, debtor := range debtors {
+		total += debtor.Debt
+		lines = append(lines, i18n.M(lang, "debtors.entry", i18n.P{"username": debtor.Username, "debt": domain.FormatAmount(debtor.Debt)}))
+	}
+	lines = append(lines, i18n.MN(lang, "debtors.total", len(debtors), i18n.P{"total": domain.FormatAmount(total)}))
+	return h.client.SendText(message.Chat.ID, markup.Join("\n", lines...))
+}
+
+// plansText формирует список планов взносов команды
+func (h *DuesHandler) plansText(lang i18n.Lang, plans []*domain.DuesPlan) markup.Text {
+	if len(plans) == 0 {
+		return i18n.M(lang, "dues.none")
+	}
+	lines := []markup.Text{i18n.M(lang, "dues.title")}
+	for _, plan := range plans {
+		members := i18n.T(lang, "dues.members_all")
+		if len(plan.MemberIDs) > 0 {
+			members = i18n.N(lang, "dues.members_count", len(plan.MemberIDs))
+		}
+		key := "dues.entry"
+		if !plan.Active {
+			key = "dues.entry_stopped"
+		}
+		lines = append(lines, i18n.M(lang, key, i18n.P{
+			"id":      plan.ID,
+			"name":    plan.Name,
+			"amount":  domain.FormatAmount(plan.Amount),
+			"period":  i18n.T(lang, "dues.period."+string(plan.Period)),
+			"start":   formatDate(lang, plan.StartDate),
+			"members": members,
+		}))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// signedAmount форматирует сумму со знаком: поступления отмечаются плюсом
+func signedAmount(amount int64) string {
+	if amount > 0 {
+		return "+" + domain.FormatAmount(amount)
+	}
+	return domain.FormatAmount(amount)
+}
@@ -0,0 +1,297 @@
+package telegram
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/markup"
+)
+
+// DutyHandler обрабатывает графики дежурств: очередь участников, уведомления дежурным,
+// обмен дежурствами, отметки о выполнении и историю
+type DutyHandler struct {
+	client      *telegram.Client
+	dutyService domain.DutyService
+	logger      *slog.Logger
+}
+
+// NewDutyHandler создает новый экземпляр DutyHandler
+func NewDutyHandler(client *telegram.Client, dutyService domain.DutyService, logger *slog.Logger) *DutyHandler {
+	return &DutyHandler{
+		client:      client,
+		dutyService: dutyService,
+		logger:      logger,
+	}
+}
+
+// HandleDutyCommand обрабатывает команды /duty, /duty add <название> <период> <дата> [пользователи...],
+// /duty stop <номер>, /duty done <номер>, /duty swap <номер> <пользователь> и
+// /duty history [ГГГГ-ММ-ДД [ГГГГ-ММ-ДД]]
+func (h *DutyHandler) HandleDutyCommand(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	args := strings.Fields(message.CommandArguments())
+	failed := func(err error) error {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "duty.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+	actorID := session.User.ID
+
+	// Номер графика или дежурства во втором аргументе
+	var id int64
+	if len(args) >= 2 {
+		id, _ = strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+	}
+
+	switch {
+	case len(args) == 0:
+		duties, err := h.dutyService.CurrentDuties(actorID)
+		if err != nil {
+			return failed(err)
+		}
+		rosters, err := h.dutyService.Rosters(actorID)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, dutiesText(lang, duties, rosters))
+
+	case len(args) >= 4 && args[0] == "add":
+		start, err := time.Parse(domain.DateLayout, args[3])
+		if err != nil {
+			return failed(i18n.NewError("error.dues_start_invalid"))
+		}
+		roster, notices, err := h.dutyService.CreateRoster(actorID, args[1], domain.DuesPeriod(strings.ToLower(args[2])), start, args[4:])
+		if err != nil {
+			return failed(err)
+		}
+		h.notifyAssigned(notices)
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "duty.created", i18n.P{
+			"id":      roster.ID,
+			"name":    roster.Name,
+			"members": strings.Join(roster.Members, ", "),
+		}))
+
+	case len(args) == 2 && id > 0 && args[0] == "stop":
+		roster, err := h.dutyService.StopRoster(actorID, id)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "duty.stopped", i18n.P{"name": roster.Name}))
+
+	case len(args) == 2 && id > 0 && args[0] == "done":
+		duty, err := h.dutyService.Complete(actorID, id)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "duty.completed", i18n.P{"name": duty.RosterName, "username": duty.Username}))
+
+	case len(args) == 3 && id > 0 && args[0] == "swap":
+		swap, notice, err := h.dutyService.RequestSwap(actorID, id, args[2])
+		if err != nil {
+			return failed(err)
+		}
+		if !h.notify(notice, "duty.swap_request", h.client.GetDutySwapKeyboard(userLang(notice.User), swap.ID)) {
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "duty.swap_undelivered", i18n.P{"username": notice.User.Username}))
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "duty.swap_requested", i18n.P{"username": notice.User.Username}))
+
+	case len(args) >= 1 && len(args) <= 3 && args[0] == "history":
+		from, to, err := parsePeriod(args[1:], time.Now())
+		if err != nil {
+			return failed(err)
+		}
+		duties, err := h.dutyService.History(actorID, from, to)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, dutyHistoryText(lang, duties, from, to))
+
+	default:
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "duty.usage"))
+	}
+}
+
+// HandleDoneCallback отмечает дежурство выполненным по кнопке из уведомления
+func (h *DutyHandler) HandleDoneCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, param string) (string, error) {
+	lang := sessionLang(session, callback.From)
+	assignmentID, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+	duty, err := h.dutyService.Complete(session.User.ID, assignmentID)
+	if err != nil {
+		return "", err
+	}
+
+	text := markup.Join("\n", dutyText(lang, duty), markup.Raw(""), i18n.M(lang, "duty.marked_done"))
+	if err := h.client.EditText(callback.Message.Chat.ID, callback.Message.MessageID, text); err != nil {
+		h.logger.Warn("Error editing duty message", "duty_id", duty.ID, logging.Err(err))
+	}
+	return i18n.T(lang, "duty.marked_done"), nil
+}
+
+// HandleSwapCallback принимает или отклоняет просьбу взять дежурство и сообщает об ответе
+// попросившему участнику
+func (h *DutyHandler) HandleSwapCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, param string) (string, error) {
+	lang := sessionLang(session, callback.From)
+	value, answer, _ := strings.Cut(param, ":")
+	swapID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || answer != "accept" && answer != "decline" {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+
+	swap, notice, err := h.dutyService.RespondSwap(session.User.ID, swapID, answer == "accept")
+	if err != nil {
+		return "", err
+	}
+	key := "duty.swap_declined"
+	if swap.Status == domain.DutySwapAccepted {
+		key = "duty.swap_accepted"
+	}
+	params := i18n.P{"username": session.User.Username}
+	if notice != nil && notice.User.ChatID != 0 {
+		requesterLang := userLang(notice.User)
+		text := markup.Join("\n", i18n.M(requesterLang, key, params), dutyText(requesterLang, notice.Assignment))
+		if err := h.client.SendText(notice.User.ChatID, text); err != nil {
+			h.logger.Warn("Error sending duty swap answer", "swap_id", swap.ID, "user_id", notice.User.ID, logging.Err(err))
+		}
+	}
+
+	answerKey := "duty.swap_declined_self"
+	if swap.Status == domain.DutySwapAccepted {
+		answerKey = "duty.swap_accepted_self"
+	}
+	if err := h.client.EditText(callback.Message.Chat.ID, callback.Message.MessageID, i18n.M(lang, answerKey)); err != nil {
+		h.logger.Warn("Error editing duty swap message", "swap_id", swap.ID, logging.Err(err))
+	}
+	return i18n.T(lang, answerKey), nil
+}
+
+// Run назначает дежурных на наступившие периоды графиков и уведомляет их с указанным
+// интервалом, пока не отменен контекст
+func (h *DutyHandler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		notices, err := h.dutyService.AssignDue(time.Now())
+		if err != nil {
+			h.logger.Error("Error assigning duties", logging.Err(err))
+		}
+		h.notifyAssigned(notices)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// notifyAssigned уведомляет участников о назначенных им дежурствах
+func (h *DutyHandler) notifyAssigned(notices []*domain.DutyNotice) {
+	for _, notice := range notices {
+		if h.notify(notice, "duty.assigned", h.client.GetDutyKeyboard(userLang(notice.User), notice.Assignment.ID)) {
+			h.logger.Info("Duty assigned", "duty_id", notice.Assignment.ID, "roster_id", notice.Assignment.RosterID, "user_id", notice.User.ID)
+		}
+	}
+}
+
+// notify отправляет пользователю сообщение о дежурстве на его языке с кнопками
+// и сообщает, удалось ли его доставить
+func (h *DutyHandler) notify(notice *domain.DutyNotice, key string, keyboard tgbotapi.InlineKeyboardMarkup) bool {
+	user := notice.User
+	if user.ChatID == 0 {
+		return false
+	}
+	lang := userLang(user)
+	text := markup.Join("\n", i18n.M(lang, key, i18n.P{"username": notice.Assignment.Username}), dutyText(lang, notice.Assignment))
+	if err := h.client.SendTextWithKeyboard(user.ChatID, text, keyboard); err != nil {
+		h.logger.Warn("Error sending duty message", "duty_id", notice.Assignment.ID, "user_id", user.ID, "message", key, logging.Err(err))
+		return false
+	}
+	return true
+}
+
+// dutyText формирует карточку дежурства
+func dutyText(lang i18n.Lang, duty *domain.DutyAssignment) markup.Text {
+	return i18n.M(lang, "duty.card", i18n.P{
+		"id":       duty.ID,
+		"name":     duty.RosterName,
+		"username": duty.Username,
+		"from":     formatDate(lang, duty.Period),
+		"to":       formatDate(lang, duty.Until.AddDate(0, 0, -1)),
+	})
+}
+
+// dutiesText формирует текущие дежурства и графики команды
+func dutiesText(lang i18n.Lang, duties []*domain.DutyAssignment, rosters []*domain.DutyRoster) markup.Text {
+	if len(rosters) == 0 {
+		return i18n.M(lang, "duty.none")
+	}
+	lines := []markup.Text{i18n.M(lang, "duty.current_title")}
+	for _, duty := range duties {
+		key := "duty.current_entry"
+		if duty.Done() {
+			key = "duty.current_entry_done"
+		}
+		lines = append(lines, i18n.M(lang, key, i18n.P{
+			"id":       duty.ID,
+			"name":     duty.RosterName,
+			"username": duty.Username,
+			"to":       formatDate(lang, duty.Until.AddDate(0, 0, -1)),
+		}))
+	}
+	if len(duties) == 0 {
+		lines = append(lines, i18n.M(lang, "duty.current_none"))
+	}
+
+	lines = append(lines, markup.Raw(""), i18n.M(lang, "duty.rosters_title"))
+	for _, roster := range rosters {
+		key := "duty.roster_entry"
+		if !roster.Active {
+			key = "duty.roster_entry_stopped"
+		}
+		lines = append(lines, i18n.M(lang, key, i18n.P{
+			"id":      roster.ID,
+			"name":    roster.Name,
+			"period":  i18n.T(lang, "dues.period."+string(roster.Period)),
+			"start":   formatDate(lang, roster.StartDate),
+			"members": strings.Join(roster.Members, " → "),
+		}))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// dutyHistoryText формирует историю дежурств команды за период
+func dutyHistoryText(lang i18n.Lang, duties []*domain.DutyAssignment, from, to time.Time) markup.Text {
+	lines := []markup.Text{i18n.M(lang, "duty.history_title", periodParams(lang, from, to))}
+	if len(duties) == 0 {
+		lines = append(lines, i18n.M(lang, "duty.history_none"))
+		return markup.Join("\n", lines...)
+	}
+	for _, duty := range duties {
+		key := "duty.history_entry"
+		if duty.Done() {
+			key = "duty.history_entry_done"
+		}
+		lines = append(lines, i18n.M(lang, key, i18n.P{
+			"id":       duty.ID,
+			"name":     duty.RosterName,
+			"username": duty.Username,
+			"from":     formatDate(lang, duty.Period),
+			"to":       formatDate(lang, duty.Until.AddDate(0, 0, -1)),
+		}))
+	}
+	return markup.Join("\n", lines...)
+}
@@ -0,0 +1,321 @@
+package telegram
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/markup"
+)
+
+// EventHandler обрабатывает события команды: создание, приглашения, ответы и напоминания
+type EventHandler struct {
+	client       *telegram.Client
+	eventService domain.EventService
+	logger       *slog.Logger
+}
+
+// NewEventHandler создает новый экземпляр EventHandler
+func NewEventHandler(client *telegram.Client, eventService domain.EventService, logger *slog.Logger) *EventHandler {
+	return &EventHandler{
+		client:       client,
+		eventService: eventService,
+		logger:       logger,
+	}
+}
+
+// HandleEventCommand обрабатывает команды /event, /event <номер>, /event cancel <номер> и
+// /event add <ГГГГ-ММ-ДД ЧЧ:ММ> | <название> [| <место> [| <мест> [| <ответить до>]]]
+func (h *EventHandler) HandleEventCommand(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	arguments := strings.TrimSpace(message.CommandArguments())
+	command, rest, _ := strings.Cut(arguments, " ")
+	failed := func(err error) error {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "event.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+
+	switch {
+	case arguments == "":
+		events, err := h.eventService.UpcomingEvents(session.User.ID)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, h.eventsText(lang, events))
+
+	case command == "add":
+		event, err := parseEvent(rest)
+		if err != nil {
+			return failed(err)
+		}
+		invitees, err := h.eventService.CreateEvent(session.User.ID, event)
+		if err != nil {
+			return failed(err)
+		}
+		sent := h.sendInvitations(event, invitees)
+		return h.client.SendText(message.Chat.ID, i18n.MN(lang, "event.created", sent, i18n.P{
+			"id":    event.ID,
+			"title": event.Title,
+		}))
+
+	case command == "cancel":
+		eventID, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+		if err != nil {
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "event.usage"))
+		}
+		event, users, err := h.eventService.CancelEvent(session.User.ID, eventID)
+		if err != nil {
+			return failed(err)
+		}
+		for _, user := range users {
+			h.notify(user, event, "event.canceled_notice", false)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "event.canceled", i18n.P{"title": event.Title}))
+
+	default:
+		eventID, err := strconv.ParseInt(arguments, 10, 64)
+		if err != nil {
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "event.usage"))
+		}
+		event, rsvps, err := h.eventService.GetEvent(session.User.ID, eventID)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendTextWithKeyboard(message.Chat.ID, attendeesText(lang, event, rsvps), h.client.GetRSVPKeyboard(lang, event.ID))
+	}
+}
+
+// HandleRSVPCallback записывает ответ на приглашение и обновляет сообщение со списком участников
+func (h *EventHandler) HandleRSVPCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, param string) (string, error) {
+	lang := sessionLang(session, callback.From)
+	id, answer, _ := strings.Cut(param, ":")
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+
+	result, err := h.eventService.Respond(session.User.ID, eventID, domain.RSVPStatus(answer))
+	if err != nil {
+		return "", err
+	}
+	if result.Promoted != nil {
+		h.notify(result.Promoted, result.Event, "event.promoted_notice", true)
+	}
+	if err := h.refresh(callback, lang, session.User.ID, eventID); err != nil {
+		h.logger.Warn("Error loading event attendees", "event_id", eventID, logging.Err(err))
+	}
+
+	if result.Status == domain.RSVPWaitlist {
+		return i18n.T(lang, "event.rsvp_waitlist", i18n.P{"position": result.Position}), nil
+	}
+	return i18n.T(lang, "event.rsvp_"+string(result.Status)), nil
+}
+
+// HandleShowCallback обновляет сообщение с текущим списком участников события
+func (h *EventHandler) HandleShowCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, param string) (string, error) {
+	lang := sessionLang(session, callback.From)
+	eventID, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+	if err := h.refresh(callback, lang, session.User.ID, eventID); err != nil {
+		return "", err
+	}
+	return i18n.T(lang, "event.refreshed"), nil
+}
+
+// Run рассылает напоминания о предстоящих событиях сразу и затем с указанным интервалом,
+// пока не отменен контекст
+func (h *EventHandler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		reminders, err := h.eventService.DueReminders(time.Now())
+		if err != nil {
+			h.logger.Error("Error collecting event reminders", logging.Err(err))
+		}
+		for _, reminder := range reminders {
+			for _, user := range reminder.Users {
+				h.notify(user, reminder.Event, "event.reminder", true)
+			}
+			h.logger.Info("Event reminder sent", "event_id", reminder.Event.ID, "recipients", len(reminder.Users))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendInvitations рассылает приглашения на событие и возвращает число отправленных
+func (h *EventHandler) sendInvitations(event *domain.Event, users []*domain.User) int {
+	sent := 0
+	for _, user := range users {
+		if h.notify(user, event, "event.invitation", true) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// notify отправляет пользователю сообщение о событии на его языке, при необходимости
+// с кнопками ответа, и сообщает, удалось ли его доставить
+func (h *EventHandler) notify(user *domain.User, event *domain.Event, key string, withKeyboard bool) bool {
+	if user.ChatID == 0 {
+		return false
+	}
+	lang := userLang(user)
+	text := markup.Join("\n", i18n.M(lang, key), eventText(lang, event))
+
+	var err error
+	if withKeyboard {
+		err = h.client.SendTextWithKeyboard(user.ChatID, text, h.client.GetRSVPKeyboard(lang, event.ID))
+	} else {
+		err = h.client.SendText(user.ChatID, text)
+	}
+	if err != nil {
+		h.logger.Warn("Error sending event message", "event_id", event.ID, "user_id", user.ID, "message", key, logging.Err(err))
+		return false
+	}
+	return true
+}
+
+// refresh заменяет сообщение с кнопками ответа текущим списком участников события.
+// Ошибка изменения сообщения только записывается в лог
+func (h *EventHandler) refresh(callback *tgbotapi.CallbackQuery, lang i18n.Lang, actorID int64, eventID int64) error {
+	event, rsvps, err := h.eventService.GetEvent(actorID, eventID)
+	if err != nil {
+		return err
+	}
+	text := attendeesText(lang, event, rsvps)
+	if err := h.client.EditTextWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, h.client.GetRSVPKeyboard(lang, eventID)); err != nil {
+		h.logger.Warn("Error editing event message", "event_id", eventID, logging.Err(err))
+	}
+	return nil
+}
+
+// eventsText формирует список предстоящих событий команды
+func (h *EventHandler) eventsText(lang i18n.Lang, events []*domain.Event) markup.Text {
+	if len(events) == 0 {
+		return i18n.M(lang, "event.none")
+	}
+	lines := []markup.Text{i18n.M(lang, "event.title")}
+	for _, event := range events {
+		lines = append(lines, i18n.M(lang, "event.entry", i18n.P{
+			"id":    event.ID,
+			"title": event.Title,
+			"time":  formatTime(lang, event.StartsAt.Local()),
+			"going": seatsText(lang, event),
+		}))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// eventText формирует карточку события: время, место, число мест и срок ответа
+func eventText(lang i18n.Lang, event *domain.Event) markup.Text {
+	lines := []markup.Text{
+		i18n.M(lang, "event.card_title", i18n.P{"id": event.ID, "title": event.Title}),
+		i18n.M(lang, "event.card_time", i18n.P{"time": formatTime(lang, event.StartsAt.Local())}),
+	}
+	if event.Location != "" {
+		lines = append(lines, i18n.M(lang, "event.card_location", i18n.P{"location": event.Location}))
+	}
+	lines = append(lines, i18n.M(lang, "event.card_going", i18n.P{"going": seatsText(lang, event)}))
+	if !event.Deadline.IsZero() {
+		lines = append(lines, i18n.M(lang, "event.card_deadline", i18n.P{"deadline": formatTime(lang, event.Deadline.Local())}))
+	}
+	if event.Canceled {
+		lines = append(lines, i18n.M(lang, "event.card_canceled"))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// seatsText описывает число идущих, свободных мест и очередь листа ожидания
+func seatsText(lang i18n.Lang, event *domain.Event) string {
+	if event.Capacity == 0 {
+		return i18n.T(lang, "event.seats_unlimited", i18n.P{"going": event.Going})
+	}
+	if event.Waitlisted > 0 {
+		return i18n.T(lang, "event.seats_waitlist", i18n.P{"going": event.Going, "capacity": event.Capacity, "waitlisted": event.Waitlisted})
+	}
+	return i18n.T(lang, "event.seats", i18n.P{"going": event.Going, "capacity": event.Capacity})
+}
+
+// attendeesText формирует карточку события со списком ответивших участников
+func attendeesText(lang i18n.Lang, event *domain.Event, rsvps []*domain.RSVP) markup.Text {
+	groups := make(map[domain.RSVPStatus][]string)
+	for _, rsvp := range rsvps {
+		groups[rsvp.Status] = append(groups[rsvp.Status], rsvp.Username)
+	}
+
+	lines := []markup.Text{eventText(lang, event), markup.Raw("")}
+	if len(rsvps) == 0 {
+		lines = append(lines, i18n.M(lang, "event.no_answers"))
+	}
+	for _, status := range []domain.RSVPStatus{domain.RSVPGoing, domain.RSVPWaitlist, domain.RSVPMaybe, domain.RSVPNotGoing} {
+		names := groups[status]
+		if len(names) == 0 {
+			continue
+		}
+		lines = append(lines, i18n.M(lang, "event.attendees_"+string(status), i18n.P{
+			"count": len(names),
+			"names": strings.Join(names, ", "),
+		}))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// parseEvent разбирает параметры нового события, разделенные вертикальной чертой:
+// начало, название, место, число мест и срок ответа. Необязательные поля можно оставить пустыми
+func parseEvent(arguments string) (*domain.Event, error) {
+	parts := strings.Split(arguments, "|")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if len(parts) < 2 || len(parts) > 5 {
+		return nil, i18n.NewError("error.event_format")
+	}
+
+	startsAt, err := parseEventTime(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	event := &domain.Event{StartsAt: startsAt, Title: parts[1]}
+	if len(parts) > 2 {
+		event.Location = parts[2]
+	}
+	if len(parts) > 3 && parts[3] != "" {
+		// Некорректное число мест передается как отрицательное, и сервис сообщает о допустимом диапазоне
+		if event.Capacity, err = strconv.Atoi(parts[3]); err != nil {
+			event.Capacity = -1
+		}
+	}
+	if len(parts) > 4 && parts[4] != "" {
+		if event.Deadline, err = parseEventTime(parts[4]); err != nil {
+			return nil, err
+		}
+	}
+	return event, nil
+}
+
+// parseEventTime разбирает дату и время вида 2006-01-02 15:04 в часовом поясе бота
+func parseEventTime(value string) (time.Time, error) {
+	t, err := time.ParseInLocation(domain.EventTimeLayout, strings.Join(strings.Fields(value), " "), time.Local)
+	if err != nil {
+		return time.Time{}, i18n.NewError("error.event_time_format")
+	}
+	return t, nil
+}
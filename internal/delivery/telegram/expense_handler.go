@@ -0,0 +1,184 @@
+package telegram
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/markup"
+)
+
+// ExpenseHandler обрабатывает общие расходы и взаиморасчеты участников команды
+type ExpenseHandler struct {
+	client         *telegram.Client
+	expenseService domain.ExpenseService
+	logger         *slog.Logger
+}
+
+// NewExpenseHandler создает новый экземпляр ExpenseHandler
+func NewExpenseHandler(client *telegram.Client, expenseService domain.ExpenseService, logger *slog.Logger) *ExpenseHandler {
+	return &ExpenseHandler{
+		client:         client,
+		expenseService: expenseService,
+		logger:         logger,
+	}
+}
+
+// HandleExpenseCommand обрабатывает команды /expense и
+// /expense add <сумма> <плательщик> <equal|shares|exact> <участники...> [-- описание]
+func (h *ExpenseHandler) HandleExpenseCommand(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	// Описание отделяется от участников двумя дефисами; некоторые клиенты заменяют их на тире
+	arguments, description, found := strings.Cut(message.CommandArguments(), "--")
+	if !found {
+		arguments, description, _ = strings.Cut(arguments, "—")
+	}
+	args := strings.Fields(arguments)
+	failed := func(err error) error {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "expense.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+
+	switch {
+	case len(args) == 0:
+		expenses, err := h.expenseService.Expenses(session.User.ID)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, h.expensesText(lang, expenses))
+
+	case len(args) >= 5 && args[0] == "add":
+		amount, err := domain.ParseAmount(args[1])
+		if err != nil {
+			return failed(i18n.NewError("error.dues_amount_invalid"))
+		}
+		mode := domain.SplitMode(strings.ToLower(args[3]))
+		expense, err := h.expenseService.AddExpense(session.User.ID, args[2], amount, mode, parseShares(mode, args[4:]), description)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "expense.added", i18n.P{
+			"id":     expense.ID,
+			"amount": domain.FormatAmount(expense.Amount),
+			"payer":  expense.PayerName,
+			"shares": sharesText(lang, expense.Shares),
+		}))
+
+	default:
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "expense.usage"))
+	}
+}
+
+// HandleSettleCommand обрабатывает команды /settle (переводы, закрывающие долги)
+// и /settle <пользователь> <сумма> (запись перевода участнику)
+func (h *ExpenseHandler) HandleSettleCommand(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	args := strings.Fields(message.CommandArguments())
+	failed := func(err error) error {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "expense.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+
+	switch len(args) {
+	case 0:
+		transfers, err := h.expenseService.SettleUp(session.User.ID)
+		if err != nil {
+			return failed(err)
+		}
+		if len(transfers) == 0 {
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "settle.none"))
+		}
+		lines := []markup.Text{i18n.M(lang, "settle.title")}
+		for _, transfer := range transfers {
+			lines = append(lines, i18n.M(lang, "settle.entry", i18n.P{
+				"from":   transfer.FromName,
+				"to":     transfer.ToName,
+				"amount": domain.FormatAmount(transfer.Amount),
+			}))
+		}
+		return h.client.SendText(message.Chat.ID, markup.Join("\n", lines...))
+
+	case 2:
+		amount, err := domain.ParseAmount(args[1])
+		if err != nil {
+			return failed(i18n.NewError("error.dues_amount_invalid"))
+		}
+		expense, err := h.expenseService.Settle(session.User.ID, args[0], amount)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "settle.recorded", i18n.P{
+			"username": expense.Shares[0].Username,
+			"amount":   domain.FormatAmount(expense.Amount),
+		}))
+
+	default:
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "expense.usage"))
+	}
+}
+
+// expensesText формирует список последних расходов и переводов команды
+func (h *ExpenseHandler) expensesText(lang i18n.Lang, expenses []*domain.Expense) markup.Text {
+	if len(expenses) == 0 {
+		return i18n.M(lang, "expense.none")
+	}
+	lines := []markup.Text{i18n.M(lang, "expense.title")}
+	for _, expense := range expenses {
+		params := i18n.P{
+			"id":          expense.ID,
+			"date":        formatDate(lang, expense.CreatedAt),
+			"payer":       expense.PayerName,
+			"amount":      domain.FormatAmount(expense.Amount),
+			"description": expense.Description,
+			"shares":      sharesText(lang, expense.Shares),
+		}
+		key := "expense.entry"
+		switch {
+		case expense.Settlement:
+			key = "expense.entry_settlement"
+			params["recipient"] = expense.Shares[0].Username
+		case expense.Description == "":
+			key = "expense.entry_plain"
+		}
+		lines = append(lines, i18n.M(lang, key, params))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// parseShares разбирает участников расхода вида «имя» или «имя:значение», где значение -
+// доля (shares) или сумма (exact). Некорректное значение передается как нулевое,
+// и сервис сообщает об ошибке с именем участника
+func parseShares(mode domain.SplitMode, args []string) []domain.ExpenseShare {
+	shares := make([]domain.ExpenseShare, len(args))
+	for i, arg := range args {
+		username, value, _ := strings.Cut(arg, ":")
+		shares[i].Username = username
+		switch mode {
+		case domain.SplitShares:
+			shares[i].Value, _ = strconv.ParseInt(value, 10, 64)
+		case domain.SplitExact:
+			shares[i].Value, _ = domain.ParseAmount(value)
+		}
+	}
+	return shares
+}
+
+// sharesText перечисляет доли участников расхода
+func sharesText(lang i18n.Lang, shares []domain.ExpenseShare) string {
+	parts := make([]string, len(shares))
+	for i, share := range shares {
+		parts[i] = i18n.T(lang, "expense.share", i18n.P{"username": share.Username, "amount": domain.FormatAmount(share.Amount)})
+	}
+	return strings.Join(parts, ", ")
+}
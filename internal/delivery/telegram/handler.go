@@ -1,177 +1,354 @@
 package telegram
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"HelpBot/client/telegram"
 	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/markup"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // Handler обрабатывает сообщения от Telegram
 type Handler struct {
-	client         *telegram.Client
-	userService    domain.UserService
-	sessionService domain.SessionService
-	authHandler    *AuthHandler
-	mu             sync.RWMutex
+	client            *telegram.Client
+	userService       domain.UserService
+	sessionService    domain.SessionService
+	transferService   domain.TransferService
+	roleService       domain.RoleService
+	authHandler       *AuthHandler
+	roleHandler       *RoleHandler
+	auditHandler      *AuditHandler
+	rosterHandler     *RosterHandler
+	accountHandler    *AccountHandler
+	importHandler     *ImportHandler
+	backupHandler     *BackupHandler
+	teamHandler       *TeamHandler
+	duesHandler       *DuesHandler
+	expenseHandler    *ExpenseHandler
+	eventHandler      *EventHandler
+	pollHandler       *PollHandler
+	attendanceHandler *AttendanceHandler
+	dutyHandler       *DutyHandler
+	announceHandler   *AnnouncementHandler
+	ticketHandler     *TicketHandler
+	logger            *slog.Logger
+	mu                sync.RWMutex
 }
 
+// Результаты обработки обновления в логе
+const (
+	outcomeOK      = "ok"
+	outcomeError   = "error"
+	outcomeSkipped = "skipped"
+)
+
 // NewHandler создает новый экземпляр Handler
 func NewHandler(
 	client *telegram.Client,
 	userService domain.UserService,
 	sessionService domain.SessionService,
+	transferService domain.TransferService,
+	roleService domain.RoleService,
+	auditService domain.AuditService,
+	twoFactorService domain.TwoFactorService,
+	rosterService domain.RosterService,
+	backupService domain.BackupService,
+	teamService domain.TeamService,
+	duesService domain.DuesService,
+	expenseService domain.ExpenseService,
+	eventService domain.EventService,
+	pollService domain.PollService,
+	attendanceService domain.AttendanceService,
+	dutyService domain.DutyService,
+	announcementService domain.AnnouncementService,
+	ticketService domain.TicketService,
+	logger *slog.Logger,
 ) *Handler {
-	authHandler := NewAuthHandler(client, sessionService, userService)
+	authHandler := NewAuthHandler(client, sessionService, userService, roleService, twoFactorService, logger)
+	roleHandler := NewRoleHandler(client, sessionService, roleService, logger)
+	auditHandler := NewAuditHandler(client, userService, roleService, auditService, logger)
 
 	return &Handler{
-		client:         client,
-		userService:    userService,
-		sessionService: sessionService,
-		authHandler:    authHandler,
+		client:            client,
+		userService:       userService,
+		sessionService:    sessionService,
+		transferService:   transferService,
+		roleService:       roleService,
+		authHandler:       authHandler,
+		roleHandler:       roleHandler,
+		auditHandler:      auditHandler,
+		rosterHandler:     NewRosterHandler(client, sessionService, teamService, roleService, logger),
+		accountHandler:    NewAccountHandler(client, sessionService, logger),
+		importHandler:     NewImportHandler(client, sessionService, rosterService, roleService, logger),
+		backupHandler:     NewBackupHandler(client, backupService, logger),
+		teamHandler:       NewTeamHandler(client, sessionService, teamService, authHandler, logger),
+		duesHandler:       NewDuesHandler(client, duesService, logger),
+		expenseHandler:    NewExpenseHandler(client, expenseService, logger),
+		eventHandler:      NewEventHandler(client, eventService, logger),
+		pollHandler:       NewPollHandler(client, pollService, logger),
+		attendanceHandler: NewAttendanceHandler(client, attendanceService, logger),
+		dutyHandler:       NewDutyHandler(client, dutyService, logger),
+		announceHandler:   NewAnnouncementHandler(client, announcementService, logger),
+		ticketHandler:     NewTicketHandler(client, sessionService, ticketService, logger),
+		logger:            logger,
 	}
 }
 
-// getTeamRosterMessage формирует сообщение со списком команды
-func (h *Handler) getTeamRosterMessage() (string, error) {
-	users, err := h.userService.GetAllUsers()
-	if err != nil {
-		return "", err
-	}
+// HandleUpdate обрабатывает обновление от Telegram. По каждому обновлению в лог
+// записывается итог: обработчик, время обработки и результат
+func (h *Handler) HandleUpdate(update *tgbotapi.Update) {
+	start := time.Now()
+	logger := h.logger.With("update_id", update.UpdateID)
 
-	message := "Состав команды:\n\n"
-	for i, user := range users {
-		message += fmt.Sprintf("%d. %s\n", i+1, user.Username)
+	var name string
+	var err error
+	switch {
+	case update.CallbackQuery != nil:
+		// Обрабатываем нажатия инлайн-кнопок
+		callback := update.CallbackQuery
+		if callback.Message != nil {
+			logger = logger.With("chat_id", callback.Message.Chat.ID)
+		}
+		logger = logger.With("user_id", callback.From.ID)
+		name, err = h.handleCallback(logger, callback)
+	case update.Message != nil && update.Message.From != nil:
+		// Обрабатываем сообщения от пользователей
+		logger = logger.With("chat_id", update.Message.Chat.ID, "user_id", update.Message.From.ID)
+		name, err = h.handleUpdateMessage(logger, update.Message)
 	}
 
-	if len(users) == 0 {
-		message += "Пока никого нет в команде"
+	outcome, level := outcomeOK, slog.LevelInfo
+	attrs := []slog.Attr{
+		slog.String("handler", name),
+		slog.Duration("latency", time.Since(start)),
+	}
+	switch {
+	case err != nil:
+		outcome, level = outcomeError, slog.LevelError
+		attrs = append(attrs, logging.Err(err))
+	case name == "":
+		outcome, level = outcomeSkipped, slog.LevelDebug
 	}
+	attrs = append(attrs, slog.String("outcome", outcome))
+	logger.LogAttrs(context.Background(), level, "Update handled", attrs...)
+}
 
-	return message, nil
+// RunEventReminders рассылает напоминания о предстоящих событиях с указанным интервалом,
+// пока не отменен контекст
+func (h *Handler) RunEventReminders(ctx context.Context, interval time.Duration) {
+	h.eventHandler.Run(ctx, interval)
 }
 
-// HandleUpdate обрабатывает обновление от Telegram
-func (h *Handler) HandleUpdate(update *tgbotapi.Update) {
-	// Обрабатываем только сообщения
-	if update.Message == nil {
-		log.Println("Received update without message, skipping")
-		return
-	}
+// RunPollClosing закрывает опросы с истекшим сроком и публикует их итоги с указанным
+// интервалом, пока не отменен контекст
+func (h *Handler) RunPollClosing(ctx context.Context, interval time.Duration) {
+	h.pollHandler.Run(ctx, interval)
+}
+
+// RunDutyRotation назначает дежурных на наступившие периоды графиков с указанным
+// интервалом, пока не отменен контекст
+func (h *Handler) RunDutyRotation(ctx context.Context, interval time.Duration) {
+	h.dutyHandler.Run(ctx, interval)
+}
 
-	log.Printf("Received message from %s (%d): %s", update.Message.From.UserName, update.Message.Chat.ID, update.Message.Text)
+// RunAnnouncementReminders напоминает о непрочитанных объявлениях с указанным интервалом,
+// пока не отменен контекст
+func (h *Handler) RunAnnouncementReminders(ctx context.Context, interval time.Duration) {
+	h.announceHandler.Run(ctx, interval)
+}
 
-	// Получаем сессию пользователя
-	session, err := h.sessionService.GetSession(update.Message.Chat.ID)
+// handleUpdateMessage обрабатывает сообщение и возвращает имя обработчика для лога
+func (h *Handler) handleUpdateMessage(logger *slog.Logger, message *tgbotapi.Message) (string, error) {
+	// Получаем сессию пользователя по его Telegram ID, а не по чату
+	session, err := h.sessionService.GetSession(message.From.ID)
 	if err != nil {
-		log.Printf("Error getting session: %v", err)
-		return
+		return "session", err
 	}
 
+	// Текст сообщения логируем только после проверки состояния сессии,
+	// чтобы пароли не попадали в лог
 	if session == nil {
-		log.Printf("Session is nil for user %d, creating new session", update.Message.Chat.ID)
+		logger.Debug("Received message", "text", loggableText(message, session), "session", "new")
 	} else {
-		log.Printf("Session state for user %d: %v, authorized: %v", update.Message.Chat.ID, session.State, session.IsAuthorized)
+		logger.Debug("Received message", "text", loggableText(message, session), "state", session.State, "authorized", session.IsAuthorized)
+	}
+
+	// Заблокированные пользователи получают только сообщение о блокировке
+	if session != nil && session.User != nil && !session.User.Active() {
+		lang := sessionLang(session, message.From)
+		return "blocked", h.client.SendText(message.Chat.ID, blockedText(lang, session.User))
 	}
 
 	// Обрабатываем команды
-	if update.Message.IsCommand() {
-		log.Printf("Processing command: %s", update.Message.Command())
-		h.handleCommand(update.Message, session)
-		return
+	if message.IsCommand() {
+		return h.handleCommand(message, session)
+	}
+
+	// В группах бот отвечает только на команды, а переписку участников пропускает
+	if !message.Chat.IsPrivate() {
+		return "", nil
 	}
 
 	// Обрабатываем текстовые сообщения
-	log.Printf("Processing text message: %s", update.Message.Text)
-	h.handleMessage(update.Message, session)
+	return h.handleMessage(logger, message, session)
 }
 
 // handleCommand обрабатывает команды
-func (h *Handler) handleCommand(message *tgbotapi.Message, session *domain.UserSession) {
+func (h *Handler) handleCommand(message *tgbotapi.Message, session *domain.UserSession) (string, error) {
 	var err error
+	name := "command." + message.Command()
+	lang := sessionLang(session, message.From)
 
 	switch message.Command() {
 	case "start":
+		if isInviteCommand(message) {
+			// Ссылка-приглашение вида t.me/<бот>?start=<код>
+			err = h.authHandler.HandleInvite(message)
+			break
+		}
 		err = h.authHandler.HandleStart(message)
+	case "invite":
+		err = h.authHandler.HandleInvite(message)
 	case "help":
-		err = h.client.SendMessage(message.Chat.ID, "Доступные команды:\n/start - начать работу с ботом\n/help - показать справку")
+		err = h.client.SendText(message.Chat.ID, i18n.M(lang, "command.help"))
+	case "language":
+		err = h.handleSettings(message, session)
+	case "transfers":
+		err = h.handleTransfers(message, session)
+	case "setrole":
+		err = h.roleHandler.HandleSetRole(message, session)
+	case "2fa":
+		err = h.authHandler.HandleTwoFactorSettings(message, session)
+	case "audit":
+		err = h.auditHandler.HandleAudit(message, session)
+	case "auditcsv":
+		err = h.auditHandler.HandleAuditExport(message, session)
+	case "users":
+		err = h.rosterHandler.HandleRoster(message, session)
+	case "roster":
+		err = h.importHandler.HandleScreen(message, session)
+	case "suspend", "deactivate", "deleteuser", "restore":
+		err = h.accountHandler.HandleStatusCommand(message, session)
+	case "backup":
+		err = h.backupHandler.HandleBackupCommand(message, session)
+	case "team":
+		err = h.teamHandler.HandleTeamCommand(message, session)
+	case "balance":
+		err = h.duesHandler.HandleBalance(message, session)
+	case "dues":
+		err = h.duesHandler.HandleDuesCommand(message, session)
+	case "debtors":
+		err = h.duesHandler.HandleDebtors(message, session)
+	case "expense":
+		err = h.expenseHandler.HandleExpenseCommand(message, session)
+	case "settle":
+		err = h.expenseHandler.HandleSettleCommand(message, session)
+	case "event":
+		err = h.eventHandler.HandleEventCommand(message, session)
+	case "poll":
+		err = h.pollHandler.HandlePollCommand(message, session)
+	case "attendance":
+		err = h.attendanceHandler.HandleAttendanceCommand(message, session)
+	case "duty":
+		err = h.dutyHandler.HandleDutyCommand(message, session)
+	case "announce":
+		err = h.announceHandler.HandleAnnounceCommand(message, session)
+	case "ticket":
+		err = h.ticketHandler.HandleTicketCommand(message, session)
 	default:
-		err = h.client.SendMessage(message.Chat.ID, "Неизвестная команда. Используйте /help для получения списка доступных команд.")
+		name = "command.unknown"
+		err = h.client.SendText(message.Chat.ID, i18n.M(lang, "command.unknown"))
 	}
 
-	if err != nil {
-		log.Printf("Error handling command: %v", err)
-	}
+	return name, err
 }
 
-// handleMessage обрабатывает текстовые сообщения
-func (h *Handler) handleMessage(message *tgbotapi.Message, session *domain.UserSession) {
+// handleMessage обрабатывает текстовые сообщения и возвращает имя обработчика для лога
+func (h *Handler) handleMessage(logger *slog.Logger, message *tgbotapi.Message, session *domain.UserSession) (string, error) {
 	var err error
+	var name string
 
 	// Если сессия не существует, создаем ее
 	if session == nil {
-		err = h.authHandler.HandleStart(message)
-		if err != nil {
-			log.Printf("Error handling start: %v", err)
-		}
-		return
+		return "start", h.authHandler.HandleStart(message)
 	}
 
 	// Обрабатываем сообщения в зависимости от состояния сессии
-	if session.State == domain.StateAwaitingUsername || session.State == domain.StateAwaitingPassword {
+	if session.State == domain.StateAwaitingRoleName && session.IsAuthorized {
+		// Администратор вводит имя новой роли
+		name = "input.role_name"
+		err = h.roleHandler.HandleRoleName(message, session)
+	} else if session.State == domain.StateAwaitingTicketReply && session.IsAuthorized {
+		// Пользователь пишет ответ по обращению в поддержку
+		name = "input.ticket_reply"
+		err = h.ticketHandler.HandleReplyText(message, session)
+	} else if session.State == domain.StateAwaitingInvitePassword {
+		// Приглашенный пользователь задает пароль
+		name = "input.invite"
+		err = h.authHandler.HandleInvitePassword(message, session)
+	} else if isTwoFactorState(session.State) {
+		// Пользователь вводит код 2FA
+		name = "input.2fa"
+		err = h.authHandler.HandleTwoFactor(message, session)
+	} else if session.State == domain.StateAwaitingUsername || session.State == domain.StateAwaitingPassword {
 		// Пользователь в процессе авторизации или регистрации
-		if message.Text == "Войти" {
+		button := buttonKey(message.Text)
+		if button == telegram.BtnLogin {
 			// Сбрасываем состояние и начинаем процесс входа
+			name = buttonHandlerName(button)
 			session.State = domain.StateNone
 			session.LastCommand = ""
-			if err := h.sessionService.UpdateSession(message.Chat.ID, session); err != nil {
-				log.Printf("Error updating session: %v", err)
+			if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
+				logger.Warn("Error updating session", logging.Err(err))
 			}
 			err = h.authHandler.HandleLogin(message)
-		} else if message.Text == "Зарегистрироваться" {
+		} else if button == telegram.BtnRegister {
 			// Сбрасываем состояние и начинаем процесс регистрации
+			name = buttonHandlerName(button)
 			session.State = domain.StateNone
 			session.LastCommand = ""
-			if err := h.sessionService.UpdateSession(message.Chat.ID, session); err != nil {
-				log.Printf("Error updating session: %v", err)
+			if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
+				logger.Warn("Error updating session", logging.Err(err))
 			}
 			err = h.authHandler.HandleRegister(message)
 		} else {
 			// Продолжаем текущий процесс
-			log.Printf("Processing input for state %v, last command: %s", session.State, session.LastCommand)
+			logger.Debug("Processing input", "state", session.State, "last_command", session.LastCommand)
 
 			// Определяем, какой процесс был начат по LastCommand
 			if session.LastCommand == "register" {
+				name = "input.register"
 				err = h.authHandler.HandleRegister(message)
 			} else if session.LastCommand == "login" {
+				name = "input.login"
 				err = h.authHandler.HandleLogin(message)
 			} else {
 				// Если LastCommand не установлен, пробуем определить по состоянию
-				log.Printf("LastCommand not set, trying to determine by state")
+				logger.Debug("LastCommand not set, trying to determine by state")
+				name = "input.login"
 				if session.State == domain.StateAwaitingUsername {
 					// Если мы ожидаем имя пользователя, предполагаем, что это вход
 					err = h.authHandler.HandleLogin(message)
 				} else {
 					// Если мы ожидаем пароль, проверяем, существует ли пользователь
-					users, err := h.userService.GetAllUsers()
+					existing, err := h.userService.GetUserByUsername(session.User.Username)
 					if err == nil {
-						userExists := false
-						for _, u := range users {
-							if u.Username == session.User.Username {
-								userExists = true
-								break
-							}
-						}
-
-						if userExists {
+						if existing != nil {
 							// Если пользователь существует, это вход
 							err = h.authHandler.HandleLogin(message)
 						} else {
 							// Если пользователь не существует, это регистрация
+							name = "input.register"
 							err = h.authHandler.HandleRegister(message)
 						}
 					} else {
@@ -181,28 +358,264 @@ func (h *Handler) handleMessage(message *tgbotapi.Message, session *domain.UserS
 				}
 			}
 		}
+	} else if message.Document != nil && session.IsAuthorized {
+		// Администратор загружает файл состава команды для импорта
+		name = "input.roster_file"
+		err = h.importHandler.HandleDocument(message, session)
 	} else {
-		// Обрабатываем сообщения авторизованного пользователя
-		switch message.Text {
-		case "Войти":
+		// Обрабатываем сообщения авторизованного пользователя.
+		// Кнопки сопоставляются по ключу, поэтому работают на любом языке
+		lang := sessionLang(session, message.From)
+		button := buttonKey(message.Text)
+		name = buttonHandlerName(button)
+		switch button {
+		case telegram.BtnLogin:
 			err = h.authHandler.HandleLogin(message)
-		case "Зарегистрироваться":
+		case telegram.BtnRegister:
 			err = h.authHandler.HandleRegister(message)
-		case "Выйти":
+		case telegram.BtnLogout:
 			err = h.authHandler.HandleLogout(message)
-		case "Мой профиль":
+		case telegram.BtnBalance:
+			err = h.duesHandler.HandleBalance(message, session)
+		case telegram.BtnProfile:
 			// Здесь будет обработка профиля пользователя
-			err = h.client.SendMessage(message.Chat.ID, "Функция просмотра профиля  находится в разработке.")
-		case "Пополнить баланс":
-			// Получаем список пользователей
-			err = h.client.SendMessage(message.Chat.ID, "Функция пополнения баланса находится в разработке.")
+			err = h.client.SendText(message.Chat.ID, i18n.M(lang, "common.in_development", i18n.P{"feature": i18n.T(lang, button)}))
+		case telegram.BtnUsers:
+			err = h.rosterHandler.HandleRoster(message, session)
+		case telegram.BtnUserManagement:
+			err = h.importHandler.HandleScreen(message, session)
+		case telegram.BtnRoles:
+			if !session.IsAuthorized {
+				err = h.authHandler.HandleStart(message)
+				break
+			}
+			err = h.roleHandler.HandleRoles(message, session)
+		case telegram.BtnAudit:
+			err = h.auditHandler.HandleAudit(message, session)
+		case telegram.BtnSettings:
+			err = h.handleSettings(message, session)
 		default:
-			
-			err = h.client.SendMessage(message.Chat.ID, "Неизвестная команда. Используйте кнопки для навигации.")
+			err = h.client.SendText(message.Chat.ID, i18n.M(lang, "message.unknown"))
 		}
 	}
 
+	return name, err
+}
+
+// buttonHandlerName возвращает имя обработчика кнопки для лога
+func buttonHandlerName(button string) string {
+	if button == "" {
+		return "message.unknown"
+	}
+	return "button." + strings.TrimPrefix(button, "btn.")
+}
+
+// handleTransfers показывает администратору ожидающие запросы на перенос аккаунтов
+func (h *Handler) handleTransfers(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized || !h.roleService.Can(session.User, domain.PermApproveTransfers) {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.forbidden"))
+	}
+
+	transfers, err := h.transferService.GetPendingTransfers()
 	if err != nil {
-		log.Printf("Error handling message: %v", err)
+		return err
+	}
+	if len(transfers) == 0 {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "transfer.none"))
+	}
+
+	for _, transfer := range transfers {
+		username := fmt.Sprintf("#%d", transfer.UserID)
+		if user, err := h.userService.GetUser(transfer.UserID); err == nil && user != nil {
+			username = user.Username
+		}
+
+		text := i18n.M(lang, "transfer.request", i18n.P{
+			"id":          transfer.ID,
+			"username":    username,
+			"telegram_id": transfer.ToTelegramID,
+			"created":     formatTime(lang, transfer.CreatedAt),
+		})
+		if err := h.client.SendTextWithKeyboard(message.Chat.ID, text, h.client.GetTransferKeyboard(lang, transfer.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleCallback обрабатывает нажатия инлайн-кнопок и возвращает имя обработчика для лога
+func (h *Handler) handleCallback(logger *slog.Logger, callback *tgbotapi.CallbackQuery) (string, error) {
+	logger.Debug("Received callback", "data", callback.Data)
+
+	action, param, _ := strings.Cut(callback.Data, ":")
+	name := "callback." + action
+
+	var answer string
+	session, err := h.sessionService.GetSession(callback.From.ID)
+	lang := sessionLang(session, callback.From)
+	switch {
+	case err != nil:
+	case callback.Message == nil:
+		answer = i18n.T(lang, "callback.stale")
+	case session != nil && session.User != nil && !session.User.Active():
+		name = "blocked"
+		answer = i18n.T(lang, "callback.blocked")
+	case action == "lang" && session != nil:
+		// Язык можно выбрать и до входа в систему
+		answer, err = h.handleLanguageCallback(callback, session, param)
+	case session == nil || !session.IsAuthorized:
+		answer = i18n.T(lang, "callback.auth_required")
+	case action == "transfer_approve" || action == "transfer_reject":
+		answer, err = h.handleTransferCallback(callback, session, action, param)
+	case action == "team_switch":
+		answer, err = h.teamHandler.HandleSwitchCallback(callback, session, param)
+	case action == "rsvp":
+		answer, err = h.eventHandler.HandleRSVPCallback(callback, session, param)
+	case action == "event_show":
+		answer, err = h.eventHandler.HandleShowCallback(callback, session, param)
+	case action == "vote":
+		answer, err = h.pollHandler.HandleVoteCallback(callback, session, param)
+	case action == "poll_show":
+		answer, err = h.pollHandler.HandleShowCallback(callback, session, param)
+	case action == "att":
+		answer, err = h.attendanceHandler.HandleMarkCallback(callback, session, param)
+	case action == "checkin":
+		answer, err = h.attendanceHandler.HandleCheckInCallback(callback, session, param)
+	case action == "duty_done":
+		answer, err = h.dutyHandler.HandleDoneCallback(callback, session, param)
+	case action == "duty_swap":
+		answer, err = h.dutyHandler.HandleSwapCallback(callback, session, param)
+	case action == "ack":
+		answer, err = h.announceHandler.HandleAcknowledgeCallback(callback, session, param)
+	case action == "ticket":
+		answer, err = h.ticketHandler.HandleCallback(callback, session, param)
+	case action == "roster_next":
+		answer, err = h.rosterHandler.HandleNextPage(callback, session)
+	case action == "roster_export":
+		answer, err = h.importHandler.HandleExportCallback(callback, session, param)
+	case action == "roster_import":
+		answer, err = h.importHandler.HandleImportCallback(callback, session, param)
+	case strings.HasPrefix(action, "role_"):
+		answer, err = h.roleHandler.HandleCallback(callback, session, action, param)
+	default:
+		name = "callback.unknown"
+		answer = i18n.T(lang, "callback.unknown")
+	}
+
+	if err != nil {
+		answer = i18n.T(lang, "callback.error", i18n.P{"error": errorText(logger, lang, err)})
+	}
+
+	if answerErr := h.client.AnswerCallback(callback.ID, answer); answerErr != nil && err == nil {
+		err = answerErr
+	}
+	return name, err
+}
+
+// handleTransferCallback одобряет или отклоняет запрос на перенос аккаунта
+func (h *Handler) handleTransferCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, action, param string) (string, error) {
+	transferID, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return "", i18n.NewError("error.transfer_invalid")
+	}
+
+	var transfer *domain.AccountTransfer
+	if action == "transfer_approve" {
+		transfer, err = h.transferService.ApproveTransfer(session.User.ID, transferID)
+	} else {
+		transfer, err = h.transferService.RejectTransfer(session.User.ID, transferID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	user, err := h.userService.GetUser(transfer.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	lang := sessionLang(session, callback.From)
+	noticeLang := userLang(user)
+
+	var result string
+	var notice markup.Text
+	if transfer.Status == domain.TransferStatusApproved {
+		result = "approved"
+		notice = i18n.M(noticeLang, "transfer.approved_notice")
+
+		// Завершаем сессию на прежнем Telegram-аккаунте
+		if transfer.FromTelegramID != 0 {
+			if err := h.sessionService.Logout(transfer.FromTelegramID); err != nil {
+				h.logger.Warn("Error closing session after transfer", "transfer_id", transfer.ID, "telegram_id", transfer.FromTelegramID, logging.Err(err))
+			}
+		}
+	} else {
+		result = "rejected"
+		notice = i18n.M(noticeLang, "transfer.rejected_notice")
+	}
+
+	if err := h.client.SendText(transfer.ToChatID, notice); err != nil {
+		h.logger.Warn("Error notifying about transfer", "transfer_id", transfer.ID, logging.Err(err))
+	}
+
+	username := fmt.Sprintf("#%d", transfer.UserID)
+	if user != nil {
+		username = user.Username
+	}
+	text := i18n.M(lang, "transfer."+result+"_by", i18n.P{
+		"id":       transfer.ID,
+		"username": username,
+		"admin":    session.User.Username,
+	})
+	if err := h.client.EditText(callback.Message.Chat.ID, callback.Message.MessageID, text); err != nil {
+		h.logger.Warn("Error editing transfer message", "transfer_id", transfer.ID, logging.Err(err))
+	}
+
+	return i18n.T(lang, "transfer."+result), nil
+}
+
+// handleSettings показывает настройки пользователя: выбор языка интерфейса
+func (h *Handler) handleSettings(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	text := i18n.M(lang, "settings.language", i18n.P{"language": i18n.T(lang, "lang."+string(lang))})
+	return h.client.SendTextWithKeyboard(message.Chat.ID, text, h.client.GetLanguageKeyboard(lang))
+}
+
+// handleLanguageCallback меняет язык интерфейса. Выбор авторизованного пользователя
+// сохраняется в его профиле, до входа - только в сессии
+func (h *Handler) handleLanguageCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, code string) (string, error) {
+	lang, ok := i18n.Parse(code)
+	if !ok {
+		return i18n.T(sessionLang(session, callback.From), "callback.unknown"), nil
+	}
+
+	if session.IsAuthorized && session.User != nil {
+		if err := h.userService.SetLanguage(session.User.ID, string(lang)); err != nil {
+			return "", err
+		}
+		session.User.Language = string(lang)
+	}
+	session.Language = string(lang)
+	if err := h.sessionService.UpdateSession(callback.From.ID, session); err != nil {
+		return "", err
+	}
+
+	text := i18n.M(lang, "settings.language", i18n.P{"language": i18n.T(lang, "lang."+string(lang))})
+	if err := h.client.EditTextWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, h.client.GetLanguageKeyboard(lang)); err != nil {
+		h.logger.Warn("Error editing language message", "chat_id", callback.Message.Chat.ID, logging.Err(err))
+	}
+
+	// Обычную клавиатуру нельзя изменить, поэтому отправляем ее заново на новом языке
+	var keyboard tgbotapi.ReplyKeyboardMarkup
+	if session.IsAuthorized {
+		var err error
+		if keyboard, err = h.authHandler.mainMenuKeyboard(callback.From.ID, lang); err != nil {
+			return "", err
+		}
+	} else {
+		keyboard = h.client.GetLoginKeyboard(lang)
 	}
+	changed := i18n.M(lang, "settings.language_changed")
+	return changed.String(), h.client.SendTextWithKeyboard(callback.Message.Chat.ID, changed, keyboard)
 }
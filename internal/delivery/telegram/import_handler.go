@@ -0,0 +1,240 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/csv"
+	"log/slog"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/markup"
+)
+
+// Ограничения предварительного просмотра импорта
+const (
+	maxImportFileSize   = 1 << 20 // Максимальный размер файла состава команды
+	maxImportPreviewRow = 30      // Количество строк плана, показываемых в сообщении
+)
+
+// ImportHandler обрабатывает импорт и выгрузку состава команды
+type ImportHandler struct {
+	client         *telegram.Client
+	sessionService domain.SessionService
+	rosterService  domain.RosterService
+	roleService    domain.RoleService
+	logger         *slog.Logger
+}
+
+// NewImportHandler создает новый экземпляр ImportHandler
+func NewImportHandler(client *telegram.Client, sessionService domain.SessionService, rosterService domain.RosterService, roleService domain.RoleService, logger *slog.Logger) *ImportHandler {
+	return &ImportHandler{
+		client:         client,
+		sessionService: sessionService,
+		rosterService:  rosterService,
+		roleService:    roleService,
+		logger:         logger,
+	}
+}
+
+// HandleScreen показывает экран состава команды: описание импорта и кнопки выгрузки
+func (h *ImportHandler) HandleScreen(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized || !h.roleService.Can(session.User, domain.PermViewUsers) {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.forbidden"))
+	}
+
+	text := i18n.M(lang, "import.screen")
+	if h.roleService.Can(session.User, domain.PermManageUsers) {
+		text = markup.Join("\n\n", text, i18n.M(lang, "import.usage"))
+	}
+	return h.client.SendTextWithKeyboard(message.Chat.ID, text, h.client.GetRosterKeyboard(lang))
+}
+
+// HandleDocument проверяет загруженный файл состава команды и показывает план изменений
+func (h *ImportHandler) HandleDocument(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if !h.roleService.Can(session.User, domain.PermManageUsers) {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.forbidden"))
+	}
+
+	document := message.Document
+	format, ok := domain.RosterFormatOf(document.FileName)
+	if !ok {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "import.unsupported"))
+	}
+	if document.FileSize > maxImportFileSize {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "import.too_large", i18n.P{"size": maxImportFileSize >> 10}))
+	}
+	data, err := h.client.DownloadFile(document.FileID, maxImportFileSize)
+	if err != nil {
+		return err
+	}
+
+	plan, err := h.rosterService.Import(session.User.ID, format, data, false)
+	if err != nil {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "import.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+
+	text := h.planText(lang, document.FileName, plan)
+	if plan.Count(domain.RosterInvalid) > 0 || plan.Count(domain.RosterCreate)+plan.Count(domain.RosterUpdate) == 0 {
+		session.Import = nil
+		if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
+			return err
+		}
+		return h.client.SendText(message.Chat.ID, text)
+	}
+
+	// Файл применяется только после подтверждения: до этого он хранится в сессии
+	session.Import = &domain.RosterDraft{FileName: document.FileName, Format: format, Data: data}
+	if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
+		return err
+	}
+	return h.client.SendTextWithKeyboard(message.Chat.ID, text, h.client.GetImportKeyboard(lang))
+}
+
+// HandleImportCallback применяет или отменяет импорт, ожидающий подтверждения
+func (h *ImportHandler) HandleImportCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, param string) (string, error) {
+	lang := sessionLang(session, callback.From)
+	if !h.roleService.Can(session.User, domain.PermManageUsers) {
+		return i18n.T(lang, "callback.forbidden"), nil
+	}
+	draft := session.Import
+	if draft == nil {
+		return i18n.T(lang, "callback.stale"), nil
+	}
+	session.Import = nil
+	if err := h.sessionService.UpdateSession(callback.From.ID, session); err != nil {
+		return "", err
+	}
+
+	chatID, messageID := callback.Message.Chat.ID, callback.Message.MessageID
+	if param != "apply" {
+		return i18n.T(lang, "import.cancelled"), h.client.EditText(chatID, messageID, i18n.M(lang, "import.cancelled"))
+	}
+
+	// Файл разбирается заново: с момента просмотра данные могли измениться
+	result, err := h.rosterService.Import(session.User.ID, draft.Format, draft.Data, true)
+	if err != nil {
+		text := i18n.M(lang, "import.failed", i18n.P{"error": errorText(h.logger, lang, err)})
+		if result == nil || result.Done(domain.RosterCreate)+result.Done(domain.RosterUpdate) == 0 {
+			return "", h.client.EditText(chatID, messageID, text)
+		}
+		// Часть строк уже применена: сообщаем об этом и отдаем выданные коды приглашения
+		text = markup.Join("\n", text, i18n.M(lang, "import.partial", i18n.P{
+			"create": result.Done(domain.RosterCreate),
+			"update": result.Done(domain.RosterUpdate),
+		}))
+		if err := h.client.EditText(chatID, messageID, text); err != nil {
+			return "", err
+		}
+		return "", h.sendInvites(chatID, lang, result)
+	}
+	if err := h.client.EditText(chatID, messageID, h.planText(lang, draft.FileName, result)); err != nil {
+		return "", err
+	}
+	return i18n.T(lang, "import.applied"), h.sendInvites(chatID, lang, result)
+}
+
+// sendInvites отправляет файл с кодами приглашения пользователей, созданных импортом
+func (h *ImportHandler) sendInvites(chatID int64, lang i18n.Lang, result *domain.RosterImport) error {
+	if result.Done(domain.RosterCreate) == 0 {
+		return nil
+	}
+	invites, err := invitesCSV(result)
+	if err != nil {
+		return err
+	}
+	caption := i18n.T(lang, "import.invites_caption", i18n.P{"expires": formatTime(lang, result.ExpiresAt)})
+	return h.client.SendDocument(chatID, "invites.csv", invites, caption)
+}
+
+// HandleExportCallback отправляет состав команды файлом в выбранном формате
+func (h *ImportHandler) HandleExportCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, param string) (string, error) {
+	lang := sessionLang(session, callback.From)
+	format := domain.RosterFormat(param)
+	if format != domain.RosterCSV && format != domain.RosterJSON {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+
+	data, err := h.rosterService.Export(session.User.ID, format)
+	if err != nil {
+		return "", err
+	}
+	return "", h.client.SendDocument(callback.Message.Chat.ID, "roster."+string(format), data, i18n.T(lang, "import.export_caption"))
+}
+
+// planText формирует описание плана импорта или его результата
+func (h *ImportHandler) planText(lang i18n.Lang, fileName string, plan *domain.RosterImport) markup.Text {
+	title := "import.preview"
+	if plan.Applied {
+		title = "import.result"
+	}
+	lines := []markup.Text{
+		i18n.M(lang, title, i18n.P{"file": fileName}),
+		i18n.M(lang, "import.summary", i18n.P{
+			"create":    plan.Count(domain.RosterCreate),
+			"update":    plan.Count(domain.RosterUpdate),
+			"unchanged": plan.Count(domain.RosterUnchanged),
+			"invalid":   plan.Count(domain.RosterInvalid),
+		}),
+		markup.Raw(""),
+	}
+
+	shown := 0
+	for _, change := range plan.Changes {
+		var line markup.Text
+		switch change.Action {
+		case domain.RosterCreate:
+			role := change.Record.Role
+			if role == "" {
+				role = domain.RoleUser
+			}
+			line = i18n.M(lang, "import.line_create", i18n.P{"username": change.Record.Username, "role": role})
+		case domain.RosterUpdate:
+			line = i18n.M(lang, "import.line_update", i18n.P{"username": change.Record.Username, "fields": strings.Join(change.Fields, ", ")})
+		case domain.RosterInvalid:
+			line = i18n.M(lang, "import.line_invalid", i18n.P{"line": change.Line, "error": errorText(h.logger, lang, change.Err)})
+		default:
+			continue
+		}
+		if shown == maxImportPreviewRow {
+			lines = append(lines, i18n.M(lang, "import.more"))
+			break
+		}
+		lines = append(lines, line)
+		shown++
+	}
+
+	switch {
+	case plan.Applied:
+	case plan.Count(domain.RosterInvalid) > 0:
+		lines = append(lines, markup.Raw(""), i18n.M(lang, "import.fix_errors"))
+	case plan.Count(domain.RosterCreate)+plan.Count(domain.RosterUpdate) == 0:
+		lines = append(lines, i18n.M(lang, "import.nothing"))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// invitesCSV формирует файл с кодами приглашения новых пользователей
+func invitesCSV(result *domain.RosterImport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"username", "invite_code", "expires_at"}); err != nil {
+		return nil, err
+	}
+	expires := result.ExpiresAt.Format("2006-01-02 15:04")
+	for _, change := range result.Changes {
+		if change.Invite == "" {
+			continue
+		}
+		if err := w.Write([]string{change.Record.Username, change.Invite, expires}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -2,81 +2,84 @@ package telegram
 
 import (
 	telegram "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	buttons "HelpBot/client/telegram"
+	"HelpBot/internal/i18n"
 )
 
 // CreateMainKeyboard создает основную клавиатуру
-func CreateMainKeyboard() telegram.ReplyKeyboardMarkup {
+func CreateMainKeyboard(lang i18n.Lang) telegram.ReplyKeyboardMarkup {
 	return telegram.ReplyKeyboardMarkup{
 		Keyboard: [][]telegram.KeyboardButton{
-			{{Text: "Войти"}},
-			{{Text: "Зарегистрироваться"}},
+			{{Text: i18n.T(lang, buttons.BtnLogin)}},
+			{{Text: i18n.T(lang, buttons.BtnRegister)}},
 		},
 		ResizeKeyboard: true,
 	}
 }
 
 // CreateAuthenticatedKeyboard создает клавиатуру для авторизованного пользователя
-func CreateAuthenticatedKeyboard() telegram.ReplyKeyboardMarkup {
+func CreateAuthenticatedKeyboard(lang i18n.Lang) telegram.ReplyKeyboardMarkup {
 	return telegram.ReplyKeyboardMarkup{
 		Keyboard: [][]telegram.KeyboardButton{
-			{{Text: "Выйти"}},
+			{{Text: i18n.T(lang, buttons.BtnLogout)}},
 		},
 		ResizeKeyboard: true,
 	}
 }
 
 // CreateCancelKeyboard создает клавиатуру с кнопкой отмены
-func CreateCancelKeyboard() telegram.ReplyKeyboardMarkup {
+func CreateCancelKeyboard(lang i18n.Lang) telegram.ReplyKeyboardMarkup {
 	return telegram.ReplyKeyboardMarkup{
 		Keyboard: [][]telegram.KeyboardButton{
-			{{Text: "Отмена"}},
+			{{Text: i18n.T(lang, buttons.BtnCancel)}},
 		},
 		ResizeKeyboard: true,
 	}
 }
 
 // CreatePaymentKeyboard создает клавиатуру для выбора способа оплаты
-func CreatePaymentKeyboard() telegram.ReplyKeyboardMarkup {
+func CreatePaymentKeyboard(lang i18n.Lang) telegram.ReplyKeyboardMarkup {
 	return telegram.ReplyKeyboardMarkup{
 		Keyboard: [][]telegram.KeyboardButton{
 			{{Text: "1"}, {Text: "2"}},
-			{{Text: "Пополнить баланс"}},
-			{{Text: "Назад"}},
+			{{Text: i18n.T(lang, buttons.BtnBalance)}},
+			{{Text: i18n.T(lang, buttons.BtnBack)}},
 		},
 		ResizeKeyboard: true,
 	}
 }
 
 // CreatePaymentProcessKeyboard создает клавиатуру для процесса оплаты
-func CreatePaymentProcessKeyboard() telegram.ReplyKeyboardMarkup {
+func CreatePaymentProcessKeyboard(lang i18n.Lang) telegram.ReplyKeyboardMarkup {
 	return telegram.ReplyKeyboardMarkup{
 		Keyboard: [][]telegram.KeyboardButton{
-			{{Text: "Произвести оплату"}},
-			{{Text: "Подтвердить оплату"}},
-			{{Text: "Отменить"}},
-			{{Text: "Назад к способам оплаты"}},
+			{{Text: i18n.T(lang, buttons.BtnPay)}},
+			{{Text: i18n.T(lang, buttons.BtnConfirmPayment)}},
+			{{Text: i18n.T(lang, buttons.BtnCancelPayment)}},
+			{{Text: i18n.T(lang, buttons.BtnBackToPaymentMethods)}},
 		},
 		ResizeKeyboard: true,
 	}
 }
 
 // CreateTeamKeyboard создает клавиатуру для информации о команде
-func CreateTeamKeyboard() telegram.ReplyKeyboardMarkup {
+func CreateTeamKeyboard(lang i18n.Lang) telegram.ReplyKeyboardMarkup {
 	return telegram.ReplyKeyboardMarkup{
 		Keyboard: [][]telegram.KeyboardButton{
-			{{Text: "Состав"}},
-			{{Text: "Назад"}},
+			{{Text: i18n.T(lang, buttons.BtnRoster)}},
+			{{Text: i18n.T(lang, buttons.BtnBack)}},
 		},
 		ResizeKeyboard: true,
 	}
 }
 
 // CreateLoginKeyboard создает клавиатуру для авторизации
-func CreateLoginKeyboard() telegram.ReplyKeyboardMarkup {
+func CreateLoginKeyboard(lang i18n.Lang) telegram.ReplyKeyboardMarkup {
 	return telegram.ReplyKeyboardMarkup{
 		Keyboard: [][]telegram.KeyboardButton{
-			{{Text: "Войти"}},
-			{{Text: "Зарегистрироваться"}},
+			{{Text: i18n.T(lang, buttons.BtnLogin)}},
+			{{Text: i18n.T(lang, buttons.BtnRegister)}},
 		},
 		ResizeKeyboard: true,
 	}
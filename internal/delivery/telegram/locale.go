@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
+)
+
+// sessionLang определяет язык интерфейса: выбранный в сессии, сохраненный в профиле
+// или, если пользователь его не выбирал, язык его клиента Telegram
+func sessionLang(session *domain.UserSession, from *tgbotapi.User) i18n.Lang {
+	if session != nil {
+		if lang, ok := i18n.Parse(session.Language); ok {
+			return lang
+		}
+		if session.User != nil {
+			if lang, ok := i18n.Parse(session.User.Language); ok {
+				return lang
+			}
+		}
+	}
+	if from != nil {
+		return i18n.FromCode(from.LanguageCode)
+	}
+	return i18n.Default
+}
+
+// userLang возвращает язык, на котором следует писать пользователю вне его сессии,
+// например при рассылке уведомлений
+func userLang(user *domain.User) i18n.Lang {
+	if user != nil {
+		if lang, ok := i18n.Parse(user.Language); ok {
+			return lang
+		}
+	}
+	return i18n.Default
+}
+
+// errorText возвращает текст ошибки для пользователя на его языке.
+// Внутренние ошибки без текста в каталоге пользователю не показываются
+func errorText(logger *slog.Logger, lang i18n.Lang, err error) string {
+	var localizable i18n.Localizable
+	if errors.As(err, &localizable) {
+		return localizable.Localize(lang)
+	}
+	logger.Warn("Internal error shown to user as generic", logging.Err(err))
+	return i18n.T(lang, "error.internal")
+}
+
+// formatTime форматирует дату и время в принятом для языка виде
+func formatTime(lang i18n.Lang, t time.Time) string {
+	return t.Format(i18n.T(lang, "format.datetime"))
+}
+
+// formatDate форматирует календарную дату в принятом для языка виде
+func formatDate(lang i18n.Lang, t time.Time) string {
+	return t.Format(i18n.T(lang, "format.date"))
+}
+
+// buttonKey возвращает ключ кнопки по ее тексту на любом языке или пустую строку
+func buttonKey(text string) string {
+	key, _ := i18n.MatchButton(text)
+	return key
+}
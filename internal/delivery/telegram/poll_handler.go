@@ -0,0 +1,383 @@
+package telegram
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/markup"
+)
+
+// PollHandler обрабатывает опросы команды: создание, голосование, живые результаты и итоги.
+// Если к команде привязана группа (/team group), опрос публикуется в ней, из какого бы чата
+// его ни создали, иначе рассылается каждому участнику, который может голосовать
+type PollHandler struct {
+	client      *telegram.Client
+	pollService domain.PollService
+	logger      *slog.Logger
+}
+
+// NewPollHandler создает новый экземпляр PollHandler
+func NewPollHandler(client *telegram.Client, pollService domain.PollService, logger *slog.Logger) *PollHandler {
+	return &PollHandler{
+		client:      client,
+		pollService: pollService,
+		logger:      logger,
+	}
+}
+
+// HandlePollCommand обрабатывает команды /poll, /poll <номер>, /poll close <номер> и
+// /poll add <вопрос> | <вариант> | <вариант> [| ...] [-- multi anon role=<роль> until=<ГГГГ-ММ-ДД ЧЧ:ММ>]
+func (h *PollHandler) HandlePollCommand(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	arguments := strings.TrimSpace(message.CommandArguments())
+	command, rest, _ := strings.Cut(arguments, " ")
+	failed := func(err error) error {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "poll.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+
+	switch {
+	case arguments == "":
+		polls, err := h.pollService.OpenPolls(session.User.ID)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, pollsText(lang, polls))
+
+	case command == "add":
+		poll, err := parsePoll(rest)
+		if err != nil {
+			return failed(err)
+		}
+		voters, err := h.pollService.CreatePoll(session.User.ID, poll)
+		if err != nil {
+			return failed(err)
+		}
+		if poll.ChatID != 0 {
+			// В группе команды опрос публикуется одним сообщением для всей команды
+			if err := h.post(poll.ChatID, lang, poll); err != nil || message.Chat.ID == poll.ChatID {
+				return err
+			}
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "poll.published", i18n.P{"id": poll.ID, "question": poll.Question}))
+		}
+		sent := 0
+		for _, user := range voters {
+			if user.ChatID != 0 && h.post(user.ChatID, userLang(user), poll) == nil {
+				sent++
+			}
+		}
+		return h.client.SendText(message.Chat.ID, i18n.MN(lang, "poll.created", sent, i18n.P{
+			"id":       poll.ID,
+			"question": poll.Question,
+		}))
+
+	case command == "close":
+		pollID, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+		if err != nil {
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "poll.usage"))
+		}
+		update, err := h.pollService.ClosePoll(session.User.ID, pollID)
+		if err != nil {
+			return failed(err)
+		}
+		if !h.finish(update, message.Chat.ID) {
+			return h.client.SendText(message.Chat.ID, summaryText(lang, update.Poll))
+		}
+		return nil
+
+	default:
+		pollID, err := strconv.ParseInt(arguments, 10, 64)
+		if err != nil {
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "poll.usage"))
+		}
+		poll, err := h.pollService.GetPoll(session.User.ID, pollID)
+		if err != nil {
+			return failed(err)
+		}
+		if poll.Closed {
+			return h.client.SendText(message.Chat.ID, pollText(lang, poll))
+		}
+		return h.post(message.Chat.ID, lang, poll)
+	}
+}
+
+// HandleVoteCallback записывает голос и обновляет все сообщения с опросом
+func (h *PollHandler) HandleVoteCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, param string) (string, error) {
+	lang := sessionLang(session, callback.From)
+	id, option, _ := strings.Cut(param, ":")
+	pollID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+	position, err := strconv.Atoi(option)
+	if err != nil {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+
+	update, selected, err := h.pollService.Vote(session.User.ID, pollID, position)
+	if err != nil {
+		return "", err
+	}
+	h.refresh(update)
+
+	text := update.Poll.Options[position-1].Text
+	if selected {
+		return i18n.T(lang, "poll.voted", i18n.P{"option": text}), nil
+	}
+	return i18n.T(lang, "poll.unvoted", i18n.P{"option": text}), nil
+}
+
+// HandleShowCallback обновляет сообщение с текущими результатами опроса
+func (h *PollHandler) HandleShowCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, param string) (string, error) {
+	lang := sessionLang(session, callback.From)
+	pollID, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+	poll, err := h.pollService.GetPoll(session.User.ID, pollID)
+	if err != nil {
+		return "", err
+	}
+	h.edit(&domain.PollMessage{ChatID: callback.Message.Chat.ID, MessageID: callback.Message.MessageID, Language: string(lang)}, poll)
+	return i18n.T(lang, "poll.refreshed"), nil
+}
+
+// Run закрывает опросы с истекшим сроком и публикует их итоги сразу и затем
+// с указанным интервалом, пока не отменен контекст
+func (h *PollHandler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		updates, err := h.pollService.CloseExpired(time.Now())
+		if err != nil {
+			h.logger.Error("Error closing expired polls", logging.Err(err))
+		}
+		for _, update := range updates {
+			h.finish(update, 0)
+			h.logger.Info("Poll closed by deadline", "poll_id", update.Poll.ID, "voters", update.Poll.Voters)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// post отправляет опрос с кнопками голосования и запоминает сообщение, чтобы обновлять результаты
+func (h *PollHandler) post(chatID int64, lang i18n.Lang, poll *domain.Poll) error {
+	messageID, err := h.client.SendTextWithKeyboardID(chatID, pollText(lang, poll), h.client.GetPollKeyboard(lang, poll))
+	if err != nil {
+		h.logger.Warn("Error sending poll", "poll_id", poll.ID, "chat_id", chatID, logging.Err(err))
+		return err
+	}
+	message := &domain.PollMessage{PollID: poll.ID, ChatID: chatID, MessageID: messageID, Language: string(lang)}
+	if err := h.pollService.AddMessage(message); err != nil {
+		h.logger.Warn("Error saving poll message", "poll_id", poll.ID, "chat_id", chatID, logging.Err(err))
+	}
+	return nil
+}
+
+// refresh обновляет результаты во всех сообщениях с опросом
+func (h *PollHandler) refresh(update *domain.PollUpdate) {
+	for _, message := range update.Messages {
+		h.edit(message, update.Poll)
+	}
+}
+
+// edit заменяет сообщение текущими результатами опроса. У закрытого опроса кнопки
+// голосования убираются. Ошибка изменения сообщения только записывается в лог
+func (h *PollHandler) edit(message *domain.PollMessage, poll *domain.Poll) {
+	lang, ok := i18n.Parse(message.Language)
+	if !ok {
+		lang = i18n.Default
+	}
+	text := pollText(lang, poll)
+
+	var err error
+	if poll.Closed {
+		err = h.client.EditText(message.ChatID, message.MessageID, text)
+	} else {
+		err = h.client.EditTextWithKeyboard(message.ChatID, message.MessageID, text, h.client.GetPollKeyboard(lang, poll))
+	}
+	if err != nil {
+		h.logger.Warn("Error editing poll message", "poll_id", poll.ID, "chat_id", message.ChatID, logging.Err(err))
+	}
+}
+
+// finish показывает итоговые результаты во всех сообщениях с опросом и публикует итоги
+// в каждом чате, где опрос был отправлен. Сообщает, получил ли итоги чат chatID
+func (h *PollHandler) finish(update *domain.PollUpdate, chatID int64) bool {
+	h.refresh(update)
+
+	posted := make(map[int64]bool)
+	for _, message := range update.Messages {
+		if posted[message.ChatID] {
+			continue
+		}
+		posted[message.ChatID] = true
+		lang, ok := i18n.Parse(message.Language)
+		if !ok {
+			lang = i18n.Default
+		}
+		if err := h.client.SendText(message.ChatID, summaryText(lang, update.Poll)); err != nil {
+			h.logger.Warn("Error sending poll results", "poll_id", update.Poll.ID, "chat_id", message.ChatID, logging.Err(err))
+		}
+	}
+	return posted[chatID]
+}
+
+// pollsText формирует список открытых опросов команды
+func pollsText(lang i18n.Lang, polls []*domain.Poll) markup.Text {
+	if len(polls) == 0 {
+		return i18n.M(lang, "poll.none")
+	}
+	lines := []markup.Text{i18n.M(lang, "poll.title")}
+	for _, poll := range polls {
+		lines = append(lines, i18n.MN(lang, "poll.entry", poll.Voters, i18n.P{
+			"id":       poll.ID,
+			"question": poll.Question,
+		}))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// pollText формирует карточку опроса: вопрос, правила голосования и текущие результаты
+func pollText(lang i18n.Lang, poll *domain.Poll) markup.Text {
+	mode := "poll.mode_single"
+	if poll.Multiple {
+		mode = "poll.mode_multiple"
+	}
+	visibility := "poll.mode_named"
+	if poll.Anonymous {
+		visibility = "poll.mode_anonymous"
+	}
+
+	lines := []markup.Text{
+		i18n.M(lang, "poll.card_title", i18n.P{"id": poll.ID, "question": poll.Question}),
+		i18n.M(lang, "poll.card_mode", i18n.P{"mode": i18n.T(lang, mode), "visibility": i18n.T(lang, visibility)}),
+	}
+	if poll.Role != "" {
+		lines = append(lines, i18n.M(lang, "poll.card_role", i18n.P{"role": poll.Role}))
+	}
+	if !poll.Closed && !poll.Deadline.IsZero() {
+		lines = append(lines, i18n.M(lang, "poll.card_deadline", i18n.P{"deadline": formatTime(lang, poll.Deadline.Local())}))
+	}
+	lines = append(lines, markup.Raw(""))
+	lines = append(lines, optionsText(lang, poll)...)
+	lines = append(lines, markup.Raw(""), i18n.MN(lang, "poll.card_voters", poll.Voters))
+	if poll.Closed {
+		lines = append(lines, i18n.M(lang, "poll.card_closed"))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// optionsText формирует строки результатов по вариантам ответа. Доля голосов считается
+// от числа проголосовавших, поэтому при выборе нескольких вариантов сумма может превышать 100%
+func optionsText(lang i18n.Lang, poll *domain.Poll) []markup.Text {
+	var lines []markup.Text
+	for _, option := range poll.Options {
+		percent := 0
+		if poll.Voters > 0 {
+			percent = option.Votes * 100 / poll.Voters
+		}
+		lines = append(lines, i18n.M(lang, "poll.option", i18n.P{
+			"position": option.Position,
+			"text":     option.Text,
+			"votes":    option.Votes,
+			"percent":  percent,
+			"bar":      strings.Repeat("▰", percent/10) + strings.Repeat("▱", 10-percent/10),
+		}))
+		if len(option.Voters) > 0 {
+			lines = append(lines, i18n.M(lang, "poll.option_voters", i18n.P{"names": strings.Join(option.Voters, ", ")}))
+		}
+	}
+	return lines
+}
+
+// summaryText формирует итоги закрытого опроса с вариантами, набравшими больше всего голосов
+func summaryText(lang i18n.Lang, poll *domain.Poll) markup.Text {
+	lines := []markup.Text{i18n.M(lang, "poll.summary", i18n.P{"question": poll.Question})}
+
+	top := 0
+	var leaders []string
+	for _, option := range poll.Options {
+		switch {
+		case option.Votes > top:
+			top = option.Votes
+			leaders = []string{option.Text}
+		case option.Votes == top && top > 0:
+			leaders = append(leaders, option.Text)
+		}
+	}
+	if len(leaders) == 0 {
+		lines = append(lines, i18n.M(lang, "poll.summary_no_votes"))
+		return markup.Join("\n", lines...)
+	}
+
+	lines = append(lines, i18n.MN(lang, "poll.summary_winner", len(leaders), i18n.P{
+		"options": strings.Join(leaders, ", "),
+		"votes":   top,
+	}))
+	lines = append(lines, markup.Raw(""))
+	lines = append(lines, optionsText(lang, poll)...)
+	lines = append(lines, i18n.MN(lang, "poll.card_voters", poll.Voters))
+	return markup.Join("\n", lines...)
+}
+
+// parsePoll разбирает вопрос и варианты ответа, разделенные вертикальной чертой, и настройки
+// после двойного дефиса: multi - несколько вариантов, anon - анонимно, role=<роль> - голосуют
+// только участники с ролью, until=<ГГГГ-ММ-ДД ЧЧ:ММ> - срок закрытия
+func parsePoll(arguments string) (*domain.Poll, error) {
+	body, settings, _ := strings.Cut(arguments, "--")
+	parts := strings.Split(body, "|")
+	if len(parts) < 2 {
+		return nil, i18n.NewError("error.poll_format")
+	}
+
+	poll := &domain.Poll{Question: strings.TrimSpace(parts[0])}
+	for _, text := range parts[1:] {
+		poll.Options = append(poll.Options, &domain.PollOption{Text: strings.TrimSpace(text)})
+	}
+
+	fields := strings.Fields(settings)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		switch {
+		case field == "multi":
+			poll.Multiple = true
+		case field == "anon":
+			poll.Anonymous = true
+		case strings.HasPrefix(field, "role="):
+			poll.Role = strings.TrimPrefix(field, "role=")
+		case strings.HasPrefix(field, "until="):
+			// Время закрытия указывается отдельным словом после даты
+			value := strings.TrimPrefix(field, "until=")
+			if i+1 < len(fields) && strings.Contains(fields[i+1], ":") && !strings.Contains(fields[i+1], "=") {
+				i++
+				value += " " + fields[i]
+			}
+			deadline, err := parseEventTime(value)
+			if err != nil {
+				return nil, err
+			}
+			poll.Deadline = deadline
+		default:
+			return nil, i18n.NewError("error.poll_setting_unknown", i18n.P{"setting": field})
+		}
+	}
+	return poll, nil
+}
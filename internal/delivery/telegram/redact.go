@@ -0,0 +1,44 @@
+package telegram
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/logging"
+)
+
+// isSensitiveState проверяет, ожидает ли сессия ввода секретных данных: пароля или кода 2FA
+func isSensitiveState(session *domain.UserSession) bool {
+	return session != nil && (session.State == domain.StateAwaitingPassword || session.State == domain.StateAwaitingInvitePassword || isTwoFactorState(session.State))
+}
+
+// isInviteCommand проверяет, содержит ли сообщение код приглашения: /invite <код> или /start <код>
+func isInviteCommand(message *tgbotapi.Message) bool {
+	command := message.Command()
+	return command == "invite" || (command == "start" && message.CommandArguments() != "")
+}
+
+// isTwoFactorState проверяет, ожидает ли сессия ввода кода 2FA
+func isTwoFactorState(state domain.UserState) bool {
+	return state == domain.StateAwaitingTOTP || state == domain.StateAwaitingTOTPSetup || state == domain.StateAwaitingTOTPDisable
+}
+
+// loggableText возвращает текст сообщения, пригодный для записи в лог.
+// Сообщения, полученные в состоянии ввода секретных данных, в лог не попадают ни в каком виде
+func loggableText(message *tgbotapi.Message, session *domain.UserSession) string {
+	if isSensitiveState(session) {
+		return logging.Redacted
+	}
+	if isInviteCommand(message) {
+		return "/" + message.Command() + " " + logging.Redacted
+	}
+	return message.Text
+}
+
+// deleteSensitiveMessage удаляет из чата сообщение с секретными данными
+func (h *AuthHandler) deleteSensitiveMessage(message *tgbotapi.Message) {
+	if err := h.client.DeleteMessage(message.Chat.ID, message.MessageID); err != nil {
+		// Текст сообщения не логируем, только его идентификатор
+		h.logger.Warn("Error deleting sensitive message", "message_id", message.MessageID, "chat_id", message.Chat.ID, logging.Err(err))
+	}
+}
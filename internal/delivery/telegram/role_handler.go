@@ -0,0 +1,205 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/markup"
+)
+
+// RoleHandler обрабатывает управление ролями и правами
+type RoleHandler struct {
+	client         *telegram.Client
+	sessionService domain.SessionService
+	roleService    domain.RoleService
+	logger         *slog.Logger
+}
+
+// NewRoleHandler создает новый экземпляр RoleHandler
+func NewRoleHandler(client *telegram.Client, sessionService domain.SessionService, roleService domain.RoleService, logger *slog.Logger) *RoleHandler {
+	return &RoleHandler{
+		client:         client,
+		sessionService: sessionService,
+		roleService:    roleService,
+		logger:         logger,
+	}
+}
+
+// HandleRoles показывает список ролей
+func (h *RoleHandler) HandleRoles(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if !h.roleService.Can(session.User, domain.PermManageRoles) {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.forbidden"))
+	}
+
+	text, keyboard, err := h.rolesView(lang)
+	if err != nil {
+		return err
+	}
+	return h.client.SendTextWithKeyboard(message.Chat.ID, text, keyboard)
+}
+
+// HandleRoleName создает роль по имени, введенному пользователем
+func (h *RoleHandler) HandleRoleName(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	session.State = domain.StateNone
+	if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
+		return err
+	}
+
+	// Первое слово - имя роли, остальное - описание
+	name, description, _ := strings.Cut(strings.TrimSpace(message.Text), " ")
+	role, err := h.roleService.CreateRole(session.User.ID, strings.ToLower(name), strings.TrimSpace(description))
+	if err != nil {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "roles.create_failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+
+	text, keyboard := h.roleView(lang, role)
+	return h.client.SendTextWithKeyboard(message.Chat.ID, text, keyboard)
+}
+
+// HandleSetRole обрабатывает команду /setrole <имя пользователя> <роль>
+func (h *RoleHandler) HandleSetRole(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	args := strings.Fields(message.CommandArguments())
+	if len(args) != 2 {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "setrole.usage"))
+	}
+
+	if err := h.sessionService.ChangeRole(session.User.ID, args[0], args[1]); err != nil {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "setrole.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+	return h.client.SendText(message.Chat.ID, i18n.M(lang, "setrole.success", i18n.P{"username": args[0], "role": args[1]}))
+}
+
+// HandleCallback обрабатывает нажатия инлайн-кнопок управления ролями
+func (h *RoleHandler) HandleCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, action, param string) (string, error) {
+	lang := sessionLang(session, callback.From)
+	if !h.roleService.Can(session.User, domain.PermManageRoles) {
+		return i18n.T(lang, "callback.forbidden"), nil
+	}
+
+	switch action {
+	case "role_list":
+		text, keyboard, err := h.rolesView(lang)
+		if err != nil {
+			return "", err
+		}
+		return "", h.client.EditTextWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, keyboard)
+
+	case "role_show":
+		role, err := h.roleService.GetRole(param)
+		if err != nil {
+			return "", err
+		}
+		if role == nil {
+			return i18n.T(lang, "roles.not_found"), nil
+		}
+		text, keyboard := h.roleView(lang, role)
+		return "", h.client.EditTextWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, keyboard)
+
+	case "role_toggle":
+		name, permission, _ := strings.Cut(param, ":")
+		role, err := h.roleService.TogglePermission(session.User.ID, name, domain.Permission(permission))
+		if err != nil {
+			return "", err
+		}
+		text, keyboard := h.roleView(lang, role)
+		return i18n.T(lang, "roles.permissions_updated"), h.client.EditTextWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, keyboard)
+
+	case "role_delete":
+		if err := h.roleService.DeleteRole(session.User.ID, param); err != nil {
+			return "", err
+		}
+		text, keyboard, err := h.rolesView(lang)
+		if err != nil {
+			return "", err
+		}
+		return i18n.T(lang, "roles.deleted"), h.client.EditTextWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, keyboard)
+
+	case "role_create":
+		session.State = domain.StateAwaitingRoleName
+		if err := h.sessionService.UpdateSession(callback.From.ID, session); err != nil {
+			return "", err
+		}
+		return "", h.client.SendText(callback.Message.Chat.ID, i18n.M(lang, "roles.enter_name"))
+
+	default:
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+}
+
+// rolesView формирует список ролей с кнопками
+func (h *RoleHandler) rolesView(lang i18n.Lang) (markup.Text, tgbotapi.InlineKeyboardMarkup, error) {
+	roles, err := h.roleService.GetRoles()
+	if err != nil {
+		return markup.Text{}, tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	var buttons [][]telegram.InlineButton
+	for _, role := range roles {
+		text := role.Name
+		if description := roleDescription(lang, role); description != "" {
+			text = fmt.Sprintf("%s (%s)", role.Name, description)
+		}
+		buttons = append(buttons, []telegram.InlineButton{{Text: text, Data: "role_show:" + role.Name}})
+	}
+	buttons = append(buttons, []telegram.InlineButton{{Text: i18n.T(lang, telegram.BtnCreateRole), Data: "role_create"}})
+
+	return i18n.M(lang, "roles.title"), h.client.CreateInlineKeyboard(buttons), nil
+}
+
+// roleView формирует описание роли с кнопками переключения прав
+func (h *RoleHandler) roleView(lang i18n.Lang, role *domain.Role) (markup.Text, tgbotapi.InlineKeyboardMarkup) {
+	lines := []markup.Text{i18n.M(lang, "roles.name", i18n.P{"name": role.Name})}
+	if description := roleDescription(lang, role); description != "" {
+		lines = append(lines, i18n.M(lang, "roles.description", i18n.P{"description": description}))
+	}
+	lines = append(lines, markup.Raw(""))
+	if role.Name == domain.RoleAdmin {
+		lines = append(lines, i18n.M(lang, "roles.admin_all"))
+	} else {
+		lines = append(lines, i18n.M(lang, "roles.toggle_hint"))
+	}
+
+	var buttons [][]telegram.InlineButton
+	if role.Name != domain.RoleAdmin {
+		for _, p := range domain.AllPermissions {
+			mark := "❌"
+			if role.Has(p) {
+				mark = "✅"
+			}
+			buttons = append(buttons, []telegram.InlineButton{{
+				Text: fmt.Sprintf("%s %s", mark, i18n.T(lang, "perm."+string(p))),
+				Data: fmt.Sprintf("role_toggle:%s:%s", role.Name, p),
+			}})
+		}
+	}
+	if !role.BuiltIn {
+		buttons = append(buttons, []telegram.InlineButton{{Text: i18n.T(lang, telegram.BtnDeleteRole), Data: "role_delete:" + role.Name}})
+	}
+	buttons = append(buttons, []telegram.InlineButton{{Text: i18n.T(lang, telegram.BtnBackToRoles), Data: "role_list"}})
+
+	return markup.Join("\n", lines...), h.client.CreateInlineKeyboard(buttons)
+}
+
+// roleDescription возвращает описание роли. Описания встроенных ролей берутся из каталога,
+// описания созданных администраторами ролей показываются как есть
+func roleDescription(lang i18n.Lang, role *domain.Role) string {
+	if role.BuiltIn {
+		if description, ok := i18n.Lookup(lang, "role.desc."+role.Name); ok {
+			return description
+		}
+	}
+	return role.Description
+}
@@ -0,0 +1,191 @@
+package telegram
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/markup"
+)
+
+// rosterPageSize определяет количество пользователей на одной странице списка
+const rosterPageSize = 20
+
+// rosterSorts сопоставляет значения фильтра sort= с порядком сортировки
+var rosterSorts = map[string]domain.UserQuery{
+	"name":     {Sort: domain.UserSortUsername},
+	"-name":    {Sort: domain.UserSortUsername, Desc: true},
+	"created":  {Sort: domain.UserSortCreated},
+	"-created": {Sort: domain.UserSortCreated, Desc: true},
+}
+
+// rosterStatuses - состояния учетных записей, которые список показывает без фильтра status=
+var rosterStatuses = []domain.UserStatus{domain.UserActive, domain.UserSuspended, domain.UserDeactivated}
+
+// RosterHandler обрабатывает просмотр списка пользователей
+type RosterHandler struct {
+	client         *telegram.Client
+	sessionService domain.SessionService
+	teamService    domain.TeamService
+	roleService    domain.RoleService
+	logger         *slog.Logger
+}
+
+// NewRosterHandler создает новый экземпляр RosterHandler
+func NewRosterHandler(client *telegram.Client, sessionService domain.SessionService, teamService domain.TeamService, roleService domain.RoleService, logger *slog.Logger) *RosterHandler {
+	return &RosterHandler{
+		client:         client,
+		sessionService: sessionService,
+		teamService:    teamService,
+		roleService:    roleService,
+		logger:         logger,
+	}
+}
+
+// HandleRoster показывает первую страницу списка участников активной команды по фильтрам из аргументов команды
+func (h *RosterHandler) HandleRoster(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session != nil && session.IsAuthorized && session.User.ActiveTeamID == 0 {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "team.required"))
+	}
+	if session == nil || !session.IsAuthorized || !h.roleService.Can(session.User, domain.PermViewUsers) {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.forbidden"))
+	}
+
+	usage := i18n.M(lang, "roster.usage")
+	query, err := parseRosterQuery(message.CommandArguments())
+	if err != nil {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "roster.filter_failed", i18n.P{"error": errorText(h.logger, lang, err), "usage": usage}))
+	}
+	query.Limit = rosterPageSize
+
+	page, err := h.teamService.FindMembers(session.User.ID, query)
+	if err != nil {
+		return err
+	}
+	if len(page.Users) == 0 {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "roster.empty", i18n.P{"usage": usage}))
+	}
+
+	text := h.rosterPage(lang, page, 0)
+	if page.NextCursor == "" {
+		return h.client.SendText(message.Chat.ID, text)
+	}
+
+	// Запоминаем запрос, чтобы кнопка «Далее» продолжила ту же выборку
+	session.Roster = &domain.RosterView{Query: query, Cursor: page.NextCursor, Shown: len(page.Users)}
+	if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
+		return err
+	}
+	return h.client.SendTextWithKeyboard(message.Chat.ID, text, h.client.GetNextPageKeyboard(lang, "roster_next"))
+}
+
+// HandleNextPage показывает следующую страницу списка вместо текущей
+func (h *RosterHandler) HandleNextPage(callback *tgbotapi.CallbackQuery, session *domain.UserSession) (string, error) {
+	lang := sessionLang(session, callback.From)
+	if !h.roleService.Can(session.User, domain.PermViewUsers) {
+		return i18n.T(lang, "callback.forbidden"), nil
+	}
+	view := session.Roster
+	if view == nil || view.Cursor == "" {
+		return i18n.T(lang, "callback.stale"), nil
+	}
+
+	query := view.Query
+	query.Cursor = view.Cursor
+	page, err := h.teamService.FindMembers(session.User.ID, query)
+	if err != nil {
+		return "", err
+	}
+
+	text := h.rosterPage(lang, page, view.Shown)
+	view.Cursor = page.NextCursor
+	view.Shown += len(page.Users)
+	if page.NextCursor == "" {
+		session.Roster = nil
+	}
+	if err := h.sessionService.UpdateSession(callback.From.ID, session); err != nil {
+		return "", err
+	}
+
+	if page.NextCursor == "" {
+		return "", h.client.EditText(callback.Message.Chat.ID, callback.Message.MessageID, text)
+	}
+	return "", h.client.EditTextWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, h.client.GetNextPageKeyboard(lang, "roster_next"))
+}
+
+// rosterPage формирует текст страницы списка. Нумерация продолжается с предыдущих страниц
+func (h *RosterHandler) rosterPage(lang i18n.Lang, page *domain.UserPage, shown int) markup.Text {
+	lines := []markup.Text{i18n.M(lang, "roster.title"), markup.Raw("")}
+	for i, user := range page.Users {
+		parts := []markup.Text{i18n.M(lang, "roster.entry", i18n.P{
+			"n":        shown + i + 1,
+			"username": user.Username,
+			"role":     user.TeamRole,
+		})}
+		if user.Position != "" {
+			parts = append(parts, i18n.M(lang, "roster.position", i18n.P{"position": user.Position}))
+		}
+		if !user.Active() {
+			parts = append(parts, i18n.M(lang, "roster.status", i18n.P{"status": i18n.T(lang, "status."+string(user.Status))}))
+		}
+		lines = append(lines, markup.Join("", parts...))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// parseRosterQuery разбирает фильтры вида key=value
+func parseRosterQuery(args string) (domain.UserQuery, error) {
+	query := domain.UserQuery{Statuses: rosterStatuses}
+	for _, arg := range strings.Fields(args) {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || value == "" {
+			return query, i18n.NewError("error.filter_invalid", i18n.P{"filter": arg})
+		}
+
+		switch key {
+		case "role":
+			query.Role = value
+		case "position":
+			query.Position = value
+		case "status":
+			switch status := domain.UserStatus(value); {
+			case value == "all":
+				query.Statuses = nil
+			case domain.IsKnownUserStatus(status):
+				query.Statuses = []domain.UserStatus{status}
+			default:
+				return query, i18n.NewError("error.filter_status", i18n.P{"value": value})
+			}
+		case "name":
+			query.NamePrefix = value
+		case "from":
+			from, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				return query, i18n.NewError("error.filter_date", i18n.P{"value": value})
+			}
+			query.CreatedFrom = from
+		case "to":
+			to, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				return query, i18n.NewError("error.filter_date", i18n.P{"value": value})
+			}
+			// Дата окончания включается в период целиком
+			query.CreatedTo = to.AddDate(0, 0, 1)
+		case "sort":
+			sort, ok := rosterSorts[value]
+			if !ok {
+				return query, i18n.NewError("error.filter_sort", i18n.P{"value": value})
+			}
+			query.Sort, query.Desc = sort.Sort, sort.Desc
+		default:
+			return query, i18n.NewError("error.filter_unknown", i18n.P{"filter": key})
+		}
+	}
+	return query, nil
+}
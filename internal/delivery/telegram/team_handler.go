@@ -0,0 +1,175 @@
+package telegram
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/markup"
+)
+
+// TeamHandler обрабатывает команды и выбор активной команды
+type TeamHandler struct {
+	client         *telegram.Client
+	sessionService domain.SessionService
+	teamService    domain.TeamService
+	authHandler    *AuthHandler
+	logger         *slog.Logger
+}
+
+// NewTeamHandler создает новый экземпляр TeamHandler
+func NewTeamHandler(client *telegram.Client, sessionService domain.SessionService, teamService domain.TeamService, authHandler *AuthHandler, logger *slog.Logger) *TeamHandler {
+	return &TeamHandler{
+		client:         client,
+		sessionService: sessionService,
+		teamService:    teamService,
+		authHandler:    authHandler,
+		logger:         logger,
+	}
+}
+
+// HandleTeamCommand обрабатывает команды /team, /team create <название>, /team add <пользователь> [роль],
+// /team remove <пользователь>, /team role <пользователь> <роль> и /team group
+func (h *TeamHandler) HandleTeamCommand(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	args := strings.Fields(message.CommandArguments())
+	failed := func(err error) error {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "team.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+	actorID := session.User.ID
+
+	switch {
+	case len(args) == 0:
+		return h.sendTeams(message.Chat.ID, lang, session.User)
+
+	case len(args) >= 2 && args[0] == "create":
+		team, err := h.teamService.CreateTeam(actorID, strings.Join(args[1:], " "))
+		if err != nil {
+			return failed(err)
+		}
+		return h.sendTeamChanged(message.Chat.ID, lang, message.From.ID, i18n.M(lang, "team.created", i18n.P{"team": team.Name}))
+
+	case (len(args) == 2 || len(args) == 3) && args[0] == "add":
+		role := ""
+		if len(args) == 3 {
+			role = strings.ToLower(args[2])
+		}
+		user, err := h.teamService.AddMember(actorID, args[1], role)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "team.member_added", i18n.P{"username": user.Username, "role": user.TeamRole}))
+
+	case len(args) == 2 && args[0] == "remove":
+		user, err := h.teamService.RemoveMember(actorID, args[1])
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "team.member_removed", i18n.P{"username": user.Username}))
+
+	case len(args) == 3 && args[0] == "role":
+		user, err := h.teamService.SetMemberRole(actorID, args[1], strings.ToLower(args[2]))
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "team.role_changed", i18n.P{"username": user.Username, "role": user.TeamRole}))
+
+	case len(args) == 1 && args[0] == "group":
+		// Группу можно привязать только из нее самой: так бот знает, что состоит в ней
+		if message.Chat.IsPrivate() {
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "team.group_private"))
+		}
+		team, err := h.teamService.BindGroup(actorID, message.Chat.ID)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "team.group_bound", i18n.P{"team": team.Name}))
+
+	default:
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "team.usage"))
+	}
+}
+
+// HandleSwitchCallback делает выбранную команду активной
+func (h *TeamHandler) HandleSwitchCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, param string) (string, error) {
+	lang := sessionLang(session, callback.From)
+	teamID, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+
+	team, err := h.teamService.SwitchTeam(session.User.ID, teamID)
+	if err != nil {
+		return "", err
+	}
+	// Страницы списка пользователей относятся к прежней команде
+	session.User.ActiveTeamID = team.ID
+	session.Roster = nil
+	if err := h.sessionService.UpdateSession(callback.From.ID, session); err != nil {
+		return "", err
+	}
+
+	if text, keyboard, err := h.teamsView(lang, session.User.ID, team.ID); err == nil {
+		if err := h.client.EditTextWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, text, keyboard); err != nil {
+			h.logger.Warn("Error editing team list", "chat_id", callback.Message.Chat.ID, logging.Err(err))
+		}
+	}
+	switched := i18n.M(lang, "team.switched", i18n.P{"team": team.Name})
+	return switched.String(), h.sendTeamChanged(callback.Message.Chat.ID, lang, callback.From.ID, switched)
+}
+
+// sendTeams отправляет список команд с кнопками выбора активной
+func (h *TeamHandler) sendTeams(chatID int64, lang i18n.Lang, user *domain.User) error {
+	text, keyboard, err := h.teamsView(lang, user.ID, user.ActiveTeamID)
+	if err != nil {
+		return err
+	}
+	if len(keyboard.InlineKeyboard) == 0 {
+		return h.client.SendText(chatID, text)
+	}
+	return h.client.SendTextWithKeyboard(chatID, text, keyboard)
+}
+
+// teamsView формирует список команд пользователя и клавиатуру выбора активной
+func (h *TeamHandler) teamsView(lang i18n.Lang, userID, activeID int64) (markup.Text, tgbotapi.InlineKeyboardMarkup, error) {
+	teams, err := h.teamService.Teams(userID)
+	if err != nil {
+		return markup.Text{}, tgbotapi.InlineKeyboardMarkup{}, err
+	}
+	if len(teams) == 0 {
+		return i18n.M(lang, "team.none"), tgbotapi.InlineKeyboardMarkup{}, nil
+	}
+
+	lines := []markup.Text{i18n.M(lang, "team.title")}
+	for _, team := range teams {
+		key := "team.entry"
+		if team.TeamID == activeID {
+			key = "team.entry_active"
+		}
+		if team.Role == "" {
+			key += "_guest"
+		}
+		lines = append(lines, i18n.M(lang, key, i18n.P{"name": team.TeamName, "role": team.Role}))
+	}
+	return markup.Join("\n", lines...), h.client.GetTeamKeyboard(teams, activeID), nil
+}
+
+// sendTeamChanged отправляет сообщение вместе с главным меню: набор доступных
+// пунктов зависит от роли в активной команде
+func (h *TeamHandler) sendTeamChanged(chatID int64, lang i18n.Lang, telegramID int64, text markup.Text) error {
+	keyboard, err := h.authHandler.mainMenuKeyboard(telegramID, lang)
+	if err != nil {
+		return err
+	}
+	return h.client.SendTextWithKeyboard(chatID, text, keyboard)
+}
@@ -0,0 +1,346 @@
+package telegram
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/client/telegram"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/markup"
+)
+
+// TicketHandler обрабатывает обращения в поддержку: пересылает обращения администраторам
+// с кнопками ответа, передает ответы между автором и администраторами, показывает
+// очередь и историю обращений. С обращениями работают только в личном чате с ботом
+type TicketHandler struct {
+	client         *telegram.Client
+	sessionService domain.SessionService
+	ticketService  domain.TicketService
+	logger         *slog.Logger
+}
+
+// NewTicketHandler создает новый экземпляр TicketHandler
+func NewTicketHandler(client *telegram.Client, sessionService domain.SessionService, ticketService domain.TicketService, logger *slog.Logger) *TicketHandler {
+	return &TicketHandler{
+		client:         client,
+		sessionService: sessionService,
+		ticketService:  ticketService,
+		logger:         logger,
+	}
+}
+
+// HandleTicketCommand обрабатывает команды /ticket, /ticket <номер>, /ticket new <текст>,
+// /ticket reply <номер> <текст>, /ticket close <номер>, /ticket assign <номер> <имя пользователя>
+// и /ticket queue [статус]
+func (h *TicketHandler) HandleTicketCommand(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+	if !message.Chat.IsPrivate() {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "ticket.private_only"))
+	}
+
+	arguments := strings.TrimSpace(message.CommandArguments())
+	command, rest, _ := strings.Cut(arguments, " ")
+	rest = strings.TrimSpace(rest)
+	failed := func(err error) error {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "ticket.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+	usage := func() error {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "ticket.usage"))
+	}
+	actorID := session.User.ID
+
+	switch command {
+	case "":
+		tickets, err := h.ticketService.Tickets(actorID)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, ticketsText(lang, tickets, "ticket.own_title", "ticket.own_none"))
+
+	case "new":
+		if rest == "" {
+			return usage()
+		}
+		ticket, staff, err := h.ticketService.Create(actorID, rest)
+		if err != nil {
+			return failed(err)
+		}
+		sent := 0
+		for _, user := range staff {
+			if h.send(user, ticket.ID, true, "ticket.new", i18n.P{"id": ticket.ID, "username": ticket.Username, "text": ticket.Text}) {
+				sent++
+			}
+		}
+		if sent == 0 {
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "ticket.created_queued", i18n.P{"id": ticket.ID}))
+		}
+		return h.client.SendText(message.Chat.ID, i18n.MN(lang, "ticket.created", sent, i18n.P{"id": ticket.ID}))
+
+	case "reply":
+		id, text, _ := strings.Cut(rest, " ")
+		ticketID, err := parseTicketID(id)
+		if err != nil || strings.TrimSpace(text) == "" {
+			return usage()
+		}
+		if err := h.reply(actorID, ticketID, text); err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "ticket.reply_sent", i18n.P{"id": ticketID}))
+
+	case "close":
+		ticketID, err := parseTicketID(rest)
+		if err != nil {
+			return usage()
+		}
+		if err := h.close(actorID, ticketID); err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "ticket.closed", i18n.P{"id": ticketID}))
+
+	case "assign":
+		args := strings.Fields(rest)
+		if len(args) != 2 {
+			return usage()
+		}
+		ticketID, err := parseTicketID(args[0])
+		if err != nil {
+			return usage()
+		}
+		ticket, err := h.assign(actorID, ticketID, args[1])
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "ticket.assigned", i18n.P{"id": ticket.ID, "username": ticket.Assignee}))
+
+	case "queue":
+		var statuses []domain.TicketStatus
+		switch rest {
+		case "":
+		case "all":
+			statuses = domain.TicketStatuses
+		default:
+			statuses = []domain.TicketStatus{domain.TicketStatus(rest)}
+			if !domain.IsTicketStatus(statuses[0]) {
+				return usage()
+			}
+		}
+		tickets, err := h.ticketService.Queue(actorID, statuses)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, ticketsText(lang, tickets, "ticket.queue_title", "ticket.queue_none"))
+
+	default:
+		ticketID, err := parseTicketID(arguments)
+		if err != nil {
+			return usage()
+		}
+		ticket, messages, err := h.ticketService.Ticket(actorID, ticketID)
+		if err != nil {
+			return failed(err)
+		}
+		return h.client.SendText(message.Chat.ID, ticketHistoryText(lang, ticket, messages))
+	}
+}
+
+// HandleCallback обрабатывает кнопки обращения: ответ, взятие в работу и закрытие
+func (h *TicketHandler) HandleCallback(callback *tgbotapi.CallbackQuery, session *domain.UserSession, param string) (string, error) {
+	lang := sessionLang(session, callback.From)
+	id, action, _ := strings.Cut(param, ":")
+	ticketID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+
+	switch action {
+	case "reply":
+		// Проверяем, что на обращение можно ответить, и ждем текст ответа следующим сообщением
+		ticket, _, err := h.ticketService.Ticket(session.User.ID, ticketID)
+		if err != nil {
+			return "", err
+		}
+		if ticket.Closed() {
+			return "", i18n.NewError("error.ticket_closed", i18n.P{"id": ticket.ID})
+		}
+		session.State = domain.StateAwaitingTicketReply
+		session.TicketID = ticket.ID
+		if err := h.sessionService.UpdateSession(callback.From.ID, session); err != nil {
+			return "", err
+		}
+		return "", h.client.SendText(callback.Message.Chat.ID, i18n.M(lang, "ticket.enter_reply", i18n.P{"id": ticket.ID}))
+
+	case "take":
+		ticket, err := h.assign(session.User.ID, ticketID, session.User.Username)
+		if err != nil {
+			return "", err
+		}
+		return i18n.T(lang, "ticket.taken", i18n.P{"id": ticket.ID}), nil
+
+	case "close":
+		if err := h.close(session.User.ID, ticketID); err != nil {
+			return "", err
+		}
+		return i18n.T(lang, "ticket.closed", i18n.P{"id": ticketID}), nil
+
+	default:
+		return i18n.T(lang, "callback.unknown"), nil
+	}
+}
+
+// HandleReplyText пересылает ответ по обращению, которого ждали после нажатия кнопки «Ответить»
+func (h *TicketHandler) HandleReplyText(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	ticketID := session.TicketID
+	session.State = domain.StateNone
+	session.TicketID = 0
+	if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
+		return err
+	}
+
+	if err := h.reply(session.User.ID, ticketID, message.Text); err != nil {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "ticket.failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+	return h.client.SendText(message.Chat.ID, i18n.M(lang, "ticket.reply_sent", i18n.P{"id": ticketID}))
+}
+
+// reply добавляет ответ в переписку и пересылает его другой стороне
+func (h *TicketHandler) reply(actorID int64, ticketID int64, text string) error {
+	ticket, message, recipients, err := h.ticketService.Reply(actorID, ticketID, text)
+	if err != nil {
+		return err
+	}
+	for _, user := range recipients {
+		// Ответ администратора получает автор, ответ автора - администраторы
+		h.send(user, ticket.ID, !message.Staff, "ticket.reply", i18n.P{"id": ticket.ID, "username": message.Username, "text": message.Text})
+	}
+	return nil
+}
+
+// assign назначает исполнителя обращения и уведомляет его и автора обращения
+func (h *TicketHandler) assign(actorID int64, ticketID int64, username string) (*domain.Ticket, error) {
+	ticket, recipients, err := h.ticketService.Assign(actorID, ticketID, username)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range recipients {
+		if user.ID == ticket.AssigneeID {
+			h.send(user, ticket.ID, true, "ticket.assigned_to_you", i18n.P{"id": ticket.ID, "username": ticket.Username, "text": ticket.Text})
+		} else {
+			h.notify(user, ticket.ID, "ticket.in_progress", i18n.P{"id": ticket.ID, "username": ticket.Assignee})
+		}
+	}
+	return ticket, nil
+}
+
+// close закрывает обращение и уведомляет другую сторону
+func (h *TicketHandler) close(actorID int64, ticketID int64) error {
+	ticket, recipients, err := h.ticketService.Close(actorID, ticketID)
+	if err != nil {
+		return err
+	}
+	for _, user := range recipients {
+		h.notify(user, ticket.ID, "ticket.closed_notice", i18n.P{"id": ticket.ID})
+	}
+	return nil
+}
+
+// notify отправляет пользователю уведомление по обращению без кнопок
+func (h *TicketHandler) notify(user *domain.User, ticketID int64, key string, params i18n.P) {
+	if user.ChatID == 0 {
+		return
+	}
+	if err := h.client.SendText(user.ChatID, i18n.M(userLang(user), key, params)); err != nil {
+		h.logger.Warn("Error sending ticket notification", "ticket_id", ticketID, "user_id", user.ID, "message", key, logging.Err(err))
+	}
+}
+
+// send отправляет пользователю сообщение по обращению с кнопками на его языке
+// и сообщает, удалось ли его доставить
+func (h *TicketHandler) send(user *domain.User, ticketID int64, staff bool, key string, params i18n.P) bool {
+	if user.ChatID == 0 {
+		return false
+	}
+	lang := userLang(user)
+	if err := h.client.SendTextWithKeyboard(user.ChatID, i18n.M(lang, key, params), h.client.GetTicketKeyboard(lang, ticketID, staff)); err != nil {
+		h.logger.Warn("Error sending ticket message", "ticket_id", ticketID, "user_id", user.ID, "message", key, logging.Err(err))
+		return false
+	}
+	return true
+}
+
+// parseTicketID разбирает номер обращения вида 12 или #12
+func parseTicketID(value string) (int64, error) {
+	return strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(value), "#"), 10, 64)
+}
+
+// ticketParams возвращает описание обращения для текста
+func ticketParams(lang i18n.Lang, ticket *domain.Ticket) i18n.P {
+	assignee := ticket.Assignee
+	if assignee == "" {
+		assignee = i18n.T(lang, "ticket.unassigned")
+	}
+	return i18n.P{
+		"id":       ticket.ID,
+		"username": ticket.Username,
+		"status":   i18n.T(lang, "ticket.status."+string(ticket.Status)),
+		"assignee": assignee,
+		"date":     formatDate(lang, ticket.CreatedAt),
+		"text":     ticketPreview(ticket.Text),
+	}
+}
+
+// ticketsText формирует список обращений с заголовком или текст о том, что их нет
+func ticketsText(lang i18n.Lang, tickets []*domain.Ticket, title, none string) markup.Text {
+	if len(tickets) == 0 {
+		return i18n.M(lang, none)
+	}
+	lines := []markup.Text{i18n.M(lang, title)}
+	for _, ticket := range tickets {
+		lines = append(lines, i18n.M(lang, "ticket.entry", ticketParams(lang, ticket)))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// ticketHistoryText формирует карточку обращения с полным текстом и перепиской
+func ticketHistoryText(lang i18n.Lang, ticket *domain.Ticket, messages []*domain.TicketMessage) markup.Text {
+	params := ticketParams(lang, ticket)
+	params["text"] = ticket.Text
+	lines := []markup.Text{i18n.M(lang, "ticket.card", params)}
+	if ticket.Closed() {
+		lines = append(lines, i18n.M(lang, "ticket.card_closed", i18n.P{"time": formatTime(lang, ticket.ClosedAt.Local())}))
+	}
+	if len(messages) == 0 {
+		return markup.Join("\n", append(lines, markup.Raw(""), i18n.M(lang, "ticket.history_none"))...)
+	}
+	lines = append(lines, markup.Raw(""), i18n.M(lang, "ticket.history_title"))
+	for _, message := range messages {
+		key := "ticket.history_entry"
+		if message.Staff {
+			key = "ticket.history_entry_staff"
+		}
+		lines = append(lines, i18n.M(lang, key, i18n.P{
+			"time":     formatTime(lang, message.CreatedAt.Local()),
+			"username": message.Username,
+			"text":     message.Text,
+		}))
+	}
+	return markup.Join("\n", lines...)
+}
+
+// ticketPreview возвращает начало текста обращения для списка
+func ticketPreview(text string) string {
+	const limit = 50
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > limit {
+		return string(runes[:limit]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,175 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/markup"
+)
+
+// HandleTwoFactorSettings обрабатывает команду /2fa: подключение или отключение 2FA
+func (h *AuthHandler) HandleTwoFactorSettings(message *tgbotapi.Message, session *domain.UserSession) error {
+	lang := sessionLang(session, message.From)
+	if !message.Chat.IsPrivate() {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "2fa.private_only"))
+	}
+	if session == nil || !session.IsAuthorized {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "common.auth_required"))
+	}
+
+	enabled, err := h.twoFactorService.IsEnabled(session.User.ID)
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		if h.twoFactorService.IsRequired(session.User) {
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "2fa.enabled_required"))
+		}
+		session.State = domain.StateAwaitingTOTPDisable
+		if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
+			return err
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "2fa.disable_prompt"))
+	}
+
+	return h.startEnrollment(message, session, lang, i18n.M(lang, "2fa.setup_intro"))
+}
+
+// startEnrollment отправляет данные для приложения-аутентификатора и ожидает код подтверждения
+func (h *AuthHandler) startEnrollment(message *tgbotapi.Message, session *domain.UserSession, lang i18n.Lang, intro markup.Text) error {
+	enrollment, err := h.twoFactorService.BeginEnrollment(session.User)
+	if err != nil {
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "2fa.setup_failed", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+
+	session.State = domain.StateAwaitingTOTPSetup
+	session.Attempts = 0
+	if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
+		return err
+	}
+
+	caption := i18n.M(lang, "2fa.setup_caption", i18n.P{
+		"intro":  intro,
+		"secret": enrollment.Secret,
+		"uri":    enrollment.URI,
+	})
+	if err := h.client.SendPhoto(message.Chat.ID, "totp.png", enrollment.QRCode, caption); err != nil {
+		return err
+	}
+	return h.client.SendText(message.Chat.ID, i18n.M(lang, "2fa.enter_setup_code"))
+}
+
+// HandleTwoFactor обрабатывает ввод кода 2FA при входе, подключении или отключении
+func (h *AuthHandler) HandleTwoFactor(message *tgbotapi.Message, session *domain.UserSession) error {
+	code := strings.TrimSpace(message.Text)
+	lang := sessionLang(session, message.From)
+
+	// Сразу удаляем сообщение с кодом из чата
+	h.deleteSensitiveMessage(message)
+
+	if !session.IsAuthorized {
+		return h.completeLogin(message, lang, code)
+	}
+
+	state := session.State
+	session.State = domain.StateNone
+	if err := h.sessionService.UpdateSession(message.From.ID, session); err != nil {
+		return err
+	}
+
+	switch state {
+	case domain.StateAwaitingTOTPSetup:
+		recoveryCodes, err := h.twoFactorService.ConfirmEnrollment(session.User.ID, code)
+		if err != nil {
+			return h.client.SendText(message.Chat.ID, i18n.M(lang, "2fa.setup_failed_retry", i18n.P{"error": errorText(h.logger, lang, err)}))
+		}
+		return h.sendRecoveryCodes(message.Chat.ID, lang, i18n.M(lang, "2fa.enabled"), recoveryCodes)
+
+	case domain.StateAwaitingTOTPDisable:
+		if err := h.sessionService.DisableTwoFactor(message.From.ID, code); err != nil {
+			text := i18n.M(lang, "2fa.disable_failed", i18n.P{"error": errorText(h.logger, lang, err)})
+			current, getErr := h.sessionService.GetSession(message.From.ID)
+			if getErr != nil {
+				return getErr
+			}
+			if current == nil || !current.IsAuthorized {
+				// Сессия завершена после слишком большого количества попыток
+				return h.client.SendTextWithKeyboard(message.Chat.ID, text, h.client.GetLoginKeyboard(lang))
+			}
+			return h.client.SendText(message.Chat.ID, text)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "2fa.disabled"))
+
+	default:
+		return fmt.Errorf("неизвестное состояние сессии")
+	}
+}
+
+// completeLogin завершает вход, ожидающий кода 2FA
+func (h *AuthHandler) completeLogin(message *tgbotapi.Message, lang i18n.Lang, code string) error {
+	recoveryCodes, err := h.sessionService.CompleteTwoFactor(message.From.ID, code)
+	if err != nil {
+		session, getErr := h.sessionService.GetSession(message.From.ID)
+		if getErr != nil {
+			return getErr
+		}
+		if session == nil || !isTwoFactorState(session.State) {
+			// Вход сброшен после слишком большого количества попыток
+			keyboard := h.client.GetLoginKeyboard(lang)
+			return h.client.SendTextWithKeyboard(message.Chat.ID, i18n.M(lang, "auth.login_failed", i18n.P{"error": errorText(h.logger, lang, err)}), keyboard)
+		}
+		return h.client.SendText(message.Chat.ID, i18n.M(lang, "2fa.retry", i18n.P{"error": errorText(h.logger, lang, err)}))
+	}
+
+	keyboard, err := h.mainMenuKeyboard(message.From.ID, lang)
+	if err != nil {
+		return err
+	}
+
+	if len(recoveryCodes) > 0 {
+		if err := h.sendRecoveryCodes(message.Chat.ID, lang, i18n.M(lang, "2fa.enabled"), recoveryCodes); err != nil {
+			return err
+		}
+	}
+	return h.client.SendTextWithKeyboard(message.Chat.ID, i18n.M(lang, "auth.login_success"), keyboard)
+}
+
+// handleTwoFactorLogin продолжает вход, если после пароля требуется второй фактор.
+// Возвращает false, если ошибка входа не связана с 2FA
+func (h *AuthHandler) handleTwoFactorLogin(message *tgbotapi.Message, lang i18n.Lang, loginErr error) (bool, error) {
+	switch {
+	case errors.Is(loginErr, domain.ErrTwoFactorRequired):
+		return true, h.client.SendText(message.Chat.ID, i18n.M(lang, "2fa.enter_code"))
+
+	case errors.Is(loginErr, domain.ErrTwoFactorEnrollmentRequired):
+		session, err := h.sessionService.GetSession(message.From.ID)
+		if err != nil {
+			return true, err
+		}
+		return true, h.startEnrollment(message, session, lang, i18n.M(lang, "2fa.setup_required_intro"))
+
+	default:
+		return false, nil
+	}
+}
+
+// sendRecoveryCodes отправляет пользователю коды восстановления
+func (h *AuthHandler) sendRecoveryCodes(chatID int64, lang i18n.Lang, intro markup.Text, codes []string) error {
+	// Каждый код показываем моноширинным, чтобы его было удобно скопировать
+	lines := make([]markup.Text, 0, len(codes))
+	for _, code := range codes {
+		lines = append(lines, markup.New("`{code}`", map[string]any{"code": code}))
+	}
+
+	text := i18n.MN(lang, "2fa.recovery_codes", len(codes), i18n.P{
+		"intro": intro,
+		"codes": markup.Join("\n", lines...),
+	})
+	return h.client.SendText(chatID, text)
+}
@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// Announcement представляет объявление команды. Объявление рассылается участникам
+// с кнопкой «Прочитано», и по каждому получателю хранится отметка о прочтении
+type Announcement struct {
+	ID        int64     `json:"id"`
+	TeamID    int64     `json:"team_id"`
+	Text      string    `json:"text"`
+	ChatID    int64     `json:"chat_id,omitempty"`    // Группа команды, в которой публикуется объявление (0 - группа не привязана)
+	MessageID int       `json:"message_id,omitempty"` // Закрепленное сообщение в группе
+	Active    bool      `json:"active"`
+	CreatedBy int64     `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ClosedAt  time.Time `json:"closed_at,omitempty"`
+
+	Recipients   int `json:"recipients"`   // Число получателей (заполняется при выборке)
+	Acknowledged int `json:"acknowledged"` // Число прочитавших (заполняется при выборке)
+}
+
+// Percent возвращает долю прочитавших получателей в процентах, округленную вниз
+func (a *Announcement) Percent() int {
+	if a.Recipients == 0 {
+		return 0
+	}
+	return a.Acknowledged * 100 / a.Recipients
+}
+
+// AnnouncementReceipt представляет получателя объявления и его отметку о прочтении
+type AnnouncementReceipt struct {
+	AnnouncementID int64     `json:"announcement_id"`
+	UserID         int64     `json:"user_id"`
+	Username       string    `json:"username"` // Имя получателя (заполняется при выборке)
+	AcknowledgedAt time.Time `json:"acknowledged_at,omitempty"`
+	Reminders      int       `json:"reminders"`             // Сколько раз получателю напоминали
+	RemindedAt     time.Time `json:"reminded_at,omitempty"` // Время последнего напоминания
+}
+
+// Acknowledged проверяет, отметил ли получатель объявление прочитанным
+func (r *AnnouncementReceipt) Acknowledged() bool {
+	return !r.AcknowledgedAt.IsZero()
+}
+
+// AnnouncementReminder содержит объявление, о котором пора напомнить, и получателей,
+// которые его еще не прочитали
+type AnnouncementReminder struct {
+	Announcement *Announcement
+	Users        []*User
+}
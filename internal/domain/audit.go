@@ -0,0 +1,73 @@
+package domain
+
+import "time"
+
+// Константы для действий, записываемых в журнал аудита
+const (
+	AuditRegister        = "register"
+	AuditLoginSuccess    = "login_success"
+	AuditLoginFailure    = "login_failure"
+	AuditLoginPending    = "login_2fa_pending"
+	AuditPasswordChange  = "password_change"
+	AuditRoleChange      = "role_change"
+	AuditTransferRequest = "transfer_request"
+	AuditTransferApprove = "transfer_approve"
+	AuditTransferReject  = "transfer_reject"
+	AuditRoleCreate      = "role_create"
+	AuditRoleUpdate      = "role_update"
+	AuditRoleDelete      = "role_delete"
+	AuditTwoFactorEnable = "2fa_enable"
+	AuditTwoFactorOff    = "2fa_disable"
+	AuditTwoFactorFail   = "2fa_failure"
+	AuditRecoveryCode    = "2fa_recovery_code"
+	AuditStatusChange    = "status_change"
+	AuditAccountPurge    = "account_purge"
+	AuditRosterImport    = "roster_import"
+	AuditInviteCreate    = "invite_create"
+	AuditInviteAccept    = "invite_accept"
+	AuditBackupCreate    = "backup_create"
+	AuditSessionRevoke   = "session_revoke"
+	AuditTeamCreate      = "team_create"
+	AuditTeamJoin        = "team_join"
+	AuditTeamLeave       = "team_leave"
+	AuditTeamRoleChange  = "team_role_change"
+	AuditTeamGroup       = "team_group"
+	AuditDuesPlanCreate  = "dues_plan_create"
+	AuditDuesPlanStop    = "dues_plan_stop"
+	AuditPaymentRecord   = "payment_record"
+	AuditExpenseAdd      = "expense_add"
+	AuditExpenseSettle   = "expense_settle"
+	AuditEventCreate     = "event_create"
+	AuditEventCancel     = "event_cancel"
+	AuditAttendanceMark  = "attendance_mark"
+	AuditPollCreate      = "poll_create"
+	AuditPollClose       = "poll_close"
+	AuditDutyCreate      = "duty_roster_create"
+	AuditDutyStop        = "duty_roster_stop"
+	AuditDutySwap        = "duty_swap"
+	AuditAnnounce        = "announcement_create"
+	AuditAnnounceClose   = "announcement_close"
+	AuditTicket          = "ticket_create"
+	AuditTicketAssign    = "ticket_assign"
+	AuditTicketClose     = "ticket_close"
+)
+
+// AuditEntry представляет запись журнала аудита
+type AuditEntry struct {
+	ID         int64     `json:"id"`
+	ActorID    int64     `json:"actor_id"`    // Пользователь, выполнивший действие (0, если неизвестен)
+	TelegramID int64     `json:"telegram_id"` // Telegram-аккаунт, с которого выполнено действие
+	TargetID   int64     `json:"target_id"`   // Пользователь, над которым выполнено действие
+	Action     string    `json:"action"`
+	Details    string    `json:"details"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AuditFilter задает условия выборки записей журнала аудита
+type AuditFilter struct {
+	UserID int64     // Пользователь - исполнитель или объект действия
+	Action string    // Действие
+	From   time.Time // Начало периода (включительно)
+	To     time.Time // Конец периода (не включительно)
+	Limit  int       // Максимальное количество записей (0 - без ограничения)
+}
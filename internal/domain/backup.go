@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// Backup описывает резервную копию базы данных
+type Backup struct {
+	Name          string    `json:"name"`
+	Size          int64     `json:"size"`
+	Checksum      string    `json:"checksum"` // SHA-256 файла резервной копии
+	Compressed    bool      `json:"compressed"`
+	CreatedAt     time.Time `json:"created_at"`
+	SchemaVersion int       `json:"schema_version,omitempty"` // Версия схемы (заполняется при проверке)
+}
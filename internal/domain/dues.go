@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout - формат календарных дат в командах и хранилище
+const DateLayout = "2006-01-02"
+
+// DuesPeriod представляет периодичность начисления взносов
+type DuesPeriod string
+
+// Периодичность взносов
+const (
+	DuesWeekly  DuesPeriod = "weekly"
+	DuesMonthly DuesPeriod = "monthly"
+)
+
+// IsKnownDuesPeriod проверяет, что периодичность входит в список известных
+func IsKnownDuesPeriod(period DuesPeriod) bool {
+	return period == DuesWeekly || period == DuesMonthly
+}
+
+// DuesPlan представляет план регулярных взносов команды. В начале каждого периода
+// участникам плана начисляется сумма взноса
+type DuesPlan struct {
+	ID             int64      `json:"id"`
+	TeamID         int64      `json:"team_id"`
+	Name           string     `json:"name"`
+	Amount         int64      `json:"amount"` // Сумма взноса в копейках
+	Period         DuesPeriod `json:"period"`
+	StartDate      time.Time  `json:"start_date"`                // Дата первого начисления (полночь UTC)
+	ChargedThrough time.Time  `json:"charged_through,omitempty"` // Начало последнего полностью начисленного периода
+	MemberIDs      []int64    `json:"member_ids,omitempty"`      // Участники плана; пусто - все участники команды
+	Active         bool       `json:"active"`
+	CreatedBy      int64      `json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// PeriodStart возвращает дату начала периода с номером n (с нуля). Месячные взносы
+// начисляются в тот же день месяца, что и первый, или в последний день короткого месяца
+func (p *DuesPlan) PeriodStart(n int) time.Time {
+	return PeriodStart(p.StartDate, p.Period, n)
+}
+
+// DuePeriods возвращает начала периодов, которые наступили к дате today и еще не начислены
+func (p *DuesPlan) DuePeriods(today time.Time) []time.Time {
+	var periods []time.Time
+	for n := 0; ; n++ {
+		start := p.PeriodStart(n)
+		if start.After(today) {
+			return periods
+		}
+		if p.ChargedThrough.IsZero() || start.After(p.ChargedThrough) {
+			periods = append(periods, start)
+		}
+	}
+}
+
+// PeriodStart возвращает дату начала периода с номером n (с нуля), если первый период
+// начинается с даты start. Месячный период начинается в тот же день месяца, что и первый,
+// или в последний день короткого месяца
+func PeriodStart(start time.Time, period DuesPeriod, n int) time.Time {
+	if period == DuesWeekly {
+		return start.AddDate(0, 0, 7*n)
+	}
+	first := time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	day := min(start.Day(), first.AddDate(0, 1, -1).Day())
+	return first.AddDate(0, 0, day-1)
+}
+
+// Date возвращает календарную дату момента t в его часовом поясе как полночь UTC
+func Date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// BalanceEntryKind представляет вид операции по балансу
+type BalanceEntryKind string
+
+// Виды операций по балансу
+const (
+	EntryCharge     BalanceEntryKind = "charge"     // Начисление взноса
+	EntryPayment    BalanceEntryKind = "payment"    // Оплата
+	EntryExpense    BalanceEntryKind = "expense"    // Доля в общем расходе или его оплата
+	EntrySettlement BalanceEntryKind = "settlement" // Перевод при взаиморасчете
+)
+
+// BalanceEntry представляет операцию по балансу участника команды. Начисления
+// уменьшают баланс, оплаты увеличивают; отрицательный баланс - долг
+type BalanceEntry struct {
+	ID        int64            `json:"id"`
+	TeamID    int64            `json:"team_id"`
+	UserID    int64            `json:"user_id"`
+	Kind      BalanceEntryKind `json:"kind"`
+	Amount    int64            `json:"amount"`               // Сумма в копейках со знаком
+	PlanID    int64            `json:"plan_id,omitempty"`    // План взносов начисления
+	ExpenseID int64            `json:"expense_id,omitempty"` // Расход или перевод
+	Period    time.Time        `json:"period,omitempty"`     // Начало периода начисления
+	Note      string           `json:"note,omitempty"`
+	CreatedBy int64            `json:"created_by,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// Debtor представляет участника команды с долгом
+type Debtor struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Debt     int64  `json:"debt"` // Сумма долга в копейках (положительная)
+}
+
+// BalanceSummary содержит баланс пользователя в команде и последние операции
+type BalanceSummary struct {
+	Team    *Team
+	Balance int64
+	Entries []*BalanceEntry
+}
+
+// ParseAmount разбирает положительную сумму вида 1500, 1500.5 или 1500,50 в копейки.
+// Знак не допускается: strconv принял бы «-0.5» как 0 рублей и 50 копеек
+func ParseAmount(s string) (int64, error) {
+	whole, frac, _ := strings.Cut(strings.Replace(s, ",", ".", 1), ".")
+	if len(frac) > 2 || whole == "" || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	rubles, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || rubles < 0 || rubles > 1e12 {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	var kopecks int64
+	if frac != "" {
+		if kopecks, err = strconv.ParseInt(frac, 10, 64); err != nil || kopecks < 0 {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+		if len(frac) == 1 {
+			kopecks *= 10
+		}
+	}
+	amount := rubles*100 + kopecks
+	if amount == 0 {
+		return 0, errors.New("amount must be positive")
+	}
+	return amount, nil
+}
+
+// isDigits проверяет, что строка состоит только из десятичных цифр
+func isDigits(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
+
+// FormatAmount форматирует сумму в копейках: 150000 - «1500», 150050 - «1500.50»
+func FormatAmount(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	if amount%100 == 0 {
+		return fmt.Sprintf("%s%d", sign, amount/100)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
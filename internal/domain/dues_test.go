@@ -0,0 +1,40 @@
+package domain_test
+
+import (
+	"testing"
+
+	"HelpBot/internal/domain"
+)
+
+func TestParseAmount(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  int64
+		ok    bool
+	}{
+		{"1500", 150000, true},
+		{"1500.5", 150050, true},
+		{"1500,50", 150050, true},
+		{"0.01", 1, true},
+		{"0", 0, false},
+		{"0.00", 0, false},
+		{"", 0, false},
+		{".50", 0, false},
+		{"1.505", 0, false},
+		{"1.2.3", 0, false},
+		{"abc", 0, false},
+		{" 15", 0, false},
+		{"-5", 0, false},
+		{"-0.5", 0, false},
+		{"-0,50", 0, false},
+		{"+5", 0, false},
+		{"+0.5", 0, false},
+		{"1.-5", 0, false},
+		{"1.+5", 0, false},
+	} {
+		got, err := domain.ParseAmount(tc.input)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("ParseAmount(%q) = %d, %v, want %d (ok %v)", tc.input, got, err, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package domain
+
+import "time"
+
+// DutyRoster представляет график дежурств команды. В начале каждого периода дежурным
+// назначается следующий по очереди участник
+type DutyRoster struct {
+	ID              int64      `json:"id"`
+	TeamID          int64      `json:"team_id"`
+	Name            string     `json:"name"`
+	Period          DuesPeriod `json:"period"`
+	StartDate       time.Time  `json:"start_date"`                 // Дата начала первого дежурства (полночь UTC)
+	MemberIDs       []int64    `json:"member_ids"`                 // Участники в порядке очереди
+	Members         []string   `json:"members,omitempty"`          // Имена участников в порядке очереди (заполняются при выборке)
+	Position        int        `json:"position"`                   // Место в очереди следующего дежурного
+	AssignedThrough time.Time  `json:"assigned_through,omitempty"` // Начало последнего периода с назначенным дежурным
+	Active          bool       `json:"active"`
+	CreatedBy       int64      `json:"created_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// PeriodStart возвращает дату начала дежурства с номером n (с нуля)
+func (r *DutyRoster) PeriodStart(n int) time.Time {
+	return PeriodStart(r.StartDate, r.Period, n)
+}
+
+// DuePeriod возвращает начало и конец периода, который идет на дату today, если дежурный
+// на него еще не назначен. Пропущенные периоды (например, пока бот был остановлен)
+// не назначаются
+func (r *DutyRoster) DuePeriod(today time.Time) (time.Time, time.Time, bool) {
+	if r.StartDate.After(today) {
+		return time.Time{}, time.Time{}, false
+	}
+	n := 0
+	for !r.PeriodStart(n + 1).After(today) {
+		n++
+	}
+	start := r.PeriodStart(n)
+	if !r.AssignedThrough.IsZero() && !start.After(r.AssignedThrough) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, r.PeriodStart(n + 1), true
+}
+
+// DutyAssignment представляет дежурство участника в одном периоде графика
+type DutyAssignment struct {
+	ID          int64     `json:"id"`
+	RosterID    int64     `json:"roster_id"`
+	RosterName  string    `json:"roster_name"` // Название графика (заполняется при выборке)
+	TeamID      int64     `json:"team_id"`
+	Period      time.Time `json:"period"` // Дата начала дежурства (полночь UTC)
+	Until       time.Time `json:"until"`  // Дата окончания дежурства, не включая ее (полночь UTC)
+	UserID      int64     `json:"user_id"`
+	Username    string    `json:"username"` // Имя дежурного (заполняется при выборке)
+	CompletedAt time.Time `json:"completed_at,omitempty"`
+	CompletedBy int64     `json:"completed_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Done проверяет, отмечено ли дежурство выполненным
+func (a *DutyAssignment) Done() bool {
+	return !a.CompletedAt.IsZero()
+}
+
+// DutySwapStatus представляет состояние запроса на обмен дежурством
+type DutySwapStatus string
+
+// Состояния запроса на обмен дежурством
+const (
+	DutySwapPending  DutySwapStatus = "pending"
+	DutySwapAccepted DutySwapStatus = "accepted"
+	DutySwapDeclined DutySwapStatus = "declined"
+)
+
+// DutySwap представляет просьбу дежурного передать дежурство другому участнику.
+// Дежурство переходит к нему, только если он согласится
+type DutySwap struct {
+	ID           int64          `json:"id"`
+	AssignmentID int64          `json:"assignment_id"`
+	FromID       int64          `json:"from_id"`
+	ToID         int64          `json:"to_id"`
+	Status       DutySwapStatus `json:"status"`
+	CreatedAt    time.Time      `json:"created_at"`
+	ResolvedAt   time.Time      `json:"resolved_at,omitempty"`
+}
+
+// DutyNotice содержит дежурство и пользователя, которого нужно о нем уведомить
+type DutyNotice struct {
+	Assignment *DutyAssignment
+	User       *User
+}
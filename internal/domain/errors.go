@@ -0,0 +1,55 @@
+package domain
+
+import "errors"
+
+// Ошибки репозиториев, одинаковые для всех хранилищ
+var (
+	// ErrUserNotFound возвращается при изменении или удалении несуществующего пользователя
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrUserExists возвращается, если имя пользователя или Telegram-аккаунт уже заняты
+	// другим пользователем или если Save вызван для уже сохраненного пользователя
+	ErrUserExists = errors.New("user already exists")
+
+	// ErrTeamExists возвращается, если название команды уже занято
+	ErrTeamExists = errors.New("team already exists")
+
+	// ErrMemberExists возвращается при повторном добавлении пользователя в команду
+	ErrMemberExists = errors.New("team member already exists")
+
+	// ErrMemberNotFound возвращается при изменении или удалении несуществующего участника команды
+	ErrMemberNotFound = errors.New("team member not found")
+
+	// ErrDuesPlanExists возвращается, если в команде уже есть план взносов с таким названием
+	ErrDuesPlanExists = errors.New("dues plan already exists")
+
+	// ErrDuesPlanNotFound возвращается при изменении несуществующего плана взносов
+	ErrDuesPlanNotFound = errors.New("dues plan not found")
+
+	// ErrEventNotFound возвращается при изменении несуществующего события
+	ErrEventNotFound = errors.New("event not found")
+
+	// ErrPollNotFound возвращается при изменении несуществующего или уже закрытого опроса
+	ErrPollNotFound = errors.New("poll not found")
+
+	// ErrDutyRosterExists возвращается, если в команде уже есть график дежурств с таким названием
+	ErrDutyRosterExists = errors.New("duty roster already exists")
+
+	// ErrDutyRosterNotFound возвращается при изменении несуществующего графика дежурств
+	ErrDutyRosterNotFound = errors.New("duty roster not found")
+
+	// ErrDutyDone возвращается при изменении несуществующего или уже выполненного дежурства
+	ErrDutyDone = errors.New("duty not found or already completed")
+
+	// ErrDutySwapResolved возвращается при ответе на несуществующий или уже решенный запрос обмена
+	ErrDutySwapResolved = errors.New("duty swap not found or already resolved")
+
+	// ErrAnnouncementClosed возвращается при изменении несуществующего или уже закрытого объявления
+	ErrAnnouncementClosed = errors.New("announcement not found or already closed")
+
+	// ErrTicketClosed возвращается при изменении несуществующего или уже закрытого обращения
+	ErrTicketClosed = errors.New("ticket not found or already closed")
+
+	// ErrInvalidQuery возвращается при некорректных параметрах выборки или курсоре
+	ErrInvalidQuery = errors.New("invalid query")
+)
@@ -0,0 +1,127 @@
+package domain
+
+import "time"
+
+// EventTimeLayout - формат даты и времени событий в командах
+const EventTimeLayout = "2006-01-02 15:04"
+
+// RSVPStatus представляет ответ участника на приглашение на событие
+type RSVPStatus string
+
+// Ответы на приглашение. Участник выбирает «иду», «не иду» или «может быть»;
+// если мест не осталось, «иду» превращается в место в листе ожидания
+const (
+	RSVPGoing    RSVPStatus = "going"
+	RSVPNotGoing RSVPStatus = "not_going"
+	RSVPMaybe    RSVPStatus = "maybe"
+	RSVPWaitlist RSVPStatus = "waitlist" // Хочет пойти, но мест нет
+)
+
+// RSVPAnswers содержит ответы, которые может выбрать участник, в порядке кнопок
+var RSVPAnswers = []RSVPStatus{RSVPGoing, RSVPNotGoing, RSVPMaybe}
+
+// IsRSVPAnswer проверяет, что ответ может выбрать участник
+func IsRSVPAnswer(status RSVPStatus) bool {
+	for _, answer := range RSVPAnswers {
+		if answer == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Event представляет событие команды: тренировку, игру или встречу
+type Event struct {
+	ID         int64     `json:"id"`
+	TeamID     int64     `json:"team_id"`
+	Title      string    `json:"title"`
+	Location   string    `json:"location,omitempty"`
+	StartsAt   time.Time `json:"starts_at"`
+	Capacity   int       `json:"capacity"`              // Число мест (0 - без ограничения)
+	Deadline   time.Time `json:"deadline,omitempty"`    // Срок ответа (нулевой - до начала события)
+	Canceled   bool      `json:"canceled"`              // Событие отменено
+	RemindedAt time.Time `json:"reminded_at,omitempty"` // Время отправки напоминания (нулевое, если не отправлялось)
+	CreatedBy  int64     `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	Going      int `json:"going"`      // Число идущих (заполняется при выборке)
+	Waitlisted int `json:"waitlisted"` // Число участников в листе ожидания (заполняется при выборке)
+}
+
+// RSVPDeadline возвращает момент, до которого принимаются ответы
+func (e *Event) RSVPDeadline() time.Time {
+	if e.Deadline.IsZero() {
+		return e.StartsAt
+	}
+	return e.Deadline
+}
+
+// RSVPOpen проверяет, что на приглашение еще можно ответить
+func (e *Event) RSVPOpen(now time.Time) bool {
+	return !e.Canceled && now.Before(e.RSVPDeadline())
+}
+
+// RSVP представляет ответ участника на приглашение
+type RSVP struct {
+	EventID     int64      `json:"event_id"`
+	UserID      int64      `json:"user_id"`
+	Username    string     `json:"username"` // Имя участника (заполняется при выборке)
+	Status      RSVPStatus `json:"status"`
+	RespondedAt time.Time  `json:"responded_at"` // Время последней смены ответа; определяет очередь листа ожидания
+}
+
+// RSVPResult описывает итог ответа участника на приглашение
+type RSVPResult struct {
+	Event    *Event     // Событие с обновленным числом участников
+	Status   RSVPStatus // Записанный ответ: RSVPWaitlist, если мест не осталось
+	Position int        // Место в листе ожидания (0, если участник не в нем)
+	Promoted *User      // Участник, которому освободилось место из листа ожидания
+}
+
+// EventReminder содержит событие, о котором пора напомнить, и получателей напоминания
+type EventReminder struct {
+	Event *Event
+	Users []*User
+}
+
+// AttendanceStatus представляет отметку о посещении события
+type AttendanceStatus string
+
+// Отметки о посещении. Участник без отметки считается не пришедшим
+const (
+	AttendancePresent AttendanceStatus = "present"
+	AttendanceAbsent  AttendanceStatus = "absent"
+)
+
+// Attendance представляет отметку о посещении события участником
+type Attendance struct {
+	EventID     int64            `json:"event_id"`
+	UserID      int64            `json:"user_id"`
+	Username    string           `json:"username"` // Имя участника (заполняется при выборке)
+	Status      AttendanceStatus `json:"status"`   // Пустая, если участника еще не отмечали
+	SelfCheckIn bool             `json:"self_check_in"`
+	MarkedBy    int64            `json:"marked_by"`
+	MarkedAt    time.Time        `json:"marked_at"`
+}
+
+// AttendanceEntry описывает посещение участником события, на котором отмечали присутствие
+type AttendanceEntry struct {
+	Event  *Event
+	Status AttendanceStatus // Пустая, если участника не отметили
+}
+
+// AttendanceRate содержит посещаемость участника за период: сколько событий с отметками
+// прошло с его вступления в команду и на скольких он был
+type AttendanceRate struct {
+	User     *User
+	Events   int
+	Attended int
+}
+
+// Percent возвращает долю посещенных событий в процентах
+func (r *AttendanceRate) Percent() int {
+	if r.Events == 0 {
+		return 0
+	}
+	return r.Attended * 100 / r.Events
+}
@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// SplitMode представляет способ деления расхода между участниками
+type SplitMode string
+
+// Способы деления расхода
+const (
+	SplitEqual  SplitMode = "equal"  // Поровну
+	SplitShares SplitMode = "shares" // Пропорционально долям
+	SplitExact  SplitMode = "exact"  // Точными суммами
+)
+
+// IsKnownSplitMode проверяет, что способ деления входит в список известных
+func IsKnownSplitMode(mode SplitMode) bool {
+	return mode == SplitEqual || mode == SplitShares || mode == SplitExact
+}
+
+// ExpenseShare представляет часть расхода, приходящуюся на участника
+type ExpenseShare struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Value    int64  `json:"value,omitempty"` // Доля (shares) или сумма в копейках (exact)
+	Amount   int64  `json:"amount"`          // Часть расхода в копейках
+}
+
+// Expense представляет расход, оплаченный одним участником команды за нескольких.
+// Перевод при взаиморасчете хранится как расход с одним участником - получателем
+type Expense struct {
+	ID          int64          `json:"id"`
+	TeamID      int64          `json:"team_id"`
+	PayerID     int64          `json:"payer_id"`
+	PayerName   string         `json:"payer_name"`
+	Amount      int64          `json:"amount"` // Сумма в копейках
+	Description string         `json:"description,omitempty"`
+	Mode        SplitMode      `json:"mode"`
+	Settlement  bool           `json:"settlement,omitempty"`
+	Shares      []ExpenseShare `json:"shares"`
+	CreatedBy   int64          `json:"created_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// Transfer представляет перевод, который закрывает взаимные долги участников
+type Transfer struct {
+	FromID   int64  `json:"from_id"`
+	FromName string `json:"from_name"`
+	ToID     int64  `json:"to_id"`
+	ToName   string `json:"to_name"`
+	Amount   int64  `json:"amount"`
+}
+
+// maxExactSettleUp ограничивает число участников, для которых минимальный набор
+// переводов ищется перебором; для большего числа используется жадный алгоритм
+const maxExactSettleUp = 16
+
+// SettleUp возвращает переводы, закрывающие взаимные долги при указанных балансах
+// (сумма балансов должна быть нулевой). Число переводов минимально: участники
+// делятся на наибольшее число групп с нулевой суммой балансов, и в группе из k
+// участников достаточно k-1 перевода
+func SettleUp(balances map[int64]int64) []*Transfer {
+	ids := make([]int64, 0, len(balances))
+	for id, balance := range balances {
+		if balance != 0 {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	var transfers []*Transfer
+	for _, group := range zeroSumGroups(ids, balances) {
+		transfers = append(transfers, settleGroup(group, balances)...)
+	}
+	return transfers
+}
+
+// zeroSumGroups делит участников на наибольшее число групп с нулевой суммой балансов.
+// dp[mask] - наибольшее число таких групп, на которые можно разбить множество mask
+func zeroSumGroups(ids []int64, balances map[int64]int64) [][]int64 {
+	n := len(ids)
+	if n == 0 {
+		return nil
+	}
+	if n > maxExactSettleUp {
+		return [][]int64{ids}
+	}
+
+	full := 1<<n - 1
+	sums := make([]int64, full+1)
+	dp := make([]int, full+1)
+	for mask := 1; mask <= full; mask++ {
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) != 0 {
+				sums[mask] = sums[mask&^(1<<i)] + balances[ids[i]]
+				break
+			}
+		}
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) != 0 {
+				dp[mask] = max(dp[mask], dp[mask&^(1<<i)])
+			}
+		}
+		if sums[mask] == 0 {
+			dp[mask]++
+		}
+	}
+
+	// Восстанавливаем порядок удаления участников: участники, удаленные между двумя
+	// множествами с нулевой суммой, образуют группу
+	var groups [][]int64
+	var group []int64
+	for mask := full; mask != 0; {
+		want := dp[mask]
+		if sums[mask] == 0 {
+			want--
+		}
+		for i := 0; i < n; i++ {
+			if mask&(1<<i) != 0 && dp[mask&^(1<<i)] == want {
+				group = append(group, ids[i])
+				mask &^= 1 << i
+				break
+			}
+		}
+		if sums[mask] == 0 {
+			groups = append(groups, group)
+			group = nil
+		}
+	}
+	return groups
+}
+
+// settleGroup закрывает долги группы: наибольший должник платит наибольшему кредитору
+func settleGroup(group []int64, balances map[int64]int64) []*Transfer {
+	left := make(map[int64]int64, len(group))
+	for _, id := range group {
+		left[id] = balances[id]
+	}
+
+	var transfers []*Transfer
+	for {
+		var debtor, creditor int64
+		for _, id := range group {
+			if left[id] < 0 && (debtor == 0 || left[id] < left[debtor]) {
+				debtor = id
+			}
+			if left[id] > 0 && (creditor == 0 || left[id] > left[creditor]) {
+				creditor = id
+			}
+		}
+		if debtor == 0 || creditor == 0 {
+			return transfers
+		}
+		amount := min(-left[debtor], left[creditor])
+		transfers = append(transfers, &Transfer{FromID: debtor, ToID: creditor, Amount: amount})
+		left[debtor] += amount
+		left[creditor] -= amount
+	}
+}
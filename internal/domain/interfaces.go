@@ -1,9 +1,14 @@
 package domain
 
+import "time"
+
 // UserRepository определяет методы для работы с пользователями в БД
 type UserRepository interface {
-	// GetByID возвращает пользователя по его ChatID
-	GetByID(chatID int64) (*User, error)
+	// GetByID возвращает пользователя по его идентификатору
+	GetByID(id int64) (*User, error)
+
+	// GetByTelegramID возвращает пользователя, привязанного к Telegram-аккаунту
+	GetByTelegramID(telegramID int64) (*User, error)
 
 	// GetByUsername возвращает пользователя по его имени пользователя
 	GetByUsername(username string) (*User, error)
@@ -14,23 +19,406 @@ type UserRepository interface {
 	// Update обновляет существующего пользователя
 	Update(user *User) error
 
-	// Delete удаляет пользователя
-	Delete(chatID int64) error
+	// UpdateStatus изменяет состояние учетной записи пользователя и причину изменения
+	UpdateStatus(id int64, status UserStatus, reason string) error
+
+	// AnonymizeDeleted обезличивает пользователей, удаленных раньше указанного времени,
+	// и возвращает их идентификаторы. Записи сохраняются, чтобы не терять историю
+	AnonymizeDeleted(before time.Time) ([]int64, error)
+
+	// RevokeSessions завершает все сессии пользователя: сессии и токены,
+	// выданные до вызова, перестают действовать
+	RevokeSessions(id int64) error
 
-	// GetAll возвращает всех пользователей
-	GetAll() ([]*User, error)
+	// Find возвращает страницу пользователей, удовлетворяющих запросу
+	Find(query UserQuery) (*UserPage, error)
 
 	// UpdatePassword обновляет пароль пользователя
-	UpdatePassword(chatID int64, newPassword string) error
+	UpdatePassword(id int64, newPassword string) error
 
 	// UpdateRole обновляет роль пользователя
-	UpdateRole(chatID int64, newRole string) error
+	UpdateRole(id int64, newRole string) error
+
+	// BindTelegram привязывает пользователя к Telegram-аккаунту и чату доставки
+	BindTelegram(id int64, telegramID int64, chatID int64) error
+
+	// UpdateLanguage обновляет язык интерфейса пользователя
+	UpdateLanguage(id int64, language string) error
+
+	// SetActiveTeam выбирает активную команду пользователя (0 - без команды)
+	SetActiveTeam(id int64, teamID int64) error
+}
+
+// TeamRepository определяет методы для работы с командами и их участниками
+type TeamRepository interface {
+	// Save сохраняет новую команду
+	Save(team *Team) error
+
+	// GetByID возвращает команду по ее идентификатору
+	GetByID(id int64) (*Team, error)
+
+	// GetAll возвращает все команды, упорядоченные по названию
+	GetAll() ([]*Team, error)
+
+	// SetGroupChat привязывает к команде группу Telegram
+	SetGroupChat(teamID int64, chatID int64) error
+
+	// GetMember возвращает членство пользователя в команде или nil, если он в ней не состоит
+	GetMember(teamID int64, userID int64) (*TeamMember, error)
+
+	// GetMemberships возвращает команды пользователя, упорядоченные по названию
+	GetMemberships(userID int64) ([]*TeamMember, error)
+
+	// AddMember добавляет пользователя в команду
+	AddMember(member *TeamMember) error
+
+	// UpdateMemberRole изменяет роль участника команды
+	UpdateMemberRole(teamID int64, userID int64, role string) error
+
+	// RemoveMember исключает пользователя из команды
+	RemoveMember(teamID int64, userID int64) error
+
+	// FindMembers возвращает страницу участников команды, удовлетворяющих запросу.
+	// Фильтр Role относится к роли в команде, она же возвращается в поле User.TeamRole
+	FindMembers(teamID int64, query UserQuery) (*UserPage, error)
+}
+
+// DuesRepository определяет методы для работы с планами взносов
+type DuesRepository interface {
+	// SavePlan сохраняет новый план взносов вместе со списком его участников
+	SavePlan(plan *DuesPlan) error
+
+	// GetPlan возвращает план взносов по его идентификатору или nil, если его нет
+	GetPlan(id int64) (*DuesPlan, error)
+
+	// GetPlans возвращает планы взносов команды, упорядоченные по названию
+	GetPlans(teamID int64) ([]*DuesPlan, error)
+
+	// GetActivePlans возвращает действующие планы взносов всех команд
+	GetActivePlans() ([]*DuesPlan, error)
+
+	// SetActive включает или останавливает план взносов
+	SetActive(id int64, active bool) error
+
+	// SetChargedThrough отмечает период, по который включительно начислены взносы плана
+	SetChargedThrough(id int64, period time.Time) error
+}
+
+// BalanceRepository определяет методы для работы с операциями по балансам участников команд
+type BalanceRepository interface {
+	// AddEntry добавляет операцию. Повторное начисление по тому же плану, пользователю
+	// и периоду не добавляется, и метод возвращает false
+	AddEntry(entry *BalanceEntry) (bool, error)
+
+	// GetBalance возвращает баланс пользователя в команде
+	GetBalance(teamID int64, userID int64) (int64, error)
+
+	// GetEntries возвращает последние операции пользователя в команде, начиная с новых
+	GetEntries(teamID int64, userID int64, limit int) ([]*BalanceEntry, error)
+
+	// GetDebtors возвращает участников команды с долгом по взносам, начиная с наибольшего долга
+	GetDebtors(teamID int64) ([]*Debtor, error)
+}
+
+// ExpenseRepository определяет методы для работы с общими расходами участников команд
+type ExpenseRepository interface {
+	// Save сохраняет расход вместе с долями участников и операциями по их балансам
+	Save(expense *Expense, entries []*BalanceEntry) error
+
+	// GetExpenses возвращает последние расходы команды, начиная с новых
+	GetExpenses(teamID int64, limit int) ([]*Expense, error)
+
+	// GetNetBalances возвращает ненулевые балансы участников команды по расходам и переводам
+	GetNetBalances(teamID int64) (map[int64]int64, error)
+}
+
+// EventRepository определяет методы для работы с событиями команд и ответами на приглашения
+type EventRepository interface {
+	// Save сохраняет новое событие
+	Save(event *Event) error
+
+	// GetByID возвращает событие по его идентификатору или nil, если его нет
+	GetByID(id int64) (*Event, error)
+
+	// GetUpcoming возвращает неотмененные события команды, которые начинаются не раньше from,
+	// в порядке начала
+	GetUpcoming(teamID int64, from time.Time) ([]*Event, error)
+
+	// GetUnreminded возвращает неотмененные события всех команд, которые начинаются не позже
+	// until и о которых еще не напоминали
+	GetUnreminded(until time.Time) ([]*Event, error)
+
+	// Cancel отмечает событие отмененным
+	Cancel(id int64) error
+
+	// MarkReminded отмечает, что напоминание о событии отправлено
+	MarkReminded(id int64, at time.Time) error
+
+	// Respond записывает ответ участника. Если мест не осталось, «иду» записывается как место
+	// в листе ожидания; если идущий участник отказывается, его место получает первый из листа
+	// ожидания, и возвращается его идентификатор (0, если никто не получил место)
+	Respond(rsvp *RSVP) (promotedID int64, err error)
+
+	// GetRSVPs возвращает ответы на приглашение в порядке ответа
+	GetRSVPs(eventID int64) ([]*RSVP, error)
+}
+
+// AttendanceRepository определяет методы для работы с отметками о посещении событий
+type AttendanceRepository interface {
+	// Mark сохраняет отметку участника, заменяя прежнюю
+	Mark(attendance *Attendance) error
+
+	// GetByEvent возвращает отметки о посещении события, упорядоченные по имени участника
+	GetByEvent(eventID int64) ([]*Attendance, error)
+
+	// GetTracked возвращает неотмененные события команды, которые начинаются в периоде
+	// [from, to) и на которых отмечали присутствие, в порядке начала
+	GetTracked(teamID int64, from, to time.Time) ([]*Event, error)
+
+	// GetByTeam возвращает отметки о посещении событий команды, которые начинаются
+	// в периоде [from, to)
+	GetByTeam(teamID int64, from, to time.Time) ([]*Attendance, error)
+}
+
+// PollRepository определяет методы для работы с опросами команд и голосами
+type PollRepository interface {
+	// Save сохраняет новый опрос вместе с вариантами ответа
+	Save(poll *Poll) error
+
+	// GetByID возвращает опрос с вариантами и числом голосов или nil, если его нет
+	GetByID(id int64) (*Poll, error)
+
+	// GetOpen возвращает незакрытые опросы команды в порядке создания
+	GetOpen(teamID int64) ([]*Poll, error)
+
+	// GetExpired возвращает незакрытые опросы всех команд, срок которых истек к моменту now
+	GetExpired(now time.Time) ([]*Poll, error)
+
+	// Close закрывает опрос. Возвращает ErrPollNotFound, если открытого опроса нет
+	Close(id int64, at time.Time) error
+
+	// Vote отмечает или снимает голос участника за вариант и сообщает, выбран ли вариант
+	// после голоса. Если выбрать можно только один вариант, прежний голос заменяется
+	Vote(vote *PollVote, multiple bool) (selected bool, err error)
+
+	// GetVotes возвращает голоса опроса по вариантам в порядке голосования
+	GetVotes(pollID int64) ([]*PollVote, error)
+
+	// AddMessage запоминает отправленное сообщение с опросом
+	AddMessage(message *PollMessage) error
+
+	// GetMessages возвращает сообщения с опросом
+	GetMessages(pollID int64) ([]*PollMessage, error)
+}
+
+// DutyRepository определяет методы для работы с графиками дежурств, дежурствами
+// и запросами на обмен
+type DutyRepository interface {
+	// SaveRoster сохраняет новый график дежурств вместе с очередью участников
+	SaveRoster(roster *DutyRoster) error
+
+	// GetRoster возвращает график дежурств по идентификатору или nil, если его нет
+	GetRoster(id int64) (*DutyRoster, error)
+
+	// GetRosters возвращает графики дежурств команды, упорядоченные по названию
+	GetRosters(teamID int64) ([]*DutyRoster, error)
+
+	// GetActiveRosters возвращает действующие графики дежурств всех команд
+	GetActiveRosters() ([]*DutyRoster, error)
+
+	// SetActive включает или останавливает график дежурств
+	SetActive(id int64, active bool) error
+
+	// Assign сохраняет дежурство и переносит очередь графика на позицию position.
+	// Сообщает, было ли дежурство добавлено: на период графика назначается один дежурный
+	Assign(assignment *DutyAssignment, position int) (bool, error)
+
+	// GetAssignment возвращает дежурство по идентификатору или nil, если его нет
+	GetAssignment(id int64) (*DutyAssignment, error)
+
+	// GetCurrent возвращает последние назначенные дежурства действующих графиков команды
+	GetCurrent(teamID int64) ([]*DutyAssignment, error)
+
+	// GetHistory возвращает дежурства команды, начавшиеся в периоде [from, to),
+	// от новых к старым
+	GetHistory(teamID int64, from, to time.Time) ([]*DutyAssignment, error)
+
+	// Complete отмечает дежурство выполненным. Возвращает ErrDutyDone, если дежурства
+	// нет или оно уже выполнено
+	Complete(id int64, completedBy int64, at time.Time) error
+
+	// SaveSwap сохраняет новый запрос на обмен дежурством
+	SaveSwap(swap *DutySwap) error
+
+	// GetSwap возвращает запрос на обмен по идентификатору или nil, если его нет
+	GetSwap(id int64) (*DutySwap, error)
+
+	// GetPendingSwap возвращает ожидающий ответа запрос на обмен дежурством или nil
+	GetPendingSwap(assignmentID int64) (*DutySwap, error)
+
+	// ResolveSwap записывает ответ на запрос обмена, а при согласии передает дежурство
+	// участнику, которого просили. Возвращает ErrDutySwapResolved, если запрос уже решен
+	ResolveSwap(swap *DutySwap) error
+}
+
+// AnnouncementRepository определяет методы для работы с объявлениями и отметками о прочтении
+type AnnouncementRepository interface {
+	// Save сохраняет новое объявление вместе с его получателями
+	Save(announcement *Announcement, userIDs []int64) error
+
+	// GetByID возвращает объявление с числом получателей и прочитавших или nil, если его нет
+	GetByID(id int64) (*Announcement, error)
+
+	// GetActive возвращает действующие объявления команды от новых к старым
+	GetActive(teamID int64) ([]*Announcement, error)
+
+	// SetMessage запоминает закрепленное сообщение объявления в группе команды
+	SetMessage(id int64, chatID int64, messageID int) error
+
+	// Close закрывает объявление. Возвращает ErrAnnouncementClosed, если объявления нет
+	// или оно уже закрыто
+	Close(id int64, at time.Time) error
+
+	// GetReceipt возвращает отметку получателя объявления или nil, если он не получатель
+	GetReceipt(id int64, userID int64) (*AnnouncementReceipt, error)
+
+	// GetReceipts возвращает отметки всех получателей объявления, упорядоченные по имени
+	GetReceipts(id int64) ([]*AnnouncementReceipt, error)
+
+	// Acknowledge отмечает объявление прочитанным получателем и сообщает, была ли
+	// отметка поставлена сейчас, а не раньше
+	Acknowledge(id int64, userID int64, at time.Time) (bool, error)
+
+	// GetUnacknowledged возвращает отметки непрочитавших получателей действующих объявлений,
+	// которым напоминали меньше maxReminders раз, а последнее напоминание (или само
+	// объявление) было не позже before
+	GetUnacknowledged(before time.Time, maxReminders int) ([]*AnnouncementReceipt, error)
+
+	// MarkReminded записывает напоминание получателю объявления
+	MarkReminded(id int64, userID int64, at time.Time) error
+}
+
+// TicketRepository определяет методы для работы с обращениями в поддержку и перепиской по ним
+type TicketRepository interface {
+	// Save сохраняет новое обращение
+	Save(ticket *Ticket) error
+
+	// GetByID возвращает обращение с именами автора и исполнителя или nil, если его нет
+	GetByID(id int64) (*Ticket, error)
+
+	// GetByUser возвращает обращения пользователя от новых к старым
+	GetByUser(userID int64) ([]*Ticket, error)
+
+	// GetByStatus возвращает обращения с указанными статусами от старых к новым
+	GetByStatus(statuses []TicketStatus) ([]*Ticket, error)
+
+	// Update сохраняет статус и исполнителя обращения. Возвращает ErrTicketClosed, если
+	// обращения нет или оно уже закрыто
+	Update(ticket *Ticket) error
+
+	// AddMessage сохраняет сообщение в переписке по обращению
+	AddMessage(message *TicketMessage) error
+
+	// GetMessages возвращает переписку по обращению от старых сообщений к новым
+	GetMessages(ticketID int64) ([]*TicketMessage, error)
+}
+
+// TransferRepository определяет методы для работы с запросами на перенос аккаунтов
+type TransferRepository interface {
+	// Save сохраняет новый запрос на перенос
+	Save(transfer *AccountTransfer) error
+
+	// GetByID возвращает запрос на перенос по его идентификатору
+	GetByID(id int64) (*AccountTransfer, error)
+
+	// GetPendingByUser возвращает ожидающий запрос на перенос аккаунта на указанный Telegram-аккаунт
+	GetPendingByUser(userID int64, toTelegramID int64) (*AccountTransfer, error)
+
+	// GetPending возвращает все ожидающие запросы на перенос
+	GetPending() ([]*AccountTransfer, error)
+
+	// UpdateStatus обновляет статус запроса на перенос
+	UpdateStatus(id int64, status string, resolvedBy int64) error
+}
+
+// RoleRepository определяет методы для работы с ролями в БД
+type RoleRepository interface {
+	// GetByName возвращает роль по ее имени
+	GetByName(name string) (*Role, error)
+
+	// GetAll возвращает все роли
+	GetAll() ([]*Role, error)
+
+	// Save сохраняет новую роль вместе с ее правами
+	Save(role *Role) error
+
+	// Update обновляет описание и права существующей роли
+	Update(role *Role) error
+
+	// Delete удаляет роль
+	Delete(name string) error
+
+	// CountUsers возвращает количество пользователей с указанной ролью
+	CountUsers(name string) (int, error)
+}
+
+// AuditRepository определяет методы для работы с журналом аудита в БД.
+// Журнал только дополняется: изменять и удалять записи нельзя
+type AuditRepository interface {
+	// Append добавляет запись в журнал
+	Append(entry *AuditEntry) error
+
+	// Find возвращает записи журнала по фильтру, начиная с самых новых
+	Find(filter AuditFilter) ([]*AuditEntry, error)
+}
+
+// TwoFactorRepository определяет методы для работы с настройками 2FA в БД
+type TwoFactorRepository interface {
+	// Get возвращает настройки 2FA пользователя
+	Get(userID int64) (*TwoFactor, error)
+
+	// SaveSecret сохраняет новый неподтвержденный секрет пользователя
+	SaveSecret(userID int64, secret string) error
+
+	// Enable включает 2FA пользователя
+	Enable(userID int64) error
+
+	// UseCounter запоминает период принятого кода из приложения, если он больше последнего
+	// запомненного, и сообщает, был ли он запомнен. Так один код нельзя использовать дважды
+	UseCounter(userID int64, counter int64) (bool, error)
+
+	// Delete удаляет настройки 2FA и коды восстановления пользователя
+	Delete(userID int64) error
+
+	// ReplaceRecoveryCodes заменяет коды восстановления пользователя
+	ReplaceRecoveryCodes(userID int64, codeHashes []string) error
+
+	// GetUnusedRecoveryCodes возвращает неиспользованные коды восстановления
+	GetUnusedRecoveryCodes(userID int64) ([]*RecoveryCode, error)
+
+	// UseRecoveryCode помечает код восстановления использованным
+	UseRecoveryCode(id int64) error
+}
+
+// InviteRepository определяет методы для работы с приглашениями пользователей
+type InviteRepository interface {
+	// Save сохраняет приглашение, заменяя предыдущее приглашение пользователя
+	Save(invite *Invite) error
+
+	// GetByCodeHash возвращает приглашение по хешу кода
+	GetByCodeHash(codeHash string) (*Invite, error)
+
+	// Delete удаляет приглашение пользователя
+	Delete(userID int64) error
 }
 
 // UserService определяет методы для работы с пользователями
 type UserService interface {
-	// GetUser возвращает пользователя по его ChatID
-	GetUser(chatID int64) (*User, error)
+	// GetUser возвращает пользователя по его идентификатору
+	GetUser(id int64) (*User, error)
+
+	// GetUserByTelegramID возвращает пользователя, привязанного к Telegram-аккаунту
+	GetUserByTelegramID(telegramID int64) (*User, error)
 
 	// GetUserByUsername возвращает пользователя по его имени пользователя
 	GetUserByUsername(username string) (*User, error)
@@ -38,36 +426,393 @@ type UserService interface {
 	// SaveUser сохраняет или обновляет пользователя
 	SaveUser(user *User) error
 
-	// DeleteUser удаляет пользователя
-	DeleteUser(chatID int64) error
-
-	// GetAllUsers возвращает всех пользователей
-	GetAllUsers() ([]*User, error)
+	// FindUsers возвращает страницу пользователей, удовлетворяющих запросу
+	FindUsers(query UserQuery) (*UserPage, error)
 
 	// UpdateUserProfile обновляет профиль пользователя
-	UpdateUserProfile(chatID int64, position, birthday, number string) error
+	UpdateUserProfile(telegramID int64, position, birthday, number string) error
+
+	// SetLanguage сохраняет язык интерфейса пользователя
+	SetLanguage(id int64, language string) error
 }
 
 // SessionService определяет методы для работы с сессиями пользователей
 type SessionService interface {
-	// GetSession возвращает сессию пользователя по его ChatID
-	GetSession(chatID int64) (*UserSession, error)
+	// GetSession возвращает сессию пользователя по его Telegram ID
+	GetSession(telegramID int64) (*UserSession, error)
 
 	// UpdateSession обновляет сессию пользователя
-	UpdateSession(chatID int64, session *UserSession) error
+	UpdateSession(telegramID int64, session *UserSession) error
 
 	// Login аутентифицирует пользователя
-	Login(chatID int64, username, password string) error
+	Login(telegramID int64, chatID int64, username, password string) error
 
 	// Logout выходит из системы пользователя
-	Logout(chatID int64) error
+	Logout(telegramID int64) error
 
 	// Register регистрирует нового пользователя
 	Register(user *User) error
 
 	// ValidateToken проверяет токен пользователя
-	ValidateToken(chatID int64, token string) error
+	ValidateToken(telegramID int64, token string) error
 
 	// IsAdmin проверяет, является ли пользователь администратором
-	IsAdmin(chatID int64) (bool, error)
+	IsAdmin(telegramID int64) (bool, error)
+
+	// ChangeRole изменяет роль пользователя (требует права roles.manage)
+	ChangeRole(actorID int64, targetUsername string, newRole string) error
+
+	// ChangeStatus изменяет состояние учетной записи пользователя (требует права users.manage)
+	// и завершает его активные сессии
+	ChangeStatus(actorID int64, targetUsername string, status UserStatus, reason string) (*User, error)
+
+	// CheckInvite проверяет код приглашения и возвращает приглашенного пользователя
+	CheckInvite(code string) (*User, error)
+
+	// AcceptInvite задает пароль пользователя по коду приглашения и привязывает его к Telegram-аккаунту
+	AcceptInvite(telegramID int64, chatID int64, code, password string) (*User, error)
+
+	// CompleteTwoFactor завершает вход кодом 2FA. Если пользователь подключал 2FA при входе,
+	// возвращает новые коды восстановления
+	CompleteTwoFactor(telegramID int64, code string) ([]string, error)
+
+	// DisableTwoFactor отключает 2FA авторизованного пользователя после проверки кода.
+	// Неудачные попытки ограничены так же, как при входе: затем сессия завершается
+	DisableTwoFactor(telegramID int64, code string) error
+}
+
+// TeamService определяет методы для работы с командами. Действия с участниками
+// выполняются в активной команде пользователя, который их выполняет
+type TeamService interface {
+	// Teams возвращает команды, доступные пользователю: его команды, а для пользователей
+	// с правом teams.manage - все команды (роль в чужих командах пустая)
+	Teams(userID int64) ([]*TeamMember, error)
+
+	// ActiveTeam возвращает активную команду пользователя или nil, если она не выбрана
+	ActiveTeam(user *User) (*Team, error)
+
+	// SwitchTeam делает команду активной для пользователя
+	SwitchTeam(userID int64, teamID int64) (*Team, error)
+
+	// CreateTeam создает команду (требует права teams.manage). Создатель становится
+	// ее администратором, и команда становится для него активной
+	CreateTeam(actorID int64, name string) (*Team, error)
+
+	// AddMember добавляет пользователя в активную команду (требует права users.manage)
+	AddMember(actorID int64, username, role string) (*User, error)
+
+	// RemoveMember исключает пользователя из активной команды (требует права users.manage)
+	RemoveMember(actorID int64, username string) (*User, error)
+
+	// SetMemberRole изменяет роль пользователя в активной команде (требует права users.manage)
+	SetMemberRole(actorID int64, username, role string) (*User, error)
+
+	// FindMembers возвращает страницу участников активной команды (требует права users.view)
+	FindMembers(actorID int64, query UserQuery) (*UserPage, error)
+
+	// BindGroup привязывает группу Telegram к активной команде (требует роли администратора команды).
+	// В этой группе публикуются и закрепляются объявления и опросы команды
+	BindGroup(actorID int64, chatID int64) (*Team, error)
+}
+
+// TransferService определяет методы для работы с переносом аккаунтов
+type TransferService interface {
+	// GetPendingTransfers возвращает ожидающие запросы на перенос
+	GetPendingTransfers() ([]*AccountTransfer, error)
+
+	// ApproveTransfer одобряет перенос аккаунта (требует права transfers.approve)
+	ApproveTransfer(actorID int64, transferID int64) (*AccountTransfer, error)
+
+	// RejectTransfer отклоняет перенос аккаунта (требует права transfers.approve)
+	RejectTransfer(actorID int64, transferID int64) (*AccountTransfer, error)
+}
+
+// RoleService определяет методы для работы с ролями и правами
+type RoleService interface {
+	// Can проверяет, есть ли у пользователя указанное право
+	Can(user *User, permission Permission) bool
+
+	// RequirePermission проверяет, что у пользователя с указанным идентификатором есть право
+	RequirePermission(userID int64, permission Permission) error
+
+	// GetRole возвращает роль по ее имени
+	GetRole(name string) (*Role, error)
+
+	// GetRoles возвращает все роли
+	GetRoles() ([]*Role, error)
+
+	// CreateRole создает новую роль без прав (только для пользователей с правом управления ролями)
+	CreateRole(actorID int64, name, description string) (*Role, error)
+
+	// TogglePermission добавляет право в роль или убирает его из нее
+	TogglePermission(actorID int64, roleName string, permission Permission) (*Role, error)
+
+	// DeleteRole удаляет пользовательскую роль
+	DeleteRole(actorID int64, name string) error
+}
+
+// AuditService определяет методы для работы с журналом аудита
+type AuditService interface {
+	// Record добавляет запись в журнал аудита
+	Record(entry *AuditEntry)
+
+	// Find возвращает записи журнала по фильтру
+	Find(filter AuditFilter) ([]*AuditEntry, error)
+
+	// ExportCSV выгружает записи журнала по фильтру в CSV
+	ExportCSV(filter AuditFilter) ([]byte, error)
+
+	// Username возвращает имя пользователя для отображения в журнале
+	Username(id int64) string
+}
+
+// RosterService определяет методы импорта и выгрузки состава команды
+type RosterService interface {
+	// Import разбирает файл состава активной команды и возвращает план изменений. Если apply установлен,
+	// изменения применяются: существующие пользователи обновляются и добавляются в команду,
+	// новые создаются с кодами приглашения. Пользователей не из команды можно указать в файле,
+	// только если право users.manage выдано ролью учетной записи. Если применение прервано ошибкой хранилища,
+	// вместе с ошибкой возвращается результат, в котором уже примененные строки отмечены Done
+	// и содержат выданные коды приглашения
+	Import(actorID int64, format RosterFormat, data []byte, apply bool) (*RosterImport, error)
+
+	// Export выгружает состав активной команды (кроме удаленных пользователей) в указанном формате
+	Export(actorID int64, format RosterFormat) ([]byte, error)
+}
+
+// DuesService определяет методы для работы с взносами и балансами участников
+type DuesService interface {
+	// CreatePlan создает план взносов активной команды (требует права dues.manage).
+	// Пустой список участников означает всех участников команды
+	CreatePlan(actorID int64, name string, amount int64, period DuesPeriod, start time.Time, usernames []string) (*DuesPlan, error)
+
+	// Plans возвращает планы взносов активной команды (требует права dues.manage)
+	Plans(actorID int64) ([]*DuesPlan, error)
+
+	// StopPlan останавливает начисления по плану взносов (требует права dues.manage)
+	StopPlan(actorID int64, planID int64) (*DuesPlan, error)
+
+	// RecordPayment записывает оплату участника активной команды (требует права payments.confirm)
+	RecordPayment(actorID int64, username string, amount int64, note string) (*BalanceEntry, error)
+
+	// Balance возвращает баланс пользователя в его активной команде
+	Balance(userID int64) (*BalanceSummary, error)
+
+	// Debtors возвращает должников активной команды (требует права dues.manage)
+	Debtors(actorID int64) ([]*Debtor, error)
+}
+
+// ExpenseService определяет методы для работы с общими расходами участников команды
+type ExpenseService interface {
+	// AddExpense записывает расход активной команды, оплаченный участником payer, и делит
+	// его между участниками указанным способом
+	AddExpense(actorID int64, payer string, amount int64, mode SplitMode, shares []ExpenseShare, description string) (*Expense, error)
+
+	// Expenses возвращает последние расходы активной команды
+	Expenses(actorID int64) ([]*Expense, error)
+
+	// SettleUp возвращает наименьший набор переводов, закрывающий взаимные долги участников
+	SettleUp(actorID int64) ([]*Transfer, error)
+
+	// Settle записывает перевод пользователя участнику активной команды в счет долга
+	Settle(actorID int64, username string, amount int64) (*Expense, error)
+}
+
+// EventService определяет методы работы с событиями команд и ответами на приглашения
+type EventService interface {
+	// CreateEvent создает событие активной команды и возвращает участников команды,
+	// которым нужно отправить приглашение
+	CreateEvent(actorID int64, event *Event) ([]*User, error)
+
+	// UpcomingEvents возвращает предстоящие события активной команды
+	UpcomingEvents(actorID int64) ([]*Event, error)
+
+	// GetEvent возвращает событие вместе с ответами участников
+	GetEvent(actorID int64, eventID int64) (*Event, []*RSVP, error)
+
+	// Respond записывает ответ участника на приглашение
+	Respond(actorID int64, eventID int64, status RSVPStatus) (*RSVPResult, error)
+
+	// CancelEvent отменяет событие и возвращает участников, которые на него собирались
+	CancelEvent(actorID int64, eventID int64) (*Event, []*User, error)
+
+	// DueReminders возвращает события, о которых пора напомнить, вместе с получателями
+	// и отмечает напоминания отправленными
+	DueReminders(now time.Time) ([]*EventReminder, error)
+}
+
+// AttendanceService определяет методы учета посещаемости событий
+type AttendanceService interface {
+	// EventAttendance возвращает событие и отметки всех активных участников команды,
+	// в том числе еще не отмеченных (требует права events.manage)
+	EventAttendance(actorID int64, eventID int64) (*Event, []*Attendance, error)
+
+	// Mark отмечает, был ли участник на событии (требует права events.manage)
+	Mark(actorID int64, eventID int64, userID int64, status AttendanceStatus) (*Attendance, error)
+
+	// CheckIn отмечает присутствие участника по его собственной отметке в окне вокруг начала
+	// события и сообщает, был ли он уже отмечен
+	CheckIn(actorID int64, eventID int64) (*Event, bool, error)
+
+	// Rates возвращает посещаемость активных участников команды за период [from, to)
+	// (требует права events.manage)
+	Rates(actorID int64, from, to time.Time) ([]*AttendanceRate, error)
+
+	// History возвращает посещения пользователем событий активной команды за период [from, to)
+	History(actorID int64, from, to time.Time) ([]*AttendanceEntry, error)
+}
+
+// PollService определяет методы работы с опросами команд
+type PollService interface {
+	// CreatePoll создает опрос активной команды и возвращает участников, которые могут
+	// в нем голосовать. ChatID опроса - привязанная к команде группа, где его нужно опубликовать
+	CreatePoll(actorID int64, poll *Poll) ([]*User, error)
+
+	// OpenPolls возвращает незакрытые опросы активной команды
+	OpenPolls(actorID int64) ([]*Poll, error)
+
+	// GetPoll возвращает опрос с текущими результатами
+	GetPoll(actorID int64, pollID int64) (*Poll, error)
+
+	// Vote отмечает или снимает голос участника и возвращает обновленный опрос вместе
+	// с сообщениями, которые нужно обновить, и признак того, что вариант выбран
+	Vote(actorID int64, pollID int64, position int) (*PollUpdate, bool, error)
+
+	// AddMessage запоминает отправленное сообщение с опросом, чтобы обновлять его результаты
+	AddMessage(message *PollMessage) error
+
+	// ClosePoll закрывает опрос и возвращает итоговые результаты вместе с его сообщениями
+	ClosePoll(actorID int64, pollID int64) (*PollUpdate, error)
+
+	// CloseExpired закрывает опросы с истекшим сроком и возвращает их итоги
+	CloseExpired(now time.Time) ([]*PollUpdate, error)
+}
+
+// DutyService определяет методы работы с графиками дежурств
+type DutyService interface {
+	// CreateRoster создает график дежурств активной команды с очередью участников в указанном
+	// порядке (требует права duties.manage) и возвращает уведомления о назначенном дежурстве
+	CreateRoster(actorID int64, name string, period DuesPeriod, start time.Time, usernames []string) (*DutyRoster, []*DutyNotice, error)
+
+	// Rosters возвращает графики дежурств активной команды
+	Rosters(actorID int64) ([]*DutyRoster, error)
+
+	// StopRoster останавливает график дежурств (требует права duties.manage)
+	StopRoster(actorID int64, rosterID int64) (*DutyRoster, error)
+
+	// CurrentDuties возвращает текущие дежурства активной команды
+	CurrentDuties(actorID int64) ([]*DutyAssignment, error)
+
+	// Complete отмечает дежурство выполненным. Отметить может дежурный или участник
+	// с правом duties.manage
+	Complete(actorID int64, assignmentID int64) (*DutyAssignment, error)
+
+	// RequestSwap просит участника команды взять дежурство пользователя и возвращает
+	// запрос вместе с уведомлением для этого участника
+	RequestSwap(actorID int64, assignmentID int64, username string) (*DutySwap, *DutyNotice, error)
+
+	// RespondSwap принимает или отклоняет запрос на обмен и возвращает уведомление
+	// для попросившего участника
+	RespondSwap(actorID int64, swapID int64, accept bool) (*DutySwap, *DutyNotice, error)
+
+	// History возвращает дежурства активной команды за период [from, to)
+	// (требует права duties.manage)
+	History(actorID int64, from, to time.Time) ([]*DutyAssignment, error)
+
+	// AssignDue назначает дежурных на наступившие периоды действующих графиков
+	// и возвращает уведомления для них
+	AssignDue(now time.Time) ([]*DutyNotice, error)
+}
+
+// AnnouncementService определяет методы работы с объявлениями команды
+type AnnouncementService interface {
+	// Create создает объявление активной команды (требует права announcements.manage)
+	// и возвращает его получателей - активных участников команды, кроме автора.
+	// ChatID объявления - привязанная к команде группа, где его нужно опубликовать
+	Create(actorID int64, text string) (*Announcement, []*User, error)
+
+	// SetMessage запоминает закрепленное сообщение объявления в группе команды
+	SetMessage(announcementID int64, chatID int64, messageID int) error
+
+	// Acknowledge отмечает объявление прочитанным пользователем и сообщает, было ли
+	// оно отмечено раньше
+	Acknowledge(actorID int64, announcementID int64) (*Announcement, bool, error)
+
+	// Announcements возвращает действующие объявления активной команды с ходом прочтения
+	// (требует права announcements.manage)
+	Announcements(actorID int64) ([]*Announcement, error)
+
+	// Progress возвращает объявление и отметки его получателей о прочтении
+	// (требует права announcements.manage)
+	Progress(actorID int64, announcementID int64) (*Announcement, []*AnnouncementReceipt, error)
+
+	// Close закрывает объявление: напоминания прекращаются, а сообщение в группе
+	// нужно открепить (требует права announcements.manage)
+	Close(actorID int64, announcementID int64) (*Announcement, error)
+
+	// DueReminders возвращает объявления, о которых пора напомнить непрочитавшим
+	// получателям, и записывает напоминания
+	DueReminders(now time.Time) ([]*AnnouncementReminder, error)
+}
+
+// TicketService определяет методы работы с обращениями в поддержку. Администраторами
+// обращений считаются пользователи с правом tickets.manage
+type TicketService interface {
+	// Create создает обращение и возвращает администраторов, которым его нужно переслать
+	Create(actorID int64, text string) (*Ticket, []*User, error)
+
+	// Ticket возвращает обращение и переписку по нему автору обращения или администратору
+	Ticket(actorID int64, ticketID int64) (*Ticket, []*TicketMessage, error)
+
+	// Tickets возвращает обращения пользователя от новых к старым
+	Tickets(actorID int64) ([]*Ticket, error)
+
+	// Queue возвращает обращения с указанными статусами, по умолчанию незакрытые
+	// (требует права tickets.manage)
+	Queue(actorID int64, statuses []TicketStatus) ([]*Ticket, error)
+
+	// Reply добавляет сообщение в переписку и возвращает тех, кому его нужно переслать:
+	// автору, если отвечает администратор, иначе исполнителю или всем администраторам
+	Reply(actorID int64, ticketID int64, text string) (*Ticket, *TicketMessage, []*User, error)
+
+	// Assign назначает обращению исполнителя, переводит его в работу и возвращает тех,
+	// кого нужно уведомить: исполнителя и автора обращения (требует права tickets.manage)
+	Assign(actorID int64, ticketID int64, username string) (*Ticket, []*User, error)
+
+	// Close закрывает обращение и возвращает тех, кого нужно об этом уведомить.
+	// Закрыть обращение может его автор или администратор
+	Close(actorID int64, ticketID int64) (*Ticket, []*User, error)
+}
+
+// BackupService определяет методы резервного копирования базы данных
+type BackupService interface {
+	// Create создает резервную копию и удаляет копии, вышедшие за пределы срока хранения
+	Create(actorID int64) (*Backup, error)
+
+	// List возвращает резервные копии от новых к старым
+	List(actorID int64) ([]*Backup, error)
+
+	// Verify проверяет контрольную сумму и целостность резервной копии
+	Verify(actorID int64, name string) (*Backup, error)
+}
+
+// TwoFactorService определяет методы для работы с двухфакторной аутентификацией
+type TwoFactorService interface {
+	// IsEnabled проверяет, подключена ли у пользователя 2FA
+	IsEnabled(userID int64) (bool, error)
+
+	// IsRequired проверяет, обязана ли у пользователя быть подключена 2FA
+	IsRequired(user *User) bool
+
+	// BeginEnrollment создает новый секрет и возвращает данные для приложения-аутентификатора
+	BeginEnrollment(user *User) (*TwoFactorEnrollment, error)
+
+	// ConfirmEnrollment включает 2FA после проверки кода и возвращает коды восстановления
+	ConfirmEnrollment(userID int64, code string) ([]string, error)
+
+	// Verify проверяет код из приложения или код восстановления
+	Verify(userID int64, code string) error
+
+	// Disable отключает 2FA после проверки кода
+	Disable(user *User, code string) error
 }
@@ -1,15 +0,0 @@
-package domain
-
-// Константы для сообщений бота
-const (
-	WelcomeMessage          = "Добро пожаловать! Выберите действие:"
-	LoginMessage            = "Введите ваше имя пользователя:"
-	PasswordMessage         = "Введите ваш пароль:"
-	RegisterMessage         = "Для регистрации введите желаемое имя пользователя:"
-	RegisterPasswordMessage = "Придумайте пароль:"
-	SuccessLoginMessage     = "Вы успешно вошли в систему!"
-	SuccessRegisterMessage  = "Вы успешно зарегистрировались!"
-	ErrorLoginMessage       = "Ошибка входа. Проверьте имя пользователя и пароль."
-	ErrorRegisterMessage    = "Ошибка регистрации. Возможно, такой пользователь уже существует."
-	LogoutMessage           = "Вы успешно вышли из системы."
-)
@@ -0,0 +1,59 @@
+package domain
+
+import "time"
+
+// Poll представляет опрос команды
+type Poll struct {
+	ID        int64         `json:"id"`
+	TeamID    int64         `json:"team_id"`
+	Question  string        `json:"question"`
+	Options   []*PollOption `json:"options"`
+	Multiple  bool          `json:"multiple"`           // Можно выбрать несколько вариантов
+	Anonymous bool          `json:"anonymous"`          // Имена проголосовавших не показываются
+	Role      string        `json:"role,omitempty"`     // Роль в команде, с которой можно голосовать (пусто - любая)
+	Deadline  time.Time     `json:"deadline,omitempty"` // Время автоматического закрытия (нулевое - закрывается вручную)
+	Closed    bool          `json:"closed"`
+	ClosedAt  time.Time     `json:"closed_at,omitempty"`
+	CreatedBy int64         `json:"created_by"`
+	CreatedAt time.Time     `json:"created_at"`
+
+	Voters int   `json:"voters"`            // Число проголосовавших участников (заполняется при выборке)
+	ChatID int64 `json:"chat_id,omitempty"` // Группа команды, в которой публикуется опрос (заполняется при создании, 0 - группа не привязана)
+}
+
+// PollOption представляет вариант ответа в опросе
+type PollOption struct {
+	Position int      `json:"position"` // Номер варианта, начиная с 1
+	Text     string   `json:"text"`
+	Votes    int      `json:"votes"`            // Число голосов (заполняется при выборке)
+	Voters   []string `json:"voters,omitempty"` // Имена проголосовавших (заполняется только для неанонимных опросов)
+}
+
+// Open проверяет, что в опросе еще можно голосовать
+func (p *Poll) Open(now time.Time) bool {
+	return !p.Closed && (p.Deadline.IsZero() || now.Before(p.Deadline))
+}
+
+// PollVote представляет голос участника за вариант опроса
+type PollVote struct {
+	PollID   int64     `json:"poll_id"`
+	Position int       `json:"position"`
+	UserID   int64     `json:"user_id"`
+	Username string    `json:"username"` // Имя участника (заполняется при выборке)
+	VotedAt  time.Time `json:"voted_at"`
+}
+
+// PollMessage представляет отправленное сообщение с опросом, которое обновляется
+// при каждом голосе. Сообщение может находиться в личном чате или в группе команды
+type PollMessage struct {
+	PollID    int64  `json:"poll_id"`
+	ChatID    int64  `json:"chat_id"`
+	MessageID int    `json:"message_id"`
+	Language  string `json:"language"` // Язык, на котором показан опрос
+}
+
+// PollUpdate содержит опрос с текущими результатами и сообщения, которые нужно обновить
+type PollUpdate struct {
+	Poll     *Poll
+	Messages []*PollMessage
+}
@@ -0,0 +1,70 @@
+package domain
+
+// Permission представляет именованное право на выполнение действия
+type Permission string
+
+// Константы для прав пользователей
+const (
+	PermViewUsers        Permission = "users.view"
+	PermManageUsers      Permission = "users.manage"
+	PermManageRoles      Permission = "roles.manage"
+	PermApproveTransfers Permission = "transfers.approve"
+	PermConfirmPayments  Permission = "payments.confirm"
+	PermManageEvents     Permission = "events.manage"
+	PermViewAudit        Permission = "audit.view"
+	PermManageBackups    Permission = "backups.manage"
+	PermManageTeams      Permission = "teams.manage"
+	PermManageDues       Permission = "dues.manage"
+	PermManageDuties     Permission = "duties.manage"
+	PermAnnounce         Permission = "announcements.manage"
+	PermTickets          Permission = "tickets.manage"
+)
+
+// AllPermissions содержит все известные права в порядке отображения.
+// Описания прав хранятся в каталогах текстов под ключами perm.<право>
+var AllPermissions = []Permission{
+	PermViewUsers,
+	PermManageUsers,
+	PermManageRoles,
+	PermApproveTransfers,
+	PermConfirmPayments,
+	PermManageEvents,
+	PermViewAudit,
+	PermManageBackups,
+	PermManageTeams,
+	PermManageDues,
+	PermManageDuties,
+	PermAnnounce,
+	PermTickets,
+}
+
+// IsKnownPermission проверяет, что право входит в список известных
+func IsKnownPermission(permission Permission) bool {
+	for _, p := range AllPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Role представляет роль как именованный набор прав
+type Role struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	BuiltIn     bool         `json:"built_in"` // Встроенные роли нельзя удалить
+}
+
+// Has проверяет, входит ли право в роль. Роль администратора содержит все права
+func (r *Role) Has(permission Permission) bool {
+	if r.Name == RoleAdmin {
+		return true
+	}
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RosterFormat задает формат файла состава команды
+type RosterFormat string
+
+// Поддерживаемые форматы файла состава команды
+const (
+	RosterCSV  RosterFormat = "csv"
+	RosterJSON RosterFormat = "json"
+)
+
+// RosterFormatOf определяет формат файла по его расширению
+func RosterFormatOf(fileName string) (RosterFormat, bool) {
+	switch format := RosterFormat(strings.TrimPrefix(strings.ToLower(filepath.Ext(fileName)), ".")); format {
+	case RosterCSV, RosterJSON:
+		return format, true
+	}
+	return "", false
+}
+
+// RosterRecord представляет строку файла состава команды. Пустые поля
+// при импорте не изменяют данные существующего пользователя
+type RosterRecord struct {
+	Username string `json:"username"`
+	Position string `json:"position,omitempty"`
+	Birthday string `json:"birthday,omitempty"`
+	Number   string `json:"number,omitempty"`
+	Role     string `json:"role,omitempty"`
+}
+
+// RosterAction описывает, что импорт сделает со строкой файла
+type RosterAction string
+
+// Действия импорта
+const (
+	RosterCreate    RosterAction = "create"    // Новый пользователь с кодом приглашения
+	RosterUpdate    RosterAction = "update"    // Изменение существующего пользователя
+	RosterUnchanged RosterAction = "unchanged" // Данные пользователя уже совпадают
+	RosterInvalid   RosterAction = "invalid"   // Строка с ошибкой, импорт невозможен
+)
+
+// RosterChange описывает изменение по одной строке файла
+type RosterChange struct {
+	Line   int          // Номер строки CSV или элемента JSON, начиная с 1
+	Record RosterRecord // Данные из файла
+	Action RosterAction
+	Fields []string // Изменяемые поля пользователя (для RosterUpdate)
+	Err    error    // Причина ошибки (для RosterInvalid)
+	Invite string   // Код приглашения нового пользователя (после применения импорта)
+	Done   bool     // Изменение применено
+}
+
+// RosterImport содержит результат разбора файла: предварительный план изменений
+// или, если импорт применен, выполненные изменения
+type RosterImport struct {
+	Changes   []*RosterChange
+	Applied   bool
+	ExpiresAt time.Time // Срок действия выданных кодов приглашения
+}
+
+// Count возвращает количество строк с указанным действием
+func (r *RosterImport) Count(action RosterAction) int {
+	n := 0
+	for _, change := range r.Changes {
+		if change.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Done возвращает количество примененных строк с указанным действием
+func (r *RosterImport) Done(action RosterAction) int {
+	n := 0
+	for _, change := range r.Changes {
+		if change.Done && change.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Invite представляет приглашение пользователя, созданного администратором:
+// по коду приглашения человек задает пароль и привязывает свой Telegram-аккаунт
+type Invite struct {
+	UserID    int64     `json:"user_id"`
+	CodeHash  string    `json:"-"` // SHA-256 кода приглашения
+	CreatedBy int64     `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired проверяет, истек ли срок действия приглашения
+func (i *Invite) Expired(now time.Time) bool {
+	return !now.Before(i.ExpiresAt)
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// Team представляет команду. Пользователь может состоять в нескольких командах
+// и работает в одной из них - активной
+type Team struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	GroupChatID int64     `json:"group_chat_id,omitempty"` // Группа Telegram, в которой публикуются объявления и опросы (0 - не привязана)
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TeamMember представляет членство пользователя в команде и его роль в ней
+type TeamMember struct {
+	TeamID   int64     `json:"team_id"`
+	TeamName string    `json:"team_name"` // Название команды (заполняется при выборке команд пользователя)
+	UserID   int64     `json:"user_id"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// TeamPermissions содержит права, которые действуют в пределах команды и определяются
+// ролью пользователя в активной команде. Остальные права относятся ко всему боту
+// и определяются ролью учетной записи
+var TeamPermissions = []Permission{
+	PermViewUsers,
+	PermManageUsers,
+	PermConfirmPayments,
+	PermManageEvents,
+	PermManageDues,
+	PermManageDuties,
+	PermAnnounce,
+}
+
+// IsTeamPermission проверяет, что право действует в пределах команды
+func IsTeamPermission(permission Permission) bool {
+	for _, p := range TeamPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
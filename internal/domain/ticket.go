@@ -0,0 +1,59 @@
+package domain
+
+import "time"
+
+// TicketStatus представляет статус обращения в поддержку
+type TicketStatus string
+
+// Статусы обращения. Новое обращение открыто, после того как администратор берет его
+// или отвечает, оно переходит в работу, а закрытое больше не принимает ответов
+const (
+	TicketOpen       TicketStatus = "open"
+	TicketInProgress TicketStatus = "in_progress"
+	TicketClosed     TicketStatus = "closed"
+)
+
+// TicketStatuses содержит все статусы обращений в порядке отображения
+var TicketStatuses = []TicketStatus{TicketOpen, TicketInProgress, TicketClosed}
+
+// IsTicketStatus проверяет, что статус обращения известен
+func IsTicketStatus(status TicketStatus) bool {
+	for _, s := range TicketStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Ticket представляет обращение пользователя к администраторам
+type Ticket struct {
+	ID         int64        `json:"id"`
+	UserID     int64        `json:"user_id"` // Автор обращения
+	Text       string       `json:"text"`    // Текст обращения
+	Status     TicketStatus `json:"status"`
+	AssigneeID int64        `json:"assignee_id,omitempty"` // Администратор, который ведет обращение (0 - не назначен)
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+	ClosedAt   time.Time    `json:"closed_at,omitempty"`
+
+	Username string `json:"username"`           // Имя автора (заполняется при выборке)
+	Assignee string `json:"assignee,omitempty"` // Имя исполнителя (заполняется при выборке)
+}
+
+// Closed проверяет, что обращение закрыто
+func (t *Ticket) Closed() bool {
+	return t.Status == TicketClosed
+}
+
+// TicketMessage представляет сообщение в переписке по обращению
+type TicketMessage struct {
+	ID        int64     `json:"id"`
+	TicketID  int64     `json:"ticket_id"`
+	AuthorID  int64     `json:"author_id"`
+	Staff     bool      `json:"staff"` // Ответ администратора, а не автора обращения
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Username string `json:"username"` // Имя автора сообщения (заполняется при выборке)
+}
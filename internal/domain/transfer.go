@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+
+	"HelpBot/internal/i18n"
+)
+
+// Статусы запроса на перенос аккаунта
+const (
+	TransferStatusPending  = "pending"
+	TransferStatusApproved = "approved"
+	TransferStatusRejected = "rejected"
+)
+
+// AccountTransfer представляет запрос на перенос аккаунта на другой Telegram-аккаунт
+type AccountTransfer struct {
+	ID             int64      `json:"id"`
+	UserID         int64      `json:"user_id"`
+	FromTelegramID int64      `json:"from_telegram_id"`
+	ToTelegramID   int64      `json:"to_telegram_id"`
+	ToChatID       int64      `json:"to_chat_id"`
+	Status         string     `json:"status"`
+	ResolvedBy     int64      `json:"resolved_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+}
+
+// TransferRequiredError возвращается при попытке входа в аккаунт,
+// привязанный к другому Telegram-аккаунту
+type TransferRequiredError struct {
+	Transfer *AccountTransfer
+	Created  bool // Запрос создан этой попыткой входа, а не существовал ранее
+}
+
+func (e *TransferRequiredError) Error() string {
+	return e.Localize(i18n.Default)
+}
+
+// Localize возвращает текст ошибки на указанном языке
+func (e *TransferRequiredError) Localize(lang i18n.Lang) string {
+	return i18n.T(lang, "error.transfer_required", i18n.P{"id": e.Transfer.ID})
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"time"
+
+	"HelpBot/internal/i18n"
+)
+
+// Ошибки входа, требующие второго фактора
+var (
+	// ErrTwoFactorRequired возвращается, когда после пароля нужно ввести код из приложения
+	ErrTwoFactorRequired = i18n.NewError("error.2fa_required")
+
+	// ErrTwoFactorEnrollmentRequired возвращается, когда пользователь обязан подключить 2FA перед входом
+	ErrTwoFactorEnrollmentRequired = i18n.NewError("error.2fa_enrollment_required")
+)
+
+// Ошибки проверки кода 2FA, которые считаются неудачной попыткой
+var (
+	// ErrTwoFactorWrongCode возвращается, когда код не подошел ни как код из приложения, ни как код восстановления
+	ErrTwoFactorWrongCode = i18n.NewError("error.2fa_wrong_code")
+
+	// ErrTwoFactorCodeUsed возвращается при повторном вводе уже принятого кода из приложения
+	ErrTwoFactorCodeUsed = i18n.NewError("error.2fa_code_used")
+)
+
+// TwoFactor представляет настройки TOTP двухфакторной аутентификации пользователя
+type TwoFactor struct {
+	UserID      int64      `json:"user_id"`
+	Secret      string     `json:"-"` // Секрет в кодировке base32
+	Enabled     bool       `json:"enabled"`
+	LastCounter int64      `json:"-"` // Период последнего принятого кода из приложения: коды этого и прошлых периодов не принимаются
+	CreatedAt   time.Time  `json:"created_at"`
+	EnabledAt   *time.Time `json:"enabled_at"`
+}
+
+// RecoveryCode представляет одноразовый код восстановления доступа
+type RecoveryCode struct {
+	ID       int64      `json:"id"`
+	UserID   int64      `json:"user_id"`
+	CodeHash string     `json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+}
+
+// TwoFactorEnrollment содержит данные для подключения приложения-аутентификатора
+type TwoFactorEnrollment struct {
+	Secret string // Секрет для ручного ввода
+	URI    string // otpauth:// URI
+	QRCode []byte // PNG с QR-кодом URI
+}
@@ -1,18 +1,75 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
 
 // User представляет пользователя в системе
 type User struct {
-	ChatID    int64     `json:"chat_id"`
-	Username  string    `json:"username"`
-	Password  string    `json:"password"`
-	Role      string    `json:"role"`
-	Position  string    `json:"position"`
-	Birthday  string    `json:"birthday"`
-	Number    string    `json:"number"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         int64     `json:"id"`
+	TelegramID int64     `json:"telegram_id"` // Telegram-аккаунт, к которому привязан пользователь
+	ChatID     int64     `json:"chat_id"`     // Личный чат для доставки сообщений
+	Username   string    `json:"username"`
+	Password   string    `json:"password"`
+	Role       string    `json:"role"`
+	Position   string    `json:"position"`
+	Birthday   string    `json:"birthday"`
+	Number     string    `json:"number"`
+	Language   string    `json:"language"` // Язык интерфейса, выбранный пользователем
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	Status          UserStatus `json:"status"`            // Состояние учетной записи
+	StatusReason    string     `json:"status_reason"`     // Причина блокировки или удаления
+	StatusChangedAt time.Time  `json:"status_changed_at"` // Время последней смены состояния (нулевое, если не менялось)
+	PurgedAt        time.Time  `json:"purged_at"`         // Время обезличивания удаленного пользователя
+
+	SessionsRevokedAt time.Time `json:"sessions_revoked_at"` // Сессии и токены, выданные раньше, недействительны
+
+	ActiveTeamID int64  `json:"active_team_id"`      // Команда, в которой работает пользователь (0, если не выбрана)
+	TeamRole     string `json:"team_role,omitempty"` // Роль в команде (заполняется при выборке участников команды)
+}
+
+// UserStatus представляет состояние учетной записи пользователя
+type UserStatus string
+
+// Состояния учетной записи. Войти в систему может только активный пользователь;
+// удаленные пользователи сохраняются для истории и обезличиваются по истечении срока хранения
+const (
+	UserActive      UserStatus = "active"
+	UserSuspended   UserStatus = "suspended"   // Временно заблокирован администратором
+	UserDeactivated UserStatus = "deactivated" // Больше не участвует в команде
+	UserDeleted     UserStatus = "deleted"     // Удален и ожидает обезличивания
+)
+
+// UserStatuses содержит все состояния учетной записи
+var UserStatuses = []UserStatus{UserActive, UserSuspended, UserDeactivated, UserDeleted}
+
+// IsKnownUserStatus проверяет, что состояние учетной записи существует
+func IsKnownUserStatus(status UserStatus) bool {
+	return slices.Contains(UserStatuses, status)
+}
+
+// Active проверяет, что пользователь может работать с ботом. Пустое состояние
+// у еще не сохраненного пользователя считается активным
+func (u *User) Active() bool {
+	return u.Status == "" || u.Status == UserActive
+}
+
+// AnonymizedPrefix - начало имени обезличенного пользователя, за ним следует идентификатор
+const AnonymizedPrefix = "deleted-"
+
+// AnonymizedUsername возвращает имя, которое получает пользователь после обезличивания
+func AnonymizedUsername(id int64) string {
+	return fmt.Sprintf("%s%d", AnonymizedPrefix, id)
+}
+
+// IsReservedUsername проверяет, что имя зарезервировано для обезличенных пользователей
+func IsReservedUsername(username string) bool {
+	return strings.HasPrefix(username, AnonymizedPrefix)
 }
 
 // UserState представляет состояние пользователя в диалоге с ботом
@@ -22,6 +79,12 @@ const (
 	StateNone UserState = iota
 	StateAwaitingUsername
 	StateAwaitingPassword
+	StateAwaitingRoleName
+	StateAwaitingTOTP           // Ожидается код 2FA при входе
+	StateAwaitingTOTPSetup      // Ожидается код для подтверждения подключения 2FA
+	StateAwaitingTOTPDisable    // Ожидается код для отключения 2FA
+	StateAwaitingInvitePassword // Ожидается пароль пользователя, принимающего приглашение
+	StateAwaitingTicketReply    // Ожидается ответ по обращению в поддержку
 )
 
 // UserSession представляет текущую сессию пользователя
@@ -29,12 +92,37 @@ type UserSession struct {
 	User         *User
 	State        UserState
 	IsAuthorized bool
-	LastCommand  string // Последняя команда пользователя (login/register)
-	Token        string // JWT токен для авторизации
+	LastCommand  string       // Последняя команда пользователя (login/register)
+	Token        string       // JWT токен для авторизации
+	Attempts     int          // Количество неудачных попыток ввода кода 2FA
+	Language     string       // Язык интерфейса в текущей сессии
+	Roster       *RosterView  // Просматриваемый постранично список пользователей
+	Import       *RosterDraft // Загруженный файл состава команды, ожидающий подтверждения импорта
+	InviteCode   string       // Код приглашения, для которого ожидается пароль
+	TicketID     int64        // Обращение, для которого ожидается ответ
+	AuthorizedAt time.Time    // Время входа в систему
+	ChatID       int64        // Чат, из которого начат вход, ожидающий кода 2FA
+}
+
+// RosterView хранит состояние постраничного просмотра списка пользователей
+type RosterView struct {
+	Query  UserQuery // Фильтры и сортировка списка
+	Cursor string    // Курсор следующей страницы
+	Shown  int       // Количество уже показанных пользователей
+}
+
+// RosterDraft хранит загруженный файл состава команды до подтверждения импорта
+type RosterDraft struct {
+	FileName string
+	Format   RosterFormat
+	Data     []byte
 }
 
-// Константы для ролей пользователей
+// Константы для встроенных ролей пользователей
 const (
-	RoleAdmin = "admin"
-	RoleUser  = "user"
+	RoleAdmin       = "admin"
+	RoleUser        = "user"
+	RoleTreasurer   = "treasurer"
+	RoleCoordinator = "coordinator"
+	RoleViewer      = "viewer"
 )
@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// UserSort задает поле сортировки пользователей
+type UserSort string
+
+// Поля сортировки пользователей. При равных значениях порядок определяется идентификатором
+const (
+	UserSortID       UserSort = "id"
+	UserSortUsername UserSort = "username"
+	UserSortCreated  UserSort = "created_at"
+)
+
+// UserQuery задает условия выборки пользователей. Пустые поля не ограничивают выборку
+type UserQuery struct {
+	Statuses    []UserStatus // Состояния учетной записи (пусто - любые)
+	Role        string       // Роль
+	Position    string       // Позиция в команде
+	NamePrefix  string       // Начало имени пользователя (с учетом регистра)
+	CreatedFrom time.Time    // Начало периода регистрации (включительно)
+	CreatedTo   time.Time    // Конец периода регистрации (не включительно)
+	Sort        UserSort     // Поле сортировки (по умолчанию - идентификатор)
+	Desc        bool         // Сортировка по убыванию
+	Limit       int          // Размер страницы (0 - без ограничения)
+	Cursor      string       // Позиция, с которой начинается страница, из UserPage.NextCursor
+	WithSecrets bool         // Возвращать хеши паролей (по умолчанию поле Password пустое)
+}
+
+// UserPage содержит страницу выборки пользователей
+type UserPage struct {
+	Users      []*User
+	NextCursor string // Курсор следующей страницы; пустой, если это последняя страница
+}
+
+// UserCursor указывает на последнего пользователя предыдущей страницы:
+// следующая страница начинается сразу после него в порядке сортировки
+type UserCursor struct {
+	Sort      UserSort  `json:"s"`
+	Desc      bool      `json:"d,omitempty"`
+	ID        int64     `json:"i"`
+	Username  string    `json:"u,omitempty"`
+	CreatedAt time.Time `json:"c,omitempty"`
+}
+
+// Normalize проверяет запрос и подставляет значения по умолчанию
+func (q UserQuery) Normalize() (UserQuery, error) {
+	switch q.Sort {
+	case "":
+		q.Sort = UserSortID
+	case UserSortID, UserSortUsername, UserSortCreated:
+	default:
+		return q, fmt.Errorf("%w: unknown sort %q", ErrInvalidQuery, q.Sort)
+	}
+	if q.Limit < 0 {
+		return q, fmt.Errorf("%w: negative limit", ErrInvalidQuery)
+	}
+	return q, nil
+}
+
+// After возвращает позицию, с которой начинается страница, или nil для первой страницы.
+// Курсор подходит только к запросу с той же сортировкой
+func (q UserQuery) After() (*UserCursor, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	var cursor UserCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	if cursor.Sort != q.Sort || cursor.Desc != q.Desc {
+		return nil, fmt.Errorf("%w: cursor does not match the sort order", ErrInvalidQuery)
+	}
+	return &cursor, nil
+}
+
+// CursorAfter возвращает курсор страницы, следующей за указанным пользователем
+func (q UserQuery) CursorAfter(user *User) string {
+	cursor := UserCursor{Sort: q.Sort, Desc: q.Desc, ID: user.ID}
+	switch q.Sort {
+	case UserSortUsername:
+		cursor.Username = user.Username
+	case UserSortCreated:
+		cursor.CreatedAt = user.CreatedAt
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// NewUserPage формирует страницу из пользователей, выбранных с запасом в одну запись:
+// лишняя запись означает, что есть следующая страница
+func NewUserPage(q UserQuery, users []*User) *UserPage {
+	page := &UserPage{Users: users}
+	if q.Limit > 0 && len(users) > q.Limit {
+		page.Users = users[:q.Limit]
+		page.NextCursor = q.CursorAfter(page.Users[q.Limit-1])
+	}
+	return page
+}
+
+// Less сравнивает двух пользователей в порядке сортировки запроса
+func (q UserQuery) Less(a, b *User) bool {
+	if q.Desc {
+		a, b = b, a
+	}
+	switch {
+	case q.Sort == UserSortUsername && a.Username != b.Username:
+		return a.Username < b.Username
+	case q.Sort == UserSortCreated && !a.CreatedAt.Equal(b.CreatedAt):
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+// Match проверяет, что пользователь удовлетворяет фильтрам запроса
+func (q UserQuery) Match(user *User) bool {
+	switch {
+	case len(q.Statuses) > 0 && !slices.Contains(q.Statuses, user.Status):
+		return false
+	case q.Role != "" && user.Role != q.Role:
+		return false
+	case q.Position != "" && user.Position != q.Position:
+		return false
+	case !strings.HasPrefix(user.Username, q.NamePrefix):
+		return false
+	case !q.CreatedFrom.IsZero() && user.CreatedAt.Before(q.CreatedFrom):
+		return false
+	case !q.CreatedTo.IsZero() && !user.CreatedAt.Before(q.CreatedTo):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package i18n
+
+// Localizable описывает ошибку, текст которой можно показать пользователю на его языке
+type Localizable interface {
+	error
+	Localize(lang Lang) string
+}
+
+// Error представляет ошибку с текстом из каталога
+type Error struct {
+	Key    string
+	Params P
+}
+
+// NewError создает ошибку с текстом из каталога
+func NewError(key string, params ...P) *Error {
+	e := &Error{Key: key}
+	if len(params) > 0 {
+		e.Params = params[0]
+	}
+	return e
+}
+
+// Error возвращает текст ошибки на языке по умолчанию
+func (e *Error) Error() string {
+	return e.Localize(Default)
+}
+
+// Localize возвращает текст ошибки на указанном языке. Если среди параметров
+// есть целое count, используется форма множественного числа
+func (e *Error) Localize(lang Lang) string {
+	if e.Params == nil {
+		return T(lang, e.Key)
+	}
+	if count, ok := e.Params["count"].(int); ok {
+		return N(lang, e.Key, count, e.Params)
+	}
+	return T(lang, e.Key, e.Params)
+}
@@ -0,0 +1,197 @@
+// Package i18n содержит каталоги пользовательских текстов бота и правила их подстановки.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"HelpBot/internal/markup"
+)
+
+// Lang представляет язык интерфейса
+type Lang string
+
+// Поддерживаемые языки
+const (
+	RU Lang = "ru"
+	EN Lang = "en"
+)
+
+// Default - язык, используемый, когда язык пользователя не поддерживается
+const Default = RU
+
+// Supported содержит поддерживаемые языки в порядке отображения
+var Supported = []Lang{RU, EN}
+
+// P задает значения подстановок вида {name}
+type P map[string]any
+
+// message представляет текст каталога: простой или с формами множественного числа
+type message struct {
+	Text   string
+	Plural map[string]string // Формы множественного числа: one, few, many, other
+}
+
+// UnmarshalJSON разбирает текст каталога из строки или объекта с формами множественного числа
+func (m *message) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &m.Text)
+	}
+	return json.Unmarshal(data, &m.Plural)
+}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs содержит тексты всех поддерживаемых языков
+var catalogs = mustLoadCatalogs()
+
+// mustLoadCatalogs загружает встроенные каталоги и завершает работу при ошибке в них
+func mustLoadCatalogs() map[Lang]map[string]message {
+	result := make(map[Lang]map[string]message)
+	for _, lang := range Supported {
+		data, err := localeFiles.ReadFile(path.Join("locales", string(lang)+".json"))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog %s: %v", lang, err))
+		}
+		catalog := make(map[string]message)
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", lang, err))
+		}
+		result[lang] = catalog
+	}
+	return result
+}
+
+// Parse возвращает поддерживаемый язык по его коду
+func Parse(code string) (Lang, bool) {
+	for _, lang := range Supported {
+		if string(lang) == code {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// FromCode выбирает язык по коду языка Telegram (например, "en-US")
+func FromCode(code string) Lang {
+	base, _, _ := strings.Cut(strings.ToLower(code), "-")
+	if lang, ok := Parse(base); ok {
+		return lang
+	}
+	return Default
+}
+
+// Lookup возвращает текст по ключу без подстановок и признак его наличия в каталоге
+func Lookup(lang Lang, key string) (string, bool) {
+	msg, ok := find(lang, key)
+	if !ok || msg.Plural != nil {
+		return "", false
+	}
+	return msg.Text, true
+}
+
+// T возвращает текст по ключу с подстановкой параметров без разметки.
+// Если текста нет в каталоге языка, используется язык по умолчанию, затем сам ключ
+func T(lang Lang, key string, params ...P) string {
+	return M(lang, key, params...).String()
+}
+
+// N возвращает текст по ключу в форме множественного числа для n без разметки.
+// Значение n доступно в тексте как {count}
+func N(lang Lang, key string, n int, params ...P) string {
+	return MN(lang, key, n, params...).String()
+}
+
+// M возвращает сообщение по ключу. Тексты каталога могут содержать разметку пакета markup,
+// значения параметров экранируются при отрисовке
+func M(lang Lang, key string, params ...P) markup.Text {
+	msg, ok := find(lang, key)
+	if !ok {
+		return markup.Raw(key)
+	}
+	text := msg.Text
+	if msg.Plural != nil {
+		text = msg.Plural["other"]
+	}
+	return markup.New(text, toMaps(params)...)
+}
+
+// MN возвращает сообщение по ключу в форме множественного числа для n
+func MN(lang Lang, key string, n int, params ...P) markup.Text {
+	msg, ok := find(lang, key)
+	if !ok {
+		return markup.Raw(key)
+	}
+
+	text := msg.Text
+	if msg.Plural != nil {
+		text, ok = msg.Plural[pluralForm(lang, n)]
+		if !ok {
+			text = msg.Plural["other"]
+		}
+	}
+	return markup.New(text, append(toMaps(params), map[string]any{"count": n})...)
+}
+
+// find ищет текст в каталоге языка, а затем в каталоге по умолчанию
+func find(lang Lang, key string) (message, bool) {
+	if msg, ok := catalogs[lang][key]; ok {
+		return msg, true
+	}
+	msg, ok := catalogs[Default][key]
+	return msg, ok
+}
+
+// toMaps преобразует параметры к виду, который принимает пакет markup
+func toMaps(params []P) []map[string]any {
+	result := make([]map[string]any, 0, len(params)+1)
+	for _, p := range params {
+		result = append(result, p)
+	}
+	return result
+}
+
+// pluralForm возвращает форму множественного числа по правилам CLDR
+func pluralForm(lang Lang, n int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	switch lang {
+	case RU:
+		mod10, mod100 := n%10, n%100
+		switch {
+		case mod10 == 1 && mod100 != 11:
+			return "one"
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	default:
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
+
+// MatchButton ищет ключ кнопки (с префиксом "btn.") по ее тексту на любом из поддерживаемых языков
+func MatchButton(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", false
+	}
+	for _, lang := range Supported {
+		for key, msg := range catalogs[lang] {
+			if strings.HasPrefix(key, "btn.") && msg.Text == text {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,53 @@
+// Package logging создает структурированный логгер приложения на основе log/slog
+// и скрывает в записях секретные данные
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Форматы вывода логов
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options содержит настройки логгера
+type Options struct {
+	Level     slog.Level // Минимальный уровень записей
+	Format    string     // Формат вывода: text или json
+	AddSource bool       // Добавлять в записи файл и строку вызова
+}
+
+// New создает логгер, записывающий в w. Секретные атрибуты скрываются автоматически
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	handlerOpts := &slog.HandlerOptions{
+		Level:       opts.Level,
+		AddSource:   opts.AddSource,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case FormatText, "":
+		handler = slog.NewTextHandler(w, handlerOpts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", opts.Format)
+	}
+	return slog.New(handler), nil
+}
+
+// Discard возвращает логгер, который ничего не записывает
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+// Err возвращает атрибут с текстом ошибки
+func Err(err error) slog.Attr {
+	return slog.Any("error", err)
+}
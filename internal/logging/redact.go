@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted заменяет в логах значения секретных атрибутов
+const Redacted = "[redacted]"
+
+// sensitiveKeys содержит имена атрибутов, значения которых никогда не попадают в лог
+var sensitiveKeys = map[string]bool{
+	"password":       true,
+	"password_hash":  true,
+	"token":          true,
+	"jwt":            true,
+	"secret":         true,
+	"totp":           true,
+	"totp_code":      true,
+	"recovery_code":  true,
+	"recovery_codes": true,
+	"authorization":  true,
+}
+
+// sensitiveSuffixes содержит окончания имен секретных атрибутов: bot_token, jwt_secret и т.п.
+var sensitiveSuffixes = []string{"_password", "_token", "_secret"}
+
+// botTokenPattern находит токены ботов в тексте. Библиотека Telegram включает
+// адрес запроса с токеном в тексты сетевых ошибок
+var botTokenPattern = regexp.MustCompile(`\d{5,}:[A-Za-z0-9_-]{30,}`)
+
+// isSensitiveKey проверяет, что атрибут с таким именем содержит секрет
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if sensitiveKeys[key] {
+		return true
+	}
+	for _, suffix := range sensitiveSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scrub удаляет из текста токены ботов
+func Scrub(text string) string {
+	return botTokenPattern.ReplaceAllString(text, Redacted)
+}
+
+// redactAttr скрывает значения секретных атрибутов и токены в строках и ошибках
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if isSensitiveKey(a.Key) && a.Value.Kind() != slog.KindGroup {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(Scrub(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(Scrub(err.Error()))
+		}
+	}
+	return a
+}
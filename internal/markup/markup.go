@@ -0,0 +1,103 @@
+// Package markup отрисовывает шаблоны сообщений в разметке Telegram (HTML или MarkdownV2)
+// и в обычный текст.
+//
+// Шаблоны используют нейтральную разметку:
+//
+//	*жирный*            - жирный текст
+//	`моноширинный`      - моноширинный текст
+//	[подпись](ссылка)   - ссылка
+//	{name}              - подстановка значения
+//	\*                  - символ разметки как обычный символ
+//
+// Значения подстановок экранируются при отрисовке, поэтому имена пользователей
+// и другие введенные пользователями данные не могут изменить разметку сообщения
+package markup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mode представляет режим разметки сообщения. Значения совпадают с parse_mode Telegram
+type Mode string
+
+// Поддерживаемые режимы разметки
+const (
+	Plain      Mode = ""
+	HTML       Mode = "HTML"
+	MarkdownV2 Mode = "MarkdownV2"
+)
+
+// ParseMode возвращает режим разметки по его названию из конфигурации
+func ParseMode(name string) (Mode, error) {
+	switch strings.ToLower(name) {
+	case "", "html":
+		return HTML, nil
+	case "markdownv2", "markdown":
+		return MarkdownV2, nil
+	case "plain", "text":
+		return Plain, nil
+	default:
+		return "", fmt.Errorf("unknown message format %q", name)
+	}
+}
+
+// Text представляет шаблон сообщения вместе со значениями подстановок.
+// Отрисовка в конкретную разметку выполняется при отправке
+type Text struct {
+	template string
+	params   map[string]any
+}
+
+// New создает сообщение по шаблону
+func New(template string, params ...map[string]any) Text {
+	t := Text{template: template}
+	if len(params) > 0 {
+		t.params = make(map[string]any)
+		for _, p := range params {
+			for name, value := range p {
+				t.params[name] = value
+			}
+		}
+	}
+	return t
+}
+
+// Raw создает сообщение из готового текста без разметки. Текст экранируется целиком
+func Raw(text string) Text {
+	return New("{text}", map[string]any{"text": text})
+}
+
+// Join объединяет сообщения через разделитель, сохраняя разметку каждого из них
+func Join(sep string, parts ...Text) Text {
+	var template strings.Builder
+	params := make(map[string]any, len(parts)+1)
+	params["sep"] = sep
+	for i, part := range parts {
+		if i > 0 {
+			template.WriteString("{sep}")
+		}
+		name := fmt.Sprintf("part%d", i)
+		template.WriteString("{" + name + "}")
+		params[name] = part
+	}
+	return New(template.String(), params)
+}
+
+// IsZero проверяет, что сообщение пустое
+func (t Text) IsZero() bool {
+	return t.template == ""
+}
+
+// String возвращает сообщение обычным текстом
+func (t Text) String() string {
+	return t.Render(Plain)
+}
+
+// Render отрисовывает сообщение в указанной разметке
+func (t Text) Render(mode Mode) string {
+	var out strings.Builder
+	r := renderer{mode: mode, params: t.params, out: &out}
+	r.nodes(parse(t.template), contextText)
+	return out.String()
+}
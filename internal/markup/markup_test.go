@@ -0,0 +1,206 @@
+package markup_test
+
+import (
+	"testing"
+
+	"HelpBot/internal/markup"
+)
+
+func TestEscape(t *testing.T) {
+	for _, tc := range []struct {
+		mode        markup.Mode
+		input, want string
+	}{
+		{markup.HTML, "<", "&lt;"},
+		{markup.HTML, ">", "&gt;"},
+		{markup.HTML, "&", "&amp;"},
+		{markup.HTML, `"`, "&quot;"},
+		{markup.HTML, "'", "'"},
+		{markup.HTML, "&lt;", "&amp;lt;"},
+		{markup.HTML, `<a href="x">Tom & Jerry</a>`, "&lt;a href=&quot;x&quot;&gt;Tom &amp; Jerry&lt;/a&gt;"},
+		{markup.HTML, "*_[]", "*_[]"},
+		{markup.MarkdownV2, `\`, `\\`},
+		{markup.MarkdownV2, `\_`, `\\\_`},
+		{markup.MarkdownV2, "a_b*c", `a\_b\*c`},
+		{markup.MarkdownV2, "<&\"'", "<&\"'"},
+		{markup.Plain, `<b>*x*</b> \`, `<b>*x*</b> \`},
+	} {
+		if got := markup.Escape(tc.mode, tc.input); got != tc.want {
+			t.Errorf("Escape(%q, %q) = %q, want %q", tc.mode, tc.input, got, tc.want)
+		}
+	}
+
+	// Каждый зарезервированный символ MarkdownV2 экранируется обратной косой чертой
+	for _, c := range "_*[]()~`>#+-=|{}.!" {
+		if got, want := markup.Escape(markup.MarkdownV2, string(c)), `\`+string(c); got != want {
+			t.Errorf("Escape(MarkdownV2, %q) = %q, want %q", c, got, want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		text     markup.Text
+		html     string
+		markdown string
+		plain    string
+	}{
+		{
+			name:     "bold",
+			text:     markup.New("*bold* text."),
+			html:     "<b>bold</b> text.",
+			markdown: `*bold* text\.`,
+			plain:    "bold text.",
+		},
+		{
+			name:     "code inside bold",
+			text:     markup.New("*run `go test` now*"),
+			html:     "<b>run <code>go test</code> now</b>",
+			markdown: "*run `go test` now*",
+			plain:    "run go test now",
+		},
+		{
+			name:     "link",
+			text:     markup.New("[docs](https://example.com/a_b?x=1)"),
+			html:     `<a href="https://example.com/a_b?x=1">docs</a>`,
+			markdown: `[docs](https://example.com/a_b?x=1)`,
+			plain:    "docs (https://example.com/a_b?x=1)",
+		},
+		{
+			name:     "link address from a parameter",
+			text:     markup.New("[{label}]({url})", map[string]any{"label": "a_b", "url": `https://example.com/w/A_(b)?q="c"`}),
+			html:     `<a href="https://example.com/w/A_(b)?q=&quot;c&quot;">a_b</a>`,
+			markdown: `[a\_b](https://example.com/w/A_(b\)?q="c")`,
+			plain:    `a_b (https://example.com/w/A_(b)?q="c")`,
+		},
+		{
+			name:     "markup is not parsed in link labels",
+			text:     markup.New("[*site*](https://example.com)"),
+			html:     `<a href="https://example.com">*site*</a>`,
+			markdown: `[\*site\*](https://example.com)`,
+			plain:    "*site* (https://example.com)",
+		},
+		{
+			name:     "bold does not nest",
+			text:     markup.New("*a *b* c*"),
+			html:     "<b>a </b>b<b> c</b>",
+			markdown: `*a *b* c*`,
+			plain:    "a b c",
+		},
+		{
+			name:     "unclosed bold",
+			text:     markup.New("*bold"),
+			html:     "*bold",
+			markdown: `\*bold`,
+			plain:    "*bold",
+		},
+		{
+			name:     "unclosed code",
+			text:     markup.New("`code"),
+			html:     "`code",
+			markdown: "\\`code",
+			plain:    "`code",
+		},
+		{
+			name:     "empty bold",
+			text:     markup.New("**"),
+			html:     "**",
+			markdown: `\*\*`,
+			plain:    "**",
+		},
+		{
+			name:     "extra closing marker",
+			text:     markup.New("*a*b*"),
+			html:     "<b>a</b>b*",
+			markdown: `*a*b\*`,
+			plain:    "ab*",
+		},
+		{
+			name:     "link without address",
+			text:     markup.New("[label]("),
+			html:     "[label](",
+			markdown: `\[label\]\(`,
+			plain:    "[label](",
+		},
+		{
+			name:     "escaped markers",
+			text:     markup.New(`\*not bold\* \{name\}`),
+			html:     "*not bold* {name}",
+			markdown: `\*not bold\* \{name\}`,
+			plain:    "*not bold* {name}",
+		},
+		{
+			name:     "parameter is escaped",
+			text:     markup.New("*{name}*", map[string]any{"name": `<i>a_b</i> & "c"`}),
+			html:     "<b>&lt;i&gt;a_b&lt;/i&gt; &amp; &quot;c&quot;</b>",
+			markdown: `*<i\>a\_b</i\> & "c"*`,
+			plain:    `<i>a_b</i> & "c"`,
+		},
+		{
+			name:     "parameter in code",
+			text:     markup.New("`{value}`", map[string]any{"value": "a`b\\c_d"}),
+			html:     "<code>a`b\\c_d</code>",
+			markdown: "`a\\`b\\\\c_d`",
+			plain:    "a`b\\c_d",
+		},
+		{
+			name:     "unknown parameter",
+			text:     markup.New("{missing}!"),
+			html:     "{missing}!",
+			markdown: `\{missing\}\!`,
+			plain:    "{missing}!",
+		},
+		{
+			name:     "nested message keeps its markup",
+			text:     markup.New("*{inner}*", map[string]any{"inner": markup.New("`x` {y}", map[string]any{"y": "1.5"})}),
+			html:     "<b><code>x</code> 1.5</b>",
+			markdown: "*`x` 1\\.5*",
+			plain:    "x 1.5",
+		},
+		{
+			name:     "nested message in code is plain",
+			text:     markup.New("`{inner}`", map[string]any{"inner": markup.New("*b*")}),
+			html:     "<code>b</code>",
+			markdown: "`b`",
+			plain:    "b",
+		},
+		{
+			name:     "join",
+			text:     markup.Join("\n", markup.New("*a*"), markup.Raw("*b*")),
+			html:     "<b>a</b>\n*b*",
+			markdown: "*a*\n\\*b\\*",
+			plain:    "a\n*b*",
+		},
+	} {
+		for _, render := range []struct {
+			mode markup.Mode
+			want string
+		}{{markup.HTML, tc.html}, {markup.MarkdownV2, tc.markdown}, {markup.Plain, tc.plain}} {
+			if got := tc.text.Render(render.mode); got != render.want {
+				t.Errorf("%s: Render(%q) = %q, want %q", tc.name, render.mode, got, render.want)
+			}
+		}
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		want markup.Mode
+		ok   bool
+	}{
+		{"", markup.HTML, true},
+		{"HTML", markup.HTML, true},
+		{"markdownv2", markup.MarkdownV2, true},
+		{"Markdown", markup.MarkdownV2, true},
+		{"plain", markup.Plain, true},
+		{"text", markup.Plain, true},
+		{"bbcode", "", false},
+	} {
+		got, err := markup.ParseMode(tc.name)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("ParseMode(%q) = %q, %v, want %q (ok %v)", tc.name, got, err, tc.want, tc.ok)
+		}
+	}
+}
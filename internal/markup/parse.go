@@ -0,0 +1,106 @@
+package markup
+
+import "strings"
+
+// nodeKind определяет вид элемента шаблона
+type nodeKind int
+
+const (
+	nodeLiteral nodeKind = iota
+	nodeParam
+	nodeBold
+	nodeCode
+	nodeLink
+)
+
+// node представляет элемент разобранного шаблона
+type node struct {
+	kind     nodeKind
+	text     string // Текст литерала или имя подстановки
+	children []node // Содержимое жирного и моноширинного текста, подпись ссылки
+	url      []node // Адрес ссылки
+}
+
+// parse разбирает шаблон. Незакрытые элементы разметки считаются обычными символами
+func parse(template string) []node {
+	nodes, _, _ := parseUntil(template, 0, 0, true)
+	return nodes
+}
+
+// parseUntil разбирает шаблон с позиции i до символа stop (0 - до конца строки).
+// Если markup равен false, разбираются только подстановки и экранирование
+func parseUntil(s string, i int, stop byte, markup bool) ([]node, int, bool) {
+	var nodes []node
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			nodes = append(nodes, node{kind: nodeLiteral, text: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i < len(s) {
+		c := s[i]
+		if stop != 0 && c == stop {
+			flush()
+			return nodes, i + 1, true
+		}
+
+		switch {
+		case c == '\\' && i+1 < len(s):
+			literal.WriteByte(s[i+1])
+			i += 2
+			continue
+
+		case c == '{':
+			if end := strings.IndexByte(s[i:], '}'); end > 1 && isParamName(s[i+1:i+end]) {
+				flush()
+				nodes = append(nodes, node{kind: nodeParam, text: s[i+1 : i+end]})
+				i += end + 1
+				continue
+			}
+
+		case markup && c == '*':
+			if children, j, ok := parseUntil(s, i+1, '*', true); ok && len(children) > 0 {
+				flush()
+				nodes = append(nodes, node{kind: nodeBold, children: children})
+				i = j
+				continue
+			}
+
+		case markup && c == '`':
+			if children, j, ok := parseUntil(s, i+1, '`', false); ok && len(children) > 0 {
+				flush()
+				nodes = append(nodes, node{kind: nodeCode, children: children})
+				i = j
+				continue
+			}
+
+		case markup && c == '[':
+			if label, j, ok := parseUntil(s, i+1, ']', false); ok && j < len(s) && s[j] == '(' {
+				if url, k, ok := parseUntil(s, j+1, ')', false); ok && len(url) > 0 {
+					flush()
+					nodes = append(nodes, node{kind: nodeLink, children: label, url: url})
+					i = k
+					continue
+				}
+			}
+		}
+
+		literal.WriteByte(c)
+		i++
+	}
+
+	flush()
+	return nodes, i, stop == 0
+}
+
+// isParamName проверяет, что строка может быть именем подстановки
+func isParamName(name string) bool {
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return name != ""
+}
@@ -0,0 +1,146 @@
+package markup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context определяет место в сообщении, от которого зависят правила экранирования
+type context int
+
+const (
+	contextText context = iota
+	contextCode
+	contextURL
+)
+
+// htmlEscaper экранирует текст для HTML-разметки Telegram
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// markdownEscaper экранирует обычный текст для MarkdownV2
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`,
+	"`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`,
+	"{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// markdownCodeEscaper экранирует содержимое моноширинного текста для MarkdownV2
+var markdownCodeEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+
+// markdownURLEscaper экранирует адрес ссылки для MarkdownV2
+var markdownURLEscaper = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+
+// Escape экранирует текст для указанной разметки
+func Escape(mode Mode, text string) string {
+	return escape(mode, contextText, text)
+}
+
+// escape экранирует текст с учетом места в сообщении
+func escape(mode Mode, ctx context, text string) string {
+	switch mode {
+	case HTML:
+		return htmlEscaper.Replace(text)
+	case MarkdownV2:
+		switch ctx {
+		case contextCode:
+			return markdownCodeEscaper.Replace(text)
+		case contextURL:
+			return markdownURLEscaper.Replace(text)
+		default:
+			return markdownEscaper.Replace(text)
+		}
+	default:
+		return text
+	}
+}
+
+// renderer отрисовывает разобранный шаблон
+type renderer struct {
+	mode   Mode
+	params map[string]any
+	out    *strings.Builder
+}
+
+// nodes отрисовывает элементы шаблона
+func (r *renderer) nodes(nodes []node, ctx context) {
+	for _, n := range nodes {
+		r.node(n, ctx)
+	}
+}
+
+// node отрисовывает элемент шаблона
+func (r *renderer) node(n node, ctx context) {
+	switch n.kind {
+	case nodeLiteral:
+		r.out.WriteString(escape(r.mode, ctx, n.text))
+
+	case nodeParam:
+		r.param(n.text, ctx)
+
+	case nodeBold:
+		switch r.mode {
+		case HTML:
+			r.wrap("<b>", n.children, ctx, "</b>")
+		case MarkdownV2:
+			r.wrap("*", n.children, ctx, "*")
+		default:
+			r.nodes(n.children, ctx)
+		}
+
+	case nodeCode:
+		switch r.mode {
+		case HTML:
+			r.wrap("<code>", n.children, contextCode, "</code>")
+		case MarkdownV2:
+			r.wrap("`", n.children, contextCode, "`")
+		default:
+			r.nodes(n.children, contextCode)
+		}
+
+	case nodeLink:
+		switch r.mode {
+		case HTML:
+			r.out.WriteString(`<a href="`)
+			r.nodes(n.url, contextURL)
+			r.wrap(`">`, n.children, contextText, "</a>")
+		case MarkdownV2:
+			r.wrap("[", n.children, contextText, "](")
+			r.nodes(n.url, contextURL)
+			r.out.WriteString(")")
+		default:
+			// В обычном тексте ссылка показывается после подписи
+			r.nodes(n.children, contextText)
+			r.out.WriteString(" (")
+			r.nodes(n.url, contextURL)
+			r.out.WriteString(")")
+		}
+	}
+}
+
+// wrap отрисовывает элементы между открывающим и закрывающим тегами
+func (r *renderer) wrap(open string, nodes []node, ctx context, close string) {
+	r.out.WriteString(open)
+	r.nodes(nodes, ctx)
+	r.out.WriteString(close)
+}
+
+// param отрисовывает значение подстановки. Вложенные сообщения отрисовываются
+// со своей разметкой, остальные значения экранируются
+func (r *renderer) param(name string, ctx context) {
+	value, ok := r.params[name]
+	if !ok {
+		// Неизвестная подстановка остается в тексте, чтобы ошибку в каталоге было видно
+		r.out.WriteString(escape(r.mode, ctx, "{"+name+"}"))
+		return
+	}
+
+	if text, ok := value.(Text); ok {
+		if ctx == contextText {
+			r.out.WriteString(text.Render(r.mode))
+		} else {
+			r.out.WriteString(escape(r.mode, ctx, text.Render(Plain)))
+		}
+		return
+	}
+	r.out.WriteString(escape(r.mode, ctx, fmt.Sprint(value)))
+}
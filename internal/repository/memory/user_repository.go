@@ -0,0 +1,295 @@
+// Package memory содержит хранилища в памяти. Они не требуют базы данных
+// и используются в тестах и для локального запуска без сохранения данных
+package memory
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// UserRepository реализует интерфейс domain.UserRepository в памяти
+type UserRepository struct {
+	mu     sync.RWMutex
+	users  map[int64]*domain.User
+	nextID int64
+}
+
+// NewUserRepository создает новый экземпляр UserRepository
+func NewUserRepository() *UserRepository {
+	return &UserRepository{
+		users:  make(map[int64]*domain.User),
+		nextID: 1,
+	}
+}
+
+// clone возвращает копию пользователя, чтобы вызывающий код не мог изменить хранимые данные
+func clone(user *domain.User) *domain.User {
+	copied := *user
+	return &copied
+}
+
+// find возвращает копию первого пользователя, удовлетворяющего условию, или nil
+func (r *UserRepository) find(match func(*domain.User) bool) *domain.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if match(user) {
+			return clone(user)
+		}
+	}
+	return nil
+}
+
+// checkUnique проверяет, что имя пользователя и Telegram-аккаунт не заняты другими пользователями
+func (r *UserRepository) checkUnique(user *domain.User) error {
+	for _, other := range r.users {
+		if other.ID == user.ID {
+			continue
+		}
+		if other.Username == user.Username {
+			return fmt.Errorf("%w: username %q is taken", domain.ErrUserExists, user.Username)
+		}
+		if user.TelegramID != 0 && other.TelegramID == user.TelegramID {
+			return fmt.Errorf("%w: telegram account %d is bound", domain.ErrUserExists, user.TelegramID)
+		}
+	}
+	return nil
+}
+
+// GetByID возвращает пользователя по его идентификатору
+func (r *UserRepository) GetByID(id int64) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if user, ok := r.users[id]; ok {
+		return clone(user), nil
+	}
+	return nil, nil
+}
+
+// GetByTelegramID возвращает пользователя, привязанного к Telegram-аккаунту
+func (r *UserRepository) GetByTelegramID(telegramID int64) (*domain.User, error) {
+	if telegramID == 0 {
+		return nil, nil
+	}
+	return r.find(func(u *domain.User) bool { return u.TelegramID == telegramID }), nil
+}
+
+// GetByUsername возвращает пользователя по его имени пользователя
+func (r *UserRepository) GetByUsername(username string) (*domain.User, error) {
+	return r.find(func(u *domain.User) bool { return u.Username == username }), nil
+}
+
+// Save сохраняет нового пользователя
+func (r *UserRepository) Save(user *domain.User) error {
+	if user.ID != 0 {
+		return fmt.Errorf("failed to save user %d: %w", user.ID, domain.ErrUserExists)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkUnique(user); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+
+	now := time.Now()
+	if user.Status == "" {
+		user.Status = domain.UserActive
+	}
+	user.ID = r.nextID
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	r.nextID++
+
+	// Активная команда выбирается отдельно, а роль в команде хранится в членстве
+	stored := clone(user)
+	stored.ActiveTeamID = 0
+	stored.TeamRole = ""
+	r.users[user.ID] = stored
+	return nil
+}
+
+// Update обновляет существующего пользователя
+func (r *UserRepository) Update(user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok {
+		return fmt.Errorf("failed to update user: %w", domain.ErrUserNotFound)
+	}
+	if err := r.checkUnique(user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	// Время создания, состояние учетной записи, отзыв сессий и активная команда не меняются при обновлении
+	updated := clone(user)
+	updated.ActiveTeamID = stored.ActiveTeamID
+	updated.TeamRole = ""
+	updated.CreatedAt = stored.CreatedAt
+	updated.Status = stored.Status
+	updated.StatusReason = stored.StatusReason
+	updated.StatusChangedAt = stored.StatusChangedAt
+	updated.PurgedAt = stored.PurgedAt
+	updated.SessionsRevokedAt = stored.SessionsRevokedAt
+	updated.UpdatedAt = time.Now()
+	r.users[user.ID] = updated
+	user.UpdatedAt = updated.UpdatedAt
+	return nil
+}
+
+// modify изменяет хранимого пользователя и обновляет время изменения
+func (r *UserRepository) modify(id int64, change func(*domain.User) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	updated := clone(stored)
+	if err := change(updated); err != nil {
+		return err
+	}
+	updated.UpdatedAt = time.Now()
+	r.users[id] = updated
+	return nil
+}
+
+// UpdateStatus изменяет состояние учетной записи пользователя и причину изменения
+func (r *UserRepository) UpdateStatus(id int64, status domain.UserStatus, reason string) error {
+	return r.modify(id, func(u *domain.User) error {
+		u.Status = status
+		u.StatusReason = reason
+		u.StatusChangedAt = time.Now()
+		return nil
+	})
+}
+
+// AnonymizeDeleted обезличивает пользователей, удаленных раньше указанного времени:
+// удаляет персональные данные и пароль, освобождает имя и Telegram-аккаунт
+func (r *UserRepository) AnonymizeDeleted(before time.Time) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var ids []int64
+	for id, user := range r.users {
+		if user.Status != domain.UserDeleted || !user.PurgedAt.IsZero() || !user.StatusChangedAt.Before(before) {
+			continue
+		}
+		r.users[id] = &domain.User{
+			ID:                user.ID,
+			Username:          domain.AnonymizedUsername(user.ID),
+			Role:              user.Role,
+			Language:          user.Language,
+			CreatedAt:         user.CreatedAt,
+			UpdatedAt:         now,
+			Status:            user.Status,
+			StatusChangedAt:   user.StatusChangedAt,
+			PurgedAt:          now,
+			SessionsRevokedAt: user.SessionsRevokedAt,
+		}
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// RevokeSessions завершает все сессии пользователя, выданные до текущего момента
+func (r *UserRepository) RevokeSessions(id int64) error {
+	return r.modify(id, func(u *domain.User) error {
+		u.SessionsRevokedAt = time.Now()
+		return nil
+	})
+}
+
+// SetActiveTeam выбирает активную команду пользователя
+func (r *UserRepository) SetActiveTeam(id int64, teamID int64) error {
+	return r.modify(id, func(u *domain.User) error {
+		u.ActiveTeamID = teamID
+		return nil
+	})
+}
+
+// UpdatePassword обновляет пароль пользователя
+func (r *UserRepository) UpdatePassword(id int64, newPassword string) error {
+	return r.modify(id, func(u *domain.User) error {
+		u.Password = newPassword
+		return nil
+	})
+}
+
+// UpdateRole обновляет роль пользователя
+func (r *UserRepository) UpdateRole(id int64, newRole string) error {
+	return r.modify(id, func(u *domain.User) error {
+		u.Role = newRole
+		return nil
+	})
+}
+
+// BindTelegram привязывает пользователя к Telegram-аккаунту и чату доставки
+func (r *UserRepository) BindTelegram(id int64, telegramID int64, chatID int64) error {
+	err := r.modify(id, func(u *domain.User) error {
+		u.TelegramID = telegramID
+		u.ChatID = chatID
+		return r.checkUnique(u)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to bind user: %w", err)
+	}
+	return nil
+}
+
+// UpdateLanguage обновляет язык интерфейса пользователя
+func (r *UserRepository) UpdateLanguage(id int64, language string) error {
+	return r.modify(id, func(u *domain.User) error {
+		u.Language = language
+		return nil
+	})
+}
+
+// Find возвращает страницу пользователей, удовлетворяющих запросу
+func (r *UserRepository) Find(query domain.UserQuery) (*domain.UserPage, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	after, err := query.After()
+	if err != nil {
+		return nil, err
+	}
+
+	// Позиция курсора сравнивается с пользователями так же, как пользователи между собой
+	var pivot *domain.User
+	if after != nil {
+		pivot = &domain.User{ID: after.ID, Username: after.Username, CreatedAt: after.CreatedAt}
+	}
+
+	r.mu.RLock()
+	var users []*domain.User
+	for _, user := range r.users {
+		if query.Match(user) && (pivot == nil || query.Less(pivot, user)) {
+			users = append(users, clone(user))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool { return query.Less(users[i], users[j]) })
+	if query.Limit > 0 && len(users) > query.Limit+1 {
+		users = users[:query.Limit+1]
+	}
+	if !query.WithSecrets {
+		for _, user := range users {
+			user.Password = ""
+		}
+	}
+	return domain.NewUserPage(query, users), nil
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/repository/memory"
+	"HelpBot/internal/repository/repotest"
+)
+
+func TestUserRepository(t *testing.T) {
+	repotest.UserRepository(t, func(t *testing.T) domain.UserRepository {
+		return memory.NewUserRepository()
+	})
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"HelpBot/internal/repository/postgres"
+	"HelpBot/internal/repository/sqlite"
+)
+
+// Поддерживаемые хранилища
+const (
+	BackendSQLite   = "sqlite"
+	BackendPostgres = "postgres"
+)
+
+// Backend определяет хранилище по строке подключения:
+// postgres:// и postgresql:// - PostgreSQL, sqlite:<путь> - SQLite
+func Backend(dsn string) (string, error) {
+	switch {
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		return BackendPostgres, nil
+	case strings.HasPrefix(dsn, "sqlite:"):
+		return BackendSQLite, nil
+	default:
+		return "", fmt.Errorf("unsupported database DSN: use postgres://... or sqlite:<path>")
+	}
+}
+
+// SQLitePath возвращает путь к файлу базы, если строка подключения относится к SQLite
+func SQLitePath(dsn string) (string, bool) {
+	if backend, err := Backend(dsn); err != nil || backend != BackendSQLite {
+		return "", false
+	}
+	return strings.TrimPrefix(dsn, "sqlite:"), true
+}
+
+// Open подключается к хранилищу по строке подключения, применяет миграции
+// и создает репозитории. Подключение нужно закрыть после завершения работы
+func Open(dsn string, logger *slog.Logger) (*Repositories, *sql.DB, error) {
+	backend, err := Backend(dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger.Info("Connecting to database", "backend", backend)
+
+	switch backend {
+	case BackendPostgres:
+		db, err := postgres.NewDB(dsn, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewRepositories(
+			postgres.NewUserRepository(db),
+			postgres.NewTransferRepository(db),
+			postgres.NewRoleRepository(db),
+			postgres.NewAuditRepository(db),
+			postgres.NewTwoFactorRepository(db),
+			postgres.NewInviteRepository(db),
+			postgres.NewTeamRepository(db),
+			postgres.NewDuesRepository(db),
+			postgres.NewBalanceRepository(db),
+			postgres.NewExpenseRepository(db),
+			postgres.NewEventRepository(db),
+			postgres.NewPollRepository(db),
+			postgres.NewAttendanceRepository(db),
+			postgres.NewDutyRepository(db),
+			postgres.NewAnnouncementRepository(db),
+			postgres.NewTicketRepository(db),
+		), db, nil
+
+	default:
+		path, _ := SQLitePath(dsn)
+		db, err := sqlite.NewDB(path, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewRepositories(
+			sqlite.NewUserRepository(db),
+			sqlite.NewTransferRepository(db),
+			sqlite.NewRoleRepository(db),
+			sqlite.NewAuditRepository(db),
+			sqlite.NewTwoFactorRepository(db),
+			sqlite.NewInviteRepository(db),
+			sqlite.NewTeamRepository(db),
+			sqlite.NewDuesRepository(db),
+			sqlite.NewBalanceRepository(db),
+			sqlite.NewExpenseRepository(db),
+			sqlite.NewEventRepository(db),
+			sqlite.NewPollRepository(db),
+			sqlite.NewAttendanceRepository(db),
+			sqlite.NewDutyRepository(db),
+			sqlite.NewAnnouncementRepository(db),
+			sqlite.NewTicketRepository(db),
+		), db, nil
+	}
+}
+
+// SchemaVersion возвращает номер последней примененной миграции
+func SchemaVersion(db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return version, nil
+}
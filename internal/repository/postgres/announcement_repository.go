@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// announcementColumns - колонки объявления в порядке, ожидаемом scanAnnouncement, вместе
+// с числом получателей и прочитавших
+const announcementColumns = `a.id, a.team_id, a.text, a.chat_id, a.message_id, a.active, a.created_by, a.created_at, a.closed_at,
+	(SELECT COUNT(*) FROM announcement_receipts r WHERE r.announcement_id = a.id),
+	(SELECT COUNT(*) FROM announcement_receipts r WHERE r.announcement_id = a.id AND r.acknowledged_at IS NOT NULL)`
+
+// receiptColumns - колонки отметки о прочтении в порядке, ожидаемом scanReceipt, вместе
+// с именем получателя
+const receiptColumns = `r.announcement_id, r.user_id, u.username, r.acknowledged_at, r.reminders, r.reminded_at`
+
+// AnnouncementRepository реализует интерфейс domain.AnnouncementRepository для PostgreSQL
+type AnnouncementRepository struct {
+	db *sql.DB
+}
+
+// NewAnnouncementRepository создает новый экземпляр AnnouncementRepository
+func NewAnnouncementRepository(db *sql.DB) *AnnouncementRepository {
+	return &AnnouncementRepository{
+		db: db,
+	}
+}
+
+// scanAnnouncement считывает объявление из строки результата
+func scanAnnouncement(row scanner) (*domain.Announcement, error) {
+	var announcement domain.Announcement
+	var closedAt sql.NullTime
+	err := row.Scan(&announcement.ID, &announcement.TeamID, &announcement.Text, &announcement.ChatID, &announcement.MessageID,
+		&announcement.Active, &announcement.CreatedBy, &announcement.CreatedAt, &closedAt,
+		&announcement.Recipients, &announcement.Acknowledged)
+	if err != nil {
+		return nil, err
+	}
+	announcement.ClosedAt = closedAt.Time
+	return &announcement, nil
+}
+
+// scanReceipt считывает отметку о прочтении из строки результата
+func scanReceipt(row scanner) (*domain.AnnouncementReceipt, error) {
+	var receipt domain.AnnouncementReceipt
+	var acknowledgedAt, remindedAt sql.NullTime
+	err := row.Scan(&receipt.AnnouncementID, &receipt.UserID, &receipt.Username, &acknowledgedAt, &receipt.Reminders, &remindedAt)
+	if err != nil {
+		return nil, err
+	}
+	receipt.AcknowledgedAt = acknowledgedAt.Time
+	receipt.RemindedAt = remindedAt.Time
+	return &receipt, nil
+}
+
+// Save сохраняет новое объявление вместе с его получателями
+func (r *AnnouncementRepository) Save(announcement *domain.Announcement, userIDs []int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := currentTime()
+	var id int64
+	err = tx.QueryRow(`
+		INSERT INTO announcements (team_id, text, chat_id, message_id, active, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		announcement.TeamID, announcement.Text, announcement.ChatID, announcement.MessageID, announcement.Active,
+		announcement.CreatedBy, now).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("failed to save announcement: %w", err)
+	}
+
+	for _, userID := range userIDs {
+		if _, err := tx.Exec("INSERT INTO announcement_receipts (announcement_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			id, userID); err != nil {
+			return fmt.Errorf("failed to save announcement recipient: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	announcement.ID = id
+	announcement.CreatedAt = now
+	announcement.Recipients = len(userIDs)
+	return nil
+}
+
+// GetByID возвращает объявление по его идентификатору
+func (r *AnnouncementRepository) GetByID(id int64) (*domain.Announcement, error) {
+	announcements, err := r.announcements("a.id = $1", id)
+	if err != nil || len(announcements) == 0 {
+		return nil, err
+	}
+	return announcements[0], nil
+}
+
+// GetActive возвращает действующие объявления команды от новых к старым
+func (r *AnnouncementRepository) GetActive(teamID int64) ([]*domain.Announcement, error) {
+	return r.announcements("a.team_id = $1 AND a.active", teamID)
+}
+
+// announcements выбирает объявления по условию от новых к старым
+func (r *AnnouncementRepository) announcements(where string, args ...any) ([]*domain.Announcement, error) {
+	rows, err := r.db.Query("SELECT "+announcementColumns+" FROM announcements a WHERE "+where+" ORDER BY a.created_at DESC, a.id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var announcements []*domain.Announcement
+	for rows.Next() {
+		announcement, err := scanAnnouncement(rows)
+		if err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, announcement)
+	}
+	return announcements, rows.Err()
+}
+
+// SetMessage запоминает закрепленное сообщение объявления в группе команды
+func (r *AnnouncementRepository) SetMessage(id int64, chatID int64, messageID int) error {
+	_, err := r.db.Exec("UPDATE announcements SET chat_id = $1, message_id = $2 WHERE id = $3", chatID, messageID, id)
+	return err
+}
+
+// Close закрывает действующее объявление
+func (r *AnnouncementRepository) Close(id int64, at time.Time) error {
+	result, err := r.db.Exec("UPDATE announcements SET active = FALSE, closed_at = $1 WHERE id = $2 AND active", at, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrAnnouncementClosed
+	}
+	return nil
+}
+
+// GetReceipt возвращает отметку получателя объявления
+func (r *AnnouncementRepository) GetReceipt(id int64, userID int64) (*domain.AnnouncementReceipt, error) {
+	receipts, err := r.receipts("r.announcement_id = $1 AND r.user_id = $2", id, userID)
+	if err != nil || len(receipts) == 0 {
+		return nil, err
+	}
+	return receipts[0], nil
+}
+
+// GetReceipts возвращает отметки всех получателей объявления, упорядоченные по имени
+func (r *AnnouncementRepository) GetReceipts(id int64) ([]*domain.AnnouncementReceipt, error) {
+	return r.receipts("r.announcement_id = $1", id)
+}
+
+// GetUnacknowledged возвращает отметки непрочитавших получателей действующих объявлений,
+// которым пора напомнить
+func (r *AnnouncementRepository) GetUnacknowledged(before time.Time, maxReminders int) ([]*domain.AnnouncementReceipt, error) {
+	return r.receipts(`r.acknowledged_at IS NULL AND r.reminders < $1
+		AND r.announcement_id IN (SELECT id FROM announcements WHERE active)
+		AND COALESCE(r.reminded_at, (SELECT created_at FROM announcements WHERE id = r.announcement_id)) <= $2`,
+		maxReminders, before)
+}
+
+// receipts выбирает отметки о прочтении по условию, упорядоченные по объявлению и имени получателя
+func (r *AnnouncementRepository) receipts(where string, args ...any) ([]*domain.AnnouncementReceipt, error) {
+	rows, err := r.db.Query(`
+		SELECT `+receiptColumns+` FROM announcement_receipts r
+		JOIN users u ON u.id = r.user_id
+		WHERE `+where+`
+		ORDER BY r.announcement_id, u.username COLLATE "C"`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var receipts []*domain.AnnouncementReceipt
+	for rows.Next() {
+		receipt, err := scanReceipt(rows)
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, rows.Err()
+}
+
+// Acknowledge отмечает объявление прочитанным получателем
+func (r *AnnouncementRepository) Acknowledge(id int64, userID int64, at time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE announcement_receipts SET acknowledged_at = $1
+		WHERE announcement_id = $2 AND user_id = $3 AND acknowledged_at IS NULL`,
+		at, id, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// MarkReminded записывает напоминание получателю объявления
+func (r *AnnouncementRepository) MarkReminded(id int64, userID int64, at time.Time) error {
+	_, err := r.db.Exec("UPDATE announcement_receipts SET reminders = reminders + 1, reminded_at = $1 WHERE announcement_id = $2 AND user_id = $3",
+		at, id, userID)
+	return err
+}
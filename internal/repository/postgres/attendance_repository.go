@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// AttendanceRepository реализует интерфейс domain.AttendanceRepository для PostgreSQL
+type AttendanceRepository struct {
+	db *sql.DB
+}
+
+// NewAttendanceRepository создает новый экземпляр AttendanceRepository
+func NewAttendanceRepository(db *sql.DB) *AttendanceRepository {
+	return &AttendanceRepository{
+		db: db,
+	}
+}
+
+// Mark сохраняет отметку участника, заменяя прежнюю
+func (r *AttendanceRepository) Mark(attendance *domain.Attendance) error {
+	now := currentTime()
+	_, err := r.db.Exec(`
+		INSERT INTO event_attendance (event_id, user_id, status, self_check_in, marked_by, marked_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (event_id, user_id) DO UPDATE SET status = excluded.status,
+			self_check_in = excluded.self_check_in, marked_by = excluded.marked_by, marked_at = excluded.marked_at`,
+		attendance.EventID, attendance.UserID, attendance.Status, attendance.SelfCheckIn, attendance.MarkedBy, now)
+	if err != nil {
+		return fmt.Errorf("failed to save attendance: %w", err)
+	}
+	attendance.MarkedAt = now
+	return nil
+}
+
+// GetByEvent возвращает отметки о посещении события
+func (r *AttendanceRepository) GetByEvent(eventID int64) ([]*domain.Attendance, error) {
+	return r.attendance("a.event_id = $1", eventID)
+}
+
+// GetTracked возвращает события команды с отметками о посещении
+func (r *AttendanceRepository) GetTracked(teamID int64, from, to time.Time) ([]*domain.Event, error) {
+	rows, err := r.db.Query(`
+		SELECT `+eventColumns+` FROM events e
+		WHERE e.team_id = $1 AND NOT e.canceled AND e.starts_at >= $2 AND e.starts_at < $3
+			AND EXISTS (SELECT 1 FROM event_attendance a WHERE a.event_id = e.id)
+		ORDER BY e.starts_at, e.id`, teamID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*domain.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// GetByTeam возвращает отметки о посещении неотмененных событий команды за период
+func (r *AttendanceRepository) GetByTeam(teamID int64, from, to time.Time) ([]*domain.Attendance, error) {
+	return r.attendance(`a.event_id IN (
+		SELECT e.id FROM events e WHERE e.team_id = $1 AND NOT e.canceled AND e.starts_at >= $2 AND e.starts_at < $3)`,
+		teamID, from, to)
+}
+
+// attendance выбирает отметки о посещении по условию
+func (r *AttendanceRepository) attendance(where string, args ...any) ([]*domain.Attendance, error) {
+	rows, err := r.db.Query(`
+		SELECT a.event_id, a.user_id, u.username, a.status, a.self_check_in, a.marked_by, a.marked_at
+		FROM event_attendance a
+		JOIN users u ON u.id = a.user_id
+		WHERE `+where+`
+		ORDER BY a.event_id, u.username`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var marks []*domain.Attendance
+	for rows.Next() {
+		var mark domain.Attendance
+		if err := rows.Scan(&mark.EventID, &mark.UserID, &mark.Username, &mark.Status, &mark.SelfCheckIn,
+			&mark.MarkedBy, &mark.MarkedAt); err != nil {
+			return nil, err
+		}
+		marks = append(marks, &mark)
+	}
+	return marks, rows.Err()
+}
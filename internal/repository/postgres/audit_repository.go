@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// AuditRepository реализует интерфейс domain.AuditRepository для PostgreSQL
+type AuditRepository struct {
+	db *sql.DB
+}
+
+// NewAuditRepository создает новый экземпляр AuditRepository
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+// Append добавляет запись в журнал
+func (r *AuditRepository) Append(entry *domain.AuditEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	err := r.db.QueryRow(`
+		INSERT INTO audit_log (actor_id, telegram_id, target_id, action, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		entry.ActorID,
+		entry.TelegramID,
+		entry.TargetID,
+		entry.Action,
+		entry.Details,
+		entry.CreatedAt,
+	).Scan(&entry.ID)
+	if err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+	return nil
+}
+
+// Find возвращает записи журнала по фильтру, начиная с самых новых
+func (r *AuditRepository) Find(filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	var conditions []string
+	var args []any
+
+	// arg добавляет значение параметра запроса и возвращает его номер
+	arg := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if filter.UserID != 0 {
+		user := arg(filter.UserID)
+		conditions = append(conditions, "(actor_id = "+user+" OR target_id = "+user+")")
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = "+arg(filter.Action))
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= "+arg(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < "+arg(filter.To))
+	}
+
+	query := "SELECT id, actor_id, telegram_id, target_id, action, details, created_at FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT " + arg(filter.Limit)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.AuditEntry
+	for rows.Next() {
+		entry := &domain.AuditEntry{}
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ActorID,
+			&entry.TelegramID,
+			&entry.TargetID,
+			&entry.Action,
+			&entry.Details,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"HelpBot/internal/domain"
+)
+
+// BalanceRepository реализует интерфейс domain.BalanceRepository для PostgreSQL
+type BalanceRepository struct {
+	db *sql.DB
+}
+
+// NewBalanceRepository создает новый экземпляр BalanceRepository
+func NewBalanceRepository(db *sql.DB) *BalanceRepository {
+	return &BalanceRepository{
+		db: db,
+	}
+}
+
+// AddEntry добавляет операцию. Повторное начисление по тому же плану, пользователю
+// и периоду пропускается благодаря уникальному ключу
+func (r *BalanceRepository) AddEntry(entry *domain.BalanceEntry) (bool, error) {
+	now := currentTime()
+	err := r.db.QueryRow(`
+		INSERT INTO balance_entries (team_id, user_id, kind, amount, plan_id, period, note, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (plan_id, user_id, period) DO NOTHING
+		RETURNING id`,
+		entry.TeamID, entry.UserID, entry.Kind, entry.Amount, nullableID(entry.PlanID),
+		nullableDate(entry.Period), entry.Note, entry.CreatedBy, now).Scan(&entry.ID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to add balance entry: %w", err)
+	}
+	entry.CreatedAt = now
+	return true, nil
+}
+
+// GetBalance возвращает баланс пользователя в команде
+func (r *BalanceRepository) GetBalance(teamID int64, userID int64) (int64, error) {
+	var balance int64
+	err := r.db.QueryRow("SELECT COALESCE(SUM(amount), 0)::BIGINT FROM balance_entries WHERE team_id = $1 AND user_id = $2",
+		teamID, userID).Scan(&balance)
+	return balance, err
+}
+
+// GetEntries возвращает последние операции пользователя в команде, начиная с новых
+func (r *BalanceRepository) GetEntries(teamID int64, userID int64, limit int) ([]*domain.BalanceEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT id, team_id, user_id, kind, amount, plan_id, expense_id, to_char(period, 'YYYY-MM-DD'), note, created_by, created_at
+		FROM balance_entries
+		WHERE team_id = $1 AND user_id = $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3`, teamID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.BalanceEntry
+	for rows.Next() {
+		var entry domain.BalanceEntry
+		var planID, expenseID sql.NullInt64
+		var period sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.TeamID, &entry.UserID, &entry.Kind, &entry.Amount,
+			&planID, &expenseID, &period, &entry.Note, &entry.CreatedBy, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entry.PlanID = planID.Int64
+		entry.ExpenseID = expenseID.Int64
+		if entry.Period, err = parseDate(period); err != nil {
+			return nil, fmt.Errorf("invalid period of balance entry %d: %w", entry.ID, err)
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
+
+// GetDebtors возвращает участников команды с долгом по взносам, начиная с наибольшего долга.
+// Расходы и переводы между участниками в отчет не входят
+func (r *BalanceRepository) GetDebtors(teamID int64) ([]*domain.Debtor, error) {
+	rows, err := r.db.Query(`
+		SELECT b.user_id, u.username, -SUM(b.amount)::BIGINT AS debt
+		FROM balance_entries b
+		JOIN users u ON u.id = b.user_id
+		WHERE b.team_id = $1 AND b.kind IN ('charge', 'payment')
+		GROUP BY b.user_id, u.username
+		HAVING SUM(b.amount) < 0
+		ORDER BY debt DESC, u.username COLLATE "C"`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var debtors []*domain.Debtor
+	for rows.Next() {
+		var debtor domain.Debtor
+		if err := rows.Scan(&debtor.UserID, &debtor.Username, &debtor.Debt); err != nil {
+			return nil, err
+		}
+		debtors = append(debtors, &debtor)
+	}
+	return debtors, rows.Err()
+}
@@ -0,0 +1,421 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	_ "github.com/lib/pq"
+)
+
+// migrationLockID идентифицирует advisory-блокировку, под которой применяются миграции.
+// Блокировка не дает нескольким экземплярам бота применять миграции одновременно
+const migrationLockID = 7311450163
+
+// migrations содержит изменения схемы базы данных в порядке применения.
+// Уже примененные миграции нельзя изменять, только добавлять новые в конец
+var migrations = []string{
+	// 1: пользователи, привязанные к Telegram-аккаунтам
+	`CREATE TABLE users (
+		id BIGSERIAL PRIMARY KEY,
+		telegram_id BIGINT UNIQUE,
+		chat_id BIGINT NOT NULL DEFAULT 0,
+		username TEXT UNIQUE NOT NULL,
+		password TEXT NOT NULL DEFAULT '',
+		role TEXT NOT NULL DEFAULT 'user',
+		position TEXT NOT NULL DEFAULT '',
+		birthday TEXT NOT NULL DEFAULT '',
+		number TEXT NOT NULL DEFAULT '',
+		language TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`,
+	// 2: запросы на перенос аккаунта на другой Telegram-аккаунт
+	`CREATE TABLE account_transfers (
+		id BIGSERIAL PRIMARY KEY,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		from_telegram_id BIGINT NOT NULL DEFAULT 0,
+		to_telegram_id BIGINT NOT NULL,
+		to_chat_id BIGINT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		resolved_by BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		resolved_at TIMESTAMPTZ
+	)`,
+	// 3: роли как наборы прав и встроенные роли
+	`CREATE TABLE roles (
+		name TEXT PRIMARY KEY,
+		description TEXT NOT NULL DEFAULT '',
+		built_in BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE TABLE role_permissions (
+		role TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+		permission TEXT NOT NULL,
+		PRIMARY KEY (role, permission)
+	);
+	INSERT INTO roles (name, description, built_in) VALUES
+		('admin', 'Администратор', TRUE),
+		('user', 'Участник', TRUE),
+		('treasurer', 'Казначей', TRUE),
+		('coordinator', 'Координатор', TRUE),
+		('viewer', 'Наблюдатель', TRUE);
+	INSERT INTO role_permissions (role, permission) VALUES
+		('treasurer', 'payments.confirm'),
+		('treasurer', 'users.view'),
+		('coordinator', 'events.manage'),
+		('coordinator', 'users.view'),
+		('viewer', 'users.view')`,
+	// 4: журнал аудита, защищенный от изменения и удаления записей
+	`CREATE TABLE audit_log (
+		id BIGSERIAL PRIMARY KEY,
+		actor_id BIGINT NOT NULL DEFAULT 0,
+		telegram_id BIGINT NOT NULL DEFAULT 0,
+		target_id BIGINT NOT NULL DEFAULT 0,
+		action TEXT NOT NULL,
+		details TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+	CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'audit_log is append-only';
+	END;
+	$$ LANGUAGE plpgsql;
+	CREATE TRIGGER audit_log_no_change BEFORE UPDATE OR DELETE ON audit_log
+		FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+	CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+		FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only()`,
+	// 5: TOTP двухфакторная аутентификация и коды восстановления
+	`CREATE TABLE user_totp (
+		user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		secret TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMPTZ NOT NULL,
+		enabled_at TIMESTAMPTZ
+	);
+	CREATE TABLE recovery_codes (
+		id BIGSERIAL PRIMARY KEY,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		code_hash TEXT NOT NULL,
+		used_at TIMESTAMPTZ
+	)`,
+	// 6: состояние учетной записи вместо удаления строк
+	`ALTER TABLE users
+		ADD COLUMN status TEXT NOT NULL DEFAULT 'active',
+		ADD COLUMN status_reason TEXT NOT NULL DEFAULT '',
+		ADD COLUMN status_changed_at TIMESTAMPTZ,
+		ADD COLUMN purged_at TIMESTAMPTZ;
+	CREATE INDEX idx_users_status ON users(status)`,
+	// 7: приглашения пользователей, созданных импортом состава команды
+	`CREATE TABLE invites (
+		user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		code_hash TEXT UNIQUE NOT NULL,
+		created_by BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	)`,
+	// 8: время, до которого выданные сессии и токены пользователя недействительны
+	`ALTER TABLE users ADD COLUMN sessions_revoked_at TIMESTAMPTZ`,
+	// 9: команды, участники с ролями в команде и активная команда пользователя.
+	// Существующие пользователи переносятся в основную команду со своими ролями
+	`CREATE TABLE teams (
+		id BIGSERIAL PRIMARY KEY,
+		name TEXT UNIQUE NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE TABLE team_members (
+		team_id BIGINT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		role TEXT NOT NULL DEFAULT 'user',
+		joined_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (team_id, user_id)
+	);
+	CREATE INDEX idx_team_members_user_id ON team_members(user_id);
+	ALTER TABLE users ADD COLUMN active_team_id BIGINT REFERENCES teams(id) ON DELETE SET NULL;
+	INSERT INTO teams (name) SELECT 'Основная команда' WHERE EXISTS (SELECT 1 FROM users);
+	INSERT INTO team_members (team_id, user_id, role)
+		SELECT t.id, u.id, u.role FROM teams t CROSS JOIN users u WHERE u.purged_at IS NULL;
+	UPDATE users SET active_team_id = (SELECT MIN(id) FROM teams) WHERE purged_at IS NULL`,
+	// 10: планы взносов и операции по балансам участников команд. Уникальный ключ
+	// начисления (план, пользователь, период) делает начисление взносов идемпотентным
+	`CREATE TABLE dues_plans (
+		id BIGSERIAL PRIMARY KEY,
+		team_id BIGINT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		amount BIGINT NOT NULL,
+		period TEXT NOT NULL,
+		start_date DATE NOT NULL,
+		charged_through DATE,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_by BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL,
+		UNIQUE (team_id, name)
+	);
+	CREATE TABLE dues_plan_members (
+		plan_id BIGINT NOT NULL REFERENCES dues_plans(id) ON DELETE CASCADE,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		PRIMARY KEY (plan_id, user_id)
+	);
+	CREATE TABLE balance_entries (
+		id BIGSERIAL PRIMARY KEY,
+		team_id BIGINT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		user_id BIGINT NOT NULL REFERENCES users(id),
+		kind TEXT NOT NULL,
+		amount BIGINT NOT NULL,
+		plan_id BIGINT REFERENCES dues_plans(id) ON DELETE CASCADE,
+		period DATE,
+		note TEXT NOT NULL DEFAULT '',
+		created_by BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL,
+		UNIQUE (plan_id, user_id, period)
+	);
+	CREATE INDEX idx_balance_entries_team_user ON balance_entries(team_id, user_id);
+	INSERT INTO role_permissions (role, permission)
+		SELECT name, 'dues.manage' FROM roles WHERE name = 'treasurer'`,
+	// 11: общие расходы участников команд и их доли. Операции по балансам ссылаются
+	// на расход, из которого они получены
+	`CREATE TABLE expenses (
+		id BIGSERIAL PRIMARY KEY,
+		team_id BIGINT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		payer_id BIGINT NOT NULL REFERENCES users(id),
+		amount BIGINT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		mode TEXT NOT NULL,
+		settlement BOOLEAN NOT NULL DEFAULT FALSE,
+		created_by BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX idx_expenses_team ON expenses(team_id, created_at);
+	CREATE TABLE expense_shares (
+		expense_id BIGINT NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+		user_id BIGINT NOT NULL REFERENCES users(id),
+		value BIGINT NOT NULL DEFAULT 0,
+		amount BIGINT NOT NULL,
+		PRIMARY KEY (expense_id, user_id)
+	);
+	ALTER TABLE balance_entries ADD COLUMN expense_id BIGINT REFERENCES expenses(id) ON DELETE CASCADE`,
+	// 12: события команд и ответы участников на приглашения
+	`CREATE TABLE events (
+		id BIGSERIAL PRIMARY KEY,
+		team_id BIGINT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		title TEXT NOT NULL,
+		location TEXT NOT NULL DEFAULT '',
+		starts_at TIMESTAMPTZ NOT NULL,
+		capacity INTEGER NOT NULL DEFAULT 0,
+		deadline TIMESTAMPTZ,
+		canceled BOOLEAN NOT NULL DEFAULT FALSE,
+		reminded_at TIMESTAMPTZ,
+		created_by BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX idx_events_team_start ON events(team_id, starts_at);
+	CREATE TABLE event_rsvps (
+		event_id BIGINT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		status TEXT NOT NULL,
+		responded_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (event_id, user_id)
+	)`,
+	// 13: опросы команд, голоса и отправленные сообщения с результатами
+	`CREATE TABLE polls (
+		id BIGSERIAL PRIMARY KEY,
+		team_id BIGINT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		question TEXT NOT NULL,
+		multiple BOOLEAN NOT NULL DEFAULT FALSE,
+		anonymous BOOLEAN NOT NULL DEFAULT FALSE,
+		role TEXT NOT NULL DEFAULT '',
+		deadline TIMESTAMPTZ,
+		closed_at TIMESTAMPTZ,
+		created_by BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX idx_polls_team ON polls(team_id, closed_at);
+	CREATE TABLE poll_options (
+		poll_id BIGINT NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		text TEXT NOT NULL,
+		PRIMARY KEY (poll_id, position)
+	);
+	CREATE TABLE poll_votes (
+		poll_id BIGINT NOT NULL,
+		position INTEGER NOT NULL,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		voted_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (poll_id, user_id, position),
+		FOREIGN KEY (poll_id, position) REFERENCES poll_options(poll_id, position) ON DELETE CASCADE
+	);
+	CREATE TABLE poll_messages (
+		poll_id BIGINT NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+		chat_id BIGINT NOT NULL,
+		message_id BIGINT NOT NULL,
+		language TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (chat_id, message_id)
+	)`,
+	// 14: отметки о посещении событий
+	`CREATE TABLE event_attendance (
+		event_id BIGINT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		status TEXT NOT NULL,
+		self_check_in BOOLEAN NOT NULL DEFAULT FALSE,
+		marked_by BIGINT NOT NULL DEFAULT 0,
+		marked_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (event_id, user_id)
+	)`,
+	// 15: графики дежурств с очередью участников, дежурства и запросы на обмен
+	`CREATE TABLE duty_rosters (
+		id BIGSERIAL PRIMARY KEY,
+		team_id BIGINT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		period TEXT NOT NULL,
+		start_date DATE NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		assigned_through DATE,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_by BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL,
+		UNIQUE (team_id, name)
+	);
+	CREATE TABLE duty_roster_members (
+		roster_id BIGINT NOT NULL REFERENCES duty_rosters(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		PRIMARY KEY (roster_id, position)
+	);
+	CREATE TABLE duty_assignments (
+		id BIGSERIAL PRIMARY KEY,
+		roster_id BIGINT NOT NULL REFERENCES duty_rosters(id) ON DELETE CASCADE,
+		team_id BIGINT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		period DATE NOT NULL,
+		until DATE NOT NULL,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		completed_at TIMESTAMPTZ,
+		completed_by BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL,
+		UNIQUE (roster_id, period)
+	);
+	CREATE INDEX idx_duty_assignments_team ON duty_assignments(team_id, period);
+	CREATE TABLE duty_swaps (
+		id BIGSERIAL PRIMARY KEY,
+		assignment_id BIGINT NOT NULL REFERENCES duty_assignments(id) ON DELETE CASCADE,
+		from_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		to_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		status TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		resolved_at TIMESTAMPTZ
+	);
+	CREATE INDEX idx_duty_swaps_assignment ON duty_swaps(assignment_id, status);
+	INSERT INTO role_permissions (role, permission)
+		SELECT name, 'duties.manage' FROM roles WHERE name = 'coordinator'`,
+	// 16: объявления команд и отметки получателей о прочтении
+	`CREATE TABLE announcements (
+		id BIGSERIAL PRIMARY KEY,
+		team_id BIGINT NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		text TEXT NOT NULL,
+		chat_id BIGINT NOT NULL DEFAULT 0,
+		message_id INTEGER NOT NULL DEFAULT 0,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_by BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL,
+		closed_at TIMESTAMPTZ
+	);
+	CREATE INDEX idx_announcements_team ON announcements(team_id, active);
+	CREATE TABLE announcement_receipts (
+		announcement_id BIGINT NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		acknowledged_at TIMESTAMPTZ,
+		reminders INTEGER NOT NULL DEFAULT 0,
+		reminded_at TIMESTAMPTZ,
+		PRIMARY KEY (announcement_id, user_id)
+	);
+	INSERT INTO role_permissions (role, permission)
+		SELECT name, 'announcements.manage' FROM roles WHERE name = 'coordinator'`,
+	// 17: обращения в поддержку и переписка по ним
+	`CREATE TABLE tickets (
+		id BIGSERIAL PRIMARY KEY,
+		user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		text TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'open',
+		assignee_id BIGINT NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		closed_at TIMESTAMPTZ
+	);
+	CREATE INDEX idx_tickets_user ON tickets(user_id);
+	CREATE INDEX idx_tickets_status ON tickets(status);
+	CREATE TABLE ticket_messages (
+		id BIGSERIAL PRIMARY KEY,
+		ticket_id BIGINT NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+		author_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		staff BOOLEAN NOT NULL DEFAULT FALSE,
+		text TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX idx_ticket_messages_ticket ON ticket_messages(ticket_id)`,
+	// 18: группа Telegram команды для объявлений и опросов
+	`ALTER TABLE teams ADD COLUMN group_chat_id BIGINT NOT NULL DEFAULT 0`,
+	// 19: период последнего принятого кода 2FA, чтобы один код нельзя было использовать повторно
+	`ALTER TABLE user_totp ADD COLUMN last_counter BIGINT NOT NULL DEFAULT 0`,
+}
+
+// NewDB создает новое подключение к базе данных PostgreSQL
+func NewDB(dsn string, logger *slog.Logger) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := migrate(db, logger); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// migrate применяет к базе данных еще не примененные миграции. Все миграции
+// выполняются в одной транзакции под advisory-блокировкой
+func migrate(db *sql.DB, logger *slog.Logger) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var current int
+	if err := tx.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return err
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", version, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", version, err)
+		}
+		logger.Info("Applied migration", "version", version, "backend", "postgres")
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,371 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// rosterColumns - колонки графика дежурств в порядке, ожидаемом scanRoster
+const rosterColumns = `id, team_id, name, period, to_char(start_date, 'YYYY-MM-DD'), position,
+	to_char(assigned_through, 'YYYY-MM-DD'), active, created_by, created_at`
+
+// assignmentColumns - колонки дежурства в порядке, ожидаемом scanAssignment, вместе
+// с названием графика и именем дежурного
+const assignmentColumns = `a.id, a.roster_id, r.name, a.team_id, to_char(a.period, 'YYYY-MM-DD'),
+	to_char(a.until, 'YYYY-MM-DD'), a.user_id, u.username,
+	a.completed_at, a.completed_by, a.created_at`
+
+// swapColumns - колонки запроса на обмен дежурством в порядке, ожидаемом scanSwap
+const swapColumns = `id, assignment_id, from_id, to_id, status, created_at, resolved_at`
+
+// DutyRepository реализует интерфейс domain.DutyRepository для PostgreSQL
+type DutyRepository struct {
+	db *sql.DB
+}
+
+// NewDutyRepository создает новый экземпляр DutyRepository
+func NewDutyRepository(db *sql.DB) *DutyRepository {
+	return &DutyRepository{
+		db: db,
+	}
+}
+
+// scanRoster считывает график дежурств из строки результата
+func scanRoster(row scanner) (*domain.DutyRoster, error) {
+	var roster domain.DutyRoster
+	var startDate, assignedThrough sql.NullString
+	err := row.Scan(&roster.ID, &roster.TeamID, &roster.Name, &roster.Period, &startDate, &roster.Position,
+		&assignedThrough, &roster.Active, &roster.CreatedBy, &roster.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if roster.StartDate, err = parseDate(startDate); err != nil {
+		return nil, fmt.Errorf("invalid start date of duty roster %d: %w", roster.ID, err)
+	}
+	if roster.AssignedThrough, err = parseDate(assignedThrough); err != nil {
+		return nil, fmt.Errorf("invalid assigned date of duty roster %d: %w", roster.ID, err)
+	}
+	return &roster, nil
+}
+
+// scanAssignment считывает дежурство из строки результата
+func scanAssignment(row scanner) (*domain.DutyAssignment, error) {
+	var assignment domain.DutyAssignment
+	var period, until sql.NullString
+	var completedAt sql.NullTime
+	err := row.Scan(&assignment.ID, &assignment.RosterID, &assignment.RosterName, &assignment.TeamID, &period, &until,
+		&assignment.UserID, &assignment.Username, &completedAt, &assignment.CompletedBy, &assignment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.Period, err = parseDate(period); err != nil {
+		return nil, fmt.Errorf("invalid period of duty %d: %w", assignment.ID, err)
+	}
+	if assignment.Until, err = parseDate(until); err != nil {
+		return nil, fmt.Errorf("invalid end of duty %d: %w", assignment.ID, err)
+	}
+	assignment.CompletedAt = completedAt.Time
+	return &assignment, nil
+}
+
+// scanSwap считывает запрос на обмен дежурством из строки результата
+func scanSwap(row scanner) (*domain.DutySwap, error) {
+	var swap domain.DutySwap
+	var resolvedAt sql.NullTime
+	err := row.Scan(&swap.ID, &swap.AssignmentID, &swap.FromID, &swap.ToID, &swap.Status, &swap.CreatedAt, &resolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	swap.ResolvedAt = resolvedAt.Time
+	return &swap, nil
+}
+
+// SaveRoster сохраняет новый график дежурств вместе с очередью участников
+func (r *DutyRepository) SaveRoster(roster *domain.DutyRoster) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := currentTime()
+	var id int64
+	err = tx.QueryRow(`
+		INSERT INTO duty_rosters (team_id, name, period, start_date, position, assigned_through, active, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`,
+		roster.TeamID, roster.Name, roster.Period, nullableDate(roster.StartDate), roster.Position,
+		nullableDate(roster.AssignedThrough), roster.Active, roster.CreatedBy, now).Scan(&id)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %q", domain.ErrDutyRosterExists, roster.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save duty roster: %w", err)
+	}
+
+	for position, userID := range roster.MemberIDs {
+		if _, err := tx.Exec("INSERT INTO duty_roster_members (roster_id, position, user_id) VALUES ($1, $2, $3)",
+			id, position, userID); err != nil {
+			return fmt.Errorf("failed to save duty roster member: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	roster.ID = id
+	roster.CreatedAt = now
+	return nil
+}
+
+// GetRoster возвращает график дежурств по его идентификатору
+func (r *DutyRepository) GetRoster(id int64) (*domain.DutyRoster, error) {
+	rosters, err := r.rosters("id = $1", id)
+	if err != nil || len(rosters) == 0 {
+		return nil, err
+	}
+	return rosters[0], nil
+}
+
+// GetRosters возвращает графики дежурств команды, упорядоченные по названию
+func (r *DutyRepository) GetRosters(teamID int64) ([]*domain.DutyRoster, error) {
+	return r.rosters("team_id = $1", teamID)
+}
+
+// GetActiveRosters возвращает действующие графики дежурств всех команд
+func (r *DutyRepository) GetActiveRosters() ([]*domain.DutyRoster, error) {
+	return r.rosters("active")
+}
+
+// rosters выбирает графики дежурств по условию вместе с очередью участников
+func (r *DutyRepository) rosters(where string, args ...any) ([]*domain.DutyRoster, error) {
+	rows, err := r.db.Query("SELECT "+rosterColumns+" FROM duty_rosters WHERE "+where+` ORDER BY name COLLATE "C", id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rosters []*domain.DutyRoster
+	for rows.Next() {
+		roster, err := scanRoster(rows)
+		if err != nil {
+			return nil, err
+		}
+		rosters = append(rosters, roster)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, roster := range rosters {
+		if err := r.members(roster); err != nil {
+			return nil, err
+		}
+	}
+	return rosters, nil
+}
+
+// members заполняет очередь участников графика дежурств
+func (r *DutyRepository) members(roster *domain.DutyRoster) error {
+	rows, err := r.db.Query(`
+		SELECT m.user_id, u.username FROM duty_roster_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.roster_id = $1
+		ORDER BY m.position`, roster.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var username string
+		if err := rows.Scan(&id, &username); err != nil {
+			return err
+		}
+		roster.MemberIDs = append(roster.MemberIDs, id)
+		roster.Members = append(roster.Members, username)
+	}
+	return rows.Err()
+}
+
+// SetActive включает или останавливает график дежурств
+func (r *DutyRepository) SetActive(id int64, active bool) error {
+	result, err := r.db.Exec("UPDATE duty_rosters SET active = $1 WHERE id = $2", active, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrDutyRosterNotFound
+	}
+	return nil
+}
+
+// Assign сохраняет дежурство и переносит очередь графика. Повторное назначение
+// на тот же период ничего не меняет
+func (r *DutyRepository) Assign(assignment *domain.DutyAssignment, position int) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	now := currentTime()
+	err = tx.QueryRow(`
+		INSERT INTO duty_assignments (roster_id, team_id, period, until, user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (roster_id, period) DO NOTHING
+		RETURNING id`,
+		assignment.RosterID, assignment.TeamID, nullableDate(assignment.Period), nullableDate(assignment.Until),
+		assignment.UserID, now).Scan(&assignment.ID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to save duty: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE duty_rosters SET position = $1, assigned_through = $2 WHERE id = $3",
+		position, nullableDate(assignment.Period), assignment.RosterID); err != nil {
+		return false, fmt.Errorf("failed to advance duty roster: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	assignment.CreatedAt = now
+	return true, nil
+}
+
+// GetAssignment возвращает дежурство по его идентификатору
+func (r *DutyRepository) GetAssignment(id int64) (*domain.DutyAssignment, error) {
+	assignments, err := r.assignments("a.id = $1", id)
+	if err != nil || len(assignments) == 0 {
+		return nil, err
+	}
+	return assignments[0], nil
+}
+
+// GetCurrent возвращает последние назначенные дежурства действующих графиков команды
+func (r *DutyRepository) GetCurrent(teamID int64) ([]*domain.DutyAssignment, error) {
+	return r.assignments("a.team_id = $1 AND r.active AND a.period = r.assigned_through", teamID)
+}
+
+// GetHistory возвращает дежурства команды, начавшиеся в периоде, от новых к старым
+func (r *DutyRepository) GetHistory(teamID int64, from, to time.Time) ([]*domain.DutyAssignment, error) {
+	return r.assignments("a.team_id = $1 AND a.period >= $2 AND a.period < $3",
+		teamID, nullableDate(from), nullableDate(to))
+}
+
+// assignments выбирает дежурства по условию от новых к старым
+func (r *DutyRepository) assignments(where string, args ...any) ([]*domain.DutyAssignment, error) {
+	rows, err := r.db.Query(`
+		SELECT `+assignmentColumns+` FROM duty_assignments a
+		JOIN duty_rosters r ON r.id = a.roster_id
+		JOIN users u ON u.id = a.user_id
+		WHERE `+where+`
+		ORDER BY a.period DESC, r.name COLLATE "C", a.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []*domain.DutyAssignment
+	for rows.Next() {
+		assignment, err := scanAssignment(rows)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, assignment)
+	}
+	return assignments, rows.Err()
+}
+
+// Complete отмечает дежурство выполненным
+func (r *DutyRepository) Complete(id int64, completedBy int64, at time.Time) error {
+	result, err := r.db.Exec("UPDATE duty_assignments SET completed_at = $1, completed_by = $2 WHERE id = $3 AND completed_at IS NULL",
+		at, completedBy, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrDutyDone
+	}
+	return nil
+}
+
+// SaveSwap сохраняет новый запрос на обмен дежурством
+func (r *DutyRepository) SaveSwap(swap *domain.DutySwap) error {
+	now := currentTime()
+	err := r.db.QueryRow(`
+		INSERT INTO duty_swaps (assignment_id, from_id, to_id, status, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		swap.AssignmentID, swap.FromID, swap.ToID, swap.Status, now).Scan(&swap.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save duty swap: %w", err)
+	}
+	swap.CreatedAt = now
+	return nil
+}
+
+// GetSwap возвращает запрос на обмен дежурством по его идентификатору
+func (r *DutyRepository) GetSwap(id int64) (*domain.DutySwap, error) {
+	swap, err := scanSwap(r.db.QueryRow("SELECT "+swapColumns+" FROM duty_swaps WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return swap, err
+}
+
+// GetPendingSwap возвращает ожидающий ответа запрос на обмен дежурством
+func (r *DutyRepository) GetPendingSwap(assignmentID int64) (*domain.DutySwap, error) {
+	swap, err := scanSwap(r.db.QueryRow("SELECT "+swapColumns+" FROM duty_swaps WHERE assignment_id = $1 AND status = $2 ORDER BY id LIMIT 1",
+		assignmentID, domain.DutySwapPending))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return swap, err
+}
+
+// ResolveSwap записывает ответ на запрос обмена и при согласии передает дежурство
+func (r *DutyRepository) ResolveSwap(swap *domain.DutySwap) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := currentTime()
+	result, err := tx.Exec("UPDATE duty_swaps SET status = $1, resolved_at = $2 WHERE id = $3 AND status = $4",
+		swap.Status, now, swap.ID, domain.DutySwapPending)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrDutySwapResolved
+	}
+	if swap.Status == domain.DutySwapAccepted {
+		if _, err := tx.Exec("UPDATE duty_assignments SET user_id = $1 WHERE id = $2", swap.ToID, swap.AssignmentID); err != nil {
+			return fmt.Errorf("failed to reassign duty: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	swap.ResolvedAt = now
+	return nil
+}
@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"HelpBot/internal/domain"
+)
+
+// ExpenseRepository реализует интерфейс domain.ExpenseRepository для PostgreSQL
+type ExpenseRepository struct {
+	db *sql.DB
+}
+
+// NewExpenseRepository создает новый экземпляр ExpenseRepository
+func NewExpenseRepository(db *sql.DB) *ExpenseRepository {
+	return &ExpenseRepository{
+		db: db,
+	}
+}
+
+// Save сохраняет расход вместе с долями участников и операциями по их балансам
+func (r *ExpenseRepository) Save(expense *domain.Expense, entries []*domain.BalanceEntry) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := currentTime()
+	var id int64
+	err = tx.QueryRow(`
+		INSERT INTO expenses (team_id, payer_id, amount, description, mode, settlement, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id`,
+		expense.TeamID, expense.PayerID, expense.Amount, expense.Description, expense.Mode,
+		expense.Settlement, expense.CreatedBy, now).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("failed to save expense: %w", err)
+	}
+
+	for _, share := range expense.Shares {
+		if _, err := tx.Exec("INSERT INTO expense_shares (expense_id, user_id, value, amount) VALUES ($1, $2, $3, $4)",
+			id, share.UserID, share.Value, share.Amount); err != nil {
+			return fmt.Errorf("failed to save expense share: %w", err)
+		}
+	}
+	for _, entry := range entries {
+		err := tx.QueryRow(`
+			INSERT INTO balance_entries (team_id, user_id, kind, amount, expense_id, note, created_by, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id`,
+			entry.TeamID, entry.UserID, entry.Kind, entry.Amount, id, entry.Note, entry.CreatedBy, now).Scan(&entry.ID)
+		if err != nil {
+			return fmt.Errorf("failed to add balance entry: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	expense.ID = id
+	expense.CreatedAt = now
+	for _, entry := range entries {
+		entry.ExpenseID = id
+		entry.CreatedAt = now
+	}
+	return nil
+}
+
+// GetExpenses возвращает последние расходы команды, начиная с новых
+func (r *ExpenseRepository) GetExpenses(teamID int64, limit int) ([]*domain.Expense, error) {
+	rows, err := r.db.Query(`
+		SELECT e.id, e.team_id, e.payer_id, u.username, e.amount, e.description, e.mode, e.settlement, e.created_by, e.created_at
+		FROM expenses e
+		JOIN users u ON u.id = e.payer_id
+		WHERE e.team_id = $1
+		ORDER BY e.created_at DESC, e.id DESC
+		LIMIT $2`, teamID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expenses []*domain.Expense
+	for rows.Next() {
+		var expense domain.Expense
+		if err := rows.Scan(&expense.ID, &expense.TeamID, &expense.PayerID, &expense.PayerName, &expense.Amount,
+			&expense.Description, &expense.Mode, &expense.Settlement, &expense.CreatedBy, &expense.CreatedAt); err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, &expense)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, expense := range expenses {
+		if expense.Shares, err = r.shares(expense.ID); err != nil {
+			return nil, err
+		}
+	}
+	return expenses, nil
+}
+
+// shares возвращает доли участников расхода, упорядоченные по имени
+func (r *ExpenseRepository) shares(expenseID int64) ([]domain.ExpenseShare, error) {
+	rows, err := r.db.Query(`
+		SELECT s.user_id, u.username, s.value, s.amount
+		FROM expense_shares s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.expense_id = $1
+		ORDER BY u.username COLLATE "C"`, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shares []domain.ExpenseShare
+	for rows.Next() {
+		var share domain.ExpenseShare
+		if err := rows.Scan(&share.UserID, &share.Username, &share.Value, &share.Amount); err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+// GetNetBalances возвращает ненулевые балансы участников команды по расходам и переводам
+func (r *ExpenseRepository) GetNetBalances(teamID int64) (map[int64]int64, error) {
+	rows, err := r.db.Query(`
+		SELECT user_id, SUM(amount)::BIGINT
+		FROM balance_entries
+		WHERE team_id = $1 AND expense_id IS NOT NULL
+		GROUP BY user_id
+		HAVING SUM(amount) <> 0`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := make(map[int64]int64)
+	for rows.Next() {
+		var userID, balance int64
+		if err := rows.Scan(&userID, &balance); err != nil {
+			return nil, err
+		}
+		balances[userID] = balance
+	}
+	return balances, rows.Err()
+}
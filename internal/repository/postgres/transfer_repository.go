@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// transferColumns содержит список колонок, выбираемых для запроса на перенос
+const transferColumns = `id, user_id, from_telegram_id, to_telegram_id, to_chat_id, status, resolved_by, created_at, resolved_at`
+
+// TransferRepository реализует интерфейс domain.TransferRepository для PostgreSQL
+type TransferRepository struct {
+	db *sql.DB
+}
+
+// NewTransferRepository создает новый экземпляр TransferRepository
+func NewTransferRepository(db *sql.DB) *TransferRepository {
+	return &TransferRepository{
+		db: db,
+	}
+}
+
+// scanTransfer считывает запрос на перенос из строки результата
+func scanTransfer(row scanner) (*domain.AccountTransfer, error) {
+	var transfer domain.AccountTransfer
+	var resolvedAt sql.NullTime
+	err := row.Scan(
+		&transfer.ID,
+		&transfer.UserID,
+		&transfer.FromTelegramID,
+		&transfer.ToTelegramID,
+		&transfer.ToChatID,
+		&transfer.Status,
+		&transfer.ResolvedBy,
+		&transfer.CreatedAt,
+		&resolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if resolvedAt.Valid {
+		transfer.ResolvedAt = &resolvedAt.Time
+	}
+	return &transfer, nil
+}
+
+// Save сохраняет новый запрос на перенос
+func (r *TransferRepository) Save(transfer *domain.AccountTransfer) error {
+	if transfer.Status == "" {
+		transfer.Status = domain.TransferStatusPending
+	}
+	transfer.CreatedAt = time.Now()
+
+	err := r.db.QueryRow(`
+		INSERT INTO account_transfers (user_id, from_telegram_id, to_telegram_id, to_chat_id, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		transfer.UserID,
+		transfer.FromTelegramID,
+		transfer.ToTelegramID,
+		transfer.ToChatID,
+		transfer.Status,
+		transfer.CreatedAt,
+	).Scan(&transfer.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save transfer: %w", err)
+	}
+	return nil
+}
+
+// GetByID возвращает запрос на перенос по его идентификатору
+func (r *TransferRepository) GetByID(id int64) (*domain.AccountTransfer, error) {
+	transfer, err := scanTransfer(r.db.QueryRow("SELECT "+transferColumns+" FROM account_transfers WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// GetPendingByUser возвращает ожидающий запрос на перенос аккаунта на указанный Telegram-аккаунт
+func (r *TransferRepository) GetPendingByUser(userID int64, toTelegramID int64) (*domain.AccountTransfer, error) {
+	transfer, err := scanTransfer(r.db.QueryRow(`
+		SELECT `+transferColumns+` FROM account_transfers
+		WHERE user_id = $1 AND to_telegram_id = $2 AND status = $3
+		ORDER BY id DESC LIMIT 1`,
+		userID, toTelegramID, domain.TransferStatusPending,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// GetPending возвращает все ожидающие запросы на перенос
+func (r *TransferRepository) GetPending() ([]*domain.AccountTransfer, error) {
+	rows, err := r.db.Query("SELECT "+transferColumns+" FROM account_transfers WHERE status = $1 ORDER BY id", domain.TransferStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []*domain.AccountTransfer
+	for rows.Next() {
+		transfer, err := scanTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+// UpdateStatus обновляет статус запроса на перенос
+func (r *TransferRepository) UpdateStatus(id int64, status string, resolvedBy int64) error {
+	_, err := r.db.Exec(`
+		UPDATE account_transfers SET status = $1, resolved_by = $2, resolved_at = $3
+		WHERE id = $4
+	`, status, resolvedBy, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update transfer: %w", err)
+	}
+	return nil
+}
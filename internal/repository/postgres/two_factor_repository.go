@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// TwoFactorRepository реализует интерфейс domain.TwoFactorRepository для PostgreSQL
+type TwoFactorRepository struct {
+	db *sql.DB
+}
+
+// NewTwoFactorRepository создает новый экземпляр TwoFactorRepository
+func NewTwoFactorRepository(db *sql.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{
+		db: db,
+	}
+}
+
+// Get возвращает настройки 2FA пользователя
+func (r *TwoFactorRepository) Get(userID int64) (*domain.TwoFactor, error) {
+	var tf domain.TwoFactor
+	var enabledAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT user_id, secret, enabled, last_counter, created_at, enabled_at
+		FROM user_totp
+		WHERE user_id = $1`, userID).Scan(
+		&tf.UserID,
+		&tf.Secret,
+		&tf.Enabled,
+		&tf.LastCounter,
+		&tf.CreatedAt,
+		&enabledAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if enabledAt.Valid {
+		tf.EnabledAt = &enabledAt.Time
+	}
+	return &tf, nil
+}
+
+// SaveSecret сохраняет новый неподтвержденный секрет пользователя
+func (r *TwoFactorRepository) SaveSecret(userID int64, secret string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO user_totp (user_id, secret, enabled, created_at)
+		VALUES ($1, $2, FALSE, $3)
+		ON CONFLICT (user_id) DO UPDATE SET secret = excluded.secret, enabled = FALSE, created_at = excluded.created_at, enabled_at = NULL,
+			last_counter = 0`,
+		userID, secret, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save totp secret: %w", err)
+	}
+	return nil
+}
+
+// Enable включает 2FA пользователя
+func (r *TwoFactorRepository) Enable(userID int64) error {
+	_, err := r.db.Exec("UPDATE user_totp SET enabled = TRUE, enabled_at = $1 WHERE user_id = $2", time.Now(), userID)
+	return err
+}
+
+// UseCounter запоминает период принятого кода, если он больше последнего запомненного.
+// Проверка и запись выполняются одним запросом, поэтому одновременные входы с одним
+// кодом не пройдут оба
+func (r *TwoFactorRepository) UseCounter(userID int64, counter int64) (bool, error) {
+	result, err := r.db.Exec("UPDATE user_totp SET last_counter = $1 WHERE user_id = $2 AND last_counter < $3", counter, userID, counter)
+	if err != nil {
+		return false, fmt.Errorf("failed to save totp counter: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// Delete удаляет настройки 2FA и коды восстановления пользователя
+func (r *TwoFactorRepository) Delete(userID int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM user_totp WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes заменяет коды восстановления пользователя
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(userID int64, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("failed to clear recovery codes: %w", err)
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec("INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, hash); err != nil {
+			return fmt.Errorf("failed to save recovery code: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetUnusedRecoveryCodes возвращает неиспользованные коды восстановления
+func (r *TwoFactorRepository) GetUnusedRecoveryCodes(userID int64) ([]*domain.RecoveryCode, error) {
+	rows, err := r.db.Query("SELECT id, user_id, code_hash FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []*domain.RecoveryCode
+	for rows.Next() {
+		code := &domain.RecoveryCode{}
+		if err := rows.Scan(&code.ID, &code.UserID, &code.CodeHash); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// UseRecoveryCode помечает код восстановления использованным
+func (r *TwoFactorRepository) UseRecoveryCode(id int64) error {
+	result, err := r.db.Exec("UPDATE recovery_codes SET used_at = $1 WHERE id = $2 AND used_at IS NULL", time.Now(), id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("recovery code %d already used", id)
+	}
+	return nil
+}
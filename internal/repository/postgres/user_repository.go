@@ -0,0 +1,418 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"HelpBot/internal/domain"
+)
+
+// uniqueViolation - код ошибки PostgreSQL при нарушении ограничения уникальности
+const uniqueViolation = "23505"
+
+// userColumns содержит список колонок, выбираемых для пользователя
+const userColumns = `id, telegram_id, chat_id, username, password, role, position, birthday, number, language, created_at, updated_at,
+	status, status_reason, status_changed_at, purged_at, sessions_revoked_at, active_team_id`
+
+// publicUserColumns содержит те же колонки, но без хеша пароля
+const publicUserColumns = `id, telegram_id, chat_id, username, '', role, position, birthday, number, language, created_at, updated_at,
+	status, status_reason, status_changed_at, purged_at, sessions_revoked_at, active_team_id`
+
+// scanner описывает общий метод Scan для sql.Row и sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// UserRepository реализует интерфейс domain.UserRepository для PostgreSQL
+type UserRepository struct {
+	db *sql.DB
+}
+
+// NewUserRepository создает новый экземпляр UserRepository
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{
+		db: db,
+	}
+}
+
+// nullableID преобразует нулевой идентификатор в NULL
+func nullableID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
+// userError приводит ошибку нарушения уникальности к domain.ErrUserExists
+func userError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: %v", domain.ErrUserExists, err)
+	}
+	return err
+}
+
+// userAffected проверяет результат изменения пользователя: ошибки уникальности
+// приводятся к domain.ErrUserExists, отсутствие измененных строк - к domain.ErrUserNotFound
+func userAffected(result sql.Result, err error) error {
+	if err != nil {
+		return userError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// currentTime возвращает текущее время с точностью хранения PostgreSQL (микросекунды),
+// чтобы время в структуре совпадало с сохраненным
+func currentTime() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// scanUser считывает пользователя из строки результата. Значения дополнительных
+// колонок после колонок пользователя считываются в extra
+func scanUser(row scanner, extra ...any) (*domain.User, error) {
+	var user domain.User
+	var telegramID, activeTeamID sql.NullInt64
+	var statusChangedAt, purgedAt, sessionsRevokedAt sql.NullTime
+	dest := []any{
+		&user.ID,
+		&telegramID,
+		&user.ChatID,
+		&user.Username,
+		&user.Password,
+		&user.Role,
+		&user.Position,
+		&user.Birthday,
+		&user.Number,
+		&user.Language,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.Status,
+		&user.StatusReason,
+		&statusChangedAt,
+		&purgedAt,
+		&sessionsRevokedAt,
+		&activeTeamID,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	user.TelegramID = telegramID.Int64
+	user.ActiveTeamID = activeTeamID.Int64
+	user.StatusChangedAt = statusChangedAt.Time
+	user.PurgedAt = purgedAt.Time
+	user.SessionsRevokedAt = sessionsRevokedAt.Time
+	return &user, nil
+}
+
+// getOne возвращает пользователя по условию или nil, если он не найден
+func (r *UserRepository) getOne(where string, args ...any) (*domain.User, error) {
+	user, err := scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE "+where, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetByID возвращает пользователя по его идентификатору
+func (r *UserRepository) GetByID(id int64) (*domain.User, error) {
+	return r.getOne("id = $1", id)
+}
+
+// GetByTelegramID возвращает пользователя, привязанного к Telegram-аккаунту
+func (r *UserRepository) GetByTelegramID(telegramID int64) (*domain.User, error) {
+	return r.getOne("telegram_id = $1", telegramID)
+}
+
+// GetByUsername возвращает пользователя по его имени пользователя
+func (r *UserRepository) GetByUsername(username string) (*domain.User, error) {
+	return r.getOne("username = $1", username)
+}
+
+// Save сохраняет нового пользователя
+func (r *UserRepository) Save(user *domain.User) error {
+	if user.ID != 0 {
+		return fmt.Errorf("failed to save user %d: %w", user.ID, domain.ErrUserExists)
+	}
+	now := currentTime()
+	if user.Status == "" {
+		user.Status = domain.UserActive
+	}
+
+	err := r.db.QueryRow(`
+		INSERT INTO users (telegram_id, chat_id, username, password, role, position, birthday, number, language, created_at, updated_at, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id`,
+		nullableID(user.TelegramID),
+		user.ChatID,
+		user.Username,
+		user.Password,
+		user.Role,
+		user.Position,
+		user.Birthday,
+		user.Number,
+		user.Language,
+		now,
+		now,
+		user.Status,
+	).Scan(&user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save user: %w", userError(err))
+	}
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	return nil
+}
+
+// UpdateStatus изменяет состояние учетной записи пользователя и причину изменения
+func (r *UserRepository) UpdateStatus(id int64, status domain.UserStatus, reason string) error {
+	now := currentTime()
+	return userAffected(r.db.Exec(`
+		UPDATE users SET status = $1, status_reason = $2, status_changed_at = $3, updated_at = $3
+		WHERE id = $4
+	`, status, reason, now, id))
+}
+
+// AnonymizeDeleted обезличивает пользователей, удаленных раньше указанного времени:
+// удаляет персональные данные и пароль, освобождает имя и Telegram-аккаунт
+func (r *UserRepository) AnonymizeDeleted(before time.Time) ([]int64, error) {
+	rows, err := r.db.Query(`
+		UPDATE users
+		SET username = $1::text || id, password = '', telegram_id = NULL, chat_id = 0, position = '', birthday = '', number = '',
+			status_reason = '', purged_at = $2, updated_at = $2
+		WHERE status = $3 AND purged_at IS NULL AND status_changed_at < $4
+		RETURNING id`,
+		domain.AnonymizedPrefix, currentTime(), domain.UserDeleted, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to anonymize users: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// RevokeSessions завершает все сессии пользователя, выданные до текущего момента
+func (r *UserRepository) RevokeSessions(id int64) error {
+	return userAffected(r.db.Exec(`
+		UPDATE users SET sessions_revoked_at = $1, updated_at = $1
+		WHERE id = $2
+	`, currentTime(), id))
+}
+
+// SetActiveTeam выбирает активную команду пользователя
+func (r *UserRepository) SetActiveTeam(id int64, teamID int64) error {
+	return userAffected(r.db.Exec(`
+		UPDATE users SET active_team_id = $1, updated_at = $2
+		WHERE id = $3
+	`, nullableID(teamID), currentTime(), id))
+}
+
+// UpdatePassword обновляет пароль пользователя
+func (r *UserRepository) UpdatePassword(id int64, newPassword string) error {
+	return userAffected(r.db.Exec(`
+		UPDATE users SET password = $1, updated_at = $2
+		WHERE id = $3
+	`, newPassword, currentTime(), id))
+}
+
+// UpdateRole обновляет роль пользователя
+func (r *UserRepository) UpdateRole(id int64, newRole string) error {
+	return userAffected(r.db.Exec(`
+		UPDATE users SET role = $1, updated_at = $2
+		WHERE id = $3
+	`, newRole, currentTime(), id))
+}
+
+// BindTelegram привязывает пользователя к Telegram-аккаунту и чату доставки
+func (r *UserRepository) BindTelegram(id int64, telegramID int64, chatID int64) error {
+	err := userAffected(r.db.Exec(`
+		UPDATE users SET telegram_id = $1, chat_id = $2, updated_at = $3
+		WHERE id = $4
+	`, nullableID(telegramID), chatID, currentTime(), id))
+	if err != nil {
+		return fmt.Errorf("failed to bind user: %w", err)
+	}
+	return nil
+}
+
+// UpdateLanguage обновляет язык интерфейса пользователя
+func (r *UserRepository) UpdateLanguage(id int64, language string) error {
+	return userAffected(r.db.Exec(`
+		UPDATE users SET language = $1, updated_at = $2
+		WHERE id = $3
+	`, language, currentTime(), id))
+}
+
+// Find возвращает страницу пользователей, удовлетворяющих запросу
+func (r *UserRepository) Find(query domain.UserQuery) (*domain.UserPage, error) {
+	return findUsers(r.db, query, 0)
+}
+
+// findUsers выбирает страницу пользователей. Если teamID задан, выбираются только участники
+// команды: фильтр по роли относится к роли в команде, и она возвращается в User.TeamRole
+func findUsers(db *sql.DB, query domain.UserQuery, teamID int64) (*domain.UserPage, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	after, err := query.After()
+	if err != nil {
+		return nil, err
+	}
+
+	var conditions []string
+	var args []any
+
+	// arg добавляет значение параметра запроса и возвращает его номер
+	arg := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if len(query.Statuses) > 0 {
+		statuses := make([]string, len(query.Statuses))
+		for i, status := range query.Statuses {
+			statuses[i] = string(status)
+		}
+		conditions = append(conditions, "status = ANY("+arg(pq.Array(statuses))+")")
+	}
+	var team string
+	switch {
+	case teamID != 0 && query.Role != "":
+		team = arg(teamID)
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM team_members m WHERE m.team_id = "+team+" AND m.user_id = users.id AND m.role = "+arg(query.Role)+")")
+	case teamID != 0:
+		team = arg(teamID)
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM team_members m WHERE m.team_id = "+team+" AND m.user_id = users.id)")
+	case query.Role != "":
+		conditions = append(conditions, "role = "+arg(query.Role))
+	}
+	if query.Position != "" {
+		conditions = append(conditions, "position = "+arg(query.Position))
+	}
+	if query.NamePrefix != "" {
+		conditions = append(conditions, "starts_with(username, "+arg(query.NamePrefix)+")")
+	}
+	if !query.CreatedFrom.IsZero() {
+		conditions = append(conditions, "created_at >= "+arg(query.CreatedFrom))
+	}
+	if !query.CreatedTo.IsZero() {
+		conditions = append(conditions, "created_at < "+arg(query.CreatedTo))
+	}
+
+	// Имена сравниваются побайтно (COLLATE "C"), как в SQLite, независимо от локали базы.
+	// Страница начинается после курсора: сравниваем поле сортировки, при равенстве - идентификатор
+	column, order, cmp := string(query.Sort), "ASC", ">"
+	if query.Sort == domain.UserSortUsername {
+		column = `username COLLATE "C"`
+	}
+	if query.Desc {
+		order, cmp = "DESC", "<"
+	}
+	if after != nil {
+		var value any
+		switch query.Sort {
+		case domain.UserSortUsername:
+			value = after.Username
+		case domain.UserSortCreated:
+			value = after.CreatedAt
+		}
+		if value == nil {
+			conditions = append(conditions, "id "+cmp+" "+arg(after.ID))
+		} else {
+			v, id := arg(value), arg(after.ID)
+			conditions = append(conditions, fmt.Sprintf("(%[1]s %[2]s %[3]s OR (%[1]s = %[3]s AND id %[2]s %[4]s))", column, cmp, v, id))
+		}
+	}
+
+	columns := publicUserColumns
+	if query.WithSecrets {
+		columns = userColumns
+	}
+	if team != "" {
+		columns += ", (SELECT m.role FROM team_members m WHERE m.team_id = " + team + " AND m.user_id = users.id)"
+	}
+	statement := "SELECT " + columns + " FROM users"
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	statement += " ORDER BY " + column + " " + order
+	if query.Sort != domain.UserSortID {
+		statement += ", id " + order
+	}
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница
+	if query.Limit > 0 {
+		statement += " LIMIT " + arg(query.Limit+1)
+	}
+
+	rows, err := db.Query(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		var extra []any
+		var teamRole string
+		if teamID != 0 {
+			extra = append(extra, &teamRole)
+		}
+		user, err := scanUser(rows, extra...)
+		if err != nil {
+			return nil, err
+		}
+		user.TeamRole = teamRole
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return domain.NewUserPage(query, users), nil
+}
+
+// Update обновляет существующего пользователя
+func (r *UserRepository) Update(user *domain.User) error {
+	now := currentTime()
+	err := userAffected(r.db.Exec(`
+		UPDATE users
+		SET telegram_id = $1, chat_id = $2, username = $3, password = $4, role = $5, position = $6, birthday = $7, number = $8, language = $9, updated_at = $10
+		WHERE id = $11`,
+		nullableID(user.TelegramID),
+		user.ChatID,
+		user.Username,
+		user.Password,
+		user.Role,
+		user.Position,
+		user.Birthday,
+		user.Number,
+		user.Language,
+		now,
+		user.ID,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	user.UpdatedAt = now
+	return nil
+}
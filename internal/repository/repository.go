@@ -11,12 +11,42 @@ type UserRepository interface {
 
 // Repositories содержит все репозитории
 type Repositories struct {
-	UserRepository domain.UserRepository
+	UserRepository         domain.UserRepository
+	TransferRepository     domain.TransferRepository
+	RoleRepository         domain.RoleRepository
+	AuditRepository        domain.AuditRepository
+	TwoFactorRepository    domain.TwoFactorRepository
+	InviteRepository       domain.InviteRepository
+	TeamRepository         domain.TeamRepository
+	DuesRepository         domain.DuesRepository
+	BalanceRepository      domain.BalanceRepository
+	ExpenseRepository      domain.ExpenseRepository
+	EventRepository        domain.EventRepository
+	PollRepository         domain.PollRepository
+	AttendanceRepository   domain.AttendanceRepository
+	DutyRepository         domain.DutyRepository
+	AnnouncementRepository domain.AnnouncementRepository
+	TicketRepository       domain.TicketRepository
 }
 
 // NewRepositories создает новый экземпляр Repositories
-func NewRepositories(userRepo domain.UserRepository) *Repositories {
+func NewRepositories(userRepo domain.UserRepository, transferRepo domain.TransferRepository, roleRepo domain.RoleRepository, auditRepo domain.AuditRepository, twoFactorRepo domain.TwoFactorRepository, inviteRepo domain.InviteRepository, teamRepo domain.TeamRepository, duesRepo domain.DuesRepository, balanceRepo domain.BalanceRepository, expenseRepo domain.ExpenseRepository, eventRepo domain.EventRepository, pollRepo domain.PollRepository, attendanceRepo domain.AttendanceRepository, dutyRepo domain.DutyRepository, announcementRepo domain.AnnouncementRepository, ticketRepo domain.TicketRepository) *Repositories {
 	return &Repositories{
-		UserRepository: userRepo,
+		UserRepository:         userRepo,
+		TransferRepository:     transferRepo,
+		RoleRepository:         roleRepo,
+		AuditRepository:        auditRepo,
+		TwoFactorRepository:    twoFactorRepo,
+		InviteRepository:       inviteRepo,
+		TeamRepository:         teamRepo,
+		DuesRepository:         duesRepo,
+		BalanceRepository:      balanceRepo,
+		ExpenseRepository:      expenseRepo,
+		EventRepository:        eventRepo,
+		PollRepository:         pollRepo,
+		AttendanceRepository:   attendanceRepo,
+		DutyRepository:         dutyRepo,
+		AnnouncementRepository: announcementRepo,
+		TicketRepository:       ticketRepo,
 	}
 }
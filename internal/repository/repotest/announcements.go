@@ -0,0 +1,138 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// testAnnouncements проверяет domain.AnnouncementRepository
+func testAnnouncements(t *testing.T, newRepos Factory) {
+	t.Run("Announcements", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.AnnouncementRepository
+		juniors := &domain.Team{Name: "Juniors"}
+		adults := &domain.Team{Name: "Adults"}
+		must(t, repos.TeamRepository.Save(juniors))
+		must(t, repos.TeamRepository.Save(adults))
+		alice := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		bob := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+
+		if announcement, err := repo.GetByID(1); err != nil || announcement != nil {
+			t.Fatalf("GetByID(missing) = %v, %v", announcement, err)
+		}
+
+		first := &domain.Announcement{TeamID: juniors.ID, Text: "Training moved", Active: true, CreatedBy: alice.ID}
+		must(t, repo.Save(first, []int64{alice.ID, bob.ID}))
+		if first.ID == 0 || first.CreatedAt.IsZero() || first.Recipients != 2 {
+			t.Fatalf("Save did not fill ID, CreatedAt and Recipients: %+v", first)
+		}
+		second := &domain.Announcement{TeamID: juniors.ID, Text: "New kit", Active: true}
+		must(t, repo.Save(second, []int64{bob.ID}))
+		must(t, repo.Save(&domain.Announcement{TeamID: adults.ID, Text: "Other team", Active: true}, nil))
+
+		must(t, repo.SetMessage(first.ID, -100, 42))
+		stored, err := repo.GetByID(first.ID)
+		must(t, err)
+		if stored == nil || stored.Text != "Training moved" || stored.ChatID != -100 || stored.MessageID != 42 ||
+			!stored.Active || stored.CreatedBy != alice.ID || stored.Recipients != 2 || stored.Acknowledged != 0 {
+			t.Fatalf("GetByID = %+v", stored)
+		}
+		assertTime(t, "CreatedAt", stored.CreatedAt, first.CreatedAt)
+
+		active, err := repo.GetActive(juniors.ID)
+		must(t, err)
+		if len(active) != 2 || active[0].ID != second.ID || active[1].ID != first.ID {
+			t.Errorf("GetActive = %v, want both announcements from new to old", active)
+		}
+
+		at := time.Now().Truncate(time.Second)
+		must(t, repo.Close(second.ID, at))
+		if err := repo.Close(second.ID, at); !errors.Is(err, domain.ErrAnnouncementClosed) {
+			t.Errorf("repeated Close = %v, want ErrAnnouncementClosed", err)
+		}
+		closed, err := repo.GetByID(second.ID)
+		must(t, err)
+		if closed.Active {
+			t.Error("announcement is still active after Close")
+		}
+		assertTime(t, "ClosedAt", closed.ClosedAt, at)
+		if active, err := repo.GetActive(juniors.ID); err != nil || len(active) != 1 {
+			t.Errorf("GetActive after Close = %v, %v, want one announcement", active, err)
+		}
+	})
+
+	t.Run("Receipts", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.AnnouncementRepository
+		team := &domain.Team{Name: "Juniors"}
+		must(t, repos.TeamRepository.Save(team))
+		alice := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		bob := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+		carol := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "carol", Role: "user"})
+
+		announcement := &domain.Announcement{TeamID: team.ID, Text: "Training moved", Active: true}
+		must(t, repo.Save(announcement, []int64{bob.ID, alice.ID}))
+
+		if receipt, err := repo.GetReceipt(announcement.ID, carol.ID); err != nil || receipt != nil {
+			t.Fatalf("GetReceipt of a non-recipient = %v, %v", receipt, err)
+		}
+		at := time.Now().Truncate(time.Second)
+		if added, err := repo.Acknowledge(announcement.ID, alice.ID, at); err != nil || !added {
+			t.Fatalf("Acknowledge = %v, %v", added, err)
+		}
+		if added, err := repo.Acknowledge(announcement.ID, alice.ID, at.Add(time.Hour)); err != nil || added {
+			t.Errorf("repeated Acknowledge = %v, %v, want already acknowledged", added, err)
+		}
+		if added, err := repo.Acknowledge(announcement.ID, carol.ID, at); err != nil || added {
+			t.Errorf("Acknowledge by a non-recipient = %v, %v, want nothing", added, err)
+		}
+
+		receipt, err := repo.GetReceipt(announcement.ID, alice.ID)
+		must(t, err)
+		if receipt == nil || receipt.Username != "alice" || !receipt.Acknowledged() {
+			t.Fatalf("GetReceipt = %+v", receipt)
+		}
+		assertTime(t, "AcknowledgedAt", receipt.AcknowledgedAt, at)
+
+		receipts, err := repo.GetReceipts(announcement.ID)
+		must(t, err)
+		if len(receipts) != 2 || receipts[0].Username != "alice" || receipts[1].Username != "bob" || receipts[1].Acknowledged() {
+			t.Errorf("GetReceipts = %v, want alice acknowledged and bob not", receipts)
+		}
+		if stored, err := repo.GetByID(announcement.ID); err != nil || stored.Acknowledged != 1 || stored.Recipients != 2 {
+			t.Errorf("progress = %+v, %v, want 1 of 2", stored, err)
+		}
+
+		// Напоминания получают только непрочитавшие, не чаще интервала и не больше maxReminders раз
+		if due, err := repo.GetUnacknowledged(announcement.CreatedAt.Add(-time.Minute), 2); err != nil || len(due) != 0 {
+			t.Errorf("GetUnacknowledged before the announcement = %v, %v, want none", due, err)
+		}
+		now := announcement.CreatedAt.Add(time.Hour)
+		due, err := repo.GetUnacknowledged(now, 2)
+		must(t, err)
+		if len(due) != 1 || due[0].UserID != bob.ID {
+			t.Fatalf("GetUnacknowledged = %v, want bob", due)
+		}
+		must(t, repo.MarkReminded(announcement.ID, bob.ID, now))
+		if due, err := repo.GetUnacknowledged(now.Add(-time.Minute), 2); err != nil || len(due) != 0 {
+			t.Errorf("GetUnacknowledged right after a reminder = %v, %v, want none", due, err)
+		}
+		if due, err := repo.GetUnacknowledged(now.Add(time.Hour), 2); err != nil || len(due) != 1 || due[0].Reminders != 1 {
+			t.Errorf("GetUnacknowledged an hour later = %v, %v, want bob once reminded", due, err)
+		}
+		must(t, repo.MarkReminded(announcement.ID, bob.ID, now.Add(time.Hour)))
+		if due, err := repo.GetUnacknowledged(now.Add(24*time.Hour), 2); err != nil || len(due) != 0 {
+			t.Errorf("GetUnacknowledged after the last reminder = %v, %v, want none", due, err)
+		}
+
+		other := &domain.Announcement{TeamID: team.ID, Text: "New kit", Active: true}
+		must(t, repo.Save(other, []int64{carol.ID}))
+		must(t, repo.Close(other.ID, time.Now()))
+		if due, err := repo.GetUnacknowledged(time.Now().Add(time.Hour), 2); err != nil || len(due) != 0 {
+			t.Errorf("GetUnacknowledged of a closed announcement = %v, %v, want none", due, err)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// testAttendance проверяет domain.AttendanceRepository
+func testAttendance(t *testing.T, newRepos Factory) {
+	t.Run("Attendance", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.AttendanceRepository
+		team := &domain.Team{Name: "Juniors"}
+		other := &domain.Team{Name: "Adults"}
+		must(t, repos.TeamRepository.Save(team))
+		must(t, repos.TeamRepository.Save(other))
+
+		now := time.Now().Truncate(time.Minute)
+		first := &domain.Event{TeamID: team.ID, Title: "First", StartsAt: now.Add(-48 * time.Hour)}
+		second := &domain.Event{TeamID: team.ID, Title: "Second", StartsAt: now.Add(-24 * time.Hour)}
+		unmarked := &domain.Event{TeamID: team.ID, Title: "Unmarked", StartsAt: now.Add(-12 * time.Hour)}
+		canceled := &domain.Event{TeamID: team.ID, Title: "Canceled", StartsAt: now.Add(-6 * time.Hour)}
+		foreign := &domain.Event{TeamID: other.ID, Title: "Foreign", StartsAt: now.Add(-time.Hour)}
+		for _, event := range []*domain.Event{first, second, unmarked, canceled, foreign} {
+			must(t, repos.EventRepository.Save(event))
+		}
+
+		alice := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		bob := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+		marks := []*domain.Attendance{
+			{EventID: first.ID, UserID: bob.ID, Status: domain.AttendancePresent, MarkedBy: alice.ID},
+			{EventID: first.ID, UserID: alice.ID, Status: domain.AttendanceAbsent, MarkedBy: alice.ID},
+			{EventID: second.ID, UserID: alice.ID, Status: domain.AttendancePresent, SelfCheckIn: true, MarkedBy: alice.ID},
+			{EventID: canceled.ID, UserID: alice.ID, Status: domain.AttendancePresent, MarkedBy: alice.ID},
+			{EventID: foreign.ID, UserID: alice.ID, Status: domain.AttendancePresent, MarkedBy: alice.ID},
+		}
+		for _, mark := range marks {
+			must(t, repo.Mark(mark))
+			if mark.MarkedAt.IsZero() {
+				t.Fatalf("Mark did not fill MarkedAt: %+v", mark)
+			}
+		}
+		must(t, repos.EventRepository.Cancel(canceled.ID))
+
+		// Повторная отметка заменяет прежнюю
+		must(t, repo.Mark(&domain.Attendance{EventID: first.ID, UserID: alice.ID, Status: domain.AttendancePresent, SelfCheckIn: true, MarkedBy: alice.ID}))
+		got, err := repo.GetByEvent(first.ID)
+		must(t, err)
+		if len(got) != 2 || got[0].Username != "alice" || got[0].Status != domain.AttendancePresent || !got[0].SelfCheckIn ||
+			got[1].Username != "bob" || got[1].MarkedBy != alice.ID {
+			t.Errorf("GetByEvent = %+v", got)
+		}
+
+		tracked, err := repo.GetTracked(team.ID, now.Add(-72*time.Hour), now)
+		must(t, err)
+		if len(tracked) != 2 || tracked[0].ID != first.ID || tracked[1].ID != second.ID {
+			t.Errorf("GetTracked = %v, want the first and the second event", tracked)
+		}
+		if tracked, err := repo.GetTracked(team.ID, now.Add(-36*time.Hour), now); err != nil || len(tracked) != 1 || tracked[0].ID != second.ID {
+			t.Errorf("GetTracked(last 36h) = %v, %v, want the second event", tracked, err)
+		}
+
+		all, err := repo.GetByTeam(team.ID, now.Add(-72*time.Hour), now)
+		must(t, err)
+		if len(all) != 3 {
+			t.Errorf("GetByTeam = %+v, want 3 marks of the team without the canceled event", all)
+		}
+		for _, mark := range all {
+			if mark.EventID != first.ID && mark.EventID != second.ID {
+				t.Errorf("GetByTeam returned a mark of event %d", mark.EventID)
+			}
+		}
+	})
+}
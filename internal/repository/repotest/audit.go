@@ -0,0 +1,75 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// testAudit проверяет domain.AuditRepository
+func testAudit(t *testing.T, newRepos Factory) {
+	t.Run("AppendAndFind", func(t *testing.T) {
+		repo := newRepos(t).AuditRepository
+		base := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+		entries := []*domain.AuditEntry{
+			{ActorID: 1, TelegramID: 100, Action: domain.AuditLoginSuccess, CreatedAt: base},
+			{ActorID: 1, TargetID: 2, Action: domain.AuditRoleChange, Details: "user -> admin", CreatedAt: base.Add(10 * time.Minute)},
+			{ActorID: 3, Action: domain.AuditLoginSuccess, CreatedAt: base.Add(20 * time.Minute)},
+		}
+		for _, entry := range entries {
+			must(t, repo.Append(entry))
+			if entry.ID == 0 {
+				t.Fatal("Append did not assign an ID")
+			}
+		}
+
+		all, err := repo.Find(domain.AuditFilter{})
+		must(t, err)
+		if len(all) != 3 || all[0].ID != entries[2].ID || all[2].ID != entries[0].ID {
+			t.Fatalf("Find(all) returned %d entries, want 3 newest first", len(all))
+		}
+		got := all[1]
+		if got.ActorID != 1 || got.TargetID != 2 || got.Action != domain.AuditRoleChange || got.Details != "user -> admin" ||
+			!got.CreatedAt.Equal(entries[1].CreatedAt) {
+			t.Errorf("Find returned %+v", got)
+		}
+
+		cases := []struct {
+			name   string
+			filter domain.AuditFilter
+			want   []int
+		}{
+			{"user as actor or target", domain.AuditFilter{UserID: 2}, []int{1}},
+			{"user as actor", domain.AuditFilter{UserID: 1}, []int{1, 0}},
+			{"action", domain.AuditFilter{Action: domain.AuditLoginSuccess}, []int{2, 0}},
+			{"from inclusive", domain.AuditFilter{From: base.Add(10 * time.Minute)}, []int{2, 1}},
+			{"to exclusive", domain.AuditFilter{To: base.Add(10 * time.Minute)}, []int{0}},
+			{"limit", domain.AuditFilter{Limit: 1}, []int{2}},
+			{"combined", domain.AuditFilter{UserID: 1, Action: domain.AuditLoginSuccess, From: base, To: base.Add(time.Hour)}, []int{0}},
+		}
+		for _, tc := range cases {
+			found, err := repo.Find(tc.filter)
+			must(t, err)
+			if len(found) != len(tc.want) {
+				t.Errorf("%s: got %d entries, want %d", tc.name, len(found), len(tc.want))
+				continue
+			}
+			for i, index := range tc.want {
+				if found[i].ID != entries[index].ID {
+					t.Errorf("%s: entry %d has ID %d, want %d", tc.name, i, found[i].ID, entries[index].ID)
+				}
+			}
+		}
+	})
+
+	t.Run("DefaultTime", func(t *testing.T) {
+		repo := newRepos(t).AuditRepository
+		entry := &domain.AuditEntry{Action: domain.AuditRegister}
+		must(t, repo.Append(entry))
+		if entry.CreatedAt.IsZero() {
+			t.Error("Append did not set CreatedAt")
+		}
+	})
+}
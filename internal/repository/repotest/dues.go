@@ -0,0 +1,186 @@
+package repotest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// testDues проверяет domain.DuesRepository
+func testDues(t *testing.T, newRepos Factory) {
+	t.Run("Plans", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.DuesRepository
+		juniors := &domain.Team{Name: "Juniors"}
+		adults := &domain.Team{Name: "Adults"}
+		must(t, repos.TeamRepository.Save(juniors))
+		must(t, repos.TeamRepository.Save(adults))
+		alice := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		bob := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+
+		if plan, err := repo.GetPlan(1); err != nil || plan != nil {
+			t.Fatalf("GetPlan(missing) = %v, %v", plan, err)
+		}
+
+		start := time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)
+		monthly := &domain.DuesPlan{
+			TeamID:    juniors.ID,
+			Name:      "Monthly",
+			Amount:    150050,
+			Period:    domain.DuesMonthly,
+			StartDate: start,
+			MemberIDs: []int64{bob.ID, alice.ID},
+			Active:    true,
+			CreatedBy: alice.ID,
+		}
+		must(t, repo.SavePlan(monthly))
+		if monthly.ID == 0 || monthly.CreatedAt.IsZero() {
+			t.Fatalf("SavePlan did not fill ID and CreatedAt: %+v", monthly)
+		}
+		must(t, repo.SavePlan(&domain.DuesPlan{TeamID: juniors.ID, Name: "Annual", Amount: 100, Period: domain.DuesWeekly, StartDate: start, Active: true}))
+		if err := repo.SavePlan(&domain.DuesPlan{TeamID: juniors.ID, Name: "Monthly", Amount: 100, Period: domain.DuesWeekly, StartDate: start}); !errors.Is(err, domain.ErrDuesPlanExists) {
+			t.Errorf("SavePlan of a duplicate name = %v, want ErrDuesPlanExists", err)
+		}
+		// Названия уникальны в пределах команды
+		must(t, repo.SavePlan(&domain.DuesPlan{TeamID: adults.ID, Name: "Monthly", Amount: 100, Period: domain.DuesWeekly, StartDate: start, Active: true}))
+
+		stored, err := repo.GetPlan(monthly.ID)
+		must(t, err)
+		if stored == nil || stored.Name != "Monthly" || stored.Amount != 150050 || stored.Period != domain.DuesMonthly ||
+			!stored.StartDate.Equal(start) || !stored.ChargedThrough.IsZero() || !stored.Active || stored.CreatedBy != alice.ID {
+			t.Fatalf("GetPlan = %+v", stored)
+		}
+		if want := []int64{alice.ID, bob.ID}; !reflect.DeepEqual(stored.MemberIDs, want) {
+			t.Errorf("MemberIDs = %v, want %v", stored.MemberIDs, want)
+		}
+		assertTime(t, "CreatedAt", stored.CreatedAt, monthly.CreatedAt)
+
+		plans, err := repo.GetPlans(juniors.ID)
+		must(t, err)
+		if len(plans) != 2 || plans[0].Name != "Annual" || plans[1].Name != "Monthly" || len(plans[0].MemberIDs) != 0 {
+			t.Errorf("GetPlans = %v, want Annual, Monthly", plans)
+		}
+
+		must(t, repo.SetActive(monthly.ID, false))
+		active, err := repo.GetActivePlans()
+		must(t, err)
+		if len(active) != 2 {
+			t.Errorf("GetActivePlans after stop = %v, want 2 plans", active)
+		}
+		for _, plan := range active {
+			if plan.ID == monthly.ID {
+				t.Error("GetActivePlans returned a stopped plan")
+			}
+		}
+
+		charged := time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC)
+		must(t, repo.SetChargedThrough(monthly.ID, charged))
+		stored, err = repo.GetPlan(monthly.ID)
+		must(t, err)
+		if !stored.ChargedThrough.Equal(charged) || stored.Active {
+			t.Errorf("plan after updates = %+v", stored)
+		}
+
+		if err := repo.SetActive(monthly.ID+100, true); !errors.Is(err, domain.ErrDuesPlanNotFound) {
+			t.Errorf("SetActive of a missing plan = %v, want ErrDuesPlanNotFound", err)
+		}
+		if err := repo.SetChargedThrough(monthly.ID+100, charged); !errors.Is(err, domain.ErrDuesPlanNotFound) {
+			t.Errorf("SetChargedThrough of a missing plan = %v, want ErrDuesPlanNotFound", err)
+		}
+	})
+}
+
+// testBalances проверяет domain.BalanceRepository
+func testBalances(t *testing.T, newRepos Factory) {
+	t.Run("Entries", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.BalanceRepository
+		team := &domain.Team{Name: "Juniors"}
+		other := &domain.Team{Name: "Adults"}
+		must(t, repos.TeamRepository.Save(team))
+		must(t, repos.TeamRepository.Save(other))
+		alice := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		bob := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+		carol := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "carol", Role: "user"})
+		plan := &domain.DuesPlan{TeamID: team.ID, Name: "Monthly", Amount: 1000, Period: domain.DuesMonthly,
+			StartDate: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), Active: true}
+		must(t, repos.DuesRepository.SavePlan(plan))
+
+		if balance, err := repo.GetBalance(team.ID, alice.ID); err != nil || balance != 0 {
+			t.Fatalf("GetBalance without entries = %d, %v", balance, err)
+		}
+
+		january := plan.StartDate
+		february := plan.PeriodStart(1)
+		charge := func(user *domain.User, period time.Time) (bool, error) {
+			return repo.AddEntry(&domain.BalanceEntry{TeamID: team.ID, UserID: user.ID, Kind: domain.EntryCharge,
+				Amount: -plan.Amount, PlanID: plan.ID, Period: period})
+		}
+		for _, c := range []struct {
+			user   *domain.User
+			period time.Time
+		}{{alice, january}, {alice, february}, {bob, january}, {carol, january}} {
+			if added, err := charge(c.user, c.period); err != nil || !added {
+				t.Fatalf("charge %s %s = %v, %v", c.user.Username, c.period.Format(domain.DateLayout), added, err)
+			}
+		}
+		// Повторное начисление за тот же период пропускается
+		if added, err := charge(alice, january); err != nil || added {
+			t.Errorf("repeated charge = %v, %v, want false", added, err)
+		}
+
+		// Оплаты не связаны с планом и не ограничены уникальным ключом
+		for _, amount := range []int64{500, 700} {
+			entry := &domain.BalanceEntry{TeamID: team.ID, UserID: bob.ID, Kind: domain.EntryPayment, Amount: amount, Note: "cash", CreatedBy: alice.ID}
+			if added, err := repo.AddEntry(entry); err != nil || !added || entry.ID == 0 || entry.CreatedAt.IsZero() {
+				t.Fatalf("payment = %v, %v (%+v)", added, err, entry)
+			}
+		}
+		mustAddEntry(t, repo, &domain.BalanceEntry{TeamID: team.ID, UserID: carol.ID, Kind: domain.EntryPayment, Amount: 1000})
+		// Операции другой команды не влияют на баланс
+		mustAddEntry(t, repo, &domain.BalanceEntry{TeamID: other.ID, UserID: alice.ID, Kind: domain.EntryPayment, Amount: 5000})
+
+		for _, c := range []struct {
+			user *domain.User
+			want int64
+		}{{alice, -2000}, {bob, 200}, {carol, 0}} {
+			if balance, err := repo.GetBalance(team.ID, c.user.ID); err != nil || balance != c.want {
+				t.Errorf("GetBalance(%s) = %d, %v, want %d", c.user.Username, balance, err, c.want)
+			}
+		}
+
+		entries, err := repo.GetEntries(team.ID, alice.ID, 10)
+		must(t, err)
+		if len(entries) != 2 || entries[0].Kind != domain.EntryCharge || entries[0].PlanID != plan.ID {
+			t.Fatalf("GetEntries(alice) = %v", entries)
+		}
+		if !entries[0].Period.Equal(february) || !entries[1].Period.Equal(january) {
+			t.Errorf("GetEntries periods = %v, %v, want newest first", entries[0].Period, entries[1].Period)
+		}
+		entries, err = repo.GetEntries(team.ID, bob.ID, 2)
+		must(t, err)
+		if len(entries) != 2 || entries[0].Amount != 700 || entries[0].Note != "cash" || entries[0].CreatedBy != alice.ID || !entries[0].Period.IsZero() {
+			t.Errorf("GetEntries(bob, 2) = %+v", entries)
+		}
+
+		debtors, err := repo.GetDebtors(team.ID)
+		must(t, err)
+		if len(debtors) != 1 || debtors[0].Username != "alice" || debtors[0].Debt != 2000 {
+			t.Errorf("GetDebtors = %v, want alice with 2000", debtors)
+		}
+		if debtors, err := repo.GetDebtors(other.ID); err != nil || len(debtors) != 0 {
+			t.Errorf("GetDebtors(other) = %v, %v", debtors, err)
+		}
+	})
+}
+
+// mustAddEntry добавляет операцию и прерывает тест, если она не добавлена
+func mustAddEntry(t *testing.T, repo domain.BalanceRepository, entry *domain.BalanceEntry) {
+	t.Helper()
+	if added, err := repo.AddEntry(entry); err != nil || !added {
+		t.Fatalf("AddEntry(%+v) = %v, %v", entry, added, err)
+	}
+}
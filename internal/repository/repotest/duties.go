@@ -0,0 +1,211 @@
+package repotest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// testDuties проверяет domain.DutyRepository
+func testDuties(t *testing.T, newRepos Factory) {
+	t.Run("Rosters", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.DutyRepository
+		juniors := &domain.Team{Name: "Juniors"}
+		adults := &domain.Team{Name: "Adults"}
+		must(t, repos.TeamRepository.Save(juniors))
+		must(t, repos.TeamRepository.Save(adults))
+		alice := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		bob := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+
+		if roster, err := repo.GetRoster(1); err != nil || roster != nil {
+			t.Fatalf("GetRoster(missing) = %v, %v", roster, err)
+		}
+
+		start := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+		ball := &domain.DutyRoster{
+			TeamID:    juniors.ID,
+			Name:      "Ball",
+			Period:    domain.DuesWeekly,
+			StartDate: start,
+			MemberIDs: []int64{bob.ID, alice.ID},
+			Active:    true,
+			CreatedBy: alice.ID,
+		}
+		must(t, repo.SaveRoster(ball))
+		if ball.ID == 0 || ball.CreatedAt.IsZero() {
+			t.Fatalf("SaveRoster did not fill ID and CreatedAt: %+v", ball)
+		}
+		if err := repo.SaveRoster(&domain.DutyRoster{TeamID: juniors.ID, Name: "Ball", Period: domain.DuesWeekly, StartDate: start}); !errors.Is(err, domain.ErrDutyRosterExists) {
+			t.Errorf("SaveRoster of a duplicate name = %v, want ErrDutyRosterExists", err)
+		}
+		must(t, repo.SaveRoster(&domain.DutyRoster{TeamID: juniors.ID, Name: "Field", Period: domain.DuesMonthly, StartDate: start, MemberIDs: []int64{alice.ID}}))
+		must(t, repo.SaveRoster(&domain.DutyRoster{TeamID: adults.ID, Name: "Ball", Period: domain.DuesWeekly, StartDate: start, Active: true}))
+
+		stored, err := repo.GetRoster(ball.ID)
+		must(t, err)
+		if stored == nil || stored.Name != "Ball" || stored.Period != domain.DuesWeekly || !stored.StartDate.Equal(start) ||
+			stored.Position != 0 || !stored.AssignedThrough.IsZero() || !stored.Active || stored.CreatedBy != alice.ID {
+			t.Fatalf("GetRoster = %+v", stored)
+		}
+		// Очередь сохраняет порядок, в котором участники перечислены
+		if !reflect.DeepEqual(stored.MemberIDs, []int64{bob.ID, alice.ID}) || !reflect.DeepEqual(stored.Members, []string{"bob", "alice"}) {
+			t.Errorf("queue = %v %v, want bob, alice", stored.MemberIDs, stored.Members)
+		}
+		assertTime(t, "CreatedAt", stored.CreatedAt, ball.CreatedAt)
+
+		rosters, err := repo.GetRosters(juniors.ID)
+		must(t, err)
+		if len(rosters) != 2 || rosters[0].Name != "Ball" || rosters[1].Name != "Field" {
+			t.Errorf("GetRosters = %v, want Ball and Field", rosters)
+		}
+		active, err := repo.GetActiveRosters()
+		must(t, err)
+		if len(active) != 2 {
+			t.Errorf("GetActiveRosters = %d rosters, want 2", len(active))
+		}
+
+		must(t, repo.SetActive(ball.ID, false))
+		if stored, err := repo.GetRoster(ball.ID); err != nil || stored.Active {
+			t.Errorf("roster after SetActive(false) = %+v, %v", stored, err)
+		}
+		if err := repo.SetActive(ball.ID+100, false); !errors.Is(err, domain.ErrDutyRosterNotFound) {
+			t.Errorf("SetActive(missing) = %v, want ErrDutyRosterNotFound", err)
+		}
+	})
+
+	t.Run("Assignments", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.DutyRepository
+		team := &domain.Team{Name: "Juniors"}
+		must(t, repos.TeamRepository.Save(team))
+		alice := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		bob := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+
+		start := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+		roster := &domain.DutyRoster{TeamID: team.ID, Name: "Ball", Period: domain.DuesWeekly, StartDate: start,
+			MemberIDs: []int64{alice.ID, bob.ID}, Active: true}
+		must(t, repo.SaveRoster(roster))
+
+		first := &domain.DutyAssignment{RosterID: roster.ID, TeamID: team.ID, Period: start, Until: roster.PeriodStart(1), UserID: alice.ID}
+		added, err := repo.Assign(first, 1)
+		if err != nil || !added || first.ID == 0 {
+			t.Fatalf("Assign = %v, %v, %+v", added, err, first)
+		}
+		// На период назначается один дежурный
+		if added, err := repo.Assign(&domain.DutyAssignment{RosterID: roster.ID, TeamID: team.ID, Period: start, Until: roster.PeriodStart(1), UserID: bob.ID}, 0); err != nil || added {
+			t.Errorf("repeated Assign = %v, %v, want not added", added, err)
+		}
+		stored, err := repo.GetRoster(roster.ID)
+		must(t, err)
+		if stored.Position != 1 || !stored.AssignedThrough.Equal(start) {
+			t.Errorf("roster after Assign = position %d, assigned through %s", stored.Position, stored.AssignedThrough)
+		}
+
+		second := &domain.DutyAssignment{RosterID: roster.ID, TeamID: team.ID, Period: roster.PeriodStart(1), Until: roster.PeriodStart(2), UserID: bob.ID}
+		if _, err := repo.Assign(second, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := repo.GetAssignment(first.ID)
+		must(t, err)
+		if got == nil || got.RosterName != "Ball" || got.Username != "alice" || !got.Period.Equal(start) ||
+			!got.Until.Equal(roster.PeriodStart(1)) || got.Done() {
+			t.Fatalf("GetAssignment = %+v", got)
+		}
+		if missing, err := repo.GetAssignment(second.ID + 100); err != nil || missing != nil {
+			t.Errorf("GetAssignment(missing) = %v, %v", missing, err)
+		}
+
+		current, err := repo.GetCurrent(team.ID)
+		must(t, err)
+		if len(current) != 1 || current[0].ID != second.ID {
+			t.Errorf("GetCurrent = %v, want the second duty", current)
+		}
+		history, err := repo.GetHistory(team.ID, start, roster.PeriodStart(2))
+		must(t, err)
+		if len(history) != 2 || history[0].ID != second.ID || history[1].ID != first.ID {
+			t.Errorf("GetHistory = %v, want both duties from new to old", history)
+		}
+		if history, err := repo.GetHistory(team.ID, roster.PeriodStart(1), roster.PeriodStart(2)); err != nil || len(history) != 1 {
+			t.Errorf("GetHistory(second week) = %v, %v, want one duty", history, err)
+		}
+
+		at := time.Now().Truncate(time.Second)
+		must(t, repo.Complete(first.ID, bob.ID, at))
+		if err := repo.Complete(first.ID, bob.ID, at); !errors.Is(err, domain.ErrDutyDone) {
+			t.Errorf("repeated Complete = %v, want ErrDutyDone", err)
+		}
+		got, err = repo.GetAssignment(first.ID)
+		must(t, err)
+		if !got.Done() || got.CompletedBy != bob.ID {
+			t.Errorf("completed duty = %+v", got)
+		}
+		assertTime(t, "CompletedAt", got.CompletedAt, at)
+	})
+
+	t.Run("Swaps", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.DutyRepository
+		team := &domain.Team{Name: "Juniors"}
+		must(t, repos.TeamRepository.Save(team))
+		alice := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		bob := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+		carol := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "carol", Role: "user"})
+
+		start := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+		roster := &domain.DutyRoster{TeamID: team.ID, Name: "Ball", Period: domain.DuesWeekly, StartDate: start,
+			MemberIDs: []int64{alice.ID}, Active: true}
+		must(t, repo.SaveRoster(roster))
+		duty := &domain.DutyAssignment{RosterID: roster.ID, TeamID: team.ID, Period: start, Until: roster.PeriodStart(1), UserID: alice.ID}
+		if _, err := repo.Assign(duty, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		if swap, err := repo.GetPendingSwap(duty.ID); err != nil || swap != nil {
+			t.Fatalf("GetPendingSwap(none) = %v, %v", swap, err)
+		}
+		declined := &domain.DutySwap{AssignmentID: duty.ID, FromID: alice.ID, ToID: bob.ID, Status: domain.DutySwapPending}
+		must(t, repo.SaveSwap(declined))
+		if declined.ID == 0 || declined.CreatedAt.IsZero() {
+			t.Fatalf("SaveSwap did not fill ID and CreatedAt: %+v", declined)
+		}
+		if pending, err := repo.GetPendingSwap(duty.ID); err != nil || pending == nil || pending.ID != declined.ID || pending.ToID != bob.ID {
+			t.Fatalf("GetPendingSwap = %+v, %v", pending, err)
+		}
+		declined.Status = domain.DutySwapDeclined
+		must(t, repo.ResolveSwap(declined))
+		if got, err := repo.GetAssignment(duty.ID); err != nil || got.UserID != alice.ID {
+			t.Errorf("duty after a declined swap = %+v, %v, want alice", got, err)
+		}
+
+		accepted := &domain.DutySwap{AssignmentID: duty.ID, FromID: alice.ID, ToID: carol.ID, Status: domain.DutySwapPending}
+		must(t, repo.SaveSwap(accepted))
+		accepted.Status = domain.DutySwapAccepted
+		must(t, repo.ResolveSwap(accepted))
+		if accepted.ResolvedAt.IsZero() {
+			t.Error("ResolveSwap did not fill ResolvedAt")
+		}
+		if err := repo.ResolveSwap(accepted); !errors.Is(err, domain.ErrDutySwapResolved) {
+			t.Errorf("repeated ResolveSwap = %v, want ErrDutySwapResolved", err)
+		}
+		if got, err := repo.GetAssignment(duty.ID); err != nil || got.UserID != carol.ID || got.Username != "carol" {
+			t.Errorf("duty after an accepted swap = %+v, %v, want carol", got, err)
+		}
+
+		stored, err := repo.GetSwap(accepted.ID)
+		must(t, err)
+		if stored == nil || stored.Status != domain.DutySwapAccepted || stored.FromID != alice.ID || stored.ResolvedAt.IsZero() {
+			t.Errorf("GetSwap = %+v", stored)
+		}
+		if missing, err := repo.GetSwap(accepted.ID + 100); err != nil || missing != nil {
+			t.Errorf("GetSwap(missing) = %v, %v", missing, err)
+		}
+		if pending, err := repo.GetPendingSwap(duty.ID); err != nil || pending != nil {
+			t.Errorf("GetPendingSwap after resolving = %v, %v, want none", pending, err)
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package repotest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// testEvents проверяет domain.EventRepository
+func testEvents(t *testing.T, newRepos Factory) {
+	t.Run("Events", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.EventRepository
+		team := &domain.Team{Name: "Juniors"}
+		other := &domain.Team{Name: "Adults"}
+		must(t, repos.TeamRepository.Save(team))
+		must(t, repos.TeamRepository.Save(other))
+
+		now := time.Now().Truncate(time.Minute)
+		training := &domain.Event{TeamID: team.ID, Title: "Training", Location: "Stadium", StartsAt: now.Add(48 * time.Hour),
+			Capacity: 2, Deadline: now.Add(24 * time.Hour), CreatedBy: 1}
+		game := &domain.Event{TeamID: team.ID, Title: "Game", StartsAt: now.Add(2 * time.Hour)}
+		past := &domain.Event{TeamID: team.ID, Title: "Past", StartsAt: now.Add(-time.Hour)}
+		foreign := &domain.Event{TeamID: other.ID, Title: "Foreign", StartsAt: now.Add(time.Hour)}
+		for _, event := range []*domain.Event{training, game, past, foreign} {
+			must(t, repo.Save(event))
+		}
+		if training.ID == 0 || training.CreatedAt.IsZero() {
+			t.Fatalf("Save did not fill ID and CreatedAt: %+v", training)
+		}
+
+		got, err := repo.GetByID(training.ID)
+		must(t, err)
+		if got == nil || got.Title != "Training" || got.Location != "Stadium" || got.Capacity != 2 || got.Canceled || !got.RemindedAt.IsZero() {
+			t.Fatalf("GetByID = %+v", got)
+		}
+		assertTime(t, "StartsAt", got.StartsAt, training.StartsAt)
+		assertTime(t, "Deadline", got.Deadline, training.Deadline)
+		if missing, err := repo.GetByID(training.ID + 100); err != nil || missing != nil {
+			t.Errorf("GetByID(missing) = %v, %v, want nil", missing, err)
+		}
+
+		upcoming, err := repo.GetUpcoming(team.ID, now)
+		must(t, err)
+		if len(upcoming) != 2 || upcoming[0].ID != game.ID || upcoming[1].ID != training.ID {
+			t.Errorf("GetUpcoming = %v, want the game and the training", upcoming)
+		}
+
+		// Два места: третий и четвертый желающие попадают в лист ожидания
+		var users []*domain.User
+		for _, name := range []string{"alice", "bob", "carol", "dave"} {
+			user := mustSaveUser(t, repos.UserRepository, &domain.User{Username: name, Role: "user"})
+			users = append(users, user)
+			rsvp := &domain.RSVP{EventID: training.ID, UserID: user.ID, Status: domain.RSVPGoing}
+			if promoted, err := repo.Respond(rsvp); err != nil || promoted != 0 {
+				t.Fatalf("Respond(%s) = %d, %v", name, promoted, err)
+			}
+			want := domain.RSVPGoing
+			if len(users) > 2 {
+				want = domain.RSVPWaitlist
+			}
+			if rsvp.Status != want || rsvp.RespondedAt.IsZero() {
+				t.Errorf("Respond(%s) = %+v, want %s", name, rsvp, want)
+			}
+		}
+		alice, bob, carol, dave := users[0], users[1], users[2], users[3]
+
+		// Повторное «иду» не меняет очередь
+		again := &domain.RSVP{EventID: training.ID, UserID: carol.ID, Status: domain.RSVPGoing}
+		if promoted, err := repo.Respond(again); err != nil || promoted != 0 || again.Status != domain.RSVPWaitlist {
+			t.Errorf("Respond(carol again) = %d, %+v, %v", promoted, again, err)
+		}
+
+		// Освободившееся место получает первый из листа ожидания
+		promoted, err := repo.Respond(&domain.RSVP{EventID: training.ID, UserID: alice.ID, Status: domain.RSVPNotGoing})
+		must(t, err)
+		if promoted != carol.ID {
+			t.Errorf("Respond(alice not going) promoted %d, want carol (%d)", promoted, carol.ID)
+		}
+		if promoted, err := repo.Respond(&domain.RSVP{EventID: training.ID, UserID: dave.ID, Status: domain.RSVPMaybe}); err != nil || promoted != 0 {
+			t.Fatalf("Respond(dave maybe) = %d, %v", promoted, err)
+		}
+
+		got, err = repo.GetByID(training.ID)
+		must(t, err)
+		if got.Going != 2 || got.Waitlisted != 0 {
+			t.Errorf("counts = going %d, waitlisted %d, want 2 and 0", got.Going, got.Waitlisted)
+		}
+		rsvps, err := repo.GetRSVPs(training.ID)
+		must(t, err)
+		statuses := make(map[string]domain.RSVPStatus)
+		for _, rsvp := range rsvps {
+			statuses[rsvp.Username] = rsvp.Status
+		}
+		want := map[string]domain.RSVPStatus{"alice": domain.RSVPNotGoing, "bob": domain.RSVPGoing, "carol": domain.RSVPGoing, "dave": domain.RSVPMaybe}
+		if !reflect.DeepEqual(statuses, want) {
+			t.Errorf("GetRSVPs = %v, want %v", statuses, want)
+		}
+		if len(rsvps) != 4 || rsvps[0].UserID != bob.ID {
+			t.Errorf("GetRSVPs order = %+v, want bob first", rsvps)
+		}
+
+		// Без ограничения мест все идут сразу
+		for _, user := range users {
+			rsvp := &domain.RSVP{EventID: game.ID, UserID: user.ID, Status: domain.RSVPGoing}
+			if _, err := repo.Respond(rsvp); err != nil || rsvp.Status != domain.RSVPGoing {
+				t.Fatalf("Respond(%s, game) = %+v, %v", user.Username, rsvp, err)
+			}
+		}
+		if _, err := repo.Respond(&domain.RSVP{EventID: training.ID + 100, UserID: bob.ID, Status: domain.RSVPGoing}); !errors.Is(err, domain.ErrEventNotFound) {
+			t.Errorf("Respond(missing event) = %v, want ErrEventNotFound", err)
+		}
+
+		unreminded, err := repo.GetUnreminded(now.Add(24 * time.Hour))
+		must(t, err)
+		if len(unreminded) != 3 || unreminded[0].ID != past.ID || unreminded[1].ID != foreign.ID || unreminded[2].ID != game.ID {
+			t.Errorf("GetUnreminded = %v, want the past, foreign and game events", unreminded)
+		}
+		must(t, repo.MarkReminded(game.ID, now))
+		must(t, repo.Cancel(past.ID))
+		unreminded, err = repo.GetUnreminded(now.Add(24 * time.Hour))
+		must(t, err)
+		if len(unreminded) != 1 || unreminded[0].ID != foreign.ID {
+			t.Errorf("GetUnreminded after reminding = %v, want the foreign event", unreminded)
+		}
+		if got, err := repo.GetByID(game.ID); err != nil || got.Going != 4 {
+			t.Errorf("GetByID(game) = %+v, %v", got, err)
+		} else {
+			assertTime(t, "RemindedAt", got.RemindedAt, now)
+		}
+		if got, err := repo.GetByID(past.ID); err != nil || !got.Canceled {
+			t.Errorf("GetByID(canceled) = %+v, %v", got, err)
+		}
+		if err := repo.Cancel(training.ID + 100); !errors.Is(err, domain.ErrEventNotFound) {
+			t.Errorf("Cancel(missing) = %v, want ErrEventNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package repotest
+
+import (
+	"reflect"
+	"testing"
+
+	"HelpBot/internal/domain"
+)
+
+// testExpenses проверяет domain.ExpenseRepository
+func testExpenses(t *testing.T, newRepos Factory) {
+	t.Run("Expenses", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.ExpenseRepository
+		team := &domain.Team{Name: "Juniors"}
+		other := &domain.Team{Name: "Adults"}
+		must(t, repos.TeamRepository.Save(team))
+		must(t, repos.TeamRepository.Save(other))
+		alice := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		bob := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+		carol := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "carol", Role: "user"})
+
+		if balances, err := repo.GetNetBalances(team.ID); err != nil || len(balances) != 0 {
+			t.Fatalf("GetNetBalances without expenses = %v, %v", balances, err)
+		}
+
+		// alice оплатила аренду поля на троих
+		rent := &domain.Expense{
+			TeamID:      team.ID,
+			PayerID:     alice.ID,
+			Amount:      3000,
+			Description: "Field rental",
+			Mode:        domain.SplitEqual,
+			Shares: []domain.ExpenseShare{
+				{UserID: carol.ID, Value: 1, Amount: 1000},
+				{UserID: alice.ID, Value: 1, Amount: 1000},
+				{UserID: bob.ID, Value: 1, Amount: 1000},
+			},
+			CreatedBy: alice.ID,
+		}
+		entries := []*domain.BalanceEntry{
+			{TeamID: team.ID, UserID: alice.ID, Kind: domain.EntryExpense, Amount: 2000, Note: "Field rental"},
+			{TeamID: team.ID, UserID: bob.ID, Kind: domain.EntryExpense, Amount: -1000, Note: "Field rental"},
+			{TeamID: team.ID, UserID: carol.ID, Kind: domain.EntryExpense, Amount: -1000, Note: "Field rental"},
+		}
+		must(t, repo.Save(rent, entries))
+		if rent.ID == 0 || rent.CreatedAt.IsZero() {
+			t.Fatalf("Save did not fill ID and CreatedAt: %+v", rent)
+		}
+		for _, entry := range entries {
+			if entry.ID == 0 || entry.ExpenseID != rent.ID {
+				t.Errorf("Save did not fill the balance entry: %+v", entry)
+			}
+		}
+
+		// bob вернул alice свою часть
+		settlement := &domain.Expense{
+			TeamID:     team.ID,
+			PayerID:    bob.ID,
+			Amount:     1000,
+			Mode:       domain.SplitExact,
+			Settlement: true,
+			Shares:     []domain.ExpenseShare{{UserID: alice.ID, Value: 1000, Amount: 1000}},
+			CreatedBy:  bob.ID,
+		}
+		must(t, repo.Save(settlement, []*domain.BalanceEntry{
+			{TeamID: team.ID, UserID: bob.ID, Kind: domain.EntrySettlement, Amount: 1000},
+			{TeamID: team.ID, UserID: alice.ID, Kind: domain.EntrySettlement, Amount: -1000},
+		}))
+		// Расходы другой команды и взносы не входят в балансы по расходам
+		must(t, repo.Save(&domain.Expense{TeamID: other.ID, PayerID: carol.ID, Amount: 500, Mode: domain.SplitEqual,
+			Shares: []domain.ExpenseShare{{UserID: alice.ID, Value: 1, Amount: 500}}},
+			[]*domain.BalanceEntry{
+				{TeamID: other.ID, UserID: carol.ID, Kind: domain.EntryExpense, Amount: 500},
+				{TeamID: other.ID, UserID: alice.ID, Kind: domain.EntryExpense, Amount: -500},
+			}))
+		mustAddEntry(t, repos.BalanceRepository, &domain.BalanceEntry{TeamID: team.ID, UserID: alice.ID, Kind: domain.EntryPayment, Amount: 700})
+
+		balances, err := repo.GetNetBalances(team.ID)
+		must(t, err)
+		if want := map[int64]int64{alice.ID: 1000, carol.ID: -1000}; !reflect.DeepEqual(balances, want) {
+			t.Errorf("GetNetBalances = %v, want %v", balances, want)
+		}
+		if balance, err := repos.BalanceRepository.GetBalance(team.ID, alice.ID); err != nil || balance != 1700 {
+			t.Errorf("GetBalance(alice) = %d, %v, want 1700", balance, err)
+		}
+		if debtors, err := repos.BalanceRepository.GetDebtors(team.ID); err != nil || len(debtors) != 0 {
+			t.Errorf("GetDebtors with expense debts only = %v, %v", debtors, err)
+		}
+		history, err := repos.BalanceRepository.GetEntries(team.ID, carol.ID, 10)
+		must(t, err)
+		if len(history) != 1 || history[0].ExpenseID != rent.ID || history[0].Note != "Field rental" {
+			t.Errorf("GetEntries(carol) = %+v", history)
+		}
+
+		expenses, err := repo.GetExpenses(team.ID, 10)
+		must(t, err)
+		if len(expenses) != 2 || expenses[0].ID != settlement.ID || expenses[1].ID != rent.ID {
+			t.Fatalf("GetExpenses = %v, want the settlement and the rent", expenses)
+		}
+		if got := expenses[0]; !got.Settlement || got.PayerName != "bob" || len(got.Shares) != 1 || got.Shares[0].Username != "alice" {
+			t.Errorf("settlement = %+v", got)
+		}
+		got := expenses[1]
+		if got.PayerName != "alice" || got.Amount != 3000 || got.Description != "Field rental" || got.Mode != domain.SplitEqual || got.Settlement {
+			t.Errorf("expense = %+v", got)
+		}
+		assertTime(t, "CreatedAt", got.CreatedAt, rent.CreatedAt)
+		var names []string
+		for _, share := range got.Shares {
+			names = append(names, share.Username)
+			if share.Amount != 1000 || share.Value != 1 {
+				t.Errorf("share = %+v", share)
+			}
+		}
+		if want := []string{"alice", "bob", "carol"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("share names = %v, want %v", names, want)
+		}
+
+		if limited, err := repo.GetExpenses(team.ID, 1); err != nil || len(limited) != 1 || limited[0].ID != settlement.ID {
+			t.Errorf("GetExpenses(limit 1) = %v, %v", limited, err)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// testInvites проверяет domain.InviteRepository
+func testInvites(t *testing.T, newRepos Factory) {
+	t.Run("Lifecycle", func(t *testing.T) {
+		repos := newRepos(t)
+		alice := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		bob := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+		repo := repos.InviteRepository
+
+		if invite, err := repo.GetByCodeHash("missing"); err != nil || invite != nil {
+			t.Fatalf("GetByCodeHash(missing) = %v, %v", invite, err)
+		}
+
+		now := time.Now()
+		must(t, repo.Save(&domain.Invite{UserID: alice.ID, CodeHash: "hash1", CreatedBy: bob.ID, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}))
+		must(t, repo.Save(&domain.Invite{UserID: bob.ID, CodeHash: "hash2", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}))
+
+		invite, err := repo.GetByCodeHash("hash1")
+		must(t, err)
+		if invite == nil || invite.UserID != alice.ID || invite.CreatedBy != bob.ID {
+			t.Fatalf("GetByCodeHash(hash1) = %+v", invite)
+		}
+		assertTime(t, "CreatedAt", invite.CreatedAt, now)
+		assertTime(t, "ExpiresAt", invite.ExpiresAt, now.Add(time.Hour))
+
+		// Новое приглашение пользователя заменяет предыдущее
+		must(t, repo.Save(&domain.Invite{UserID: alice.ID, CodeHash: "hash3", CreatedAt: now, ExpiresAt: now.Add(2 * time.Hour)}))
+		if invite, err := repo.GetByCodeHash("hash1"); err != nil || invite != nil {
+			t.Errorf("old code after replace = %v, %v", invite, err)
+		}
+		invite, err = repo.GetByCodeHash("hash3")
+		must(t, err)
+		if invite == nil || invite.UserID != alice.ID {
+			t.Fatalf("GetByCodeHash(hash3) = %+v", invite)
+		}
+		assertTime(t, "replaced ExpiresAt", invite.ExpiresAt, now.Add(2*time.Hour))
+
+		must(t, repo.Delete(alice.ID))
+		if invite, err := repo.GetByCodeHash("hash3"); err != nil || invite != nil {
+			t.Errorf("GetByCodeHash after Delete = %v, %v", invite, err)
+		}
+		if invite, err := repo.GetByCodeHash("hash2"); err != nil || invite == nil {
+			t.Errorf("Delete removed another user's invite: %v, %v", invite, err)
+		}
+		must(t, repo.Delete(alice.ID))
+	})
+}
@@ -0,0 +1,131 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// testPolls проверяет domain.PollRepository
+func testPolls(t *testing.T, newRepos Factory) {
+	t.Run("Polls", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.PollRepository
+		team := &domain.Team{Name: "Juniors"}
+		other := &domain.Team{Name: "Adults"}
+		must(t, repos.TeamRepository.Save(team))
+		must(t, repos.TeamRepository.Save(other))
+
+		now := time.Now().Truncate(time.Minute)
+		options := func(texts ...string) []*domain.PollOption {
+			var options []*domain.PollOption
+			for _, text := range texts {
+				options = append(options, &domain.PollOption{Text: text})
+			}
+			return options
+		}
+		single := &domain.Poll{TeamID: team.ID, Question: "Where?", Options: options("Park", "Gym", "Pool"),
+			Anonymous: true, Role: "coordinator", Deadline: now.Add(time.Hour), CreatedBy: 1}
+		multiple := &domain.Poll{TeamID: team.ID, Question: "When?", Options: options("Monday", "Friday"), Multiple: true}
+		expired := &domain.Poll{TeamID: other.ID, Question: "Old", Options: options("Yes", "No"), Deadline: now.Add(-time.Minute)}
+		for _, poll := range []*domain.Poll{single, multiple, expired} {
+			must(t, repo.Save(poll))
+		}
+		if single.ID == 0 || single.CreatedAt.IsZero() || single.Options[2].Position != 3 {
+			t.Fatalf("Save did not fill ID, CreatedAt and positions: %+v", single)
+		}
+
+		got, err := repo.GetByID(single.ID)
+		must(t, err)
+		if got == nil || got.Question != "Where?" || !got.Anonymous || got.Multiple || got.Role != "coordinator" || got.Closed ||
+			len(got.Options) != 3 || got.Options[1].Text != "Gym" || got.Options[1].Position != 2 {
+			t.Fatalf("GetByID = %+v", got)
+		}
+		assertTime(t, "Deadline", got.Deadline, single.Deadline)
+		if missing, err := repo.GetByID(single.ID + 100); err != nil || missing != nil {
+			t.Errorf("GetByID(missing) = %v, %v, want nil", missing, err)
+		}
+
+		alice := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		bob := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+		vote := func(poll *domain.Poll, user *domain.User, position int, want bool) {
+			t.Helper()
+			selected, err := repo.Vote(&domain.PollVote{PollID: poll.ID, UserID: user.ID, Position: position}, poll.Multiple)
+			if err != nil || selected != want {
+				t.Fatalf("Vote(%s, %q, %d) = %v, %v, want %v", user.Username, poll.Question, position, selected, err, want)
+			}
+		}
+
+		// В опросе с одним вариантом новый голос заменяет прежний, а повторный снимает его
+		vote(single, alice, 1, true)
+		vote(single, alice, 2, true)
+		vote(single, bob, 2, true)
+		vote(single, bob, 2, false)
+		vote(single, bob, 3, true)
+		// В опросе с несколькими вариантами голоса независимы
+		vote(multiple, alice, 1, true)
+		vote(multiple, alice, 2, true)
+		vote(multiple, bob, 2, true)
+		vote(multiple, alice, 1, false)
+
+		got, err = repo.GetByID(single.ID)
+		must(t, err)
+		if got.Voters != 2 || got.Options[0].Votes != 0 || got.Options[1].Votes != 1 || got.Options[2].Votes != 1 {
+			t.Errorf("single results = %d voters, %d/%d/%d votes", got.Voters, got.Options[0].Votes, got.Options[1].Votes, got.Options[2].Votes)
+		}
+		got, err = repo.GetByID(multiple.ID)
+		must(t, err)
+		if got.Voters != 2 || got.Options[0].Votes != 0 || got.Options[1].Votes != 2 {
+			t.Errorf("multiple results = %d voters, %d/%d votes", got.Voters, got.Options[0].Votes, got.Options[1].Votes)
+		}
+		votes, err := repo.GetVotes(multiple.ID)
+		must(t, err)
+		if len(votes) != 2 || votes[0].Username != "alice" || votes[1].Username != "bob" || votes[0].Position != 2 || votes[0].VotedAt.IsZero() {
+			t.Errorf("GetVotes = %+v, want alice and bob for the second option", votes)
+		}
+
+		open, err := repo.GetOpen(team.ID)
+		must(t, err)
+		if len(open) != 2 || open[0].ID != single.ID || open[1].ID != multiple.ID || len(open[1].Options) != 2 {
+			t.Errorf("GetOpen = %v, want both polls of the team", open)
+		}
+		expiredPolls, err := repo.GetExpired(now)
+		must(t, err)
+		if len(expiredPolls) != 1 || expiredPolls[0].ID != expired.ID {
+			t.Errorf("GetExpired = %v, want the old poll", expiredPolls)
+		}
+
+		must(t, repo.AddMessage(&domain.PollMessage{PollID: single.ID, ChatID: 200, MessageID: 7, Language: "en"}))
+		must(t, repo.AddMessage(&domain.PollMessage{PollID: single.ID, ChatID: 100, MessageID: 5, Language: "ru"}))
+		must(t, repo.AddMessage(&domain.PollMessage{PollID: single.ID, ChatID: 100, MessageID: 5, Language: "ru"}))
+		must(t, repo.AddMessage(&domain.PollMessage{PollID: multiple.ID, ChatID: 100, MessageID: 6}))
+		messages, err := repo.GetMessages(single.ID)
+		must(t, err)
+		if len(messages) != 2 || messages[0].ChatID != 100 || messages[0].MessageID != 5 || messages[0].Language != "ru" {
+			t.Errorf("GetMessages = %+v", messages)
+		}
+
+		// Закрытый опрос не принимает голосов и не закрывается повторно
+		must(t, repo.Close(single.ID, now))
+		if err := repo.Close(single.ID, now); !errors.Is(err, domain.ErrPollNotFound) {
+			t.Errorf("repeated Close = %v, want ErrPollNotFound", err)
+		}
+		if _, err := repo.Vote(&domain.PollVote{PollID: single.ID, UserID: alice.ID, Position: 1}, false); !errors.Is(err, domain.ErrPollNotFound) {
+			t.Errorf("Vote in a closed poll = %v, want ErrPollNotFound", err)
+		}
+		if _, err := repo.Vote(&domain.PollVote{PollID: single.ID + 100, UserID: alice.ID, Position: 1}, false); !errors.Is(err, domain.ErrPollNotFound) {
+			t.Errorf("Vote in a missing poll = %v, want ErrPollNotFound", err)
+		}
+		got, err = repo.GetByID(single.ID)
+		must(t, err)
+		if !got.Closed {
+			t.Errorf("GetByID after Close = %+v, want closed", got)
+		}
+		assertTime(t, "ClosedAt", got.ClosedAt, now)
+		if open, err := repo.GetOpen(team.ID); err != nil || len(open) != 1 || open[0].ID != multiple.ID {
+			t.Errorf("GetOpen after Close = %v, %v, want the multiple choice poll", open, err)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+// Package repotest содержит общий набор тестов на соответствие для реализаций
+// репозиториев. Каждое хранилище (SQLite, PostgreSQL, память) обязано проходить
+// одни и те же тесты, поэтому их поведение совпадает
+package repotest
+
+import (
+	"testing"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/repository"
+)
+
+// Factory создает пустое хранилище для одного теста. Хранилище должно быть
+// закрыто и удалено через t.Cleanup
+type Factory func(t *testing.T) *repository.Repositories
+
+// UserFactory создает пустой репозиторий пользователей для одного теста
+type UserFactory func(t *testing.T) domain.UserRepository
+
+// Run проверяет все репозитории хранилища
+func Run(t *testing.T, newRepos Factory) {
+	t.Run("UserRepository", func(t *testing.T) {
+		UserRepository(t, func(t *testing.T) domain.UserRepository { return newRepos(t).UserRepository })
+	})
+	t.Run("TransferRepository", func(t *testing.T) { testTransfers(t, newRepos) })
+	t.Run("RoleRepository", func(t *testing.T) { testRoles(t, newRepos) })
+	t.Run("AuditRepository", func(t *testing.T) { testAudit(t, newRepos) })
+	t.Run("TwoFactorRepository", func(t *testing.T) { testTwoFactor(t, newRepos) })
+	t.Run("InviteRepository", func(t *testing.T) { testInvites(t, newRepos) })
+	t.Run("TeamRepository", func(t *testing.T) { testTeams(t, newRepos) })
+	t.Run("DuesRepository", func(t *testing.T) { testDues(t, newRepos) })
+	t.Run("BalanceRepository", func(t *testing.T) { testBalances(t, newRepos) })
+	t.Run("ExpenseRepository", func(t *testing.T) { testExpenses(t, newRepos) })
+	t.Run("EventRepository", func(t *testing.T) { testEvents(t, newRepos) })
+	t.Run("PollRepository", func(t *testing.T) { testPolls(t, newRepos) })
+	t.Run("AttendanceRepository", func(t *testing.T) { testAttendance(t, newRepos) })
+	t.Run("DutyRepository", func(t *testing.T) { testDuties(t, newRepos) })
+	t.Run("AnnouncementRepository", func(t *testing.T) { testAnnouncements(t, newRepos) })
+	t.Run("TicketRepository", func(t *testing.T) { testTickets(t, newRepos) })
+}
+
+// mustSaveUser сохраняет пользователя и прерывает тест при ошибке
+func mustSaveUser(t *testing.T, repo domain.UserRepository, user *domain.User) *domain.User {
+	t.Helper()
+	if err := repo.Save(user); err != nil {
+		t.Fatalf("Save(%q): %v", user.Username, err)
+	}
+	if user.ID == 0 {
+		t.Fatalf("Save(%q) did not assign an ID", user.Username)
+	}
+	return user
+}
+
+// must прерывает тест при ошибке
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,96 @@
+package repotest
+
+import (
+	"slices"
+	"testing"
+
+	"HelpBot/internal/domain"
+)
+
+// testRoles проверяет domain.RoleRepository
+func testRoles(t *testing.T, newRepos Factory) {
+	t.Run("BuiltIn", func(t *testing.T) {
+		repo := newRepos(t).RoleRepository
+		roles, err := repo.GetAll()
+		must(t, err)
+
+		var names []string
+		for _, role := range roles {
+			if !role.BuiltIn {
+				t.Errorf("role %q is not built in", role.Name)
+			}
+			names = append(names, role.Name)
+		}
+		want := []string{"admin", "coordinator", "treasurer", "user", "viewer"}
+		if !slices.Equal(names, want) {
+			t.Errorf("GetAll = %v, want %v", names, want)
+		}
+
+		treasurer, err := repo.GetByName("treasurer")
+		must(t, err)
+		perms := []domain.Permission{domain.PermManageDues, domain.PermConfirmPayments, domain.PermViewUsers}
+		if treasurer == nil || !slices.Equal(treasurer.Permissions, perms) {
+			t.Errorf("treasurer = %+v, want permissions %v", treasurer, perms)
+		}
+	})
+
+	t.Run("CustomRole", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.RoleRepository
+
+		role := &domain.Role{
+			Name:        "captain",
+			Description: "Team captain",
+			Permissions: []domain.Permission{domain.PermViewUsers, domain.PermManageEvents},
+		}
+		must(t, repo.Save(role))
+		if err := repo.Save(&domain.Role{Name: "captain"}); err == nil {
+			t.Error("Save with duplicate name succeeded")
+		}
+
+		got, err := repo.GetByName("captain")
+		must(t, err)
+		wantPerms := []domain.Permission{domain.PermManageEvents, domain.PermViewUsers}
+		if got == nil || got.BuiltIn || got.Description != "Team captain" || !slices.Equal(got.Permissions, wantPerms) {
+			t.Fatalf("GetByName = %+v", got)
+		}
+
+		// Пользовательские роли идут после встроенных
+		roles, err := repo.GetAll()
+		must(t, err)
+		if last := roles[len(roles)-1]; last.Name != "captain" {
+			t.Errorf("last role = %q, want captain", last.Name)
+		}
+
+		role.Description = "Captain"
+		role.Permissions = []domain.Permission{domain.PermViewAudit}
+		must(t, repo.Update(role))
+		got, err = repo.GetByName("captain")
+		must(t, err)
+		if got.Description != "Captain" || !slices.Equal(got.Permissions, []domain.Permission{domain.PermViewAudit}) {
+			t.Errorf("after Update got %+v", got)
+		}
+
+		mustSaveUser(t, repos.UserRepository, &domain.User{Username: "c1", Role: "captain"})
+		mustSaveUser(t, repos.UserRepository, &domain.User{Username: "c2", Role: "captain"})
+		mustSaveUser(t, repos.UserRepository, &domain.User{Username: "u1", Role: "user"})
+		if count, err := repo.CountUsers("captain"); err != nil || count != 2 {
+			t.Errorf("CountUsers = %d, %v; want 2", count, err)
+		}
+
+		must(t, repo.Delete("captain"))
+		if got, err := repo.GetByName("captain"); err != nil || got != nil {
+			t.Errorf("GetByName after Delete = %v, %v", got, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepos(t).RoleRepository
+		if got, err := repo.GetByName("missing"); err != nil || got != nil {
+			t.Errorf("GetByName(missing) = %v, %v", got, err)
+		}
+		if count, err := repo.CountUsers("missing"); err != nil || count != 0 {
+			t.Errorf("CountUsers(missing) = %d, %v", count, err)
+		}
+	})
+}
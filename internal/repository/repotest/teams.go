@@ -0,0 +1,171 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+
+	"HelpBot/internal/domain"
+)
+
+// testTeams проверяет domain.TeamRepository
+func testTeams(t *testing.T, newRepos Factory) {
+	t.Run("Teams", func(t *testing.T) {
+		repo := newRepos(t).TeamRepository
+
+		if teams, err := repo.GetAll(); err != nil || len(teams) != 0 {
+			t.Fatalf("GetAll of an empty store = %v, %v", teams, err)
+		}
+		if team, err := repo.GetByID(1); err != nil || team != nil {
+			t.Fatalf("GetByID(missing) = %v, %v", team, err)
+		}
+
+		juniors := &domain.Team{Name: "Juniors"}
+		must(t, repo.Save(juniors))
+		must(t, repo.Save(&domain.Team{Name: "Adults"}))
+		if juniors.ID == 0 || juniors.CreatedAt.IsZero() {
+			t.Fatalf("Save did not fill ID and CreatedAt: %+v", juniors)
+		}
+		if err := repo.Save(&domain.Team{Name: "Juniors"}); !errors.Is(err, domain.ErrTeamExists) {
+			t.Errorf("Save of a duplicate name = %v, want ErrTeamExists", err)
+		}
+
+		stored, err := repo.GetByID(juniors.ID)
+		must(t, err)
+		if stored == nil || stored.Name != "Juniors" {
+			t.Fatalf("GetByID = %+v", stored)
+		}
+		assertTime(t, "CreatedAt", stored.CreatedAt, juniors.CreatedAt)
+		if stored.GroupChatID != 0 {
+			t.Errorf("GroupChatID of a new team = %d, want 0", stored.GroupChatID)
+		}
+		must(t, repo.SetGroupChat(juniors.ID, -1001234567890))
+		if stored, err := repo.GetByID(juniors.ID); err != nil || stored.GroupChatID != -1001234567890 {
+			t.Errorf("GetByID after SetGroupChat = %+v, %v", stored, err)
+		}
+
+		teams, err := repo.GetAll()
+		must(t, err)
+		if len(teams) != 2 || teams[0].Name != "Adults" || teams[1].Name != "Juniors" {
+			t.Errorf("GetAll = %v, want Adults, Juniors", teams)
+		}
+	})
+
+	t.Run("Members", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.TeamRepository
+		alice := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		bob := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+		juniors := &domain.Team{Name: "Juniors"}
+		adults := &domain.Team{Name: "Adults"}
+		must(t, repo.Save(juniors))
+		must(t, repo.Save(adults))
+
+		if member, err := repo.GetMember(juniors.ID, alice.ID); err != nil || member != nil {
+			t.Fatalf("GetMember before AddMember = %v, %v", member, err)
+		}
+
+		member := &domain.TeamMember{TeamID: juniors.ID, UserID: alice.ID, Role: "admin"}
+		must(t, repo.AddMember(member))
+		if member.JoinedAt.IsZero() {
+			t.Error("AddMember did not fill JoinedAt")
+		}
+		must(t, repo.AddMember(&domain.TeamMember{TeamID: adults.ID, UserID: alice.ID, Role: "user"}))
+		must(t, repo.AddMember(&domain.TeamMember{TeamID: juniors.ID, UserID: bob.ID, Role: "user"}))
+		if err := repo.AddMember(&domain.TeamMember{TeamID: juniors.ID, UserID: alice.ID, Role: "user"}); !errors.Is(err, domain.ErrMemberExists) {
+			t.Errorf("repeated AddMember = %v, want ErrMemberExists", err)
+		}
+
+		stored, err := repo.GetMember(juniors.ID, alice.ID)
+		must(t, err)
+		if stored == nil || stored.Role != "admin" || stored.TeamName != "Juniors" {
+			t.Fatalf("GetMember = %+v", stored)
+		}
+		assertTime(t, "JoinedAt", stored.JoinedAt, member.JoinedAt)
+
+		memberships, err := repo.GetMemberships(alice.ID)
+		must(t, err)
+		if len(memberships) != 2 || memberships[0].TeamName != "Adults" || memberships[1].TeamName != "Juniors" {
+			t.Errorf("GetMemberships = %v, want Adults, Juniors", memberships)
+		}
+
+		must(t, repo.UpdateMemberRole(juniors.ID, bob.ID, "coordinator"))
+		if stored, err := repo.GetMember(juniors.ID, bob.ID); err != nil || stored == nil || stored.Role != "coordinator" {
+			t.Errorf("GetMember after UpdateMemberRole = %+v, %v", stored, err)
+		}
+		if err := repo.UpdateMemberRole(adults.ID, bob.ID, "admin"); !errors.Is(err, domain.ErrMemberNotFound) {
+			t.Errorf("UpdateMemberRole of a non-member = %v, want ErrMemberNotFound", err)
+		}
+
+		must(t, repo.RemoveMember(adults.ID, alice.ID))
+		if memberships, err := repo.GetMemberships(alice.ID); err != nil || len(memberships) != 1 {
+			t.Errorf("GetMemberships after RemoveMember = %v, %v", memberships, err)
+		}
+		if err := repo.RemoveMember(adults.ID, alice.ID); !errors.Is(err, domain.ErrMemberNotFound) {
+			t.Errorf("repeated RemoveMember = %v, want ErrMemberNotFound", err)
+		}
+	})
+
+	t.Run("FindMembers", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.TeamRepository
+		juniors := &domain.Team{Name: "Juniors"}
+		adults := &domain.Team{Name: "Adults"}
+		must(t, repo.Save(juniors))
+		must(t, repo.Save(adults))
+
+		// Глобальная роль не совпадает с ролью в команде
+		for _, m := range []struct {
+			username, role string
+			team           *domain.Team
+		}{
+			{"alice", "admin", juniors},
+			{"bob", "user", juniors},
+			{"carol", "user", adults},
+			{"dave", "coordinator", juniors},
+		} {
+			user := mustSaveUser(t, repos.UserRepository, &domain.User{Username: m.username, Role: "user"})
+			must(t, repo.AddMember(&domain.TeamMember{TeamID: m.team.ID, UserID: user.ID, Role: m.role}))
+		}
+
+		page, err := repo.FindMembers(juniors.ID, domain.UserQuery{Sort: domain.UserSortUsername})
+		must(t, err)
+		if got := usernames(page.Users); len(got) != 3 || got[0] != "alice" || got[1] != "bob" || got[2] != "dave" {
+			t.Fatalf("FindMembers = %v, want alice, bob, dave", got)
+		}
+		if page.Users[0].TeamRole != "admin" || page.Users[0].Role != "user" {
+			t.Errorf("FindMembers roles of alice = %q/%q, want admin/user", page.Users[0].TeamRole, page.Users[0].Role)
+		}
+
+		page, err = repo.FindMembers(juniors.ID, domain.UserQuery{Role: "admin"})
+		must(t, err)
+		if got := usernames(page.Users); len(got) != 1 || got[0] != "alice" {
+			t.Errorf("FindMembers(role=admin) = %v, want alice", got)
+		}
+
+		// Постраничная выборка не выходит за пределы команды
+		page, err = repo.FindMembers(juniors.ID, domain.UserQuery{Sort: domain.UserSortUsername, Limit: 2})
+		must(t, err)
+		if got := usernames(page.Users); len(got) != 2 || page.NextCursor == "" {
+			t.Fatalf("first page = %v, cursor %q", got, page.NextCursor)
+		}
+		page, err = repo.FindMembers(juniors.ID, domain.UserQuery{Sort: domain.UserSortUsername, Limit: 2, Cursor: page.NextCursor})
+		must(t, err)
+		if got := usernames(page.Users); len(got) != 1 || got[0] != "dave" || page.NextCursor != "" {
+			t.Errorf("second page = %v, cursor %q", got, page.NextCursor)
+		}
+	})
+
+	t.Run("ActiveTeam", func(t *testing.T) {
+		repos := newRepos(t)
+		team := &domain.Team{Name: "Juniors"}
+		must(t, repos.TeamRepository.Save(team))
+		user := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+
+		must(t, repos.UserRepository.SetActiveTeam(user.ID, team.ID))
+		stored, err := repos.UserRepository.GetByID(user.ID)
+		must(t, err)
+		if stored.ActiveTeamID != team.ID {
+			t.Errorf("ActiveTeamID = %d, want %d", stored.ActiveTeamID, team.ID)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+
+	"HelpBot/internal/domain"
+)
+
+// testTickets проверяет domain.TicketRepository
+func testTickets(t *testing.T, newRepos Factory) {
+	t.Run("Tickets", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.TicketRepository
+		alice := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		bob := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+		root := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "root", Role: "admin"})
+
+		if ticket, err := repo.GetByID(1); err != nil || ticket != nil {
+			t.Fatalf("GetByID(missing) = %v, %v", ticket, err)
+		}
+
+		first := &domain.Ticket{UserID: alice.ID, Text: "Cannot pay dues", Status: domain.TicketOpen}
+		must(t, repo.Save(first))
+		if first.ID == 0 || first.CreatedAt.IsZero() || first.UpdatedAt.IsZero() {
+			t.Fatalf("Save did not fill ID and times: %+v", first)
+		}
+		second := &domain.Ticket{UserID: alice.ID, Text: "Wrong team", Status: domain.TicketOpen}
+		must(t, repo.Save(second))
+		third := &domain.Ticket{UserID: bob.ID, Text: "Forgot password", Status: domain.TicketOpen}
+		must(t, repo.Save(third))
+
+		stored, err := repo.GetByID(first.ID)
+		must(t, err)
+		if stored == nil || stored.Text != "Cannot pay dues" || stored.Status != domain.TicketOpen || stored.Username != "alice" ||
+			stored.AssigneeID != 0 || stored.Assignee != "" || !stored.ClosedAt.IsZero() {
+			t.Fatalf("GetByID = %+v", stored)
+		}
+		assertTime(t, "CreatedAt", stored.CreatedAt, first.CreatedAt)
+
+		own, err := repo.GetByUser(alice.ID)
+		must(t, err)
+		if len(own) != 2 || own[0].ID != second.ID || own[1].ID != first.ID {
+			t.Errorf("GetByUser = %v, want both tickets of alice from new to old", own)
+		}
+
+		first.Status = domain.TicketInProgress
+		first.AssigneeID = root.ID
+		must(t, repo.Update(first))
+		if stored, err := repo.GetByID(first.ID); err != nil || stored.Status != domain.TicketInProgress || stored.Assignee != "root" {
+			t.Errorf("GetByID after Update = %+v, %v, want in progress with root", stored, err)
+		}
+
+		second.Status = domain.TicketClosed
+		must(t, repo.Update(second))
+		if second.ClosedAt.IsZero() {
+			t.Error("Update did not fill ClosedAt of a closed ticket")
+		}
+		if err := repo.Update(second); !errors.Is(err, domain.ErrTicketClosed) {
+			t.Errorf("Update of a closed ticket = %v, want ErrTicketClosed", err)
+		}
+		if stored, err := repo.GetByID(second.ID); err != nil || !stored.Closed() {
+			t.Errorf("GetByID after Close = %+v, %v", stored, err)
+		} else {
+			assertTime(t, "ClosedAt", stored.ClosedAt, second.ClosedAt)
+		}
+
+		queue, err := repo.GetByStatus([]domain.TicketStatus{domain.TicketOpen, domain.TicketInProgress})
+		must(t, err)
+		if len(queue) != 2 || queue[0].ID != first.ID || queue[1].ID != third.ID {
+			t.Errorf("GetByStatus(open, in progress) = %v, want first and third from old to new", queue)
+		}
+		if closed, err := repo.GetByStatus([]domain.TicketStatus{domain.TicketClosed}); err != nil || len(closed) != 1 || closed[0].ID != second.ID {
+			t.Errorf("GetByStatus(closed) = %v, %v, want second", closed, err)
+		}
+	})
+
+	t.Run("Messages", func(t *testing.T) {
+		repos := newRepos(t)
+		repo := repos.TicketRepository
+		alice := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		root := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "root", Role: "admin"})
+
+		ticket := &domain.Ticket{UserID: alice.ID, Text: "Cannot pay dues", Status: domain.TicketOpen}
+		must(t, repo.Save(ticket))
+		if messages, err := repo.GetMessages(ticket.ID); err != nil || len(messages) != 0 {
+			t.Fatalf("GetMessages of a new ticket = %v, %v", messages, err)
+		}
+
+		reply := &domain.TicketMessage{TicketID: ticket.ID, AuthorID: root.ID, Staff: true, Text: "Which month?"}
+		must(t, repo.AddMessage(reply))
+		if reply.ID == 0 || reply.CreatedAt.IsZero() {
+			t.Fatalf("AddMessage did not fill ID and CreatedAt: %+v", reply)
+		}
+		must(t, repo.AddMessage(&domain.TicketMessage{TicketID: ticket.ID, AuthorID: alice.ID, Text: "March"}))
+
+		messages, err := repo.GetMessages(ticket.ID)
+		must(t, err)
+		if len(messages) != 2 || messages[0].Username != "root" || !messages[0].Staff || messages[0].Text != "Which month?" ||
+			messages[1].Username != "alice" || messages[1].Staff || messages[1].Text != "March" {
+			t.Errorf("GetMessages = %v, want the reply of root and the answer of alice", messages)
+		}
+		assertTime(t, "CreatedAt", messages[0].CreatedAt, reply.CreatedAt)
+	})
+}
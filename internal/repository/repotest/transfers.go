@@ -0,0 +1,92 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// testTransfers проверяет domain.TransferRepository
+func testTransfers(t *testing.T, newRepos Factory) {
+	t.Run("Lifecycle", func(t *testing.T) {
+		repos := newRepos(t)
+		user := mustSaveUser(t, repos.UserRepository, &domain.User{TelegramID: 1, Username: "alice", Role: "user"})
+		admin := mustSaveUser(t, repos.UserRepository, &domain.User{TelegramID: 2, Username: "admin", Role: "admin"})
+		repo := repos.TransferRepository
+
+		transfer := &domain.AccountTransfer{UserID: user.ID, FromTelegramID: 1, ToTelegramID: 10, ToChatID: 11}
+		must(t, repo.Save(transfer))
+		if transfer.ID == 0 || transfer.Status != domain.TransferStatusPending || transfer.CreatedAt.IsZero() {
+			t.Fatalf("Save did not fill defaults: %+v", transfer)
+		}
+		other := &domain.AccountTransfer{UserID: user.ID, FromTelegramID: 1, ToTelegramID: 20, ToChatID: 21}
+		must(t, repo.Save(other))
+
+		got, err := repo.GetByID(transfer.ID)
+		must(t, err)
+		if got == nil || got.UserID != user.ID || got.FromTelegramID != 1 || got.ToTelegramID != 10 ||
+			got.ToChatID != 11 || got.Status != domain.TransferStatusPending || got.ResolvedAt != nil {
+			t.Fatalf("GetByID = %+v", got)
+		}
+
+		got, err = repo.GetPendingByUser(user.ID, 20)
+		must(t, err)
+		if got == nil || got.ID != other.ID {
+			t.Errorf("GetPendingByUser = %+v, want transfer %d", got, other.ID)
+		}
+
+		pending, err := repo.GetPending()
+		must(t, err)
+		if len(pending) != 2 || pending[0].ID != transfer.ID || pending[1].ID != other.ID {
+			t.Errorf("GetPending returned %d transfers in wrong order", len(pending))
+		}
+
+		must(t, repo.UpdateStatus(transfer.ID, domain.TransferStatusApproved, admin.ID))
+		got, err = repo.GetByID(transfer.ID)
+		must(t, err)
+		if got.Status != domain.TransferStatusApproved || got.ResolvedBy != admin.ID || got.ResolvedAt == nil {
+			t.Errorf("after UpdateStatus got %+v", got)
+		}
+		if got, _ := repo.GetPendingByUser(user.ID, 10); got != nil {
+			t.Error("resolved transfer is still pending")
+		}
+		pending, err = repo.GetPending()
+		must(t, err)
+		if len(pending) != 1 || pending[0].ID != other.ID {
+			t.Errorf("GetPending after resolve returned %d transfers", len(pending))
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepos(t).TransferRepository
+		if got, err := repo.GetByID(1); err != nil || got != nil {
+			t.Errorf("GetByID(missing) = %v, %v", got, err)
+		}
+		if got, err := repo.GetPendingByUser(1, 1); err != nil || got != nil {
+			t.Errorf("GetPendingByUser(missing) = %v, %v", got, err)
+		}
+	})
+
+	t.Run("KeptForDeletedUser", func(t *testing.T) {
+		// Удаление и обезличивание пользователя не удаляют историю его запросов
+		repos := newRepos(t)
+		user := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+		transfer := &domain.AccountTransfer{UserID: user.ID, ToTelegramID: 3, ToChatID: 3}
+		must(t, repos.TransferRepository.Save(transfer))
+
+		must(t, repos.UserRepository.UpdateStatus(user.ID, domain.UserDeleted, ""))
+		_, err := repos.UserRepository.AnonymizeDeleted(time.Now().Add(time.Hour))
+		must(t, err)
+		if got, err := repos.TransferRepository.GetByID(transfer.ID); err != nil || got == nil || got.UserID != user.ID {
+			t.Errorf("transfer of deleted user = %v, %v", got, err)
+		}
+	})
+
+	t.Run("UnknownUser", func(t *testing.T) {
+		repo := newRepos(t).TransferRepository
+		if err := repo.Save(&domain.AccountTransfer{UserID: 999, ToTelegramID: 1, ToChatID: 1}); err == nil {
+			t.Error("Save for unknown user succeeded")
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package repotest
+
+import (
+	"testing"
+
+	"HelpBot/internal/domain"
+)
+
+// testTwoFactor проверяет domain.TwoFactorRepository
+func testTwoFactor(t *testing.T, newRepos Factory) {
+	t.Run("Lifecycle", func(t *testing.T) {
+		repos := newRepos(t)
+		user := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "alice", Role: "user"})
+		repo := repos.TwoFactorRepository
+
+		if tf, err := repo.Get(user.ID); err != nil || tf != nil {
+			t.Fatalf("Get before setup = %v, %v", tf, err)
+		}
+
+		must(t, repo.SaveSecret(user.ID, "SECRET1"))
+		tf, err := repo.Get(user.ID)
+		must(t, err)
+		if tf == nil || tf.Secret != "SECRET1" || tf.Enabled || tf.EnabledAt != nil || tf.CreatedAt.IsZero() {
+			t.Fatalf("Get after SaveSecret = %+v", tf)
+		}
+
+		must(t, repo.Enable(user.ID))
+		tf, err = repo.Get(user.ID)
+		must(t, err)
+		if !tf.Enabled || tf.EnabledAt == nil {
+			t.Fatalf("Get after Enable = %+v", tf)
+		}
+
+		// Период принимается только больше последнего запомненного
+		for _, tc := range []struct {
+			counter int64
+			want    bool
+		}{{100, true}, {100, false}, {99, false}, {101, true}} {
+			if used, err := repo.UseCounter(user.ID, tc.counter); err != nil || used != tc.want {
+				t.Errorf("UseCounter(%d) = %v, %v, want %v", tc.counter, used, err, tc.want)
+			}
+		}
+		if tf, err := repo.Get(user.ID); err != nil || tf.LastCounter != 101 {
+			t.Errorf("LastCounter = %+v, %v, want 101", tf, err)
+		}
+
+		// Новый секрет снова требует подтверждения
+		must(t, repo.SaveSecret(user.ID, "SECRET2"))
+		tf, err = repo.Get(user.ID)
+		must(t, err)
+		if tf.Secret != "SECRET2" || tf.Enabled || tf.EnabledAt != nil || tf.LastCounter != 0 {
+			t.Errorf("Get after second SaveSecret = %+v", tf)
+		}
+
+		must(t, repo.Delete(user.ID))
+		if tf, err := repo.Get(user.ID); err != nil || tf != nil {
+			t.Errorf("Get after Delete = %v, %v", tf, err)
+		}
+	})
+
+	t.Run("RecoveryCodes", func(t *testing.T) {
+		repos := newRepos(t)
+		user := mustSaveUser(t, repos.UserRepository, &domain.User{Username: "bob", Role: "user"})
+		repo := repos.TwoFactorRepository
+		must(t, repo.SaveSecret(user.ID, "SECRET"))
+
+		must(t, repo.ReplaceRecoveryCodes(user.ID, []string{"h1", "h2", "h3"}))
+		codes, err := repo.GetUnusedRecoveryCodes(user.ID)
+		must(t, err)
+		if len(codes) != 3 {
+			t.Fatalf("GetUnusedRecoveryCodes = %d codes, want 3", len(codes))
+		}
+		for _, code := range codes {
+			if code.UserID != user.ID || code.ID == 0 || code.CodeHash == "" {
+				t.Errorf("unexpected code %+v", code)
+			}
+		}
+
+		must(t, repo.UseRecoveryCode(codes[0].ID))
+		if err := repo.UseRecoveryCode(codes[0].ID); err == nil {
+			t.Error("UseRecoveryCode succeeded twice for the same code")
+		}
+		codes, err = repo.GetUnusedRecoveryCodes(user.ID)
+		must(t, err)
+		if len(codes) != 2 {
+			t.Errorf("after use got %d unused codes, want 2", len(codes))
+		}
+
+		must(t, repo.ReplaceRecoveryCodes(user.ID, []string{"h4"}))
+		codes, err = repo.GetUnusedRecoveryCodes(user.ID)
+		must(t, err)
+		if len(codes) != 1 || codes[0].CodeHash != "h4" {
+			t.Errorf("after replace got %+v", codes)
+		}
+
+		must(t, repo.Delete(user.ID))
+		codes, err = repo.GetUnusedRecoveryCodes(user.ID)
+		must(t, err)
+		if len(codes) != 0 {
+			t.Errorf("after Delete got %d codes", len(codes))
+		}
+	})
+}
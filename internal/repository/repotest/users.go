@@ -0,0 +1,291 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// timeTolerance учитывает точность хранения времени в разных хранилищах
+const timeTolerance = time.Second
+
+// UserRepository проверяет реализацию domain.UserRepository
+func UserRepository(t *testing.T, newRepo UserFactory) {
+	t.Run("SaveAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustSaveUser(t, repo, &domain.User{
+			TelegramID: 1001,
+			ChatID:     2001,
+			Username:   "alice",
+			Password:   "hash",
+			Role:       "user",
+			Position:   "goalkeeper",
+			Birthday:   "2000-01-02",
+			Number:     "7",
+			Language:   "en",
+		})
+
+		for name, get := range map[string]func() (*domain.User, error){
+			"GetByID":         func() (*domain.User, error) { return repo.GetByID(user.ID) },
+			"GetByTelegramID": func() (*domain.User, error) { return repo.GetByTelegramID(1001) },
+			"GetByUsername":   func() (*domain.User, error) { return repo.GetByUsername("alice") },
+		} {
+			got, err := get()
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if got == nil {
+				t.Fatalf("%s: user not found", name)
+			}
+			if got.ID != user.ID || got.TelegramID != 1001 || got.ChatID != 2001 || got.Username != "alice" ||
+				got.Password != "hash" || got.Role != "user" || got.Position != "goalkeeper" ||
+				got.Birthday != "2000-01-02" || got.Number != "7" || got.Language != "en" {
+				t.Errorf("%s = %+v, want saved fields", name, got)
+			}
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		if user, err := repo.GetByID(42); err != nil || user != nil {
+			t.Errorf("GetByID(missing) = %v, %v; want nil, nil", user, err)
+		}
+		if user, err := repo.GetByTelegramID(42); err != nil || user != nil {
+			t.Errorf("GetByTelegramID(missing) = %v, %v; want nil, nil", user, err)
+		}
+		if user, err := repo.GetByUsername("nobody"); err != nil || user != nil {
+			t.Errorf("GetByUsername(missing) = %v, %v; want nil, nil", user, err)
+		}
+
+		// Изменение несуществующего пользователя - ошибка, а не молчаливое бездействие
+		for name, err := range map[string]error{
+			"Update":         repo.Update(&domain.User{ID: 42, Username: "ghost", Role: "user"}),
+			"UpdatePassword": repo.UpdatePassword(42, "x"),
+			"UpdateRole":     repo.UpdateRole(42, "admin"),
+			"UpdateLanguage": repo.UpdateLanguage(42, "en"),
+			"BindTelegram":   repo.BindTelegram(42, 1, 1),
+			"UpdateStatus":   repo.UpdateStatus(42, domain.UserSuspended, ""),
+		} {
+			if !errors.Is(err, domain.ErrUserNotFound) {
+				t.Errorf("%s(missing) = %v, want ErrUserNotFound", name, err)
+			}
+		}
+		if user, _ := repo.GetByUsername("ghost"); user != nil {
+			t.Error("Update of a missing user inserted it")
+		}
+	})
+
+	t.Run("UniqueUsername", func(t *testing.T) {
+		repo := newRepo(t)
+		mustSaveUser(t, repo, &domain.User{Username: "bob", Role: "user"})
+		if err := repo.Save(&domain.User{Username: "bob", Role: "user"}); !errors.Is(err, domain.ErrUserExists) {
+			t.Errorf("Save with duplicate username = %v, want ErrUserExists", err)
+		}
+
+		other := mustSaveUser(t, repo, &domain.User{Username: "robert", Role: "user"})
+		other.Username = "bob"
+		if err := repo.Update(other); !errors.Is(err, domain.ErrUserExists) {
+			t.Errorf("Update to a taken username = %v, want ErrUserExists", err)
+		}
+		if got, _ := repo.GetByID(other.ID); got == nil || got.Username != "robert" {
+			t.Errorf("failed Update changed the user: %+v", got)
+		}
+	})
+
+	t.Run("UniqueTelegramID", func(t *testing.T) {
+		repo := newRepo(t)
+		mustSaveUser(t, repo, &domain.User{TelegramID: 5, Username: "first", Role: "user"})
+		if err := repo.Save(&domain.User{TelegramID: 5, Username: "second", Role: "user"}); !errors.Is(err, domain.ErrUserExists) {
+			t.Errorf("Save with duplicate telegram ID = %v, want ErrUserExists", err)
+		}
+
+		third := mustSaveUser(t, repo, &domain.User{TelegramID: 6, Username: "third", Role: "user"})
+		if err := repo.BindTelegram(third.ID, 5, 5); !errors.Is(err, domain.ErrUserExists) {
+			t.Errorf("BindTelegram to a bound account = %v, want ErrUserExists", err)
+		}
+
+		// Пользователи без привязки к Telegram не конфликтуют друг с другом
+		mustSaveUser(t, repo, &domain.User{Username: "unbound1", Role: "user"})
+		mustSaveUser(t, repo, &domain.User{Username: "unbound2", Role: "user"})
+		if user, err := repo.GetByTelegramID(0); err != nil || user != nil {
+			t.Errorf("GetByTelegramID(0) = %v, %v; want nil, nil", user, err)
+		}
+	})
+
+	t.Run("SaveExisting", func(t *testing.T) {
+		// Save только добавляет пользователей: повторный Save сохраненного пользователя
+		// не создает копию и не изменяет данные, для изменения есть Update
+		repo := newRepo(t)
+		user := mustSaveUser(t, repo, &domain.User{Username: "carol", Role: "user"})
+		id := user.ID
+
+		user.Position = "changed"
+		if err := repo.Save(user); !errors.Is(err, domain.ErrUserExists) {
+			t.Errorf("Save of a saved user = %v, want ErrUserExists", err)
+		}
+		if user.ID != id {
+			t.Errorf("failed Save changed the ID from %d to %d", id, user.ID)
+		}
+
+		users := allUsers(t, repo)
+		if len(users) != 1 || users[0].Position != "" {
+			t.Errorf("after failed Save got %+v", users)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustSaveUser(t, repo, &domain.User{TelegramID: 1, Username: "carol", Role: "user"})
+
+		user.TelegramID = 0
+		user.Username = "caroline"
+		user.Position = "captain"
+		user.Language = "ru"
+		must(t, repo.Update(user))
+
+		got, err := repo.GetByID(user.ID)
+		must(t, err)
+		if got.Username != "caroline" || got.Position != "captain" || got.Language != "ru" || got.TelegramID != 0 {
+			t.Errorf("after Update got %+v", got)
+		}
+		if old, _ := repo.GetByUsername("carol"); old != nil {
+			t.Error("old username still resolves after Update")
+		}
+		if users := allUsers(t, repo); len(users) != 1 {
+			t.Errorf("Update inserted a user: got %d users", len(users))
+		}
+	})
+
+	t.Run("FieldUpdates", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustSaveUser(t, repo, &domain.User{Username: "dave", Password: "old", Role: "user"})
+
+		must(t, repo.UpdatePassword(user.ID, "new"))
+		must(t, repo.UpdateRole(user.ID, "admin"))
+		must(t, repo.UpdateLanguage(user.ID, "en"))
+		must(t, repo.BindTelegram(user.ID, 777, 888))
+
+		got, err := repo.GetByTelegramID(777)
+		must(t, err)
+		if got == nil || got.ID != user.ID {
+			t.Fatalf("GetByTelegramID after BindTelegram = %+v", got)
+		}
+		if got.Password != "new" || got.Role != "admin" || got.Language != "en" || got.ChatID != 888 {
+			t.Errorf("after field updates got %+v", got)
+		}
+
+		// Отвязка от Telegram освобождает аккаунт для другого пользователя
+		must(t, repo.BindTelegram(user.ID, 0, 0))
+		mustSaveUser(t, repo, &domain.User{TelegramID: 777, Username: "erin", Role: "user"})
+	})
+
+	t.Run("Timestamps", func(t *testing.T) {
+		repo := newRepo(t)
+		before := time.Now()
+		user := mustSaveUser(t, repo, &domain.User{Username: "frank", Role: "user"})
+
+		if user.CreatedAt.IsZero() || !user.CreatedAt.Equal(user.UpdatedAt) {
+			t.Fatalf("Save set CreatedAt=%v UpdatedAt=%v, want equal non-zero", user.CreatedAt, user.UpdatedAt)
+		}
+		if user.CreatedAt.Before(before.Add(-timeTolerance)) || user.CreatedAt.After(time.Now().Add(timeTolerance)) {
+			t.Errorf("CreatedAt %v is not the save time", user.CreatedAt)
+		}
+
+		stored, err := repo.GetByID(user.ID)
+		must(t, err)
+		assertTime(t, "stored CreatedAt", stored.CreatedAt, user.CreatedAt)
+		assertTime(t, "stored UpdatedAt", stored.UpdatedAt, user.UpdatedAt)
+
+		// Изменения сдвигают UpdatedAt и не трогают CreatedAt, даже если вызывающий код его изменил
+		time.Sleep(10 * time.Millisecond)
+		stored.Position = "coach"
+		stored.CreatedAt = time.Time{}
+		must(t, repo.Update(stored))
+		updated, err := repo.GetByID(user.ID)
+		must(t, err)
+		assertTime(t, "CreatedAt after Update", updated.CreatedAt, user.CreatedAt)
+		if !updated.UpdatedAt.After(user.UpdatedAt) {
+			t.Errorf("Update did not advance UpdatedAt: %v -> %v", user.UpdatedAt, updated.UpdatedAt)
+		}
+		assertTime(t, "returned UpdatedAt", stored.UpdatedAt, updated.UpdatedAt)
+
+		time.Sleep(10 * time.Millisecond)
+		must(t, repo.UpdatePassword(user.ID, "x"))
+		changed, err := repo.GetByID(user.ID)
+		must(t, err)
+		assertTime(t, "CreatedAt after UpdatePassword", changed.CreatedAt, user.CreatedAt)
+		if !changed.UpdatedAt.After(updated.UpdatedAt) {
+			t.Errorf("UpdatePassword did not advance UpdatedAt: %v -> %v", updated.UpdatedAt, changed.UpdatedAt)
+		}
+	})
+
+	t.Run("Isolation", func(t *testing.T) {
+		// Изменение полученного объекта без Update не меняет хранимые данные
+		repo := newRepo(t)
+		user := mustSaveUser(t, repo, &domain.User{Username: "gina", Role: "user"})
+		user.Role = "admin"
+
+		got, err := repo.GetByID(user.ID)
+		must(t, err)
+		got.Position = "changed"
+		again, err := repo.GetByID(user.ID)
+		must(t, err)
+		if again.Role != "user" || again.Position != "" {
+			t.Errorf("stored user changed without Update: %+v", again)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo := newRepo(t)
+		if users := allUsers(t, repo); len(users) != 0 {
+			t.Fatalf("empty repository lists %d users", len(users))
+		}
+
+		first := mustSaveUser(t, repo, &domain.User{Username: "u1", Role: "user"})
+		second := mustSaveUser(t, repo, &domain.User{Username: "u2", Role: "user"})
+		if second.ID <= first.ID {
+			t.Errorf("IDs are not increasing: %d then %d", first.ID, second.ID)
+		}
+
+		users := allUsers(t, repo)
+		if len(users) != 2 || users[0].ID != first.ID || users[1].ID != second.ID {
+			t.Fatalf("users = %v, want [u1 u2]", usernames(users))
+		}
+	})
+
+	t.Run("Find", func(t *testing.T) { testFindUsers(t, newRepo(t)) })
+	t.Run("Status", func(t *testing.T) { testUserStatus(t, newRepo(t)) })
+	t.Run("AnonymizeDeleted", func(t *testing.T) { testAnonymizeDeleted(t, newRepo(t)) })
+	t.Run("RevokeSessions", func(t *testing.T) { testRevokeSessions(t, newRepo(t)) })
+	t.Run("ClearActiveTeam", func(t *testing.T) { testClearActiveTeam(t, newRepo(t)) })
+}
+
+// allUsers возвращает всех пользователей в порядке создания
+func allUsers(t *testing.T, repo domain.UserRepository) []*domain.User {
+	t.Helper()
+	page, err := repo.Find(domain.UserQuery{})
+	must(t, err)
+	if page.NextCursor != "" {
+		t.Errorf("unlimited query returned a next cursor")
+	}
+	return page.Users
+}
+
+// assertTime сравнивает время с учетом точности хранения
+func assertTime(t *testing.T, name string, got, want time.Time) {
+	t.Helper()
+	if diff := got.Sub(want); diff > timeTolerance || diff < -timeTolerance {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
+
+// usernames возвращает имена пользователей для сообщений об ошибках
+func usernames(users []*domain.User) []string {
+	names := make([]string, len(users))
+	for i, user := range users {
+		names[i] = user.Username
+	}
+	return names
+}
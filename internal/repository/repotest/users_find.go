@@ -0,0 +1,168 @@
+package repotest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// testFindUsers проверяет фильтры, сортировку и постраничную выборку пользователей
+func testFindUsers(t *testing.T, repo domain.UserRepository) {
+	// Пользователи создаются с паузами, чтобы время регистрации различалось
+	var saved []*domain.User
+	for _, user := range []*domain.User{
+		{Username: "anna", Password: "h1", Role: "user", Position: "forward"},
+		{Username: "andrew", Password: "h2", Role: "admin", Position: "goalkeeper"},
+		{Username: "Boris", Password: "h3", Role: "user", Position: "forward"},
+		{Username: "bella", Password: "h4", Role: "treasurer"},
+		{Username: "an_na", Password: "h5", Role: "user", Position: "defender"},
+		{Username: "дима", Password: "h6", Role: "user"},
+	} {
+		saved = append(saved, mustSaveUser(t, repo, user))
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	find := func(t *testing.T, query domain.UserQuery) *domain.UserPage {
+		t.Helper()
+		page, err := repo.Find(query)
+		if err != nil {
+			t.Fatalf("Find(%+v): %v", query, err)
+		}
+		return page
+	}
+	expect := func(t *testing.T, query domain.UserQuery, want ...string) {
+		t.Helper()
+		page := find(t, query)
+		if got := usernames(page.Users); !reflect.DeepEqual(got, want) && len(got)+len(want) > 0 {
+			t.Errorf("Find(%+v) = %v, want %v", query, got, want)
+		}
+		if page.NextCursor != "" {
+			t.Errorf("Find(%+v) returned a next cursor for the only page", query)
+		}
+	}
+
+	t.Run("Secrets", func(t *testing.T) {
+		for _, user := range find(t, domain.UserQuery{}).Users {
+			if user.Password != "" {
+				t.Errorf("user %s returned with a password hash", user.Username)
+			}
+		}
+		for i, user := range find(t, domain.UserQuery{WithSecrets: true}).Users {
+			if user.Password != saved[i].Password {
+				t.Errorf("WithSecrets: user %s password = %q, want %q", user.Username, user.Password, saved[i].Password)
+			}
+		}
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		expect(t, domain.UserQuery{}, "anna", "andrew", "Boris", "bella", "an_na", "дима")
+		expect(t, domain.UserQuery{Role: "user"}, "anna", "Boris", "an_na", "дима")
+		expect(t, domain.UserQuery{Position: "forward"}, "anna", "Boris")
+		expect(t, domain.UserQuery{Role: "user", Position: "forward"}, "anna", "Boris")
+		expect(t, domain.UserQuery{Role: "nobody"})
+
+		// Префикс имени учитывает регистр, а _ и % не являются шаблонами
+		expect(t, domain.UserQuery{NamePrefix: "an"}, "anna", "andrew", "an_na")
+		expect(t, domain.UserQuery{NamePrefix: "an_"}, "an_na")
+		expect(t, domain.UserQuery{NamePrefix: "b"}, "bella")
+		expect(t, domain.UserQuery{NamePrefix: "%"})
+		expect(t, domain.UserQuery{NamePrefix: "ди"}, "дима")
+
+		// Период регистрации: начало включается, конец - нет
+		from, err := repo.GetByID(saved[2].ID)
+		must(t, err)
+		to, err := repo.GetByID(saved[4].ID)
+		must(t, err)
+		expect(t, domain.UserQuery{CreatedFrom: from.CreatedAt, CreatedTo: to.CreatedAt}, "Boris", "bella")
+		expect(t, domain.UserQuery{CreatedFrom: to.CreatedAt}, "an_na", "дима")
+		expect(t, domain.UserQuery{CreatedTo: from.CreatedAt}, "anna", "andrew")
+	})
+
+	t.Run("Sort", func(t *testing.T) {
+		// Имена сравниваются побайтно во всех хранилищах
+		expect(t, domain.UserQuery{Sort: domain.UserSortUsername}, "Boris", "an_na", "andrew", "anna", "bella", "дима")
+		expect(t, domain.UserQuery{Sort: domain.UserSortUsername, Desc: true}, "дима", "bella", "anna", "andrew", "an_na", "Boris")
+		expect(t, domain.UserQuery{Sort: domain.UserSortCreated}, "anna", "andrew", "Boris", "bella", "an_na", "дима")
+		expect(t, domain.UserQuery{Sort: domain.UserSortCreated, Desc: true}, "дима", "an_na", "bella", "Boris", "andrew", "anna")
+		expect(t, domain.UserQuery{Desc: true}, "дима", "an_na", "bella", "Boris", "andrew", "anna")
+		expect(t, domain.UserQuery{Role: "user", Sort: domain.UserSortUsername}, "Boris", "an_na", "anna", "дима")
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		for _, query := range []domain.UserQuery{
+			{},
+			{Desc: true},
+			{Sort: domain.UserSortUsername},
+			{Sort: domain.UserSortUsername, Desc: true},
+			{Sort: domain.UserSortCreated},
+			{Sort: domain.UserSortCreated, Desc: true},
+			{Role: "user", Sort: domain.UserSortUsername},
+		} {
+			want := usernames(find(t, query).Users)
+
+			// Листаем по две записи, пока есть следующая страница
+			var got []string
+			paged := query
+			paged.Limit = 2
+			for pages := 0; ; pages++ {
+				if pages > len(want) {
+					t.Fatalf("Find(%+v) does not stop paginating", query)
+				}
+				page := find(t, paged)
+				if len(page.Users) > 2 {
+					t.Fatalf("Find(%+v) returned %d users, limit 2", paged, len(page.Users))
+				}
+				got = append(got, usernames(page.Users)...)
+				if page.NextCursor == "" {
+					break
+				}
+				paged.Cursor = page.NextCursor
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("pages of %+v = %v, want %v", query, got, want)
+			}
+		}
+
+		// Если записей ровно на страницу, курсора следующей страницы нет
+		if page := find(t, domain.UserQuery{Limit: len(saved)}); page.NextCursor != "" || len(page.Users) != len(saved) {
+			t.Errorf("full page: %d users, cursor %q", len(page.Users), page.NextCursor)
+		}
+		if page := find(t, domain.UserQuery{Limit: len(saved) - 1}); page.NextCursor == "" {
+			t.Error("no cursor when one more user remains")
+		}
+	})
+
+	t.Run("CursorAfterStatusChange", func(t *testing.T) {
+		// Курсор указывает на позицию, а не на запись: если последний пользователь
+		// страницы выпал из выборки, переход к следующей странице не ломается
+		query := domain.UserQuery{Statuses: []domain.UserStatus{domain.UserActive}, Sort: domain.UserSortUsername, Limit: 2}
+		first := find(t, query)
+		if got := usernames(first.Users); !reflect.DeepEqual(got, []string{"Boris", "an_na"}) {
+			t.Fatalf("first page = %v", got)
+		}
+		must(t, repo.UpdateStatus(first.Users[1].ID, domain.UserSuspended, ""))
+
+		query.Cursor = first.NextCursor
+		if got := usernames(find(t, query).Users); !reflect.DeepEqual(got, []string{"andrew", "anna"}) {
+			t.Errorf("page after deleted cursor user = %v, want [andrew anna]", got)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		byName := find(t, domain.UserQuery{Sort: domain.UserSortUsername, Limit: 1}).NextCursor
+		for name, query := range map[string]domain.UserQuery{
+			"unknown sort":    {Sort: "password"},
+			"negative limit":  {Limit: -1},
+			"garbage cursor":  {Cursor: "not a cursor"},
+			"foreign cursor":  {Sort: domain.UserSortCreated, Cursor: byName},
+			"reversed cursor": {Sort: domain.UserSortUsername, Desc: true, Cursor: byName},
+		} {
+			if _, err := repo.Find(query); !errors.Is(err, domain.ErrInvalidQuery) {
+				t.Errorf("%s: Find = %v, want ErrInvalidQuery", name, err)
+			}
+		}
+	})
+}
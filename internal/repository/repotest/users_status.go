@@ -0,0 +1,207 @@
+package repotest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// testUserStatus проверяет смену состояния учетной записи
+func testUserStatus(t *testing.T, repo domain.UserRepository) {
+	user := mustSaveUser(t, repo, &domain.User{Username: "ivan", Password: "hash", Role: "user"})
+	if user.Status != domain.UserActive {
+		t.Errorf("Save set status %q, want active", user.Status)
+	}
+	stored, err := repo.GetByID(user.ID)
+	must(t, err)
+	if stored.Status != domain.UserActive || stored.StatusReason != "" || !stored.StatusChangedAt.IsZero() || !stored.PurgedAt.IsZero() {
+		t.Errorf("new user status = %q %q %v %v", stored.Status, stored.StatusReason, stored.StatusChangedAt, stored.PurgedAt)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	before := time.Now()
+	must(t, repo.UpdateStatus(user.ID, domain.UserSuspended, "unpaid dues"))
+	suspended, err := repo.GetByID(user.ID)
+	must(t, err)
+	if suspended.Status != domain.UserSuspended || suspended.StatusReason != "unpaid dues" {
+		t.Errorf("after UpdateStatus got %q %q", suspended.Status, suspended.StatusReason)
+	}
+	if suspended.StatusChangedAt.Before(before.Add(-timeTolerance)) || suspended.StatusChangedAt.After(time.Now().Add(timeTolerance)) {
+		t.Errorf("StatusChangedAt %v is not the change time", suspended.StatusChangedAt)
+	}
+	if !suspended.UpdatedAt.After(stored.UpdatedAt) {
+		t.Errorf("UpdateStatus did not advance UpdatedAt: %v -> %v", stored.UpdatedAt, suspended.UpdatedAt)
+	}
+
+	// Update изменяет профиль, но не состояние учетной записи
+	suspended.Status = domain.UserActive
+	suspended.StatusReason = ""
+	suspended.Position = "coach"
+	must(t, repo.Update(suspended))
+	updated, err := repo.GetByID(user.ID)
+	must(t, err)
+	if updated.Position != "coach" || updated.Status != domain.UserSuspended || updated.StatusReason != "unpaid dues" {
+		t.Errorf("Update changed the status: %q %q", updated.Status, updated.StatusReason)
+	}
+	assertTime(t, "StatusChangedAt after Update", updated.StatusChangedAt, suspended.StatusChangedAt)
+
+	// Восстановление возвращает учетную запись в активное состояние
+	must(t, repo.UpdateStatus(user.ID, domain.UserActive, ""))
+	restored, err := repo.GetByID(user.ID)
+	must(t, err)
+	if restored.Status != domain.UserActive || restored.StatusReason != "" {
+		t.Errorf("after restore got %q %q", restored.Status, restored.StatusReason)
+	}
+
+	// Имя удаленного, но еще не обезличенного пользователя остается занятым
+	deleted := mustSaveUser(t, repo, &domain.User{Username: "jack", Role: "user"})
+	must(t, repo.UpdateStatus(deleted.ID, domain.UserDeleted, "left"))
+	if err := repo.Save(&domain.User{Username: "jack", Role: "user"}); !errors.Is(err, domain.ErrUserExists) {
+		t.Errorf("Save with the name of a deleted user = %v, want ErrUserExists", err)
+	}
+
+	deactivated := mustSaveUser(t, repo, &domain.User{Username: "kate", Role: "user"})
+	must(t, repo.UpdateStatus(deactivated.ID, domain.UserDeactivated, ""))
+
+	for _, tc := range []struct {
+		statuses []domain.UserStatus
+		want     []string
+	}{
+		{nil, []string{"ivan", "jack", "kate"}},
+		{[]domain.UserStatus{domain.UserActive}, []string{"ivan"}},
+		{[]domain.UserStatus{domain.UserDeleted, domain.UserDeactivated}, []string{"jack", "kate"}},
+		{[]domain.UserStatus{domain.UserSuspended}, nil},
+	} {
+		page, err := repo.Find(domain.UserQuery{Statuses: tc.statuses})
+		must(t, err)
+		if got := usernames(page.Users); !reflect.DeepEqual(got, tc.want) && len(got)+len(tc.want) > 0 {
+			t.Errorf("Find(statuses=%v) = %v, want %v", tc.statuses, got, tc.want)
+		}
+	}
+}
+
+// testAnonymizeDeleted проверяет обезличивание давно удаленных пользователей
+func testAnonymizeDeleted(t *testing.T, repo domain.UserRepository) {
+	profile := func(name string, telegramID int64) *domain.User {
+		return &domain.User{
+			TelegramID: telegramID,
+			ChatID:     telegramID,
+			Username:   name,
+			Password:   "hash",
+			Role:       "treasurer",
+			Position:   "forward",
+			Birthday:   "2000-01-01",
+			Number:     "10",
+			Language:   "en",
+		}
+	}
+	old := mustSaveUser(t, repo, profile("old", 1))
+	recent := mustSaveUser(t, repo, profile("recent", 2))
+	suspended := mustSaveUser(t, repo, profile("suspended", 3))
+	active := mustSaveUser(t, repo, profile("active", 4))
+
+	must(t, repo.UpdateStatus(old.ID, domain.UserDeleted, "left the team"))
+	must(t, repo.UpdateStatus(suspended.ID, domain.UserSuspended, ""))
+	time.Sleep(10 * time.Millisecond)
+	cutoff := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	must(t, repo.UpdateStatus(recent.ID, domain.UserDeleted, ""))
+
+	ids, err := repo.AnonymizeDeleted(cutoff)
+	must(t, err)
+	if !reflect.DeepEqual(ids, []int64{old.ID}) {
+		t.Fatalf("AnonymizeDeleted = %v, want [%d]", ids, old.ID)
+	}
+
+	got, err := repo.GetByID(old.ID)
+	must(t, err)
+	if got.Username != domain.AnonymizedUsername(old.ID) || got.Password != "" || got.TelegramID != 0 || got.ChatID != 0 ||
+		got.Position != "" || got.Birthday != "" || got.Number != "" || got.StatusReason != "" {
+		t.Errorf("anonymized user keeps personal data: %+v", got)
+	}
+	if got.Status != domain.UserDeleted || got.PurgedAt.IsZero() || got.Role != "treasurer" {
+		t.Errorf("anonymized user status = %q, purged %v, role %q", got.Status, got.PurgedAt, got.Role)
+	}
+	assertTime(t, "CreatedAt after anonymization", got.CreatedAt, old.CreatedAt)
+
+	// Имя и Telegram-аккаунт обезличенного пользователя освобождаются
+	if user, err := repo.GetByUsername("old"); err != nil || user != nil {
+		t.Errorf("GetByUsername(old) = %v, %v; want nil, nil", user, err)
+	}
+	if user, err := repo.GetByTelegramID(1); err != nil || user != nil {
+		t.Errorf("GetByTelegramID(1) = %v, %v; want nil, nil", user, err)
+	}
+	mustSaveUser(t, repo, profile("old", 1))
+
+	// Повторный запуск не затрагивает уже обезличенных пользователей
+	ids, err = repo.AnonymizeDeleted(time.Now().Add(time.Hour))
+	must(t, err)
+	if !reflect.DeepEqual(ids, []int64{recent.ID}) {
+		t.Errorf("second AnonymizeDeleted = %v, want [%d]", ids, recent.ID)
+	}
+
+	for _, id := range []int64{suspended.ID, active.ID} {
+		user, err := repo.GetByID(id)
+		must(t, err)
+		if !user.PurgedAt.IsZero() || user.Password != "hash" || user.Position != "forward" {
+			t.Errorf("user %s was anonymized: %+v", user.Username, user)
+		}
+	}
+}
+
+// testRevokeSessions проверяет отметку о завершении сессий пользователя
+func testRevokeSessions(t *testing.T, repo domain.UserRepository) {
+	user := mustSaveUser(t, repo, &domain.User{Username: "ivan", Password: "hash", Role: "user"})
+	if stored, err := repo.GetByID(user.ID); err != nil || !stored.SessionsRevokedAt.IsZero() {
+		t.Fatalf("new user SessionsRevokedAt = %v, %v", stored, err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	before := time.Now()
+	must(t, repo.RevokeSessions(user.ID))
+	stored, err := repo.GetByID(user.ID)
+	must(t, err)
+	assertTime(t, "SessionsRevokedAt", stored.SessionsRevokedAt, before)
+	if !stored.UpdatedAt.After(user.UpdatedAt) {
+		t.Errorf("RevokeSessions did not advance UpdatedAt: %v -> %v", user.UpdatedAt, stored.UpdatedAt)
+	}
+
+	// Update не сбрасывает отметку
+	stored.Position = "coach"
+	stored.SessionsRevokedAt = time.Time{}
+	must(t, repo.Update(stored))
+	updated, err := repo.GetByID(user.ID)
+	must(t, err)
+	if updated.SessionsRevokedAt.IsZero() {
+		t.Error("Update cleared SessionsRevokedAt")
+	}
+
+	if err := repo.RevokeSessions(user.ID + 100); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("RevokeSessions of a missing user = %v, want ErrUserNotFound", err)
+	}
+}
+
+// testClearActiveTeam проверяет сброс активной команды. Выбор существующей
+// команды проверяется вместе с domain.TeamRepository
+func testClearActiveTeam(t *testing.T, repo domain.UserRepository) {
+	user := mustSaveUser(t, repo, &domain.User{Username: "ivan", Password: "hash", Role: "user", ActiveTeamID: 5})
+	stored, err := repo.GetByID(user.ID)
+	must(t, err)
+	if stored.ActiveTeamID != 0 {
+		t.Errorf("Save stored ActiveTeamID = %d, want 0", stored.ActiveTeamID)
+	}
+
+	must(t, repo.SetActiveTeam(user.ID, 0))
+	stored, err = repo.GetByID(user.ID)
+	must(t, err)
+	if stored.ActiveTeamID != 0 {
+		t.Errorf("ActiveTeamID after clear = %d, want 0", stored.ActiveTeamID)
+	}
+
+	if err := repo.SetActiveTeam(user.ID+100, 0); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("SetActiveTeam of a missing user = %v, want ErrUserNotFound", err)
+	}
+}
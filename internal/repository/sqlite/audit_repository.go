@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// AuditRepository реализует интерфейс domain.AuditRepository для SQLite
+type AuditRepository struct {
+	db *sql.DB
+}
+
+// NewAuditRepository создает новый экземпляр AuditRepository
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+// Append добавляет запись в журнал
+func (r *AuditRepository) Append(entry *domain.AuditEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO audit_log (actor_id, telegram_id, target_id, action, details, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		entry.ActorID,
+		entry.TelegramID,
+		entry.TargetID,
+		entry.Action,
+		entry.Details,
+		entry.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get audit entry id: %w", err)
+	}
+	entry.ID = id
+	return nil
+}
+
+// Find возвращает записи журнала по фильтру, начиная с самых новых
+func (r *AuditRepository) Find(filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	var conditions []string
+	var args []any
+
+	if filter.UserID != 0 {
+		conditions = append(conditions, "(actor_id = ? OR target_id = ?)")
+		args = append(args, filter.UserID, filter.UserID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To)
+	}
+
+	query := "SELECT id, actor_id, telegram_id, target_id, action, details, created_at FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.AuditEntry
+	for rows.Next() {
+		entry := &domain.AuditEntry{}
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ActorID,
+			&entry.TelegramID,
+			&entry.TargetID,
+			&entry.Action,
+			&entry.Details,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
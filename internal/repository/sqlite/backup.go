@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Snapshot записывает согласованную копию открытой базы данных в новый файл.
+// VACUUM INTO выполняется в рамках одной транзакции чтения, поэтому бот может
+// продолжать работу во время резервного копирования
+func Snapshot(db *sql.DB, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("snapshot file %s already exists", path)
+	}
+	if _, err := db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	return nil
+}
+
+// OpenReadOnly открывает существующий файл базы данных только для чтения,
+// не применяя миграции. Такого подключения достаточно для Snapshot
+func OpenReadOnly(path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro&_foreign_keys=on")
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Check открывает файл базы данных только для чтения, проверяет его целостность
+// и возвращает версию схемы. Базы, созданные более новой версией бота, не принимаются
+func Check(path string) (int, error) {
+	db, err := OpenReadOnly(path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return 0, fmt.Errorf("not a valid database: %w", err)
+	}
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(problems) > 0 {
+		return 0, fmt.Errorf("integrity check failed: %s", strings.Join(problems, "; "))
+	}
+
+	var violations int
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_foreign_key_check").Scan(&violations); err != nil {
+		return 0, err
+	}
+	if violations > 0 {
+		return 0, fmt.Errorf("foreign key check failed: %d violations", violations)
+	}
+
+	var version int
+	err = db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	switch {
+	case err != nil && strings.Contains(err.Error(), "no such table"):
+		return 0, errors.New("not a HelpBot database: schema_migrations table is missing")
+	case err != nil:
+		return 0, err
+	case version == 0:
+		return 0, errors.New("not a HelpBot database: no migrations applied")
+	case version > len(migrations):
+		return 0, fmt.Errorf("database schema version %d is newer than supported %d", version, len(migrations))
+	}
+	return version, nil
+}
@@ -2,14 +2,360 @@ package sqlite
 
 import (
 	"database/sql"
-	"log"
+	"fmt"
+	"log/slog"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// migrations содержит изменения схемы базы данных в порядке применения.
+// Уже примененные миграции нельзя изменять, только добавлять новые в конец
+var migrations = []string{
+	// 1: пользователи, привязанные к Telegram-аккаунтам
+	`CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		telegram_id INTEGER UNIQUE,
+		chat_id INTEGER NOT NULL DEFAULT 0,
+		username TEXT UNIQUE NOT NULL,
+		password TEXT NOT NULL DEFAULT '',
+		role TEXT NOT NULL DEFAULT 'user',
+		position TEXT NOT NULL DEFAULT '',
+		birthday TEXT NOT NULL DEFAULT '',
+		number TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`,
+	// 2: запросы на перенос аккаунта на другой Telegram-аккаунт
+	`CREATE TABLE account_transfers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		from_telegram_id INTEGER NOT NULL DEFAULT 0,
+		to_telegram_id INTEGER NOT NULL,
+		to_chat_id INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		resolved_by INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		resolved_at TIMESTAMP
+	)`,
+	// 3: роли как наборы прав и встроенные роли
+	`CREATE TABLE roles (
+		name TEXT PRIMARY KEY,
+		description TEXT NOT NULL DEFAULT '',
+		built_in INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE role_permissions (
+		role TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+		permission TEXT NOT NULL,
+		PRIMARY KEY (role, permission)
+	);
+	INSERT INTO roles (name, description, built_in) VALUES
+		('admin', 'Администратор', 1),
+		('user', 'Участник', 1),
+		('treasurer', 'Казначей', 1),
+		('coordinator', 'Координатор', 1),
+		('viewer', 'Наблюдатель', 1);
+	INSERT INTO role_permissions (role, permission) VALUES
+		('treasurer', 'payments.confirm'),
+		('treasurer', 'users.view'),
+		('coordinator', 'events.manage'),
+		('coordinator', 'users.view'),
+		('viewer', 'users.view')`,
+	// 4: журнал аудита, защищенный от изменения и удаления записей
+	`CREATE TABLE audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		actor_id INTEGER NOT NULL DEFAULT 0,
+		telegram_id INTEGER NOT NULL DEFAULT 0,
+		target_id INTEGER NOT NULL DEFAULT 0,
+		action TEXT NOT NULL,
+		details TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+	CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit_log is append-only');
+	END;
+	CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit_log is append-only');
+	END`,
+	// 5: TOTP двухфакторная аутентификация и коды восстановления
+	`CREATE TABLE user_totp (
+		user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		secret TEXT NOT NULL,
+		enabled INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP NOT NULL,
+		enabled_at TIMESTAMP
+	);
+	CREATE TABLE recovery_codes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		code_hash TEXT NOT NULL,
+		used_at TIMESTAMP
+	)`,
+	// 6: язык интерфейса пользователя
+	`ALTER TABLE users ADD COLUMN language TEXT NOT NULL DEFAULT ''`,
+	// 7: состояние учетной записи вместо удаления строк
+	`ALTER TABLE users ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+	ALTER TABLE users ADD COLUMN status_reason TEXT NOT NULL DEFAULT '';
+	ALTER TABLE users ADD COLUMN status_changed_at DATETIME;
+	ALTER TABLE users ADD COLUMN purged_at DATETIME;
+	CREATE INDEX idx_users_status ON users(status)`,
+	// 8: приглашения пользователей, созданных импортом состава команды
+	`CREATE TABLE invites (
+		user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		code_hash TEXT UNIQUE NOT NULL,
+		created_by INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL
+	)`,
+	// 9: время, до которого выданные сессии и токены пользователя недействительны
+	`ALTER TABLE users ADD COLUMN sessions_revoked_at DATETIME`,
+	// 10: команды, участники с ролями в команде и активная команда пользователя.
+	// Существующие пользователи переносятся в основную команду со своими ролями
+	`CREATE TABLE teams (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE team_members (
+		team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		role TEXT NOT NULL DEFAULT 'user',
+		joined_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (team_id, user_id)
+	);
+	CREATE INDEX idx_team_members_user_id ON team_members(user_id);
+	ALTER TABLE users ADD COLUMN active_team_id INTEGER REFERENCES teams(id) ON DELETE SET NULL;
+	INSERT INTO teams (name) SELECT 'Основная команда' WHERE EXISTS (SELECT 1 FROM users);
+	INSERT INTO team_members (team_id, user_id, role)
+		SELECT t.id, u.id, u.role FROM teams t CROSS JOIN users u WHERE u.purged_at IS NULL;
+	UPDATE users SET active_team_id = (SELECT MIN(id) FROM teams) WHERE purged_at IS NULL`,
+	// 11: планы взносов и операции по балансам участников команд. Уникальный ключ
+	// начисления (план, пользователь, период) делает начисление взносов идемпотентным
+	`CREATE TABLE dues_plans (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		amount INTEGER NOT NULL,
+		period TEXT NOT NULL,
+		start_date TEXT NOT NULL,
+		charged_through TEXT,
+		active INTEGER NOT NULL DEFAULT 1,
+		created_by INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		UNIQUE (team_id, name)
+	);
+	CREATE TABLE dues_plan_members (
+		plan_id INTEGER NOT NULL REFERENCES dues_plans(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		PRIMARY KEY (plan_id, user_id)
+	);
+	CREATE TABLE balance_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		kind TEXT NOT NULL,
+		amount INTEGER NOT NULL,
+		plan_id INTEGER REFERENCES dues_plans(id) ON DELETE CASCADE,
+		period TEXT,
+		note TEXT NOT NULL DEFAULT '',
+		created_by INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		UNIQUE (plan_id, user_id, period)
+	);
+	CREATE INDEX idx_balance_entries_team_user ON balance_entries(team_id, user_id);
+	INSERT INTO role_permissions (role, permission)
+		SELECT name, 'dues.manage' FROM roles WHERE name = 'treasurer'`,
+	// 12: общие расходы участников команд и их доли. Операции по балансам ссылаются
+	// на расход, из которого они получены
+	`CREATE TABLE expenses (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		payer_id INTEGER NOT NULL REFERENCES users(id),
+		amount INTEGER NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		mode TEXT NOT NULL,
+		settlement INTEGER NOT NULL DEFAULT 0,
+		created_by INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL
+	);
+	CREATE INDEX idx_expenses_team ON expenses(team_id, created_at);
+	CREATE TABLE expense_shares (
+		expense_id INTEGER NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		value INTEGER NOT NULL DEFAULT 0,
+		amount INTEGER NOT NULL,
+		PRIMARY KEY (expense_id, user_id)
+	);
+	ALTER TABLE balance_entries ADD COLUMN expense_id INTEGER REFERENCES expenses(id) ON DELETE CASCADE`,
+	// 13: события команд и ответы участников на приглашения
+	`CREATE TABLE events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		title TEXT NOT NULL,
+		location TEXT NOT NULL DEFAULT '',
+		starts_at DATETIME NOT NULL,
+		capacity INTEGER NOT NULL DEFAULT 0,
+		deadline DATETIME,
+		canceled INTEGER NOT NULL DEFAULT 0,
+		reminded_at DATETIME,
+		created_by INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL
+	);
+	CREATE INDEX idx_events_team_start ON events(team_id, starts_at);
+	CREATE TABLE event_rsvps (
+		event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		status TEXT NOT NULL,
+		responded_at DATETIME NOT NULL,
+		PRIMARY KEY (event_id, user_id)
+	)`,
+	// 14: опросы команд, голоса и отправленные сообщения с результатами
+	`CREATE TABLE polls (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		question TEXT NOT NULL,
+		multiple INTEGER NOT NULL DEFAULT 0,
+		anonymous INTEGER NOT NULL DEFAULT 0,
+		role TEXT NOT NULL DEFAULT '',
+		deadline DATETIME,
+		closed_at DATETIME,
+		created_by INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL
+	);
+	CREATE INDEX idx_polls_team ON polls(team_id, closed_at);
+	CREATE TABLE poll_options (
+		poll_id INTEGER NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		text TEXT NOT NULL,
+		PRIMARY KEY (poll_id, position)
+	);
+	CREATE TABLE poll_votes (
+		poll_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		voted_at DATETIME NOT NULL,
+		PRIMARY KEY (poll_id, user_id, position),
+		FOREIGN KEY (poll_id, position) REFERENCES poll_options(poll_id, position) ON DELETE CASCADE
+	);
+	CREATE TABLE poll_messages (
+		poll_id INTEGER NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+		chat_id INTEGER NOT NULL,
+		message_id INTEGER NOT NULL,
+		language TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (chat_id, message_id)
+	)`,
+	// 15: отметки о посещении событий
+	`CREATE TABLE event_attendance (
+		event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		status TEXT NOT NULL,
+		self_check_in INTEGER NOT NULL DEFAULT 0,
+		marked_by INTEGER NOT NULL DEFAULT 0,
+		marked_at DATETIME NOT NULL,
+		PRIMARY KEY (event_id, user_id)
+	)`,
+	// 16: графики дежурств с очередью участников, дежурства и запросы на обмен
+	`CREATE TABLE duty_rosters (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		period TEXT NOT NULL,
+		start_date TEXT NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		assigned_through TEXT,
+		active INTEGER NOT NULL DEFAULT 1,
+		created_by INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		UNIQUE (team_id, name)
+	);
+	CREATE TABLE duty_roster_members (
+		roster_id INTEGER NOT NULL REFERENCES duty_rosters(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		PRIMARY KEY (roster_id, position)
+	);
+	CREATE TABLE duty_assignments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		roster_id INTEGER NOT NULL REFERENCES duty_rosters(id) ON DELETE CASCADE,
+		team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		period TEXT NOT NULL,
+		until TEXT NOT NULL,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		completed_at DATETIME,
+		completed_by INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		UNIQUE (roster_id, period)
+	);
+	CREATE INDEX idx_duty_assignments_team ON duty_assignments(team_id, period);
+	CREATE TABLE duty_swaps (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		assignment_id INTEGER NOT NULL REFERENCES duty_assignments(id) ON DELETE CASCADE,
+		from_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		to_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		status TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		resolved_at DATETIME
+	);
+	CREATE INDEX idx_duty_swaps_assignment ON duty_swaps(assignment_id, status);
+	INSERT INTO role_permissions (role, permission)
+		SELECT name, 'duties.manage' FROM roles WHERE name = 'coordinator'`,
+	// 17: объявления команд и отметки получателей о прочтении
+	`CREATE TABLE announcements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+		text TEXT NOT NULL,
+		chat_id INTEGER NOT NULL DEFAULT 0,
+		message_id INTEGER NOT NULL DEFAULT 0,
+		active INTEGER NOT NULL DEFAULT 1,
+		created_by INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		closed_at DATETIME
+	);
+	CREATE INDEX idx_announcements_team ON announcements(team_id, active);
+	CREATE TABLE announcement_receipts (
+		announcement_id INTEGER NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		acknowledged_at DATETIME,
+		reminders INTEGER NOT NULL DEFAULT 0,
+		reminded_at DATETIME,
+		PRIMARY KEY (announcement_id, user_id)
+	);
+	INSERT INTO role_permissions (role, permission)
+		SELECT name, 'announcements.manage' FROM roles WHERE name = 'coordinator'`,
+	// 18: обращения в поддержку и переписка по ним
+	`CREATE TABLE tickets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		text TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'open',
+		assignee_id INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		closed_at DATETIME
+	);
+	CREATE INDEX idx_tickets_user ON tickets(user_id);
+	CREATE INDEX idx_tickets_status ON tickets(status);
+	CREATE TABLE ticket_messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		ticket_id INTEGER NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+		author_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		staff INTEGER NOT NULL DEFAULT 0,
+		text TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+	CREATE INDEX idx_ticket_messages_ticket ON ticket_messages(ticket_id)`,
+	// 19: группа Telegram команды для объявлений и опросов
+	`ALTER TABLE teams ADD COLUMN group_chat_id INTEGER NOT NULL DEFAULT 0`,
+	// 20: период последнего принятого кода 2FA, чтобы один код нельзя было использовать повторно
+	`ALTER TABLE user_totp ADD COLUMN last_counter INTEGER NOT NULL DEFAULT 0`,
+}
+
 // NewDB создает новое подключение к базе данных SQLite
-func NewDB(dbPath string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+func NewDB(dbPath string, logger *slog.Logger) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on")
 	if err != nil {
 		return nil, err
 	}
@@ -18,31 +364,50 @@ func NewDB(dbPath string) (*sql.DB, error) {
 		return nil, err
 	}
 
-	if err := createTables(db); err != nil {
+	if err := migrate(db, logger); err != nil {
 		return nil, err
 	}
 
 	return db, nil
 }
 
-// createTables создает необходимые таблицы в базе данных
-func createTables(db *sql.DB) error {
-	// Создаем таблицу пользователей с полями для авторизации
+// migrate применяет к базе данных еще не примененные миграции
+func migrate(db *sql.DB, logger *slog.Logger) error {
 	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS users (
-			chat_id INTEGER PRIMARY KEY,
-			username TEXT UNIQUE NOT NULL,
-			position TEXT NOT NULL
-			password TEXT NOT NULL,
-			role TEXT DEFAULT 'user',
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)
 	`)
 	if err != nil {
-		log.Printf("Error creating users table: %v", err)
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
 		return err
 	}
 
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %d: %w", version, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		logger.Info("Applied migration", "version", version)
+	}
+
 	return nil
 }
@@ -0,0 +1,188 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// planColumns - колонки плана взносов в порядке, ожидаемом scanPlan
+const planColumns = `id, team_id, name, amount, period, start_date, charged_through, active, created_by, created_at`
+
+// DuesRepository реализует интерфейс domain.DuesRepository для SQLite
+type DuesRepository struct {
+	db *sql.DB
+}
+
+// NewDuesRepository создает новый экземпляр DuesRepository
+func NewDuesRepository(db *sql.DB) *DuesRepository {
+	return &DuesRepository{
+		db: db,
+	}
+}
+
+// nullableDate преобразует нулевую дату в NULL, остальные - в строку вида 2006-01-02
+func nullableDate(date time.Time) sql.NullString {
+	if date.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: date.Format(domain.DateLayout), Valid: true}
+}
+
+// parseDate разбирает дату из строки вида 2006-01-02; NULL дает нулевую дату
+func parseDate(value sql.NullString) (time.Time, error) {
+	if !value.Valid {
+		return time.Time{}, nil
+	}
+	return time.Parse(domain.DateLayout, value.String)
+}
+
+// scanPlan считывает план взносов из строки результата
+func scanPlan(row scanner) (*domain.DuesPlan, error) {
+	var plan domain.DuesPlan
+	var startDate, chargedThrough sql.NullString
+	err := row.Scan(&plan.ID, &plan.TeamID, &plan.Name, &plan.Amount, &plan.Period,
+		&startDate, &chargedThrough, &plan.Active, &plan.CreatedBy, &plan.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if plan.StartDate, err = parseDate(startDate); err != nil {
+		return nil, fmt.Errorf("invalid start date of dues plan %d: %w", plan.ID, err)
+	}
+	if plan.ChargedThrough, err = parseDate(chargedThrough); err != nil {
+		return nil, fmt.Errorf("invalid charged date of dues plan %d: %w", plan.ID, err)
+	}
+	return &plan, nil
+}
+
+// SavePlan сохраняет новый план взносов вместе со списком его участников
+func (r *DuesRepository) SavePlan(plan *domain.DuesPlan) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`
+		INSERT INTO dues_plans (team_id, name, amount, period, start_date, charged_through, active, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		plan.TeamID, plan.Name, plan.Amount, plan.Period, nullableDate(plan.StartDate),
+		nullableDate(plan.ChargedThrough), plan.Active, plan.CreatedBy, now)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %q", domain.ErrDuesPlanExists, plan.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save dues plan: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get dues plan id: %w", err)
+	}
+
+	for _, userID := range plan.MemberIDs {
+		if _, err := tx.Exec("INSERT INTO dues_plan_members (plan_id, user_id) VALUES (?, ?)", id, userID); err != nil {
+			return fmt.Errorf("failed to save dues plan member: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	plan.ID = id
+	plan.CreatedAt = now
+	return nil
+}
+
+// GetPlan возвращает план взносов по его идентификатору
+func (r *DuesRepository) GetPlan(id int64) (*domain.DuesPlan, error) {
+	plans, err := r.plans("id = ?", id)
+	if err != nil || len(plans) == 0 {
+		return nil, err
+	}
+	return plans[0], nil
+}
+
+// GetPlans возвращает планы взносов команды, упорядоченные по названию
+func (r *DuesRepository) GetPlans(teamID int64) ([]*domain.DuesPlan, error) {
+	return r.plans("team_id = ?", teamID)
+}
+
+// GetActivePlans возвращает действующие планы взносов всех команд
+func (r *DuesRepository) GetActivePlans() ([]*domain.DuesPlan, error) {
+	return r.plans("active = 1")
+}
+
+// plans выбирает планы взносов по условию вместе с их участниками
+func (r *DuesRepository) plans(where string, args ...any) ([]*domain.DuesPlan, error) {
+	rows, err := r.db.Query("SELECT "+planColumns+" FROM dues_plans WHERE "+where+" ORDER BY name, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plans []*domain.DuesPlan
+	for rows.Next() {
+		plan, err := scanPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, plan := range plans {
+		if plan.MemberIDs, err = r.members(plan.ID); err != nil {
+			return nil, err
+		}
+	}
+	return plans, nil
+}
+
+// members возвращает идентификаторы участников плана взносов
+func (r *DuesRepository) members(planID int64) ([]int64, error) {
+	rows, err := r.db.Query("SELECT user_id FROM dues_plan_members WHERE plan_id = ? ORDER BY user_id", planID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SetActive включает или останавливает план взносов
+func (r *DuesRepository) SetActive(id int64, active bool) error {
+	return planAffected(r.db.Exec("UPDATE dues_plans SET active = ? WHERE id = ?", active, id))
+}
+
+// SetChargedThrough отмечает период, по который включительно начислены взносы плана
+func (r *DuesRepository) SetChargedThrough(id int64, period time.Time) error {
+	return planAffected(r.db.Exec("UPDATE dues_plans SET charged_through = ? WHERE id = ?", nullableDate(period), id))
+}
+
+// planAffected приводит отсутствие измененных строк к domain.ErrDuesPlanNotFound
+func planAffected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrDuesPlanNotFound
+	}
+	return nil
+}
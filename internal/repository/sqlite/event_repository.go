@@ -0,0 +1,232 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// eventColumns - колонки события в порядке, ожидаемом scanEvent, вместе с числом
+// идущих участников и участников в листе ожидания
+const eventColumns = `e.id, e.team_id, e.title, e.location, e.starts_at, e.capacity, e.deadline, e.canceled,
+	e.reminded_at, e.created_by, e.created_at,
+	(SELECT COUNT(*) FROM event_rsvps r WHERE r.event_id = e.id AND r.status = 'going'),
+	(SELECT COUNT(*) FROM event_rsvps r WHERE r.event_id = e.id AND r.status = 'waitlist')`
+
+// EventRepository реализует интерфейс domain.EventRepository для SQLite
+type EventRepository struct {
+	db *sql.DB
+}
+
+// NewEventRepository создает новый экземпляр EventRepository
+func NewEventRepository(db *sql.DB) *EventRepository {
+	return &EventRepository{
+		db: db,
+	}
+}
+
+// nullableTime преобразует нулевое время в NULL
+func nullableTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// scanEvent считывает событие из строки результата
+func scanEvent(row scanner) (*domain.Event, error) {
+	var event domain.Event
+	var deadline, remindedAt sql.NullTime
+	err := row.Scan(&event.ID, &event.TeamID, &event.Title, &event.Location, &event.StartsAt, &event.Capacity,
+		&deadline, &event.Canceled, &remindedAt, &event.CreatedBy, &event.CreatedAt, &event.Going, &event.Waitlisted)
+	if err != nil {
+		return nil, err
+	}
+	event.Deadline = deadline.Time
+	event.RemindedAt = remindedAt.Time
+	return &event, nil
+}
+
+// Save сохраняет новое событие
+func (r *EventRepository) Save(event *domain.Event) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO events (team_id, title, location, starts_at, capacity, deadline, canceled, reminded_at, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.TeamID, event.Title, event.Location, event.StartsAt, event.Capacity, nullableTime(event.Deadline),
+		event.Canceled, nullableTime(event.RemindedAt), event.CreatedBy, now)
+	if err != nil {
+		return fmt.Errorf("failed to save event: %w", err)
+	}
+	if event.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get event id: %w", err)
+	}
+	event.CreatedAt = now
+	return nil
+}
+
+// GetByID возвращает событие по его идентификатору
+func (r *EventRepository) GetByID(id int64) (*domain.Event, error) {
+	event, err := scanEvent(r.db.QueryRow("SELECT "+eventColumns+" FROM events e WHERE e.id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return event, err
+}
+
+// GetUpcoming возвращает неотмененные события команды, которые начинаются не раньше from
+func (r *EventRepository) GetUpcoming(teamID int64, from time.Time) ([]*domain.Event, error) {
+	return r.events("e.team_id = ? AND e.canceled = 0 AND e.starts_at >= ?", teamID, from)
+}
+
+// GetUnreminded возвращает неотмененные события, которые начинаются не позже until
+// и о которых еще не напоминали
+func (r *EventRepository) GetUnreminded(until time.Time) ([]*domain.Event, error) {
+	return r.events("e.canceled = 0 AND e.reminded_at IS NULL AND e.starts_at <= ?", until)
+}
+
+// events выбирает события по условию в порядке начала
+func (r *EventRepository) events(where string, args ...any) ([]*domain.Event, error) {
+	rows, err := r.db.Query("SELECT "+eventColumns+" FROM events e WHERE "+where+" ORDER BY e.starts_at, e.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*domain.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// Cancel отмечает событие отмененным
+func (r *EventRepository) Cancel(id int64) error {
+	return eventAffected(r.db.Exec("UPDATE events SET canceled = 1 WHERE id = ?", id))
+}
+
+// MarkReminded отмечает, что напоминание о событии отправлено
+func (r *EventRepository) MarkReminded(id int64, at time.Time) error {
+	return eventAffected(r.db.Exec("UPDATE events SET reminded_at = ? WHERE id = ?", at, id))
+}
+
+// Respond записывает ответ участника с учетом числа мест. Повторный ответ «иду» не меняет
+// ни места, ни очереди в листе ожидания
+func (r *EventRepository) Respond(rsvp *domain.RSVP) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var capacity int
+	err = tx.QueryRow("SELECT capacity FROM events WHERE id = ?", rsvp.EventID).Scan(&capacity)
+	if err == sql.ErrNoRows {
+		return 0, domain.ErrEventNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var previous domain.RSVPStatus
+	var respondedAt time.Time
+	err = tx.QueryRow("SELECT status, responded_at FROM event_rsvps WHERE event_id = ? AND user_id = ?",
+		rsvp.EventID, rsvp.UserID).Scan(&previous, &respondedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	status := rsvp.Status
+	switch {
+	case status == domain.RSVPGoing && (previous == domain.RSVPGoing || previous == domain.RSVPWaitlist):
+		status = previous
+	case status == domain.RSVPGoing && capacity > 0:
+		var going int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM event_rsvps WHERE event_id = ? AND status = 'going'",
+			rsvp.EventID).Scan(&going); err != nil {
+			return 0, err
+		}
+		if going >= capacity {
+			status = domain.RSVPWaitlist
+		}
+	}
+	if status == previous {
+		rsvp.Status = previous
+		rsvp.RespondedAt = respondedAt
+		return 0, nil
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`
+		INSERT INTO event_rsvps (event_id, user_id, status, responded_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (event_id, user_id) DO UPDATE SET status = excluded.status, responded_at = excluded.responded_at`,
+		rsvp.EventID, rsvp.UserID, status, now); err != nil {
+		return 0, fmt.Errorf("failed to save rsvp: %w", err)
+	}
+
+	// Освободившееся место получает тот, кто раньше встал в лист ожидания
+	var promotedID int64
+	if previous == domain.RSVPGoing && capacity > 0 {
+		err := tx.QueryRow(`
+			SELECT user_id FROM event_rsvps WHERE event_id = ? AND status = 'waitlist'
+			ORDER BY responded_at, user_id LIMIT 1`, rsvp.EventID).Scan(&promotedID)
+		if err != nil && err != sql.ErrNoRows {
+			return 0, err
+		}
+		if promotedID != 0 {
+			if _, err := tx.Exec("UPDATE event_rsvps SET status = 'going', responded_at = ? WHERE event_id = ? AND user_id = ?",
+				now, rsvp.EventID, promotedID); err != nil {
+				return 0, fmt.Errorf("failed to promote from waitlist: %w", err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	rsvp.Status = status
+	rsvp.RespondedAt = now
+	return promotedID, nil
+}
+
+// GetRSVPs возвращает ответы на приглашение в порядке ответа
+func (r *EventRepository) GetRSVPs(eventID int64) ([]*domain.RSVP, error) {
+	rows, err := r.db.Query(`
+		SELECT r.event_id, r.user_id, u.username, r.status, r.responded_at
+		FROM event_rsvps r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.event_id = ?
+		ORDER BY r.responded_at, r.user_id`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rsvps []*domain.RSVP
+	for rows.Next() {
+		var rsvp domain.RSVP
+		if err := rows.Scan(&rsvp.EventID, &rsvp.UserID, &rsvp.Username, &rsvp.Status, &rsvp.RespondedAt); err != nil {
+			return nil, err
+		}
+		rsvps = append(rsvps, &rsvp)
+	}
+	return rsvps, rows.Err()
+}
+
+// eventAffected приводит отсутствие измененных строк к domain.ErrEventNotFound
+func eventAffected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrEventNotFound
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"HelpBot/internal/domain"
+)
+
+// InviteRepository реализует интерфейс domain.InviteRepository для SQLite
+type InviteRepository struct {
+	db *sql.DB
+}
+
+// NewInviteRepository создает новый экземпляр InviteRepository
+func NewInviteRepository(db *sql.DB) *InviteRepository {
+	return &InviteRepository{
+		db: db,
+	}
+}
+
+// Save сохраняет приглашение, заменяя предыдущее приглашение пользователя
+func (r *InviteRepository) Save(invite *domain.Invite) error {
+	_, err := r.db.Exec(`
+		INSERT INTO invites (user_id, code_hash, created_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET code_hash = excluded.code_hash, created_by = excluded.created_by,
+			created_at = excluded.created_at, expires_at = excluded.expires_at`,
+		invite.UserID, invite.CodeHash, invite.CreatedBy, invite.CreatedAt, invite.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save invite: %w", err)
+	}
+	return nil
+}
+
+// GetByCodeHash возвращает приглашение по хешу кода
+func (r *InviteRepository) GetByCodeHash(codeHash string) (*domain.Invite, error) {
+	var invite domain.Invite
+	err := r.db.QueryRow(`
+		SELECT user_id, code_hash, created_by, created_at, expires_at
+		FROM invites
+		WHERE code_hash = ?`, codeHash).Scan(
+		&invite.UserID,
+		&invite.CodeHash,
+		&invite.CreatedBy,
+		&invite.CreatedAt,
+		&invite.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// Delete удаляет приглашение пользователя
+func (r *InviteRepository) Delete(userID int64) error {
+	_, err := r.db.Exec("DELETE FROM invites WHERE user_id = ?", userID)
+	return err
+}
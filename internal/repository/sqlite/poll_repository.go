@@ -0,0 +1,280 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// pollColumns - колонки опроса в порядке, ожидаемом scanPoll, вместе с числом проголосовавших
+const pollColumns = `p.id, p.team_id, p.question, p.multiple, p.anonymous, p.role, p.deadline, p.closed_at,
+	p.created_by, p.created_at,
+	(SELECT COUNT(DISTINCT v.user_id) FROM poll_votes v WHERE v.poll_id = p.id)`
+
+// PollRepository реализует интерфейс domain.PollRepository для SQLite
+type PollRepository struct {
+	db *sql.DB
+}
+
+// NewPollRepository создает новый экземпляр PollRepository
+func NewPollRepository(db *sql.DB) *PollRepository {
+	return &PollRepository{
+		db: db,
+	}
+}
+
+// scanPoll считывает опрос без вариантов ответа из строки результата
+func scanPoll(row scanner) (*domain.Poll, error) {
+	var poll domain.Poll
+	var deadline, closedAt sql.NullTime
+	err := row.Scan(&poll.ID, &poll.TeamID, &poll.Question, &poll.Multiple, &poll.Anonymous, &poll.Role,
+		&deadline, &closedAt, &poll.CreatedBy, &poll.CreatedAt, &poll.Voters)
+	if err != nil {
+		return nil, err
+	}
+	poll.Deadline = deadline.Time
+	poll.Closed = closedAt.Valid
+	poll.ClosedAt = closedAt.Time
+	return &poll, nil
+}
+
+// Save сохраняет новый опрос вместе с вариантами ответа
+func (r *PollRepository) Save(poll *domain.Poll) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`
+		INSERT INTO polls (team_id, question, multiple, anonymous, role, deadline, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		poll.TeamID, poll.Question, poll.Multiple, poll.Anonymous, poll.Role, nullableTime(poll.Deadline), poll.CreatedBy, now)
+	if err != nil {
+		return fmt.Errorf("failed to save poll: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get poll id: %w", err)
+	}
+	for i, option := range poll.Options {
+		if _, err := tx.Exec("INSERT INTO poll_options (poll_id, position, text) VALUES (?, ?, ?)", id, i+1, option.Text); err != nil {
+			return fmt.Errorf("failed to save poll option: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	poll.ID = id
+	poll.CreatedAt = now
+	poll.Closed = false
+	poll.ClosedAt = time.Time{}
+	for i, option := range poll.Options {
+		option.Position = i + 1
+	}
+	return nil
+}
+
+// GetByID возвращает опрос по его идентификатору
+func (r *PollRepository) GetByID(id int64) (*domain.Poll, error) {
+	poll, err := scanPoll(r.db.QueryRow("SELECT "+pollColumns+" FROM polls p WHERE p.id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if poll.Options, err = r.options(poll.ID); err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+// GetOpen возвращает незакрытые опросы команды
+func (r *PollRepository) GetOpen(teamID int64) ([]*domain.Poll, error) {
+	return r.polls("p.team_id = ? AND p.closed_at IS NULL", teamID)
+}
+
+// GetExpired возвращает незакрытые опросы, срок которых истек
+func (r *PollRepository) GetExpired(now time.Time) ([]*domain.Poll, error) {
+	return r.polls("p.closed_at IS NULL AND p.deadline IS NOT NULL AND p.deadline <= ?", now)
+}
+
+// polls выбирает опросы по условию в порядке создания вместе с вариантами ответа
+func (r *PollRepository) polls(where string, args ...any) ([]*domain.Poll, error) {
+	rows, err := r.db.Query("SELECT "+pollColumns+" FROM polls p WHERE "+where+" ORDER BY p.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var polls []*domain.Poll
+	for rows.Next() {
+		poll, err := scanPoll(rows)
+		if err != nil {
+			return nil, err
+		}
+		polls = append(polls, poll)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, poll := range polls {
+		if poll.Options, err = r.options(poll.ID); err != nil {
+			return nil, err
+		}
+	}
+	return polls, nil
+}
+
+// options возвращает варианты ответа опроса с числом голосов
+func (r *PollRepository) options(pollID int64) ([]*domain.PollOption, error) {
+	rows, err := r.db.Query(`
+		SELECT o.position, o.text,
+			(SELECT COUNT(*) FROM poll_votes v WHERE v.poll_id = o.poll_id AND v.position = o.position)
+		FROM poll_options o
+		WHERE o.poll_id = ?
+		ORDER BY o.position`, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var options []*domain.PollOption
+	for rows.Next() {
+		var option domain.PollOption
+		if err := rows.Scan(&option.Position, &option.Text, &option.Votes); err != nil {
+			return nil, err
+		}
+		options = append(options, &option)
+	}
+	return options, rows.Err()
+}
+
+// Close закрывает опрос, если он еще открыт
+func (r *PollRepository) Close(id int64, at time.Time) error {
+	result, err := r.db.Exec("UPDATE polls SET closed_at = ? WHERE id = ? AND closed_at IS NULL", at, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrPollNotFound
+	}
+	return nil
+}
+
+// Vote отмечает или снимает голос участника за вариант. Повторный голос за выбранный
+// вариант снимает его, а в опросе с одним вариантом ответа новый голос заменяет прежний
+func (r *PollRepository) Vote(vote *domain.PollVote, multiple bool) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var closedAt sql.NullTime
+	err = tx.QueryRow("SELECT closed_at FROM polls WHERE id = ?", vote.PollID).Scan(&closedAt)
+	if err == sql.ErrNoRows || closedAt.Valid {
+		return false, domain.ErrPollNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var voted int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM poll_votes WHERE poll_id = ? AND user_id = ? AND position = ?",
+		vote.PollID, vote.UserID, vote.Position).Scan(&voted); err != nil {
+		return false, err
+	}
+	if voted > 0 {
+		if _, err := tx.Exec("DELETE FROM poll_votes WHERE poll_id = ? AND user_id = ? AND position = ?",
+			vote.PollID, vote.UserID, vote.Position); err != nil {
+			return false, fmt.Errorf("failed to retract vote: %w", err)
+		}
+		return false, tx.Commit()
+	}
+
+	if !multiple {
+		if _, err := tx.Exec("DELETE FROM poll_votes WHERE poll_id = ? AND user_id = ?", vote.PollID, vote.UserID); err != nil {
+			return false, fmt.Errorf("failed to replace vote: %w", err)
+		}
+	}
+	now := time.Now()
+	if _, err := tx.Exec("INSERT INTO poll_votes (poll_id, position, user_id, voted_at) VALUES (?, ?, ?, ?)",
+		vote.PollID, vote.Position, vote.UserID, now); err != nil {
+		return false, fmt.Errorf("failed to save vote: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	vote.VotedAt = now
+	return true, nil
+}
+
+// GetVotes возвращает голоса опроса по вариантам в порядке голосования
+func (r *PollRepository) GetVotes(pollID int64) ([]*domain.PollVote, error) {
+	rows, err := r.db.Query(`
+		SELECT v.poll_id, v.position, v.user_id, u.username, v.voted_at
+		FROM poll_votes v
+		JOIN users u ON u.id = v.user_id
+		WHERE v.poll_id = ?
+		ORDER BY v.position, v.voted_at, v.user_id`, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var votes []*domain.PollVote
+	for rows.Next() {
+		var vote domain.PollVote
+		if err := rows.Scan(&vote.PollID, &vote.Position, &vote.UserID, &vote.Username, &vote.VotedAt); err != nil {
+			return nil, err
+		}
+		votes = append(votes, &vote)
+	}
+	return votes, rows.Err()
+}
+
+// AddMessage запоминает отправленное сообщение с опросом
+func (r *PollRepository) AddMessage(message *domain.PollMessage) error {
+	_, err := r.db.Exec(`
+		INSERT INTO poll_messages (poll_id, chat_id, message_id, language) VALUES (?, ?, ?, ?)
+		ON CONFLICT (chat_id, message_id) DO NOTHING`,
+		message.PollID, message.ChatID, message.MessageID, message.Language)
+	if err != nil {
+		return fmt.Errorf("failed to save poll message: %w", err)
+	}
+	return nil
+}
+
+// GetMessages возвращает сообщения с опросом
+func (r *PollRepository) GetMessages(pollID int64) ([]*domain.PollMessage, error) {
+	rows, err := r.db.Query(`
+		SELECT poll_id, chat_id, message_id, language FROM poll_messages
+		WHERE poll_id = ?
+		ORDER BY chat_id, message_id`, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*domain.PollMessage
+	for rows.Next() {
+		var message domain.PollMessage
+		if err := rows.Scan(&message.PollID, &message.ChatID, &message.MessageID, &message.Language); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &message)
+	}
+	return messages, rows.Err()
+}
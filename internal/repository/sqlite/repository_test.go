@@ -0,0 +1,22 @@
+package sqlite_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"HelpBot/internal/logging"
+	"HelpBot/internal/repository"
+	"HelpBot/internal/repository/repotest"
+)
+
+func TestRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repository.Repositories {
+		dsn := "sqlite:" + filepath.Join(t.TempDir(), "test.db")
+		repos, db, err := repository.Open(dsn, logging.Discard())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return repos
+	})
+}
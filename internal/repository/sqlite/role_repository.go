@@ -0,0 +1,167 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"HelpBot/internal/domain"
+)
+
+// RoleRepository реализует интерфейс domain.RoleRepository для SQLite
+type RoleRepository struct {
+	db *sql.DB
+}
+
+// NewRoleRepository создает новый экземпляр RoleRepository
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{
+		db: db,
+	}
+}
+
+// GetByName возвращает роль по ее имени
+func (r *RoleRepository) GetByName(name string) (*domain.Role, error) {
+	var role domain.Role
+	err := r.db.QueryRow(`
+		SELECT name, description, built_in
+		FROM roles
+		WHERE name = ?`, name).Scan(
+		&role.Name,
+		&role.Description,
+		&role.BuiltIn,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	role.Permissions, err = r.getPermissions(role.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GetAll возвращает все роли
+func (r *RoleRepository) GetAll() ([]*domain.Role, error) {
+	rows, err := r.db.Query(`
+		SELECT name, description, built_in
+		FROM roles
+		ORDER BY built_in DESC, name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*domain.Role
+	for rows.Next() {
+		role := &domain.Role{}
+		if err := rows.Scan(&role.Name, &role.Description, &role.BuiltIn); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, role := range roles {
+		role.Permissions, err = r.getPermissions(role.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+// getPermissions возвращает права роли
+func (r *RoleRepository) getPermissions(name string) ([]domain.Permission, error) {
+	rows, err := r.db.Query("SELECT permission FROM role_permissions WHERE role = ? ORDER BY permission", name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []domain.Permission
+	for rows.Next() {
+		var permission domain.Permission
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, rows.Err()
+}
+
+// Save сохраняет новую роль вместе с ее правами
+func (r *RoleRepository) Save(role *domain.Role) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO roles (name, description, built_in)
+		VALUES (?, ?, ?)`,
+		role.Name,
+		role.Description,
+		role.BuiltIn,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save role: %w", err)
+	}
+
+	if err := replacePermissions(tx, role); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update обновляет описание и права существующей роли
+func (r *RoleRepository) Update(role *domain.Role) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE roles SET description = ? WHERE name = ?", role.Description, role.Name)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	if err := replacePermissions(tx, role); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// replacePermissions заменяет права роли на переданные
+func replacePermissions(tx *sql.Tx, role *domain.Role) error {
+	if _, err := tx.Exec("DELETE FROM role_permissions WHERE role = ?", role.Name); err != nil {
+		return fmt.Errorf("failed to clear role permissions: %w", err)
+	}
+	for _, permission := range role.Permissions {
+		if _, err := tx.Exec("INSERT INTO role_permissions (role, permission) VALUES (?, ?)", role.Name, permission); err != nil {
+			return fmt.Errorf("failed to save role permission: %w", err)
+		}
+	}
+	return nil
+}
+
+// Delete удаляет роль
+func (r *RoleRepository) Delete(name string) error {
+	_, err := r.db.Exec("DELETE FROM roles WHERE name = ?", name)
+	return err
+}
+
+// CountUsers возвращает количество пользователей с указанной ролью
+func (r *RoleRepository) CountUsers(name string) (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM users WHERE role = ?", name).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,175 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"HelpBot/internal/domain"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// TeamRepository реализует интерфейс domain.TeamRepository для SQLite
+type TeamRepository struct {
+	db *sql.DB
+}
+
+// NewTeamRepository создает новый экземпляр TeamRepository
+func NewTeamRepository(db *sql.DB) *TeamRepository {
+	return &TeamRepository{
+		db: db,
+	}
+}
+
+// isUniqueViolation проверяет, что ошибка вызвана нарушением уникальности или первичного ключа
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
+
+// Save сохраняет новую команду
+func (r *TeamRepository) Save(team *domain.Team) error {
+	now := time.Now()
+	result, err := r.db.Exec("INSERT INTO teams (name, created_at) VALUES (?, ?)", team.Name, now)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %q", domain.ErrTeamExists, team.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save team: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get team id: %w", err)
+	}
+	team.ID = id
+	team.CreatedAt = now
+	return nil
+}
+
+// GetByID возвращает команду по ее идентификатору
+func (r *TeamRepository) GetByID(id int64) (*domain.Team, error) {
+	var team domain.Team
+	err := r.db.QueryRow("SELECT id, name, group_chat_id, created_at FROM teams WHERE id = ?", id).
+		Scan(&team.ID, &team.Name, &team.GroupChatID, &team.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// GetAll возвращает все команды, упорядоченные по названию
+func (r *TeamRepository) GetAll() ([]*domain.Team, error) {
+	rows, err := r.db.Query("SELECT id, name, group_chat_id, created_at FROM teams ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teams []*domain.Team
+	for rows.Next() {
+		var team domain.Team
+		if err := rows.Scan(&team.ID, &team.Name, &team.GroupChatID, &team.CreatedAt); err != nil {
+			return nil, err
+		}
+		teams = append(teams, &team)
+	}
+	return teams, rows.Err()
+}
+
+// SetGroupChat привязывает к команде группу Telegram
+func (r *TeamRepository) SetGroupChat(teamID int64, chatID int64) error {
+	if _, err := r.db.Exec("UPDATE teams SET group_chat_id = ? WHERE id = ?", chatID, teamID); err != nil {
+		return fmt.Errorf("failed to set team group chat: %w", err)
+	}
+	return nil
+}
+
+// GetMember возвращает членство пользователя в команде
+func (r *TeamRepository) GetMember(teamID int64, userID int64) (*domain.TeamMember, error) {
+	members, err := r.members("m.team_id = ? AND m.user_id = ?", teamID, userID)
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+	return members[0], nil
+}
+
+// GetMemberships возвращает команды пользователя, упорядоченные по названию
+func (r *TeamRepository) GetMemberships(userID int64) ([]*domain.TeamMember, error) {
+	return r.members("m.user_id = ?", userID)
+}
+
+// members выбирает членства по условию вместе с названиями команд
+func (r *TeamRepository) members(where string, args ...any) ([]*domain.TeamMember, error) {
+	rows, err := r.db.Query(`
+		SELECT m.team_id, t.name, m.user_id, m.role, m.joined_at
+		FROM team_members m
+		JOIN teams t ON t.id = m.team_id
+		WHERE `+where+`
+		ORDER BY t.name, t.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*domain.TeamMember
+	for rows.Next() {
+		var member domain.TeamMember
+		if err := rows.Scan(&member.TeamID, &member.TeamName, &member.UserID, &member.Role, &member.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, &member)
+	}
+	return members, rows.Err()
+}
+
+// AddMember добавляет пользователя в команду
+func (r *TeamRepository) AddMember(member *domain.TeamMember) error {
+	now := time.Now()
+	_, err := r.db.Exec("INSERT INTO team_members (team_id, user_id, role, joined_at) VALUES (?, ?, ?, ?)",
+		member.TeamID, member.UserID, member.Role, now)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: user %d in team %d", domain.ErrMemberExists, member.UserID, member.TeamID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add team member: %w", err)
+	}
+	member.JoinedAt = now
+	return nil
+}
+
+// UpdateMemberRole изменяет роль участника команды
+func (r *TeamRepository) UpdateMemberRole(teamID int64, userID int64, role string) error {
+	return memberAffected(r.db.Exec("UPDATE team_members SET role = ? WHERE team_id = ? AND user_id = ?", role, teamID, userID))
+}
+
+// RemoveMember исключает пользователя из команды
+func (r *TeamRepository) RemoveMember(teamID int64, userID int64) error {
+	return memberAffected(r.db.Exec("DELETE FROM team_members WHERE team_id = ? AND user_id = ?", teamID, userID))
+}
+
+// FindMembers возвращает страницу участников команды
+func (r *TeamRepository) FindMembers(teamID int64, query domain.UserQuery) (*domain.UserPage, error) {
+	return findUsers(r.db, query, teamID)
+}
+
+// memberAffected приводит отсутствие измененных строк к domain.ErrMemberNotFound
+func memberAffected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrMemberNotFound
+	}
+	return nil
+}
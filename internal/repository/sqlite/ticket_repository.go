@@ -0,0 +1,184 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"HelpBot/internal/domain"
+)
+
+// ticketColumns - колонки обращения в порядке, ожидаемом scanTicket, вместе с именами
+// автора и исполнителя
+const ticketColumns = `t.id, t.user_id, t.text, t.status, t.assignee_id, t.created_at, t.updated_at, t.closed_at,
+	u.username, COALESCE(a.username, '')`
+
+// ticketFrom - таблицы, из которых выбираются обращения
+const ticketFrom = ` FROM tickets t
+	JOIN users u ON u.id = t.user_id
+	LEFT JOIN users a ON a.id = t.assignee_id`
+
+// TicketRepository реализует интерфейс domain.TicketRepository для SQLite
+type TicketRepository struct {
+	db *sql.DB
+}
+
+// NewTicketRepository создает новый экземпляр TicketRepository
+func NewTicketRepository(db *sql.DB) *TicketRepository {
+	return &TicketRepository{
+		db: db,
+	}
+}
+
+// scanTicket считывает обращение из строки результата
+func scanTicket(row scanner) (*domain.Ticket, error) {
+	var ticket domain.Ticket
+	var closedAt sql.NullTime
+	err := row.Scan(&ticket.ID, &ticket.UserID, &ticket.Text, &ticket.Status, &ticket.AssigneeID,
+		&ticket.CreatedAt, &ticket.UpdatedAt, &closedAt, &ticket.Username, &ticket.Assignee)
+	if err != nil {
+		return nil, err
+	}
+	ticket.ClosedAt = closedAt.Time
+	return &ticket, nil
+}
+
+// Save сохраняет новое обращение
+func (r *TicketRepository) Save(ticket *domain.Ticket) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO tickets (user_id, text, status, assignee_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		ticket.UserID, ticket.Text, ticket.Status, ticket.AssigneeID, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to save ticket: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get ticket id: %w", err)
+	}
+
+	ticket.ID = id
+	ticket.CreatedAt = now
+	ticket.UpdatedAt = now
+	return nil
+}
+
+// GetByID возвращает обращение по его идентификатору
+func (r *TicketRepository) GetByID(id int64) (*domain.Ticket, error) {
+	tickets, err := r.tickets("t.id = ?", "", id)
+	if err != nil || len(tickets) == 0 {
+		return nil, err
+	}
+	return tickets[0], nil
+}
+
+// GetByUser возвращает обращения пользователя от новых к старым
+func (r *TicketRepository) GetByUser(userID int64) ([]*domain.Ticket, error) {
+	return r.tickets("t.user_id = ?", "DESC", userID)
+}
+
+// GetByStatus возвращает обращения с указанными статусами от старых к новым
+func (r *TicketRepository) GetByStatus(statuses []domain.TicketStatus) ([]*domain.Ticket, error) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(statuses))
+	for i, status := range statuses {
+		args[i] = status
+	}
+	return r.tickets("t.status IN (?"+strings.Repeat(", ?", len(statuses)-1)+")", "", args...)
+}
+
+// tickets выбирает обращения по условию в порядке создания (order - пустой или DESC)
+func (r *TicketRepository) tickets(where, order string, args ...any) ([]*domain.Ticket, error) {
+	rows, err := r.db.Query("SELECT "+ticketColumns+ticketFrom+" WHERE "+where+
+		" ORDER BY t.created_at "+order+", t.id "+order, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickets []*domain.Ticket
+	for rows.Next() {
+		ticket, err := scanTicket(rows)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, ticket)
+	}
+	return tickets, rows.Err()
+}
+
+// Update сохраняет статус и исполнителя незакрытого обращения
+func (r *TicketRepository) Update(ticket *domain.Ticket) error {
+	now := time.Now()
+	var closedAt sql.NullTime
+	if ticket.Status == domain.TicketClosed {
+		closedAt = sql.NullTime{Time: now, Valid: true}
+	}
+	result, err := r.db.Exec(`
+		UPDATE tickets SET status = ?, assignee_id = ?, updated_at = ?, closed_at = ?
+		WHERE id = ? AND status <> ?`,
+		ticket.Status, ticket.AssigneeID, now, closedAt, ticket.ID, domain.TicketClosed)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrTicketClosed
+	}
+
+	ticket.UpdatedAt = now
+	ticket.ClosedAt = closedAt.Time
+	return nil
+}
+
+// AddMessage сохраняет сообщение в переписке по обращению
+func (r *TicketRepository) AddMessage(message *domain.TicketMessage) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		INSERT INTO ticket_messages (ticket_id, author_id, staff, text, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		message.TicketID, message.AuthorID, message.Staff, message.Text, now)
+	if err != nil {
+		return fmt.Errorf("failed to save ticket message: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get ticket message id: %w", err)
+	}
+
+	message.ID = id
+	message.CreatedAt = now
+	return nil
+}
+
+// GetMessages возвращает переписку по обращению от старых сообщений к новым
+func (r *TicketRepository) GetMessages(ticketID int64) ([]*domain.TicketMessage, error) {
+	rows, err := r.db.Query(`
+		SELECT m.id, m.ticket_id, m.author_id, m.staff, m.text, m.created_at, u.username
+		FROM ticket_messages m
+		JOIN users u ON u.id = m.author_id
+		WHERE m.ticket_id = ?
+		ORDER BY m.created_at, m.id`, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*domain.TicketMessage
+	for rows.Next() {
+		var message domain.TicketMessage
+		if err := rows.Scan(&message.ID, &message.TicketID, &message.AuthorID, &message.Staff, &message.Text,
+			&message.CreatedAt, &message.Username); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &message)
+	}
+	return messages, rows.Err()
+}
@@ -2,14 +2,29 @@ package sqlite
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"HelpBot/internal/domain"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
+// userColumns содержит список колонок, выбираемых для пользователя
+const userColumns = `id, telegram_id, chat_id, username, password, role, position, birthday, number, language, created_at, updated_at,
+	status, status_reason, status_changed_at, purged_at, sessions_revoked_at, active_team_id`
+
+// publicUserColumns содержит те же колонки, но без хеша пароля
+const publicUserColumns = `id, telegram_id, chat_id, username, '', role, position, birthday, number, language, created_at, updated_at,
+	status, status_reason, status_changed_at, purged_at, sessions_revoked_at, active_team_id`
+
+// scanner описывает общий метод Scan для sql.Row и sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
 // UserRepository реализует интерфейс domain.UserRepository для SQLite
 type UserRepository struct {
 	db *sql.DB
@@ -22,13 +37,45 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	}
 }
 
-// GetByID возвращает пользователя по его ChatID
-func (r *UserRepository) GetByID(chatID int64) (*domain.User, error) {
+// nullableID преобразует нулевой идентификатор в NULL
+func nullableID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
+// userError приводит ошибку нарушения уникальности к domain.ErrUserExists
+func userError(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return fmt.Errorf("%w: %v", domain.ErrUserExists, err)
+	}
+	return err
+}
+
+// userAffected проверяет результат изменения пользователя: ошибки уникальности
+// приводятся к domain.ErrUserExists, отсутствие измененных строк - к domain.ErrUserNotFound
+func userAffected(result sql.Result, err error) error {
+	if err != nil {
+		return userError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// scanUser считывает пользователя из строки результата. Значения дополнительных
+// колонок после колонок пользователя считываются в extra
+func scanUser(row scanner, extra ...any) (*domain.User, error) {
 	var user domain.User
-	err := r.db.QueryRow(`
-		SELECT chat_id, username, password, role, position, birthday, number, created_at, updated_at 
-		FROM users 
-		WHERE chat_id = ?`, chatID).Scan(
+	var telegramID, activeTeamID sql.NullInt64
+	var statusChangedAt, purgedAt, sessionsRevokedAt sql.NullTime
+	dest := []any{
+		&user.ID,
+		&telegramID,
 		&user.ChatID,
 		&user.Username,
 		&user.Password,
@@ -36,50 +83,69 @@ func (r *UserRepository) GetByID(chatID int64) (*domain.User, error) {
 		&user.Position,
 		&user.Birthday,
 		&user.Number,
+		&user.Language,
 		&user.CreatedAt,
 		&user.UpdatedAt,
-	)
-	if err == sql.ErrNoRows {
-		return nil, nil
+		&user.Status,
+		&user.StatusReason,
+		&statusChangedAt,
+		&purgedAt,
+		&sessionsRevokedAt,
+		&activeTeamID,
 	}
-	if err != nil {
+	if err := row.Scan(append(dest, extra...)...); err != nil {
 		return nil, err
 	}
+	user.TelegramID = telegramID.Int64
+	user.ActiveTeamID = activeTeamID.Int64
+	user.StatusChangedAt = statusChangedAt.Time
+	user.PurgedAt = purgedAt.Time
+	user.SessionsRevokedAt = sessionsRevokedAt.Time
 	return &user, nil
 }
 
-// GetByUsername возвращает пользователя по его имени пользователя
-func (r *UserRepository) GetByUsername(username string) (*domain.User, error) {
-	var user domain.User
-	err := r.db.QueryRow(`
-		SELECT chat_id, username, password, role, position, birthday, number, created_at, updated_at 
-		FROM users 
-		WHERE username = ?`, username).Scan(
-		&user.ChatID,
-		&user.Username,
-		&user.Password,
-		&user.Role,
-		&user.Position,
-		&user.Birthday,
-		&user.Number,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+// getOne возвращает пользователя по условию или nil, если он не найден
+func (r *UserRepository) getOne(where string, args ...any) (*domain.User, error) {
+	user, err := scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE "+where, args...))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &user, nil
+	return user, nil
+}
+
+// GetByID возвращает пользователя по его идентификатору
+func (r *UserRepository) GetByID(id int64) (*domain.User, error) {
+	return r.getOne("id = ?", id)
+}
+
+// GetByTelegramID возвращает пользователя, привязанного к Telegram-аккаунту
+func (r *UserRepository) GetByTelegramID(telegramID int64) (*domain.User, error) {
+	return r.getOne("telegram_id = ?", telegramID)
+}
+
+// GetByUsername возвращает пользователя по его имени пользователя
+func (r *UserRepository) GetByUsername(username string) (*domain.User, error) {
+	return r.getOne("username = ?", username)
 }
 
 // Save сохраняет нового пользователя
 func (r *UserRepository) Save(user *domain.User) error {
+	if user.ID != 0 {
+		return fmt.Errorf("failed to save user %d: %w", user.ID, domain.ErrUserExists)
+	}
+	now := time.Now()
+	if user.Status == "" {
+		user.Status = domain.UserActive
+	}
+
 	// Создаем нового пользователя
-	_, err := r.db.Exec(`
-		INSERT INTO users (chat_id, username, password, role, position, birthday, number, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	result, err := r.db.Exec(`
+		INSERT INTO users (telegram_id, chat_id, username, password, role, position, birthday, number, language, created_at, updated_at, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		nullableID(user.TelegramID),
 		user.ChatID,
 		user.Username,
 		user.Password,
@@ -87,45 +153,212 @@ func (r *UserRepository) Save(user *domain.User) error {
 		user.Position,
 		user.Birthday,
 		user.Number,
-		time.Now(),
-		time.Now(),
+		user.Language,
+		now,
+		now,
+		user.Status,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to save user: %w", err)
+		return fmt.Errorf("failed to save user: %w", userError(err))
 	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get user id: %w", err)
+	}
+	user.ID = id
+	user.CreatedAt = now
+	user.UpdatedAt = now
 	return nil
 }
 
-// Delete удаляет пользователя
-func (r *UserRepository) Delete(chatID int64) error {
-	_, err := r.db.Exec("DELETE FROM users WHERE chat_id = ?", chatID)
-	return err
+// UpdateStatus изменяет состояние учетной записи пользователя и причину изменения
+func (r *UserRepository) UpdateStatus(id int64, status domain.UserStatus, reason string) error {
+	now := time.Now()
+	return userAffected(r.db.Exec(`
+		UPDATE users SET status = ?, status_reason = ?, status_changed_at = ?, updated_at = ?
+		WHERE id = ?
+	`, status, reason, now, now, id))
+}
+
+// AnonymizeDeleted обезличивает пользователей, удаленных раньше указанного времени:
+// удаляет персональные данные и пароль, освобождает имя и Telegram-аккаунт
+func (r *UserRepository) AnonymizeDeleted(before time.Time) ([]int64, error) {
+	now := time.Now()
+	rows, err := r.db.Query(`
+		UPDATE users
+		SET username = ? || id, password = '', telegram_id = NULL, chat_id = 0, position = '', birthday = '', number = '',
+			status_reason = '', purged_at = ?, updated_at = ?
+		WHERE status = ? AND purged_at IS NULL AND status_changed_at < ?
+		RETURNING id`,
+		domain.AnonymizedPrefix, now, now, domain.UserDeleted, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to anonymize users: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// RevokeSessions завершает все сессии пользователя, выданные до текущего момента
+func (r *UserRepository) RevokeSessions(id int64) error {
+	now := time.Now()
+	return userAffected(r.db.Exec(`
+		UPDATE users SET sessions_revoked_at = ?, updated_at = ?
+		WHERE id = ?
+	`, now, now, id))
+}
+
+// SetActiveTeam выбирает активную команду пользователя
+func (r *UserRepository) SetActiveTeam(id int64, teamID int64) error {
+	return userAffected(r.db.Exec(`
+		UPDATE users SET active_team_id = ?, updated_at = ?
+		WHERE id = ?
+	`, nullableID(teamID), time.Now(), id))
 }
 
 // UpdatePassword обновляет пароль пользователя
-func (r *UserRepository) UpdatePassword(chatID int64, newPassword string) error {
-	_, err := r.db.Exec(`
-		UPDATE users SET password = ?, updated_at = CURRENT_TIMESTAMP 
-		WHERE chat_id = ?
-	`, newPassword, chatID)
-	return err
+func (r *UserRepository) UpdatePassword(id int64, newPassword string) error {
+	return userAffected(r.db.Exec(`
+		UPDATE users SET password = ?, updated_at = ?
+		WHERE id = ?
+	`, newPassword, time.Now(), id))
 }
 
 // UpdateRole обновляет роль пользователя
-func (r *UserRepository) UpdateRole(chatID int64, newRole string) error {
-	_, err := r.db.Exec(`
-		UPDATE users SET role = ?, updated_at = CURRENT_TIMESTAMP 
-		WHERE chat_id = ?
-	`, newRole, chatID)
-	return err
+func (r *UserRepository) UpdateRole(id int64, newRole string) error {
+	return userAffected(r.db.Exec(`
+		UPDATE users SET role = ?, updated_at = ?
+		WHERE id = ?
+	`, newRole, time.Now(), id))
 }
 
-// GetAll возвращает всех пользователей
-func (r *UserRepository) GetAll() ([]*domain.User, error) {
-	rows, err := r.db.Query(`
-		SELECT chat_id, username, password, role, position, birthday, number, created_at, updated_at 
-		FROM users
-	`)
+// BindTelegram привязывает пользователя к Telegram-аккаунту и чату доставки
+func (r *UserRepository) BindTelegram(id int64, telegramID int64, chatID int64) error {
+	err := userAffected(r.db.Exec(`
+		UPDATE users SET telegram_id = ?, chat_id = ?, updated_at = ?
+		WHERE id = ?
+	`, nullableID(telegramID), chatID, time.Now(), id))
+	if err != nil {
+		return fmt.Errorf("failed to bind user: %w", err)
+	}
+	return nil
+}
+
+// UpdateLanguage обновляет язык интерфейса пользователя
+func (r *UserRepository) UpdateLanguage(id int64, language string) error {
+	return userAffected(r.db.Exec(`
+		UPDATE users SET language = ?, updated_at = ?
+		WHERE id = ?
+	`, language, time.Now(), id))
+}
+
+// Find возвращает страницу пользователей, удовлетворяющих запросу
+func (r *UserRepository) Find(query domain.UserQuery) (*domain.UserPage, error) {
+	return findUsers(r.db, query, 0)
+}
+
+// findUsers выбирает страницу пользователей. Если teamID задан, выбираются только участники
+// команды: фильтр по роли относится к роли в команде, и она возвращается в User.TeamRole
+func findUsers(db *sql.DB, query domain.UserQuery, teamID int64) (*domain.UserPage, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	after, err := query.After()
+	if err != nil {
+		return nil, err
+	}
+
+	var conditions []string
+	var args []any
+
+	if len(query.Statuses) > 0 {
+		conditions = append(conditions, "status IN (?"+strings.Repeat(", ?", len(query.Statuses)-1)+")")
+		for _, status := range query.Statuses {
+			args = append(args, status)
+		}
+	}
+	switch {
+	case teamID != 0 && query.Role != "":
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM team_members m WHERE m.team_id = ? AND m.user_id = users.id AND m.role = ?)")
+		args = append(args, teamID, query.Role)
+	case teamID != 0:
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM team_members m WHERE m.team_id = ? AND m.user_id = users.id)")
+		args = append(args, teamID)
+	case query.Role != "":
+		conditions = append(conditions, "role = ?")
+		args = append(args, query.Role)
+	}
+	if query.Position != "" {
+		conditions = append(conditions, "position = ?")
+		args = append(args, query.Position)
+	}
+	if query.NamePrefix != "" {
+		// Сравнение подстроки вместо LIKE учитывает регистр и не требует экранирования % и _
+		conditions = append(conditions, "substr(username, 1, length(?)) = ?")
+		args = append(args, query.NamePrefix, query.NamePrefix)
+	}
+	if !query.CreatedFrom.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, query.CreatedFrom)
+	}
+	if !query.CreatedTo.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, query.CreatedTo)
+	}
+
+	// Страница начинается после курсора: сравниваем поле сортировки, при равенстве - идентификатор
+	column, order, cmp := string(query.Sort), "ASC", ">"
+	if query.Desc {
+		order, cmp = "DESC", "<"
+	}
+	if after != nil {
+		switch query.Sort {
+		case domain.UserSortID:
+			conditions = append(conditions, "id "+cmp+" ?")
+			args = append(args, after.ID)
+		case domain.UserSortUsername:
+			conditions = append(conditions, fmt.Sprintf("(username %[1]s ? OR (username = ? AND id %[1]s ?))", cmp))
+			args = append(args, after.Username, after.Username, after.ID)
+		case domain.UserSortCreated:
+			conditions = append(conditions, fmt.Sprintf("(created_at %[1]s ? OR (created_at = ? AND id %[1]s ?))", cmp))
+			args = append(args, after.CreatedAt, after.CreatedAt, after.ID)
+		}
+	}
+
+	columns := publicUserColumns
+	if query.WithSecrets {
+		columns = userColumns
+	}
+	if teamID != 0 {
+		// Параметр колонки предшествует параметрам условий
+		columns += ", (SELECT m.role FROM team_members m WHERE m.team_id = ? AND m.user_id = users.id)"
+		args = append([]any{teamID}, args...)
+	}
+	statement := "SELECT " + columns + " FROM users"
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	statement += " ORDER BY " + column + " " + order
+	if query.Sort != domain.UserSortID {
+		statement += ", id " + order
+	}
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница
+	if query.Limit > 0 {
+		statement += " LIMIT ?"
+		args = append(args, query.Limit+1)
+	}
+
+	rows, err := db.Query(statement, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -133,46 +366,46 @@ func (r *UserRepository) GetAll() ([]*domain.User, error) {
 
 	var users []*domain.User
 	for rows.Next() {
-		user := &domain.User{}
-		err := rows.Scan(
-			&user.ChatID,
-			&user.Username,
-			&user.Password,
-			&user.Role,
-			&user.Position,
-			&user.Birthday,
-			&user.Number,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
+		var extra []any
+		var teamRole string
+		if teamID != 0 {
+			extra = append(extra, &teamRole)
+		}
+		user, err := scanUser(rows, extra...)
 		if err != nil {
 			return nil, err
 		}
+		user.TeamRole = teamRole
 		users = append(users, user)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
-	return users, nil
+	return domain.NewUserPage(query, users), nil
 }
 
 // Update обновляет существующего пользователя
 func (r *UserRepository) Update(user *domain.User) error {
-	_, err := r.db.Exec(`
-		UPDATE users 
-		SET username = ?, password = ?, role = ?, position = ?, birthday = ?, number = ?, updated_at = ?
-		WHERE chat_id = ?`,
+	now := time.Now()
+	err := userAffected(r.db.Exec(`
+		UPDATE users
+		SET telegram_id = ?, chat_id = ?, username = ?, password = ?, role = ?, position = ?, birthday = ?, number = ?, language = ?, updated_at = ?
+		WHERE id = ?`,
+		nullableID(user.TelegramID),
+		user.ChatID,
 		user.Username,
 		user.Password,
 		user.Role,
 		user.Position,
 		user.Birthday,
 		user.Number,
-		time.Now(),
-		user.ChatID,
-	)
+		user.Language,
+		now,
+		user.ID,
+	))
 	if err != nil {
 		return fmt.Errorf("failed to update user: %w", err)
 	}
+	user.UpdatedAt = now
 	return nil
 }
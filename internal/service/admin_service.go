@@ -0,0 +1,203 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+)
+
+// adminPasswordGroups - длина пароля, выдаваемого при сбросе: 4 группы по 4 символа
+const adminPasswordGroups = 4
+
+// adminDetails отмечает в журнале аудита действия, выполненные через helpbotctl
+const adminDetails = "via=helpbotctl"
+
+// AdminService выполняет действия администратора сервера напрямую с хранилищем (helpbotctl).
+// Права в боте не проверяются: доступ к базе данных уже дает полный контроль над ней.
+// Действия записываются в журнал аудита без автора
+type AdminService struct {
+	auth     *AuthService
+	userRepo domain.UserRepository
+	roles    domain.RoleService
+	audit    domain.AuditService
+}
+
+// NewAdminService создает новый экземпляр AdminService
+func NewAdminService(auth *AuthService, userRepo domain.UserRepository, roles domain.RoleService, audit domain.AuditService) *AdminService {
+	return &AdminService{
+		auth:     auth,
+		userRepo: userRepo,
+		roles:    roles,
+		audit:    audit,
+	}
+}
+
+// CreateUser создает пользователя с указанной ролью. Если пароль не задан, он генерируется;
+// возвращается пароль, с которым пользователь может войти
+func (s *AdminService) CreateUser(username, password, role string) (*domain.User, string, error) {
+	if username == "" || strings.ContainsFunc(username, unicode.IsSpace) {
+		return nil, "", i18n.NewError("error.import_username_invalid", i18n.P{"username": username})
+	}
+	if role == "" {
+		role = domain.RoleUser
+	}
+	if err := s.checkRole(role); err != nil {
+		return nil, "", err
+	}
+	if password == "" {
+		generated, err := randomCode(adminPasswordGroups)
+		if err != nil {
+			return nil, "", err
+		}
+		password = generated
+	}
+
+	// Register хеширует пароль в структуре, поэтому исходный пароль сохраняется отдельно
+	user := &domain.User{Username: username, Password: password, Role: role}
+	if err := s.auth.Register(user); err != nil {
+		return nil, "", err
+	}
+	return user, password, nil
+}
+
+// SetRole назначает пользователю роль
+func (s *AdminService) SetRole(username, role string) (*domain.User, error) {
+	user, err := s.user(username)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRole(role); err != nil {
+		return nil, err
+	}
+	if user.Role == role {
+		return user, nil
+	}
+
+	if err := s.userRepo.UpdateRole(user.ID, role); err != nil {
+		return nil, err
+	}
+	s.audit.Record(&domain.AuditEntry{
+		TargetID: user.ID,
+		Action:   domain.AuditRoleChange,
+		Details:  fmt.Sprintf("role=%s->%s %s", user.Role, role, adminDetails),
+	})
+	user.Role = role
+	return user, nil
+}
+
+// ResetPassword задает пользователю новый пароль (или генерирует его, если пароль не задан)
+// и завершает его сессии. Возвращается новый пароль
+func (s *AdminService) ResetPassword(username, password string) (*domain.User, string, error) {
+	user, err := s.user(username)
+	if err != nil {
+		return nil, "", err
+	}
+	if password == "" {
+		if password, err = randomCode(adminPasswordGroups); err != nil {
+			return nil, "", err
+		}
+	}
+
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка хеширования пароля: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return nil, "", err
+	}
+	s.audit.Record(&domain.AuditEntry{
+		TargetID: user.ID,
+		Action:   domain.AuditPasswordChange,
+		Details:  "reset " + adminDetails,
+	})
+
+	// Тот, кто знал старый пароль, не должен оставаться в системе
+	if err := s.revoke(user); err != nil {
+		return nil, "", err
+	}
+	return user, password, nil
+}
+
+// RevokeSessions завершает все сессии и токены пользователя
+func (s *AdminService) RevokeSessions(username string) (*domain.User, error) {
+	user, err := s.user(username)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.revoke(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// DeleteUser помечает учетную запись удаленной и завершает ее сессии.
+// Данные обезличиваются позже, по истечении срока хранения
+func (s *AdminService) DeleteUser(username, reason string) (*domain.User, error) {
+	user, err := s.user(username)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == domain.UserDeleted {
+		return nil, i18n.NewError("error.status_unchanged")
+	}
+
+	if err := s.userRepo.UpdateStatus(user.ID, domain.UserDeleted, reason); err != nil {
+		return nil, err
+	}
+	details := fmt.Sprintf("status=%s->%s", user.Status, domain.UserDeleted)
+	if reason != "" {
+		details += " reason=" + reason
+	}
+	s.audit.Record(&domain.AuditEntry{
+		TargetID: user.ID,
+		Action:   domain.AuditStatusChange,
+		Details:  details + " " + adminDetails,
+	})
+
+	user.Status = domain.UserDeleted
+	user.StatusReason = reason
+	return user, s.revoke(user)
+}
+
+// revoke завершает сессии пользователя и записывает это в журнал аудита
+func (s *AdminService) revoke(user *domain.User) error {
+	if err := s.userRepo.RevokeSessions(user.ID); err != nil {
+		return err
+	}
+	s.audit.Record(&domain.AuditEntry{
+		TargetID: user.ID,
+		Action:   domain.AuditSessionRevoke,
+		Details:  adminDetails,
+	})
+	return nil
+}
+
+// user возвращает пользователя по имени. Обезличенных пользователей изменять нельзя
+func (s *AdminService) user(username string) (*domain.User, error) {
+	user, err := s.userRepo.GetByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, i18n.NewError("error.user_not_found")
+	}
+	if !user.PurgedAt.IsZero() {
+		return nil, i18n.NewError("error.account_purged")
+	}
+	return user, nil
+}
+
+// checkRole проверяет, что роль существует
+func (s *AdminService) checkRole(name string) error {
+	role, err := s.roles.GetRole(name)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return i18n.NewError("error.invalid_role")
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package service_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"HelpBot/internal/config"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/repository"
+	"HelpBot/internal/service"
+)
+
+// newAdminService создает AdminService и AuthService поверх временной базы SQLite
+func newAdminService(t *testing.T) (*repository.Repositories, *service.AuthService, *service.AdminService) {
+	t.Helper()
+	repos, db, err := repository.Open("sqlite:"+filepath.Join(t.TempDir(), "test.db"), logging.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cfg := &config.Config{JWTSecret: "0123456789abcdef", JWTExpiration: time.Hour}
+	audit := service.NewAuditService(repos.AuditRepository, repos.UserRepository, logging.Discard())
+	roles := service.NewRoleService(repos.RoleRepository, repos.UserRepository, repos.TeamRepository, audit)
+	auth := service.NewAuthService(repos.UserRepository, repos.TransferRepository, repos.InviteRepository, repos.TeamRepository, roles, audit, cfg)
+	return repos, auth, service.NewAdminService(auth, repos.UserRepository, roles, audit)
+}
+
+func TestAdminCreateUser(t *testing.T) {
+	repos, auth, admin := newAdminService(t)
+
+	// Первый администратор создается со сгенерированным паролем и сразу может войти
+	user, password, err := admin.CreateUser("root", "", domain.RoleAdmin)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if user.Role != domain.RoleAdmin || password == "" {
+		t.Errorf("CreateUser = %+v, %q", user, password)
+	}
+	if _, err := auth.Login(10, 10, "root", password); err != nil {
+		t.Errorf("Login with the generated password: %v", err)
+	}
+
+	for _, tc := range []struct {
+		username, role, key string
+	}{
+		{"root", domain.RoleUser, "error.username_taken"},
+		{"bob", "superuser", "error.invalid_role"},
+		{"two words", domain.RoleUser, "error.import_username_invalid"},
+	} {
+		if _, _, err := admin.CreateUser(tc.username, "secret", tc.role); errorKey(err) != tc.key {
+			t.Errorf("CreateUser(%q, %q) = %v, want %s", tc.username, tc.role, err, tc.key)
+		}
+	}
+
+	// Смена роли записывается в журнал аудита без автора
+	if _, err := admin.SetRole("root", domain.RoleCoordinator); err != nil {
+		t.Fatalf("SetRole: %v", err)
+	}
+	entries, err := repos.AuditRepository.Find(domain.AuditFilter{UserID: user.ID, Action: domain.AuditRoleChange})
+	if err != nil || len(entries) != 1 || entries[0].ActorID != 0 || !strings.Contains(entries[0].Details, "via=helpbotctl") {
+		t.Errorf("role change audit entries = %v, %v", entries, err)
+	}
+}
+
+func TestAdminResetPasswordRevokesSessions(t *testing.T) {
+	_, auth, admin := newAdminService(t)
+	if _, _, err := admin.CreateUser("alice", "old-secret", domain.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.Login(10, 10, "alice", "old-secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := admin.ResetPassword("alice", "new-secret"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if _, err := auth.Login(10, 10, "alice", "old-secret"); err == nil {
+		t.Error("Login with the old password succeeded")
+	}
+	user, err := auth.Login(10, 10, "alice", "new-secret")
+	if err != nil {
+		t.Fatalf("Login with the new password: %v", err)
+	}
+
+	// Токен, полученный сразу после сброса, действителен
+	fresh, err := auth.GenerateToken(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.ValidateToken(fresh); err != nil {
+		t.Errorf("ValidateToken(new) = %v", err)
+	}
+}
+
+func TestAdminRevokeSessions(t *testing.T) {
+	repos, auth, admin := newAdminService(t)
+	if _, _, err := admin.CreateUser("alice", "secret", domain.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+	user, err := auth.Login(10, 10, "alice", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := auth.GenerateToken(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Время выдачи токена хранится с точностью до секунды, поэтому сессии завершаются в следующую
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	if _, err := admin.RevokeSessions("alice"); err != nil {
+		t.Fatalf("RevokeSessions: %v", err)
+	}
+	if _, err := auth.ValidateToken(token); errorKey(err) != "error.session_revoked" {
+		t.Errorf("ValidateToken after RevokeSessions = %v, want error.session_revoked", err)
+	}
+
+	stored, err := repos.UserRepository.GetByUsername("alice")
+	if err != nil || stored.SessionsRevokedAt.IsZero() {
+		t.Errorf("SessionsRevokedAt after RevokeSessions = %v, %v", stored, err)
+	}
+	if _, err := admin.RevokeSessions("nobody"); errorKey(err) != "error.user_not_found" {
+		t.Errorf("RevokeSessions(nobody) = %v, want error.user_not_found", err)
+	}
+}
+
+func TestAdminDeleteUser(t *testing.T) {
+	_, auth, admin := newAdminService(t)
+	if _, _, err := admin.CreateUser("alice", "secret", domain.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := admin.DeleteUser("alice", "left the team")
+	if err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if user.Status != domain.UserDeleted || user.StatusReason != "left the team" {
+		t.Errorf("DeleteUser = %+v", user)
+	}
+	if _, err := auth.Login(10, 10, "alice", "secret"); errorKey(err) != "error.account_deleted" {
+		t.Errorf("Login after DeleteUser = %v, want error.account_deleted", err)
+	}
+	if _, err := admin.DeleteUser("alice", ""); errorKey(err) != "error.status_unchanged" {
+		t.Errorf("repeated DeleteUser = %v, want error.status_unchanged", err)
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+)
+
+// Ограничения объявлений
+const (
+	maxAnnouncementLength    = 2000
+	maxAnnouncementReminders = 3
+)
+
+// AnnouncementService реализует интерфейс domain.AnnouncementService
+type AnnouncementService struct {
+	announcementRepo domain.AnnouncementRepository
+	teamRepo         domain.TeamRepository
+	userRepo         domain.UserRepository
+	roles            domain.RoleService
+	audit            domain.AuditService
+	reminderInterval time.Duration // Через сколько напоминать непрочитавшим (0 - не напоминать)
+}
+
+// NewAnnouncementService создает новый экземпляр AnnouncementService
+func NewAnnouncementService(announcementRepo domain.AnnouncementRepository, teamRepo domain.TeamRepository, userRepo domain.UserRepository,
+	roles domain.RoleService, audit domain.AuditService, reminderInterval time.Duration) *AnnouncementService {
+	return &AnnouncementService{
+		announcementRepo: announcementRepo,
+		teamRepo:         teamRepo,
+		userRepo:         userRepo,
+		roles:            roles,
+		audit:            audit,
+		reminderInterval: reminderInterval,
+	}
+}
+
+// Create создает объявление активной команды. Получатели - активные участники команды
+// на момент создания, кроме автора. Если к команде привязана группа, объявление
+// запоминает ее, чтобы опубликовать и закрепить там
+func (s *AnnouncementService) Create(actorID int64, text string) (*domain.Announcement, []*domain.User, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermAnnounce)
+	if err != nil {
+		return nil, nil, err
+	}
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > maxAnnouncementLength {
+		return nil, nil, i18n.NewError("error.announcement_text_invalid", i18n.P{"max": maxAnnouncementLength})
+	}
+
+	page, err := s.teamRepo.FindMembers(team.ID, domain.UserQuery{Statuses: []domain.UserStatus{domain.UserActive}})
+	if err != nil {
+		return nil, nil, err
+	}
+	var recipients []*domain.User
+	var userIDs []int64
+	for _, user := range page.Users {
+		if user.ID != actorID {
+			recipients = append(recipients, user)
+			userIDs = append(userIDs, user.ID)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, nil, i18n.NewError("error.announcement_no_recipients")
+	}
+
+	announcement := &domain.Announcement{TeamID: team.ID, Text: text, ChatID: team.GroupChatID, Active: true, CreatedBy: actorID}
+	if err := s.announcementRepo.Save(announcement, userIDs); err != nil {
+		return nil, nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditAnnounce,
+		Details: fmt.Sprintf("team=%d announcement=%d recipients=%d", team.ID, announcement.ID, len(recipients)),
+	})
+	return announcement, recipients, nil
+}
+
+// SetMessage запоминает закрепленное сообщение объявления в группе команды
+func (s *AnnouncementService) SetMessage(announcementID int64, chatID int64, messageID int) error {
+	return s.announcementRepo.SetMessage(announcementID, chatID, messageID)
+}
+
+// Acknowledge отмечает объявление прочитанным пользователем. Отметить может только получатель;
+// закрытое объявление тоже можно отметить
+func (s *AnnouncementService) Acknowledge(actorID int64, announcementID int64) (*domain.Announcement, bool, error) {
+	announcement, err := s.announcementRepo.GetByID(announcementID)
+	if err != nil {
+		return nil, false, err
+	}
+	if announcement == nil {
+		return nil, false, i18n.NewError("error.announcement_not_found", i18n.P{"id": announcementID})
+	}
+	receipt, err := s.announcementRepo.GetReceipt(announcementID, actorID)
+	if err != nil {
+		return nil, false, err
+	}
+	if receipt == nil {
+		return nil, false, i18n.NewError("error.announcement_not_recipient")
+	}
+	if receipt.Acknowledged() {
+		return announcement, true, nil
+	}
+
+	added, err := s.announcementRepo.Acknowledge(announcementID, actorID, time.Now())
+	if err != nil {
+		return nil, false, err
+	}
+	if added {
+		announcement.Acknowledged++
+	}
+	return announcement, !added, nil
+}
+
+// Announcements возвращает действующие объявления активной команды с ходом прочтения
+func (s *AnnouncementService) Announcements(actorID int64) ([]*domain.Announcement, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermAnnounce)
+	if err != nil {
+		return nil, err
+	}
+	return s.announcementRepo.GetActive(team.ID)
+}
+
+// Progress возвращает объявление активной команды и отметки его получателей
+func (s *AnnouncementService) Progress(actorID int64, announcementID int64) (*domain.Announcement, []*domain.AnnouncementReceipt, error) {
+	announcement, err := s.managed(actorID, announcementID)
+	if err != nil {
+		return nil, nil, err
+	}
+	receipts, err := s.announcementRepo.GetReceipts(announcement.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return announcement, receipts, nil
+}
+
+// Close закрывает объявление активной команды
+func (s *AnnouncementService) Close(actorID int64, announcementID int64) (*domain.Announcement, error) {
+	announcement, err := s.managed(actorID, announcementID)
+	if err != nil {
+		return nil, err
+	}
+	if !announcement.Active {
+		return nil, i18n.NewError("error.announcement_closed", i18n.P{"id": announcement.ID})
+	}
+
+	now := time.Now()
+	if err := s.announcementRepo.Close(announcement.ID, now); err != nil {
+		return nil, err
+	}
+	announcement.Active = false
+	announcement.ClosedAt = now
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditAnnounceClose,
+		Details: fmt.Sprintf("team=%d announcement=%d acknowledged=%d/%d",
+			announcement.TeamID, announcement.ID, announcement.Acknowledged, announcement.Recipients),
+	})
+	return announcement, nil
+}
+
+// DueReminders возвращает объявления, о которых пора напомнить непрочитавшим получателям.
+// Напоминание повторяется через заданный интервал, но не больше maxAnnouncementReminders раз
+func (s *AnnouncementService) DueReminders(now time.Time) ([]*domain.AnnouncementReminder, error) {
+	if s.reminderInterval <= 0 {
+		return nil, nil
+	}
+	receipts, err := s.announcementRepo.GetUnacknowledged(now.Add(-s.reminderInterval), maxAnnouncementReminders)
+	if err != nil {
+		return nil, err
+	}
+
+	// Отметки упорядочены по объявлению, поэтому получатели одного объявления идут подряд
+	var reminders []*domain.AnnouncementReminder
+	var current *domain.AnnouncementReminder
+	for _, receipt := range receipts {
+		if err := s.announcementRepo.MarkReminded(receipt.AnnouncementID, receipt.UserID, now); err != nil {
+			return nil, err
+		}
+		if current == nil || current.Announcement.ID != receipt.AnnouncementID {
+			announcement, err := s.announcementRepo.GetByID(receipt.AnnouncementID)
+			if err != nil {
+				return nil, err
+			}
+			current = &domain.AnnouncementReminder{Announcement: announcement}
+			reminders = append(reminders, current)
+		}
+
+		// Заблокированным и выбывшим из команды получателям не напоминаем
+		user, err := s.userRepo.GetByID(receipt.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil || !user.Active() {
+			continue
+		}
+		member, err := s.teamRepo.GetMember(current.Announcement.TeamID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if member != nil {
+			current.Users = append(current.Users, user)
+		}
+	}
+
+	due := reminders[:0]
+	for _, reminder := range reminders {
+		if len(reminder.Users) > 0 {
+			due = append(due, reminder)
+		}
+	}
+	return due, nil
+}
+
+// managed возвращает объявление активной команды пользователя с правом announcements.manage
+func (s *AnnouncementService) managed(actorID int64, announcementID int64) (*domain.Announcement, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermAnnounce)
+	if err != nil {
+		return nil, err
+	}
+	announcement, err := s.announcementRepo.GetByID(announcementID)
+	if err != nil {
+		return nil, err
+	}
+	if announcement == nil || announcement.TeamID != team.ID {
+		return nil, i18n.NewError("error.announcement_not_found", i18n.P{"id": announcementID})
+	}
+	return announcement, nil
+}
@@ -0,0 +1,163 @@
+package service_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/service"
+)
+
+func TestAnnouncementAcknowledgements(t *testing.T) {
+	f, root := newTeamFixture(t)
+	f.juniors(t, root)
+	coach := f.coordinator(t, root, "coach")
+	announcements := service.NewAnnouncementService(f.repos.AnnouncementRepository, f.repos.TeamRepository, f.repos.UserRepository,
+		f.roles, f.audit, 24*time.Hour)
+	alice := f.register(t, "alice", domain.RoleUser)
+	bob := f.register(t, "bob", domain.RoleUser)
+
+	if _, _, err := announcements.Create(alice.ID, "Training moved"); errorKey(err) != "error.forbidden" {
+		t.Errorf("Create without announcements.manage = %v, want error.forbidden", err)
+	}
+	if _, _, err := announcements.Create(coach.ID, "   "); errorKey(err) != "error.announcement_text_invalid" {
+		t.Errorf("Create with an empty text = %v, want error.announcement_text_invalid", err)
+	}
+
+	// Объявление публикуется в привязанной группе, даже если создано в личном чате
+	if _, err := f.teams.BindGroup(root.ID, -100); err != nil {
+		t.Fatal(err)
+	}
+	announcement, recipients, err := announcements.Create(coach.ID, " Training moved to 18:00 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if announcement.ChatID != -100 {
+		t.Errorf("ChatID = %d, want the team group", announcement.ChatID)
+	}
+	// Автор не получает свое объявление
+	names := usernamesOf(recipients)
+	sort.Strings(names)
+	if len(names) != 3 || names[0] != "alice" || names[1] != "bob" || names[2] != "root" {
+		t.Errorf("recipients = %v, want alice, bob and root", names)
+	}
+	if announcement.Text != "Training moved to 18:00" {
+		t.Errorf("text = %q, want it trimmed", announcement.Text)
+	}
+
+	if _, already, err := announcements.Acknowledge(alice.ID, announcement.ID); err != nil || already {
+		t.Fatalf("Acknowledge = %v, %v, want a new mark", already, err)
+	}
+	if _, already, err := announcements.Acknowledge(alice.ID, announcement.ID); err != nil || !already {
+		t.Errorf("repeated Acknowledge = %v, %v, want an existing mark", already, err)
+	}
+	if _, _, err := announcements.Acknowledge(coach.ID, announcement.ID); errorKey(err) != "error.announcement_not_recipient" {
+		t.Errorf("Acknowledge by the author = %v, want error.announcement_not_recipient", err)
+	}
+	if _, _, err := announcements.Acknowledge(alice.ID, announcement.ID+1); errorKey(err) != "error.announcement_not_found" {
+		t.Errorf("Acknowledge of a missing announcement = %v, want error.announcement_not_found", err)
+	}
+
+	if _, _, err := announcements.Progress(bob.ID, announcement.ID); errorKey(err) != "error.forbidden" {
+		t.Errorf("Progress without announcements.manage = %v, want error.forbidden", err)
+	}
+	progress, receipts, err := announcements.Progress(coach.ID, announcement.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Acknowledged != 1 || progress.Recipients != 3 || progress.Percent() != 33 {
+		t.Errorf("progress = %d of %d, want 1 of 3", progress.Acknowledged, progress.Recipients)
+	}
+	acknowledged := make(map[string]bool)
+	for _, receipt := range receipts {
+		acknowledged[receipt.Username] = receipt.Acknowledged()
+	}
+	if len(receipts) != 3 || !acknowledged["alice"] || acknowledged["bob"] || acknowledged["root"] {
+		t.Errorf("receipts = %v, want only alice acknowledged", acknowledged)
+	}
+
+	active, err := announcements.Announcements(coach.ID)
+	if err != nil || len(active) != 1 || active[0].Acknowledged != 1 {
+		t.Errorf("Announcements = %v, %v, want one announcement read once", active, err)
+	}
+	if err := announcements.SetMessage(announcement.ID, -100, 7); err != nil {
+		t.Fatal(err)
+	}
+	closed, err := announcements.Close(coach.ID, announcement.ID)
+	if err != nil || closed.Active || closed.ChatID != -100 || closed.MessageID != 7 {
+		t.Fatalf("Close = %+v, %v, want the pinned group message to unpin", closed, err)
+	}
+	if _, err := announcements.Close(coach.ID, announcement.ID); errorKey(err) != "error.announcement_closed" {
+		t.Errorf("repeated Close = %v, want error.announcement_closed", err)
+	}
+	if active, err := announcements.Announcements(coach.ID); err != nil || len(active) != 0 {
+		t.Errorf("Announcements after Close = %v, %v, want none", active, err)
+	}
+	// Прочитать закрытое объявление все еще можно
+	if _, already, err := announcements.Acknowledge(bob.ID, announcement.ID); err != nil || already {
+		t.Errorf("Acknowledge of a closed announcement = %v, %v", already, err)
+	}
+
+	if _, err := f.teams.RemoveMember(root.ID, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := announcements.Progress(bob.ID, announcement.ID); errorKey(err) != "error.team_required" {
+		t.Errorf("Progress after leaving the team = %v, want error.team_required", err)
+	}
+}
+
+func TestAnnouncementReminders(t *testing.T) {
+	f, root := newTeamFixture(t)
+	f.juniors(t, root)
+	coach := f.coordinator(t, root, "coach")
+	announcements := service.NewAnnouncementService(f.repos.AnnouncementRepository, f.repos.TeamRepository, f.repos.UserRepository,
+		f.roles, f.audit, 24*time.Hour)
+	alice := f.register(t, "alice", domain.RoleUser)
+	f.register(t, "bob", domain.RoleUser)
+	f.register(t, "carol", domain.RoleUser)
+
+	announcement, _, err := announcements.Create(coach.ID, "Training moved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := announcements.Acknowledge(alice.ID, announcement.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.teams.RemoveMember(root.ID, "carol"); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if reminders, err := announcements.DueReminders(now); err != nil || len(reminders) != 0 {
+		t.Errorf("DueReminders right away = %v, %v, want none", reminders, err)
+	}
+	// Напоминание получают непрочитавшие участники команды, не чаще раза в сутки и не больше трех раз
+	for day := 1; day <= 4; day++ {
+		reminders, err := announcements.DueReminders(now.Add(time.Duration(day) * 25 * time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if day == 4 {
+			if len(reminders) != 0 {
+				t.Errorf("fourth reminder = %v, want none", reminders)
+			}
+			continue
+		}
+		if len(reminders) != 1 || reminders[0].Announcement.ID != announcement.ID {
+			t.Fatalf("reminder %d = %v, want the announcement", day, reminders)
+		}
+		if names := usernamesOf(reminders[0].Users); len(names) != 2 || names[0] != "bob" || names[1] != "root" {
+			t.Errorf("reminder %d users = %v, want bob and root", day, names)
+		}
+		if again, err := announcements.DueReminders(now.Add(time.Duration(day) * 25 * time.Hour)); err != nil || len(again) != 0 {
+			t.Errorf("repeated reminder %d = %v, %v, want none", day, again, err)
+		}
+	}
+
+	disabled := service.NewAnnouncementService(f.repos.AnnouncementRepository, f.repos.TeamRepository, f.repos.UserRepository,
+		f.roles, f.audit, 0)
+	if reminders, err := disabled.DueReminders(now.Add(100 * time.Hour)); err != nil || reminders != nil {
+		t.Errorf("DueReminders without an interval = %v, %v, want none", reminders, err)
+	}
+}
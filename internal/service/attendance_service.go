@@ -0,0 +1,269 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+)
+
+// AttendanceService реализует интерфейс domain.AttendanceService
+type AttendanceService struct {
+	attendanceRepo domain.AttendanceRepository
+	eventRepo      domain.EventRepository
+	teamRepo       domain.TeamRepository
+	userRepo       domain.UserRepository
+	roles          domain.RoleService
+	audit          domain.AuditService
+	checkInWindow  time.Duration // Сколько до и после начала события участник может отметиться сам (0 - не может)
+}
+
+// NewAttendanceService создает новый экземпляр AttendanceService
+func NewAttendanceService(attendanceRepo domain.AttendanceRepository, eventRepo domain.EventRepository,
+	teamRepo domain.TeamRepository, userRepo domain.UserRepository, roles domain.RoleService,
+	audit domain.AuditService, checkInWindow time.Duration) *AttendanceService {
+	return &AttendanceService{
+		attendanceRepo: attendanceRepo,
+		eventRepo:      eventRepo,
+		teamRepo:       teamRepo,
+		userRepo:       userRepo,
+		roles:          roles,
+		audit:          audit,
+		checkInWindow:  checkInWindow,
+	}
+}
+
+// EventAttendance возвращает событие активной команды и отметки всех ее активных участников.
+// Неотмеченные участники возвращаются с пустой отметкой
+func (s *AttendanceService) EventAttendance(actorID int64, eventID int64) (*domain.Event, []*domain.Attendance, error) {
+	_, team, event, err := s.managedEvent(actorID, eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	marks, err := s.attendanceRepo.GetByEvent(event.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	page, err := s.teamRepo.FindMembers(team.ID, domain.UserQuery{Statuses: []domain.UserStatus{domain.UserActive}})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byUser := make(map[int64]*domain.Attendance, len(marks))
+	for _, mark := range marks {
+		byUser[mark.UserID] = mark
+	}
+	attendance := make([]*domain.Attendance, 0, len(page.Users))
+	for _, user := range page.Users {
+		mark, ok := byUser[user.ID]
+		if !ok {
+			mark = &domain.Attendance{EventID: event.ID, UserID: user.ID, Username: user.Username}
+		}
+		attendance = append(attendance, mark)
+	}
+	sort.Slice(attendance, func(i, j int) bool { return attendance[i].Username < attendance[j].Username })
+	return event, attendance, nil
+}
+
+// Mark отмечает, был ли участник команды на событии. Отмечать можно с открытия окна
+// самостоятельной отметки, то есть незадолго до начала события
+func (s *AttendanceService) Mark(actorID int64, eventID int64, userID int64, status domain.AttendanceStatus) (*domain.Attendance, error) {
+	if status != domain.AttendancePresent && status != domain.AttendanceAbsent {
+		return nil, i18n.NewError("error.attendance_status_invalid")
+	}
+	_, team, event, err := s.managedEvent(actorID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().Before(event.StartsAt.Add(-s.checkInWindow)) {
+		return nil, i18n.NewError("error.attendance_not_open", i18n.P{"title": event.Title})
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.PurgedAt.IsZero() {
+		return nil, i18n.NewError("error.user_not_found")
+	}
+	member, err := s.teamRepo.GetMember(team.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, i18n.NewError("error.member_not_found", i18n.P{"username": user.Username, "team": team.Name})
+	}
+
+	mark := &domain.Attendance{EventID: event.ID, UserID: user.ID, Username: user.Username, Status: status, MarkedBy: actorID}
+	if err := s.attendanceRepo.Mark(mark); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actorID,
+		Action:   domain.AuditAttendanceMark,
+		TargetID: user.ID,
+		Details:  fmt.Sprintf("team=%d event=%d status=%s", team.ID, event.ID, status),
+	})
+	return mark, nil
+}
+
+// CheckIn отмечает присутствие участника на событии его команды. Отметиться можно
+// в окне вокруг начала события; повторная отметка ничего не меняет
+func (s *AttendanceService) CheckIn(actorID int64, eventID int64) (*domain.Event, bool, error) {
+	if s.checkInWindow <= 0 {
+		return nil, false, i18n.NewError("error.checkin_disabled")
+	}
+	actor, err := s.userRepo.GetByID(actorID)
+	if err != nil {
+		return nil, false, err
+	}
+	if actor == nil || !actor.Active() {
+		return nil, false, i18n.NewError("error.forbidden")
+	}
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, false, err
+	}
+	if event == nil {
+		return nil, false, i18n.NewError("error.event_not_found", i18n.P{"id": eventID})
+	}
+	member, err := s.teamRepo.GetMember(event.TeamID, actor.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if member == nil {
+		return nil, false, i18n.NewError("error.event_not_found", i18n.P{"id": eventID})
+	}
+	if event.Canceled {
+		return nil, false, i18n.NewError("error.event_canceled", i18n.P{"title": event.Title})
+	}
+	now := time.Now()
+	if now.Before(event.StartsAt.Add(-s.checkInWindow)) || now.After(event.StartsAt.Add(s.checkInWindow)) {
+		return nil, false, i18n.NewError("error.checkin_closed", i18n.P{"title": event.Title})
+	}
+
+	marks, err := s.attendanceRepo.GetByEvent(event.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, mark := range marks {
+		if mark.UserID == actor.ID && mark.Status == domain.AttendancePresent {
+			return event, true, nil
+		}
+	}
+	mark := &domain.Attendance{EventID: event.ID, UserID: actor.ID, Status: domain.AttendancePresent, SelfCheckIn: true, MarkedBy: actor.ID}
+	if err := s.attendanceRepo.Mark(mark); err != nil {
+		return nil, false, err
+	}
+	return event, false, nil
+}
+
+// Rates возвращает посещаемость активных участников активной команды за период, упорядоченную
+// по имени. Участнику засчитываются события с отметками, прошедшие с его вступления в команду,
+// и события, на которых его отметили
+func (s *AttendanceService) Rates(actorID int64, from, to time.Time) ([]*domain.AttendanceRate, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageEvents)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.attendanceRepo.GetTracked(team.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	marks, err := s.attendanceRepo.GetByTeam(team.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	page, err := s.teamRepo.FindMembers(team.ID, domain.UserQuery{Statuses: []domain.UserStatus{domain.UserActive}})
+	if err != nil {
+		return nil, err
+	}
+
+	var rates []*domain.AttendanceRate
+	for _, user := range page.Users {
+		member, err := s.teamRepo.GetMember(team.ID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if member == nil {
+			continue
+		}
+		rate := &domain.AttendanceRate{User: user}
+		for _, entry := range memberAttendance(events, marks, user.ID, member.JoinedAt) {
+			rate.Events++
+			if entry.Status == domain.AttendancePresent {
+				rate.Attended++
+			}
+		}
+		rates = append(rates, rate)
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].User.Username < rates[j].User.Username })
+	return rates, nil
+}
+
+// History возвращает события активной команды с отметками за период, которые прошли
+// с вступления пользователя в команду, вместе с его отметкой на каждом
+func (s *AttendanceService) History(actorID int64, from, to time.Time) ([]*domain.AttendanceEntry, error) {
+	actor, team, err := teamMember(s.userRepo, s.teamRepo, s.roles, actorID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := s.teamRepo.GetMember(team.ID, actor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, nil
+	}
+	events, err := s.attendanceRepo.GetTracked(team.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	marks, err := s.attendanceRepo.GetByTeam(team.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return memberAttendance(events, marks, actor.ID, member.JoinedAt), nil
+}
+
+// managedEvent возвращает пользователя, его активную команду и ее неотмененное событие,
+// проверяя право управлять событиями
+func (s *AttendanceService) managedEvent(actorID int64, eventID int64) (*domain.User, *domain.Team, *domain.Event, error) {
+	actor, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageEvents)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if event == nil || event.TeamID != team.ID {
+		return nil, nil, nil, i18n.NewError("error.event_not_found", i18n.P{"id": eventID})
+	}
+	if event.Canceled {
+		return nil, nil, nil, i18n.NewError("error.event_canceled", i18n.P{"title": event.Title})
+	}
+	return actor, team, event, nil
+}
+
+// memberAttendance отбирает события, которые засчитываются участнику: прошедшие с его
+// вступления в команду или те, на которых его отметили, - вместе с его отметками
+func memberAttendance(events []*domain.Event, marks []*domain.Attendance, userID int64, joinedAt time.Time) []*domain.AttendanceEntry {
+	statuses := make(map[int64]domain.AttendanceStatus)
+	for _, mark := range marks {
+		if mark.UserID == userID {
+			statuses[mark.EventID] = mark.Status
+		}
+	}
+	var entries []*domain.AttendanceEntry
+	for _, event := range events {
+		status, marked := statuses[event.ID]
+		if !marked && event.StartsAt.Before(joinedAt) {
+			continue
+		}
+		entries = append(entries, &domain.AttendanceEntry{Event: event, Status: status})
+	}
+	return entries
+}
@@ -0,0 +1,162 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/service"
+)
+
+// saveEvent сохраняет событие команды напрямую, минуя проверку времени начала
+func saveEvent(t *testing.T, f *teamFixture, teamID int64, title string, startsAt time.Time) *domain.Event {
+	t.Helper()
+	event := &domain.Event{TeamID: teamID, Title: title, StartsAt: startsAt.Truncate(time.Minute)}
+	if err := f.repos.EventRepository.Save(event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestAttendanceMarkingAndCheckIn(t *testing.T) {
+	f, root := newTeamFixture(t)
+	f.juniors(t, root)
+	coach := f.coordinator(t, root, "coach")
+	attendance := service.NewAttendanceService(f.repos.AttendanceRepository, f.repos.EventRepository, f.repos.TeamRepository,
+		f.repos.UserRepository, f.roles, f.audit, 2*time.Hour)
+	alice := f.register(t, "alice", domain.RoleUser)
+	bob := f.register(t, "bob", domain.RoleUser)
+	now := time.Now()
+	past := saveEvent(t, f, coach.ActiveTeamID, "Match", now.Add(-3*time.Hour))
+	current := saveEvent(t, f, coach.ActiveTeamID, "Training", now.Add(30*time.Minute))
+	future := saveEvent(t, f, coach.ActiveTeamID, "Camp", now.Add(48*time.Hour))
+
+	for _, tc := range []struct {
+		name    string
+		actorID int64
+		eventID int64
+		status  domain.AttendanceStatus
+		key     string
+	}{
+		{"without events.manage", alice.ID, past.ID, domain.AttendancePresent, "error.forbidden"},
+		{"unknown status", coach.ID, past.ID, "late", "error.attendance_status_invalid"},
+		{"missing event", coach.ID, future.ID + 1, domain.AttendancePresent, "error.event_not_found"},
+		{"long before the start", coach.ID, future.ID, domain.AttendancePresent, "error.attendance_not_open"},
+	} {
+		if _, err := attendance.Mark(tc.actorID, tc.eventID, alice.ID, tc.status); errorKey(err) != tc.key {
+			t.Errorf("Mark %s = %v, want %s", tc.name, err, tc.key)
+		}
+	}
+
+	// Координатор отмечает прошедшее событие
+	if _, err := attendance.Mark(coach.ID, past.ID, alice.ID, domain.AttendancePresent); err != nil {
+		t.Fatalf("Mark(alice): %v", err)
+	}
+	if _, err := attendance.Mark(coach.ID, past.ID, bob.ID, domain.AttendancePresent); err != nil {
+		t.Fatalf("Mark(bob): %v", err)
+	}
+	mark, err := attendance.Mark(coach.ID, past.ID, bob.ID, domain.AttendanceAbsent)
+	if err != nil || mark.Username != "bob" || mark.Status != domain.AttendanceAbsent {
+		t.Fatalf("repeated Mark(bob) = %+v, %v", mark, err)
+	}
+	_, marks, err := attendance.EventAttendance(coach.ID, past.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := make(map[string]domain.AttendanceStatus)
+	for _, mark := range marks {
+		statuses[mark.Username] = mark.Status
+	}
+	if len(marks) < 3 || statuses["alice"] != domain.AttendancePresent || statuses["bob"] != domain.AttendanceAbsent || statuses["coach"] != "" {
+		t.Errorf("EventAttendance = %v, want every member with alice present, bob absent and coach unmarked", statuses)
+	}
+
+	// Участники отмечаются сами только в окне вокруг начала события
+	for _, event := range []*domain.Event{past, future} {
+		if _, _, err := attendance.CheckIn(alice.ID, event.ID); errorKey(err) != "error.checkin_closed" {
+			t.Errorf("CheckIn(%s) = %v, want error.checkin_closed", event.Title, err)
+		}
+	}
+	if _, already, err := attendance.CheckIn(alice.ID, current.ID); err != nil || already {
+		t.Fatalf("CheckIn = %v, %v, want a new check-in", already, err)
+	}
+	if _, already, err := attendance.CheckIn(alice.ID, current.ID); err != nil || !already {
+		t.Errorf("repeated CheckIn = %v, %v, want an existing check-in", already, err)
+	}
+
+	if _, err := f.teams.RemoveMember(root.ID, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := attendance.CheckIn(bob.ID, current.ID); errorKey(err) != "error.event_not_found" {
+		t.Errorf("CheckIn by an outsider = %v, want error.event_not_found", err)
+	}
+
+	// Приостановленный участник не может отметиться, хотя остается в команде
+	if err := f.repos.UserRepository.UpdateStatus(alice.ID, domain.UserSuspended, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := attendance.CheckIn(alice.ID, current.ID); errorKey(err) != "error.forbidden" {
+		t.Errorf("CheckIn by a suspended member = %v, want error.forbidden", err)
+	}
+
+	disabled := service.NewAttendanceService(f.repos.AttendanceRepository, f.repos.EventRepository, f.repos.TeamRepository,
+		f.repos.UserRepository, f.roles, f.audit, 0)
+	if _, _, err := disabled.CheckIn(alice.ID, current.ID); errorKey(err) != "error.checkin_disabled" {
+		t.Errorf("CheckIn without a window = %v, want error.checkin_disabled", err)
+	}
+}
+
+func TestAttendanceRatesAndHistory(t *testing.T) {
+	f, root := newTeamFixture(t)
+	f.juniors(t, root)
+	coach := f.coordinator(t, root, "coach")
+	attendance := service.NewAttendanceService(f.repos.AttendanceRepository, f.repos.EventRepository, f.repos.TeamRepository,
+		f.repos.UserRepository, f.roles, f.audit, 2*time.Hour)
+	alice := f.register(t, "alice", domain.RoleUser)
+	bob := f.register(t, "bob", domain.RoleUser)
+	now := time.Now()
+	// Событие началось до вступления участников в команду и засчитывается только отмеченным
+	before := saveEvent(t, f, coach.ActiveTeamID, "Match", now.Add(-3*time.Hour))
+	current := saveEvent(t, f, coach.ActiveTeamID, "Training", now.Add(30*time.Minute))
+	saveEvent(t, f, coach.ActiveTeamID, "Unmarked", now.Add(time.Hour))
+
+	if _, err := attendance.Mark(coach.ID, before.ID, alice.ID, domain.AttendancePresent); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := attendance.Mark(coach.ID, current.ID, bob.ID, domain.AttendanceAbsent); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := attendance.CheckIn(alice.ID, current.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	from, to := now.Add(-24*time.Hour), now.Add(24*time.Hour)
+	if _, err := attendance.Rates(alice.ID, from, to); errorKey(err) != "error.forbidden" {
+		t.Errorf("Rates without events.manage = %v, want error.forbidden", err)
+	}
+	rates, err := attendance.Rates(coach.ID, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string][2]int)
+	for _, rate := range rates {
+		got[rate.User.Username] = [2]int{rate.Attended, rate.Events}
+	}
+	for name, want := range map[string][2]int{"alice": {2, 2}, "bob": {0, 1}, "coach": {0, 1}} {
+		if got[name] != want {
+			t.Errorf("rate of %s = %v, want %v attended of events", name, got[name], want)
+		}
+	}
+
+	history, err := attendance.History(alice.ID, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Event.ID != before.ID || history[1].Event.ID != current.ID ||
+		history[0].Status != domain.AttendancePresent || history[1].Status != domain.AttendancePresent {
+		t.Errorf("History(alice) = %+v, want both events attended", history)
+	}
+	if history, err := attendance.History(alice.ID, now, to); err != nil || len(history) != 1 {
+		t.Errorf("History(alice) from now = %v, %v, want only the current event", history, err)
+	}
+}
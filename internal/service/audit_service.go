@@ -0,0 +1,102 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"log/slog"
+	"strconv"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/logging"
+)
+
+// AuditService реализует интерфейс domain.AuditService
+type AuditService struct {
+	auditRepo domain.AuditRepository
+	userRepo  domain.UserRepository
+	logger    *slog.Logger
+}
+
+// NewAuditService создает новый экземпляр AuditService
+func NewAuditService(auditRepo domain.AuditRepository, userRepo domain.UserRepository, logger *slog.Logger) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+		userRepo:  userRepo,
+		logger:    logger,
+	}
+}
+
+// Record добавляет запись в журнал аудита. Ошибка записи не прерывает
+// основное действие и только попадает в лог
+func (s *AuditService) Record(entry *domain.AuditEntry) {
+	if err := s.auditRepo.Append(entry); err != nil {
+		s.logger.Error("Error writing audit entry", "action", entry.Action, logging.Err(err))
+	}
+}
+
+// Find возвращает записи журнала по фильтру
+func (s *AuditService) Find(filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	return s.auditRepo.Find(filter)
+}
+
+// ExportCSV выгружает записи журнала по фильтру в CSV
+func (s *AuditService) ExportCSV(filter domain.AuditFilter) ([]byte, error) {
+	entries, err := s.auditRepo.Find(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"id", "created_at", "action", "actor_id", "actor", "telegram_id", "target_id", "target", "details"}); err != nil {
+		return nil, err
+	}
+
+	usernames := make(map[int64]string)
+	for _, entry := range entries {
+		record := []string{
+			strconv.FormatInt(entry.ID, 10),
+			entry.CreatedAt.Format("2006-01-02 15:04:05"),
+			entry.Action,
+			strconv.FormatInt(entry.ActorID, 10),
+			s.username(usernames, entry.ActorID),
+			strconv.FormatInt(entry.TelegramID, 10),
+			strconv.FormatInt(entry.TargetID, 10),
+			s.username(usernames, entry.TargetID),
+			entry.Details,
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Username возвращает имя пользователя для отображения в журнале
+func (s *AuditService) Username(id int64) string {
+	return s.username(nil, id)
+}
+
+// username возвращает имя пользователя, запоминая найденные имена в cache
+func (s *AuditService) username(cache map[int64]string, id int64) string {
+	if id == 0 {
+		return ""
+	}
+	if name, ok := cache[id]; ok {
+		return name
+	}
+
+	name := "#" + strconv.FormatInt(id, 10)
+	if user, err := s.userRepo.GetByID(id); err == nil && user != nil {
+		name = user.Username
+	}
+	if cache != nil {
+		cache[id] = name
+	}
+	return name
+}
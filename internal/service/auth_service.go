@@ -3,25 +3,35 @@ package service
 import (
 	"errors"
 	"fmt"
-	"time"
 
 	"HelpBot/internal/config"
 	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 
 type AuthService struct {
-	userRepo domain.UserRepository
-	config   *config.Config
+	userRepo     domain.UserRepository
+	transferRepo domain.TransferRepository
+	inviteRepo   domain.InviteRepository
+	teamRepo     domain.TeamRepository
+	roles        domain.RoleService
+	audit        domain.AuditService
+	config       *config.Config
 }
 
 
-func NewAuthService(userRepo domain.UserRepository, cfg *config.Config) *AuthService {
+func NewAuthService(userRepo domain.UserRepository, transferRepo domain.TransferRepository, inviteRepo domain.InviteRepository, teamRepo domain.TeamRepository, roles domain.RoleService, audit domain.AuditService, cfg *config.Config) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
-		config:   cfg,
+		userRepo:     userRepo,
+		transferRepo: transferRepo,
+		inviteRepo:   inviteRepo,
+		teamRepo:     teamRepo,
+		roles:        roles,
+		audit:        audit,
+		config:       cfg,
 	}
 }
 
@@ -39,13 +49,29 @@ func checkPasswordHash(password, hash string) bool {
 
 // Register регистрирует нового пользователя
 func (s *AuthService) Register(user *domain.User) error {
+	// Имена вида deleted-<id> получают обезличенные пользователи
+	if domain.IsReservedUsername(user.Username) {
+		return i18n.NewError("error.username_reserved")
+	}
+
 	// Проверяем, существует ли пользователь с таким именем
 	existingUser, err := s.userRepo.GetByUsername(user.Username)
 	if err != nil {
 		return err
 	}
 	if existingUser != nil {
-		return errors.New("пользователь с таким именем уже существует")
+		return i18n.NewError("error.username_taken")
+	}
+
+	// Проверяем, не привязан ли к этому Telegram-аккаунту другой пользователь
+	if user.TelegramID != 0 {
+		boundUser, err := s.userRepo.GetByTelegramID(user.TelegramID)
+		if err != nil {
+			return err
+		}
+		if boundUser != nil {
+			return i18n.NewError("error.telegram_already_bound", i18n.P{"username": boundUser.Username})
+		}
 	}
 
 	// Хешируем пароль
@@ -60,49 +86,216 @@ func (s *AuthService) Register(user *domain.User) error {
 		user.Role = domain.RoleUser
 	}
 
-	// Сохраняем пользователя
-	return s.userRepo.Save(user)
+	// Сохраняем пользователя. Имя могли занять между проверкой и сохранением
+	if err := s.userRepo.Save(user); err != nil {
+		if errors.Is(err, domain.ErrUserExists) {
+			return i18n.NewError("error.username_taken")
+		}
+		return err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:    user.ID,
+		TelegramID: user.TelegramID,
+		TargetID:   user.ID,
+		Action:     domain.AuditRegister,
+		Details:    "username=" + user.Username,
+	})
+	return s.joinOnlyTeam(user)
+}
+
+// joinOnlyTeam добавляет нового пользователя в команду, если она единственная.
+// При нескольких командах пользователя добавляет администратор команды
+func (s *AuthService) joinOnlyTeam(user *domain.User) error {
+	teams, err := s.teamRepo.GetAll()
+	if err != nil || len(teams) != 1 {
+		return err
+	}
+	if err := s.teamRepo.AddMember(&domain.TeamMember{TeamID: teams[0].ID, UserID: user.ID, Role: user.Role}); err != nil {
+		return err
+	}
+	if err := s.userRepo.SetActiveTeam(user.ID, teams[0].ID); err != nil {
+		return err
+	}
+	user.ActiveTeamID = teams[0].ID
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  user.ID,
+		TargetID: user.ID,
+		Action:   domain.AuditTeamJoin,
+		Details:  fmt.Sprintf("team=%d role=%s source=register", teams[0].ID, user.Role),
+	})
+	return nil
+}
+
+// Login авторизует пользователя по паролю без проверки второго фактора. Вход возможен только
+// с Telegram-аккаунта, к которому привязан пользователь; с любого другого создается запрос на перенос
+func (s *AuthService) Login(telegramID int64, chatID int64, username, password string) (*domain.User, error) {
+	user, err := s.CheckPassword(telegramID, chatID, username, password)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CompleteLogin(user, telegramID, chatID); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
-// Login авторизует пользователя
-func (s *AuthService) Login(username, password string) (*domain.User, error) {
+// CheckPassword проверяет имя, пароль и состояние пользователя, не выполняя вход. Привязка
+// к Telegram-аккаунту и запись об успешном входе делаются в CompleteLogin, когда пройдены
+// все проверки, включая второй фактор
+func (s *AuthService) CheckPassword(telegramID int64, chatID int64, username, password string) (*domain.User, error) {
 	// Получаем пользователя по имени
 	user, err := s.userRepo.GetByUsername(username)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("пользователь не найден")
+		s.audit.Record(&domain.AuditEntry{
+			TelegramID: telegramID,
+			Action:     domain.AuditLoginFailure,
+			Details:    "username=" + username + " reason=user_not_found",
+		})
+		return nil, i18n.NewError("error.user_not_found")
 	}
 
 	// Проверяем пароль
 	if !checkPasswordHash(password, user.Password) {
-		return nil, errors.New("неверный пароль")
+		s.audit.Record(&domain.AuditEntry{
+			TelegramID: telegramID,
+			TargetID:   user.ID,
+			Action:     domain.AuditLoginFailure,
+			Details:    "username=" + username + " reason=wrong_password",
+		})
+		return nil, i18n.NewError("error.wrong_password")
 	}
 
-	// Обновляем время последнего входа
-	user.UpdatedAt = time.Now()
-	if err := s.userRepo.Save(user); err != nil {
-		return nil, err
+	// Приостановленные, деактивированные и удаленные пользователи войти не могут
+	if !user.Active() {
+		s.audit.Record(&domain.AuditEntry{
+			TelegramID: telegramID,
+			TargetID:   user.ID,
+			Action:     domain.AuditLoginFailure,
+			Details:    "username=" + username + " reason=status_" + string(user.Status),
+		})
+		return nil, i18n.NewError("error.account_" + string(user.Status))
 	}
 
+	// Пользователь привязан к другому Telegram-аккаунту: войти можно только после переноса
+	if user.TelegramID != 0 && user.TelegramID != telegramID {
+		return nil, s.requestTransfer(user, telegramID, chatID)
+	}
 	return user, nil
 }
 
+// RecordTwoFactorPending записывает в журнал верный пароль, после которого вход ожидает
+// проверки второго фактора
+func (s *AuthService) RecordTwoFactorPending(user *domain.User, telegramID int64) {
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:    user.ID,
+		TelegramID: telegramID,
+		TargetID:   user.ID,
+		Action:     domain.AuditLoginPending,
+	})
+}
+
+// CompleteLogin завершает вход пользователя, прошедшего все проверки: привязывает его
+// к Telegram-аккаунту, обновляет время последнего входа и записывает успешный вход
+func (s *AuthService) CompleteLogin(user *domain.User, telegramID int64, chatID int64) error {
+	switch user.TelegramID {
+	case 0:
+		// Пользователь еще не привязан: привязываем к текущему Telegram-аккаунту
+		if err := s.bindTelegram(user, telegramID, chatID); err != nil {
+			return err
+		}
+	case telegramID:
+		// Обновляем чат доставки, если пользователь пишет из другого чата
+		if user.ChatID != chatID {
+			if err := s.userRepo.BindTelegram(user.ID, telegramID, chatID); err != nil {
+				return err
+			}
+			user.ChatID = chatID
+		}
+	default:
+		return s.requestTransfer(user, telegramID, chatID)
+	}
+
+	// Обновляем время последнего входа
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:    user.ID,
+		TelegramID: telegramID,
+		TargetID:   user.ID,
+		Action:     domain.AuditLoginSuccess,
+	})
+	return nil
+}
+
+// bindTelegram привязывает пользователя к Telegram-аккаунту, если тот свободен
+func (s *AuthService) bindTelegram(user *domain.User, telegramID int64, chatID int64) error {
+	boundUser, err := s.userRepo.GetByTelegramID(telegramID)
+	if err != nil {
+		return err
+	}
+	if boundUser != nil && boundUser.ID != user.ID {
+		return i18n.NewError("error.telegram_already_bound", i18n.P{"username": boundUser.Username})
+	}
+
+	if err := s.userRepo.BindTelegram(user.ID, telegramID, chatID); err != nil {
+		return err
+	}
+	user.TelegramID = telegramID
+	user.ChatID = chatID
+	return nil
+}
+
+// requestTransfer создает запрос на перенос аккаунта, если такого запроса еще нет
+func (s *AuthService) requestTransfer(user *domain.User, telegramID int64, chatID int64) error {
+	transfer, err := s.transferRepo.GetPendingByUser(user.ID, telegramID)
+	if err != nil {
+		return err
+	}
+	if transfer != nil {
+		return &domain.TransferRequiredError{Transfer: transfer}
+	}
+
+	transfer = &domain.AccountTransfer{
+		UserID:         user.ID,
+		FromTelegramID: user.TelegramID,
+		ToTelegramID:   telegramID,
+		ToChatID:       chatID,
+		Status:         domain.TransferStatusPending,
+	}
+	if err := s.transferRepo.Save(transfer); err != nil {
+		return err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		TelegramID: telegramID,
+		TargetID:   user.ID,
+		Action:     domain.AuditTransferRequest,
+		Details:    fmt.Sprintf("transfer_id=%d from_telegram_id=%d to_telegram_id=%d", transfer.ID, transfer.FromTelegramID, transfer.ToTelegramID),
+	})
+	return &domain.TransferRequiredError{Transfer: transfer, Created: true}
+}
+
 // ChangePassword изменяет пароль пользователя
-func (s *AuthService) ChangePassword(chatID int64, oldPassword, newPassword string) error {
+func (s *AuthService) ChangePassword(userID int64, oldPassword, newPassword string) error {
 	// Получаем пользователя
-	user, err := s.userRepo.GetByID(chatID)
+	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
 		return err
 	}
 	if user == nil {
-		return errors.New("пользователь не найден")
+		return i18n.NewError("error.user_not_found")
 	}
 
 	// Проверяем старый пароль
 	if !checkPasswordHash(oldPassword, user.Password) {
-		return errors.New("неверный пароль")
+		return i18n.NewError("error.wrong_password")
 	}
 
 	// Хешируем новый пароль
@@ -112,19 +305,25 @@ func (s *AuthService) ChangePassword(chatID int64, oldPassword, newPassword stri
 	}
 
 	// Обновляем пароль
-	return s.userRepo.UpdatePassword(chatID, hashedPassword)
+	if err := s.userRepo.UpdatePassword(userID, hashedPassword); err != nil {
+		return err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:    userID,
+		TelegramID: user.TelegramID,
+		TargetID:   userID,
+		Action:     domain.AuditPasswordChange,
+	})
+	return nil
 }
 
-// ChangeRole изменяет роль пользователя (только для администраторов)
-func (s *AuthService) ChangeRole(adminChatID int64, targetUsername string, newRole string) error {
-	// Проверяем, является ли пользователь администратором
-	admin, err := s.userRepo.GetByID(adminChatID)
-	if err != nil {
+// ChangeRole изменяет роль пользователя (требует права roles.manage)
+func (s *AuthService) ChangeRole(actorID int64, targetUsername string, newRole string) error {
+	// Проверяем право на управление ролями
+	if err := s.roles.RequirePermission(actorID, domain.PermManageRoles); err != nil {
 		return err
 	}
-	if admin == nil || admin.Role != domain.RoleAdmin {
-		return errors.New("недостаточно прав для выполнения операции")
-	}
 
 	// Получаем целевого пользователя
 	targetUser, err := s.userRepo.GetByUsername(targetUsername)
@@ -132,14 +331,186 @@ func (s *AuthService) ChangeRole(adminChatID int64, targetUsername string, newRo
 		return err
 	}
 	if targetUser == nil {
-		return errors.New("пользователь не найден")
+		return i18n.NewError("error.user_not_found")
 	}
 
-	// Проверяем валидность роли
-	if newRole != domain.RoleAdmin && newRole != domain.RoleUser {
-		return errors.New("недопустимая роль")
+	// Проверяем, что роль существует
+	role, err := s.roles.GetRole(newRole)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return i18n.NewError("error.invalid_role")
 	}
 
 	// Обновляем роль
-	return s.userRepo.UpdateRole(targetUser.ChatID, newRole)
+	if err := s.userRepo.UpdateRole(targetUser.ID, newRole); err != nil {
+		return err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actorID,
+		TargetID: targetUser.ID,
+		Action:   domain.AuditRoleChange,
+		Details:  fmt.Sprintf("role=%s->%s", targetUser.Role, newRole),
+	})
+	return nil
+}
+
+// ChangeStatus изменяет состояние учетной записи пользователя (требует права users.manage)
+func (s *AuthService) ChangeStatus(actorID int64, targetUsername string, status domain.UserStatus, reason string) (*domain.User, error) {
+	// Проверяем право на управление пользователями
+	if err := s.roles.RequirePermission(actorID, domain.PermManageUsers); err != nil {
+		return nil, err
+	}
+	if !domain.IsKnownUserStatus(status) {
+		return nil, i18n.NewError("error.invalid_status")
+	}
+
+	// Получаем целевого пользователя
+	targetUser, err := s.userRepo.GetByUsername(targetUsername)
+	if err != nil {
+		return nil, err
+	}
+	if targetUser == nil {
+		return nil, i18n.NewError("error.user_not_found")
+	}
+	if targetUser.ID == actorID {
+		return nil, i18n.NewError("error.status_self")
+	}
+	// Администратор команды управляет только ее участниками
+	if err := s.requireTeammate(actorID, targetUser); err != nil {
+		return nil, err
+	}
+	// Обезличенного пользователя восстановить уже нельзя
+	if !targetUser.PurgedAt.IsZero() {
+		return nil, i18n.NewError("error.account_purged")
+	}
+	if targetUser.Status == status {
+		return nil, i18n.NewError("error.status_unchanged")
+	}
+
+	// Обновляем состояние
+	if err := s.userRepo.UpdateStatus(targetUser.ID, status, reason); err != nil {
+		return nil, err
+	}
+
+	details := fmt.Sprintf("status=%s->%s", targetUser.Status, status)
+	if reason != "" {
+		details += " reason=" + reason
+	}
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actorID,
+		TargetID: targetUser.ID,
+		Action:   domain.AuditStatusChange,
+		Details:  details,
+	})
+
+	targetUser.Status = status
+	targetUser.StatusReason = reason
+	return targetUser, nil
+}
+
+// requireTeammate проверяет, что пользователь состоит в активной команде автора.
+// Администратор бота управляет всеми пользователями
+func (s *AuthService) requireTeammate(actorID int64, target *domain.User) error {
+	actor, err := s.userRepo.GetByID(actorID)
+	if err != nil {
+		return err
+	}
+	if actor == nil || !actor.Active() {
+		return i18n.NewError("error.forbidden")
+	}
+	if actor.Role == domain.RoleAdmin {
+		return nil
+	}
+	member, err := s.teamRepo.GetMember(actor.ActiveTeamID, target.ID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return i18n.NewError("error.user_not_found")
+	}
+	return nil
+}
+
+// GetPendingTransfers возвращает ожидающие запросы на перенос аккаунтов
+func (s *AuthService) GetPendingTransfers() ([]*domain.AccountTransfer, error) {
+	return s.transferRepo.GetPending()
+}
+
+// ApproveTransfer одобряет перенос аккаунта на новый Telegram-аккаунт (требует права transfers.approve)
+func (s *AuthService) ApproveTransfer(actorID int64, transferID int64) (*domain.AccountTransfer, error) {
+	transfer, err := s.pendingTransfer(actorID, transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(transfer.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, i18n.NewError("error.user_not_found")
+	}
+
+	// Перепривязываем пользователя к новому Telegram-аккаунту
+	if err := s.bindTelegram(user, transfer.ToTelegramID, transfer.ToChatID); err != nil {
+		return nil, err
+	}
+
+	if err := s.transferRepo.UpdateStatus(transfer.ID, domain.TransferStatusApproved, actorID); err != nil {
+		return nil, err
+	}
+	transfer.Status = domain.TransferStatusApproved
+	transfer.ResolvedBy = actorID
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actorID,
+		TargetID: transfer.UserID,
+		Action:   domain.AuditTransferApprove,
+		Details:  fmt.Sprintf("transfer_id=%d to_telegram_id=%d", transfer.ID, transfer.ToTelegramID),
+	})
+	return transfer, nil
+}
+
+// RejectTransfer отклоняет перенос аккаунта (требует права transfers.approve)
+func (s *AuthService) RejectTransfer(actorID int64, transferID int64) (*domain.AccountTransfer, error) {
+	transfer, err := s.pendingTransfer(actorID, transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transferRepo.UpdateStatus(transfer.ID, domain.TransferStatusRejected, actorID); err != nil {
+		return nil, err
+	}
+	transfer.Status = domain.TransferStatusRejected
+	transfer.ResolvedBy = actorID
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actorID,
+		TargetID: transfer.UserID,
+		Action:   domain.AuditTransferReject,
+		Details:  fmt.Sprintf("transfer_id=%d to_telegram_id=%d", transfer.ID, transfer.ToTelegramID),
+	})
+	return transfer, nil
+}
+
+// pendingTransfer проверяет право на одобрение переноса и возвращает ожидающий запрос
+func (s *AuthService) pendingTransfer(actorID int64, transferID int64) (*domain.AccountTransfer, error) {
+	if err := s.roles.RequirePermission(actorID, domain.PermApproveTransfers); err != nil {
+		return nil, err
+	}
+
+	transfer, err := s.transferRepo.GetByID(transferID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil {
+		return nil, i18n.NewError("error.transfer_not_found")
+	}
+	if transfer.Status != domain.TransferStatusPending {
+		return nil, i18n.NewError("error.transfer_resolved")
+	}
+	return transfer, nil
 }
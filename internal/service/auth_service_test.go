@@ -0,0 +1,125 @@
+package service_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"HelpBot/internal/config"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/repository"
+	"HelpBot/internal/service"
+)
+
+// newAuthService создает AuthService поверх временной базы SQLite
+func newAuthService(t *testing.T) (*repository.Repositories, *service.AuthService) {
+	t.Helper()
+	repos, db, err := repository.Open("sqlite:"+filepath.Join(t.TempDir(), "test.db"), logging.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cfg := &config.Config{JWTSecret: "0123456789abcdef"}
+	audit := service.NewAuditService(repos.AuditRepository, repos.UserRepository, logging.Discard())
+	roles := service.NewRoleService(repos.RoleRepository, repos.UserRepository, repos.TeamRepository, audit)
+	return repos, service.NewAuthService(repos.UserRepository, repos.TransferRepository, repos.InviteRepository, repos.TeamRepository, roles, audit, cfg)
+}
+
+// errorKey возвращает ключ локализованной ошибки
+func errorKey(err error) string {
+	var localized *i18n.Error
+	if errors.As(err, &localized) {
+		return localized.Key
+	}
+	return ""
+}
+
+func TestLoginUpdatesExistingUser(t *testing.T) {
+	repos, auth := newAuthService(t)
+
+	if err := auth.Register(&domain.User{Username: "alice", Password: "secret"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	// Повторные входы изменяют сохраненного пользователя, а не добавляют нового
+	for i := 0; i < 2; i++ {
+		user, err := auth.Login(10, 20, "alice", "secret")
+		if err != nil {
+			t.Fatalf("Login #%d: %v", i+1, err)
+		}
+		if user.TelegramID != 10 || user.ChatID != 20 {
+			t.Errorf("Login #%d bound user to %d/%d", i+1, user.TelegramID, user.ChatID)
+		}
+	}
+
+	page, err := repos.UserRepository.Find(domain.UserQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Users) != 1 {
+		t.Errorf("got %d users after logins, want 1", len(page.Users))
+	}
+}
+
+func TestChangeStatus(t *testing.T) {
+	repos, auth := newAuthService(t)
+
+	admin := &domain.User{Username: "admin", Password: "secret", Role: domain.RoleAdmin}
+	alice := &domain.User{Username: "alice", Password: "secret"}
+	for _, user := range []*domain.User{admin, alice} {
+		if err := auth.Register(user); err != nil {
+			t.Fatalf("Register %s: %v", user.Username, err)
+		}
+	}
+
+	if _, err := auth.ChangeStatus(alice.ID, "admin", domain.UserSuspended, ""); errorKey(err) != "error.forbidden" {
+		t.Errorf("ChangeStatus without permission = %v", err)
+	}
+	if _, err := auth.ChangeStatus(admin.ID, "admin", domain.UserSuspended, ""); errorKey(err) != "error.status_self" {
+		t.Errorf("ChangeStatus of own account = %v", err)
+	}
+
+	// Приостановленный пользователь не может войти, пока его не восстановят
+	user, err := auth.ChangeStatus(admin.ID, "alice", domain.UserSuspended, "unpaid dues")
+	if err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+	if user.Status != domain.UserSuspended || user.StatusReason != "unpaid dues" {
+		t.Errorf("suspended user = %q %q", user.Status, user.StatusReason)
+	}
+	if _, err := auth.Login(10, 10, "alice", "secret"); errorKey(err) != "error.account_suspended" {
+		t.Errorf("Login of suspended user = %v", err)
+	}
+	if _, err := auth.ChangeStatus(admin.ID, "alice", domain.UserSuspended, ""); errorKey(err) != "error.status_unchanged" {
+		t.Errorf("repeated suspend = %v", err)
+	}
+
+	if _, err := auth.ChangeStatus(admin.ID, "alice", domain.UserActive, ""); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if _, err := auth.Login(10, 10, "alice", "secret"); err != nil {
+		t.Errorf("Login of restored user: %v", err)
+	}
+
+	// Обезличенного пользователя восстановить нельзя, а его имя снова свободно
+	if _, err := auth.ChangeStatus(admin.ID, "alice", domain.UserDeleted, ""); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := repos.UserRepository.AnonymizeDeleted(time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	anonymized := domain.AnonymizedUsername(alice.ID)
+	if _, err := auth.ChangeStatus(admin.ID, anonymized, domain.UserActive, ""); errorKey(err) != "error.account_purged" {
+		t.Errorf("restore of purged user = %v", err)
+	}
+	if err := auth.Register(&domain.User{Username: "alice", Password: "secret"}); err != nil {
+		t.Errorf("Register with the name of a purged user: %v", err)
+	}
+	if err := auth.Register(&domain.User{Username: anonymized, Password: "secret"}); errorKey(err) != "error.username_reserved" {
+		t.Errorf("Register with a reserved name = %v", err)
+	}
+}
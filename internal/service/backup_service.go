@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"HelpBot/internal/backup"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
+)
+
+// BackupService реализует интерфейс domain.BackupService
+type BackupService struct {
+	manager *backup.Manager // nil, если хранилище не поддерживает резервное копирование
+	roles   domain.RoleService
+	audit   domain.AuditService
+	logger  *slog.Logger
+}
+
+// NewBackupService создает новый экземпляр BackupService
+func NewBackupService(manager *backup.Manager, roles domain.RoleService, audit domain.AuditService, logger *slog.Logger) *BackupService {
+	return &BackupService{
+		manager: manager,
+		roles:   roles,
+		audit:   audit,
+		logger:  logger,
+	}
+}
+
+// Create создает резервную копию по команде администратора (требует права backups.manage)
+func (s *BackupService) Create(actorID int64) (*domain.Backup, error) {
+	if err := s.require(actorID); err != nil {
+		return nil, err
+	}
+
+	created, err := s.backup()
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditBackupCreate,
+		Details: "name=" + created.Name,
+	})
+	return created, nil
+}
+
+// List возвращает резервные копии от новых к старым (требует права backups.manage)
+func (s *BackupService) List(actorID int64) ([]*domain.Backup, error) {
+	if err := s.require(actorID); err != nil {
+		return nil, err
+	}
+	return s.manager.List()
+}
+
+// Verify проверяет контрольную сумму и целостность резервной копии (требует права backups.manage)
+func (s *BackupService) Verify(actorID int64, name string) (*domain.Backup, error) {
+	if err := s.require(actorID); err != nil {
+		return nil, err
+	}
+
+	verified, err := s.manager.Verify(name)
+	switch {
+	case errors.Is(err, backup.ErrNotFound):
+		return nil, i18n.NewError("error.backup_not_found", i18n.P{"name": name})
+	case errors.Is(err, backup.ErrChecksumMissing), errors.Is(err, backup.ErrChecksumMismatch):
+		return nil, i18n.NewError("error.backup_checksum")
+	case err != nil:
+		s.logger.Warn("Backup verification failed", "backup", name, logging.Err(err))
+		return nil, i18n.NewError("error.backup_invalid")
+	}
+	return verified, nil
+}
+
+// Run создает резервные копии с указанным интервалом, пока не отменен контекст.
+// Первая копия создается, когда с последней прошел интервал, поэтому перезапуски
+// бота не приводят к лишним копиям. Нулевой интервал отключает расписание
+func (s *BackupService) Run(ctx context.Context, interval time.Duration) {
+	if s.manager == nil || interval <= 0 {
+		s.logger.Info("Scheduled backups are disabled")
+		return
+	}
+
+	for {
+		latest, err := s.manager.Latest()
+		if err != nil {
+			s.logger.Error("Error listing backups", logging.Err(err))
+		}
+		timer := time.NewTimer(time.Until(latest.Add(interval)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := s.backup(); err != nil {
+			s.logger.Error("Error creating scheduled backup", logging.Err(err))
+			// Повторяем попытку через интервал, а не сразу
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}
+}
+
+// backup создает копию и применяет срок хранения
+func (s *BackupService) backup() (*domain.Backup, error) {
+	created, err := s.manager.Create()
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Backup created", "backup", created.Name, "size", created.Size)
+
+	removed, err := s.manager.Prune()
+	if err != nil {
+		s.logger.Error("Error removing old backups", logging.Err(err))
+	}
+	for _, name := range removed {
+		s.logger.Info("Old backup removed", "backup", name)
+	}
+	return created, nil
+}
+
+// require проверяет право на управление резервными копиями и их поддержку хранилищем
+func (s *BackupService) require(actorID int64) error {
+	if err := s.roles.RequirePermission(actorID, domain.PermManageBackups); err != nil {
+		return err
+	}
+	if s.manager == nil {
+		return i18n.NewError("error.backup_unsupported")
+	}
+	return nil
+}
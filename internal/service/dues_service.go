@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+	"HelpBot/internal/logging"
+)
+
+// Ограничения взносов и операций по балансу
+const (
+	maxDuesPlanNameLength = 64
+	maxPaymentNoteLength  = 200
+	balanceEntriesShown   = 10
+)
+
+// DuesService реализует интерфейс domain.DuesService
+type DuesService struct {
+	duesRepo    domain.DuesRepository
+	balanceRepo domain.BalanceRepository
+	teamRepo    domain.TeamRepository
+	userRepo    domain.UserRepository
+	roles       domain.RoleService
+	audit       domain.AuditService
+	logger      *slog.Logger
+}
+
+// NewDuesService создает новый экземпляр DuesService
+func NewDuesService(duesRepo domain.DuesRepository, balanceRepo domain.BalanceRepository, teamRepo domain.TeamRepository,
+	userRepo domain.UserRepository, roles domain.RoleService, audit domain.AuditService, logger *slog.Logger) *DuesService {
+	return &DuesService{
+		duesRepo:    duesRepo,
+		balanceRepo: balanceRepo,
+		teamRepo:    teamRepo,
+		userRepo:    userRepo,
+		roles:       roles,
+		audit:       audit,
+		logger:      logger,
+	}
+}
+
+// CreatePlan создает план взносов активной команды (требует права dues.manage).
+// Пустой список участников означает всех участников команды. Наступившие периоды
+// начисляются сразу
+func (s *DuesService) CreatePlan(actorID int64, name string, amount int64, period domain.DuesPeriod, start time.Time, usernames []string) (*domain.DuesPlan, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageDues)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxDuesPlanNameLength {
+		return nil, i18n.NewError("error.dues_name_invalid", i18n.P{"max": maxDuesPlanNameLength})
+	}
+	if amount <= 0 {
+		return nil, i18n.NewError("error.dues_amount_invalid")
+	}
+	if !domain.IsKnownDuesPeriod(period) {
+		return nil, i18n.NewError("error.dues_period_invalid")
+	}
+	if start.IsZero() {
+		return nil, i18n.NewError("error.dues_start_invalid")
+	}
+
+	plan := &domain.DuesPlan{
+		TeamID:    team.ID,
+		Name:      name,
+		Amount:    amount,
+		Period:    period,
+		StartDate: domain.Date(start),
+		Active:    true,
+		CreatedBy: actorID,
+	}
+	seen := make(map[int64]bool, len(usernames))
+	for _, username := range usernames {
+		user, err := memberByName(s.userRepo, s.teamRepo, team, username)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[user.ID] {
+			seen[user.ID] = true
+			plan.MemberIDs = append(plan.MemberIDs, user.ID)
+		}
+	}
+
+	if err := s.duesRepo.SavePlan(plan); err != nil {
+		if errors.Is(err, domain.ErrDuesPlanExists) {
+			return nil, i18n.NewError("error.dues_plan_exists", i18n.P{"name": name})
+		}
+		return nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditDuesPlanCreate,
+		Details: fmt.Sprintf("team=%d plan=%d name=%s amount=%s period=%s start=%s members=%d",
+			team.ID, plan.ID, plan.Name, domain.FormatAmount(plan.Amount), plan.Period,
+			plan.StartDate.Format(domain.DateLayout), len(plan.MemberIDs)),
+	})
+
+	if _, err := s.chargePlan(plan, domain.Date(time.Now())); err != nil {
+		// Оставшиеся периоды начислит плановый запуск
+		s.logger.Error("Error charging new dues plan", "plan_id", plan.ID, logging.Err(err))
+	}
+	return plan, nil
+}
+
+// Plans возвращает планы взносов активной команды (требует права dues.manage)
+func (s *DuesService) Plans(actorID int64) ([]*domain.DuesPlan, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageDues)
+	if err != nil {
+		return nil, err
+	}
+	return s.duesRepo.GetPlans(team.ID)
+}
+
+// StopPlan останавливает начисления по плану взносов (требует права dues.manage).
+// Начисленные ранее суммы сохраняются
+func (s *DuesService) StopPlan(actorID int64, planID int64) (*domain.DuesPlan, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageDues)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := s.duesRepo.GetPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil || plan.TeamID != team.ID {
+		return nil, i18n.NewError("error.dues_plan_not_found", i18n.P{"id": planID})
+	}
+	if !plan.Active {
+		return nil, i18n.NewError("error.dues_plan_stopped", i18n.P{"name": plan.Name})
+	}
+
+	if err := s.duesRepo.SetActive(plan.ID, false); err != nil {
+		return nil, err
+	}
+	plan.Active = false
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditDuesPlanStop,
+		Details: fmt.Sprintf("team=%d plan=%d name=%s", team.ID, plan.ID, plan.Name),
+	})
+	return plan, nil
+}
+
+// RecordPayment записывает оплату участника активной команды (требует права payments.confirm)
+func (s *DuesService) RecordPayment(actorID int64, username string, amount int64, note string) (*domain.BalanceEntry, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermConfirmPayments)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, i18n.NewError("error.dues_amount_invalid")
+	}
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > maxPaymentNoteLength {
+		return nil, i18n.NewError("error.payment_note_too_long", i18n.P{"max": maxPaymentNoteLength})
+	}
+	user, err := memberByName(s.userRepo, s.teamRepo, team, username)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &domain.BalanceEntry{
+		TeamID:    team.ID,
+		UserID:    user.ID,
+		Kind:      domain.EntryPayment,
+		Amount:    amount,
+		Note:      note,
+		CreatedBy: actorID,
+	}
+	if _, err := s.balanceRepo.AddEntry(entry); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actorID,
+		TargetID: user.ID,
+		Action:   domain.AuditPaymentRecord,
+		Details:  fmt.Sprintf("team=%d amount=%s", team.ID, domain.FormatAmount(amount)),
+	})
+	return entry, nil
+}
+
+// Balance возвращает баланс пользователя в его активной команде и последние операции
+func (s *DuesService) Balance(userID int64) (*domain.BalanceSummary, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, i18n.NewError("error.user_not_found")
+	}
+	team, err := s.teamRepo.GetByID(user.ActiveTeamID)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, i18n.NewError("error.team_required")
+	}
+
+	balance, err := s.balanceRepo.GetBalance(team.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.balanceRepo.GetEntries(team.ID, user.ID, balanceEntriesShown)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.BalanceSummary{Team: team, Balance: balance, Entries: entries}, nil
+}
+
+// Debtors возвращает должников активной команды (требует права dues.manage)
+func (s *DuesService) Debtors(actorID int64) ([]*domain.Debtor, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageDues)
+	if err != nil {
+		return nil, err
+	}
+	return s.balanceRepo.GetDebtors(team.ID)
+}
+
+// ChargeDue начисляет взносы за все наступившие к моменту now периоды действующих планов
+// и возвращает число новых начислений. Начисление можно безопасно повторять: уже
+// начисленные периоды пропускаются
+func (s *DuesService) ChargeDue(now time.Time) (int, error) {
+	plans, err := s.duesRepo.GetActivePlans()
+	if err != nil {
+		return 0, err
+	}
+
+	today := domain.Date(now)
+	total := 0
+	var errs []error
+	for _, plan := range plans {
+		charged, err := s.chargePlan(plan, today)
+		total += charged
+		if err != nil {
+			errs = append(errs, fmt.Errorf("dues plan %d: %w", plan.ID, err))
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+// Run начисляет взносы сразу и затем с указанным интервалом, пока не отменен контекст
+func (s *DuesService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		charged, err := s.ChargeDue(time.Now())
+		if err != nil {
+			s.logger.Error("Error charging dues", logging.Err(err))
+		}
+		if charged > 0 {
+			s.logger.Info("Dues charged", "count", charged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// chargePlan начисляет взносы по плану за наступившие периоды. Период отмечается
+// начисленным только после начисления всем участникам, поэтому прерванное начисление
+// продолжается при следующем запуске, а уникальный ключ не дает начислить дважды
+func (s *DuesService) chargePlan(plan *domain.DuesPlan, today time.Time) (int, error) {
+	periods := plan.DuePeriods(today)
+	if len(periods) == 0 {
+		return 0, nil
+	}
+	members, err := s.planMembers(plan)
+	if err != nil {
+		return 0, err
+	}
+
+	charged := 0
+	for _, period := range periods {
+		for _, userID := range members {
+			added, err := s.balanceRepo.AddEntry(&domain.BalanceEntry{
+				TeamID: plan.TeamID,
+				UserID: userID,
+				Kind:   domain.EntryCharge,
+				Amount: -plan.Amount,
+				PlanID: plan.ID,
+				Period: period,
+			})
+			if err != nil {
+				return charged, err
+			}
+			if added {
+				charged++
+			}
+		}
+		if err := s.duesRepo.SetChargedThrough(plan.ID, period); err != nil {
+			return charged, err
+		}
+		plan.ChargedThrough = period
+	}
+	return charged, nil
+}
+
+// planMembers возвращает идентификаторы активных участников команды, которым начисляются
+// взносы по плану. Исключенные из команды участники плана пропускаются
+func (s *DuesService) planMembers(plan *domain.DuesPlan) ([]int64, error) {
+	if len(plan.MemberIDs) == 0 {
+		page, err := s.teamRepo.FindMembers(plan.TeamID, domain.UserQuery{Statuses: []domain.UserStatus{domain.UserActive}})
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]int64, len(page.Users))
+		for i, user := range page.Users {
+			ids[i] = user.ID
+		}
+		return ids, nil
+	}
+
+	var ids []int64
+	for _, userID := range plan.MemberIDs {
+		member, err := s.teamRepo.GetMember(plan.TeamID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if member == nil {
+			continue
+		}
+		user, err := s.userRepo.GetByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil && user.Active() {
+			ids = append(ids, userID)
+		}
+	}
+	return ids, nil
+}
+
+// memberByName возвращает участника команды по имени; имя можно указать с @
+func memberByName(userRepo domain.UserRepository, teamRepo domain.TeamRepository, team *domain.Team, username string) (*domain.User, error) {
+	user, err := userRepo.GetByUsername(strings.TrimPrefix(username, "@"))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.PurgedAt.IsZero() {
+		return nil, i18n.NewError("error.user_not_found")
+	}
+	member, err := teamRepo.GetMember(team.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, i18n.NewError("error.member_not_found", i18n.P{"username": user.Username, "team": team.Name})
+	}
+	return user, nil
+}
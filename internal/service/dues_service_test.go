@@ -0,0 +1,187 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/service"
+)
+
+// balanceOf возвращает баланс пользователя в активной команде
+func balanceOf(t *testing.T, dues *service.DuesService, userID int64) int64 {
+	t.Helper()
+	summary, err := dues.Balance(userID)
+	if err != nil {
+		t.Fatalf("Balance(%d): %v", userID, err)
+	}
+	return summary.Balance
+}
+
+func TestDuesChargeIsIdempotent(t *testing.T) {
+	f, root := newTeamFixture(t)
+	f.juniors(t, root)
+	dues := service.NewDuesService(f.repos.DuesRepository, f.repos.BalanceRepository, f.repos.TeamRepository,
+		f.repos.UserRepository, f.roles, f.audit, logging.Discard())
+	alice := f.register(t, "alice", domain.RoleUser)
+	f.register(t, "bob", domain.RoleUser)
+
+	// План начинается в будущем, чтобы при создании ничего не начислялось
+	year := time.Now().Year() + 1
+	day := func(month time.Month, d int) time.Time { return time.Date(year, month, d, 0, 0, 0, 0, time.UTC) }
+	plan, err := dues.CreatePlan(root.ID, "Monthly", 1000, domain.DuesMonthly, day(time.January, 31), nil)
+	if err != nil {
+		t.Fatalf("CreatePlan: %v", err)
+	}
+
+	charged, err := dues.ChargeDue(day(time.March, 31).Add(12 * time.Hour))
+	if err != nil || charged != 9 {
+		t.Fatalf("ChargeDue = %d, %v, want 9 charges for 3 members and 3 periods", charged, err)
+	}
+	if charged, err := dues.ChargeDue(day(time.March, 31).Add(13 * time.Hour)); err != nil || charged != 0 {
+		t.Errorf("repeated ChargeDue = %d, %v, want 0", charged, err)
+	}
+	// Прерванный запуск не успел отметить период: повтор не начисляет дважды
+	if err := f.repos.DuesRepository.SetChargedThrough(plan.ID, day(time.January, 31)); err != nil {
+		t.Fatal(err)
+	}
+	if charged, err := dues.ChargeDue(day(time.March, 31)); err != nil || charged != 0 {
+		t.Errorf("ChargeDue after an interrupted run = %d, %v, want 0", charged, err)
+	}
+	if balance := balanceOf(t, dues, alice.ID); balance != -3000 {
+		t.Errorf("alice's balance = %d, want -3000", balance)
+	}
+
+	// Февральский взнос начисляется в последний день короткого месяца
+	summary, err := dues.Balance(alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var periods []time.Time
+	for _, entry := range summary.Entries {
+		periods = append(periods, entry.Period)
+	}
+	february := time.Date(year, time.March, 0, 0, 0, 0, 0, time.UTC)
+	if len(periods) != 3 || !periods[1].Equal(february) {
+		t.Errorf("charged periods = %v, want the second one on %s", periods, february.Format(domain.DateLayout))
+	}
+
+	// Новый участник платит начиная со следующего периода
+	carol := f.register(t, "carol", domain.RoleUser)
+	if charged, err := dues.ChargeDue(day(time.April, 30)); err != nil || charged != 4 {
+		t.Errorf("ChargeDue for April = %d, %v, want 4", charged, err)
+	}
+	if balance := balanceOf(t, dues, carol.ID); balance != -1000 {
+		t.Errorf("carol's balance = %d, want -1000", balance)
+	}
+
+	if _, err := dues.StopPlan(root.ID, plan.ID); err != nil {
+		t.Fatalf("StopPlan: %v", err)
+	}
+	if _, err := dues.StopPlan(root.ID, plan.ID); errorKey(err) != "error.dues_plan_stopped" {
+		t.Errorf("repeated StopPlan = %v, want error.dues_plan_stopped", err)
+	}
+	if charged, err := dues.ChargeDue(day(time.June, 30)); err != nil || charged != 0 {
+		t.Errorf("ChargeDue of a stopped plan = %d, %v, want 0", charged, err)
+	}
+}
+
+func TestDuesPaymentsAndDebtors(t *testing.T) {
+	f, root := newTeamFixture(t)
+	f.juniors(t, root)
+	dues := service.NewDuesService(f.repos.DuesRepository, f.repos.BalanceRepository, f.repos.TeamRepository,
+		f.repos.UserRepository, f.roles, f.audit, logging.Discard())
+	alice := f.register(t, "alice", domain.RoleUser)
+	bob := f.register(t, "bob", domain.RoleUser)
+	treasurer := f.register(t, "treasurer", domain.RoleUser)
+	if _, err := f.teams.SetMemberRole(root.ID, "treasurer", domain.RoleTreasurer); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		call func() error
+		key  string
+	}{
+		{"without dues.manage", func() error {
+			_, err := dues.CreatePlan(alice.ID, "Weekly", 500, domain.DuesWeekly, time.Now(), nil)
+			return err
+		}, "error.forbidden"},
+		{"zero amount", func() error {
+			_, err := dues.CreatePlan(root.ID, "Weekly", 0, domain.DuesWeekly, time.Now(), nil)
+			return err
+		}, "error.dues_amount_invalid"},
+		{"unknown period", func() error {
+			_, err := dues.CreatePlan(root.ID, "Weekly", 500, "daily", time.Now(), nil)
+			return err
+		}, "error.dues_period_invalid"},
+		{"unknown member", func() error {
+			_, err := dues.CreatePlan(root.ID, "Weekly", 500, domain.DuesWeekly, time.Now(), []string{"nobody"})
+			return err
+		}, "error.user_not_found"},
+		{"payment without payments.confirm", func() error {
+			_, err := dues.RecordPayment(alice.ID, "bob", 500, "")
+			return err
+		}, "error.forbidden"},
+		{"debtors without dues.manage", func() error { _, err := dues.Debtors(alice.ID); return err }, "error.forbidden"},
+	} {
+		if err := tc.call(); errorKey(err) != tc.key {
+			t.Errorf("%s = %v, want %s", tc.name, err, tc.key)
+		}
+	}
+
+	// Еженедельный план для двух участников начисляется сразу за наступившие недели
+	start := domain.Date(time.Now()).AddDate(0, 0, -7)
+	plan, err := dues.CreatePlan(treasurer.ID, "Weekly", 50050, domain.DuesWeekly, start, []string{"alice", "@bob", "alice"})
+	if err != nil {
+		t.Fatalf("CreatePlan: %v", err)
+	}
+	if len(plan.MemberIDs) != 2 {
+		t.Errorf("plan members = %v, want alice and bob", plan.MemberIDs)
+	}
+	if _, err := dues.CreatePlan(treasurer.ID, "Weekly", 100, domain.DuesMonthly, start, nil); errorKey(err) != "error.dues_plan_exists" {
+		t.Errorf("CreatePlan of a duplicate = %v, want error.dues_plan_exists", err)
+	}
+	if balance := balanceOf(t, dues, alice.ID); balance != -100100 {
+		t.Errorf("alice's balance = %d, want -100100", balance)
+	}
+	if balance := balanceOf(t, dues, treasurer.ID); balance != 0 {
+		t.Errorf("treasurer's balance = %d, want 0", balance)
+	}
+
+	if _, err := dues.RecordPayment(treasurer.ID, "bob", 100100, "cash"); err != nil {
+		t.Fatalf("RecordPayment: %v", err)
+	}
+	if _, err := dues.RecordPayment(treasurer.ID, "alice", 30000, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dues.RecordPayment(treasurer.ID, "alice", -1, ""); errorKey(err) != "error.dues_amount_invalid" {
+		t.Errorf("negative payment = %v, want error.dues_amount_invalid", err)
+	}
+
+	debtors, err := dues.Debtors(treasurer.ID)
+	if err != nil {
+		t.Fatalf("Debtors: %v", err)
+	}
+	if len(debtors) != 1 || debtors[0].UserID != alice.ID || debtors[0].Debt != 70100 {
+		t.Errorf("Debtors = %v, want alice with 70100", debtors)
+	}
+	if summary, err := dues.Balance(bob.ID); err != nil || summary.Balance != 0 || summary.Entries[0].Note != "cash" {
+		t.Errorf("bob's balance = %+v, %v", summary, err)
+	}
+
+	entries, err := f.repos.AuditRepository.Find(domain.AuditFilter{UserID: alice.ID, Action: domain.AuditPaymentRecord})
+	if err != nil || len(entries) != 1 || entries[0].ActorID != treasurer.ID {
+		t.Errorf("payment audit entries = %v, %v", entries, err)
+	}
+
+	// Без активной команды баланс недоступен
+	if _, err := f.teams.CreateTeam(root.ID, "Adults"); err != nil {
+		t.Fatal(err)
+	}
+	dave := f.register(t, "dave", domain.RoleUser)
+	if _, err := dues.Balance(dave.ID); errorKey(err) != "error.team_required" {
+		t.Errorf("Balance without a team = %v, want error.team_required", err)
+	}
+}
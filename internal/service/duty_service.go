@@ -0,0 +1,423 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+)
+
+// Ограничения графиков дежурств
+const (
+	maxDutyRosterNameLength = 64
+	maxDutyRosterMembers    = 50
+)
+
+// DutyService реализует интерфейс domain.DutyService
+type DutyService struct {
+	dutyRepo domain.DutyRepository
+	teamRepo domain.TeamRepository
+	userRepo domain.UserRepository
+	roles    domain.RoleService
+	audit    domain.AuditService
+}
+
+// NewDutyService создает новый экземпляр DutyService
+func NewDutyService(dutyRepo domain.DutyRepository, teamRepo domain.TeamRepository, userRepo domain.UserRepository,
+	roles domain.RoleService, audit domain.AuditService) *DutyService {
+	return &DutyService{
+		dutyRepo: dutyRepo,
+		teamRepo: teamRepo,
+		userRepo: userRepo,
+		roles:    roles,
+		audit:    audit,
+	}
+}
+
+// CreateRoster создает график дежурств активной команды. Участники дежурят в порядке,
+// в котором перечислены; пустой список означает всех активных участников команды по имени.
+// Если первый период уже наступил, дежурный на него назначается сразу
+func (s *DutyService) CreateRoster(actorID int64, name string, period domain.DuesPeriod, start time.Time, usernames []string) (*domain.DutyRoster, []*domain.DutyNotice, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageDuties)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxDutyRosterNameLength {
+		return nil, nil, i18n.NewError("error.duty_name_invalid", i18n.P{"max": maxDutyRosterNameLength})
+	}
+	if !domain.IsKnownDuesPeriod(period) {
+		return nil, nil, i18n.NewError("error.dues_period_invalid")
+	}
+	if start.IsZero() {
+		return nil, nil, i18n.NewError("error.dues_start_invalid")
+	}
+
+	roster := &domain.DutyRoster{
+		TeamID:    team.ID,
+		Name:      name,
+		Period:    period,
+		StartDate: domain.Date(start),
+		Active:    true,
+		CreatedBy: actorID,
+	}
+	if len(usernames) == 0 {
+		page, err := s.teamRepo.FindMembers(team.ID, domain.UserQuery{Statuses: []domain.UserStatus{domain.UserActive}})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, user := range page.Users {
+			roster.MemberIDs = append(roster.MemberIDs, user.ID)
+			roster.Members = append(roster.Members, user.Username)
+		}
+	}
+	seen := make(map[int64]bool, len(usernames))
+	for _, username := range usernames {
+		user, err := memberByName(s.userRepo, s.teamRepo, team, username)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !seen[user.ID] {
+			seen[user.ID] = true
+			roster.MemberIDs = append(roster.MemberIDs, user.ID)
+			roster.Members = append(roster.Members, user.Username)
+		}
+	}
+	if len(roster.MemberIDs) == 0 || len(roster.MemberIDs) > maxDutyRosterMembers {
+		return nil, nil, i18n.NewError("error.duty_members_invalid", i18n.P{"max": maxDutyRosterMembers})
+	}
+
+	if err := s.dutyRepo.SaveRoster(roster); err != nil {
+		if errors.Is(err, domain.ErrDutyRosterExists) {
+			return nil, nil, i18n.NewError("error.duty_roster_exists", i18n.P{"name": name})
+		}
+		return nil, nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditDutyCreate,
+		Details: fmt.Sprintf("team=%d roster=%d name=%s period=%s start=%s members=%d",
+			team.ID, roster.ID, roster.Name, roster.Period, roster.StartDate.Format(domain.DateLayout), len(roster.MemberIDs)),
+	})
+
+	notice, err := s.assign(roster, domain.Date(time.Now()))
+	if err != nil {
+		return nil, nil, err
+	}
+	var notices []*domain.DutyNotice
+	if notice != nil {
+		notices = append(notices, notice)
+	}
+	return roster, notices, nil
+}
+
+// Rosters возвращает графики дежурств активной команды
+func (s *DutyService) Rosters(actorID int64) ([]*domain.DutyRoster, error) {
+	_, team, err := teamMember(s.userRepo, s.teamRepo, s.roles, actorID)
+	if err != nil {
+		return nil, err
+	}
+	return s.dutyRepo.GetRosters(team.ID)
+}
+
+// StopRoster останавливает график дежурств активной команды. Назначенные дежурства сохраняются
+func (s *DutyService) StopRoster(actorID int64, rosterID int64) (*domain.DutyRoster, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageDuties)
+	if err != nil {
+		return nil, err
+	}
+	roster, err := s.dutyRepo.GetRoster(rosterID)
+	if err != nil {
+		return nil, err
+	}
+	if roster == nil || roster.TeamID != team.ID {
+		return nil, i18n.NewError("error.duty_roster_not_found", i18n.P{"id": rosterID})
+	}
+	if !roster.Active {
+		return nil, i18n.NewError("error.duty_roster_stopped", i18n.P{"name": roster.Name})
+	}
+
+	if err := s.dutyRepo.SetActive(roster.ID, false); err != nil {
+		return nil, err
+	}
+	roster.Active = false
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditDutyStop,
+		Details: fmt.Sprintf("team=%d roster=%d name=%s", team.ID, roster.ID, roster.Name),
+	})
+	return roster, nil
+}
+
+// CurrentDuties возвращает текущие дежурства действующих графиков активной команды
+func (s *DutyService) CurrentDuties(actorID int64) ([]*domain.DutyAssignment, error) {
+	_, team, err := teamMember(s.userRepo, s.teamRepo, s.roles, actorID)
+	if err != nil {
+		return nil, err
+	}
+	return s.dutyRepo.GetCurrent(team.ID)
+}
+
+// Complete отмечает дежурство выполненным. Отметить может сам дежурный или участник
+// команды дежурства с правом duties.manage
+func (s *DutyService) Complete(actorID int64, assignmentID int64) (*domain.DutyAssignment, error) {
+	actor, assignment, err := s.duty(actorID, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.UserID != actor.ID && !s.canManage(actor, assignment.TeamID) {
+		return nil, i18n.NewError("error.forbidden")
+	}
+	if assignment.Done() {
+		return nil, i18n.NewError("error.duty_done", i18n.P{"name": assignment.RosterName})
+	}
+
+	now := time.Now()
+	if err := s.dutyRepo.Complete(assignment.ID, actor.ID, now); err != nil {
+		if errors.Is(err, domain.ErrDutyDone) {
+			return nil, i18n.NewError("error.duty_done", i18n.P{"name": assignment.RosterName})
+		}
+		return nil, err
+	}
+	assignment.CompletedAt = now
+	assignment.CompletedBy = actor.ID
+	return assignment, nil
+}
+
+// RequestSwap просит участника команды взять невыполненное дежурство пользователя.
+// Одновременно по дежурству может ожидать ответа только один запрос
+func (s *DutyService) RequestSwap(actorID int64, assignmentID int64, username string) (*domain.DutySwap, *domain.DutyNotice, error) {
+	actor, assignment, err := s.duty(actorID, assignmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if assignment.UserID != actor.ID {
+		return nil, nil, i18n.NewError("error.duty_not_yours")
+	}
+	if assignment.Done() {
+		return nil, nil, i18n.NewError("error.duty_done", i18n.P{"name": assignment.RosterName})
+	}
+	team, err := s.teamRepo.GetByID(assignment.TeamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if team == nil {
+		return nil, nil, i18n.NewError("error.duty_not_found", i18n.P{"id": assignmentID})
+	}
+	target, err := memberByName(s.userRepo, s.teamRepo, team, username)
+	if err != nil {
+		return nil, nil, err
+	}
+	if target.ID == actor.ID {
+		return nil, nil, i18n.NewError("error.duty_swap_self")
+	}
+	if !target.Active() {
+		return nil, nil, i18n.NewError("error.duty_swap_inactive", i18n.P{"username": target.Username})
+	}
+	pending, err := s.dutyRepo.GetPendingSwap(assignment.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pending != nil {
+		return nil, nil, i18n.NewError("error.duty_swap_pending")
+	}
+
+	swap := &domain.DutySwap{AssignmentID: assignment.ID, FromID: actor.ID, ToID: target.ID, Status: domain.DutySwapPending}
+	if err := s.dutyRepo.SaveSwap(swap); err != nil {
+		return nil, nil, err
+	}
+	return swap, &domain.DutyNotice{Assignment: assignment, User: target}, nil
+}
+
+// RespondSwap принимает или отклоняет запрос на обмен. Ответить может только участник,
+// которого просили; при согласии дежурство переходит к нему
+func (s *DutyService) RespondSwap(actorID int64, swapID int64, accept bool) (*domain.DutySwap, *domain.DutyNotice, error) {
+	swap, err := s.dutyRepo.GetSwap(swapID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if swap == nil || swap.ToID != actorID {
+		return nil, nil, i18n.NewError("error.duty_swap_not_found")
+	}
+	if swap.Status != domain.DutySwapPending {
+		return nil, nil, i18n.NewError("error.duty_swap_resolved")
+	}
+	assignment, err := s.dutyRepo.GetAssignment(swap.AssignmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if assignment == nil {
+		return nil, nil, i18n.NewError("error.duty_swap_not_found")
+	}
+	if accept && (assignment.Done() || assignment.UserID != swap.FromID) {
+		return nil, nil, i18n.NewError("error.duty_swap_outdated")
+	}
+	if accept {
+		member, err := s.teamRepo.GetMember(assignment.TeamID, actorID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if member == nil {
+			return nil, nil, i18n.NewError("error.duty_swap_not_found")
+		}
+	}
+
+	swap.Status = domain.DutySwapDeclined
+	if accept {
+		swap.Status = domain.DutySwapAccepted
+	}
+	if err := s.dutyRepo.ResolveSwap(swap); err != nil {
+		if errors.Is(err, domain.ErrDutySwapResolved) {
+			return nil, nil, i18n.NewError("error.duty_swap_resolved")
+		}
+		return nil, nil, err
+	}
+
+	if accept {
+		if assignment, err = s.dutyRepo.GetAssignment(assignment.ID); err != nil {
+			return nil, nil, err
+		}
+		s.audit.Record(&domain.AuditEntry{
+			ActorID:  swap.FromID,
+			Action:   domain.AuditDutySwap,
+			TargetID: swap.ToID,
+			Details: fmt.Sprintf("team=%d roster=%d duty=%d period=%s",
+				assignment.TeamID, assignment.RosterID, assignment.ID, assignment.Period.Format(domain.DateLayout)),
+		})
+	}
+
+	requester, err := s.userRepo.GetByID(swap.FromID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var notice *domain.DutyNotice
+	if requester != nil && requester.Active() {
+		notice = &domain.DutyNotice{Assignment: assignment, User: requester}
+	}
+	return swap, notice, nil
+}
+
+// History возвращает дежурства активной команды, начавшиеся в периоде, от новых к старым
+func (s *DutyService) History(actorID int64, from, to time.Time) ([]*domain.DutyAssignment, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageDuties)
+	if err != nil {
+		return nil, err
+	}
+	return s.dutyRepo.GetHistory(team.ID, domain.Date(from), domain.Date(to))
+}
+
+// AssignDue назначает дежурных на наступившие к моменту now периоды действующих графиков.
+// Назначение можно безопасно повторять: на период назначается один дежурный
+func (s *DutyService) AssignDue(now time.Time) ([]*domain.DutyNotice, error) {
+	rosters, err := s.dutyRepo.GetActiveRosters()
+	if err != nil {
+		return nil, err
+	}
+
+	today := domain.Date(now)
+	var notices []*domain.DutyNotice
+	var errs []error
+	for _, roster := range rosters {
+		notice, err := s.assign(roster, today)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("duty roster %d: %w", roster.ID, err))
+			continue
+		}
+		if notice != nil {
+			notices = append(notices, notice)
+		}
+	}
+	return notices, errors.Join(errs...)
+}
+
+// assign назначает дежурного на текущий период графика, если он еще не назначен.
+// Дежурит следующий по очереди активный участник команды; выбывшие пропускаются
+func (s *DutyService) assign(roster *domain.DutyRoster, today time.Time) (*domain.DutyNotice, error) {
+	period, until, ok := roster.DuePeriod(today)
+	if !ok {
+		return nil, nil
+	}
+
+	count := len(roster.MemberIDs)
+	for i := 0; i < count; i++ {
+		position := (roster.Position + i) % count
+		userID := roster.MemberIDs[position]
+		member, err := s.teamRepo.GetMember(roster.TeamID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if member == nil {
+			continue
+		}
+		user, err := s.userRepo.GetByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil || !user.Active() {
+			continue
+		}
+
+		assignment := &domain.DutyAssignment{
+			RosterID:   roster.ID,
+			RosterName: roster.Name,
+			TeamID:     roster.TeamID,
+			Period:     period,
+			Until:      until,
+			UserID:     user.ID,
+			Username:   user.Username,
+		}
+		added, err := s.dutyRepo.Assign(assignment, (position+1)%count)
+		if err != nil || !added {
+			return nil, err
+		}
+		roster.Position = (position + 1) % count
+		roster.AssignedThrough = period
+		return &domain.DutyNotice{Assignment: assignment, User: user}, nil
+	}
+	// В очереди не осталось активных участников: назначим, когда они появятся
+	return nil, nil
+}
+
+// duty возвращает пользователя и дежурство, проверяя, что пользователь состоит в команде
+// дежурства или может управлять всеми командами
+func (s *DutyService) duty(actorID int64, assignmentID int64) (*domain.User, *domain.DutyAssignment, error) {
+	actor, err := s.userRepo.GetByID(actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if actor == nil {
+		return nil, nil, i18n.NewError("error.forbidden")
+	}
+	assignment, err := s.dutyRepo.GetAssignment(assignmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if assignment == nil {
+		return nil, nil, i18n.NewError("error.duty_not_found", i18n.P{"id": assignmentID})
+	}
+	member, err := s.teamRepo.GetMember(assignment.TeamID, actor.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if member == nil && !s.roles.Can(actor, domain.PermManageTeams) {
+		return nil, nil, i18n.NewError("error.duty_not_found", i18n.P{"id": assignmentID})
+	}
+	return actor, assignment, nil
+}
+
+// canManage проверяет, может ли пользователь управлять дежурствами команды. Права в команде
+// определяются ролью в активной команде, поэтому дежурства другой команды отмечает только
+// тот, кто может управлять всеми командами
+func (s *DutyService) canManage(user *domain.User, teamID int64) bool {
+	if user.ActiveTeamID == teamID && s.roles.Can(user, domain.PermManageDuties) {
+		return true
+	}
+	return s.roles.Can(user, domain.PermManageTeams)
+}
@@ -0,0 +1,175 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/service"
+)
+
+// assignedTo возвращает имена дежурных из уведомлений о назначении
+func assignedTo(notices []*domain.DutyNotice) []string {
+	var names []string
+	for _, notice := range notices {
+		names = append(names, notice.Assignment.Username)
+	}
+	return names
+}
+
+func TestDutyRosterRotation(t *testing.T) {
+	f, root := newTeamFixture(t)
+	f.juniors(t, root)
+	coach := f.coordinator(t, root, "coach")
+	duties := service.NewDutyService(f.repos.DutyRepository, f.repos.TeamRepository, f.repos.UserRepository, f.roles, f.audit)
+	alice := f.register(t, "alice", domain.RoleUser)
+	f.register(t, "bob", domain.RoleUser)
+	f.register(t, "carol", domain.RoleUser)
+	if _, err := f.teams.RemoveMember(root.ID, "carol"); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	start := domain.Date(now).AddDate(0, 0, -14)
+
+	for _, tc := range []struct {
+		name    string
+		actorID int64
+		period  domain.DuesPeriod
+		users   []string
+		key     string
+	}{
+		{"without duties.manage", alice.ID, domain.DuesWeekly, nil, "error.forbidden"},
+		{"unknown period", coach.ID, "daily", nil, "error.dues_period_invalid"},
+		{"outsider", coach.ID, domain.DuesWeekly, []string{"carol"}, "error.member_not_found"},
+	} {
+		if _, _, err := duties.CreateRoster(tc.actorID, "Ball", tc.period, start, tc.users); errorKey(err) != tc.key {
+			t.Errorf("CreateRoster %s = %v, want %s", tc.name, err, tc.key)
+		}
+	}
+
+	// Первый дежурный назначается на текущий период сразу; пропущенные периоды не назначаются
+	roster, notices, err := duties.CreateRoster(coach.ID, "Ball", domain.DuesWeekly, start, []string{"alice", "@bob", "coach", "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := assignedTo(notices); len(names) != 1 || names[0] != "alice" {
+		t.Fatalf("CreateRoster notified %v, want alice", names)
+	}
+	if !notices[0].Assignment.Period.Equal(start.AddDate(0, 0, 14)) {
+		t.Errorf("first duty starts %s, want the current week", notices[0].Assignment.Period)
+	}
+	if _, _, err := duties.CreateRoster(coach.ID, "Ball", domain.DuesMonthly, start, nil); errorKey(err) != "error.duty_roster_exists" {
+		t.Errorf("CreateRoster of a duplicate = %v, want error.duty_roster_exists", err)
+	}
+
+	// Повторное назначение в том же периоде ничего не меняет
+	if notices, err := duties.AssignDue(now); err != nil || len(notices) != 0 {
+		t.Errorf("repeated AssignDue = %v, %v, want nothing", assignedTo(notices), err)
+	}
+	if notices, err := duties.AssignDue(now.AddDate(0, 0, 7)); err != nil || len(notices) != 1 || notices[0].Assignment.Username != "bob" {
+		t.Errorf("AssignDue next week = %v, %v, want bob", assignedTo(notices), err)
+	}
+
+	// Выбывший из команды участник пропускается
+	if _, err := f.teams.RemoveMember(root.ID, "coach"); err != nil {
+		t.Fatal(err)
+	}
+	if notices, err := duties.AssignDue(now.AddDate(0, 0, 14)); err != nil || len(notices) != 1 || notices[0].Assignment.Username != "alice" {
+		t.Errorf("AssignDue without coach = %v, %v, want alice", assignedTo(notices), err)
+	}
+
+	current, err := duties.CurrentDuties(alice.ID)
+	if err != nil || len(current) != 1 || current[0].Username != "alice" {
+		t.Errorf("CurrentDuties = %v, %v, want alice", current, err)
+	}
+
+	if _, err := f.teams.SetMemberRole(root.ID, "alice", domain.RoleCoordinator); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := duties.StopRoster(alice.ID, roster.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := duties.StopRoster(alice.ID, roster.ID); errorKey(err) != "error.duty_roster_stopped" {
+		t.Errorf("repeated StopRoster = %v, want error.duty_roster_stopped", err)
+	}
+	if notices, err := duties.AssignDue(now.AddDate(0, 0, 21)); err != nil || len(notices) != 0 {
+		t.Errorf("AssignDue of a stopped roster = %v, %v, want nothing", assignedTo(notices), err)
+	}
+}
+
+func TestDutySwapCompletionAndHistory(t *testing.T) {
+	f, root := newTeamFixture(t)
+	f.juniors(t, root)
+	coach := f.coordinator(t, root, "coach")
+	duties := service.NewDutyService(f.repos.DutyRepository, f.repos.TeamRepository, f.repos.UserRepository, f.roles, f.audit)
+	alice := f.register(t, "alice", domain.RoleUser)
+	bob := f.register(t, "bob", domain.RoleUser)
+	now := time.Now()
+
+	_, notices, err := duties.CreateRoster(coach.ID, "Ball", domain.DuesWeekly, now, []string{"alice", "bob"})
+	if err != nil || len(notices) != 1 {
+		t.Fatalf("CreateRoster = %v, %v", assignedTo(notices), err)
+	}
+	duty := notices[0].Assignment
+
+	for _, tc := range []struct {
+		name     string
+		actorID  int64
+		username string
+		key      string
+	}{
+		{"by another member", bob.ID, "coach", "error.duty_not_yours"},
+		{"with self", alice.ID, "alice", "error.duty_swap_self"},
+		{"with a stranger", alice.ID, "nobody", "error.user_not_found"},
+	} {
+		if _, _, err := duties.RequestSwap(tc.actorID, duty.ID, tc.username); errorKey(err) != tc.key {
+			t.Errorf("RequestSwap %s = %v, want %s", tc.name, err, tc.key)
+		}
+	}
+
+	swap, notice, err := duties.RequestSwap(alice.ID, duty.ID, "bob")
+	if err != nil || notice.User.ID != bob.ID {
+		t.Fatalf("RequestSwap = %+v, %v", notice, err)
+	}
+	if _, _, err := duties.RequestSwap(alice.ID, duty.ID, "coach"); errorKey(err) != "error.duty_swap_pending" {
+		t.Errorf("second RequestSwap = %v, want error.duty_swap_pending", err)
+	}
+	if _, _, err := duties.RespondSwap(coach.ID, swap.ID, true); errorKey(err) != "error.duty_swap_not_found" {
+		t.Errorf("RespondSwap by another member = %v, want error.duty_swap_not_found", err)
+	}
+	if _, notice, err := duties.RespondSwap(bob.ID, swap.ID, false); err != nil || notice.User.ID != alice.ID || notice.Assignment.Username != "alice" {
+		t.Fatalf("declining RespondSwap = %+v, %v", notice, err)
+	}
+
+	// После отказа можно попросить снова; при согласии дежурство переходит
+	swap, _, err = duties.RequestSwap(alice.ID, duty.ID, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, notice, err := duties.RespondSwap(bob.ID, swap.ID, true); err != nil || notice.User.ID != alice.ID || notice.Assignment.Username != "bob" {
+		t.Fatalf("accepting RespondSwap = %+v, %v", notice, err)
+	}
+	if _, _, err := duties.RespondSwap(bob.ID, swap.ID, true); errorKey(err) != "error.duty_swap_resolved" {
+		t.Errorf("repeated RespondSwap = %v, want error.duty_swap_resolved", err)
+	}
+
+	if _, err := duties.Complete(alice.ID, duty.ID); errorKey(err) != "error.forbidden" {
+		t.Errorf("Complete by a former assignee = %v, want error.forbidden", err)
+	}
+	done, err := duties.Complete(bob.ID, duty.ID)
+	if err != nil || !done.Done() || done.CompletedBy != bob.ID {
+		t.Fatalf("Complete = %+v, %v", done, err)
+	}
+	if _, err := duties.Complete(coach.ID, duty.ID); errorKey(err) != "error.duty_done" {
+		t.Errorf("repeated Complete = %v, want error.duty_done", err)
+	}
+
+	from, to := now.AddDate(0, 0, -1), now.AddDate(0, 0, 1)
+	if _, err := duties.History(bob.ID, from, to); errorKey(err) != "error.forbidden" {
+		t.Errorf("History without duties.manage = %v, want error.forbidden", err)
+	}
+	history, err := duties.History(coach.ID, from, to)
+	if err != nil || len(history) != 1 || history[0].Username != "bob" || !history[0].Done() {
+		t.Errorf("History = %v, %v, want the done duty of bob", history, err)
+	}
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+)
+
+// Ограничения событий
+const (
+	maxEventTitleLength    = 100
+	maxEventLocationLength = 100
+	maxEventCapacity       = 1000
+)
+
+// EventService реализует интерфейс domain.EventService
+type EventService struct {
+	eventRepo    domain.EventRepository
+	teamRepo     domain.TeamRepository
+	userRepo     domain.UserRepository
+	roles        domain.RoleService
+	audit        domain.AuditService
+	reminderLead time.Duration // За сколько до начала напоминать о событии (0 - не напоминать)
+}
+
+// NewEventService создает новый экземпляр EventService
+func NewEventService(eventRepo domain.EventRepository, teamRepo domain.TeamRepository, userRepo domain.UserRepository,
+	roles domain.RoleService, audit domain.AuditService, reminderLead time.Duration) *EventService {
+	return &EventService{
+		eventRepo:    eventRepo,
+		teamRepo:     teamRepo,
+		userRepo:     userRepo,
+		roles:        roles,
+		audit:        audit,
+		reminderLead: reminderLead,
+	}
+}
+
+// CreateEvent создает событие активной команды и возвращает ее активных участников,
+// которым нужно отправить приглашение. О событии, до которого осталось меньше срока
+// напоминания, отдельно не напоминают: приглашение приходит незадолго до начала
+func (s *EventService) CreateEvent(actorID int64, event *domain.Event) ([]*domain.User, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	event.Title = strings.TrimSpace(event.Title)
+	event.Location = strings.TrimSpace(event.Location)
+	if event.Title == "" || utf8.RuneCountInString(event.Title) > maxEventTitleLength {
+		return nil, i18n.NewError("error.event_title_invalid", i18n.P{"max": maxEventTitleLength})
+	}
+	if utf8.RuneCountInString(event.Location) > maxEventLocationLength {
+		return nil, i18n.NewError("error.event_location_too_long", i18n.P{"max": maxEventLocationLength})
+	}
+	now := time.Now()
+	if !event.StartsAt.After(now) {
+		return nil, i18n.NewError("error.event_time_invalid")
+	}
+	if event.Capacity < 0 || event.Capacity > maxEventCapacity {
+		return nil, i18n.NewError("error.event_capacity_invalid", i18n.P{"max": maxEventCapacity})
+	}
+	if !event.Deadline.IsZero() && (!event.Deadline.After(now) || event.Deadline.After(event.StartsAt)) {
+		return nil, i18n.NewError("error.event_deadline_invalid")
+	}
+
+	event.TeamID = team.ID
+	event.CreatedBy = actorID
+	event.Canceled = false
+	event.RemindedAt = time.Time{}
+	if s.reminderLead > 0 && !event.StartsAt.After(now.Add(s.reminderLead)) {
+		event.RemindedAt = now
+	}
+	if err := s.eventRepo.Save(event); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditEventCreate,
+		Details: fmt.Sprintf("team=%d event=%d title=%s starts=%s capacity=%d",
+			team.ID, event.ID, event.Title, event.StartsAt.Format(domain.EventTimeLayout), event.Capacity),
+	})
+
+	page, err := s.teamRepo.FindMembers(team.ID, domain.UserQuery{Statuses: []domain.UserStatus{domain.UserActive}})
+	if err != nil {
+		return nil, err
+	}
+	return page.Users, nil
+}
+
+// UpcomingEvents возвращает предстоящие события активной команды в порядке начала
+func (s *EventService) UpcomingEvents(actorID int64) ([]*domain.Event, error) {
+	_, team, err := teamMember(s.userRepo, s.teamRepo, s.roles, actorID)
+	if err != nil {
+		return nil, err
+	}
+	return s.eventRepo.GetUpcoming(team.ID, time.Now())
+}
+
+// GetEvent возвращает событие вместе с ответами участников. Событие доступно участникам
+// его команды, даже если она не выбрана активной: приглашения приходят из всех команд
+func (s *EventService) GetEvent(actorID int64, eventID int64) (*domain.Event, []*domain.RSVP, error) {
+	_, event, err := s.eventMember(actorID, eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	rsvps, err := s.eventRepo.GetRSVPs(event.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return event, rsvps, nil
+}
+
+// Respond записывает ответ участника на приглашение до срока ответа. Если мест не осталось,
+// участник попадает в лист ожидания, а если идущий отказывается, его место получает первый
+// из листа ожидания
+func (s *EventService) Respond(actorID int64, eventID int64, status domain.RSVPStatus) (*domain.RSVPResult, error) {
+	if !domain.IsRSVPAnswer(status) {
+		return nil, i18n.NewError("error.rsvp_invalid")
+	}
+	actor, event, err := s.eventMember(actorID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if !event.RSVPOpen(time.Now()) {
+		return nil, i18n.NewError("error.event_closed", i18n.P{"title": event.Title})
+	}
+
+	rsvp := &domain.RSVP{EventID: event.ID, UserID: actor.ID, Status: status}
+	promotedID, err := s.eventRepo.Respond(rsvp)
+	if err != nil {
+		if errors.Is(err, domain.ErrEventNotFound) {
+			return nil, i18n.NewError("error.event_not_found", i18n.P{"id": eventID})
+		}
+		return nil, err
+	}
+
+	result := &domain.RSVPResult{Status: rsvp.Status}
+	if result.Event, err = s.eventRepo.GetByID(event.ID); err != nil {
+		return nil, err
+	}
+	if rsvp.Status == domain.RSVPWaitlist {
+		rsvps, err := s.eventRepo.GetRSVPs(event.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range rsvps {
+			if other.Status != domain.RSVPWaitlist {
+				continue
+			}
+			result.Position++
+			if other.UserID == actor.ID {
+				break
+			}
+		}
+	}
+	if promotedID != 0 {
+		if result.Promoted, err = s.userRepo.GetByID(promotedID); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// CancelEvent отменяет событие активной команды и возвращает активных участников,
+// которые собирались на него, в том числе из листа ожидания
+func (s *EventService) CancelEvent(actorID int64, eventID int64) (*domain.Event, []*domain.User, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageEvents)
+	if err != nil {
+		return nil, nil, err
+	}
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if event == nil || event.TeamID != team.ID {
+		return nil, nil, i18n.NewError("error.event_not_found", i18n.P{"id": eventID})
+	}
+	if event.Canceled {
+		return nil, nil, i18n.NewError("error.event_canceled", i18n.P{"title": event.Title})
+	}
+
+	if err := s.eventRepo.Cancel(event.ID); err != nil {
+		return nil, nil, err
+	}
+	event.Canceled = true
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditEventCancel,
+		Details: fmt.Sprintf("team=%d event=%d title=%s", team.ID, event.ID, event.Title),
+	})
+
+	users, err := s.respondents(event.ID, domain.RSVPGoing, domain.RSVPMaybe, domain.RSVPWaitlist)
+	if err != nil {
+		return nil, nil, err
+	}
+	return event, users, nil
+}
+
+// DueReminders возвращает события, которые начнутся в пределах срока напоминания,
+// вместе с идущими и сомневающимися участниками. Событие отмечается напомненным
+// до отправки, поэтому напоминание не повторяется и после перезапуска бота
+func (s *EventService) DueReminders(now time.Time) ([]*domain.EventReminder, error) {
+	if s.reminderLead <= 0 {
+		return nil, nil
+	}
+	events, err := s.eventRepo.GetUnreminded(now.Add(s.reminderLead))
+	if err != nil {
+		return nil, err
+	}
+
+	var reminders []*domain.EventReminder
+	for _, event := range events {
+		if err := s.eventRepo.MarkReminded(event.ID, now); err != nil {
+			return nil, err
+		}
+		// О начавшихся событиях (например, пока бот был остановлен) не напоминаем
+		if !event.StartsAt.After(now) {
+			continue
+		}
+		users, err := s.respondents(event.ID, domain.RSVPGoing, domain.RSVPMaybe)
+		if err != nil {
+			return nil, err
+		}
+		if len(users) > 0 {
+			reminders = append(reminders, &domain.EventReminder{Event: event, Users: users})
+		}
+	}
+	return reminders, nil
+}
+
+// eventMember возвращает пользователя и событие, проверяя, что пользователь активен и состоит
+// в команде события или может управлять всеми командами
+func (s *EventService) eventMember(actorID int64, eventID int64) (*domain.User, *domain.Event, error) {
+	actor, err := s.userRepo.GetByID(actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if actor == nil || !actor.Active() {
+		return nil, nil, i18n.NewError("error.forbidden")
+	}
+	event, err := s.eventRepo.GetByID(eventID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if event == nil {
+		return nil, nil, i18n.NewError("error.event_not_found", i18n.P{"id": eventID})
+	}
+	member, err := s.teamRepo.GetMember(event.TeamID, actor.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if member == nil && !s.roles.Can(actor, domain.PermManageTeams) {
+		return nil, nil, i18n.NewError("error.event_not_found", i18n.P{"id": eventID})
+	}
+	return actor, event, nil
+}
+
+// respondents возвращает активных пользователей, ответивших на приглашение одним из ответов
+func (s *EventService) respondents(eventID int64, statuses ...domain.RSVPStatus) ([]*domain.User, error) {
+	rsvps, err := s.eventRepo.GetRSVPs(eventID)
+	if err != nil {
+		return nil, err
+	}
+	var users []*domain.User
+	for _, rsvp := range rsvps {
+		if !slices.Contains(statuses, rsvp.Status) {
+			continue
+		}
+		user, err := s.userRepo.GetByID(rsvp.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil && user.Active() {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
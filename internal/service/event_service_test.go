@@ -0,0 +1,189 @@
+package service_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/service"
+)
+
+func TestEventRSVPWithWaitlist(t *testing.T) {
+	f, root := newTeamFixture(t)
+	f.juniors(t, root)
+	coach := f.coordinator(t, root, "coach")
+	events := service.NewEventService(f.repos.EventRepository, f.repos.TeamRepository, f.repos.UserRepository, f.roles, f.audit, 24*time.Hour)
+	var users []*domain.User
+	for _, name := range []string{"alice", "bob", "carol", "dave", "eve"} {
+		users = append(users, f.register(t, name, domain.RoleUser))
+	}
+	alice, bob, carol, dave, eve := users[0], users[1], users[2], users[3], users[4]
+	startsAt := time.Now().Add(72 * time.Hour).Truncate(time.Minute)
+
+	for _, tc := range []struct {
+		name    string
+		actorID int64
+		event   domain.Event
+		key     string
+	}{
+		{"without events.manage", alice.ID, domain.Event{Title: "Training", StartsAt: startsAt}, "error.forbidden"},
+		{"empty title", coach.ID, domain.Event{Title: " ", StartsAt: startsAt}, "error.event_title_invalid"},
+		{"in the past", coach.ID, domain.Event{Title: "Training", StartsAt: time.Now().Add(-time.Minute)}, "error.event_time_invalid"},
+		{"negative capacity", coach.ID, domain.Event{Title: "Training", StartsAt: startsAt, Capacity: -1}, "error.event_capacity_invalid"},
+		{"deadline after start", coach.ID, domain.Event{Title: "Training", StartsAt: startsAt, Deadline: startsAt.Add(time.Hour)}, "error.event_deadline_invalid"},
+	} {
+		if _, err := events.CreateEvent(tc.actorID, &tc.event); errorKey(err) != tc.key {
+			t.Errorf("CreateEvent %s = %v, want %s", tc.name, err, tc.key)
+		}
+	}
+
+	event := &domain.Event{Title: " Training ", Location: "Stadium", StartsAt: startsAt, Capacity: 2}
+	invitees, err := events.CreateEvent(coach.ID, event)
+	if err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	if names := usernamesOf(invitees); !slices.Contains(names, "alice") || !slices.Contains(names, "coach") || len(names) != 7 {
+		t.Errorf("invitees = %v, want the whole team", names)
+	}
+	if event.ID == 0 || event.Title != "Training" || !event.RemindedAt.IsZero() {
+		t.Errorf("event = %+v", event)
+	}
+
+	// Два места: третий и четвертый желающие попадают в лист ожидания по очереди
+	for i, user := range []*domain.User{alice, bob, carol, dave} {
+		result, err := events.Respond(user.ID, event.ID, domain.RSVPGoing)
+		if err != nil {
+			t.Fatalf("Respond(%s): %v", user.Username, err)
+		}
+		wantStatus, wantPosition := domain.RSVPGoing, 0
+		if i >= 2 {
+			wantStatus, wantPosition = domain.RSVPWaitlist, i-1
+		}
+		if result.Status != wantStatus || result.Position != wantPosition || result.Promoted != nil {
+			t.Errorf("Respond(%s) = %+v, want %s at %d", user.Username, result, wantStatus, wantPosition)
+		}
+	}
+	if _, err := events.Respond(alice.ID, event.ID, domain.RSVPWaitlist); errorKey(err) != "error.rsvp_invalid" {
+		t.Errorf("Respond with waitlist = %v, want error.rsvp_invalid", err)
+	}
+
+	// Отказ идущего отдает место первому из листа ожидания
+	result, err := events.Respond(alice.ID, event.ID, domain.RSVPNotGoing)
+	if err != nil {
+		t.Fatalf("Respond(alice not going): %v", err)
+	}
+	if result.Promoted == nil || result.Promoted.ID != carol.ID {
+		t.Errorf("promoted = %+v, want carol", result.Promoted)
+	}
+	if result.Event.Going != 2 || result.Event.Waitlisted != 1 {
+		t.Errorf("counts = going %d, waitlisted %d, want 2 and 1", result.Event.Going, result.Event.Waitlisted)
+	}
+	if result, err := events.Respond(dave.ID, event.ID, domain.RSVPGoing); err != nil || result.Position != 1 {
+		t.Errorf("Respond(dave again) = %+v, %v, want waitlist position 1", result, err)
+	}
+
+	// Исключенный из команды больше не видит событие
+	if _, err := f.teams.RemoveMember(root.ID, "eve"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := events.GetEvent(eve.ID, event.ID); errorKey(err) != "error.event_not_found" {
+		t.Errorf("GetEvent by an outsider = %v, want error.event_not_found", err)
+	}
+	if _, err := events.Respond(eve.ID, event.ID, domain.RSVPGoing); errorKey(err) != "error.event_not_found" {
+		t.Errorf("Respond by an outsider = %v, want error.event_not_found", err)
+	}
+	// Приостановленный участник остается в команде, но не может ответить и занять место
+	if err := f.repos.UserRepository.UpdateStatus(dave.ID, domain.UserSuspended, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := events.Respond(dave.ID, event.ID, domain.RSVPNotGoing); errorKey(err) != "error.forbidden" {
+		t.Errorf("Respond by a suspended member = %v, want error.forbidden", err)
+	}
+	if _, _, err := events.GetEvent(dave.ID, event.ID); errorKey(err) != "error.forbidden" {
+		t.Errorf("GetEvent by a suspended member = %v, want error.forbidden", err)
+	}
+	if err := f.repos.UserRepository.UpdateStatus(dave.ID, domain.UserActive, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	_, rsvps, err := events.GetEvent(bob.ID, event.ID)
+	if err != nil || len(rsvps) != 4 {
+		t.Fatalf("GetEvent = %v, %v", rsvps, err)
+	}
+
+	// Отмену получают идущие и ожидающие, но не отказавшиеся
+	if _, _, err := events.CancelEvent(alice.ID, event.ID); errorKey(err) != "error.forbidden" {
+		t.Errorf("CancelEvent without events.manage = %v, want error.forbidden", err)
+	}
+	canceled, notified, err := events.CancelEvent(coach.ID, event.ID)
+	if err != nil || !canceled.Canceled {
+		t.Fatalf("CancelEvent = %+v, %v", canceled, err)
+	}
+	names := usernamesOf(notified)
+	slices.Sort(names)
+	if want := []string{"bob", "carol", "dave"}; !slices.Equal(names, want) {
+		t.Errorf("notified = %v, want %v", names, want)
+	}
+	if _, _, err := events.CancelEvent(coach.ID, event.ID); errorKey(err) != "error.event_canceled" {
+		t.Errorf("repeated CancelEvent = %v, want error.event_canceled", err)
+	}
+	if _, err := events.Respond(bob.ID, event.ID, domain.RSVPMaybe); errorKey(err) != "error.event_closed" {
+		t.Errorf("Respond to a canceled event = %v, want error.event_closed", err)
+	}
+	if upcoming, err := events.UpcomingEvents(bob.ID); err != nil || len(upcoming) != 0 {
+		t.Errorf("UpcomingEvents after cancel = %v, %v", upcoming, err)
+	}
+}
+
+func TestEventRemindersAreSentOnce(t *testing.T) {
+	f, root := newTeamFixture(t)
+	f.juniors(t, root)
+	coach := f.coordinator(t, root, "coach")
+	events := service.NewEventService(f.repos.EventRepository, f.repos.TeamRepository, f.repos.UserRepository, f.roles, f.audit, 24*time.Hour)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		f.register(t, name, domain.RoleUser)
+	}
+	now := time.Now()
+
+	// О скором событии не напоминают отдельно: приглашение приходит незадолго до начала
+	soon := &domain.Event{Title: "Meeting", StartsAt: now.Add(2 * time.Hour)}
+	later := &domain.Event{Title: "Game", StartsAt: now.Add(48 * time.Hour)}
+	for _, event := range []*domain.Event{soon, later} {
+		if _, err := events.CreateEvent(coach.ID, event); err != nil {
+			t.Fatalf("CreateEvent(%s): %v", event.Title, err)
+		}
+	}
+	if soon.RemindedAt.IsZero() || !later.RemindedAt.IsZero() {
+		t.Errorf("RemindedAt = %v and %v, want only the soon event marked", soon.RemindedAt, later.RemindedAt)
+	}
+
+	answers := map[string]domain.RSVPStatus{"alice": domain.RSVPGoing, "bob": domain.RSVPMaybe, "carol": domain.RSVPNotGoing}
+	for name, status := range answers {
+		user, err := f.repos.UserRepository.GetByUsername(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, event := range []*domain.Event{soon, later} {
+			if _, err := events.Respond(user.ID, event.ID, status); err != nil {
+				t.Fatalf("Respond(%s): %v", name, err)
+			}
+		}
+	}
+
+	if reminders, err := events.DueReminders(now); err != nil || len(reminders) != 0 {
+		t.Errorf("DueReminders two days before = %v, %v, want none", reminders, err)
+	}
+	reminders, err := events.DueReminders(now.Add(25 * time.Hour))
+	if err != nil || len(reminders) != 1 || reminders[0].Event.ID != later.ID {
+		t.Fatalf("DueReminders a day before = %v, %v, want the game", reminders, err)
+	}
+	names := usernamesOf(reminders[0].Users)
+	slices.Sort(names)
+	if want := []string{"alice", "bob"}; !slices.Equal(names, want) {
+		t.Errorf("reminded = %v, want %v", names, want)
+	}
+	if reminders, err := events.DueReminders(now.Add(26 * time.Hour)); err != nil || len(reminders) != 0 {
+		t.Errorf("repeated DueReminders = %v, %v, want none", reminders, err)
+	}
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+)
+
+// Ограничения общих расходов
+const (
+	maxExpenseDescriptionLength = 200
+	maxExpenseParticipants      = 50
+	maxExpenseShareWeight       = 1000
+	expensesShown               = 10
+)
+
+// ExpenseService реализует интерфейс domain.ExpenseService
+type ExpenseService struct {
+	expenseRepo domain.ExpenseRepository
+	teamRepo    domain.TeamRepository
+	userRepo    domain.UserRepository
+	roles       domain.RoleService
+	audit       domain.AuditService
+}
+
+// NewExpenseService создает новый экземпляр ExpenseService
+func NewExpenseService(expenseRepo domain.ExpenseRepository, teamRepo domain.TeamRepository, userRepo domain.UserRepository,
+	roles domain.RoleService, audit domain.AuditService) *ExpenseService {
+	return &ExpenseService{
+		expenseRepo: expenseRepo,
+		teamRepo:    teamRepo,
+		userRepo:    userRepo,
+		roles:       roles,
+		audit:       audit,
+	}
+}
+
+// AddExpense записывает расход активной команды, оплаченный участником payer, и делит
+// его между участниками: поровну, пропорционально долям или точными суммами. Плательщику
+// засчитывается оплаченная сумма за вычетом его доли, остальным участникам - долг на их долю
+func (s *ExpenseService) AddExpense(actorID int64, payer string, amount int64, mode domain.SplitMode,
+	shares []domain.ExpenseShare, description string) (*domain.Expense, error) {
+	_, team, err := teamMember(s.userRepo, s.teamRepo, s.roles, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, i18n.NewError("error.dues_amount_invalid")
+	}
+	if !domain.IsKnownSplitMode(mode) {
+		return nil, i18n.NewError("error.expense_mode_invalid")
+	}
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(description) > maxExpenseDescriptionLength {
+		return nil, i18n.NewError("error.expense_description_too_long", i18n.P{"max": maxExpenseDescriptionLength})
+	}
+	if len(shares) == 0 || len(shares) > maxExpenseParticipants {
+		return nil, i18n.NewError("error.expense_participants_invalid", i18n.P{"max": maxExpenseParticipants})
+	}
+
+	payerUser, err := memberByName(s.userRepo, s.teamRepo, team, payer)
+	if err != nil {
+		return nil, err
+	}
+	shares, err = s.resolveShares(team, shares)
+	if err != nil {
+		return nil, err
+	}
+	if len(shares) == 1 && shares[0].UserID == payerUser.ID {
+		return nil, i18n.NewError("error.expense_self")
+	}
+	if err := splitExpense(amount, mode, shares); err != nil {
+		return nil, err
+	}
+
+	expense := &domain.Expense{
+		TeamID:      team.ID,
+		PayerID:     payerUser.ID,
+		PayerName:   payerUser.Username,
+		Amount:      amount,
+		Description: description,
+		Mode:        mode,
+		Shares:      shares,
+		CreatedBy:   actorID,
+	}
+	if err := s.save(expense, domain.EntryExpense); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actorID,
+		TargetID: payerUser.ID,
+		Action:   domain.AuditExpenseAdd,
+		Details: fmt.Sprintf("team=%d expense=%d amount=%s mode=%s participants=%d",
+			team.ID, expense.ID, domain.FormatAmount(amount), mode, len(shares)),
+	})
+	return expense, nil
+}
+
+// Expenses возвращает последние расходы и переводы активной команды
+func (s *ExpenseService) Expenses(actorID int64) ([]*domain.Expense, error) {
+	_, team, err := teamMember(s.userRepo, s.teamRepo, s.roles, actorID)
+	if err != nil {
+		return nil, err
+	}
+	return s.expenseRepo.GetExpenses(team.ID, expensesShown)
+}
+
+// SettleUp возвращает наименьший набор переводов, закрывающий взаимные долги участников
+// активной команды по расходам. Взносы в расчет не входят
+func (s *ExpenseService) SettleUp(actorID int64) ([]*domain.Transfer, error) {
+	_, team, err := teamMember(s.userRepo, s.teamRepo, s.roles, actorID)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := s.expenseRepo.GetNetBalances(team.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := domain.SettleUp(balances)
+	names := make(map[int64]string)
+	for _, transfer := range transfers {
+		for _, id := range []int64{transfer.FromID, transfer.ToID} {
+			if _, ok := names[id]; ok {
+				continue
+			}
+			names[id] = fmt.Sprintf("#%d", id)
+			if user, err := s.userRepo.GetByID(id); err == nil && user != nil {
+				names[id] = user.Username
+			}
+		}
+		transfer.FromName = names[transfer.FromID]
+		transfer.ToName = names[transfer.ToID]
+	}
+	return transfers, nil
+}
+
+// Settle записывает перевод пользователя участнику активной команды в счет долга
+func (s *ExpenseService) Settle(actorID int64, username string, amount int64) (*domain.Expense, error) {
+	actor, team, err := teamMember(s.userRepo, s.teamRepo, s.roles, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, i18n.NewError("error.dues_amount_invalid")
+	}
+	recipient, err := memberByName(s.userRepo, s.teamRepo, team, username)
+	if err != nil {
+		return nil, err
+	}
+	if recipient.ID == actor.ID {
+		return nil, i18n.NewError("error.expense_self")
+	}
+
+	expense := &domain.Expense{
+		TeamID:     team.ID,
+		PayerID:    actor.ID,
+		PayerName:  actor.Username,
+		Amount:     amount,
+		Mode:       domain.SplitExact,
+		Settlement: true,
+		Shares:     []domain.ExpenseShare{{UserID: recipient.ID, Username: recipient.Username, Value: amount, Amount: amount}},
+		CreatedBy:  actorID,
+	}
+	if err := s.save(expense, domain.EntrySettlement); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actorID,
+		TargetID: recipient.ID,
+		Action:   domain.AuditExpenseSettle,
+		Details:  fmt.Sprintf("team=%d expense=%d amount=%s", team.ID, expense.ID, domain.FormatAmount(amount)),
+	})
+	return expense, nil
+}
+
+// save сохраняет расход вместе с операциями по балансам: плательщику засчитывается
+// оплаченная сумма, участникам - долг на их долю. Операции одного пользователя
+// объединяются, нулевые не записываются
+func (s *ExpenseService) save(expense *domain.Expense, kind domain.BalanceEntryKind) error {
+	amounts := map[int64]int64{expense.PayerID: expense.Amount}
+	order := []int64{expense.PayerID}
+	for _, share := range expense.Shares {
+		if _, ok := amounts[share.UserID]; !ok {
+			order = append(order, share.UserID)
+		}
+		amounts[share.UserID] -= share.Amount
+	}
+
+	var entries []*domain.BalanceEntry
+	for _, userID := range order {
+		if amounts[userID] == 0 {
+			continue
+		}
+		entries = append(entries, &domain.BalanceEntry{
+			TeamID:    expense.TeamID,
+			UserID:    userID,
+			Kind:      kind,
+			Amount:    amounts[userID],
+			Note:      expense.Description,
+			CreatedBy: expense.CreatedBy,
+		})
+	}
+	return s.expenseRepo.Save(expense, entries)
+}
+
+// resolveShares находит участников расхода по именам. Каждый участник указывается один раз
+func (s *ExpenseService) resolveShares(team *domain.Team, shares []domain.ExpenseShare) ([]domain.ExpenseShare, error) {
+	resolved := make([]domain.ExpenseShare, len(shares))
+	seen := make(map[int64]bool, len(shares))
+	for i, share := range shares {
+		user, err := memberByName(s.userRepo, s.teamRepo, team, share.Username)
+		if err != nil {
+			return nil, err
+		}
+		if seen[user.ID] {
+			return nil, i18n.NewError("error.expense_participant_duplicate", i18n.P{"username": user.Username})
+		}
+		seen[user.ID] = true
+		resolved[i] = domain.ExpenseShare{UserID: user.ID, Username: user.Username, Value: share.Value}
+	}
+	return resolved, nil
+}
+
+// splitExpense делит сумму между участниками и заполняет их доли. Копейки, которые
+// не делятся нацело, достаются участникам с наибольшим остатком, а при равенстве -
+// указанным раньше
+func splitExpense(amount int64, mode domain.SplitMode, shares []domain.ExpenseShare) error {
+	switch mode {
+	case domain.SplitEqual:
+		for i := range shares {
+			shares[i].Value = 1
+		}
+	case domain.SplitShares:
+		for _, share := range shares {
+			if share.Value <= 0 || share.Value > maxExpenseShareWeight {
+				return i18n.NewError("error.expense_share_invalid", i18n.P{"username": share.Username, "max": maxExpenseShareWeight})
+			}
+		}
+	case domain.SplitExact:
+		var sum int64
+		for i, share := range shares {
+			if share.Value <= 0 {
+				return i18n.NewError("error.expense_exact_invalid", i18n.P{"username": share.Username})
+			}
+			shares[i].Amount = share.Value
+			sum += share.Value
+		}
+		if sum != amount {
+			return i18n.NewError("error.expense_exact_mismatch", i18n.P{"sum": domain.FormatAmount(sum), "amount": domain.FormatAmount(amount)})
+		}
+		return nil
+	}
+
+	var total int64
+	for _, share := range shares {
+		total += share.Value
+	}
+	remainders := make([]int64, len(shares))
+	left := amount
+	for i, share := range shares {
+		shares[i].Amount = amount * share.Value / total
+		remainders[i] = amount * share.Value % total
+		left -= shares[i].Amount
+	}
+
+	order := make([]int, len(shares))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(remainders[b], remainders[a])
+	})
+	for _, i := range order[:left] {
+		shares[i].Amount++
+	}
+	return nil
+}
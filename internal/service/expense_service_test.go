@@ -0,0 +1,159 @@
+package service_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/service"
+)
+
+// shareAmounts возвращает доли участников расхода в виде «имя=сумма»
+func shareAmounts(expense *domain.Expense) []string {
+	var amounts []string
+	for _, share := range expense.Shares {
+		amounts = append(amounts, fmt.Sprintf("%s=%d", share.Username, share.Amount))
+	}
+	return amounts
+}
+
+func TestExpenseSplits(t *testing.T) {
+	f, root := newTeamFixture(t)
+	f.juniors(t, root)
+	expenses := service.NewExpenseService(f.repos.ExpenseRepository, f.repos.TeamRepository, f.repos.UserRepository, f.roles, f.audit)
+	alice := f.register(t, "alice", domain.RoleUser)
+	bob := f.register(t, "bob", domain.RoleUser)
+	f.register(t, "carol", domain.RoleUser)
+	participants := func(values ...any) []domain.ExpenseShare {
+		var shares []domain.ExpenseShare
+		for i := 0; i < len(values); i += 2 {
+			shares = append(shares, domain.ExpenseShare{Username: values[i].(string), Value: int64(values[i+1].(int))})
+		}
+		return shares
+	}
+
+	for _, tc := range []struct {
+		name   string
+		mode   domain.SplitMode
+		amount int64
+		shares []domain.ExpenseShare
+		want   []string
+	}{
+		// Неделимые копейки достаются указанным раньше
+		{"equal", domain.SplitEqual, 1000, participants("alice", 0, "bob", 0, "carol", 0), []string{"alice=334", "bob=333", "carol=333"}},
+		{"shares", domain.SplitShares, 1000, participants("alice", 2, "@bob", 1), []string{"alice=667", "bob=333"}},
+		{"exact", domain.SplitExact, 1000, participants("bob", 700, "carol", 300), []string{"bob=700", "carol=300"}},
+	} {
+		expense, err := expenses.AddExpense(bob.ID, "alice", tc.amount, tc.mode, tc.shares, " Field rental ")
+		if err != nil {
+			t.Fatalf("AddExpense(%s): %v", tc.name, err)
+		}
+		if got := shareAmounts(expense); !slices.Equal(got, tc.want) {
+			t.Errorf("%s shares = %v, want %v", tc.name, got, tc.want)
+		}
+		if expense.PayerID != alice.ID || expense.Description != "Field rental" {
+			t.Errorf("%s expense = %+v", tc.name, expense)
+		}
+	}
+
+	// alice платит 3000 при своей доле 1001, доля bob - 333 + 333 + 700
+	balances, err := f.repos.ExpenseRepository.GetNetBalances(f.user(t, alice.ID).ActiveTeamID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances[alice.ID] != 1999 || balances[bob.ID] != -1366 {
+		t.Errorf("net balances = %v", balances)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		mode   domain.SplitMode
+		shares []domain.ExpenseShare
+		key    string
+	}{
+		{"unknown mode", "percent", participants("bob", 0), "error.expense_mode_invalid"},
+		{"no participants", domain.SplitEqual, nil, "error.expense_participants_invalid"},
+		{"duplicate participant", domain.SplitEqual, participants("bob", 0, "@bob", 0), "error.expense_participant_duplicate"},
+		{"unknown participant", domain.SplitEqual, participants("nobody", 0), "error.user_not_found"},
+		{"payer only", domain.SplitEqual, participants("alice", 0), "error.expense_self"},
+		{"zero share", domain.SplitShares, participants("bob", 0, "carol", 1), "error.expense_share_invalid"},
+		{"exact mismatch", domain.SplitExact, participants("bob", 500, "carol", 400), "error.expense_exact_mismatch"},
+	} {
+		if _, err := expenses.AddExpense(bob.ID, "alice", 1000, tc.mode, tc.shares, ""); errorKey(err) != tc.key {
+			t.Errorf("%s = %v, want %s", tc.name, err, tc.key)
+		}
+	}
+}
+
+func TestExpenseSettleUpUsesMinimumTransfers(t *testing.T) {
+	f, root := newTeamFixture(t)
+	f.juniors(t, root)
+	expenses := service.NewExpenseService(f.repos.ExpenseRepository, f.repos.TeamRepository, f.repos.UserRepository, f.roles, f.audit)
+	for _, name := range []string{"anna", "boris", "clara", "dmitry", "elena"} {
+		f.register(t, name, domain.RoleUser)
+	}
+	exact := func(username string, amount int64) []domain.ExpenseShare {
+		return []domain.ExpenseShare{{Username: username, Value: amount}}
+	}
+
+	// Балансы +2, +2, +3, -3, -4: жадный алгоритм дает четыре перевода, а достаточно трех
+	for _, e := range []struct {
+		payer, participant string
+		amount             int64
+	}{{"clara", "dmitry", 300}, {"anna", "elena", 200}, {"boris", "elena", 200}} {
+		if _, err := expenses.AddExpense(root.ID, e.payer, e.amount, domain.SplitExact, exact(e.participant, e.amount), ""); err != nil {
+			t.Fatalf("AddExpense: %v", err)
+		}
+	}
+
+	transfersOf := func() []string {
+		t.Helper()
+		transfers, err := expenses.SettleUp(root.ID)
+		if err != nil {
+			t.Fatalf("SettleUp: %v", err)
+		}
+		var result []string
+		for _, transfer := range transfers {
+			result = append(result, fmt.Sprintf("%s->%s:%d", transfer.FromName, transfer.ToName, transfer.Amount))
+		}
+		slices.Sort(result)
+		return result
+	}
+	if got, want := transfersOf(), []string{"dmitry->clara:300", "elena->anna:200", "elena->boris:200"}; !slices.Equal(got, want) {
+		t.Errorf("SettleUp = %v, want %v", got, want)
+	}
+
+	elena, err := f.repos.UserRepository.GetByUsername("elena")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := expenses.Settle(elena.ID, "anna", 200); err != nil {
+		t.Fatalf("Settle: %v", err)
+	}
+	if _, err := expenses.Settle(elena.ID, "elena", 200); errorKey(err) != "error.expense_self" {
+		t.Errorf("Settle with yourself = %v, want error.expense_self", err)
+	}
+	if got, want := transfersOf(), []string{"dmitry->clara:300", "elena->boris:200"}; !slices.Equal(got, want) {
+		t.Errorf("SettleUp after a transfer = %v, want %v", got, want)
+	}
+
+	list, err := expenses.Expenses(elena.ID)
+	if err != nil || len(list) != 4 || !list[0].Settlement || list[0].PayerName != "elena" {
+		t.Errorf("Expenses = %v, %v", list, err)
+	}
+
+	// Пользователь вне команды не видит ее расходы
+	if _, err := f.teams.CreateTeam(root.ID, "Adults"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.teams.SwitchTeam(root.ID, f.user(t, elena.ID).ActiveTeamID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.teams.RemoveMember(root.ID, "elena"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := expenses.SettleUp(elena.ID); errorKey(err) != "error.team_required" {
+		t.Errorf("SettleUp after leaving the team = %v, want error.team_required", err)
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+)
+
+// Параметры кодов приглашения
+const (
+	inviteTTL        = 14 * 24 * time.Hour
+	inviteCodeGroups = 3 // Код вида xxxx-xxxx-xxxx
+)
+
+// newInviteCode генерирует код приглашения
+func newInviteCode() (string, error) {
+	return randomCode(inviteCodeGroups)
+}
+
+// randomCode генерирует случайную строку из групп по четыре символа кодов восстановления
+func randomCode(groups int) (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < groups*4; i++ {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// hashInviteCode возвращает хеш кода приглашения для поиска в хранилище.
+// Код случайный и ограничен по сроку, поэтому медленный хеш не нужен
+func hashInviteCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckInvite проверяет код приглашения и возвращает приглашенного пользователя
+func (s *AuthService) CheckInvite(code string) (*domain.User, error) {
+	invite, err := s.inviteRepo.GetByCodeHash(hashInviteCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if invite == nil {
+		return nil, i18n.NewError("error.invite_invalid")
+	}
+	if invite.Expired(time.Now()) {
+		return nil, i18n.NewError("error.invite_expired")
+	}
+
+	user, err := s.userRepo.GetByID(invite.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.TelegramID != 0 {
+		return nil, i18n.NewError("error.invite_invalid")
+	}
+	if !user.Active() {
+		return nil, i18n.NewError("error.account_" + string(user.Status))
+	}
+	return user, nil
+}
+
+// AcceptInvite задает пароль пользователя по коду приглашения и привязывает его
+// к Telegram-аккаунту. Код приглашения можно использовать только один раз
+func (s *AuthService) AcceptInvite(telegramID int64, chatID int64, code, password string) (*domain.User, error) {
+	user, err := s.CheckInvite(code)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверяем, не привязан ли к этому Telegram-аккаунту другой пользователь
+	boundUser, err := s.userRepo.GetByTelegramID(telegramID)
+	if err != nil {
+		return nil, err
+	}
+	if boundUser != nil {
+		return nil, i18n.NewError("error.telegram_already_bound", i18n.P{"username": boundUser.Username})
+	}
+
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка хеширования пароля: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.BindTelegram(user.ID, telegramID, chatID); err != nil {
+		return nil, err
+	}
+	if err := s.inviteRepo.Delete(user.ID); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:    user.ID,
+		TelegramID: telegramID,
+		TargetID:   user.ID,
+		Action:     domain.AuditInviteAccept,
+	})
+	user.TelegramID = telegramID
+	user.ChatID = chatID
+	return user, nil
+}
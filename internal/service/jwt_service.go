@@ -6,15 +6,17 @@ import (
 	"time"
 
 	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 // JWTClaims представляет собой данные, которые будут храниться в JWT токене
 type JWTClaims struct {
-	ChatID   int64  `json:"chat_id"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	UserID     int64  `json:"user_id"`
+	TelegramID int64  `json:"telegram_id"`
+	Username   string `json:"username"`
+	Role       string `json:"role"`
 	jwt.RegisteredClaims
 }
 
@@ -25,15 +27,16 @@ func (s *AuthService) GenerateToken(user *domain.User) (string, error) {
 
 	// Создаем claims для токена
 	claims := JWTClaims{
-		ChatID:   user.ChatID,
-		Username: user.Username,
-		Role:     user.Role,
+		UserID:     user.ID,
+		TelegramID: user.TelegramID,
+		Username:   user.Username,
+		Role:       user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.JWTExpiration)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "helpbot",
-			Subject:   fmt.Sprintf("%d", user.ChatID),
+			Subject:   fmt.Sprintf("%d", user.ID),
 		},
 	}
 
@@ -76,7 +79,7 @@ func (s *AuthService) ValidateToken(tokenString string) (*domain.User, error) {
 	}
 
 	// Получаем пользователя из базы данных
-	user, err := s.userRepo.GetByID(claims.ChatID)
+	user, err := s.userRepo.GetByID(claims.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -85,10 +88,22 @@ func (s *AuthService) ValidateToken(tokenString string) (*domain.User, error) {
 		return nil, errors.New("user not found")
 	}
 
-	// Проверяем, что имя пользователя и роль совпадают
-	if user.Username != claims.Username || user.Role != claims.Role {
+	// Проверяем, что имя пользователя, роль и привязка совпадают
+	if user.Username != claims.Username || user.Role != claims.Role || user.TelegramID != claims.TelegramID {
 		return nil, errors.New("token data mismatch")
 	}
 
+	// Токены заблокированных пользователей недействительны
+	if !user.Active() {
+		return nil, i18n.NewError("error.account_" + string(user.Status))
+	}
+
+	// Токены, выданные до завершения сессий пользователя, недействительны. Время выдачи
+	// хранится с точностью до секунды, поэтому с ней же сравнивается и время завершения:
+	// иначе токен, полученный сразу после сброса пароля, тоже был бы отклонен
+	if claims.IssuedAt == nil || claims.IssuedAt.Time.Before(user.SessionsRevokedAt.Truncate(time.Second)) {
+		return nil, i18n.NewError("error.session_revoked")
+	}
+
 	return user, nil
 }
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+)
+
+// Ограничения опросов
+const (
+	maxPollQuestionLength = 200
+	maxPollOptionLength   = 100
+	minPollOptions        = 2
+	maxPollOptions        = 10
+)
+
+// PollService реализует интерфейс domain.PollService
+type PollService struct {
+	pollRepo domain.PollRepository
+	teamRepo domain.TeamRepository
+	userRepo domain.UserRepository
+	roles    domain.RoleService
+	audit    domain.AuditService
+}
+
+// NewPollService создает новый экземпляр PollService
+func NewPollService(pollRepo domain.PollRepository, teamRepo domain.TeamRepository, userRepo domain.UserRepository,
+	roles domain.RoleService, audit domain.AuditService) *PollService {
+	return &PollService{
+		pollRepo: pollRepo,
+		teamRepo: teamRepo,
+		userRepo: userRepo,
+		roles:    roles,
+		audit:    audit,
+	}
+}
+
+// CreatePoll создает опрос активной команды и возвращает ее активных участников,
+// которые могут в нем голосовать: всех или только с ролью опроса. Если к команде
+// привязана группа, опрос запоминает ее, чтобы опубликовать там
+func (s *PollService) CreatePoll(actorID int64, poll *domain.Poll) ([]*domain.User, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	poll.Question = strings.TrimSpace(poll.Question)
+	if poll.Question == "" || utf8.RuneCountInString(poll.Question) > maxPollQuestionLength {
+		return nil, i18n.NewError("error.poll_question_invalid", i18n.P{"max": maxPollQuestionLength})
+	}
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return nil, i18n.NewError("error.poll_options_invalid", i18n.P{"min": minPollOptions, "max": maxPollOptions})
+	}
+	seen := make(map[string]bool, len(poll.Options))
+	for _, option := range poll.Options {
+		option.Text = strings.TrimSpace(option.Text)
+		if option.Text == "" || utf8.RuneCountInString(option.Text) > maxPollOptionLength {
+			return nil, i18n.NewError("error.poll_option_invalid", i18n.P{"max": maxPollOptionLength})
+		}
+		key := strings.ToLower(option.Text)
+		if seen[key] {
+			return nil, i18n.NewError("error.poll_option_duplicate", i18n.P{"option": option.Text})
+		}
+		seen[key] = true
+	}
+	if poll.Role != "" {
+		role, err := s.roles.GetRole(poll.Role)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			return nil, i18n.NewError("error.invalid_role")
+		}
+	}
+	if !poll.Deadline.IsZero() && !poll.Deadline.After(time.Now()) {
+		return nil, i18n.NewError("error.poll_deadline_invalid")
+	}
+
+	poll.TeamID = team.ID
+	poll.ChatID = team.GroupChatID
+	poll.CreatedBy = actorID
+	if err := s.pollRepo.Save(poll); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditPollCreate,
+		Details: fmt.Sprintf("team=%d poll=%d question=%s options=%d multiple=%t anonymous=%t role=%s",
+			team.ID, poll.ID, poll.Question, len(poll.Options), poll.Multiple, poll.Anonymous, poll.Role),
+	})
+
+	page, err := s.teamRepo.FindMembers(team.ID, domain.UserQuery{
+		Statuses: []domain.UserStatus{domain.UserActive},
+		Role:     poll.Role,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page.Users, nil
+}
+
+// OpenPolls возвращает незакрытые опросы активной команды в порядке создания
+func (s *PollService) OpenPolls(actorID int64) ([]*domain.Poll, error) {
+	_, team, err := teamMember(s.userRepo, s.teamRepo, s.roles, actorID)
+	if err != nil {
+		return nil, err
+	}
+	return s.pollRepo.GetOpen(team.ID)
+}
+
+// GetPoll возвращает опрос с текущими результатами. Опрос доступен участникам его команды,
+// даже если она не выбрана активной
+func (s *PollService) GetPoll(actorID int64, pollID int64) (*domain.Poll, error) {
+	actor, err := s.userRepo.GetByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+	if actor == nil {
+		return nil, i18n.NewError("error.forbidden")
+	}
+	poll, err := s.results(pollID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := s.teamRepo.GetMember(poll.TeamID, actor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil && !s.roles.Can(actor, domain.PermManageTeams) {
+		return nil, i18n.NewError("error.poll_not_found", i18n.P{"id": pollID})
+	}
+	return poll, nil
+}
+
+// Vote отмечает голос участника команды опроса. Повторный голос за выбранный вариант снимает его,
+// а в опросе с одним вариантом ответа голос за другой вариант заменяет прежний
+func (s *PollService) Vote(actorID int64, pollID int64, position int) (*domain.PollUpdate, bool, error) {
+	actor, err := s.userRepo.GetByID(actorID)
+	if err != nil {
+		return nil, false, err
+	}
+	if actor == nil || !actor.Active() {
+		return nil, false, i18n.NewError("error.forbidden")
+	}
+	poll, err := s.pollRepo.GetByID(pollID)
+	if err != nil {
+		return nil, false, err
+	}
+	if poll == nil {
+		return nil, false, i18n.NewError("error.poll_not_found", i18n.P{"id": pollID})
+	}
+	member, err := s.teamRepo.GetMember(poll.TeamID, actor.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if member == nil {
+		return nil, false, i18n.NewError("error.poll_team_only")
+	}
+	if poll.Role != "" && member.Role != poll.Role {
+		return nil, false, i18n.NewError("error.poll_role_only", i18n.P{"role": poll.Role})
+	}
+	if !poll.Open(time.Now()) {
+		return nil, false, i18n.NewError("error.poll_closed", i18n.P{"question": poll.Question})
+	}
+	if position < 1 || position > len(poll.Options) {
+		return nil, false, i18n.NewError("error.poll_option_unknown")
+	}
+
+	selected, err := s.pollRepo.Vote(&domain.PollVote{PollID: poll.ID, UserID: actor.ID, Position: position}, poll.Multiple)
+	if err != nil {
+		if errors.Is(err, domain.ErrPollNotFound) {
+			return nil, false, i18n.NewError("error.poll_closed", i18n.P{"question": poll.Question})
+		}
+		return nil, false, err
+	}
+	update, err := s.update(poll.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	return update, selected, nil
+}
+
+// AddMessage запоминает отправленное сообщение с опросом
+func (s *PollService) AddMessage(message *domain.PollMessage) error {
+	return s.pollRepo.AddMessage(message)
+}
+
+// ClosePoll досрочно закрывает опрос активной команды и возвращает итоговые результаты
+func (s *PollService) ClosePoll(actorID int64, pollID int64) (*domain.PollUpdate, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageEvents)
+	if err != nil {
+		return nil, err
+	}
+	poll, err := s.pollRepo.GetByID(pollID)
+	if err != nil {
+		return nil, err
+	}
+	if poll == nil || poll.TeamID != team.ID {
+		return nil, i18n.NewError("error.poll_not_found", i18n.P{"id": pollID})
+	}
+	if err := s.pollRepo.Close(poll.ID, time.Now()); err != nil {
+		if errors.Is(err, domain.ErrPollNotFound) {
+			return nil, i18n.NewError("error.poll_closed", i18n.P{"question": poll.Question})
+		}
+		return nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditPollClose,
+		Details: fmt.Sprintf("team=%d poll=%d question=%s voters=%d", team.ID, poll.ID, poll.Question, poll.Voters),
+	})
+	return s.update(poll.ID)
+}
+
+// CloseExpired закрывает опросы, срок которых истек, и возвращает их итоги. Опрос,
+// закрытый одновременно вручную, пропускается, поэтому итоги не публикуются дважды
+func (s *PollService) CloseExpired(now time.Time) ([]*domain.PollUpdate, error) {
+	polls, err := s.pollRepo.GetExpired(now)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []*domain.PollUpdate
+	for _, poll := range polls {
+		if err := s.pollRepo.Close(poll.ID, now); err != nil {
+			if errors.Is(err, domain.ErrPollNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		update, err := s.update(poll.ID)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// update возвращает опрос с результатами вместе с его сообщениями
+func (s *PollService) update(pollID int64) (*domain.PollUpdate, error) {
+	poll, err := s.results(pollID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.pollRepo.GetMessages(poll.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.PollUpdate{Poll: poll, Messages: messages}, nil
+}
+
+// results возвращает опрос с числом голосов, а для неанонимного опроса - и с именами
+// проголосовавших
+func (s *PollService) results(pollID int64) (*domain.Poll, error) {
+	poll, err := s.pollRepo.GetByID(pollID)
+	if err != nil {
+		return nil, err
+	}
+	if poll == nil {
+		return nil, i18n.NewError("error.poll_not_found", i18n.P{"id": pollID})
+	}
+	if poll.Anonymous {
+		return poll, nil
+	}
+
+	votes, err := s.pollRepo.GetVotes(poll.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, vote := range votes {
+		if vote.Position >= 1 && vote.Position <= len(poll.Options) {
+			option := poll.Options[vote.Position-1]
+			option.Voters = append(option.Voters, vote.Username)
+		}
+	}
+	return poll, nil
+}
@@ -0,0 +1,172 @@
+package service_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/service"
+)
+
+// pollOptions возвращает варианты ответа с указанными текстами
+func pollOptions(texts ...string) []*domain.PollOption {
+	var options []*domain.PollOption
+	for _, text := range texts {
+		options = append(options, &domain.PollOption{Text: text})
+	}
+	return options
+}
+
+func TestPollVoting(t *testing.T) {
+	f, root := newTeamFixture(t)
+	f.juniors(t, root)
+	coach := f.coordinator(t, root, "coach")
+	polls := service.NewPollService(f.repos.PollRepository, f.repos.TeamRepository, f.repos.UserRepository, f.roles, f.audit)
+	alice := f.register(t, "alice", domain.RoleUser)
+	bob := f.register(t, "bob", domain.RoleUser)
+	eve := f.register(t, "eve", domain.RoleUser)
+
+	for _, tc := range []struct {
+		name    string
+		actorID int64
+		poll    domain.Poll
+		key     string
+	}{
+		{"without events.manage", alice.ID, domain.Poll{Question: "Where?", Options: pollOptions("Park", "Gym")}, "error.forbidden"},
+		{"empty question", coach.ID, domain.Poll{Question: " ", Options: pollOptions("Park", "Gym")}, "error.poll_question_invalid"},
+		{"one option", coach.ID, domain.Poll{Question: "Where?", Options: pollOptions("Park")}, "error.poll_options_invalid"},
+		{"empty option", coach.ID, domain.Poll{Question: "Where?", Options: pollOptions("Park", " ")}, "error.poll_option_invalid"},
+		{"duplicate option", coach.ID, domain.Poll{Question: "Where?", Options: pollOptions("Park", "park")}, "error.poll_option_duplicate"},
+		{"unknown role", coach.ID, domain.Poll{Question: "Where?", Options: pollOptions("Park", "Gym"), Role: "captain"}, "error.invalid_role"},
+		{"past deadline", coach.ID, domain.Poll{Question: "Where?", Options: pollOptions("Park", "Gym"), Deadline: time.Now().Add(-time.Minute)}, "error.poll_deadline_invalid"},
+	} {
+		if _, err := polls.CreatePoll(tc.actorID, &tc.poll); errorKey(err) != tc.key {
+			t.Errorf("CreatePoll %s = %v, want %s", tc.name, err, tc.key)
+		}
+	}
+
+	// Открытый опрос с одним вариантом ответа для всей команды
+	poll := &domain.Poll{Question: " Where? ", Options: pollOptions("Park", "Gym", "Pool")}
+	voters, err := polls.CreatePoll(coach.ID, poll)
+	if err != nil {
+		t.Fatalf("CreatePoll: %v", err)
+	}
+	if names := usernamesOf(voters); !slices.Contains(names, "alice") || !slices.Contains(names, "coach") {
+		t.Errorf("voters = %v, want the whole team", names)
+	}
+	if poll.ID == 0 || poll.Question != "Where?" || poll.ChatID != 0 {
+		t.Errorf("poll = %+v", poll)
+	}
+
+	vote := func(user *domain.User, position int, want bool) *domain.PollUpdate {
+		t.Helper()
+		update, selected, err := polls.Vote(user.ID, poll.ID, position)
+		if err != nil || selected != want {
+			t.Fatalf("Vote(%s, %d) = %v, %v, want %v", user.Username, position, selected, err, want)
+		}
+		return update
+	}
+	vote(alice, 1, true)
+	vote(alice, 2, true)
+	vote(bob, 2, true)
+	vote(bob, 3, true)
+	update := vote(bob, 3, false)
+	if got := update.Poll; got.Voters != 1 || got.Options[1].Votes != 1 || !slices.Equal(got.Options[1].Voters, []string{"alice"}) || got.Options[0].Votes != 0 {
+		t.Errorf("results = %d voters, %+v", got.Voters, got.Options[1])
+	}
+	if _, _, err := polls.Vote(alice.ID, poll.ID, 4); errorKey(err) != "error.poll_option_unknown" {
+		t.Errorf("Vote for a missing option = %v, want error.poll_option_unknown", err)
+	}
+
+	// Исключенный из команды не голосует и не видит опрос
+	if _, err := f.teams.RemoveMember(root.ID, "eve"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := polls.Vote(eve.ID, poll.ID, 1); errorKey(err) != "error.poll_team_only" {
+		t.Errorf("Vote by an outsider = %v, want error.poll_team_only", err)
+	}
+	if _, err := polls.GetPoll(eve.ID, poll.ID); errorKey(err) != "error.poll_not_found" {
+		t.Errorf("GetPoll by an outsider = %v, want error.poll_not_found", err)
+	}
+
+	// Анонимный опрос с несколькими вариантами только для координаторов публикуется
+	// в привязанной группе команды
+	if _, err := f.teams.BindGroup(root.ID, -100); err != nil {
+		t.Fatal(err)
+	}
+	secret := &domain.Poll{Question: "Captain?", Options: pollOptions("Alice", "Bob"), Multiple: true, Anonymous: true, Role: domain.RoleCoordinator}
+	voters, err = polls.CreatePoll(coach.ID, secret)
+	if err != nil {
+		t.Fatalf("CreatePoll(secret): %v", err)
+	}
+	if secret.ChatID != -100 {
+		t.Errorf("secret ChatID = %d, want the team group", secret.ChatID)
+	}
+	if names := usernamesOf(voters); !slices.Equal(names, []string{"coach"}) {
+		t.Errorf("secret voters = %v, want only the coordinator", names)
+	}
+	if _, _, err := polls.Vote(alice.ID, secret.ID, 1); errorKey(err) != "error.poll_role_only" {
+		t.Errorf("Vote without the role = %v, want error.poll_role_only", err)
+	}
+	for _, position := range []int{1, 2} {
+		if _, selected, err := polls.Vote(coach.ID, secret.ID, position); err != nil || !selected {
+			t.Fatalf("Vote(coach, %d) = %v, %v", position, selected, err)
+		}
+	}
+	got, err := polls.GetPoll(alice.ID, secret.ID)
+	if err != nil || got.Voters != 1 || got.Options[0].Votes != 1 || got.Options[1].Votes != 1 || got.Options[0].Voters != nil {
+		t.Errorf("GetPoll(secret) = %+v, %v, want counts without names", got, err)
+	}
+
+	open, err := polls.OpenPolls(alice.ID)
+	if err != nil || len(open) != 2 {
+		t.Errorf("OpenPolls = %v, %v, want both polls", open, err)
+	}
+
+	// Закрытие возвращает итоги вместе с сообщениями, которые нужно обновить
+	for _, chatID := range []int64{100, 200} {
+		if err := polls.AddMessage(&domain.PollMessage{PollID: poll.ID, ChatID: chatID, MessageID: 1, Language: "en"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := polls.ClosePoll(alice.ID, poll.ID); errorKey(err) != "error.forbidden" {
+		t.Errorf("ClosePoll without events.manage = %v, want error.forbidden", err)
+	}
+	closed, err := polls.ClosePoll(coach.ID, poll.ID)
+	if err != nil || !closed.Poll.Closed || len(closed.Messages) != 2 || closed.Poll.Options[1].Votes != 1 {
+		t.Fatalf("ClosePoll = %+v, %v", closed, err)
+	}
+	if _, err := polls.ClosePoll(coach.ID, poll.ID); errorKey(err) != "error.poll_closed" {
+		t.Errorf("repeated ClosePoll = %v, want error.poll_closed", err)
+	}
+	if _, _, err := polls.Vote(bob.ID, poll.ID, 1); errorKey(err) != "error.poll_closed" {
+		t.Errorf("Vote in a closed poll = %v, want error.poll_closed", err)
+	}
+}
+
+func TestPollCloseExpired(t *testing.T) {
+	f, root := newTeamFixture(t)
+	f.juniors(t, root)
+	coach := f.coordinator(t, root, "coach")
+	polls := service.NewPollService(f.repos.PollRepository, f.repos.TeamRepository, f.repos.UserRepository, f.roles, f.audit)
+	now := time.Now()
+	poll := &domain.Poll{Question: "Where?", Options: pollOptions("Park", "Gym"), Deadline: now.Add(time.Hour)}
+	if _, err := polls.CreatePoll(coach.ID, poll); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := polls.Vote(coach.ID, poll.ID, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if updates, err := polls.CloseExpired(now); err != nil || len(updates) != 0 {
+		t.Errorf("CloseExpired before the deadline = %v, %v, want none", updates, err)
+	}
+	updates, err := polls.CloseExpired(now.Add(2 * time.Hour))
+	if err != nil || len(updates) != 1 || updates[0].Poll.ID != poll.ID || !updates[0].Poll.Closed || updates[0].Poll.Options[1].Votes != 1 {
+		t.Fatalf("CloseExpired after the deadline = %v, %v, want the closed poll", updates, err)
+	}
+	if updates, err := polls.CloseExpired(now.Add(3 * time.Hour)); err != nil || len(updates) != 0 {
+		t.Errorf("repeated CloseExpired = %v, %v, want none", updates, err)
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/logging"
+)
+
+// PurgeService обезличивает пользователей, удаленных дольше срока хранения
+type PurgeService struct {
+	userRepo      domain.UserRepository
+	twoFactorRepo domain.TwoFactorRepository
+	audit         domain.AuditService
+	logger        *slog.Logger
+}
+
+// NewPurgeService создает новый экземпляр PurgeService
+func NewPurgeService(userRepo domain.UserRepository, twoFactorRepo domain.TwoFactorRepository, audit domain.AuditService, logger *slog.Logger) *PurgeService {
+	return &PurgeService{
+		userRepo:      userRepo,
+		twoFactorRepo: twoFactorRepo,
+		audit:         audit,
+		logger:        logger,
+	}
+}
+
+// Purge обезличивает пользователей, удаленных до указанного момента, и удаляет их настройки 2FA.
+// Платежи и записи журнала аудита сохраняются и ссылаются на обезличенную запись
+func (s *PurgeService) Purge(before time.Time) ([]int64, error) {
+	ids, err := s.userRepo.AnonymizeDeleted(before)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if err := s.twoFactorRepo.Delete(id); err != nil {
+			s.logger.Error("Error deleting 2FA of purged user", "user_id", id, logging.Err(err))
+		}
+		s.audit.Record(&domain.AuditEntry{
+			TargetID: id,
+			Action:   domain.AuditAccountPurge,
+		})
+	}
+	return ids, nil
+}
+
+// Run запускает обезличивание сразу и затем с указанным интервалом, пока не отменен контекст.
+// Нулевой срок хранения отключает обезличивание
+func (s *PurgeService) Run(ctx context.Context, retention, interval time.Duration) {
+	if retention <= 0 {
+		s.logger.Info("Purge of deleted users is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ids, err := s.Purge(time.Now().Add(-retention))
+		switch {
+		case err != nil:
+			s.logger.Error("Error purging deleted users", logging.Err(err))
+		case len(ids) > 0:
+			s.logger.Info("Deleted users purged", "count", len(ids))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
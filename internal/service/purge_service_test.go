@@ -0,0 +1,56 @@
+package service_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/service"
+)
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	repos, auth := newAuthService(t)
+	audit := service.NewAuditService(repos.AuditRepository, repos.UserRepository, logging.Discard())
+	purge := service.NewPurgeService(repos.UserRepository, repos.TwoFactorRepository, audit, logging.Discard())
+
+	alice := &domain.User{Username: "alice", Password: "secret"}
+	bob := &domain.User{Username: "bob", Password: "secret"}
+	for _, user := range []*domain.User{alice, bob} {
+		if err := auth.Register(user); err != nil {
+			t.Fatalf("Register %s: %v", user.Username, err)
+		}
+	}
+	if err := repos.TwoFactorRepository.SaveSecret(alice.ID, "SECRET"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.UserRepository.UpdateStatus(alice.ID, domain.UserDeleted, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := purge.Purge(time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int64{alice.ID}) {
+		t.Fatalf("Purge = %v, want [%d]", ids, alice.ID)
+	}
+
+	// Вместе с учетной записью удаляются настройки 2FA, а в журнал попадает запись об обезличивании
+	if tf, err := repos.TwoFactorRepository.Get(alice.ID); err != nil || tf != nil {
+		t.Errorf("2FA after purge = %v, %v", tf, err)
+	}
+	entries, err := audit.Find(domain.AuditFilter{UserID: alice.ID, Action: domain.AuditAccountPurge})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %d purge audit entries, want 1", len(entries))
+	}
+
+	// Повторный запуск ничего не меняет
+	if ids, err := purge.Purge(time.Now().Add(time.Second)); err != nil || len(ids) != 0 {
+		t.Errorf("second Purge = %v, %v", ids, err)
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+)
+
+// roleNamePattern определяет допустимый формат имени роли
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,31}$`)
+
+// RoleService реализует интерфейс domain.RoleService
+type RoleService struct {
+	roleRepo domain.RoleRepository
+	userRepo domain.UserRepository
+	teamRepo domain.TeamRepository
+	audit    domain.AuditService
+}
+
+// NewRoleService создает новый экземпляр RoleService
+func NewRoleService(roleRepo domain.RoleRepository, userRepo domain.UserRepository, teamRepo domain.TeamRepository, audit domain.AuditService) *RoleService {
+	return &RoleService{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+		teamRepo: teamRepo,
+		audit:    audit,
+	}
+}
+
+// Can проверяет, есть ли у пользователя указанное право. Права команды
+// (domain.TeamPermissions) определяются ролью в активной команде, остальные -
+// ролью учетной записи. Администратор бота обладает всеми правами во всех командах
+func (s *RoleService) Can(user *domain.User, permission domain.Permission) bool {
+	// У заблокированных пользователей нет прав, даже если роль их предоставляет
+	if user == nil || user.Role == "" || !user.Active() {
+		return false
+	}
+	if user.Role == domain.RoleAdmin {
+		return true
+	}
+
+	roleName := user.Role
+	if domain.IsTeamPermission(permission) {
+		roleName = s.teamRole(user)
+	}
+	if roleName == "" {
+		return false
+	}
+	if roleName == domain.RoleAdmin {
+		return true
+	}
+
+	role, err := s.roleRepo.GetByName(roleName)
+	if err != nil || role == nil {
+		return false
+	}
+	return role.Has(permission)
+}
+
+// teamRole возвращает роль пользователя в активной команде или пустую строку,
+// если команда не выбрана или пользователь в ней не состоит
+func (s *RoleService) teamRole(user *domain.User) string {
+	if user.ActiveTeamID == 0 {
+		return ""
+	}
+	member, err := s.teamRepo.GetMember(user.ActiveTeamID, user.ID)
+	if err != nil || member == nil {
+		return ""
+	}
+	return member.Role
+}
+
+// RequirePermission проверяет, что у пользователя с указанным идентификатором есть право
+func (s *RoleService) RequirePermission(userID int64, permission domain.Permission) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if !s.Can(user, permission) {
+		return i18n.NewError("error.forbidden")
+	}
+	return nil
+}
+
+// GetRole возвращает роль по ее имени
+func (s *RoleService) GetRole(name string) (*domain.Role, error) {
+	return s.roleRepo.GetByName(name)
+}
+
+// GetRoles возвращает все роли
+func (s *RoleService) GetRoles() ([]*domain.Role, error) {
+	return s.roleRepo.GetAll()
+}
+
+// CreateRole создает новую роль без прав
+func (s *RoleService) CreateRole(actorID int64, name, description string) (*domain.Role, error) {
+	if err := s.RequirePermission(actorID, domain.PermManageRoles); err != nil {
+		return nil, err
+	}
+
+	if !roleNamePattern.MatchString(name) {
+		return nil, i18n.NewError("error.role_name_invalid")
+	}
+
+	existingRole, err := s.roleRepo.GetByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if existingRole != nil {
+		return nil, i18n.NewError("error.role_exists")
+	}
+
+	role := &domain.Role{
+		Name:        name,
+		Description: description,
+	}
+	if err := s.roleRepo.Save(role); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditRoleCreate,
+		Details: "role=" + role.Name,
+	})
+	return role, nil
+}
+
+// TogglePermission добавляет право в роль или убирает его из нее
+func (s *RoleService) TogglePermission(actorID int64, roleName string, permission domain.Permission) (*domain.Role, error) {
+	if err := s.RequirePermission(actorID, domain.PermManageRoles); err != nil {
+		return nil, err
+	}
+
+	if !domain.IsKnownPermission(permission) {
+		return nil, i18n.NewError("error.unknown_permission", i18n.P{"permission": permission})
+	}
+
+	role, err := s.roleRepo.GetByName(roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, i18n.NewError("error.role_not_found")
+	}
+	if role.Name == domain.RoleAdmin {
+		return nil, i18n.NewError("error.admin_role_immutable")
+	}
+
+	// Убираем право, если оно уже есть, иначе добавляем
+	var permissions []domain.Permission
+	found := false
+	for _, p := range role.Permissions {
+		if p == permission {
+			found = true
+			continue
+		}
+		permissions = append(permissions, p)
+	}
+	if !found {
+		permissions = append(permissions, permission)
+	}
+	role.Permissions = permissions
+
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, err
+	}
+
+	change := "+"
+	if found {
+		change = "-"
+	}
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditRoleUpdate,
+		Details: fmt.Sprintf("role=%s permission=%s%s", role.Name, change, permission),
+	})
+	return role, nil
+}
+
+// DeleteRole удаляет пользовательскую роль, если она никому не назначена
+func (s *RoleService) DeleteRole(actorID int64, name string) error {
+	if err := s.RequirePermission(actorID, domain.PermManageRoles); err != nil {
+		return err
+	}
+
+	role, err := s.roleRepo.GetByName(name)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return i18n.NewError("error.role_not_found")
+	}
+	if role.BuiltIn {
+		return i18n.NewError("error.builtin_role")
+	}
+
+	count, err := s.roleRepo.CountUsers(name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return i18n.NewError("error.role_in_use", i18n.P{"count": count})
+	}
+
+	if err := s.roleRepo.Delete(name); err != nil {
+		return err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditRoleDelete,
+		Details: "role=" + name,
+	})
+	return nil
+}
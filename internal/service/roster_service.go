@@ -0,0 +1,481 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+)
+
+// Ограничения импорта состава команды
+const (
+	maxRosterRecords = 500
+	rosterPageSize   = 100 // Размер страницы при выгрузке пользователей
+)
+
+// rosterColumns - столбцы файла состава команды в порядке выгрузки
+var rosterColumns = []string{"username", "position", "birthday", "number", "role"}
+
+// RosterService реализует интерфейс domain.RosterService
+type RosterService struct {
+	userRepo   domain.UserRepository
+	teamRepo   domain.TeamRepository
+	inviteRepo domain.InviteRepository
+	roles      domain.RoleService
+	audit      domain.AuditService
+}
+
+// NewRosterService создает новый экземпляр RosterService
+func NewRosterService(userRepo domain.UserRepository, teamRepo domain.TeamRepository, inviteRepo domain.InviteRepository, roles domain.RoleService, audit domain.AuditService) *RosterService {
+	return &RosterService{
+		userRepo:   userRepo,
+		teamRepo:   teamRepo,
+		inviteRepo: inviteRepo,
+		roles:      roles,
+		audit:      audit,
+	}
+}
+
+// plannedChange связывает строку файла с существующим пользователем
+type plannedChange struct {
+	*domain.RosterChange
+	user   *domain.User       // Существующий пользователь (nil для нового)
+	member *domain.TeamMember // Членство существующего пользователя в команде (nil, если он не в команде)
+}
+
+// Import разбирает файл состава активной команды и возвращает план изменений (требует права users.manage).
+// Роль в файле - роль в команде. Если apply установлен и в файле нет ошибок, изменения применяются
+func (s *RosterService) Import(actorID int64, format domain.RosterFormat, data []byte, apply bool) (*domain.RosterImport, error) {
+	actor, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := parseRoster(format, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, i18n.NewError("error.import_empty")
+	}
+	if len(records) > maxRosterRecords {
+		return nil, i18n.NewError("error.import_too_large", i18n.P{"max": maxRosterRecords})
+	}
+
+	result := &domain.RosterImport{}
+	planned := make([]plannedChange, 0, len(records))
+	seen := make(map[string]bool, len(records))
+	// Назначать роль администратора команды может только ее администратор
+	canAssignAdmin := isTeamAdmin(s.teamRepo, actor, team.ID)
+	// Изменять профили и добавлять в команду пользователей не из команды может только тот,
+	// кому право users.manage выдано ролью учетной записи, а не ролью в команде
+	canManageAll, err := s.managesAllUsers(actor)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range records {
+		user, member, err := s.plan(team, change, seen, canAssignAdmin, canManageAll)
+		if err != nil {
+			return nil, err
+		}
+		result.Changes = append(result.Changes, change)
+		planned = append(planned, plannedChange{RosterChange: change, user: user, member: member})
+	}
+
+	if !apply {
+		return result, nil
+	}
+	if invalid := result.Count(domain.RosterInvalid); invalid > 0 {
+		return result, i18n.NewError("error.import_invalid", i18n.P{"count": invalid})
+	}
+
+	result.ExpiresAt = time.Now().Add(inviteTTL)
+	for _, change := range planned {
+		switch change.Action {
+		case domain.RosterCreate:
+			err = s.create(actorID, team, change.RosterChange, result.ExpiresAt)
+		case domain.RosterUpdate:
+			err = s.update(actorID, team, change)
+		}
+		if err != nil {
+			// Строки применяются по одной, поэтому предыдущие строки уже сохранены. Возвращаем их
+			// вместе с ошибкой, чтобы выданные коды приглашения не потерялись
+			s.recordImport(actorID, team, format, result, fmt.Sprintf(" failed_line=%d", change.Line))
+			return result, fmt.Errorf("line %d: %w", change.Line, err)
+		}
+		change.Done = true
+	}
+	result.Applied = true
+
+	s.recordImport(actorID, team, format, result, "")
+	return result, nil
+}
+
+// recordImport записывает в журнал аудита примененные строки импорта
+func (s *RosterService) recordImport(actorID int64, team *domain.Team, format domain.RosterFormat, result *domain.RosterImport, suffix string) {
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditRosterImport,
+		Details: fmt.Sprintf("team=%d format=%s created=%d updated=%d unchanged=%d", team.ID, format,
+			result.Done(domain.RosterCreate), result.Done(domain.RosterUpdate), result.Count(domain.RosterUnchanged)) + suffix,
+	})
+}
+
+// managesAllUsers проверяет, что право users.manage выдано пользователю ролью учетной записи
+func (s *RosterService) managesAllUsers(actor *domain.User) (bool, error) {
+	if actor.Role == domain.RoleAdmin {
+		return true, nil
+	}
+	role, err := s.roles.GetRole(actor.Role)
+	if err != nil {
+		return false, err
+	}
+	return role != nil && role.Has(domain.PermManageUsers), nil
+}
+
+// plan определяет действие для строки файла и возвращает существующего пользователя и его
+// членство в команде. Ошибки данных записываются в строку, а ошибки хранилища возвращаются.
+// Существующих пользователей не из команды можно включить в файл, только если canManageAll установлен
+func (s *RosterService) plan(team *domain.Team, change *domain.RosterChange, seen map[string]bool, canAssignAdmin, canManageAll bool) (*domain.User, *domain.TeamMember, error) {
+	record := &change.Record
+	invalid := func(err error) (*domain.User, *domain.TeamMember, error) {
+		change.Action = domain.RosterInvalid
+		change.Err = err
+		return nil, nil, nil
+	}
+
+	switch {
+	case record.Username == "":
+		return invalid(i18n.NewError("error.import_username_required"))
+	case strings.ContainsFunc(record.Username, unicode.IsSpace):
+		return invalid(i18n.NewError("error.import_username_invalid", i18n.P{"username": record.Username}))
+	case domain.IsReservedUsername(record.Username):
+		return invalid(i18n.NewError("error.username_reserved"))
+	case seen[record.Username]:
+		return invalid(i18n.NewError("error.import_duplicate", i18n.P{"username": record.Username}))
+	}
+	seen[record.Username] = true
+
+	if record.Role != "" {
+		role, err := s.roles.GetRole(record.Role)
+		if err != nil {
+			return nil, nil, err
+		}
+		if role == nil {
+			return invalid(i18n.NewError("error.invalid_role"))
+		}
+	}
+
+	user, err := s.userRepo.GetByUsername(record.Username)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		if record.Role == domain.RoleAdmin && !canAssignAdmin {
+			return invalid(i18n.NewError("error.import_role_forbidden"))
+		}
+		change.Action = domain.RosterCreate
+		return nil, nil, nil
+	}
+	if user.Status == domain.UserDeleted {
+		return invalid(i18n.NewError("error.import_user_deleted", i18n.P{"username": record.Username}))
+	}
+
+	member, err := s.teamRepo.GetMember(team.ID, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if member == nil && !canManageAll {
+		return invalid(i18n.NewError("error.import_not_member", i18n.P{"username": record.Username}))
+	}
+	teamRole := ""
+	if member != nil {
+		teamRole = member.Role
+	} else {
+		change.Fields = append(change.Fields, "team")
+	}
+
+	for _, field := range []struct {
+		name           string
+		current, value string
+	}{
+		{"position", user.Position, record.Position},
+		{"birthday", user.Birthday, record.Birthday},
+		{"number", user.Number, record.Number},
+		{"role", teamRole, record.Role},
+	} {
+		if field.value != "" && field.value != field.current {
+			change.Fields = append(change.Fields, field.name)
+		}
+	}
+	if slices.Contains(change.Fields, "role") && (record.Role == domain.RoleAdmin || teamRole == domain.RoleAdmin) && !canAssignAdmin {
+		return invalid(i18n.NewError("error.import_role_forbidden"))
+	}
+
+	change.Action = domain.RosterUnchanged
+	if len(change.Fields) > 0 {
+		change.Action = domain.RosterUpdate
+	}
+	return user, member, nil
+}
+
+// create добавляет нового пользователя в команду и выдает ему код приглашения.
+// Роль из файла становится ролью в команде, роль учетной записи - обычная
+func (s *RosterService) create(actorID int64, team *domain.Team, change *domain.RosterChange, expiresAt time.Time) error {
+	record := change.Record
+	user := &domain.User{
+		Username: record.Username,
+		Role:     domain.RoleUser,
+		Position: record.Position,
+		Birthday: record.Birthday,
+		Number:   record.Number,
+	}
+	if err := s.userRepo.Save(user); err != nil {
+		return err
+	}
+	role := record.Role
+	if role == "" {
+		role = domain.RoleUser
+	}
+	if err := s.join(actorID, team, user, role); err != nil {
+		return err
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := s.inviteRepo.Save(&domain.Invite{
+		UserID:    user.ID,
+		CodeHash:  hashInviteCode(code),
+		CreatedBy: actorID,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+	change.Invite = code
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actorID,
+		TargetID: user.ID,
+		Action:   domain.AuditInviteCreate,
+		Details:  "username=" + user.Username + " role=" + role,
+	})
+	return nil
+}
+
+// update изменяет профиль существующего пользователя непустыми полями строки
+// и его членство в команде
+func (s *RosterService) update(actorID int64, team *domain.Team, change plannedChange) error {
+	record := change.Record
+	user := change.user
+	for _, field := range []struct {
+		target *string
+		value  string
+	}{
+		{&user.Position, record.Position},
+		{&user.Birthday, record.Birthday},
+		{&user.Number, record.Number},
+	} {
+		if field.value != "" {
+			*field.target = field.value
+		}
+	}
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	if change.member == nil {
+		role := record.Role
+		if role == "" {
+			role = domain.RoleUser
+		}
+		return s.join(actorID, team, user, role)
+	}
+	if record.Role != "" && record.Role != change.member.Role {
+		if err := s.teamRepo.UpdateMemberRole(team.ID, user.ID, record.Role); err != nil {
+			return err
+		}
+		s.audit.Record(&domain.AuditEntry{
+			ActorID:  actorID,
+			TargetID: user.ID,
+			Action:   domain.AuditTeamRoleChange,
+			Details:  fmt.Sprintf("team=%d role=%s->%s source=import", team.ID, change.member.Role, record.Role),
+		})
+	}
+	return nil
+}
+
+// join добавляет пользователя в команду. Если у него нет активной команды, она становится активной
+func (s *RosterService) join(actorID int64, team *domain.Team, user *domain.User, role string) error {
+	if err := s.teamRepo.AddMember(&domain.TeamMember{TeamID: team.ID, UserID: user.ID, Role: role}); err != nil {
+		return err
+	}
+	if user.ActiveTeamID == 0 {
+		if err := s.userRepo.SetActiveTeam(user.ID, team.ID); err != nil {
+			return err
+		}
+		user.ActiveTeamID = team.ID
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actorID,
+		TargetID: user.ID,
+		Action:   domain.AuditTeamJoin,
+		Details:  fmt.Sprintf("team=%d role=%s source=import", team.ID, role),
+	})
+	return nil
+}
+
+// Export выгружает состав активной команды с ролями в ней (требует права users.view).
+// Удаленные пользователи не выгружаются
+func (s *RosterService) Export(actorID int64, format domain.RosterFormat) ([]byte, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermViewUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []domain.RosterRecord
+	query := domain.UserQuery{
+		Statuses: []domain.UserStatus{domain.UserActive, domain.UserSuspended, domain.UserDeactivated},
+		Sort:     domain.UserSortUsername,
+		Limit:    rosterPageSize,
+	}
+	for {
+		page, err := s.teamRepo.FindMembers(team.ID, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range page.Users {
+			records = append(records, domain.RosterRecord{
+				Username: user.Username,
+				Position: user.Position,
+				Birthday: user.Birthday,
+				Number:   user.Number,
+				Role:     user.TeamRole,
+			})
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	switch format {
+	case domain.RosterCSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		if err := w.Write(rosterColumns); err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			if err := w.Write([]string{record.Username, record.Position, record.Birthday, record.Number, record.Role}); err != nil {
+				return nil, err
+			}
+		}
+		w.Flush()
+		return buf.Bytes(), w.Error()
+	case domain.RosterJSON:
+		if records == nil {
+			records = []domain.RosterRecord{}
+		}
+		return json.MarshalIndent(records, "", "  ")
+	default:
+		return nil, i18n.NewError("error.import_format")
+	}
+}
+
+// parseRoster разбирает файл состава команды в строки импорта
+func parseRoster(format domain.RosterFormat, data []byte) ([]*domain.RosterChange, error) {
+	// Excel добавляет в начало UTF-8 файла метку порядка байтов
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var changes []*domain.RosterChange
+	switch format {
+	case domain.RosterCSV:
+		r := csv.NewReader(bytes.NewReader(data))
+		// В русской локали Excel разделяет столбцы точкой с запятой
+		if header, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+			r.Comma = ';'
+		}
+		r.TrimLeadingSpace = true
+
+		header, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, i18n.NewError("error.import_malformed", i18n.P{"error": err.Error()})
+		}
+		columns := make([]string, len(header))
+		for i, name := range header {
+			columns[i] = strings.ToLower(strings.TrimSpace(name))
+			if !slices.Contains(rosterColumns, columns[i]) {
+				return nil, i18n.NewError("error.import_unknown_column", i18n.P{"column": name})
+			}
+		}
+		if !slices.Contains(columns, "username") {
+			return nil, i18n.NewError("error.import_no_username")
+		}
+
+		for {
+			row, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, i18n.NewError("error.import_malformed", i18n.P{"error": err.Error()})
+			}
+			line, _ := r.FieldPos(0)
+			change := &domain.RosterChange{Line: line}
+			for i, value := range row {
+				value = strings.TrimSpace(value)
+				switch columns[i] {
+				case "username":
+					change.Record.Username = value
+				case "position":
+					change.Record.Position = value
+				case "birthday":
+					change.Record.Birthday = value
+				case "number":
+					change.Record.Number = value
+				case "role":
+					change.Record.Role = strings.ToLower(value)
+				}
+			}
+			changes = append(changes, change)
+		}
+
+	case domain.RosterJSON:
+		var records []domain.RosterRecord
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&records); err != nil {
+			return nil, i18n.NewError("error.import_malformed", i18n.P{"error": err.Error()})
+		}
+		for i, record := range records {
+			changes = append(changes, &domain.RosterChange{Line: i + 1, Record: domain.RosterRecord{
+				Username: strings.TrimSpace(record.Username),
+				Position: strings.TrimSpace(record.Position),
+				Birthday: strings.TrimSpace(record.Birthday),
+				Number:   strings.TrimSpace(record.Number),
+				Role:     strings.ToLower(strings.TrimSpace(record.Role)),
+			}})
+		}
+
+	default:
+		return nil, i18n.NewError("error.import_format")
+	}
+	return changes, nil
+}
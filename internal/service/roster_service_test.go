@@ -0,0 +1,227 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/service"
+)
+
+func TestRosterImport(t *testing.T) {
+	repos, auth := newAuthService(t)
+	audit := service.NewAuditService(repos.AuditRepository, repos.UserRepository, logging.Discard())
+	roles := service.NewRoleService(repos.RoleRepository, repos.UserRepository, repos.TeamRepository, audit)
+	roster := service.NewRosterService(repos.UserRepository, repos.TeamRepository, repos.InviteRepository, roles, audit)
+	teams := service.NewTeamService(repos.TeamRepository, repos.UserRepository, roles, audit)
+
+	admin := &domain.User{Username: "admin", Password: "secret", Role: domain.RoleAdmin}
+	if err := auth.Register(admin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := roster.Export(admin.ID, domain.RosterCSV); errorKey(err) != "error.team_required" {
+		t.Errorf("Export without an active team = %v, want error.team_required", err)
+	}
+	if _, err := teams.CreateTeam(admin.ID, "Main"); err != nil {
+		t.Fatal(err)
+	}
+	// Единственная команда пополняется при регистрации
+	alice := &domain.User{Username: "alice", Password: "secret", Position: "forward"}
+	if err := auth.Register(alice); err != nil {
+		t.Fatal(err)
+	}
+
+	file := []byte("\xef\xbb\xbfUsername;Position;Number\nalice;goalkeeper;1\nbob;defender;7\nadmin;;\n")
+	if _, err := roster.Import(alice.ID, domain.RosterCSV, file, false); errorKey(err) != "error.forbidden" {
+		t.Errorf("Import without permission = %v", err)
+	}
+
+	// Предварительный просмотр ничего не изменяет
+	plan, err := roster.Import(admin.ID, domain.RosterCSV, file, false)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	want := []domain.RosterAction{domain.RosterUpdate, domain.RosterCreate, domain.RosterUnchanged}
+	for i, change := range plan.Changes {
+		if change.Action != want[i] {
+			t.Errorf("line %d: action %q, want %q (%v)", change.Line, change.Action, want[i], change.Err)
+		}
+	}
+	if plan.Applied {
+		t.Error("dry run is marked as applied")
+	}
+	if user, err := repos.UserRepository.GetByUsername("bob"); err != nil || user != nil {
+		t.Fatalf("dry run created bob: %v, %v", user, err)
+	}
+
+	// Строки с ошибками не позволяют применить импорт
+	broken := []byte(`[{"username": "carol", "role": "nobody"}, {"username": "carol"}]`)
+	plan, err = roster.Import(admin.ID, domain.RosterJSON, broken, true)
+	if errorKey(err) != "error.import_invalid" || plan.Count(domain.RosterInvalid) != 2 {
+		t.Errorf("Import of invalid file = %v", err)
+	}
+
+	result, err := roster.Import(admin.ID, domain.RosterCSV, file, true)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	updated, err := repos.UserRepository.GetByID(alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Position != "goalkeeper" || updated.Number != "1" {
+		t.Errorf("alice after import = %q %q", updated.Position, updated.Number)
+	}
+
+	// Новый пользователь принимает приглашение, задает пароль и входит в систему
+	code := result.Changes[1].Invite
+	if code == "" {
+		t.Fatal("no invite code for bob")
+	}
+	if _, err := auth.CheckInvite("wrong-code"); errorKey(err) != "error.invite_invalid" {
+		t.Errorf("CheckInvite of unknown code = %v", err)
+	}
+	if _, err := auth.AcceptInvite(20, 20, code, "bobpass"); err != nil {
+		t.Fatalf("AcceptInvite: %v", err)
+	}
+	if _, err := auth.CheckInvite(code); errorKey(err) != "error.invite_invalid" {
+		t.Errorf("invite is reusable: %v", err)
+	}
+	if _, err := auth.Login(20, 20, "bob", "bobpass"); err != nil {
+		t.Errorf("Login after invite: %v", err)
+	}
+
+	// Выгрузка повторно импортируется без изменений
+	for _, format := range []domain.RosterFormat{domain.RosterCSV, domain.RosterJSON} {
+		data, err := roster.Export(admin.ID, format)
+		if err != nil {
+			t.Fatalf("Export %s: %v", format, err)
+		}
+		if format == domain.RosterJSON {
+			var records []domain.RosterRecord
+			if err := json.Unmarshal(data, &records); err != nil || len(records) != 3 {
+				t.Errorf("exported JSON = %s (%v)", data, err)
+			}
+		} else if !bytes.HasPrefix(data, []byte("username,position,birthday,number,role\n")) {
+			t.Errorf("exported CSV = %s", data)
+		}
+		plan, err := roster.Import(admin.ID, format, data, false)
+		if err != nil {
+			t.Fatalf("re-import %s: %v", format, err)
+		}
+		if plan.Count(domain.RosterUnchanged) != len(plan.Changes) {
+			t.Errorf("re-import of %s export is not a no-op", format)
+		}
+	}
+}
+
+// failingUsers отказывает в сохранении указанного пользователя
+type failingUsers struct {
+	domain.UserRepository
+	username string
+}
+
+func (r *failingUsers) Save(user *domain.User) error {
+	if user.Username == r.username {
+		return errors.New("disk is full")
+	}
+	return r.UserRepository.Save(user)
+}
+
+func TestRosterImportPartialFailure(t *testing.T) {
+	f, root := newTeamFixture(t)
+	if _, err := f.teams.CreateTeam(root.ID, "Main"); err != nil {
+		t.Fatal(err)
+	}
+	users := &failingUsers{UserRepository: f.repos.UserRepository, username: "carol"}
+	roster := service.NewRosterService(users, f.repos.TeamRepository, f.repos.InviteRepository, f.roles, f.audit)
+
+	result, err := roster.Import(root.ID, domain.RosterCSV, []byte("username,number\nbob,7\ncarol,8\n"), true)
+	if err == nil {
+		t.Fatal("Import succeeded although the second row failed")
+	}
+	if result == nil || result.Applied {
+		t.Fatalf("Import result = %+v, want a partial result", result)
+	}
+	bob, carol := result.Changes[0], result.Changes[1]
+	if !bob.Done || bob.Invite == "" || carol.Done || carol.Invite != "" {
+		t.Errorf("changes = %+v, %+v, want only bob applied with an invite", bob, carol)
+	}
+	if result.Done(domain.RosterCreate) != 1 {
+		t.Errorf("Done(create) = %d, want 1", result.Done(domain.RosterCreate))
+	}
+	// Выданный код приглашения действителен
+	if _, err := f.auth.CheckInvite(bob.Invite); err != nil {
+		t.Errorf("CheckInvite of the applied row = %v", err)
+	}
+	if user, err := f.repos.UserRepository.GetByUsername("carol"); err != nil || user != nil {
+		t.Errorf("carol = %v, %v, want not created", user, err)
+	}
+}
+
+func TestRosterImportOutsideTeam(t *testing.T) {
+	f, root := newTeamFixture(t)
+	juniors, err := f.teams.CreateTeam(root.ID, "Juniors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	adults, err := f.teams.CreateTeam(root.ID, "Adults")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := f.register(t, "alice", domain.RoleUser)
+	f.register(t, "bob", domain.RoleUser)
+	carol := f.register(t, "carol", domain.RoleUser)
+	roster := service.NewRosterService(f.repos.UserRepository, f.repos.TeamRepository, f.repos.InviteRepository, f.roles, f.audit)
+
+	// alice администрирует Adults, а carol состоит только в Juniors
+	if _, err := f.teams.AddMember(root.ID, "alice", domain.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.teams.AddMember(root.ID, "bob", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.teams.SwitchTeam(root.ID, juniors.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.teams.AddMember(root.ID, "carol", ""); err != nil {
+		t.Fatal(err)
+	}
+	carol = f.user(t, carol.ID)
+
+	// Администратор команды не может изменить профиль пользователя не из своей команды
+	file := []byte("username,position\nbob,goalkeeper\ncarol,defender\n")
+	plan, err := roster.Import(alice.ID, domain.RosterCSV, file, true)
+	if errorKey(err) != "error.import_invalid" {
+		t.Fatalf("Import by a team admin = %v, want error.import_invalid", err)
+	}
+	if plan.Changes[0].Action != domain.RosterUpdate || plan.Changes[1].Action != domain.RosterInvalid ||
+		errorKey(plan.Changes[1].Err) != "error.import_not_member" {
+		t.Errorf("changes = %+v, %+v, want bob updated and carol rejected", plan.Changes[0], plan.Changes[1])
+	}
+	if stored := f.user(t, carol.ID); stored.Position != "" || stored.ActiveTeamID != juniors.ID {
+		t.Errorf("carol after a rejected import = %+v", stored)
+	}
+
+	// Администратор бота добавляет существующего пользователя в команду
+	if _, err := f.teams.SwitchTeam(root.ID, adults.ID); err != nil {
+		t.Fatal(err)
+	}
+	result, err := roster.Import(root.ID, domain.RosterCSV, file, true)
+	if err != nil {
+		t.Fatalf("Import by the bot admin: %v", err)
+	}
+	if result.Changes[1].Action != domain.RosterUpdate || !slices.Contains(result.Changes[1].Fields, "team") {
+		t.Errorf("carol change = %+v, want an update with the team", result.Changes[1])
+	}
+	if stored := f.user(t, carol.ID); stored.Position != "defender" {
+		t.Errorf("carol position = %q, want defender", stored.Position)
+	}
+	if member, err := f.repos.TeamRepository.GetMember(adults.ID, carol.ID); err != nil || member == nil {
+		t.Errorf("carol in Adults = %v, %v", member, err)
+	}
+}
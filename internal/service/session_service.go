@@ -1,40 +1,51 @@
 package service
 
 import (
+	"errors"
 	"sync"
+	"time"
 
 	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
 )
 
 // SessionService реализует интерфейс domain.SessionService
 type SessionService struct {
 	userService domain.UserService
 	authService *AuthService
+	twoFactor   domain.TwoFactorService
 	sessions    map[int64]*domain.UserSession
 	mu          sync.RWMutex
 }
 
+// maxTwoFactorAttempts определяет количество попыток ввода кода 2FA до сброса входа
+const maxTwoFactorAttempts = 5
+
 // NewSessionService создает новый экземпляр SessionService
-func NewSessionService(userService domain.UserService, authService *AuthService) *SessionService {
+func NewSessionService(userService domain.UserService, authService *AuthService, twoFactor domain.TwoFactorService) *SessionService {
 	return &SessionService{
 		userService: userService,
 		authService: authService,
+		twoFactor:   twoFactor,
 		sessions:    make(map[int64]*domain.UserSession),
 	}
 }
 
-// GetSession возвращает текущую сессию пользователя
-func (s *SessionService) GetSession(chatID int64) (*domain.UserSession, error) {
+// GetSession возвращает текущую сессию пользователя по его Telegram ID
+func (s *SessionService) GetSession(telegramID int64) (*domain.UserSession, error) {
 	s.mu.RLock()
-	session, exists := s.sessions[chatID]
+	session, exists := s.sessions[telegramID]
 	s.mu.RUnlock()
 
+	if exists && session.IsAuthorized {
+		return s.refresh(telegramID, session)
+	}
 	if exists {
 		return session, nil
 	}
 
 	// Если сессия не найдена, пытаемся получить пользователя из БД
-	user, err := s.userService.GetUser(chatID)
+	user, err := s.userService.GetUserByTelegramID(telegramID)
 	if err != nil {
 		return nil, err
 	}
@@ -49,42 +60,173 @@ func (s *SessionService) GetSession(chatID int64) (*domain.UserSession, error) {
 		User:         user,
 		State:        domain.StateNone,
 		IsAuthorized: false, // По умолчанию пользователь не авторизован
+		Language:     user.Language,
 	}
 
 	// Сохраняем сессию
 	s.mu.Lock()
-	s.sessions[chatID] = session
+	s.sessions[telegramID] = session
 	s.mu.Unlock()
 
 	return session, nil
 }
 
+// refresh перечитывает пользователя авторизованной сессии из хранилища, чтобы изменения,
+// сделанные в обход бота (например, через helpbotctl), применялись сразу. Если сессии
+// пользователя завершены или аккаунт перенесен, сессия сбрасывается
+func (s *SessionService) refresh(telegramID int64, session *domain.UserSession) (*domain.UserSession, error) {
+	user, err := s.userService.GetUser(session.User.ID)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil && user.TelegramID == telegramID && !session.AuthorizedAt.Before(user.SessionsRevokedAt) {
+		session.User = user
+		return session, nil
+	}
+
+	if err := s.DeleteSession(telegramID); err != nil {
+		return nil, err
+	}
+	return s.GetSession(telegramID)
+}
+
 // UpdateSession обновляет сессию пользователя
-func (s *SessionService) UpdateSession(chatID int64, session *domain.UserSession) error {
+func (s *SessionService) UpdateSession(telegramID int64, session *domain.UserSession) error {
 	s.mu.Lock()
-	s.sessions[chatID] = session
+	s.sessions[telegramID] = session
 	s.mu.Unlock()
 
 	return nil
 }
 
 // DeleteSession удаляет сессию пользователя
-func (s *SessionService) DeleteSession(chatID int64) error {
+func (s *SessionService) DeleteSession(telegramID int64) error {
 	s.mu.Lock()
-	delete(s.sessions, chatID)
+	delete(s.sessions, telegramID)
 	s.mu.Unlock()
 
 	return nil
 }
 
 // Login авторизует пользователя и обновляет его сессию
-func (s *SessionService) Login(chatID int64, username, password string) error {
-	// Авторизуем пользователя
-	user, err := s.authService.Login(username, password)
+func (s *SessionService) Login(telegramID int64, chatID int64, username, password string) error {
+	// Проверяем пароль. Вход завершается только после проверки второго фактора, если он нужен
+	user, err := s.authService.CheckPassword(telegramID, chatID, username, password)
+	if err != nil {
+		return err
+	}
+
+	// Проверяем, нужен ли второй фактор
+	enabled, err := s.twoFactor.IsEnabled(user.ID)
+	if err != nil {
+		return err
+	}
+	if enabled || s.twoFactor.IsRequired(user) {
+		session := &domain.UserSession{
+			User:     user,
+			State:    domain.StateAwaitingTOTP,
+			Language: s.sessionLanguage(telegramID, user),
+			ChatID:   chatID,
+		}
+		result := domain.ErrTwoFactorRequired
+		if !enabled {
+			session.State = domain.StateAwaitingTOTPSetup
+			result = domain.ErrTwoFactorEnrollmentRequired
+		}
+		if err := s.UpdateSession(telegramID, session); err != nil {
+			return err
+		}
+		s.authService.RecordTwoFactorPending(user, telegramID)
+		return result
+	}
+
+	if err := s.authService.CompleteLogin(user, telegramID, chatID); err != nil {
+		return err
+	}
+	return s.authorize(telegramID, user)
+}
+
+// CompleteTwoFactor завершает вход кодом 2FA. Если пользователь подключал 2FA при входе,
+// возвращает новые коды восстановления
+func (s *SessionService) CompleteTwoFactor(telegramID int64, code string) ([]string, error) {
+	session, err := s.GetSession(telegramID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.User == nil || session.IsAuthorized {
+		return nil, i18n.NewError("error.no_pending_2fa")
+	}
+
+	var recoveryCodes []string
+	switch session.State {
+	case domain.StateAwaitingTOTP:
+		err = s.twoFactor.Verify(session.User.ID, code)
+	case domain.StateAwaitingTOTPSetup:
+		recoveryCodes, err = s.twoFactor.ConfirmEnrollment(session.User.ID, code)
+	default:
+		return nil, i18n.NewError("error.no_pending_2fa")
+	}
+
+	if err != nil {
+		session.Attempts++
+		if session.Attempts >= maxTwoFactorAttempts {
+			// Слишком много попыток: начинаем вход заново с пароля
+			session.State = domain.StateNone
+			session.LastCommand = ""
+			session.Attempts = 0
+			if updateErr := s.UpdateSession(telegramID, session); updateErr != nil {
+				return nil, updateErr
+			}
+			return nil, i18n.NewError("error.2fa_too_many_attempts")
+		}
+		return nil, err
+	}
+
+	if err := s.authService.CompleteLogin(session.User, telegramID, session.ChatID); err != nil {
+		return nil, err
+	}
+	if err := s.authorize(telegramID, session.User); err != nil {
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+// DisableTwoFactor отключает 2FA пользователя кодом из приложения или кодом восстановления.
+// Неудачные попытки считаются так же, как при входе: после maxTwoFactorAttempts ошибок
+// сессия завершается, и нужно войти заново с паролем
+func (s *SessionService) DisableTwoFactor(telegramID int64, code string) error {
+	session, err := s.GetSession(telegramID)
 	if err != nil {
 		return err
 	}
+	if session == nil || session.User == nil || !session.IsAuthorized {
+		return i18n.NewError("error.forbidden")
+	}
+
+	if err := s.twoFactor.Disable(session.User, code); err != nil {
+		if !errors.Is(err, domain.ErrTwoFactorWrongCode) && !errors.Is(err, domain.ErrTwoFactorCodeUsed) {
+			return err
+		}
+		session.Attempts++
+		if session.Attempts >= maxTwoFactorAttempts {
+			// Слишком много попыток: завершаем сессию, как при входе
+			if deleteErr := s.DeleteSession(telegramID); deleteErr != nil {
+				return deleteErr
+			}
+			return i18n.NewError("error.2fa_too_many_attempts")
+		}
+		if updateErr := s.UpdateSession(telegramID, session); updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+
+	session.Attempts = 0
+	return s.UpdateSession(telegramID, session)
+}
 
+// authorize выдает пользователю токен и отмечает его сессию авторизованной
+func (s *SessionService) authorize(telegramID int64, user *domain.User) error {
 	// Генерируем JWT токен
 	token, err := s.authService.GenerateToken(user)
 	if err != nil {
@@ -92,7 +234,7 @@ func (s *SessionService) Login(chatID int64, username, password string) error {
 	}
 
 	// Получаем текущую сессию
-	session, err := s.GetSession(chatID)
+	session, err := s.GetSession(telegramID)
 	if err != nil {
 		return err
 	}
@@ -111,15 +253,36 @@ func (s *SessionService) Login(chatID int64, username, password string) error {
 		session.State = domain.StateNone
 		session.IsAuthorized = true
 		session.Token = token
+		session.Attempts = 0
+	}
+	session.AuthorizedAt = time.Now()
+
+	if user.Language != "" {
+		// Сохраненный выбор пользователя важнее языка, выбранного до входа
+		session.Language = user.Language
 	}
 
 	// Сохраняем сессию
-	return s.UpdateSession(chatID, session)
+	return s.UpdateSession(telegramID, session)
+}
+
+// sessionLanguage возвращает язык интерфейса для новой сессии пользователя:
+// сохраненный в профиле или выбранный в текущей сессии до входа
+func (s *SessionService) sessionLanguage(telegramID int64, user *domain.User) string {
+	if user.Language != "" {
+		return user.Language
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if session, ok := s.sessions[telegramID]; ok {
+		return session.Language
+	}
+	return ""
 }
 
 // Logout выходит из системы и удаляет сессию пользователя
-func (s *SessionService) Logout(chatID int64) error {
-	return s.DeleteSession(chatID)
+func (s *SessionService) Logout(telegramID int64) error {
+	return s.DeleteSession(telegramID)
 }
 
 // Register регистрирует нового пользователя
@@ -127,9 +290,27 @@ func (s *SessionService) Register(user *domain.User) error {
 	return s.authService.Register(user)
 }
 
+// CheckInvite проверяет код приглашения и возвращает приглашенного пользователя
+func (s *SessionService) CheckInvite(code string) (*domain.User, error) {
+	return s.authService.CheckInvite(code)
+}
+
+// AcceptInvite принимает приглашение. Сессия создается заново из сохраненного
+// пользователя, после чего он входит в систему с новым паролем
+func (s *SessionService) AcceptInvite(telegramID int64, chatID int64, code, password string) (*domain.User, error) {
+	user, err := s.authService.AcceptInvite(telegramID, chatID, code, password)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.DeleteSession(telegramID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 // IsAdmin проверяет, является ли пользователь администратором
-func (s *SessionService) IsAdmin(chatID int64) (bool, error) {
-	session, err := s.GetSession(chatID)
+func (s *SessionService) IsAdmin(telegramID int64) (bool, error) {
+	session, err := s.GetSession(telegramID)
 	if err != nil {
 		return false, err
 	}
@@ -139,16 +320,56 @@ func (s *SessionService) IsAdmin(chatID int64) (bool, error) {
 	return session.User.Role == domain.RoleAdmin, nil
 }
 
+// ChangeRole изменяет роль пользователя и обновляет его активную сессию
+func (s *SessionService) ChangeRole(actorID int64, targetUsername string, newRole string) error {
+	if err := s.authService.ChangeRole(actorID, targetUsername, newRole); err != nil {
+		return err
+	}
+
+	// Обновляем роль в сессии пользователя, если он сейчас в системе
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, session := range s.sessions {
+		if session.IsAuthorized && session.User != nil && session.User.Username == targetUsername {
+			session.User.Role = newRole
+		}
+	}
+	return nil
+}
+
+// ChangeStatus изменяет состояние учетной записи пользователя и завершает его сессии:
+// при следующем обращении сессия создается заново из актуальных данных
+func (s *SessionService) ChangeStatus(actorID int64, targetUsername string, status domain.UserStatus, reason string) (*domain.User, error) {
+	user, err := s.authService.ChangeStatus(actorID, targetUsername, status, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for telegramID, session := range s.sessions {
+		if session.User != nil && session.User.ID == user.ID {
+			delete(s.sessions, telegramID)
+		}
+	}
+	return user, nil
+}
+
 // ValidateToken проверяет валидность JWT токена и обновляет сессию
-func (s *SessionService) ValidateToken(chatID int64, tokenString string) error {
+func (s *SessionService) ValidateToken(telegramID int64, tokenString string) error {
 	// Проверяем токен
 	user, err := s.authService.ValidateToken(tokenString)
 	if err != nil {
 		return err
 	}
 
+	// Токен действителен только для Telegram-аккаунта, к которому привязан пользователь
+	if user.TelegramID != telegramID {
+		return i18n.NewError("error.token_other_account")
+	}
+
 	// Получаем текущую сессию
-	session, err := s.GetSession(chatID)
+	session, err := s.GetSession(telegramID)
 	if err != nil {
 		return err
 	}
@@ -168,7 +389,8 @@ func (s *SessionService) ValidateToken(chatID int64, tokenString string) error {
 		session.IsAuthorized = true
 		session.Token = tokenString
 	}
+	session.AuthorizedAt = time.Now()
 
 	// Сохраняем сессию
-	return s.UpdateSession(chatID, session)
+	return s.UpdateSession(telegramID, session)
 }
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+)
+
+// maxTeamNameLength ограничивает длину названия команды
+const maxTeamNameLength = 64
+
+// TeamService реализует интерфейс domain.TeamService
+type TeamService struct {
+	teamRepo domain.TeamRepository
+	userRepo domain.UserRepository
+	roles    domain.RoleService
+	audit    domain.AuditService
+}
+
+// NewTeamService создает новый экземпляр TeamService
+func NewTeamService(teamRepo domain.TeamRepository, userRepo domain.UserRepository, roles domain.RoleService, audit domain.AuditService) *TeamService {
+	return &TeamService{
+		teamRepo: teamRepo,
+		userRepo: userRepo,
+		roles:    roles,
+		audit:    audit,
+	}
+}
+
+// Teams возвращает команды пользователя, а пользователям с правом teams.manage - все команды
+func (s *TeamService) Teams(userID int64) ([]*domain.TeamMember, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, i18n.NewError("error.user_not_found")
+	}
+
+	memberships, err := s.teamRepo.GetMemberships(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.roles.Can(user, domain.PermManageTeams) {
+		return memberships, nil
+	}
+
+	teams, err := s.teamRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[int64]string, len(memberships))
+	for _, member := range memberships {
+		roles[member.TeamID] = member.Role
+	}
+	result := make([]*domain.TeamMember, 0, len(teams))
+	for _, team := range teams {
+		result = append(result, &domain.TeamMember{
+			TeamID:   team.ID,
+			TeamName: team.Name,
+			UserID:   userID,
+			Role:     roles[team.ID],
+		})
+	}
+	return result, nil
+}
+
+// ActiveTeam возвращает активную команду пользователя или nil, если она не выбрана
+func (s *TeamService) ActiveTeam(user *domain.User) (*domain.Team, error) {
+	if user == nil || user.ActiveTeamID == 0 {
+		return nil, nil
+	}
+	return s.teamRepo.GetByID(user.ActiveTeamID)
+}
+
+// SwitchTeam делает команду активной. Выбрать можно команду, в которой пользователь
+// состоит, а с правом teams.manage - любую
+func (s *TeamService) SwitchTeam(userID int64, teamID int64) (*domain.Team, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, i18n.NewError("error.user_not_found")
+	}
+	team, err := s.teamRepo.GetByID(teamID)
+	if err != nil {
+		return nil, err
+	}
+	if team == nil {
+		return nil, i18n.NewError("error.team_not_found")
+	}
+
+	member, err := s.teamRepo.GetMember(teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil && !s.roles.Can(user, domain.PermManageTeams) {
+		return nil, i18n.NewError("error.team_not_member", i18n.P{"team": team.Name})
+	}
+
+	if err := s.userRepo.SetActiveTeam(userID, teamID); err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// CreateTeam создает команду (требует права teams.manage). Создатель становится
+// ее администратором, и команда становится для него активной
+func (s *TeamService) CreateTeam(actorID int64, name string) (*domain.Team, error) {
+	if err := s.roles.RequirePermission(actorID, domain.PermManageTeams); err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxTeamNameLength {
+		return nil, i18n.NewError("error.team_name_invalid", i18n.P{"max": maxTeamNameLength})
+	}
+
+	team := &domain.Team{Name: name}
+	if err := s.teamRepo.Save(team); err != nil {
+		if errors.Is(err, domain.ErrTeamExists) {
+			return nil, i18n.NewError("error.team_exists", i18n.P{"team": name})
+		}
+		return nil, err
+	}
+	if err := s.teamRepo.AddMember(&domain.TeamMember{TeamID: team.ID, UserID: actorID, Role: domain.RoleAdmin}); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetActiveTeam(actorID, team.ID); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditTeamCreate,
+		Details: fmt.Sprintf("team=%d name=%s", team.ID, team.Name),
+	})
+	return team, nil
+}
+
+// AddMember добавляет пользователя в активную команду (требует права users.manage).
+// Если у пользователя нет активной команды, она становится активной
+func (s *TeamService) AddMember(actorID int64, username, role string) (*domain.User, error) {
+	actor, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageUsers)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		role = domain.RoleUser
+	}
+	if err := s.checkRole(actor, team, role); err != nil {
+		return nil, err
+	}
+
+	target, err := s.target(username)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.teamRepo.AddMember(&domain.TeamMember{TeamID: team.ID, UserID: target.ID, Role: role}); err != nil {
+		if errors.Is(err, domain.ErrMemberExists) {
+			return nil, i18n.NewError("error.member_exists", i18n.P{"username": target.Username, "team": team.Name})
+		}
+		return nil, err
+	}
+	if target.ActiveTeamID == 0 {
+		if err := s.userRepo.SetActiveTeam(target.ID, team.ID); err != nil {
+			return nil, err
+		}
+		target.ActiveTeamID = team.ID
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actorID,
+		TargetID: target.ID,
+		Action:   domain.AuditTeamJoin,
+		Details:  fmt.Sprintf("team=%d role=%s", team.ID, role),
+	})
+	target.TeamRole = role
+	return target, nil
+}
+
+// RemoveMember исключает пользователя из активной команды (требует права users.manage).
+// Если команда была для него активной, активной становится другая его команда
+func (s *TeamService) RemoveMember(actorID int64, username string) (*domain.User, error) {
+	actor, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageUsers)
+	if err != nil {
+		return nil, err
+	}
+	target, member, err := s.member(team, username)
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == actorID {
+		return nil, i18n.NewError("error.team_self")
+	}
+	// Исключить администратора команды может только администратор
+	if member.Role == domain.RoleAdmin && !isTeamAdmin(s.teamRepo, actor, team.ID) {
+		return nil, i18n.NewError("error.team_admin_required")
+	}
+
+	if err := s.teamRepo.RemoveMember(team.ID, target.ID); err != nil {
+		return nil, err
+	}
+	if target.ActiveTeamID == team.ID {
+		memberships, err := s.teamRepo.GetMemberships(target.ID)
+		if err != nil {
+			return nil, err
+		}
+		target.ActiveTeamID = 0
+		if len(memberships) > 0 {
+			target.ActiveTeamID = memberships[0].TeamID
+		}
+		if err := s.userRepo.SetActiveTeam(target.ID, target.ActiveTeamID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actorID,
+		TargetID: target.ID,
+		Action:   domain.AuditTeamLeave,
+		Details:  fmt.Sprintf("team=%d", team.ID),
+	})
+	return target, nil
+}
+
+// SetMemberRole изменяет роль пользователя в активной команде (требует права users.manage)
+func (s *TeamService) SetMemberRole(actorID int64, username, role string) (*domain.User, error) {
+	actor, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageUsers)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRole(actor, team, role); err != nil {
+		return nil, err
+	}
+	target, member, err := s.member(team, username)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role == role {
+		return nil, i18n.NewError("error.team_role_unchanged")
+	}
+	// Понизить администратора команды может только администратор
+	if member.Role == domain.RoleAdmin && !isTeamAdmin(s.teamRepo, actor, team.ID) {
+		return nil, i18n.NewError("error.team_admin_required")
+	}
+
+	if err := s.teamRepo.UpdateMemberRole(team.ID, target.ID, role); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actorID,
+		TargetID: target.ID,
+		Action:   domain.AuditTeamRoleChange,
+		Details:  fmt.Sprintf("team=%d role=%s->%s", team.ID, member.Role, role),
+	})
+	target.TeamRole = role
+	return target, nil
+}
+
+// FindMembers возвращает страницу участников активной команды (требует права users.view)
+func (s *TeamService) FindMembers(actorID int64, query domain.UserQuery) (*domain.UserPage, error) {
+	_, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermViewUsers)
+	if err != nil {
+		return nil, err
+	}
+	query.WithSecrets = false
+	return s.teamRepo.FindMembers(team.ID, query)
+}
+
+// BindGroup привязывает группу Telegram к активной команде. Привязать группу может
+// только администратор команды; повторная привязка заменяет прежнюю группу
+func (s *TeamService) BindGroup(actorID int64, chatID int64) (*domain.Team, error) {
+	actor, team, err := teamActor(s.userRepo, s.teamRepo, s.roles, actorID, domain.PermManageUsers)
+	if err != nil {
+		return nil, err
+	}
+	if !isTeamAdmin(s.teamRepo, actor, team.ID) {
+		return nil, i18n.NewError("error.team_group_forbidden")
+	}
+	if err := s.teamRepo.SetGroupChat(team.ID, chatID); err != nil {
+		return nil, err
+	}
+	team.GroupChatID = chatID
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actorID,
+		Action:  domain.AuditTeamGroup,
+		Details: fmt.Sprintf("team=%d chat=%d", team.ID, chatID),
+	})
+	return team, nil
+}
+
+// checkRole проверяет, что роль существует и что пользователь может ее назначить
+func (s *TeamService) checkRole(actor *domain.User, team *domain.Team, role string) error {
+	existing, err := s.roles.GetRole(role)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return i18n.NewError("error.invalid_role")
+	}
+	if role == domain.RoleAdmin && !isTeamAdmin(s.teamRepo, actor, team.ID) {
+		return i18n.NewError("error.team_admin_required")
+	}
+	return nil
+}
+
+// target возвращает пользователя по имени
+func (s *TeamService) target(username string) (*domain.User, error) {
+	user, err := s.userRepo.GetByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.PurgedAt.IsZero() {
+		return nil, i18n.NewError("error.user_not_found")
+	}
+	return user, nil
+}
+
+// member возвращает пользователя по имени и его членство в команде
+func (s *TeamService) member(team *domain.Team, username string) (*domain.User, *domain.TeamMember, error) {
+	user, err := s.target(username)
+	if err != nil {
+		return nil, nil, err
+	}
+	member, err := s.teamRepo.GetMember(team.ID, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if member == nil {
+		return nil, nil, i18n.NewError("error.member_not_found", i18n.P{"username": user.Username, "team": team.Name})
+	}
+	user.TeamRole = member.Role
+	return user, member, nil
+}
+
+// teamActor возвращает пользователя и его активную команду, проверяя, что у него
+// есть право в этой команде
+func teamActor(userRepo domain.UserRepository, teamRepo domain.TeamRepository, roles domain.RoleService,
+	actorID int64, permission domain.Permission) (*domain.User, *domain.Team, error) {
+	actor, err := userRepo.GetByID(actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if actor == nil {
+		return nil, nil, i18n.NewError("error.forbidden")
+	}
+	team, err := teamRepo.GetByID(actor.ActiveTeamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if team == nil {
+		return nil, nil, i18n.NewError("error.team_required")
+	}
+	if !roles.Can(actor, permission) {
+		return nil, nil, i18n.NewError("error.forbidden")
+	}
+	return actor, team, nil
+}
+
+// teamMember возвращает пользователя и его активную команду, проверяя, что он в ней
+// состоит или может управлять всеми командами
+func teamMember(userRepo domain.UserRepository, teamRepo domain.TeamRepository, roles domain.RoleService,
+	actorID int64) (*domain.User, *domain.Team, error) {
+	actor, err := userRepo.GetByID(actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if actor == nil {
+		return nil, nil, i18n.NewError("error.forbidden")
+	}
+	team, err := teamRepo.GetByID(actor.ActiveTeamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if team == nil {
+		return nil, nil, i18n.NewError("error.team_required")
+	}
+	member, err := teamRepo.GetMember(team.ID, actor.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if member == nil && !roles.Can(actor, domain.PermManageTeams) {
+		return nil, nil, i18n.NewError("error.team_not_member", i18n.P{"team": team.Name})
+	}
+	return actor, team, nil
+}
+
+// isTeamAdmin проверяет, что пользователь - администратор бота или команды
+func isTeamAdmin(teamRepo domain.TeamRepository, user *domain.User, teamID int64) bool {
+	if user.Role == domain.RoleAdmin {
+		return true
+	}
+	member, err := teamRepo.GetMember(teamID, user.ID)
+	return err == nil && member != nil && member.Role == domain.RoleAdmin
+}
@@ -0,0 +1,286 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"HelpBot/internal/config"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/repository"
+	"HelpBot/internal/service"
+)
+
+// teamFixture содержит сервисы, участвующие в работе с командами
+type teamFixture struct {
+	repos *repository.Repositories
+	auth  *service.AuthService
+	audit *service.AuditService
+	roles *service.RoleService
+	teams *service.TeamService
+}
+
+// newTeamFixture создает сервисы поверх временной базы SQLite и регистрирует
+// администратора бота root
+func newTeamFixture(t *testing.T) (*teamFixture, *domain.User) {
+	t.Helper()
+	repos, db, err := repository.Open("sqlite:"+filepath.Join(t.TempDir(), "test.db"), logging.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cfg := &config.Config{JWTSecret: "0123456789abcdef", JWTExpiration: time.Hour}
+	audit := service.NewAuditService(repos.AuditRepository, repos.UserRepository, logging.Discard())
+	roles := service.NewRoleService(repos.RoleRepository, repos.UserRepository, repos.TeamRepository, audit)
+	f := &teamFixture{
+		repos: repos,
+		audit: audit,
+		auth:  service.NewAuthService(repos.UserRepository, repos.TransferRepository, repos.InviteRepository, repos.TeamRepository, roles, audit, cfg),
+		roles: roles,
+		teams: service.NewTeamService(repos.TeamRepository, repos.UserRepository, roles, audit),
+	}
+	return f, f.register(t, "root", domain.RoleAdmin)
+}
+
+// register регистрирует пользователя с указанной ролью учетной записи
+func (f *teamFixture) register(t *testing.T, username, role string) *domain.User {
+	t.Helper()
+	user := &domain.User{Username: username, Password: "secret", Role: role}
+	if err := f.auth.Register(user); err != nil {
+		t.Fatalf("Register %s: %v", username, err)
+	}
+	return user
+}
+
+// juniors создает команду Juniors. Пока команда одна, регистрируемые пользователи
+// сразу попадают в нее
+func (f *teamFixture) juniors(t *testing.T, root *domain.User) *domain.Team {
+	t.Helper()
+	team, err := f.teams.CreateTeam(root.ID, "Juniors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return team
+}
+
+// coordinator регистрирует пользователя и назначает его координатором активной команды root
+func (f *teamFixture) coordinator(t *testing.T, root *domain.User, username string) *domain.User {
+	t.Helper()
+	user := f.register(t, username, domain.RoleUser)
+	if _, err := f.teams.SetMemberRole(root.ID, username, domain.RoleCoordinator); err != nil {
+		t.Fatal(err)
+	}
+	return f.user(t, user.ID)
+}
+
+// user перечитывает пользователя из базы
+func (f *teamFixture) user(t *testing.T, id int64) *domain.User {
+	t.Helper()
+	user, err := f.repos.UserRepository.GetByID(id)
+	if err != nil || user == nil {
+		t.Fatalf("GetByID(%d) = %v, %v", id, user, err)
+	}
+	return user
+}
+
+func TestTeamCreateAndRegister(t *testing.T) {
+	f, root := newTeamFixture(t)
+	alice := f.register(t, "alice", domain.RoleUser)
+
+	if _, err := f.teams.CreateTeam(alice.ID, "Juniors"); errorKey(err) != "error.forbidden" {
+		t.Errorf("CreateTeam without teams.manage = %v, want error.forbidden", err)
+	}
+	if _, err := f.teams.CreateTeam(root.ID, "  "); errorKey(err) != "error.team_name_invalid" {
+		t.Errorf("CreateTeam with an empty name = %v, want error.team_name_invalid", err)
+	}
+
+	juniors, err := f.teams.CreateTeam(root.ID, "Juniors")
+	if err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	if _, err := f.teams.CreateTeam(root.ID, "Juniors"); errorKey(err) != "error.team_exists" {
+		t.Errorf("CreateTeam of a duplicate = %v, want error.team_exists", err)
+	}
+	if active, err := f.teams.ActiveTeam(f.user(t, root.ID)); err != nil || active == nil || active.ID != juniors.ID {
+		t.Errorf("creator's active team = %v, %v", active, err)
+	}
+
+	// Пока команда одна, новые пользователи попадают в нее сразу
+	bob := f.register(t, "bob", domain.RoleUser)
+	if bob.ActiveTeamID != juniors.ID {
+		t.Errorf("bob's active team = %d, want %d", bob.ActiveTeamID, juniors.ID)
+	}
+	if _, err := f.teams.CreateTeam(root.ID, "Adults"); err != nil {
+		t.Fatal(err)
+	}
+	if carol := f.register(t, "carol", domain.RoleUser); carol.ActiveTeamID != 0 {
+		t.Errorf("carol joined team %d with several teams", carol.ActiveTeamID)
+	}
+
+	// Администратор бота видит все команды, остальные - только свои
+	if all, err := f.teams.Teams(root.ID); err != nil || len(all) != 2 {
+		t.Errorf("Teams(root) = %v, %v", all, err)
+	}
+	if own, err := f.teams.Teams(bob.ID); err != nil || len(own) != 1 || own[0].TeamName != "Juniors" {
+		t.Errorf("Teams(bob) = %v, %v", own, err)
+	}
+	if own, err := f.teams.Teams(alice.ID); err != nil || len(own) != 0 {
+		t.Errorf("Teams(alice) = %v, %v", own, err)
+	}
+}
+
+func TestTeamScopedPermissions(t *testing.T) {
+	f, root := newTeamFixture(t)
+	juniors, err := f.teams.CreateTeam(root.ID, "Juniors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	adults, err := f.teams.CreateTeam(root.ID, "Adults")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice := f.register(t, "alice", domain.RoleUser)
+	bob := f.register(t, "bob", domain.RoleUser)
+
+	// root работает в Adults, поэтому добавляет alice туда администратором
+	if _, err := f.teams.AddMember(root.ID, "alice", domain.RoleAdmin); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	if _, err := f.teams.SwitchTeam(root.ID, juniors.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.teams.AddMember(root.ID, "alice", domain.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.teams.AddMember(root.ID, "bob", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.teams.AddMember(root.ID, "bob", ""); errorKey(err) != "error.member_exists" {
+		t.Errorf("repeated AddMember = %v, want error.member_exists", err)
+	}
+
+	// Права команды зависят от роли в активной команде
+	alice = f.user(t, alice.ID)
+	if alice.ActiveTeamID != adults.ID || !f.roles.Can(alice, domain.PermManageUsers) {
+		t.Errorf("alice in Adults: team %d, users.manage %v", alice.ActiveTeamID, f.roles.Can(alice, domain.PermManageUsers))
+	}
+	if f.roles.Can(alice, domain.PermManageRoles) {
+		t.Error("team administrator got the bot-wide roles.manage permission")
+	}
+	if _, err := f.teams.SwitchTeam(alice.ID, juniors.ID); err != nil {
+		t.Fatal(err)
+	}
+	if f.roles.Can(f.user(t, alice.ID), domain.PermManageUsers) {
+		t.Error("alice kept users.manage after switching to a team where she is a member")
+	}
+	if _, err := f.teams.SwitchTeam(bob.ID, adults.ID); errorKey(err) != "error.team_not_member" {
+		t.Errorf("SwitchTeam to a foreign team = %v, want error.team_not_member", err)
+	}
+
+	// Администратор команды управляет только ее участниками
+	if _, err := f.teams.SwitchTeam(alice.ID, adults.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.auth.ChangeStatus(alice.ID, "bob", domain.UserSuspended, ""); errorKey(err) != "error.user_not_found" {
+		t.Errorf("ChangeStatus of another team's member = %v, want error.user_not_found", err)
+	}
+	page, err := f.teams.FindMembers(alice.ID, domain.UserQuery{Sort: domain.UserSortUsername})
+	if err != nil {
+		t.Fatalf("FindMembers: %v", err)
+	}
+	if got := usernamesOf(page.Users); len(got) != 2 || got[0] != "alice" || got[1] != "root" {
+		t.Errorf("FindMembers(Adults) = %v, want alice, root", got)
+	}
+}
+
+func TestTeamMemberManagement(t *testing.T) {
+	f, root := newTeamFixture(t)
+	juniors, err := f.teams.CreateTeam(root.ID, "Juniors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Менеджер может управлять участниками, но не назначать администраторов
+	if _, err := f.roles.CreateRole(root.ID, "manager", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.roles.TogglePermission(root.ID, "manager", domain.PermManageUsers); err != nil {
+		t.Fatal(err)
+	}
+	manager := f.register(t, "manager", domain.RoleUser)
+	bob := f.register(t, "bob", domain.RoleUser)
+	if _, err := f.teams.SetMemberRole(root.ID, "manager", "manager"); err != nil {
+		t.Fatalf("SetMemberRole: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		call func() error
+		key  string
+	}{
+		{"promote to admin", func() error { _, err := f.teams.SetMemberRole(manager.ID, "bob", domain.RoleAdmin); return err }, "error.team_admin_required"},
+		{"demote admin", func() error { _, err := f.teams.SetMemberRole(manager.ID, "root", domain.RoleUser); return err }, "error.team_admin_required"},
+		{"remove admin", func() error { _, err := f.teams.RemoveMember(manager.ID, "root"); return err }, "error.team_admin_required"},
+		{"unknown role", func() error { _, err := f.teams.SetMemberRole(manager.ID, "bob", "nobody"); return err }, "error.invalid_role"},
+		{"same role", func() error { _, err := f.teams.SetMemberRole(manager.ID, "bob", domain.RoleUser); return err }, "error.team_role_unchanged"},
+		{"remove self", func() error { _, err := f.teams.RemoveMember(manager.ID, "manager"); return err }, "error.team_self"},
+		{"unknown user", func() error { _, err := f.teams.RemoveMember(manager.ID, "nobody"); return err }, "error.user_not_found"},
+		{"bind group", func() error { _, err := f.teams.BindGroup(manager.ID, -100); return err }, "error.team_group_forbidden"},
+	} {
+		if err := tc.call(); errorKey(err) != tc.key {
+			t.Errorf("%s = %v, want %s", tc.name, err, tc.key)
+		}
+	}
+
+	// Группу команды привязывает ее администратор
+	if team, err := f.teams.BindGroup(root.ID, -100); err != nil || team.GroupChatID != -100 {
+		t.Fatalf("BindGroup = %+v, %v", team, err)
+	}
+	if team, err := f.repos.TeamRepository.GetByID(juniors.ID); err != nil || team.GroupChatID != -100 {
+		t.Errorf("team after BindGroup = %+v, %v", team, err)
+	}
+
+	user, err := f.teams.SetMemberRole(manager.ID, "bob", domain.RoleCoordinator)
+	if err != nil || user.TeamRole != domain.RoleCoordinator {
+		t.Fatalf("SetMemberRole = %+v, %v", user, err)
+	}
+	if f.user(t, bob.ID).Role != domain.RoleUser {
+		t.Error("SetMemberRole changed the account role")
+	}
+
+	// После исключения активной становится другая команда пользователя
+	adults, err := f.teams.CreateTeam(root.ID, "Adults")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.teams.AddMember(root.ID, "bob", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.teams.SwitchTeam(root.ID, juniors.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.teams.RemoveMember(manager.ID, "bob"); err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+	if active := f.user(t, bob.ID).ActiveTeamID; active != adults.ID {
+		t.Errorf("bob's active team after removal = %d, want %d", active, adults.ID)
+	}
+	if _, err := f.teams.RemoveMember(manager.ID, "bob"); errorKey(err) != "error.member_not_found" {
+		t.Errorf("repeated RemoveMember = %v, want error.member_not_found", err)
+	}
+
+	entries, err := f.repos.AuditRepository.Find(domain.AuditFilter{UserID: bob.ID, Action: domain.AuditTeamLeave})
+	if err != nil || len(entries) != 1 || entries[0].ActorID != manager.ID {
+		t.Errorf("team leave audit entries = %v, %v", entries, err)
+	}
+}
+
+// usernamesOf возвращает имена пользователей
+func usernamesOf(users []*domain.User) []string {
+	names := make([]string, len(users))
+	for i, user := range users {
+		names[i] = user.Username
+	}
+	return names
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+)
+
+// Ограничения обращений в поддержку
+const (
+	maxTicketLength = 2000
+	maxOpenTickets  = 5 // Сколько незакрытых обращений может быть у одного пользователя
+	staffPageSize   = 100
+)
+
+// TicketService реализует интерфейс domain.TicketService
+type TicketService struct {
+	ticketRepo domain.TicketRepository
+	userRepo   domain.UserRepository
+	roles      domain.RoleService
+	audit      domain.AuditService
+}
+
+// NewTicketService создает новый экземпляр TicketService
+func NewTicketService(ticketRepo domain.TicketRepository, userRepo domain.UserRepository, roles domain.RoleService,
+	audit domain.AuditService) *TicketService {
+	return &TicketService{
+		ticketRepo: ticketRepo,
+		userRepo:   userRepo,
+		roles:      roles,
+		audit:      audit,
+	}
+}
+
+// Create создает обращение пользователя и возвращает администраторов, которым его нужно переслать
+func (s *TicketService) Create(actorID int64, text string) (*domain.Ticket, []*domain.User, error) {
+	actor, err := s.actor(actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	text, err = ticketText(text)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tickets, err := s.ticketRepo.GetByUser(actor.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	open := 0
+	for _, ticket := range tickets {
+		if !ticket.Closed() {
+			open++
+		}
+	}
+	if open >= maxOpenTickets {
+		return nil, nil, i18n.NewError("error.ticket_limit", i18n.P{"max": maxOpenTickets})
+	}
+
+	ticket := &domain.Ticket{UserID: actor.ID, Text: text, Status: domain.TicketOpen, Username: actor.Username}
+	if err := s.ticketRepo.Save(ticket); err != nil {
+		return nil, nil, err
+	}
+	staff, err := s.staff(actor.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID: actor.ID,
+		Action:  domain.AuditTicket,
+		Details: fmt.Sprintf("ticket=%d", ticket.ID),
+	})
+	return ticket, staff, nil
+}
+
+// Ticket возвращает обращение и переписку по нему автору обращения или администратору
+func (s *TicketService) Ticket(actorID int64, ticketID int64) (*domain.Ticket, []*domain.TicketMessage, error) {
+	_, ticket, _, err := s.ticket(actorID, ticketID)
+	if err != nil {
+		return nil, nil, err
+	}
+	messages, err := s.ticketRepo.GetMessages(ticket.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ticket, messages, nil
+}
+
+// Tickets возвращает обращения пользователя от новых к старым
+func (s *TicketService) Tickets(actorID int64) ([]*domain.Ticket, error) {
+	actor, err := s.actor(actorID)
+	if err != nil {
+		return nil, err
+	}
+	return s.ticketRepo.GetByUser(actor.ID)
+}
+
+// Queue возвращает обращения с указанными статусами, по умолчанию незакрытые
+func (s *TicketService) Queue(actorID int64, statuses []domain.TicketStatus) ([]*domain.Ticket, error) {
+	if err := s.roles.RequirePermission(actorID, domain.PermTickets); err != nil {
+		return nil, err
+	}
+	if len(statuses) == 0 {
+		statuses = []domain.TicketStatus{domain.TicketOpen, domain.TicketInProgress}
+	}
+	return s.ticketRepo.GetByStatus(statuses)
+}
+
+// Reply добавляет сообщение в переписку по незакрытому обращению. Первый ответ
+// администратора переводит обращение в работу и назначает его исполнителем,
+// если исполнитель еще не назначен
+func (s *TicketService) Reply(actorID int64, ticketID int64, text string) (*domain.Ticket, *domain.TicketMessage, []*domain.User, error) {
+	actor, ticket, staff, err := s.ticket(actorID, ticketID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if ticket.Closed() {
+		return nil, nil, nil, i18n.NewError("error.ticket_closed", i18n.P{"id": ticket.ID})
+	}
+	text, err = ticketText(text)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if staff && ticket.Status == domain.TicketOpen {
+		ticket.Status = domain.TicketInProgress
+		if ticket.AssigneeID == 0 {
+			ticket.AssigneeID = actor.ID
+			ticket.Assignee = actor.Username
+		}
+		if err := s.update(ticket); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	message := &domain.TicketMessage{TicketID: ticket.ID, AuthorID: actor.ID, Staff: staff, Text: text, Username: actor.Username}
+	if err := s.ticketRepo.AddMessage(message); err != nil {
+		return nil, nil, nil, err
+	}
+	recipients, err := s.recipients(ticket, actor.ID, staff)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return ticket, message, recipients, nil
+}
+
+// Assign назначает обращению исполнителя и переводит обращение в работу. Исполнителем
+// может быть только пользователь с правом tickets.manage. Возвращает тех, кого нужно
+// уведомить: исполнителя, если его назначил другой администратор, и автора обращения
+func (s *TicketService) Assign(actorID int64, ticketID int64, username string) (*domain.Ticket, []*domain.User, error) {
+	if err := s.roles.RequirePermission(actorID, domain.PermTickets); err != nil {
+		return nil, nil, err
+	}
+	_, ticket, _, err := s.ticket(actorID, ticketID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ticket.Closed() {
+		return nil, nil, i18n.NewError("error.ticket_closed", i18n.P{"id": ticket.ID})
+	}
+	assignee, err := s.userRepo.GetByUsername(strings.TrimPrefix(strings.TrimSpace(username), "@"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if assignee == nil || !assignee.Active() || !s.roles.Can(assignee, domain.PermTickets) {
+		return nil, nil, i18n.NewError("error.ticket_assignee_invalid", i18n.P{"username": username})
+	}
+
+	ticket.Status = domain.TicketInProgress
+	ticket.AssigneeID = assignee.ID
+	ticket.Assignee = assignee.Username
+	if err := s.update(ticket); err != nil {
+		return nil, nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actorID,
+		TargetID: assignee.ID,
+		Action:   domain.AuditTicketAssign,
+		Details:  fmt.Sprintf("ticket=%d", ticket.ID),
+	})
+
+	var recipients []*domain.User
+	if assignee.ID != actorID {
+		recipients = append(recipients, assignee)
+	}
+	author, err := s.userRepo.GetByID(ticket.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if author != nil && author.Active() && author.ID != actorID && author.ID != assignee.ID {
+		recipients = append(recipients, author)
+	}
+	return ticket, recipients, nil
+}
+
+// Close закрывает обращение и возвращает тех, кого нужно об этом уведомить
+func (s *TicketService) Close(actorID int64, ticketID int64) (*domain.Ticket, []*domain.User, error) {
+	actor, ticket, staff, err := s.ticket(actorID, ticketID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ticket.Closed() {
+		return nil, nil, i18n.NewError("error.ticket_closed", i18n.P{"id": ticket.ID})
+	}
+
+	ticket.Status = domain.TicketClosed
+	if err := s.update(ticket); err != nil {
+		return nil, nil, err
+	}
+	recipients, err := s.recipients(ticket, actor.ID, staff)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  actor.ID,
+		TargetID: ticket.UserID,
+		Action:   domain.AuditTicketClose,
+		Details:  fmt.Sprintf("ticket=%d", ticket.ID),
+	})
+	return ticket, recipients, nil
+}
+
+// actor возвращает активного пользователя по идентификатору
+func (s *TicketService) actor(actorID int64) (*domain.User, error) {
+	actor, err := s.userRepo.GetByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+	if actor == nil || !actor.Active() {
+		return nil, i18n.NewError("error.forbidden")
+	}
+	return actor, nil
+}
+
+// ticket возвращает пользователя и обращение, к которому у него есть доступ, и сообщает,
+// действует ли пользователь как администратор. Автор обращения всегда действует как автор
+func (s *TicketService) ticket(actorID int64, ticketID int64) (*domain.User, *domain.Ticket, bool, error) {
+	actor, err := s.actor(actorID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	ticket, err := s.ticketRepo.GetByID(ticketID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	staff := s.roles.Can(actor, domain.PermTickets)
+	// Чужие обращения для пользователя без права tickets.manage не существуют
+	if ticket == nil || (ticket.UserID != actor.ID && !staff) {
+		return nil, nil, false, i18n.NewError("error.ticket_not_found", i18n.P{"id": ticketID})
+	}
+	return actor, ticket, staff && ticket.UserID != actor.ID, nil
+}
+
+// update сохраняет статус и исполнителя обращения
+func (s *TicketService) update(ticket *domain.Ticket) error {
+	if err := s.ticketRepo.Update(ticket); err != nil {
+		if errors.Is(err, domain.ErrTicketClosed) {
+			return i18n.NewError("error.ticket_closed", i18n.P{"id": ticket.ID})
+		}
+		return err
+	}
+	return nil
+}
+
+// recipients возвращает тех, кому нужно переслать сообщение по обращению: автору, если
+// пишет администратор, иначе исполнителю, а без исполнителя - всем администраторам
+func (s *TicketService) recipients(ticket *domain.Ticket, actorID int64, staff bool) ([]*domain.User, error) {
+	userID := ticket.AssigneeID
+	if staff {
+		userID = ticket.UserID
+	}
+	if userID == 0 {
+		return s.staff(actorID)
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.Active() || user.ID == actorID {
+		if staff {
+			return nil, nil
+		}
+		// Исполнитель больше не может ответить, поэтому сообщение получают все администраторы
+		return s.staff(actorID)
+	}
+	return []*domain.User{user}, nil
+}
+
+// staff возвращает активных пользователей с правом tickets.manage, кроме указанного
+func (s *TicketService) staff(exceptID int64) ([]*domain.User, error) {
+	var staff []*domain.User
+	// Просматриваем пользователей постранично, не загружая весь список сразу
+	query := domain.UserQuery{Statuses: []domain.UserStatus{domain.UserActive}, Limit: staffPageSize}
+	for {
+		page, err := s.userRepo.Find(query)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range page.Users {
+			if user.ID != exceptID && s.roles.Can(user, domain.PermTickets) {
+				staff = append(staff, user)
+			}
+		}
+		if page.NextCursor == "" {
+			return staff, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+// ticketText проверяет и нормализует текст обращения или ответа
+func ticketText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > maxTicketLength {
+		return "", i18n.NewError("error.ticket_text_invalid", i18n.P{"max": maxTicketLength})
+	}
+	return text, nil
+}
@@ -0,0 +1,144 @@
+package service_test
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/service"
+)
+
+func TestTicketConversation(t *testing.T) {
+	f, root := newTeamFixture(t)
+	tickets := service.NewTicketService(f.repos.TicketRepository, f.repos.UserRepository, f.roles, f.audit)
+	support := f.register(t, "support", domain.RoleAdmin)
+	alice := f.register(t, "alice", domain.RoleUser)
+	bob := f.register(t, "bob", domain.RoleUser)
+
+	if _, _, err := tickets.Create(alice.ID, "  "); errorKey(err) != "error.ticket_text_invalid" {
+		t.Errorf("Create with an empty text = %v, want error.ticket_text_invalid", err)
+	}
+	ticket, staff, err := tickets.Create(alice.ID, " Cannot pay dues ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Обращение пересылается всем администраторам
+	names := usernamesOf(staff)
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "root" || names[1] != "support" {
+		t.Errorf("staff = %v, want root and support", names)
+	}
+	if ticket.Text != "Cannot pay dues" || ticket.Status != domain.TicketOpen || ticket.Username != "alice" {
+		t.Errorf("ticket = %+v", ticket)
+	}
+
+	if _, _, err := tickets.Ticket(bob.ID, ticket.ID); errorKey(err) != "error.ticket_not_found" {
+		t.Errorf("Ticket of another user = %v, want error.ticket_not_found", err)
+	}
+	if _, err := tickets.Queue(bob.ID, nil); errorKey(err) != "error.forbidden" {
+		t.Errorf("Queue without tickets.manage = %v, want error.forbidden", err)
+	}
+
+	// Пока исполнителя нет, сообщение автора получают все администраторы
+	if _, _, recipients, err := tickets.Reply(alice.ID, ticket.ID, "It says the card is declined"); err != nil || len(recipients) != 2 {
+		t.Errorf("Reply of the author = %v, %v, want both admins", recipients, err)
+	}
+	// Первый ответ администратора берет обращение в работу
+	replied, message, recipients, err := tickets.Reply(root.ID, ticket.ID, "Which month?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replied.Status != domain.TicketInProgress || replied.Assignee != "root" || !message.Staff {
+		t.Errorf("after the reply of root: %+v, staff message = %v", replied, message.Staff)
+	}
+	if names := usernamesOf(recipients); len(names) != 1 || names[0] != "alice" {
+		t.Errorf("Reply of an admin recipients = %v, want alice", names)
+	}
+	if _, _, recipients, err := tickets.Reply(alice.ID, ticket.ID, "March"); err != nil || len(recipients) != 1 || recipients[0].ID != root.ID {
+		t.Errorf("Reply to the assignee = %v, %v, want root", usernamesOf(recipients), err)
+	}
+
+	if _, _, err := tickets.Assign(alice.ID, ticket.ID, "support"); errorKey(err) != "error.forbidden" {
+		t.Errorf("Assign without tickets.manage = %v, want error.forbidden", err)
+	}
+	if _, _, err := tickets.Assign(root.ID, ticket.ID, "bob"); errorKey(err) != "error.ticket_assignee_invalid" {
+		t.Errorf("Assign to a user without tickets.manage = %v, want error.ticket_assignee_invalid", err)
+	}
+	assigned, notified, err := tickets.Assign(root.ID, ticket.ID, "@support")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assigned.Assignee != "support" || assigned.AssigneeID != support.ID {
+		t.Errorf("Assign = %+v, want support", assigned)
+	}
+	if names := usernamesOf(notified); len(names) != 2 || names[0] != "support" || names[1] != "alice" {
+		t.Errorf("Assign notified = %v, want support and alice", names)
+	}
+
+	queue, err := tickets.Queue(support.ID, nil)
+	if err != nil || len(queue) != 1 || queue[0].ID != ticket.ID || queue[0].Status != domain.TicketInProgress {
+		t.Errorf("Queue = %v, %v, want the ticket in progress", queue, err)
+	}
+	stored, messages, err := tickets.Ticket(alice.ID, ticket.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Assignee != "support" || len(messages) != 3 || messages[0].Staff || !messages[1].Staff || messages[2].Text != "March" {
+		t.Errorf("Ticket = %+v with %d messages", stored, len(messages))
+	}
+
+	closed, recipients, err := tickets.Close(alice.ID, ticket.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !closed.Closed() || closed.ClosedAt.IsZero() {
+		t.Errorf("Close = %+v", closed)
+	}
+	if names := usernamesOf(recipients); len(names) != 1 || names[0] != "support" {
+		t.Errorf("Close recipients = %v, want the assignee", names)
+	}
+	if _, _, err := tickets.Close(root.ID, ticket.ID); errorKey(err) != "error.ticket_closed" {
+		t.Errorf("repeated Close = %v, want error.ticket_closed", err)
+	}
+	if _, _, _, err := tickets.Reply(root.ID, ticket.ID, "Anything else?"); errorKey(err) != "error.ticket_closed" {
+		t.Errorf("Reply to a closed ticket = %v, want error.ticket_closed", err)
+	}
+	if queue, err := tickets.Queue(root.ID, nil); err != nil || len(queue) != 0 {
+		t.Errorf("Queue after Close = %v, %v, want none", queue, err)
+	}
+	if queue, err := tickets.Queue(root.ID, []domain.TicketStatus{domain.TicketClosed}); err != nil || len(queue) != 1 {
+		t.Errorf("Queue(closed) = %v, %v, want the ticket", queue, err)
+	}
+}
+
+func TestTicketLimit(t *testing.T) {
+	f, root := newTeamFixture(t)
+	tickets := service.NewTicketService(f.repos.TicketRepository, f.repos.UserRepository, f.roles, f.audit)
+	alice := f.register(t, "alice", domain.RoleUser)
+
+	var first *domain.Ticket
+	for i := 1; i <= 5; i++ {
+		ticket, _, err := tickets.Create(alice.ID, fmt.Sprintf("Question %d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == nil {
+			first = ticket
+		}
+	}
+	if _, _, err := tickets.Create(alice.ID, "Question 6"); errorKey(err) != "error.ticket_limit" {
+		t.Errorf("Create over the limit = %v, want error.ticket_limit", err)
+	}
+	if _, _, err := tickets.Close(root.ID, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := tickets.Create(alice.ID, "Question 6"); err != nil {
+		t.Errorf("Create after closing a ticket = %v", err)
+	}
+
+	own, err := tickets.Tickets(alice.ID)
+	if err != nil || len(own) != 6 || own[0].Text != "Question 6" {
+		t.Errorf("Tickets = %v, %v, want six tickets from new to old", own, err)
+	}
+}
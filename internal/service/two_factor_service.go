@@ -0,0 +1,240 @@
+package service
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"HelpBot/internal/config"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/i18n"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Параметры кодов восстановления
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789" // Без похожих символов: i, l, o, 0, 1
+)
+
+// TwoFactorService реализует интерфейс domain.TwoFactorService
+type TwoFactorService struct {
+	twoFactorRepo domain.TwoFactorRepository
+	audit         domain.AuditService
+	config        *config.Config
+}
+
+// NewTwoFactorService создает новый экземпляр TwoFactorService
+func NewTwoFactorService(twoFactorRepo domain.TwoFactorRepository, audit domain.AuditService, cfg *config.Config) *TwoFactorService {
+	return &TwoFactorService{
+		twoFactorRepo: twoFactorRepo,
+		audit:         audit,
+		config:        cfg,
+	}
+}
+
+// IsEnabled проверяет, подключена ли у пользователя 2FA
+func (s *TwoFactorService) IsEnabled(userID int64) (bool, error) {
+	tf, err := s.twoFactorRepo.Get(userID)
+	if err != nil {
+		return false, err
+	}
+	return tf != nil && tf.Enabled, nil
+}
+
+// IsRequired проверяет, обязана ли у пользователя быть подключена 2FA
+func (s *TwoFactorService) IsRequired(user *domain.User) bool {
+	return user != nil && s.config.RequireAdmin2FA && user.Role == domain.RoleAdmin
+}
+
+// BeginEnrollment создает новый секрет и возвращает данные для приложения-аутентификатора
+func (s *TwoFactorService) BeginEnrollment(user *domain.User) (*domain.TwoFactorEnrollment, error) {
+	enabled, err := s.IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, i18n.NewError("error.2fa_already_enabled")
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.SaveSecret(user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	uri := totpURI(secret, user.Username)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render qr code: %w", err)
+	}
+
+	return &domain.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    uri,
+		QRCode: png,
+	}, nil
+}
+
+// ConfirmEnrollment включает 2FA после проверки кода и возвращает коды восстановления
+func (s *TwoFactorService) ConfirmEnrollment(userID int64, code string) ([]string, error) {
+	tf, err := s.twoFactorRepo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		return nil, i18n.NewError("error.2fa_not_started")
+	}
+	if tf.Enabled {
+		return nil, i18n.NewError("error.2fa_already_enabled")
+	}
+
+	counter, ok := matchTOTP(tf.Secret, code, time.Now())
+	if !ok {
+		s.recordFailure(userID, "enrollment")
+		return nil, domain.ErrTwoFactorWrongCode
+	}
+	// Код подтверждения тоже запоминается, чтобы его нельзя было сразу использовать для входа
+	if _, err := s.twoFactorRepo.UseCounter(userID, counter); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.generateRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.Enable(userID); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  userID,
+		TargetID: userID,
+		Action:   domain.AuditTwoFactorEnable,
+	})
+	return codes, nil
+}
+
+// Verify проверяет код из приложения или одноразовый код восстановления. Код из приложения
+// принимается один раз: повторно не принимаются ни он, ни коды предыдущих периодов
+func (s *TwoFactorService) Verify(userID int64, code string) error {
+	tf, err := s.twoFactorRepo.Get(userID)
+	if err != nil {
+		return err
+	}
+	if tf == nil || !tf.Enabled {
+		return i18n.NewError("error.2fa_not_enabled")
+	}
+
+	code = strings.ToLower(strings.TrimSpace(code))
+	if counter, ok := matchTOTP(tf.Secret, code, time.Now()); ok {
+		used, err := s.twoFactorRepo.UseCounter(userID, counter)
+		if err != nil {
+			return err
+		}
+		if !used {
+			s.recordFailure(userID, "replay")
+			return domain.ErrTwoFactorCodeUsed
+		}
+		return nil
+	}
+
+	// Код не подошел как TOTP: проверяем коды восстановления
+	recoveryCodes, err := s.twoFactorRepo.GetUnusedRecoveryCodes(userID)
+	if err != nil {
+		return err
+	}
+	for _, recoveryCode := range recoveryCodes {
+		if !checkPasswordHash(code, recoveryCode.CodeHash) {
+			continue
+		}
+		if err := s.twoFactorRepo.UseRecoveryCode(recoveryCode.ID); err != nil {
+			return err
+		}
+		s.audit.Record(&domain.AuditEntry{
+			ActorID:  userID,
+			TargetID: userID,
+			Action:   domain.AuditRecoveryCode,
+			Details:  fmt.Sprintf("remaining=%d", len(recoveryCodes)-1),
+		})
+		return nil
+	}
+
+	s.recordFailure(userID, "login")
+	return domain.ErrTwoFactorWrongCode
+}
+
+// Disable отключает 2FA после проверки кода
+func (s *TwoFactorService) Disable(user *domain.User, code string) error {
+	if s.IsRequired(user) {
+		return i18n.NewError("error.2fa_required_admin")
+	}
+	if err := s.Verify(user.ID, code); err != nil {
+		return err
+	}
+	if err := s.twoFactorRepo.Delete(user.ID); err != nil {
+		return err
+	}
+
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  user.ID,
+		TargetID: user.ID,
+		Action:   domain.AuditTwoFactorOff,
+	})
+	return nil
+}
+
+// generateRecoveryCodes создает новые коды восстановления и сохраняет их хеши
+func (s *TwoFactorService) generateRecoveryCodes(userID int64) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := randomRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := hashPassword(code)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка хеширования кода восстановления: %w", err)
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hash)
+	}
+
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// randomRecoveryCode генерирует код восстановления вида xxxx-xxxx
+func randomRecoveryCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < 8; i++ {
+		if i == 4 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// recordFailure записывает в журнал аудита неудачную проверку кода
+func (s *TwoFactorService) recordFailure(userID int64, stage string) {
+	s.audit.Record(&domain.AuditEntry{
+		ActorID:  userID,
+		TargetID: userID,
+		Action:   domain.AuditTwoFactorFail,
+		Details:  "stage=" + stage,
+	})
+}
@@ -0,0 +1,165 @@
+package service_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"HelpBot/internal/config"
+	"HelpBot/internal/domain"
+	"HelpBot/internal/logging"
+	"HelpBot/internal/service"
+)
+
+// totpAt вычисляет код из приложения для секрета и момента времени (RFC 6238)
+func totpAt(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(at.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff%1000000)
+}
+
+// newTwoFactorUser регистрирует пользователя alice, входит от его имени без 2FA
+// и подключает 2FA кодом периода now. Возвращает секрет приложения
+func newTwoFactorUser(t *testing.T, now time.Time) (*service.TwoFactorService, *service.SessionService, *domain.User, string) {
+	t.Helper()
+	repos, auth := newAuthService(t)
+	audit := service.NewAuditService(repos.AuditRepository, repos.UserRepository, logging.Discard())
+	twoFactor := service.NewTwoFactorService(repos.TwoFactorRepository, audit, &config.Config{})
+	sessions := service.NewSessionService(service.NewUserService(repos.UserRepository), auth, twoFactor)
+
+	user := &domain.User{Username: "alice", Password: "secret"}
+	if err := auth.Register(user); err != nil {
+		t.Fatal(err)
+	}
+	if err := sessions.Login(10, 20, "alice", "secret"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	enrollment, err := twoFactor.BeginEnrollment(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := twoFactor.ConfirmEnrollment(user.ID, totpAt(t, enrollment.Secret, now)); err != nil {
+		t.Fatalf("ConfirmEnrollment: %v", err)
+	}
+	return twoFactor, sessions, user, enrollment.Secret
+}
+
+func TestTwoFactorRejectsReplayedCode(t *testing.T) {
+	now := time.Now()
+	twoFactor, _, user, secret := newTwoFactorUser(t, now)
+
+	// Код подтверждения подключения уже использован
+	if err := twoFactor.Verify(user.ID, totpAt(t, secret, now)); errorKey(err) != "error.2fa_code_used" {
+		t.Errorf("Verify with the enrollment code = %v, want error.2fa_code_used", err)
+	}
+	next := totpAt(t, secret, now.Add(30*time.Second))
+	if err := twoFactor.Verify(user.ID, next); err != nil {
+		t.Fatalf("Verify with the next code: %v", err)
+	}
+	if err := twoFactor.Verify(user.ID, next); errorKey(err) != "error.2fa_code_used" {
+		t.Errorf("Verify with a replayed code = %v, want error.2fa_code_used", err)
+	}
+	// Код более раннего периода тоже не принимается
+	if err := twoFactor.Verify(user.ID, totpAt(t, secret, now.Add(-30*time.Second))); err == nil {
+		t.Error("Verify accepted a code older than the last used one")
+	}
+}
+
+func TestTwoFactorDisableLockout(t *testing.T) {
+	twoFactor, sessions, user, _ := newTwoFactorUser(t, time.Now())
+
+	for i := 1; i < 5; i++ {
+		if err := sessions.DisableTwoFactor(10, "wrong!"); errorKey(err) != "error.2fa_wrong_code" {
+			t.Fatalf("DisableTwoFactor #%d = %v, want error.2fa_wrong_code", i, err)
+		}
+	}
+	if err := sessions.DisableTwoFactor(10, "wrong!"); errorKey(err) != "error.2fa_too_many_attempts" {
+		t.Fatalf("fifth DisableTwoFactor = %v, want error.2fa_too_many_attempts", err)
+	}
+	// Сессия завершена, а 2FA осталась включенной
+	if session, err := sessions.GetSession(10); err != nil || (session != nil && session.IsAuthorized) {
+		t.Errorf("session after the lockout = %+v, %v, want logged out", session, err)
+	}
+	if enabled, err := twoFactor.IsEnabled(user.ID); err != nil || !enabled {
+		t.Errorf("IsEnabled after the lockout = %v, %v, want enabled", enabled, err)
+	}
+	if err := sessions.DisableTwoFactor(10, "wrong!"); errorKey(err) != "error.forbidden" {
+		t.Errorf("DisableTwoFactor after the lockout = %v, want error.forbidden", err)
+	}
+}
+
+func TestTwoFactorLoginCompletesAfterCode(t *testing.T) {
+	repos, auth := newAuthService(t)
+	audit := service.NewAuditService(repos.AuditRepository, repos.UserRepository, logging.Discard())
+	twoFactor := service.NewTwoFactorService(repos.TwoFactorRepository, audit, &config.Config{})
+	sessions := service.NewSessionService(service.NewUserService(repos.UserRepository), auth, twoFactor)
+
+	// 2FA подключена у пользователя, который еще ни разу не входил и не привязан к Telegram
+	user := &domain.User{Username: "alice", Password: "secret"}
+	if err := auth.Register(user); err != nil {
+		t.Fatal(err)
+	}
+	enrollment, err := twoFactor.BeginEnrollment(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if _, err := twoFactor.ConfirmEnrollment(user.ID, totpAt(t, enrollment.Secret, now)); err != nil {
+		t.Fatal(err)
+	}
+	stored := func() *domain.User {
+		t.Helper()
+		stored, err := repos.UserRepository.GetByID(user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stored
+	}
+	actions := func() map[string]int {
+		t.Helper()
+		entries, err := repos.AuditRepository.Find(domain.AuditFilter{UserID: user.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts := make(map[string]int)
+		for _, entry := range entries {
+			counts[entry.Action]++
+		}
+		return counts
+	}
+
+	// Верный пароль без кода не завершает вход: аккаунт не привязан, успешного входа в журнале нет
+	if err := sessions.Login(10, 20, "alice", "secret"); !errors.Is(err, domain.ErrTwoFactorRequired) {
+		t.Fatalf("Login = %v, want ErrTwoFactorRequired", err)
+	}
+	if bound := stored(); bound.TelegramID != 0 {
+		t.Errorf("TelegramID after the password step = %d, want unbound", bound.TelegramID)
+	}
+	if counts := actions(); counts[domain.AuditLoginSuccess] != 0 || counts[domain.AuditLoginPending] != 1 {
+		t.Errorf("audit after the password step = %v, want one pending login and no success", counts)
+	}
+
+	if _, err := sessions.CompleteTwoFactor(10, totpAt(t, enrollment.Secret, now.Add(30*time.Second))); err != nil {
+		t.Fatalf("CompleteTwoFactor: %v", err)
+	}
+	if bound := stored(); bound.TelegramID != 10 || bound.ChatID != 20 {
+		t.Errorf("user after the code = %d/%d, want bound to 10/20", bound.TelegramID, bound.ChatID)
+	}
+	if counts := actions(); counts[domain.AuditLoginSuccess] != 1 {
+		t.Errorf("audit after the code = %v, want one successful login", counts)
+	}
+}
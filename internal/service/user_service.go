@@ -2,7 +2,6 @@ package service
 
 import (
 	"fmt"
-	"time"
 
 	"HelpBot/internal/domain"
 )
@@ -19,15 +18,20 @@ func NewUserService(userRepo domain.UserRepository) *UserService {
 	}
 }
 
-// GetUser возвращает пользователя по его ChatID
-func (s *UserService) GetUser(chatID int64) (*domain.User, error) {
-	return s.userRepo.GetByID(chatID)
+// GetUser возвращает пользователя по его идентификатору
+func (s *UserService) GetUser(id int64) (*domain.User, error) {
+	return s.userRepo.GetByID(id)
+}
+
+// GetUserByTelegramID возвращает пользователя, привязанного к Telegram-аккаунту
+func (s *UserService) GetUserByTelegramID(telegramID int64) (*domain.User, error) {
+	return s.userRepo.GetByTelegramID(telegramID)
 }
 
 // SaveUser сохраняет или обновляет пользователя
 func (s *UserService) SaveUser(user *domain.User) error {
 	// Проверяем, существует ли пользователь
-	existingUser, err := s.userRepo.GetByID(user.ChatID)
+	existingUser, err := s.userRepo.GetByID(user.ID)
 	if err != nil {
 		return err
 	}
@@ -41,47 +45,46 @@ func (s *UserService) SaveUser(user *domain.User) error {
 	}
 }
 
-// DeleteUser удаляет пользователя
-func (s *UserService) DeleteUser(chatID int64) error {
-	return s.userRepo.Delete(chatID)
-}
-
-// GetAllUsers возвращает всех пользователей
-func (s *UserService) GetAllUsers() ([]*domain.User, error) {
-	return s.userRepo.GetAll()
+// FindUsers возвращает страницу пользователей, удовлетворяющих запросу.
+// Хеши паролей за пределы сервиса не передаются
+func (s *UserService) FindUsers(query domain.UserQuery) (*domain.UserPage, error) {
+	query.WithSecrets = false
+	return s.userRepo.Find(query)
 }
 
 // UpdateUserProfile обновляет профиль пользователя
-func (s *UserService) UpdateUserProfile(chatID int64, position, birthday, number string) error {
-	user, err := s.userRepo.GetByID(chatID)
+func (s *UserService) UpdateUserProfile(telegramID int64, position, birthday, number string) error {
+	user, err := s.userRepo.GetByTelegramID(telegramID)
 	if err != nil {
 		return err
 	}
 
 	if user == nil {
 		// Создаем нового пользователя, если он не существует
-		username := fmt.Sprintf("user_%d", chatID)
+		username := fmt.Sprintf("user_%d", telegramID)
 		user = &domain.User{
-			ChatID:    chatID,
-			Username:  username,
-			Position:  position,
-			Birthday:  birthday,
-			Number:    number,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			TelegramID: telegramID,
+			Username:   username,
+			Position:   position,
+			Birthday:   birthday,
+			Number:     number,
 		}
-	} else {
-		// Обновляем существующего пользователя
-		user.Position = position
-		user.Birthday = birthday
-		user.Number = number
-		user.UpdatedAt = time.Now()
+		return s.userRepo.Save(user)
 	}
 
-	return s.userRepo.Save(user)
+	// Обновляем существующего пользователя
+	user.Position = position
+	user.Birthday = birthday
+	user.Number = number
+	return s.userRepo.Update(user)
 }
 
 // GetUserByUsername возвращает пользователя по его имени пользователя
 func (s *UserService) GetUserByUsername(username string) (*domain.User, error) {
 	return s.userRepo.GetByUsername(username)
 }
+
+// SetLanguage сохраняет язык интерфейса пользователя
+func (s *UserService) SetLanguage(id int64, language string) error {
+	return s.userRepo.UpdateLanguage(id, language)
+}
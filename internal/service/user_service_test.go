@@ -0,0 +1,33 @@
+package service_test
+
+import (
+	"testing"
+
+	"HelpBot/internal/domain"
+	"HelpBot/internal/repository/memory"
+	"HelpBot/internal/service"
+)
+
+func TestUpdateUserProfile(t *testing.T) {
+	repo := memory.NewUserRepository()
+	users := service.NewUserService(repo)
+
+	// Первый вызов создает пользователя, повторный - изменяет его, а не падает на Save
+	if err := users.UpdateUserProfile(100, "defender", "2001-02-03", "4"); err != nil {
+		t.Fatalf("create profile: %v", err)
+	}
+	if err := users.UpdateUserProfile(100, "forward", "2001-02-03", "9"); err != nil {
+		t.Fatalf("update profile: %v", err)
+	}
+
+	page, err := repo.Find(domain.UserQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Users) != 1 {
+		t.Fatalf("got %d users, want 1", len(page.Users))
+	}
+	if user := page.Users[0]; user.TelegramID != 100 || user.Position != "forward" || user.Number != "9" {
+		t.Errorf("profile = %+v", user)
+	}
+}